@@ -55,6 +55,7 @@ func main() {
 			op.Config,
 			op.Clock,
 			op.GetClient(),
+			op.KubernetesInterface,
 			op.EventRecorder,
 			op.UnavailableOfferingsCache,
 			op.SSMCache,
@@ -64,10 +65,18 @@ func main() {
 			op.InstanceProfileProvider,
 			op.InstanceProvider,
 			op.PricingProvider,
+			op.CommitmentProvider,
 			op.AMIProvider,
 			op.LaunchTemplateProvider,
 			op.VersionProvider,
 			op.InstanceTypesProvider,
+			op.VPCEndpointProvider,
+			op.DHCPOptionsProvider,
+			op.CapacityBlockProvider,
+			op.PlacementGroupProvider,
+			op.PolicyProvider,
+			op.SpotPlacementScoreProvider,
+			op.QuotaProvider,
 		)...).
 		Start(ctx)
 }
@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// iam-policy writes the IAM policy that the Karpenter controller needs for its settings to stdout. It accepts the same
+// flags and environment variables as the controller, so it can be run with the settings of an installation.
+//
+//	iam-policy --interruption-queue karpenter-interruption --aws-feature-gates ValidateQuotas=true > policy.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/iampolicy"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+func main() {
+	opts := &options.Options{}
+	fs := &coreoptions.FlagSet{FlagSet: flag.NewFlagSet("iam-policy", flag.ExitOnError)}
+	opts.AddFlags(fs)
+	// The options aren't validated, since settings like cluster-name are required by the controller but don't change
+	// the policy
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fail(err)
+	}
+	gates, err := options.ParseFeatureGates(fs.Lookup("aws-feature-gates").Value.String())
+	if err != nil {
+		fail(fmt.Errorf("parsing aws feature gates, %w", err))
+	}
+	opts.FeatureGates = gates
+	policy, err := json.MarshalIndent(iampolicy.Policy(opts), "", "  ")
+	if err != nil {
+		fail(fmt.Errorf("encoding policy, %w", err))
+	}
+	fmt.Println(string(policy))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
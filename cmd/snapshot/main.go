@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// snapshot exports the instance types, offerings and prices of a region to a file, and imports the file into a
+// ConfigMap which Karpenter loads in air-gapped environments that can't reach the EC2 and pricing APIs.
+//
+//	snapshot export -o snapshot.json.gz
+//	snapshot import -f snapshot.json.gz -namespace kube-system -name karpenter-offering-snapshot | kubectl apply -f -
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
+)
+
+const usage = `usage:
+  snapshot export [-region <region>] -o <file>
+  snapshot import -f <file> [-namespace <namespace>] [-name <name>]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fail(errors.New(usage))
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(context.Background(), os.Args[2:])
+	case "import":
+		err = importSnapshot(os.Args[2:])
+	default:
+		err = errors.New(usage)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// export snapshots the instance types, offerings and prices of the region from the EC2 and pricing APIs
+func export(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	region := fs.String("region", "", "The region to export, defaults to the region of the AWS configuration")
+	output := fs.String("o", "", "The file to write the snapshot to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return errors.New(usage)
+	}
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("loading aws config, %w", err)
+	}
+	if *region != "" {
+		cfg.Region = *region
+	}
+	ctx = options.ToContext(ctx, &options.Options{})
+	ec2api := ec2.NewFromConfig(cfg)
+	pricingProvider := pricing.NewDefaultProvider(ctx, pricing.NewAPI(cfg), ec2api, cfg.Region)
+	if err := pricingProvider.UpdateOnDemandPricing(ctx); err != nil {
+		return fmt.Errorf("updating on-demand pricing, %w", err)
+	}
+	if err := pricingProvider.UpdateSpotPricing(ctx); err != nil {
+		return fmt.Errorf("updating spot pricing, %w", err)
+	}
+	s, err := snapshot.Export(ctx, ec2api, pricingProvider, cfg.Region, time.Now())
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("creating %s, %w", *output, err)
+	}
+	defer f.Close()
+	if err := s.Write(f); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d instance types from %s to %s\n", len(s.InstanceTypes), s.Region, *output)
+	return nil
+}
+
+// importSnapshot validates a snapshot and writes a ConfigMap containing it to stdout, so that it can be applied to an
+// air-gapped cluster
+func importSnapshot(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("f", "", "The snapshot file to import")
+	namespace := fs.String("namespace", "kube-system", "The namespace that Karpenter is installed in")
+	name := fs.String("name", "karpenter-offering-snapshot", "The name of the ConfigMap, which the offering-snapshot-configmap setting must be set to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New(usage)
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("opening %s, %w", *file, err)
+	}
+	defer f.Close()
+	s, err := snapshot.Read(f)
+	if err != nil {
+		return err
+	}
+	if s.Stale(time.Now()) {
+		fmt.Fprintf(os.Stderr, "warning: the snapshot was exported %s ago, it may be missing instance types and have outdated prices\n", s.Age(time.Now()).Round(time.Hour))
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("reading %s, %w", *file, err)
+	}
+	manifest, err := yaml.Marshal(&corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: *namespace, Name: *name},
+		BinaryData: map[string][]byte{snapshot.ConfigMapKey: data},
+	})
+	if err != nil {
+		return fmt.Errorf("encoding configmap, %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d instance types from %s, exported at %s\n", len(s.InstanceTypes), s.Region, s.CreatedAt.Format(time.RFC3339))
+	_, err = os.Stdout.Write(manifest)
+	return err
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -125,6 +125,7 @@ func getInstanceTypeInfo(info ec2types.InstanceTypeInfo) string {
 	fmt.Fprintf(src, "BurstablePerformanceSupported: aws.Bool(%t),\n", lo.FromPtr(info.BurstablePerformanceSupported))
 	fmt.Fprintf(src, "BareMetal: aws.Bool(%t),\n", lo.FromPtr(info.BareMetal))
 	fmt.Fprintf(src, "Hypervisor: \"%s\",\n", info.Hypervisor)
+	fmt.Fprintf(src, "NitroEnclavesSupport: \"%s\",\n", info.NitroEnclavesSupport)
 
 	fmt.Fprintf(src, "ProcessorInfo: &ec2types.ProcessorInfo{\n")
 	fmt.Fprintf(src, "Manufacturer: aws.String(\"%s\"),\n", lo.FromPtr(info.ProcessorInfo.Manufacturer))
@@ -176,6 +177,7 @@ func getInstanceTypeInfo(info ec2types.InstanceTypeInfo) string {
 	if info.InstanceStorageInfo != nil {
 		fmt.Fprintf(src, "InstanceStorageInfo: &ec2types.InstanceStorageInfo{")
 		fmt.Fprintf(src, "NvmeSupport: \"%s\",\n", string(info.InstanceStorageInfo.NvmeSupport))
+		fmt.Fprintf(src, "EncryptionSupport: \"%s\",\n", string(info.InstanceStorageInfo.EncryptionSupport))
 		fmt.Fprintf(src, "TotalSizeInGB: aws.Int64(%d),\n", lo.FromPtr(info.InstanceStorageInfo.TotalSizeInGB))
 		fmt.Fprintf(src, "},\n")
 	}
@@ -189,6 +191,9 @@ func getInstanceTypeInfo(info ec2types.InstanceTypeInfo) string {
 	fmt.Fprintf(src, "Ipv4AddressesPerInterface: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface))
 	fmt.Fprintf(src, "EncryptionInTransitSupported: aws.Bool(%t),\n", lo.FromPtr(info.NetworkInfo.EncryptionInTransitSupported))
 	fmt.Fprintf(src, "DefaultNetworkCardIndex: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex))
+	fmt.Fprintf(src, "EnaSupport: \"%s\",\n", string(info.NetworkInfo.EnaSupport))
+	fmt.Fprintf(src, "EnaSrdSupported: aws.Bool(%t),\n", lo.FromPtr(info.NetworkInfo.EnaSrdSupported))
+	fmt.Fprintf(src, "EfaSupported: aws.Bool(%t),\n", lo.FromPtr(info.NetworkInfo.EfaSupported))
 	fmt.Fprintf(src, "NetworkCards: []ec2types.NetworkCardInfo{\n")
 	for _, networkCard := range info.NetworkInfo.NetworkCards {
 		fmt.Fprintf(src, getNetworkCardInfo(networkCard))
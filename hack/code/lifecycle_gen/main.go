@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// announcedDeprecations are the instance families whose retirement has been announced, but which EC2 still reports as
+// current generation. Add a family with the announcement when it's published.
+var announcedDeprecations = map[string]string{}
+
+const fileFormat = `
+%s
+package instancetype
+
+// GENERATED FILE. DO NOT EDIT DIRECTLY.
+// Update hack/code/lifecycle_gen/main.go and re-generate to edit
+
+var (
+	// PreviousGenerationFamilies are the instance families which EC2 reports as previous generation, or whose
+	// retirement has been announced, mapped to the reason that they're aging
+	PreviousGenerationFamilies = map[string]string{
+		%s
+	}
+)
+`
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatalf("Usage: `lifecycle_gen/main.go pkg/providers/instancetype/zz_generated.lifecycle.go`")
+	}
+
+	families := map[string]string{}
+	for _, family := range getPreviousGenerationFamilies() {
+		families[family] = "previous generation"
+	}
+	for family, announcement := range announcedDeprecations {
+		families[family] = announcement
+	}
+	names := lo.Keys(families)
+	sort.Strings(names)
+
+	var body string
+	for _, family := range names {
+		body += fmt.Sprintf("\t%q: %q,\n", family, families[family])
+	}
+
+	license := lo.Must(os.ReadFile("hack/boilerplate.go.txt"))
+
+	// Format and print to the file
+	formatted := lo.Must(format.Source([]byte(fmt.Sprintf(fileFormat, license, body))))
+	file := lo.Must(os.Create(flag.Args()[0]))
+	lo.Must(file.Write(formatted))
+	file.Close()
+}
+
+func getPreviousGenerationFamilies() []string {
+	if err := os.Setenv("AWS_SDK_LOAD_CONFIG", "true"); err != nil {
+		log.Fatalf("setting AWS_SDK_LOAD_CONFIG, %s", err)
+	}
+	if err := os.Setenv("AWS_REGION", "us-east-1"); err != nil {
+		log.Fatalf("setting AWS_REGION, %s", err)
+	}
+	ctx := context.Background()
+	cfg := lo.Must(config.LoadDefaultConfig(ctx))
+	ec2api := ec2.NewFromConfig(cfg)
+	families := sets.New[string]()
+
+	params := &ec2.DescribeInstanceTypesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("current-generation"), Values: []string{"false"}}},
+	}
+	// Retrieve the instance types in a loop using NextToken
+	for {
+		result := lo.Must(ec2api.DescribeInstanceTypes(ctx, params))
+		for _, info := range result.InstanceTypes {
+			families.Insert(strings.Split(string(info.InstanceType), ".")[0])
+		}
+		// Check if they are any instances left
+		if result.NextToken != nil {
+			params.NextToken = result.NextToken
+		} else {
+			break
+		}
+	}
+	return sets.List(families)
+}
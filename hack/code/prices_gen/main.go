@@ -34,7 +34,6 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 
-	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -110,9 +109,8 @@ func main() {
 	for _, region := range getAWSRegions(opts.partition) {
 		log.Println("fetching for", region)
 		pricingProvider := pricing.NewDefaultProvider(ctx, pricing.NewAPI(cfg), ec2api, region)
-		controller := controllerspricing.NewController(pricingProvider)
-		_, err := controller.Reconcile(ctx)
-		if err != nil {
+		// Only on-demand prices are generated, so they're updated without the pricing controller, which needs a kube client
+		if err := pricingProvider.UpdateOnDemandPricing(ctx); err != nil {
 			log.Fatalf("failed to initialize pricing provider %s", err)
 		}
 		instanceTypes := pricingProvider.InstanceTypes()
@@ -42,6 +42,14 @@ vpcLimits() {
   checkForUpdates "${GENERATED_FILE}"
 }
 
+lifecycle() {
+  GENERATED_FILE="pkg/providers/instancetype/zz_generated.lifecycle.go"
+
+  go run hack/code/lifecycle_gen/main.go -- "${GENERATED_FILE}"
+
+  checkForUpdates "${GENERATED_FILE}"
+}
+
 instanceTypeTestData() {
   GENERATED_FILE="pkg/fake/zz_generated.describe_instance_types.go"
 
@@ -107,6 +115,8 @@ echo "Updating pricing..."
 pricing
 echo "Updating VPC limits..."
 vpcLimits
+echo "Updating instance family lifecycles..."
+lifecycle
 echo "Updating instance type data..."
 instanceTypeTestData
 echo "Finished codegen"
@@ -19,10 +19,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/samber/lo"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
@@ -66,10 +70,60 @@ func main() {
 		}
 	})
 
+	nodeClassDefaultsBlock := "\n### EC2NodeClass API Server Defaults\n\n"
+	nodeClassDefaultsBlock += "The following EC2NodeClass fields are defaulted by the API server when they are not specified. "
+	nodeClassDefaultsBlock += "Defaults which Karpenter resolves when launching instances, like the block device mappings of each AMI family, aren't listed here and are documented with their fields in the [EC2NodeClass docs]({{<ref \"../concepts/nodeclasses\" >}}).\n\n"
+	nodeClassDefaultsBlock += "| Field | Default |\n"
+	nodeClassDefaultsBlock += "|--|--|\n"
+	for _, d := range ec2NodeClassDefaults() {
+		nodeClassDefaultsBlock += fmt.Sprintf("| %s | `%s` |\n", d.path, d.value)
+	}
+
 	log.Println("writing output to", outputFileName)
 	f, err := os.Create(outputFileName)
 	if err != nil {
 		log.Fatalf("unable to open %s to write generated output: %v", outputFileName, err)
 	}
-	f.WriteString(topDoc + envVarsBlock + bottomDoc)
+	f.WriteString(topDoc + envVarsBlock + nodeClassDefaultsBlock + bottomDoc)
+}
+
+type fieldDefault struct {
+	path  string
+	value string
+}
+
+// ec2NodeClassDefaults walks the OpenAPI schema of the storage version of the EC2NodeClass CRD and returns the
+// default for every field that has one. Defaults are sourced from the generated CRD rather than the Go types so
+// that the docs reflect the defaults that are actually applied by the API server.
+func ec2NodeClassDefaults() []fieldDefault {
+	crd, ok := lo.Find(apis.CRDs, func(crd *apiextensionsv1.CustomResourceDefinition) bool {
+		return crd.Spec.Names.Kind == "EC2NodeClass"
+	})
+	if !ok {
+		log.Fatalf("unable to find the EC2NodeClass CRD")
+	}
+	version, ok := lo.Find(crd.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion) bool { return v.Storage })
+	if !ok || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+		log.Fatalf("unable to find the storage version schema for the EC2NodeClass CRD")
+	}
+	spec, ok := version.Schema.OpenAPIV3Schema.Properties["spec"]
+	if !ok {
+		log.Fatalf("unable to find the spec in the EC2NodeClass CRD schema")
+	}
+	var defaults []fieldDefault
+	collectDefaults("spec", spec, &defaults)
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].path < defaults[j].path })
+	return defaults
+}
+
+func collectDefaults(path string, schema apiextensionsv1.JSONSchemaProps, defaults *[]fieldDefault) {
+	if schema.Default != nil {
+		*defaults = append(*defaults, fieldDefault{path: path, value: string(schema.Default.Raw)})
+	}
+	for name, prop := range schema.Properties {
+		collectDefaults(fmt.Sprintf("%s.%s", path, name), prop, defaults)
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		collectDefaults(fmt.Sprintf("%s[]", path), *schema.Items.Schema, defaults)
+	}
 }
@@ -0,0 +1,8 @@
+# Taints Validation
+
+# Taints and startup taints are registered by the kubelet, so they're rendered into the user data of the launch template
+# which EC2 limits to 16 KB. Limiting the number of taints surfaces oversized NodePools at admission rather than as
+# launch or registration failures.
+# NodePool Validation:
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.template.properties.spec.properties.taints.maxItems = 50' -i pkg/apis/crds/karpenter.sh_nodepools.yaml
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.template.properties.spec.properties.startupTaints.maxItems = 50' -i pkg/apis/crds/karpenter.sh_nodepools.yaml
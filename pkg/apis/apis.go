@@ -28,6 +28,12 @@ var (
 	CompatibilityGroup = "compatibility." + Group
 	//go:embed crds/karpenter.k8s.aws_ec2nodeclasses.yaml
 	EC2NodeClassCRD []byte
+	//go:embed crds/karpenter.k8s.aws_consolidationestimates.yaml
+	ConsolidationEstimateCRD []byte
+	//go:embed crds/karpenter.k8s.aws_nodepooltemplates.yaml
+	NodePoolTemplateCRD []byte
+	//go:embed crds/karpenter.k8s.aws_provisioningaudits.yaml
+	ProvisioningAuditCRD []byte
 	//go:embed crds/karpenter.sh_nodepools.yaml
 	NodePoolCRD []byte
 	//go:embed crds/karpenter.sh_nodeclaims.yaml
@@ -36,5 +42,8 @@ var (
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](EC2NodeClassCRD),
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodeClaimCRD),
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodePoolCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodePoolTemplateCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](ConsolidationEstimateCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](ProvisioningAuditCRD),
 	}
 )
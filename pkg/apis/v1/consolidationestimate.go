@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The actions that consolidation is proposed to take on a node
+const (
+	ConsolidationActionDelete  = "Delete"
+	ConsolidationActionReplace = "Replace"
+)
+
+// ConsolidationEstimateSpec is the specification of a consolidation estimate of a NodePool
+type ConsolidationEstimateSpec struct {
+	// NodePool is the name of the NodePool whose consolidation is observed
+	// +kubebuilder:validation:XValidation:message="nodePool is immutable",rule="self == oldSelf"
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	NodePool string `json:"nodePool"`
+	// ObservationPeriod is the duration, starting when the ConsolidationEstimate is created, for which the consolidation
+	// that is estimated for the NodePool is recorded
+	// +kubebuilder:validation:Pattern=`^(([0-9]+(s|m|h))+)$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="24h"
+	// +optional
+	ObservationPeriod metav1.Duration `json:"observationPeriod,omitempty"`
+}
+
+// ConsolidationCandidate is a node which consolidation is proposed to disrupt
+type ConsolidationCandidate struct {
+	// NodeClaim is the name of the NodeClaim of the node
+	NodeClaim string `json:"nodeClaim"`
+	// Node is the name of the node
+	// +optional
+	Node string `json:"node,omitempty"`
+	// InstanceType is the instance type of the node
+	InstanceType string `json:"instanceType"`
+	// CapacityType is the capacity type of the node
+	CapacityType string `json:"capacityType"`
+	// Action is the action that consolidation is proposed to take on the node, either Delete or Replace
+	// +kubebuilder:validation:Enum:={Delete,Replace}
+	Action string `json:"action"`
+	// Replacement is the cheapest instance type that the node's pods fit on, which the node is proposed to be replaced with
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+	// HourlySavings is the estimated reduction in the hourly price of the NodePool, in USD
+	HourlySavings string `json:"hourlySavings"`
+	// Pods are the pods, in namespace/name form, which would be evicted from the node
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+	// FirstProposed is the time at which the node was first proposed for the action
+	FirstProposed metav1.Time `json:"firstProposed"`
+}
+
+// ConsolidationEstimateStatus contains the consolidation which was proposed at the latest evaluation of the NodePool
+type ConsolidationEstimateStatus struct {
+	// ObservationEnd is the time at which the observation period ends and the estimate stops being updated
+	// +optional
+	ObservationEnd *metav1.Time `json:"observationEnd,omitempty"`
+	// LastEvaluated is the time at which consolidation of the NodePool was last evaluated
+	// +optional
+	LastEvaluated *metav1.Time `json:"lastEvaluated,omitempty"`
+	// Candidates are the nodes which consolidation is proposed to disrupt
+	// +optional
+	Candidates []ConsolidationCandidate `json:"candidates,omitempty"`
+	// EstimatedHourlySavings is the estimated reduction in the hourly price of the NodePool if every candidate was
+	// disrupted, in USD
+	// +optional
+	EstimatedHourlySavings string `json:"estimatedHourlySavings,omitempty"`
+}
+
+// ConsolidationEstimate is the Schema for the ConsolidationEstimate API. A ConsolidationEstimate records an estimate of
+// the consolidation of a NodePool for an observation period, so that it can be audited before consolidation is enabled.
+// It doesn't pause consolidation. The estimate is node by node, and doesn't include consolidation which moves pods onto
+// other existing nodes.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="NodePool",type="string",JSONPath=".spec.nodePool",description=""
+// +kubebuilder:printcolumn:name="Savings",type="string",JSONPath=".status.estimatedHourlySavings",description=""
+// +kubebuilder:printcolumn:name="Ends",type="date",JSONPath=".status.observationEnd",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:resource:path=consolidationestimates,scope=Cluster,categories=karpenter
+// +kubebuilder:subresource:status
+type ConsolidationEstimate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsolidationEstimateSpec   `json:"spec,omitempty"`
+	Status ConsolidationEstimateStatus `json:"status,omitempty"`
+}
+
+// ConsolidationEstimateList contains a list of ConsolidationEstimate
+// +kubebuilder:object:root=true
+type ConsolidationEstimateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsolidationEstimate `json:"items"`
+}
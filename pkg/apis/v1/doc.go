@@ -32,5 +32,11 @@ func init() {
 	scheme.Scheme.AddKnownTypes(gv,
 		&EC2NodeClass{},
 		&EC2NodeClassList{},
+		&ConsolidationEstimate{},
+		&ConsolidationEstimateList{},
+		&NodePoolTemplate{},
+		&NodePoolTemplateList{},
+		&ProvisioningAudit{},
+		&ProvisioningAuditList{},
 	)
 }
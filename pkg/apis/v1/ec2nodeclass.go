@@ -21,6 +21,7 @@ import (
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -43,6 +44,14 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
 	SecurityGroupSelectorTerms []SecurityGroupSelectorTerm `json:"securityGroupSelectorTerms" hash:"ignore"`
+	// CapacityBlockSelectorTerms is a list of or capacity block selector terms. The terms are ORed. Instances which are
+	// launched with the capacity-block capacity type are launched into the selected EC2 Capacity Blocks for ML while
+	// they're active, and are drained before the blocks end.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in capacityBlockSelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	CapacityBlockSelectorTerms []CapacityBlockSelectorTerm `json:"capacityBlockSelectorTerms,omitempty" hash:"ignore"`
 	// AssociatePublicIPAddress controls if public IP addresses are assigned to instances that are launched with the nodeclass.
 	// +optional
 	AssociatePublicIPAddress *bool `json:"associatePublicIPAddress,omitempty"`
@@ -51,6 +60,7 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.alias) || has(x.tags) || has(x.name) || has(x.owner)))"
 	// +kubebuilder:validation:XValidation:message="'alias' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.exists(x, has(x.alias) && (has(x.id) || has(x.tags) || has(x.name) || has(x.owner)))"
 	// +kubebuilder:validation:XValidation:message="'alias' is mutually exclusive, cannot be set with a combination of other amiSelectorTerms",rule="!(self.exists(x, has(x.alias)) && self.size() != 1)"
+	// +kubebuilder:validation:XValidation:message="'fallbackAlias' can only be set with 'id' in amiSelectorTerms",rule="!self.exists(x, has(x.fallbackAlias) && !has(x.id))"
 	// +kubebuilder:validation:MinItems:=1
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
@@ -61,7 +71,7 @@ type EC2NodeClassSpec struct {
 	// alias is specified, this field is required.
 	// NOTE: We ignore the AMIFamily for hashing here because we hash the AMIFamily dynamically by using the alias using
 	// the AMIFamily() helper function
-	// +kubebuilder:validation:Enum:={AL2,AL2023,Bottlerocket,Custom,Windows2019,Windows2022}
+	// +kubebuilder:validation:Enum:={AL2,AL2023,Bottlerocket,Custom,Windows2019,Windows2022,Windows2025}
 	// +optional
 	AMIFamily *string `json:"amiFamily,omitempty" hash:"ignore"`
 	// UserData to be applied to the provisioned nodes.
@@ -86,12 +96,15 @@ type EC2NodeClassSpec struct {
 	// +optional
 	InstanceProfile *string `json:"instanceProfile,omitempty"`
 	// Tags to be applied on ec2 resources like instances and launch templates.
+	// EC2 resources support up to 50 tags and Karpenter applies up to 6 of its own, so at most 44 tags can be specified.
 	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
+	// +kubebuilder:validation:XValidation:message="tag keys can't exceed 128 characters and tag values can't exceed 256 characters",rule="self.all(k, size(k) <= 128 && size(self[k]) <= 256)"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching eks:eks-cluster-name",rule="self.all(k, k !='eks:eks-cluster-name')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !k.startsWith('kubernetes.io/cluster') )"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
+	// +kubebuilder:validation:MaxProperties:=44
 	// +optional
 	Tags map[string]string `json:"tags,omitempty"`
 	// Kubelet defines args to be used when configuring kubelet on provisioned nodes.
@@ -102,6 +115,17 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:XValidation:message="evictionSoftGracePeriod OwnerKey does not have a matching evictionSoft",rule="has(self.evictionSoftGracePeriod) ? self.evictionSoftGracePeriod.all(e, (e in self.evictionSoft)):true"
 	// +optional
 	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
+	// KubeletConfigurationPatch is merged into the kubelet configuration that Karpenter generates, so that kubelet
+	// settings which the kubelet field doesn't support can be set without replacing the generated UserData. Objects are merged
+	// recursively, null values remove the setting and any other value replaces it, like a JSON merge patch.
+	// For the AL2023 AMI family, the patch is merged into the kubelet configuration of the NodeConfig and uses the
+	// names of the upstream KubeletConfiguration. For the Bottlerocket AMI family, the patch is merged into the
+	// settings.kubernetes table and uses the names of the Bottlerocket settings. Other AMI families ignore the patch.
+	// Settings which instance types are modeled with, like maxPods and kubeReserved, must be set through kubelet instead.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	// +optional
+	KubeletConfigurationPatch *apiextensionsv1.JSON `json:"kubeletConfigurationPatch,omitempty"`
 	// BlockDeviceMappings to be applied to provisioned nodes.
 	// +kubebuilder:validation:XValidation:message="must have only one blockDeviceMappings with rootVolume",rule="self.filter(x, has(x.rootVolume)?x.rootVolume==true:false).size() <= 1"
 	// +kubebuilder:validation:MaxItems:=50
@@ -110,9 +134,46 @@ type EC2NodeClassSpec struct {
 	// InstanceStorePolicy specifies how to handle instance-store disks.
 	// +optional
 	InstanceStorePolicy *InstanceStorePolicy `json:"instanceStorePolicy,omitempty"`
+	// InstanceStoreEncryption specifies whether data on instance-store disks must be encrypted at rest. When Required,
+	// instance types with instance-store disks that don't support hardware encryption won't be launched.
+	// Instance types without instance-store disks are unaffected.
+	// +optional
+	InstanceStoreEncryption *InstanceStoreEncryption `json:"instanceStoreEncryption,omitempty"`
+	// InstanceStoreSecureWipe configures the generated UserData to discard all data on instance-store disks when the
+	// instance shuts down. This is only supported for the AL2 and AL2023 AMI families.
+	// +optional
+	InstanceStoreSecureWipe *bool `json:"instanceStoreSecureWipe,omitempty"`
 	// DetailedMonitoring controls if detailed monitoring is enabled for instances that are launched
 	// +optional
 	DetailedMonitoring *bool `json:"detailedMonitoring,omitempty"`
+	// TerminationProtection enables EC2 termination protection on the on-demand instances which are launched, so that
+	// they can't be terminated out of band, e.g. from the EC2 console, while Karpenter drains their nodes. Karpenter
+	// removes the protection once the node is drained, just before it terminates the instance. Spot instances can't be
+	// protected from termination, so they're launched without it.
+	// +optional
+	TerminationProtection *bool `json:"terminationProtection,omitempty"`
+	// EnclaveOptions configures AWS Nitro Enclaves for the instances which are launched. When enclaves are enabled, only
+	// the instance types which support Nitro Enclaves are launched.
+	// +optional
+	EnclaveOptions *EnclaveOptions `json:"enclaveOptions,omitempty"`
+	// KeyName is the name of the EC2 key pair that instances are launched with, for organizations which require
+	// emergency SSH access to nodes. Nodes launched with a key pair are annotated with karpenter.k8s.aws/ssh-key-name.
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	KeyName *string `json:"keyName,omitempty"`
+	// OutpostARN is the ARN of the AWS Outpost that instances are launched on. Only the selected subnets which are on the
+	// Outpost are launched into, and only the instance types which are configured on the Outpost are launched. Outpost
+	// capacity is paid for upfront and only supports on-demand instances, so offerings on the Outpost have no price.
+	// +kubebuilder:validation:Pattern:=`^arn:aws[a-z-]*:outposts:[a-z0-9-]+:[0-9]{12}:outpost/op-[0-9a-f]{17}$`
+	// +optional
+	OutpostARN *string `json:"outpostArn,omitempty"`
+	// PlacementGroup is the placement group that instances are launched into, selected by name or by tags. Cluster, spread
+	// and partition placement groups are supported. For partition placement groups, Karpenter assigns each instance to a
+	// partition and labels the node with karpenter.k8s.aws/placement-partition, so that pods can be spread across
+	// partitions with topology spread constraints.
+	// +optional
+	PlacementGroup *PlacementGroup `json:"placementGroup,omitempty"`
 	// MetadataOptions for the generated launch template of provisioned nodes.
 	//
 	// This specifies the exposure of the Instance Metadata Service to
@@ -134,6 +195,144 @@ type EC2NodeClassSpec struct {
 	// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateFleet.html
 	// +optional
 	Context *string `json:"context,omitempty"`
+	// ReadinessGates is a list of additional status conditions that must be True before the EC2NodeClass is
+	// considered Ready. These conditions are not managed by Karpenter and are expected to be set on the
+	// EC2NodeClass status by an external controller (e.g. a compliance controller).
+	// +kubebuilder:validation:XValidation:message="readinessGates cannot reference a condition type managed by Karpenter",rule="self.all(x, !(x.conditionType in ['Ready','AMIsReady','SubnetsReady','SecurityGroupsReady','InstanceProfileReady','ValidationSucceeded','FastLaunchEnabled','RegistriesReachable','LaunchDryRunSucceeded','NodeRoleECRPullAllowed','NodeRoleDescribeClusterAllowed','NodeRoleEBSCSIAllowed','ZonalResourcesValid','QuotasSufficient','DHCPOptionsCompatible','PinnedAMIsAvailable']))"
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	ReadinessGates []ReadinessGate `json:"readinessGates,omitempty" hash:"ignore"`
+	// WindowsFastLaunch configures EC2 Fast Launch for the Windows AMIs selected by the EC2NodeClass.
+	// Fast Launch pre-provisions snapshots of Windows AMIs which reduces the time it takes to launch Windows instances.
+	// https://docs.aws.amazon.com/AWSEC2/latest/WindowsGuide/win-ami-config-fast-launch.html
+	// +optional
+	WindowsFastLaunch *WindowsFastLaunch `json:"windowsFastLaunch,omitempty" hash:"ignore"`
+	// WindowsGMSA configures the prerequisites for running Windows containers with group managed service account (gMSA)
+	// credential specs on the nodes, so that they can authenticate with Active Directory without custom UserData.
+	// This is only supported for the Windows2019, Windows2022 and Windows2025 AMI families.
+	// +optional
+	WindowsGMSA *WindowsGMSA `json:"windowsGMSA,omitempty"`
+	// DNS configures the DNS servers that the nodes resolve names with, in place of the DNS servers from the DHCP options
+	// of the VPC. This is needed when the DHCP options of the VPC set custom DNS servers which can't resolve the cluster
+	// endpoint, so that nodes never join the cluster. Pods with the Default DNS policy resolve names with the same servers.
+	// This is only supported for the AL2 and AL2023 AMI families.
+	// +optional
+	DNS *DNS `json:"dns,omitempty"`
+	// DeletionPolicy determines what happens to the NodeClaims of the EC2NodeClass when it's deleted. With the Cascade
+	// policy, deletion waits for every NodeClaim to terminate. With the Orphan policy, the NodeClaims are released from
+	// Karpenter's management and their instances are left running so that they can be adopted manually.
+	// +kubebuilder:validation:Enum:={Cascade,Orphan}
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty" hash:"ignore"`
+	// DriftPolicy configures how nodes are remediated when they drift from the EC2NodeClass. By default, drifted
+	// nodes are replaced.
+	// +optional
+	DriftPolicy *DriftPolicy `json:"driftPolicy,omitempty" hash:"ignore"`
+	// Profile pre-populates opinionated defaults for the fields of the EC2NodeClass which aren't set. Fields which are
+	// set take precedence over the profile, so that a profile can be customized with deltas. The secure profile launches
+	// instances without public IP addresses into private subnets, with detailed monitoring and encrypted gp3 volumes. The
+	// cost-optimized profile launches instances without detailed monitoring, with gp3 volumes and with instance store
+	// disks used for ephemeral storage. The performance profile launches instances with detailed monitoring, gp3 volumes
+	// with increased IOPS and throughput, and instance store disks used for ephemeral storage.
+	// +kubebuilder:validation:Enum:={secure,cost-optimized,performance}
+	// +optional
+	Profile *Profile `json:"profile,omitempty"`
+}
+
+// DriftPolicy configures how nodes are remediated when they drift from their EC2NodeClass
+type DriftPolicy struct {
+	// VolumeResize determines how nodes are remediated when the only change to the EC2NodeClass is an increase of the
+	// volume sizes of its block device mappings. With the Replace policy, the nodes are replaced. With the InPlace
+	// policy, the EBS volumes of the nodes are modified to the new sizes and a node agent is requested to expand their
+	// filesystems, through the karpenter.k8s.aws/volume-resize-requested annotation of the nodes.
+	// +kubebuilder:validation:Enum:={Replace,InPlace}
+	// +optional
+	VolumeResize *VolumeResizePolicy `json:"volumeResize,omitempty"`
+}
+
+// PlacementGroup selects the placement group which instances are launched into
+// +kubebuilder:validation:XValidation:message="expected exactly one of ['name', 'tags']",rule="has(self.name) != has(self.tags)"
+type PlacementGroup struct {
+	// Name of the placement group
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Tags is a map of key/value tags used to select the placement group. Exactly one placement group must match.
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MinProperties:=1
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// Partitions is the number of partitions of a partition placement group. Instances are assigned to partitions 1
+	// through Partitions, unless the pods' requirements on karpenter.k8s.aws/placement-partition are more restrictive.
+	// Defaults to the partition count of the placement group.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=7
+	// +optional
+	Partitions int32 `json:"partitions,omitempty"`
+}
+
+// WindowsFastLaunch configures EC2 Fast Launch for Windows AMIs.
+type WindowsFastLaunch struct {
+	// Enabled configures Karpenter to enable Fast Launch on each selected Windows AMI which doesn't have Fast Launch
+	// configured. AMIs which already have Fast Launch configured are left untouched.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// TargetResourceCount is the number of pre-provisioned snapshots to keep on hand for each AMI.
+	// If omitted, the EC2 default is used.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	TargetResourceCount *int32 `json:"targetResourceCount,omitempty"`
+	// MaxParallelLaunches is the maximum number of instances which may be launched in parallel to create the
+	// pre-provisioned snapshots. If omitted, the EC2 default is used.
+	// +kubebuilder:validation:Minimum:=6
+	// +optional
+	MaxParallelLaunches *int32 `json:"maxParallelLaunches,omitempty"`
+}
+
+// WindowsGMSA configures the nodes to resolve the Active Directory domain of the gMSAs that Windows containers run as.
+// The credential specs themselves, including the plugin input of domainless gMSA, are provided to pods through the
+// gMSA admission webhook.
+type WindowsGMSA struct {
+	// DomainName is the fully qualified name of the Active Directory domain of the gMSAs, e.g. corp.example.com. The
+	// domain is added to the DNS suffix search list of the nodes.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)+$`
+	// +kubebuilder:validation:MaxLength:=253
+	// +required
+	DomainName string `json:"domainName"`
+	// DNSServers are the IPv4 addresses of the DNS servers of the domain, typically its domain controllers, which the
+	// nodes resolve names with. If omitted, the nodes resolve names with the DNS servers of the VPC, which must then
+	// forward the domain to its domain controllers.
+	// +kubebuilder:validation:items:Pattern:=`^([0-9]{1,3}\.){3}[0-9]{1,3}$`
+	// +kubebuilder:validation:MaxItems:=4
+	// +optional
+	DNSServers []string `json:"dnsServers,omitempty"`
+}
+
+// DNS configures the DNS resolution of the nodes
+type DNS struct {
+	// Nameservers are the IPv4 addresses of the DNS servers that the nodes resolve names with.
+	// +kubebuilder:validation:items:Pattern:=`^([0-9]{1,3}\.){3}[0-9]{1,3}$`
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:MaxItems:=3
+	// +required
+	Nameservers []string `json:"nameservers"`
+	// SearchDomains are the domains which are added to the DNS search list of the nodes, e.g. corp.example.com.
+	// +kubebuilder:validation:items:Pattern:=`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)*$`
+	// +kubebuilder:validation:MaxItems:=6
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// ReadinessGate references an additional status condition that gates the readiness of the EC2NodeClass.
+type ReadinessGate struct {
+	// ConditionType refers to a condition in the EC2NodeClass's status conditions with a matching type.
+	// +kubebuilder:validation:Pattern:=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])$`
+	// +kubebuilder:validation:MaxLength:=316
+	// +required
+	ConditionType string `json:"conditionType"`
 }
 
 // SubnetSelectorTerm defines selection logic for a subnet used by Karpenter to launch nodes.
@@ -149,6 +348,28 @@ type SubnetSelectorTerm struct {
 	// +kubebuilder:validation:Pattern="subnet-[0-9a-z]+"
 	// +optional
 	ID string `json:"id,omitempty"`
+	// Weight is the preference for the zones of the subnets selected by this term. Instances are launched into the
+	// zones with the highest weight first, falling back to zones with lower weights when capacity isn't available.
+	// Subnets which aren't selected by a weighted term have a weight of 0.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// CapacityBlockSelectorTerm defines selection logic for a Capacity Block for ML used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type CapacityBlockSelectorTerm struct {
+	// Tags is a map of key/value tags used to select capacity blocks.
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the capacity reservation id of the capacity block in EC2
+	// +kubebuilder:validation:Pattern="cr-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
 }
 
 // SecurityGroupSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
@@ -174,16 +395,25 @@ type SecurityGroupSelectorTerm struct {
 type AMISelectorTerm struct {
 	// Alias specifies which EKS optimized AMI to select.
 	// Each alias consists of a family and an AMI version, specified as "family@version".
-	// Valid families include: al2, al2023, bottlerocket, windows2019, and windows2022.
+	// Valid families include: al2, al2023, bottlerocket, windows2019, windows2022, and windows2025.
 	// The version can either be pinned to a specific AMI release, with that AMIs version format (ex: "al2023@v20240625" or "bottlerocket@v1.10.0").
 	// The version can also be set to "latest" for any family. Setting the version to latest will result in drift when a new AMI is released. This is **not** recommended for production environments.
 	// Note: The Windows families do **not** support version pinning, and only latest may be used.
 	// +kubebuilder:validation:XValidation:message="'alias' is improperly formatted, must match the format 'family@version'",rule="self.matches('^[a-zA-Z0-9]+@.+$')"
-	// +kubebuilder:validation:XValidation:message="family is not supported, must be one of the following: 'al2', 'al2023', 'bottlerocket', 'windows2019', 'windows2022'",rule="self.split('@')[0] in ['al2','al2023','bottlerocket','windows2019','windows2022']"
-	// +kubebuilder:validation:XValidation:message="windows families may only specify version 'latest'",rule="self.split('@')[0] in ['windows2019','windows2022'] ? self.split('@')[1] == 'latest' : true"
+	// +kubebuilder:validation:XValidation:message="family is not supported, must be one of the following: 'al2', 'al2023', 'bottlerocket', 'windows2019', 'windows2022', 'windows2025'",rule="self.split('@')[0] in ['al2','al2023','bottlerocket','windows2019','windows2022','windows2025']"
+	// +kubebuilder:validation:XValidation:message="windows families may only specify version 'latest'",rule="self.split('@')[0] in ['windows2019','windows2022','windows2025'] ? self.split('@')[1] == 'latest' : true"
 	// +kubebuilder:validation:MaxLength=30
 	// +optional
 	Alias string `json:"alias,omitempty"`
+	// FallbackAlias specifies an EKS optimized AMI to select when the AMI of an id term is deprecated or no longer exists.
+	// It uses the same "family@version" format as alias, and may only be set with id. The family must match the
+	// amiFamily of the EC2NodeClass, unless the amiFamily is Custom.
+	// +kubebuilder:validation:XValidation:message="'fallbackAlias' is improperly formatted, must match the format 'family@version'",rule="self.matches('^[a-zA-Z0-9]+@.+$')"
+	// +kubebuilder:validation:XValidation:message="family is not supported, must be one of the following: 'al2', 'al2023', 'bottlerocket', 'windows2019', 'windows2022', 'windows2025'",rule="self.split('@')[0] in ['al2','al2023','bottlerocket','windows2019','windows2022','windows2025']"
+	// +kubebuilder:validation:XValidation:message="windows families may only specify version 'latest'",rule="self.split('@')[0] in ['windows2019','windows2022','windows2025'] ? self.split('@')[1] == 'latest' : true"
+	// +kubebuilder:validation:MaxLength=30
+	// +optional
+	FallbackAlias string `json:"fallbackAlias,omitempty"`
 	// Tags is a map of key/value tags used to select amis.
 	// Specifying '*' for a value selects all values for a given tag key.
 	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
@@ -273,6 +503,14 @@ type KubeletConfiguration struct {
 	CPUCFSQuota *bool `json:"cpuCFSQuota,omitempty"`
 }
 
+// EnclaveOptions configures AWS Nitro Enclaves for the instances which are launched
+type EnclaveOptions struct {
+	// Enabled launches instances with Nitro Enclaves enabled, so that isolated compute environments can be created from
+	// their vCPUs and memory.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
 // MetadataOptions contains parameters for specifying the exposure of the
 // Instance Metadata Service to provisioned EC2 nodes.
 type MetadataOptions struct {
@@ -415,11 +653,54 @@ const (
 	InstanceStorePolicyRAID0 InstanceStorePolicy = "RAID0"
 )
 
+// InstanceStoreEncryption enumerates options for encrypting data on instance store disks.
+// +kubebuilder:validation:Enum={Required}
+type InstanceStoreEncryption string
+
+const (
+	// InstanceStoreEncryptionRequired restricts instance types to those whose instance store disks are encrypted at
+	// rest using hardware encryption. The encryption keys are unique to each instance and are destroyed when the
+	// instance is stopped or terminated.
+	InstanceStoreEncryptionRequired InstanceStoreEncryption = "Required"
+)
+
+// DeletionPolicy enumerates what happens to the NodeClaims of an EC2NodeClass when it's deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyCascade waits for every NodeClaim of the EC2NodeClass to terminate before the EC2NodeClass is
+	// deleted. This is the default.
+	DeletionPolicyCascade DeletionPolicy = "Cascade"
+	// DeletionPolicyOrphan releases the NodeClaims of the EC2NodeClass from Karpenter's management without terminating
+	// their instances. The Karpenter tags are removed from the instances, and the nodes are left in the cluster.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// VolumeResizePolicy enumerates how nodes are remediated when the volume sizes of their EC2NodeClass increase.
+type VolumeResizePolicy string
+
+const (
+	// VolumeResizePolicyReplace replaces the nodes. This is the default.
+	VolumeResizePolicyReplace VolumeResizePolicy = "Replace"
+	// VolumeResizePolicyInPlace modifies the EBS volumes of the nodes to the new sizes, without replacing them.
+	VolumeResizePolicyInPlace VolumeResizePolicy = "InPlace"
+)
+
+// Profile enumerates the named sets of defaults which can be selected by an EC2NodeClass.
+type Profile string
+
+const (
+	ProfileSecure        Profile = "secure"
+	ProfileCostOptimized Profile = "cost-optimized"
+	ProfilePerformance   Profile = "performance"
+)
+
 // EC2NodeClass is the Schema for the EC2NodeClass API
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 // +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.role",priority=1,description=""
+// +kubebuilder:printcolumn:name="NodeClaims",type="integer",JSONPath=".status.dependents.count",priority=1,description=""
 // +kubebuilder:resource:path=ec2nodeclasses,scope=Cluster,categories=karpenter,shortName={ec2nc,ec2ncs}
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
@@ -434,7 +715,9 @@ type EC2NodeClass struct {
 	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Bottlerocket' or 'Custom' when using a Bottlerocket alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'bottlerocket') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Bottlerocket') : true)"
 	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Windows2019' or 'Custom' when using a Windows2019 alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'windows2019') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Windows2019') : true)"
 	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Windows2022' or 'Custom' when using a Windows2022 alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'windows2022') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Windows2022') : true)"
+	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Windows2025' or 'Custom' when using a Windows2025 alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'windows2025') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Windows2025') : true)"
 	// +kubebuilder:validation:XValidation:message="must specify amiFamily if amiSelectorTerms does not contain an alias",rule="self.amiSelectorTerms.exists(x, has(x.alias)) ? true : has(self.amiFamily)"
+	// +kubebuilder:validation:XValidation:message="the family of a fallbackAlias must match amiFamily, unless amiFamily is 'Custom'",rule="!has(self.amiFamily) || self.amiFamily == 'Custom' || self.amiSelectorTerms.all(x, !has(x.fallbackAlias) || x.fallbackAlias.find('^[^@]+') == self.amiFamily.lowerAscii())"
 	Spec   EC2NodeClassSpec   `json:"spec,omitempty"`
 	Status EC2NodeClassStatus `json:"status,omitempty"`
 }
@@ -459,6 +742,32 @@ func (in *EC2NodeClass) Hash() string {
 	})))
 }
 
+// HashWithoutVolumeSizes returns the hash of the EC2NodeClass with the volume sizes of its block device mappings
+// ignored. Nodes whose EC2NodeClass only differs by the sizes of its volumes share this hash, so that their volumes
+// can be resized in place rather than the nodes being replaced.
+func (in *EC2NodeClass) HashWithoutVolumeSizes() string {
+	nodeClass := in.DeepCopy()
+	for _, bdm := range nodeClass.Spec.BlockDeviceMappings {
+		if bdm != nil && bdm.EBS != nil {
+			bdm.EBS.VolumeSize = nil
+		}
+	}
+	return nodeClass.Hash()
+}
+
+// VolumeResizePolicy returns the policy which remediates nodes when only the volume sizes of the EC2NodeClass increase
+func (in *EC2NodeClass) VolumeResizePolicy() VolumeResizePolicy {
+	if in.Spec.DriftPolicy == nil {
+		return VolumeResizePolicyReplace
+	}
+	return lo.FromPtrOr(in.Spec.DriftPolicy.VolumeResize, VolumeResizePolicyReplace)
+}
+
+// EnclavesEnabled returns true if instances are launched with Nitro Enclaves enabled
+func (in *EC2NodeClass) EnclavesEnabled() bool {
+	return in.Spec.EnclaveOptions != nil && lo.FromPtr(in.Spec.EnclaveOptions.Enabled)
+}
+
 func (in *EC2NodeClass) InstanceProfileName(clusterName, region string) string {
 	return fmt.Sprintf("%s_%d", clusterName, lo.Must(hashstructure.Hash(fmt.Sprintf("%s%s", region, in.Name), hashstructure.FormatV2, nil)))
 }
@@ -491,6 +800,36 @@ func (in *EC2NodeClass) AMIFamily() string {
 	return AMIFamilyCustom
 }
 
+// PlacementGroupName returns the name of the placement group that instances are launched into. It's empty if instances
+// aren't launched into a placement group, or if the placement group is selected by tags and hasn't been resolved yet.
+func (in *EC2NodeClass) PlacementGroupName() string {
+	if in.Spec.PlacementGroup == nil {
+		return ""
+	}
+	if in.Spec.PlacementGroup.Name != "" {
+		return in.Spec.PlacementGroup.Name
+	}
+	if in.Status.PlacementGroup != nil {
+		return in.Status.PlacementGroup.Name
+	}
+	return ""
+}
+
+// PlacementGroupPartitions returns the number of partitions that instances are assigned to, or 0 if instances aren't
+// launched into a partition placement group
+func (in *EC2NodeClass) PlacementGroupPartitions() int32 {
+	if in.Spec.PlacementGroup == nil {
+		return 0
+	}
+	if in.Spec.PlacementGroup.Partitions != 0 {
+		return in.Spec.PlacementGroup.Partitions
+	}
+	if in.Status.PlacementGroup != nil && in.Status.PlacementGroup.Strategy == PlacementGroupStrategyPartition {
+		return in.Status.PlacementGroup.PartitionCount
+	}
+	return 0
+}
+
 type Alias struct {
 	Family  string
 	Version string
@@ -517,6 +856,17 @@ func (in *EC2NodeClass) Alias() *Alias {
 	}
 }
 
+// Fallback returns the alias to select when the AMI of an id term is unavailable, or nil if the term doesn't have one
+func (in AMISelectorTerm) Fallback() *Alias {
+	if in.FallbackAlias == "" {
+		return nil
+	}
+	return &Alias{
+		Family:  amiFamilyFromAlias(in.FallbackAlias),
+		Version: amiVersionFromAlias(in.FallbackAlias),
+	}
+}
+
 func amiFamilyFromAlias(alias string) string {
 	components := strings.Split(alias, "@")
 	if len(components) != 2 {
@@ -528,6 +878,7 @@ func amiFamilyFromAlias(alias string) string {
 		AMIFamilyBottlerocket,
 		AMIFamilyWindows2019,
 		AMIFamilyWindows2022,
+		AMIFamilyWindows2025,
 	}, func(family string) bool {
 		return strings.ToLower(family) == components[0]
 	})
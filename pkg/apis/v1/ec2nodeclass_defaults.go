@@ -16,7 +16,69 @@ package v1
 
 import (
 	"context"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// profilePerformanceIOPS and profilePerformanceThroughput are the IOPS and throughput of the gp3 volumes of the
+	// performance profile, which double the IOPS and quadruple the throughput that gp3 volumes include at no charge
+	profilePerformanceIOPS       = int64(6000)
+	profilePerformanceThroughput = int64(500)
 )
 
-// SetDefaults for the EC2NodeClass
-func (in *EC2NodeClass) SetDefaults(_ context.Context) {}
+// SetDefaults for the EC2NodeClass applies the defaults of its profile to the fields which aren't set. The defaults
+// aren't persisted, so the hash of the EC2NodeClass must be taken before they're applied.
+func (in *EC2NodeClass) SetDefaults(_ context.Context) {
+	if in.Spec.Profile == nil {
+		return
+	}
+	switch lo.FromPtr(in.Spec.Profile) {
+	case ProfileSecure:
+		in.Spec.AssociatePublicIPAddress = lo.CoalesceOrEmpty(in.Spec.AssociatePublicIPAddress, lo.ToPtr(false))
+		in.Spec.DetailedMonitoring = lo.CoalesceOrEmpty(in.Spec.DetailedMonitoring, lo.ToPtr(true))
+		if in.Spec.MetadataOptions == nil {
+			in.Spec.MetadataOptions = &MetadataOptions{}
+		}
+		in.Spec.MetadataOptions.HTTPTokens = lo.CoalesceOrEmpty(in.Spec.MetadataOptions.HTTPTokens, lo.ToPtr("required"))
+		in.Spec.MetadataOptions.HTTPPutResponseHopLimit = lo.CoalesceOrEmpty(in.Spec.MetadataOptions.HTTPPutResponseHopLimit, lo.ToPtr(int64(1)))
+	case ProfileCostOptimized:
+		in.Spec.DetailedMonitoring = lo.CoalesceOrEmpty(in.Spec.DetailedMonitoring, lo.ToPtr(false))
+		in.Spec.InstanceStorePolicy = lo.CoalesceOrEmpty(in.Spec.InstanceStorePolicy, lo.ToPtr(InstanceStorePolicyRAID0))
+	case ProfilePerformance:
+		in.Spec.DetailedMonitoring = lo.CoalesceOrEmpty(in.Spec.DetailedMonitoring, lo.ToPtr(true))
+		in.Spec.InstanceStorePolicy = lo.CoalesceOrEmpty(in.Spec.InstanceStorePolicy, lo.ToPtr(InstanceStorePolicyRAID0))
+	}
+	for _, bdm := range in.Spec.BlockDeviceMappings {
+		if bdm != nil && bdm.EBS != nil {
+			in.Spec.Profile.SetBlockDeviceDefaults(bdm.EBS)
+		}
+	}
+}
+
+// PrivateSubnetsOnly returns true if instances are only launched into subnets which don't assign public IP addresses.
+// The secure profile only launches instances into private subnets, unless associatePublicIPAddress is set to true.
+func (in *EC2NodeClass) PrivateSubnetsOnly() bool {
+	return lo.FromPtr(in.Spec.Profile) == ProfileSecure && !lo.FromPtr(in.Spec.AssociatePublicIPAddress)
+}
+
+// SetBlockDeviceDefaults applies the defaults of the profile to the fields of an EBS block device which aren't set. It's
+// also applied to the default block device mappings of the AMI family, when the EC2NodeClass doesn't specify any.
+func (p *Profile) SetBlockDeviceDefaults(ebs *BlockDevice) {
+	if p == nil {
+		return
+	}
+	ebs.VolumeType = lo.CoalesceOrEmpty(ebs.VolumeType, lo.ToPtr("gp3"))
+	switch *p {
+	case ProfileSecure:
+		// The encryption of volumes which are created from snapshots is inherited from the snapshot
+		if ebs.SnapshotID == nil {
+			ebs.Encrypted = lo.CoalesceOrEmpty(ebs.Encrypted, lo.ToPtr(true))
+		}
+	case ProfilePerformance:
+		if lo.FromPtr(ebs.VolumeType) == "gp3" {
+			ebs.IOPS = lo.CoalesceOrEmpty(ebs.IOPS, lo.ToPtr(profilePerformanceIOPS))
+			ebs.Throughput = lo.CoalesceOrEmpty(ebs.Throughput, lo.ToPtr(profilePerformanceThroughput))
+		}
+	}
+}
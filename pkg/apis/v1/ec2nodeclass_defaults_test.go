@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Defaults", func() {
+	var nodeClass *v1.EC2NodeClass
+	BeforeEach(func() {
+		nodeClass = &v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				BlockDeviceMappings: []*v1.BlockDeviceMapping{
+					{
+						DeviceName: lo.ToPtr("/dev/xvda"),
+						RootVolume: true,
+						EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("100Gi"))},
+					},
+				},
+			},
+		}
+	})
+	It("should not default any fields without a profile", func() {
+		expected := nodeClass.DeepCopy()
+		nodeClass.SetDefaults(ctx)
+		Expect(nodeClass).To(Equal(expected))
+	})
+	It("should default the fields of the secure profile", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfileSecure)
+		nodeClass.SetDefaults(ctx)
+		Expect(nodeClass.Spec.AssociatePublicIPAddress).To(Equal(lo.ToPtr(false)))
+		Expect(nodeClass.Spec.DetailedMonitoring).To(Equal(lo.ToPtr(true)))
+		Expect(nodeClass.Spec.MetadataOptions.HTTPTokens).To(Equal(lo.ToPtr("required")))
+		Expect(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit).To(Equal(lo.ToPtr(int64(1))))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.Encrypted).To(Equal(lo.ToPtr(true)))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType).To(Equal(lo.ToPtr("gp3")))
+		Expect(nodeClass.PrivateSubnetsOnly()).To(BeTrue())
+	})
+	It("should default the fields of the cost-optimized profile", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfileCostOptimized)
+		nodeClass.SetDefaults(ctx)
+		Expect(nodeClass.Spec.DetailedMonitoring).To(Equal(lo.ToPtr(false)))
+		Expect(nodeClass.Spec.InstanceStorePolicy).To(Equal(lo.ToPtr(v1.InstanceStorePolicyRAID0)))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType).To(Equal(lo.ToPtr("gp3")))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.IOPS).To(BeNil())
+		Expect(nodeClass.PrivateSubnetsOnly()).To(BeFalse())
+	})
+	It("should default the fields of the performance profile", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfilePerformance)
+		nodeClass.SetDefaults(ctx)
+		Expect(nodeClass.Spec.DetailedMonitoring).To(Equal(lo.ToPtr(true)))
+		Expect(nodeClass.Spec.InstanceStorePolicy).To(Equal(lo.ToPtr(v1.InstanceStorePolicyRAID0)))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.IOPS).To(Equal(lo.ToPtr(int64(6000))))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.Throughput).To(Equal(lo.ToPtr(int64(500))))
+	})
+	It("should prefer the fields which are set over the defaults of the profile", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfileSecure)
+		nodeClass.Spec.AssociatePublicIPAddress = lo.ToPtr(true)
+		nodeClass.Spec.DetailedMonitoring = lo.ToPtr(false)
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType = lo.ToPtr("io2")
+		nodeClass.SetDefaults(ctx)
+		Expect(nodeClass.Spec.AssociatePublicIPAddress).To(Equal(lo.ToPtr(true)))
+		Expect(nodeClass.Spec.DetailedMonitoring).To(Equal(lo.ToPtr(false)))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType).To(Equal(lo.ToPtr("io2")))
+		Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.Encrypted).To(Equal(lo.ToPtr(true)))
+		Expect(nodeClass.PrivateSubnetsOnly()).To(BeFalse())
+	})
+})
@@ -17,6 +17,7 @@ package v1_test
 import (
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/karpenter/pkg/test"
@@ -136,8 +137,18 @@ var _ = Describe("Hash", func() {
 		Entry("Tags", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 		Entry("Context", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
 		Entry("DetailedMonitoring", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("KeyName", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{KeyName: aws.String("test-key-pair")}}),
+		Entry("TerminationProtection", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{TerminationProtection: aws.Bool(true)}}),
+		Entry("EnclaveOptions", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{EnclaveOptions: &v1.EnclaveOptions{Enabled: aws.Bool(true)}}}),
+		Entry("OutpostARN", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{OutpostARN: aws.String("arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0")}}),
+		Entry("Profile", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Profile: lo.ToPtr(v1.ProfileSecure)}}),
 		Entry("InstanceStorePolicy", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStorePolicy: lo.ToPtr(v1.InstanceStorePolicyRAID0)}}),
+		Entry("InstanceStoreEncryption", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStoreEncryption: lo.ToPtr(v1.InstanceStoreEncryptionRequired)}}),
+		Entry("InstanceStoreSecureWipe", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStoreSecureWipe: lo.ToPtr(true)}}),
+		Entry("KubeletConfigurationPatch", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{KubeletConfigurationPatch: &apiextensionsv1.JSON{Raw: []byte(`{"cpuManagerPolicy":"static"}`)}}}),
 		Entry("AssociatePublicIPAddress", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssociatePublicIPAddress: lo.ToPtr(true)}}),
+		Entry("WindowsGMSA", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{WindowsGMSA: &v1.WindowsGMSA{DomainName: "corp.example.com"}}}),
+		Entry("DNS", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{DNS: &v1.DNS{Nameservers: []string{"10.0.0.2"}}}}),
 		Entry("MetadataOptions HTTPEndpoint", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPEndpoint: lo.ToPtr("enabled")}}}),
 		Entry("MetadataOptions HTTPProtocolIPv6", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPProtocolIPv6: lo.ToPtr("enabled")}}}),
 		Entry("MetadataOptions HTTPPutResponseHopLimit", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPPutResponseHopLimit: lo.ToPtr(int64(10))}}}),
@@ -202,6 +213,18 @@ var _ = Describe("Hash", func() {
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	})
+	It("should not change hash when the drift policy is updated", func() {
+		hash := nodeClass.Hash()
+		nodeClass.Spec.DriftPolicy = &v1.DriftPolicy{VolumeResize: lo.ToPtr(v1.VolumeResizePolicyInPlace)}
+		Expect(nodeClass.Hash()).To(Equal(hash))
+	})
+	It("should not change the hash without volume sizes when volume sizes are updated", func() {
+		hash := nodeClass.HashWithoutVolumeSizes()
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = resource.NewScaledQuantity(100, resource.Giga)
+		Expect(nodeClass.HashWithoutVolumeSizes()).To(Equal(hash))
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType = lo.ToPtr("io2")
+		Expect(nodeClass.HashWithoutVolumeSizes()).ToNot(Equal(hash))
+	})
 	It("should expect two EC2NodeClasses with the same spec to have the same hash", func() {
 		otherNodeClass := &v1.EC2NodeClass{
 			Spec: nodeClass.Spec,
@@ -15,8 +15,12 @@ limitations under the License.
 package v1
 
 import (
+	"time"
+
 	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -25,6 +29,37 @@ const (
 	ConditionTypeAMIsReady            = "AMIsReady"
 	ConditionTypeInstanceProfileReady = "InstanceProfileReady"
 	ConditionTypeValidationSucceeded  = "ValidationSucceeded"
+	// ConditionTypeFastLaunchEnabled surfaces whether EC2 Fast Launch could be enabled on the Windows AMIs of the
+	// EC2NodeClass. It's only set when Fast Launch is requested, and doesn't gate the readiness of the EC2NodeClass.
+	ConditionTypeFastLaunchEnabled = "FastLaunchEnabled"
+	// ConditionTypeRegistriesReachable surfaces whether nodes are likely to be able to pull images from ECR. It doesn't
+	// gate the readiness of the EC2NodeClass since it's based on a best-effort analysis of the configuration.
+	ConditionTypeRegistriesReachable = "RegistriesReachable"
+	// ConditionTypeLaunchDryRunSucceeded surfaces whether a DryRun CreateFleet request with a representative configuration
+	// of the EC2NodeClass succeeded. It's only set when launch dry runs are enabled, and doesn't gate the readiness of the
+	// EC2NodeClass.
+	ConditionTypeLaunchDryRunSucceeded = "LaunchDryRunSucceeded"
+	// ConditionTypeNodeRoleECRPullAllowed, ConditionTypeNodeRoleDescribeClusterAllowed and ConditionTypeNodeRoleEBSCSIAllowed
+	// surface whether the IAM policy simulator allows the node role the actions which nodes commonly need. They're only
+	// set when node role permissions are simulated, and don't gate the readiness of the EC2NodeClass.
+	ConditionTypeNodeRoleECRPullAllowed         = "NodeRoleECRPullAllowed"
+	ConditionTypeNodeRoleDescribeClusterAllowed = "NodeRoleDescribeClusterAllowed"
+	ConditionTypeNodeRoleEBSCSIAllowed          = "NodeRoleEBSCSIAllowed"
+	// ConditionTypeZonalResourcesValid surfaces whether the regional and zonal resources referenced by the EC2NodeClass,
+	// like its placement group and capacity blocks, exist and can be launched into from the zones of its subnets. It's
+	// only set when the EC2NodeClass references these resources, and doesn't gate the readiness of the EC2NodeClass.
+	ConditionTypeZonalResourcesValid = "ZonalResourcesValid"
+	// ConditionTypeQuotasSufficient surfaces whether the service quotas of the account allow the NodePools which launch
+	// instances with the EC2NodeClass to reach their limits. It's only set when quotas are validated, and doesn't gate the
+	// readiness of the EC2NodeClass.
+	ConditionTypeQuotasSufficient = "QuotasSufficient"
+	// ConditionTypeDHCPOptionsCompatible surfaces whether the DNS servers that nodes resolve names with are likely to
+	// resolve the cluster endpoint. It's false when the DHCP options of the VPC set custom DNS servers and the
+	// EC2NodeClass doesn't configure DNS servers, and doesn't gate the readiness of the EC2NodeClass.
+	ConditionTypeDHCPOptionsCompatible = "DHCPOptionsCompatible"
+	// ConditionTypePinnedAMIsAvailable surfaces whether the AMIs which are pinned by id in the amiSelectorTerms still exist
+	// and aren't deprecated. It's only set when AMIs are pinned by id, and doesn't gate the readiness of the EC2NodeClass.
+	ConditionTypePinnedAMIsAvailable = "PinnedAMIsAvailable"
 )
 
 // Subnet contains resolved Subnet selector values utilized for node launch
@@ -38,6 +73,9 @@ type Subnet struct {
 	// The associated availability zone ID
 	// +optional
 	ZoneID string `json:"zoneID,omitempty"`
+	// Weight is the highest weight of the subnet selector terms which select the subnet
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -61,11 +99,116 @@ type AMI struct {
 	// Name of the AMI
 	// +optional
 	Name string `json:"name,omitempty"`
+	// FastLaunchState is the EC2 Fast Launch state of the AMI. This is only populated for Windows AMIs which
+	// have Fast Launch configured.
+	// +optional
+	FastLaunchState string `json:"fastLaunchState,omitempty"`
 	// Requirements of the AMI to be utilized on an instance type
 	// +required
 	Requirements []corev1.NodeSelectorRequirement `json:"requirements"`
 }
 
+// CapacityBlockDrainLeadTime is how long before the end of a capacity block that Karpenter stops launching instances
+// into it and starts draining the nodes which were launched into it. EC2 begins terminating the instances of a capacity
+// block 30 minutes before it ends, so this leaves the pods of the nodes 30 minutes to be evicted gracefully.
+const CapacityBlockDrainLeadTime = time.Hour
+
+// CapacityBlock contains resolved Capacity Block for ML selector values utilized for node launch
+type CapacityBlock struct {
+	// ID of the capacity reservation of the capacity block
+	// +required
+	ID string `json:"id"`
+	// InstanceType is the instance type which the capacity block reserves
+	// +required
+	InstanceType string `json:"instanceType"`
+	// Zone is the availability zone of the capacity block
+	// +required
+	Zone string `json:"zone"`
+	// State of the capacity block, e.g. scheduled or active
+	// +required
+	State string `json:"state"`
+	// StartTime is the time at which instances can first be launched into the capacity block
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+	// EndTime is the time at which the capacity block ends and its instances are terminated
+	// +required
+	EndTime metav1.Time `json:"endTime"`
+	// AvailableInstanceCount is the number of instances which can still be launched into the capacity block
+	// +optional
+	AvailableInstanceCount int32 `json:"availableInstanceCount,omitempty"`
+}
+
+// DrainTime is the time at which Karpenter stops launching instances into the capacity block and starts draining the
+// nodes which were launched into it
+func (in *CapacityBlock) DrainTime() time.Time {
+	return in.EndTime.Add(-CapacityBlockDrainLeadTime)
+}
+
+// Launchable returns whether instances can be launched into the capacity block at the given time
+func (in *CapacityBlock) Launchable(now time.Time) bool {
+	return in.State == "active" && in.AvailableInstanceCount > 0 && !now.Before(in.StartTime.Time) && now.Before(in.DrainTime())
+}
+
+const (
+	PlacementGroupStrategyCluster   = "cluster"
+	PlacementGroupStrategySpread    = "spread"
+	PlacementGroupStrategyPartition = "partition"
+)
+
+// ResolvedPlacementGroup contains the resolved placement group utilized for node launch
+type ResolvedPlacementGroup struct {
+	// ID of the placement group
+	// +required
+	ID string `json:"id"`
+	// Name of the placement group
+	// +required
+	Name string `json:"name"`
+	// Strategy of the placement group, one of cluster, spread or partition
+	// +required
+	Strategy string `json:"strategy"`
+	// PartitionCount is the number of partitions of a partition placement group
+	// +optional
+	PartitionCount int32 `json:"partitionCount,omitempty"`
+	// SpreadLevel is the level at which a spread placement group spreads instances, either rack or host
+	// +optional
+	SpreadLevel string `json:"spreadLevel,omitempty"`
+}
+
+// MaxDependentNodeClaims is the number of NodeClaim names which are listed in the dependents of an EC2NodeClass
+const MaxDependentNodeClaims = 20
+
+// Dependents summarizes the NodeClaims which were launched with an EC2NodeClass
+type Dependents struct {
+	// Count is the number of NodeClaims which were launched with the EC2NodeClass
+	// +required
+	Count int `json:"count"`
+	// NodeClaims are the names of the NodeClaims which were launched with the EC2NodeClass, limited to the first 20
+	// in alphabetical order
+	// +optional
+	NodeClaims []string `json:"nodeClaims,omitempty"`
+}
+
+// MaxSummarizedInstanceTypes is the number of instance types which are summarized in the status of an EC2NodeClass
+const MaxSummarizedInstanceTypes = 50
+
+// InstanceTypeSummary contains the resources and price of an instance type when it's launched with the EC2NodeClass
+type InstanceTypeSummary struct {
+	// Name of the instance type
+	// +required
+	Name string `json:"name"`
+	// MaxPods is the number of pods which the kubelet of the instance type is configured to run
+	// +required
+	MaxPods int64 `json:"maxPods"`
+	// Allocatable is the capacity of the instance type less the VM overhead, kube-reserved, system-reserved and eviction
+	// thresholds which result from the AMI family and kubelet configuration of the EC2NodeClass
+	// +optional
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+	// Price is the lowest hourly price, in US dollars, of the offerings of the instance type which are available in the
+	// zones of the subnets
+	// +optional
+	Price string `json:"price,omitempty"`
+}
+
 // EC2NodeClassStatus contains the resolved state of the EC2NodeClass
 type EC2NodeClassStatus struct {
 	// Subnets contains the current subnet values that are available to the
@@ -83,19 +226,40 @@ type EC2NodeClassStatus struct {
 	// InstanceProfile contains the resolved instance profile for the role
 	// +optional
 	InstanceProfile string `json:"instanceProfile,omitempty"`
+	// CapacityBlocks contains the current Capacity Blocks for ML that are selected by the capacity block selectors
+	// and haven't ended
+	// +optional
+	CapacityBlocks []CapacityBlock `json:"capacityBlocks,omitempty"`
+	// PlacementGroup contains the placement group that is selected by the placement group of the spec
+	// +optional
+	PlacementGroup *ResolvedPlacementGroup `json:"placementGroup,omitempty"`
+	// Dependents summarizes the NodeClaims which were launched with the EC2NodeClass. Deleting the EC2NodeClass waits
+	// for these NodeClaims to terminate, or orphans them with the Orphan deletion policy.
+	// +optional
+	Dependents *Dependents `json:"dependents,omitempty"`
+	// InstanceTypes summarizes the instance types which are selected by the karpenter.k8s.aws/instance-type-summary
+	// annotation, either by name or as the cheapest instance types, so that the resources which they're resolved with can
+	// be verified
+	// +optional
+	InstanceTypes []InstanceTypeSummary `json:"instanceTypes,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
 }
 
 func (in *EC2NodeClass) StatusConditions() status.ConditionSet {
-	return status.NewReadyConditions(
+	conditionTypes := []string{
 		ConditionTypeAMIsReady,
 		ConditionTypeSubnetsReady,
 		ConditionTypeSecurityGroupsReady,
 		ConditionTypeInstanceProfileReady,
 		ConditionTypeValidationSucceeded,
-	).For(in)
+	}
+	// Readiness gates are externally managed conditions which must also be true for the EC2NodeClass to be Ready
+	conditionTypes = append(conditionTypes, lo.Map(in.Spec.ReadinessGates, func(g ReadinessGate, _ int) string {
+		return g.ConditionType
+	})...)
+	return status.NewReadyConditions(lo.Uniq(conditionTypes)...).For(in)
 }
 
 func (in *EC2NodeClass) GetConditions() []status.Condition {
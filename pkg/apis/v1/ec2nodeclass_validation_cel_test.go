@@ -87,7 +87,7 @@ var _ = Describe("CEL/Validation", func() {
 		})
 	})
 	Context("AMIFamily", func() {
-		amiFamilies := []string{v1.AMIFamilyAL2, v1.AMIFamilyAL2023, v1.AMIFamilyBottlerocket, v1.AMIFamilyWindows2019, v1.AMIFamilyWindows2022, v1.AMIFamilyCustom}
+		amiFamilies := []string{v1.AMIFamilyAL2, v1.AMIFamilyAL2023, v1.AMIFamilyBottlerocket, v1.AMIFamilyWindows2019, v1.AMIFamilyWindows2022, v1.AMIFamilyWindows2025, v1.AMIFamilyCustom}
 		DescribeTable("should succeed with valid families", func() []interface{} {
 			f := func(amiFamily string) {
 				// Set a custom AMI family so it's compatible with all ami family types
@@ -210,6 +210,22 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
 		})
+		It("should succeed with up to 44 tags", func() {
+			nc.Spec.Tags = lo.SliceToMap(lo.Range(44), func(i int) (string, string) { return fmt.Sprintf("tag-%d", i), "value" })
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with more than 44 tags", func() {
+			nc.Spec.Tags = lo.SliceToMap(lo.Range(45), func(i int) (string, string) { return fmt.Sprintf("tag-%d", i), "value" })
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+		})
+		It("should fail if a tag key or value exceeds the EC2 limits", func() {
+			nc.Spec.Tags = map[string]string{strings.Repeat("k", 129): "value"}
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{"key": strings.Repeat("v", 257)}
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{strings.Repeat("k", 128): strings.Repeat("v", 256)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
 	})
 	Context("SubnetSelectorTerms", func() {
 		It("should succeed with a valid subnet selector on tags", func() {
@@ -230,6 +246,22 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
+		It("should succeed with a weighted subnet selector", func() {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{Tags: map[string]string{"test": "testvalue"}, Weight: lo.ToPtr[int32](100)},
+				{ID: "subnet-12345749", Weight: lo.ToPtr[int32](1)},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail when the weight of a subnet selector is out of range", func(weight int32) {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{Tags: map[string]string{"test": "testvalue"}, Weight: lo.ToPtr(weight)},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("zero", int32(0)),
+			Entry("above 100", int32(101)),
+		)
 		It("should fail when subnet selector terms is set to nil", func() {
 			nc.Spec.SubnetSelectorTerms = nil
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
@@ -599,6 +631,7 @@ var _ = Describe("CEL/Validation", func() {
 			Entry("bottlerocket (pinned)", "bottlerocket@1.10.0", v1.AMIFamilyBottlerocket),
 			Entry("windows2019 (latest)", "windows2019@latest", v1.AMIFamilyWindows2019),
 			Entry("windows2022 (latest)", "windows2022@latest", v1.AMIFamilyWindows2022),
+			Entry("windows2025 (latest)", "windows2025@latest", v1.AMIFamilyWindows2025),
 		)
 		DescribeTable(
 			"should fail for incorrectly formatted aliases",
@@ -624,6 +657,44 @@ var _ = Describe("CEL/Validation", func() {
 			},
 			Entry("Windows2019", "windows2019@v1.0.0"),
 			Entry("Windows2022", "windows2022@v1.0.0"),
+			Entry("Windows2025", "windows2025@v1.0.0"),
+		)
+		It("should succeed with a fallbackAlias on an id term", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyAL2023)
+			nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-1234749", FallbackAlias: "al2023@latest"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a fallbackAlias of any family when the amiFamily is custom", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyCustom)
+			nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-1234749", FallbackAlias: "bottlerocket@latest"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable(
+			"should fail when specifying fallbackAlias without id",
+			func(term v1.AMISelectorTerm) {
+				nc.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyAL2023)
+				term.FallbackAlias = "al2023@latest"
+				nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{term}
+				Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+			},
+			Entry("tags", v1.AMISelectorTerm{Tags: map[string]string{"test": "testvalue"}}),
+			Entry("name", v1.AMISelectorTerm{Name: "my-custom-ami"}),
+		)
+		It("should fail when the family of the fallbackAlias doesn't match the amiFamily", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyAL2)
+			nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-1234749", FallbackAlias: "al2023@latest"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		DescribeTable(
+			"should fail for invalid fallbackAliases",
+			func(alias string) {
+				nc.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyCustom)
+				nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-1234749", FallbackAlias: alias}}
+				Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+			},
+			Entry("missing version", "al2023@"),
+			Entry("invalid family", "ubuntu@latest"),
+			Entry("pinned Windows version", "windows2022@v1.0.0"),
 		)
 	})
 	Context("Kubelet", func() {
@@ -1060,4 +1131,71 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Update(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("ReadinessGates", func() {
+		It("should succeed when gating on an externally managed condition", func() {
+			nc.Spec.ReadinessGates = []v1.ReadinessGate{{ConditionType: "example.com/ComplianceCheckPassed"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail when gating on a condition managed by Karpenter", func(conditionType string) {
+			nc.Spec.ReadinessGates = []v1.ReadinessGate{{ConditionType: conditionType}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("Ready", "Ready"),
+			Entry(v1.ConditionTypeAMIsReady, v1.ConditionTypeAMIsReady),
+			Entry(v1.ConditionTypeSubnetsReady, v1.ConditionTypeSubnetsReady),
+			Entry(v1.ConditionTypeSecurityGroupsReady, v1.ConditionTypeSecurityGroupsReady),
+			Entry(v1.ConditionTypeInstanceProfileReady, v1.ConditionTypeInstanceProfileReady),
+			Entry(v1.ConditionTypeValidationSucceeded, v1.ConditionTypeValidationSucceeded),
+			Entry(v1.ConditionTypeFastLaunchEnabled, v1.ConditionTypeFastLaunchEnabled),
+			Entry(v1.ConditionTypeRegistriesReachable, v1.ConditionTypeRegistriesReachable),
+			Entry(v1.ConditionTypeLaunchDryRunSucceeded, v1.ConditionTypeLaunchDryRunSucceeded),
+			Entry(v1.ConditionTypeNodeRoleECRPullAllowed, v1.ConditionTypeNodeRoleECRPullAllowed),
+			Entry(v1.ConditionTypeNodeRoleDescribeClusterAllowed, v1.ConditionTypeNodeRoleDescribeClusterAllowed),
+			Entry(v1.ConditionTypeNodeRoleEBSCSIAllowed, v1.ConditionTypeNodeRoleEBSCSIAllowed),
+			Entry(v1.ConditionTypeZonalResourcesValid, v1.ConditionTypeZonalResourcesValid),
+			Entry(v1.ConditionTypeQuotasSufficient, v1.ConditionTypeQuotasSufficient),
+			Entry(v1.ConditionTypeDHCPOptionsCompatible, v1.ConditionTypeDHCPOptionsCompatible),
+			Entry(v1.ConditionTypePinnedAMIsAvailable, v1.ConditionTypePinnedAMIsAvailable),
+		)
+	})
+	Context("PlacementGroup", func() {
+		It("should succeed when the placement group is selected by name", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed when the placement group is selected by tags", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"karpenter.sh/discovery": "test-cluster"}, Partitions: 3}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the placement group is selected by both name and tags", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Tags: map[string]string{"karpenter.sh/discovery": "test-cluster"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the placement group isn't selected", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Partitions: 3}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the placement group is selected by an empty tag", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"karpenter.sh/discovery": ""}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when there are more than 7 partitions", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 8}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("OutpostARN", func() {
+		It("should succeed when the outpost arn is valid", func() {
+			nc.Spec.OutpostARN = lo.ToPtr("arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the outpost arn isn't an outpost", func() {
+			nc.Spec.OutpostARN = lo.ToPtr("arn:aws:ec2:us-west-2:123456789012:subnet/subnet-0123456789abcdef0")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the outpost arn is an outpost id", func() {
+			nc.Spec.OutpostARN = lo.ToPtr("op-0123456789abcdef0")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 })
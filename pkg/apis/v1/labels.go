@@ -30,7 +30,10 @@ func init() {
 	karpv1.RestrictedLabelDomains = karpv1.RestrictedLabelDomains.Insert(RestrictedLabelDomains...)
 	karpv1.WellKnownLabels = karpv1.WellKnownLabels.Insert(
 		LabelInstanceHypervisor,
+		LabelInstanceBareMetal,
 		LabelInstanceEncryptionInTransitSupported,
+		LabelNitroEnclavesSupported,
+		LabelInstanceNetworkAcceleration,
 		LabelInstanceCategory,
 		LabelInstanceFamily,
 		LabelInstanceGeneration,
@@ -41,7 +44,13 @@ func init() {
 		LabelInstanceCPUSustainedClockSpeedMhz,
 		LabelInstanceMemory,
 		LabelInstanceEBSBandwidth,
+		LabelInstanceEBSBaselineThroughput,
+		LabelInstanceEBSMaximumThroughput,
+		LabelInstanceEBSBaselineIOPS,
+		LabelInstanceEBSMaximumIOPS,
 		LabelInstanceNetworkBandwidth,
+		LabelInstanceNetworkCards,
+		LabelInstanceNetworkInterfaces,
 		LabelInstanceGPUName,
 		LabelInstanceGPUManufacturer,
 		LabelInstanceGPUCount,
@@ -50,13 +59,18 @@ func init() {
 		LabelInstanceAcceleratorManufacturer,
 		LabelInstanceAcceleratorCount,
 		LabelTopologyZoneID,
+		LabelTopologyZoneType,
+		LabelPlacementPartition,
+		LabelCapacityBlockID,
+		LabelBatch,
 		corev1.LabelWindowsBuild,
 	)
 }
 
 var (
-	TerminationFinalizer   = apis.Group + "/termination"
-	AWSToKubeArchitectures = map[string]string{
+	TerminationFinalizer      = apis.Group + "/termination"
+	LifecycleWebhookFinalizer = apis.Group + "/lifecycle-webhook"
+	AWSToKubeArchitectures    = map[string]string{
 		"x86_64":                 karpv1.ArchitectureAmd64,
 		karpv1.ArchitectureArm64: karpv1.ArchitectureArm64,
 	}
@@ -75,6 +89,10 @@ var (
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(EKSClusterNameTagKey))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(NodeClassTagKey))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(NodeClaimTagKey))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(WarmPoolTagKey))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(WarmPoolNodeClassHashTagKey))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(WarmPoolNodePoolHashTagKey))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(LaunchTemplateOwnerTagKey))),
 	}
 	AMIFamilyBottlerocket                          = "Bottlerocket"
 	AMIFamilyAL2                                   = "AL2"
@@ -82,12 +100,15 @@ var (
 	AMIFamilyUbuntu                                = "Ubuntu"
 	AMIFamilyWindows2019                           = "Windows2019"
 	AMIFamilyWindows2022                           = "Windows2022"
+	AMIFamilyWindows2025                           = "Windows2025"
 	AMIFamilyCustom                                = "Custom"
 	Windows2019                                    = "2019"
 	Windows2022                                    = "2022"
+	Windows2025                                    = "2025"
 	WindowsCore                                    = "Core"
 	Windows2019Build                               = "10.0.17763"
 	Windows2022Build                               = "10.0.20348"
+	Windows2025Build                               = "10.0.26100"
 	ResourceNVIDIAGPU          corev1.ResourceName = "nvidia.com/gpu"
 	ResourceAMDGPU             corev1.ResourceName = "amd.com/gpu"
 	ResourceAWSNeuron          corev1.ResourceName = "aws.amazon.com/neuron"
@@ -96,13 +117,30 @@ var (
 	ResourceAWSPodENI          corev1.ResourceName = "vpc.amazonaws.com/pod-eni"
 	ResourcePrivateIPv4Address corev1.ResourceName = "vpc.amazonaws.com/PrivateIPv4Address"
 	ResourceEFA                corev1.ResourceName = "vpc.amazonaws.com/efa"
+	ResourceNetworkBandwidth   corev1.ResourceName = "networking.k8s.aws/bandwidth-mbps"
+	ResourceSecondaryENI       corev1.ResourceName = "networking.k8s.aws/secondary-eni"
+	ResourceNetworkCard        corev1.ResourceName = "networking.k8s.aws/network-card"
+	ResourceEBSThroughput      corev1.ResourceName = "storage.k8s.aws/ebs-throughput-mbps"
+	ResourceEBSIOPS            corev1.ResourceName = "storage.k8s.aws/ebs-iops"
 
 	LabelNodeClass = apis.Group + "/ec2nodeclass"
 
 	LabelTopologyZoneID = "topology.k8s.aws/zone-id"
+	// LabelTopologyZoneType distinguishes Availability Zones from Local Zones and Wavelength Zones
+	LabelTopologyZoneType = apis.Group + "/zone-type"
+
+	LabelPlacementPartition = apis.Group + "/placement-partition"
+
+	LabelCapacityBlockID = apis.Group + "/capacity-block-id"
+
+	LabelNodePoolTemplate = apis.Group + "/nodepool-template"
+	LabelBatch            = apis.Group + "/batch"
 
 	LabelInstanceHypervisor                   = apis.Group + "/instance-hypervisor"
+	LabelInstanceBareMetal                    = apis.Group + "/instance-baremetal"
 	LabelInstanceEncryptionInTransitSupported = apis.Group + "/instance-encryption-in-transit-supported"
+	LabelNitroEnclavesSupported               = apis.Group + "/nitro-enclaves-supported"
+	LabelInstanceNetworkAcceleration          = apis.Group + "/instance-network-acceleration"
 	LabelInstanceCategory                     = apis.Group + "/instance-category"
 	LabelInstanceFamily                       = apis.Group + "/instance-family"
 	LabelInstanceGeneration                   = apis.Group + "/instance-generation"
@@ -113,7 +151,13 @@ var (
 	LabelInstanceCPUSustainedClockSpeedMhz    = apis.Group + "/instance-cpu-sustained-clock-speed-mhz"
 	LabelInstanceMemory                       = apis.Group + "/instance-memory"
 	LabelInstanceEBSBandwidth                 = apis.Group + "/instance-ebs-bandwidth"
+	LabelInstanceEBSBaselineThroughput        = apis.Group + "/instance-ebs-baseline-throughput"
+	LabelInstanceEBSMaximumThroughput         = apis.Group + "/instance-ebs-maximum-throughput"
+	LabelInstanceEBSBaselineIOPS              = apis.Group + "/instance-ebs-baseline-iops"
+	LabelInstanceEBSMaximumIOPS               = apis.Group + "/instance-ebs-maximum-iops"
 	LabelInstanceNetworkBandwidth             = apis.Group + "/instance-network-bandwidth"
+	LabelInstanceNetworkCards                 = apis.Group + "/instance-network-cards"
+	LabelInstanceNetworkInterfaces            = apis.Group + "/instance-network-interfaces"
 	LabelInstanceGPUName                      = apis.Group + "/instance-gpu-name"
 	LabelInstanceGPUManufacturer              = apis.Group + "/instance-gpu-manufacturer"
 	LabelInstanceGPUCount                     = apis.Group + "/instance-gpu-count"
@@ -125,11 +169,72 @@ var (
 	AnnotationClusterNameTaggedCompatability  = apis.CompatibilityGroup + "/cluster-name-tagged"
 	AnnotationEC2NodeClassHashVersion         = apis.Group + "/ec2nodeclass-hash-version"
 	AnnotationInstanceTagged                  = apis.Group + "/tagged"
+	AnnotationLicenseConfigurationARN         = apis.Group + "/license-configuration-arn"
+	AnnotationInstancePreferences             = apis.Group + "/instance-preferences"
+	AnnotationDoNotDisruptSynced              = apis.Group + "/do-not-disrupt-synced"
+	AnnotationCircuitBreakerTripped           = apis.Group + "/circuit-breaker-tripped"
+	AnnotationCircuitBreakerAcknowledged      = apis.Group + "/circuit-breaker-acknowledged"
+	AnnotationCircuitBreakerPaused            = apis.Group + "/circuit-breaker-paused"
+	AnnotationCircuitBreakerDeletions         = apis.Group + "/circuit-breaker-deletions"
+	AnnotationIdleSince                       = apis.Group + "/idle-since"
+	AnnotationSSHKeyName                      = apis.Group + "/ssh-key-name"
+	AnnotationWarmPoolSize                    = apis.Group + "/warm-pool-size"
+	AnnotationRollRequestedAt                 = apis.Group + "/roll-requested-at"
+	AnnotationLifecycleWebhookEvents          = apis.Group + "/lifecycle-webhook-events"
+	AnnotationMaxNodeCPU                      = apis.Group + "/max-node-cpu"
+	AnnotationMaxNodeMemory                   = apis.Group + "/max-node-memory"
+	AnnotationMinSpotPools                    = apis.Group + "/min-spot-pools"
+	AnnotationSpotRatio                       = apis.Group + "/spot-ratio"
+	AnnotationEC2NodeClassHashWithoutVolumes  = apis.Group + "/ec2nodeclass-hash-without-volume-sizes"
+	AnnotationVolumeResizeRequested           = apis.Group + "/volume-resize-requested"
+	AnnotationRebootAfter                     = apis.Group + "/reboot-after"
+	AnnotationRebootedAt                      = apis.Group + "/rebooted-at"
+	AnnotationBootDurationObserved            = apis.Group + "/boot-duration-observed"
+	AnnotationRegistrationDurationObserved    = apis.Group + "/registration-duration-observed"
+	AnnotationInstanceTypeSummary             = apis.Group + "/instance-type-summary"
+	AnnotationLaunchClientToken               = apis.Group + "/launch-client-token"
+
+	NodeClaimTagKey                = coreapis.Group + "/nodeclaim"
+	NameTagKey                     = "Name"
+	NodePoolTagKey                 = karpv1.NodePoolLabelKey
+	NodeClassTagKey                = LabelNodeClass
+	LaunchTemplateNamePrefix       = apis.Group
+	EKSClusterNameTagKey           = "eks:eks-cluster-name"
+	DoNotDisruptTagKey             = karpv1.DoNotDisruptAnnotationKey
+	BatchTagKey                    = LabelBatch
+	WarmPoolTagKey                 = apis.Group + "/warm-pool"
+	WarmPoolNodeClassHashTagKey    = apis.Group + "/warm-pool-ec2nodeclass-hash"
+	WarmPoolNodePoolHashTagKey     = apis.Group + "/warm-pool-nodepool-hash"
+	TerminationProtectionTagKey    = apis.Group + "/termination-protection"
+	LaunchClientTokenTagKey        = AnnotationLaunchClientToken
+	CostAttributionNamespaceTagKey = apis.Group + "/cost-attribution-namespace"
+	DiscoveryTagKey                = coreapis.Group + "/discovery"
+	ExcludeTagKey                  = coreapis.Group + "/exclude"
+
+	LaunchTemplateOwnerTagKey = apis.Group + "/launch-template-owner"
+)
+
+// StandbyTaint keeps pods from binding to the nodes of warm pool standby instances. Standby instances register with it,
+// and it's removed once the instance is resumed for a NodeClaim.
+var StandbyTaint = corev1.Taint{
+	Key:    apis.Group + "/standby",
+	Effect: corev1.TaintEffectNoSchedule,
+}
+
+// CapacityTypeCapacityBlock is the value of the capacity-type label of instances which are launched into an EC2
+// Capacity Block for ML
+const CapacityTypeCapacityBlock = "capacity-block"
+
+// Values of the zone-type label, which match the zone types of EC2
+const (
+	ZoneTypeAvailabilityZone = "availability-zone"
+	ZoneTypeLocalZone        = "local-zone"
+	ZoneTypeWavelengthZone   = "wavelength-zone"
+)
 
-	NodeClaimTagKey          = coreapis.Group + "/nodeclaim"
-	NameTagKey               = "Name"
-	NodePoolTagKey           = karpv1.NodePoolLabelKey
-	NodeClassTagKey          = LabelNodeClass
-	LaunchTemplateNamePrefix = apis.Group
-	EKSClusterNameTagKey     = "eks:eks-cluster-name"
+// Values of the instance-network-acceleration label
+const (
+	NetworkAccelerationENA        = "ena"
+	NetworkAccelerationENAExpress = "ena-express"
+	NetworkAccelerationEFA        = "efa"
 )
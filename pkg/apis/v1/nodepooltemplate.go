@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// NodePoolTemplateSpec is the specification of the NodePools which are created for each batch of pods which select
+// the NodePoolTemplate. Pods select a NodePoolTemplate with the karpenter.k8s.aws/nodepool-template label, and their
+// batch with a karpenter.k8s.aws/batch node selector.
+type NodePoolTemplateSpec struct {
+	// NodePool is the specification of the NodePools which are created for each batch. The NodePools reference a copy
+	// of the EC2NodeClass referenced by this specification, which is created for each batch.
+	// +kubebuilder:validation:XValidation:message="nodeClassRef must reference an EC2NodeClass",rule="self.template.spec.nodeClassRef.group == 'karpenter.k8s.aws' && self.template.spec.nodeClassRef.kind == 'EC2NodeClass'"
+	// +required
+	NodePool karpv1.NodePoolSpec `json:"nodePool"`
+	// NodeClass contains the fields which are overridden in the copy of the EC2NodeClass which is created for each batch
+	// +optional
+	NodeClass *NodeClassOverrides `json:"nodeClass,omitempty"`
+	// TTLAfterIdle is the duration after which the NodePool and EC2NodeClass of a batch are deleted, once the batch has
+	// no pods which haven't completed and no nodes.
+	// +kubebuilder:validation:Pattern=`^(([0-9]+(s|m|h))+)$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="10m"
+	// +optional
+	TTLAfterIdle metav1.Duration `json:"ttlAfterIdle,omitempty"`
+}
+
+// NodeClassOverrides are the fields of an EC2NodeClass which can be overridden for the batches of a NodePoolTemplate
+type NodeClassOverrides struct {
+	// Tags are merged over the tags of the EC2NodeClass, so that the resources of each batch can be attributed to it.
+	// The karpenter.k8s.aws/batch tag is always applied with the name of the batch.
+	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
+	// +kubebuilder:validation:XValidation:message="tag keys can't exceed 128 characters and tag values can't exceed 256 characters",rule="self.all(k, size(k) <= 128 && size(self[k]) <= 256)"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching eks:eks-cluster-name",rule="self.all(k, k !='eks:eks-cluster-name')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !k.startsWith('kubernetes.io/cluster') )"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
+	// +kubebuilder:validation:MaxProperties:=43
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// NodePoolTemplateStatus contains the resolved state of the NodePoolTemplate
+type NodePoolTemplateStatus struct {
+	// NodePools are the names of the NodePools which have been created for the batches of the NodePoolTemplate
+	// +optional
+	NodePools []string `json:"nodePools,omitempty"`
+}
+
+// NodePoolTemplate is the Schema for the NodePoolTemplate API. A NodePoolTemplate stamps out a short-lived NodePool
+// and EC2NodeClass for each batch of pods which selects it, and deletes them once the batch is idle.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="NodeClass",type="string",JSONPath=".spec.nodePool.template.spec.nodeClassRef.name",description=""
+// +kubebuilder:printcolumn:name="TTLAfterIdle",type="string",JSONPath=".spec.ttlAfterIdle",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="NodePools",type="string",JSONPath=".status.nodePools",priority=1,description=""
+// +kubebuilder:resource:path=nodepooltemplates,scope=Cluster,categories=karpenter,shortName={nptemplate,nptemplates}
+// +kubebuilder:subresource:status
+type NodePoolTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodePoolTemplateSpec   `json:"spec,omitempty"`
+	Status NodePoolTemplateStatus `json:"status,omitempty"`
+}
+
+// NodePoolTemplateList contains a list of NodePoolTemplate
+// +kubebuilder:object:root=true
+type NodePoolTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodePoolTemplate `json:"items"`
+}
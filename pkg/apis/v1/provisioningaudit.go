@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The actions that are recorded in a ProvisioningAudit
+const (
+	AuditActionLaunched   = "Launched"
+	AuditActionDisrupted  = "Disrupted"
+	AuditActionTerminated = "Terminated"
+)
+
+// ProvisioningAuditSpec identifies the NodePool whose actions are recorded
+type ProvisioningAuditSpec struct {
+	// NodePool is the name of the NodePool whose actions are recorded
+	// +kubebuilder:validation:XValidation:message="nodePool is immutable",rule="self == oldSelf"
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	NodePool string `json:"nodePool"`
+}
+
+// ProvisioningAuditEntry is an action that Karpenter took on a NodeClaim of the NodePool
+type ProvisioningAuditEntry struct {
+	// Time is the time at which the action was taken
+	Time metav1.Time `json:"time"`
+	// Action is the action that was taken, one of Launched, Disrupted or Terminated
+	// +kubebuilder:validation:Enum:={Launched,Disrupted,Terminated}
+	Action string `json:"action"`
+	// Reason is the reason that the action was taken, e.g. the disruption reason of a disrupted NodeClaim
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// NodeClaim is the name of the NodeClaim that the action was taken on
+	NodeClaim string `json:"nodeClaim"`
+	// Node is the name of the node of the NodeClaim
+	// +optional
+	Node string `json:"node,omitempty"`
+	// InstanceID is the ID of the EC2 instance of the NodeClaim
+	// +optional
+	InstanceID string `json:"instanceID,omitempty"`
+	// InstanceType is the instance type of the EC2 instance
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// CapacityType is the capacity type of the EC2 instance
+	// +optional
+	CapacityType string `json:"capacityType,omitempty"`
+	// Zone is the availability zone of the EC2 instance
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// ImageID is the ID of the AMI that the EC2 instance was launched with
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+}
+
+// ProvisioningAuditStatus contains the most recent actions that Karpenter took on the NodeClaims of the NodePool
+type ProvisioningAuditStatus struct {
+	// Entries are the most recent actions, ordered from oldest to newest. The oldest entries are discarded once the
+	// number of entries exceeds the configured size.
+	// +optional
+	Entries []ProvisioningAuditEntry `json:"entries,omitempty"`
+}
+
+// ProvisioningAudit is the Schema for the ProvisioningAudit API. A ProvisioningAudit records a bounded history of the
+// launches, disruptions and terminations of the NodeClaims of a NodePool, so that recent actions can be audited without
+// querying the logs of the controller.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="NodePool",type="string",JSONPath=".spec.nodePool",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:resource:path=provisioningaudits,scope=Cluster,categories=karpenter
+// +kubebuilder:subresource:status
+type ProvisioningAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProvisioningAuditSpec   `json:"spec,omitempty"`
+	Status ProvisioningAuditStatus `json:"status,omitempty"`
+}
+
+// ProvisioningAuditList contains a list of ProvisioningAudit
+// +kubebuilder:object:root=true
+type ProvisioningAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProvisioningAudit `json:"items"`
+}
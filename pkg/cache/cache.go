@@ -41,6 +41,20 @@ const (
 	// DiscoveredCapacityCacheTTL is the time to drop discovered resource capacity data per-instance type
 	// if it is not updated by a node creation event or refreshed during controller reconciliation
 	DiscoveredCapacityCacheTTL = 60 * 24 * time.Hour
+	// SpotPlacementScoresTTL is the time before the spot placement scores of an instance family are refreshed. Scores
+	// change slowly, and the number of distinct requests that can be made to GetSpotPlacementScores is limited.
+	SpotPlacementScoresTTL = 15 * time.Minute
+	// SpotPlacementScoreFamiliesTTL is the time that the spot placement scores of an instance family keep being refreshed
+	// after spot instances of the family were last launched
+	SpotPlacementScoreFamiliesTTL = 24 * time.Hour
+	// ServiceQuotasTTL is the time before the applied values of service quotas are refreshed. Quota increases are
+	// requested through support and applied infrequently.
+	ServiceQuotasTTL = time.Hour
+	// HandledInterruptionMessagesTTL is the time that the IDs of handled interruption events are remembered for, so that
+	// events which are delivered to multiple interruption queues are only handled once
+	HandledInterruptionMessagesTTL = 10 * time.Minute
+	// FastLaunchFailureTTL is the time before enabling EC2 Fast Launch is retried for an AMI which it failed for
+	FastLaunchFailureTTL = 30 * time.Minute
 )
 
 const (
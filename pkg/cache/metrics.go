@@ -0,0 +1,58 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	reasonLabel            = "reason"
+	capacityTypeLabel      = "capacity_type"
+)
+
+var (
+	UnavailableOfferingsMarkedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "unavailable_offerings_marked_total",
+			Help:      "Number of times that an offering was marked as unavailable for launch, e.g. because EC2 returned InsufficientInstanceCapacity for it. Labeled by reason and capacity type.",
+		},
+		[]string{
+			reasonLabel,
+			capacityTypeLabel,
+		},
+	)
+	UnavailableOfferingsCount = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "unavailable_offerings",
+			Help:      "The number of offerings which are currently unavailable for launch. Labeled by the reason that they were last marked as unavailable for and capacity type.",
+		},
+		[]string{
+			reasonLabel,
+			capacityTypeLabel,
+		},
+	)
+)
@@ -0,0 +1,116 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cache")
+}
+
+var _ = Describe("UnavailableOfferings", func() {
+	var unavailableOfferings *awscache.UnavailableOfferings
+	serve := func() []awscache.UnavailableOffering {
+		recorder := httptest.NewRecorder()
+		unavailableOfferings.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, awscache.UnavailableOfferingsDebugPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		var offerings []awscache.UnavailableOffering
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &offerings)).To(Succeed())
+		return offerings
+	}
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+		unavailableOfferings = awscache.NewUnavailableOfferings()
+		awscache.UnavailableOfferingsMarkedTotal.Reset()
+		awscache.UnavailableOfferingsCount.Reset()
+	})
+
+	It("should mark offerings as unavailable for the default TTL", func() {
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		Expect(unavailableOfferings.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+		Expect(unavailableOfferings.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+
+		offerings := unavailableOfferings.List()
+		Expect(offerings).To(HaveLen(1))
+		Expect(offerings[0].ExpiresAt.Sub(offerings[0].MarkedAt)).To(Equal(awscache.UnavailableOfferingsTTL))
+	})
+	It("should mark offerings as unavailable for the TTL of the reason", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{UnavailableOfferingsTTLs: lo.ToPtr("InsufficientInstanceCapacity=10m")}))
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		unavailableOfferings.MarkUnavailable(ctx, "VcpuLimitExceeded", "c5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+
+		offerings := unavailableOfferings.List()
+		Expect(offerings).To(HaveLen(2))
+		ttls := lo.SliceToMap(offerings, func(o awscache.UnavailableOffering) (string, time.Duration) {
+			return o.Reason, o.ExpiresAt.Sub(o.MarkedAt)
+		})
+		Expect(ttls).To(Equal(map[string]time.Duration{"InsufficientInstanceCapacity": 10 * time.Minute, "VcpuLimitExceeded": awscache.UnavailableOfferingsTTL}))
+	})
+	It("should serve the unavailable offerings", func() {
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1b", karpv1.CapacityTypeSpot)
+		unavailableOfferings.MarkUnavailable(ctx, "spot_interrupted", "c5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+
+		offerings := serve()
+		Expect(offerings).To(HaveLen(2))
+		Expect(offerings[0].InstanceType).To(Equal("c5.large"))
+		Expect(offerings[0].Zone).To(Equal("test-zone-1a"))
+		Expect(offerings[0].CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+		Expect(offerings[0].Reason).To(Equal("spot_interrupted"))
+		Expect(offerings[1].InstanceType).To(Equal("m5.large"))
+		Expect(offerings[1].Reason).To(Equal("InsufficientInstanceCapacity"))
+	})
+	It("should count the offerings which are marked as unavailable", func() {
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1b", karpv1.CapacityTypeSpot)
+
+		ExpectMetricCounterValue(awscache.UnavailableOfferingsMarkedTotal, 3, map[string]string{"reason": "InsufficientInstanceCapacity", "capacity_type": karpv1.CapacityTypeSpot})
+		ExpectMetricGaugeValue(awscache.UnavailableOfferingsCount, 2, map[string]string{"reason": "InsufficientInstanceCapacity", "capacity_type": karpv1.CapacityTypeSpot})
+	})
+	It("should stop counting offerings which are available again", func() {
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		unavailableOfferings.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1b", karpv1.CapacityTypeSpot)
+		unavailableOfferings.Delete("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectMetricGaugeValue(awscache.UnavailableOfferingsCount, 1, map[string]string{"reason": "InsufficientInstanceCapacity", "capacity_type": karpv1.CapacityTypeSpot})
+
+		unavailableOfferings.Flush()
+		Expect(serve()).To(BeEmpty())
+		_, found := FindMetricWithLabelValues("karpenter_cloudprovider_unavailable_offerings", map[string]string{"reason": "InsufficientInstanceCapacity", "capacity_type": karpv1.CapacityTypeSpot})
+		Expect(found).To(BeFalse())
+	})
+})
@@ -16,8 +16,13 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -25,15 +30,33 @@ import (
 
 	"github.com/patrickmn/go-cache"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
+// UnavailableOfferingsDebugPath is the path of the metrics server that the unavailable offerings are served at
+const UnavailableOfferingsDebugPath = "/debug/offerings"
+
 // UnavailableOfferings stores any offerings that return ICE (insufficient capacity errors) when
 // attempting to launch the capacity. These offerings are ignored as long as they are in the cache on
 // GetInstanceTypes responses
 type UnavailableOfferings struct {
-	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
+	// key: <capacityType>:<instanceType>:<zone>, value: UnavailableOffering
 	cache  *cache.Cache
 	SeqNum uint64
+
+	// mu serializes the updates of the unavailable offerings gauge, which is recounted from the cache
+	mu sync.Mutex
+}
+
+// UnavailableOffering is an offering which is unavailable for launch until it expires
+type UnavailableOffering struct {
+	InstanceType string    `json:"instanceType"`
+	Zone         string    `json:"zone"`
+	CapacityType string    `json:"capacityType"`
+	Reason       string    `json:"reason"`
+	MarkedAt     time.Time `json:"markedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
 }
 
 func NewUnavailableOfferings() *UnavailableOfferings {
@@ -43,6 +66,7 @@ func NewUnavailableOfferings() *UnavailableOfferings {
 	}
 	uo.cache.OnEvicted(func(_ string, _ interface{}) {
 		atomic.AddUint64(&uo.SeqNum, 1)
+		uo.recordUnavailableOfferings()
 	})
 	return uo
 }
@@ -53,17 +77,33 @@ func (u *UnavailableOfferings) IsUnavailable(instanceType ec2types.InstanceType,
 	return found
 }
 
-// MarkUnavailable communicates recently observed temporary capacity shortages in the provided offerings
+// MarkUnavailable communicates recently observed temporary capacity shortages in the provided offerings. The offering
+// is unavailable for the TTL that's configured for the reason in unavailable-offerings-ttls, or for
+// UnavailableOfferingsTTL if none is configured.
 func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, unavailableReason string, instanceType ec2types.InstanceType, zone, capacityType string) {
+	ttl, ok := options.FromContext(ctx).UnavailableOfferingsTTL(unavailableReason)
+	if !ok {
+		ttl = UnavailableOfferingsTTL
+	}
 	// even if the key is already in the cache, we still need to call Set to extend the cached entry's TTL
 	log.FromContext(ctx).WithValues(
 		"reason", unavailableReason,
 		"instance-type", instanceType,
 		"zone", zone,
 		"capacity-type", capacityType,
-		"ttl", UnavailableOfferingsTTL).V(1).Info("removing offering from offerings")
-	u.cache.SetDefault(u.key(instanceType, zone, capacityType), struct{}{})
+		"ttl", ttl).V(1).Info("removing offering from offerings")
+	now := time.Now()
+	u.cache.Set(u.key(instanceType, zone, capacityType), UnavailableOffering{
+		InstanceType: string(instanceType),
+		Zone:         zone,
+		CapacityType: capacityType,
+		Reason:       unavailableReason,
+		MarkedAt:     now,
+		ExpiresAt:    now.Add(ttl),
+	}, ttl)
 	atomic.AddUint64(&u.SeqNum, 1)
+	UnavailableOfferingsMarkedTotal.Inc(map[string]string{reasonLabel: unavailableReason, capacityTypeLabel: capacityType})
+	u.recordUnavailableOfferings()
 }
 
 func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, fleetErr ec2types.CreateFleetError, capacityType string) {
@@ -78,6 +118,34 @@ func (u *UnavailableOfferings) Delete(instanceType ec2types.InstanceType, zone s
 
 func (u *UnavailableOfferings) Flush() {
 	u.cache.Flush()
+	u.recordUnavailableOfferings()
+}
+
+// List returns the offerings which are currently unavailable, sorted by capacity type, instance type and zone
+func (u *UnavailableOfferings) List() []UnavailableOffering {
+	items := u.cache.Items()
+	keys := lo.Keys(items)
+	sort.Strings(keys)
+	return lo.Map(keys, func(key string, _ int) UnavailableOffering { return items[key].Object.(UnavailableOffering) })
+}
+
+// ServeHTTP serves the offerings which are currently unavailable, so that operators can see why instance types aren't
+// being launched in a zone
+func (u *UnavailableOfferings) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(u.List())
+}
+
+// recordUnavailableOfferings recounts the unavailable offerings of each reason and capacity type
+func (u *UnavailableOfferings) recordUnavailableOfferings() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	UnavailableOfferingsCount.Reset()
+	for labels, count := range lo.CountValuesBy(u.List(), func(o UnavailableOffering) [2]string {
+		return [2]string{o.Reason, o.CapacityType}
+	}) {
+		UnavailableOfferingsCount.Set(float64(count), map[string]string{reasonLabel: labels[0], capacityTypeLabel: labels[1]})
+	}
 }
 
 // key returns the cache key for all offerings in the cache
@@ -42,9 +42,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cloudproviderevents "github.com/aws/karpenter-provider-aws/pkg/cloudprovider/events"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
@@ -96,18 +98,30 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	if nodeClassReady.IsUnknown() {
 		return nil, cloudprovider.NewCreateError(fmt.Errorf("resolving NodeClass readiness, NodeClass is in Ready=Unknown, %s", nodeClassReady.Message), "NodeClass is in Ready=Unknown")
 	}
+	// The hash is taken before the profile's defaults are applied, so that it matches the hash of the EC2NodeClass
+	nodeClassHash := nodeClass.Hash()
+	nodeClassHashWithoutVolumes := nodeClass.HashWithoutVolumeSizes()
+	nodeClass.SetDefaults(ctx)
+	start := time.Now()
 	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
+	instance.ObserveLaunchPhase(nodeClaim.Labels[karpv1.NodePoolLabelKey], instance.PhaseOfferingResolution, time.Since(start))
 	if err != nil {
 		return nil, cloudprovider.NewCreateError(fmt.Errorf("resolving instance types, %w", err), "Error resolving instance types")
 	}
 	if len(instanceTypes) == 0 {
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested instance types were unavailable during launch"))
 	}
-	tags, err := getTags(ctx, nodeClass, nodeClaim)
+	if instanceTypes, err = c.balanceNodePool(ctx, nodeClaim, instanceTypes); err != nil {
+		return nil, cloudprovider.NewCreateError(fmt.Errorf("balancing nodepool, %w", err), "Error balancing NodePool")
+	}
+	tags, err := instance.Tags(ctx, nodeClass, nodeClaim)
 	if err != nil {
 		return nil, cloudprovider.NewNodeClassNotReadyError(err)
 	}
-	instance, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, tags, instanceTypes)
+	instance := c.resumeStandby(ctx, nodeClaim, nodeClassHash, instanceTypes)
+	if instance == nil {
+		instance, err = c.launch(ctx, nodeClass, nodeClaim, tags, instanceTypes)
+	}
 	if err != nil {
 		conditionMessage := "Error creating instance"
 		var createError *cloudprovider.CreateError
@@ -121,12 +135,117 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	})
 	nc := c.instanceToNodeClaim(instance, instanceType, nodeClass)
 	nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-		v1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
-		v1.AnnotationEC2NodeClassHashVersion: v1.EC2NodeClassHashVersion,
+		v1.AnnotationEC2NodeClassHash:               nodeClassHash,
+		v1.AnnotationEC2NodeClassHashVersion:        v1.EC2NodeClassHashVersion,
+		v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClassHashWithoutVolumes,
 	})
+	// Nodes which accept SSH connections are annotated so that they can be audited from the cluster
+	if nodeClass.Spec.KeyName != nil {
+		nc.Annotations[v1.AnnotationSSHKeyName] = lo.FromPtr(nodeClass.Spec.KeyName)
+	}
 	return nc, nil
 }
 
+// launch launches an instance for the NodeClaim. When launches are journaled, the client token of the launch is recorded
+// on the NodeClaim before the instance is launched, so that a launch which is retried after the controller restarted
+// mid-launch adopts the instance that was launched with the token rather than launching another.
+func (c *CloudProvider) launch(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, tags map[string]string, instanceTypes []*cloudprovider.InstanceType) (*instance.Instance, error) {
+	if !options.FromContext(ctx).FeatureGates.LaunchJournal {
+		return c.instanceProvider.Create(ctx, nodeClass, nodeClaim, tags, instanceTypes)
+	}
+	journaled := nodeClaim
+	if token, ok := nodeClaim.Annotations[v1.AnnotationLaunchClientToken]; ok {
+		launched, err := c.instanceProvider.GetByClientToken(ctx, token)
+		if err == nil {
+			log.FromContext(ctx).WithValues("instance", launched.ID, "client-token", token).Info("adopted instance of journaled launch")
+			return launched, nil
+		}
+		if !cloudprovider.IsNodeClaimNotFoundError(err) {
+			return nil, fmt.Errorf("getting instance of journaled launch, %w", err)
+		}
+	} else {
+		journaled = nodeClaim.DeepCopy()
+		journaled.Annotations = lo.Assign(journaled.Annotations, map[string]string{v1.AnnotationLaunchClientToken: string(uuid.NewUUID())})
+		if err := c.kubeClient.Patch(ctx, journaled, client.MergeFrom(nodeClaim)); err != nil {
+			return nil, fmt.Errorf("recording launch in journal, %w", err)
+		}
+	}
+	launched, err := c.instanceProvider.Create(ctx, nodeClass, journaled, tags, instanceTypes)
+	if err != nil {
+		// A launch which EC2 rejected for its configuration or for a lack of capacity didn't launch an instance, so it's
+		// removed from the journal for the next attempt to be made with a new client token. Other failures, e.g. timeouts
+		// or internal and throttling errors, don't prove that no instance was launched, so they keep the token for the
+		// next attempt to reconcile whether the instance was launched.
+		if !awserrors.IsTerminal(err) && !cloudprovider.IsInsufficientCapacityError(err) && awserrors.Classify(err) != awserrors.ClassInsufficientCapacity {
+			return nil, err
+		}
+		cleared := journaled.DeepCopy()
+		delete(cleared.Annotations, v1.AnnotationLaunchClientToken)
+		if patchErr := c.kubeClient.Patch(ctx, cleared, client.MergeFrom(journaled)); patchErr != nil {
+			log.FromContext(ctx).Error(patchErr, "failed removing launch from journal")
+		}
+		return nil, err
+	}
+	return launched, nil
+}
+
+// resumeStandby resumes a standby instance from the warm pool of the NodeClaim's NodePool, if the NodePool has a warm
+// pool. Failures to resume a standby instance aren't fatal, since a new instance can be launched instead.
+func (c *CloudProvider) resumeStandby(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClassHash string, instanceTypes []*cloudprovider.InstanceType) *instance.Instance {
+	if !options.FromContext(ctx).FeatureGates.WarmPools {
+		return nil
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Labels[karpv1.NodePoolLabelKey]}, nodePool); err != nil {
+		return nil
+	}
+	if size, err := instance.WarmPoolSize(nodePool); err != nil || size == 0 {
+		return nil
+	}
+	standby, err := c.instanceProvider.Resume(ctx, nodeClaim, nodeClassHash, nodePool.Hash(), instanceTypes)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed resuming standby instance, launching a new instance")
+		return nil
+	}
+	return standby
+}
+
+// balanceNodePool narrows the instance types so that the NodeClaims of the NodeClaim's NodePool move towards the spot
+// ratio of the NodePool, and so that its spot NodeClaims are spread across the minimum number of spot pools of the
+// NodePool. Replacements which are launched by consolidation go through the same path, so they keep the NodePool
+// balanced.
+func (c *CloudProvider) balanceNodePool(ctx context.Context, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, error) {
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Labels[karpv1.NodePoolLabelKey]}, nodePool); err != nil {
+		return instanceTypes, client.IgnoreNotFound(err)
+	}
+	spotRatio, hasSpotRatio, err := instance.SpotRatio(nodePool)
+	if err != nil {
+		return nil, err
+	}
+	minPools, err := instance.MinSpotPools(nodePool)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSpotRatio && minPools <= 1 {
+		return instanceTypes, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	others := lo.Reject(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) bool { return nc.Name == nodeClaim.Name })
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if hasSpotRatio {
+		spot, onDemand := instance.CapacityTypeCPUs(others)
+		instanceTypes = instance.BalanceCapacityTypes(spotRatio, spot, onDemand, reqs, instanceTypes)
+	}
+	if minPools > 1 {
+		instanceTypes = instance.DiversifySpotPools(minPools, others, reqs, instanceTypes)
+	}
+	return instanceTypes, nil
+}
+
 func (c *CloudProvider) List(ctx context.Context) ([]*karpv1.NodeClaim, error) {
 	instances, err := c.instanceProvider.List(ctx)
 	if err != nil {
@@ -180,11 +299,26 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *karpv1.N
 		// as the cause.
 		return nil, fmt.Errorf("resolving node class, %w", err)
 	}
+	nodeClass.SetDefaults(ctx)
 	// TODO, break this coupling
 	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodeClass)
 	if err != nil {
 		return nil, err
 	}
+	if options.FromContext(ctx).ExcludePreviousGenerationFamilies {
+		instanceTypes = instancetype.ExcludePreviousGeneration(nodePool, instanceTypes)
+	}
+	// Malformed maximum node size annotations are ignored rather than blocking the NodePool from launching
+	maxSize, err := instancetype.MaxNodeSize(nodePool)
+	if err != nil {
+		c.recorder.Publish(cloudproviderevents.NodePoolInvalidMaxNodeSize(nodePool, err))
+	}
+	if len(maxSize) > 0 {
+		var excluded []string
+		if instanceTypes, excluded = instancetype.ExcludeAboveMaxNodeSize(maxSize, instanceTypes); len(excluded) > 0 {
+			c.recorder.Publish(cloudproviderevents.NodePoolInstanceTypesAboveMaxNodeSize(nodePool, maxSize, excluded))
+		}
+	}
 	return instanceTypes, nil
 }
 
@@ -214,6 +348,9 @@ func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeCla
 	if nodePool.Spec.Template.Spec.NodeClassRef == nil {
 		return "", nil
 	}
+	if rolled := c.isNodePoolRolled(nodeClaim, nodePool); rolled != "" {
+		return rolled, nil
+	}
 	nodeClass, err := c.resolveNodeClassFromNodePool(ctx, nodePool)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -225,6 +362,11 @@ func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeCla
 	if err != nil {
 		return "", err
 	}
+	if driftReason == "" && options.FromContext(ctx).FeatureGates.SpotPriceDrift {
+		if driftReason, err = c.isSpotPriceDrifted(ctx, nodeClaim, nodePool); err != nil {
+			return "", fmt.Errorf("calculating spot price drift, %w", err)
+		}
+	}
 	return driftReason, nil
 }
 
@@ -237,26 +379,6 @@ func (c *CloudProvider) GetSupportedNodeClasses() []status.Object {
 	return []status.Object{&v1.EC2NodeClass{}}
 }
 
-func getTags(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim) (map[string]string, error) {
-	if offendingTag, found := lo.FindKeyBy(nodeClass.Spec.Tags, func(k string, v string) bool {
-		for _, exp := range v1.RestrictedTagPatterns {
-			if exp.MatchString(k) {
-				return true
-			}
-		}
-		return false
-	}); found {
-		return nil, fmt.Errorf("%q tag does not pass tag validation requirements", offendingTag)
-	}
-	staticTags := map[string]string{
-		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
-		karpv1.NodePoolLabelKey: nodeClaim.Labels[karpv1.NodePoolLabelKey],
-		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
-		v1.LabelNodeClass:       nodeClass.Name,
-	}
-	return lo.Assign(nodeClass.Spec.Tags, staticTags), nil
-}
-
 func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
 	return []cloudprovider.RepairPolicy{
 		// Supported Kubelet Node Conditions
@@ -410,6 +532,13 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 		}
 		nodeClaim.Status.Capacity = lo.PickBy(instanceType.Capacity, resourceFilter)
 		nodeClaim.Status.Allocatable = lo.PickBy(instanceType.Allocatable(), resourceFilter)
+		// The zone type varies between the zones that the instance type is offered in, so it's resolved from the
+		// offerings in the instance's zone
+		if offering, ok := lo.Find(instanceType.Offerings, func(o cloudprovider.Offering) bool {
+			return o.Requirements.Get(corev1.LabelTopologyZone).Any() == i.Zone && o.Requirements.Has(v1.LabelTopologyZoneType)
+		}); ok {
+			labels[v1.LabelTopologyZoneType] = offering.Requirements.Get(v1.LabelTopologyZoneType).Any()
+		}
 	}
 	labels[corev1.LabelTopologyZone] = i.Zone
 	// Attempt to resolve the zoneID from the instance's EC2NodeClass' status condition.
@@ -424,9 +553,18 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 		}
 	}
 	labels[karpv1.CapacityTypeLabelKey] = i.CapacityType
+	if i.PartitionNumber != 0 {
+		labels[v1.LabelPlacementPartition] = fmt.Sprint(i.PartitionNumber)
+	}
+	if i.CapacityBlockID != "" {
+		labels[v1.LabelCapacityBlockID] = i.CapacityBlockID
+	}
 	if v, ok := i.Tags[karpv1.NodePoolLabelKey]; ok {
 		labels[karpv1.NodePoolLabelKey] = v
 	}
+	if v, ok := i.Tags[v1.LaunchClientTokenTagKey]; ok {
+		annotations[v1.AnnotationLaunchClientToken] = v
+	}
 	nodeClaim.Labels = labels
 	nodeClaim.Annotations = annotations
 	nodeClaim.CreationTimestamp = metav1.Time{Time: i.LaunchTime}
@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
@@ -36,12 +37,64 @@ const (
 	SubnetDrift        cloudprovider.DriftReason = "SubnetDrift"
 	SecurityGroupDrift cloudprovider.DriftReason = "SecurityGroupDrift"
 	NodeClassDrift     cloudprovider.DriftReason = "NodeClassDrift"
+	RollDrift          cloudprovider.DriftReason = "RollRequested"
+	SpotPriceDrift     cloudprovider.DriftReason = "SpotPriceAboveOnDemand"
 )
 
+// isNodePoolRolled returns RollDrift if the NodeClaim was launched before a roll of its NodePool was requested
+func (c *CloudProvider) isNodePoolRolled(nodeClaim *karpv1.NodeClaim, nodePool *karpv1.NodePool) cloudprovider.DriftReason {
+	requestedAt, ok, err := utils.RollRequestedAt(nodePool)
+	if !ok || err != nil {
+		return ""
+	}
+	return lo.Ternary(nodeClaim.CreationTimestamp.Time.Before(requestedAt), RollDrift, "")
+}
+
+// isSpotPriceDrifted returns SpotPriceDrift if the NodeClaim is a spot instance whose spot price in its zone rose above
+// the on-demand price of its instance type, since replacing it with on-demand or cheaper spot capacity costs less
+func (c *CloudProvider) isSpotPriceDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodePool *karpv1.NodePool) (cloudprovider.DriftReason, error) {
+	if nodeClaim.Labels[karpv1.CapacityTypeLabelKey] != karpv1.CapacityTypeSpot {
+		return "", nil
+	}
+	instanceTypes, err := c.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return "", fmt.Errorf("getting instanceTypes, %w", err)
+	}
+	instanceType, found := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return it.Name == nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+	})
+	if !found {
+		return "", nil
+	}
+	zone := nodeClaim.Labels[corev1.LabelTopologyZone]
+	price := func(capacityType string) (float64, bool) {
+		offering, ok := lo.Find(instanceType.Offerings, func(o cloudprovider.Offering) bool {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == capacityType && o.Requirements.Get(corev1.LabelTopologyZone).Any() == zone
+		})
+		return offering.Price, ok
+	}
+	spotPrice, ok := price(karpv1.CapacityTypeSpot)
+	if !ok {
+		return "", nil
+	}
+	onDemandPrice, ok := price(karpv1.CapacityTypeOnDemand)
+	if !ok {
+		return "", nil
+	}
+	return lo.Ternary(spotPrice > onDemandPrice, SpotPriceDrift, ""), nil
+}
+
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodePool *karpv1.NodePool, nodeClass *v1.EC2NodeClass) (cloudprovider.DriftReason, error) {
-	// First check if the node class is statically drifted to save on API calls.
+	// First check if the node class is statically drifted to save on API calls. Nodes whose volumes only need to grow
+	// aren't drifted when the EC2NodeClass resizes volumes in place, since they're remediated without being replaced.
 	if drifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass); drifted != "" {
-		return drifted, nil
+		resizable, err := c.isResizableInPlace(ctx, nodeClaim, nodeClass)
+		if err != nil {
+			return "", fmt.Errorf("calculating volume resize, %w", err)
+		}
+		if !resizable {
+			return drifted, nil
+		}
 	}
 	instance, err := c.getInstance(ctx, nodeClaim.Status.ProviderID)
 	if err != nil {
@@ -135,6 +188,28 @@ func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *karpv1.NodeClaim, node
 	return lo.Ternary(nodeClassHash != nodeClaimHash, NodeClassDrift, "")
 }
 
+// isResizableInPlace returns true if the only difference between the NodeClaim and its EC2NodeClass is an increase of
+// the volume sizes of the block device mappings, and the EC2NodeClass resizes volumes in place
+func (c *CloudProvider) isResizableInPlace(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) (bool, error) {
+	if !options.FromContext(ctx).FeatureGates.InPlaceUpdates || nodeClass.VolumeResizePolicy() != v1.VolumeResizePolicyInPlace {
+		return false, nil
+	}
+	hash, ok := nodeClaim.Annotations[v1.AnnotationEC2NodeClassHashWithoutVolumes]
+	if !ok || hash != nodeClass.HashWithoutVolumeSizes() {
+		return false, nil
+	}
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		return false, err
+	}
+	volumes, err := c.instanceProvider.GetVolumes(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	_, ok = instance.VolumeResizes(nodeClass, volumes)
+	return ok, nil
+}
+
 func (c *CloudProvider) getInstance(ctx context.Context, providerID string) (*instance.Instance, error) {
 	// Get InstanceID to fetch from EC2
 	instanceID, err := utils.ParseInstanceID(providerID)
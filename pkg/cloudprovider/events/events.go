@@ -15,10 +15,17 @@ limitations under the License.
 package events
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
+
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 func NodePoolFailedToResolveNodeClass(nodePool *v1.NodePool) events.Event {
@@ -38,3 +45,29 @@ func NodeClaimFailedToResolveNodeClass(nodeClaim *v1.NodeClaim) events.Event {
 		DedupeValues:   []string{string(nodeClaim.UID)},
 	}
 }
+
+func NodePoolInstanceTypesAboveMaxNodeSize(nodePool *v1.NodePool, maxSize corev1.ResourceList, instanceTypes []string) events.Event {
+	limits := lo.FilterMap([]corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}, func(name corev1.ResourceName, _ int) (string, bool) {
+		quantity, ok := maxSize[name]
+		return fmt.Sprintf("%s=%s", name, quantity.String()), ok
+	})
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "MaxNodeSizeExceeded",
+		Message:        fmt.Sprintf("Suppressed instance types above the maximum node size (%s): %s", strings.Join(limits, ", "), utils.PrettySlice(instanceTypes, 5)),
+		DedupeValues:   append([]string{string(nodePool.UID)}, limits...),
+		DedupeTimeout:  time.Hour,
+	}
+}
+
+func NodePoolInvalidMaxNodeSize(nodePool *v1.NodePool, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidMaxNodeSize",
+		Message:        fmt.Sprintf("Ignoring invalid maximum node size, %s", err),
+		DedupeValues:   []string{string(nodePool.UID), err.Error()},
+		DedupeTimeout:  time.Hour,
+	}
+}
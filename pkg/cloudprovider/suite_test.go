@@ -35,6 +35,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 
 	opstatus "github.com/awslabs/operatorpkg/status"
 	"github.com/imdario/mergo"
@@ -221,6 +222,15 @@ var _ = Describe("CloudProvider", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(corecloudprovider.IsNodeClassNotReadyError(err)).To(BeTrue())
 	})
+	It("should return NodeClassNotReady error on creation if NodeClass tags would exceed the EC2 tag limit", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		nodeClass.Spec.Tags = lo.SliceToMap(lo.Range(45), func(i int) (string, string) { return fmt.Sprintf("tag-%d", i), "value" })
+		ExpectApplied(ctx, env.Client, nodeClass)
+		_, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(HaveOccurred())
+		Expect(corecloudprovider.IsNodeClassNotReadyError(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("exceeding the EC2 limit of 50 tags"))
+	})
 	It("should return an ICE error when there are no instance types to launch", func() {
 		// Specify no instance types and expect to receive a capacity error
 		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
@@ -259,6 +269,23 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(zoneID).To(Equal(subnet.ZoneID))
 	})
+	It("should return the placement partition as a label on the nodeClaim", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 3}
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelPlacementPartition, Operator: corev1.NodeSelectorOpIn, Values: []string{"2"}},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProviderNodeClaim).ToNot(BeNil())
+		Expect(cloudProviderNodeClaim.GetLabels()).To(HaveKeyWithValue(v1.LabelPlacementPartition, "2"))
+	})
+	It("should not return a placement partition label without a placement group", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProviderNodeClaim.GetLabels()).ToNot(HaveKey(v1.LabelPlacementPartition))
+	})
 	It("should expect a strict set of annotation keys", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
 		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
@@ -284,6 +311,352 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(v).To(Equal(v1.EC2NodeClassHashVersion))
 	})
+	It("should annotate the nodeClaim with the key pair that the instance is launched with", func() {
+		nodeClass.Spec.KeyName = aws.String("test-key-pair")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim).ToNot(BeNil())
+		Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationSSHKeyName, "test-key-pair"))
+	})
+	Context("Warm Pools", func() {
+		var standbyID string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{WarmPools: lo.ToPtr(true)}}))
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationWarmPoolSize: "1"})
+			// The NodePool and EC2NodeClass are applied first so that the standby instance is tagged with their defaulted hashes
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			standbyID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(standbyID, ec2types.Instance{
+				InstanceId:   aws.String(standbyID),
+				InstanceType: "m5.large",
+				State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+				Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				LaunchTime:   aws.Time(time.Now().Add(-time.Hour)),
+				Tags: []ec2types.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+					{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String(nodePool.Name)},
+					{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+					{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String(nodePool.Name)},
+					{Key: aws.String(v1.WarmPoolNodeClassHashTagKey), Value: aws.String(nodeClass.Hash())},
+					{Key: aws.String(v1.WarmPoolNodePoolHashTagKey), Value: aws.String(nodePool.Hash())},
+				},
+			})
+		})
+		It("should resume a standby instance instead of launching an instance", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).To(HaveSuffix(standbyID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(standbyID))
+
+			raw, ok := awsEnv.EC2API.Instances.Load(standbyID)
+			Expect(ok).To(BeTrue())
+			Expect(raw.(ec2types.Instance).State.Name).To(Equal(ec2types.InstanceStateNamePending))
+			Expect(lo.ContainsBy(raw.(ec2types.Instance).Tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == v1.WarmPoolTagKey })).To(BeFalse())
+		})
+		DescribeTable("should launch an instance if the standby instance was launched from a stale template",
+			func(tagKey string) {
+				Expect(awsEnv.EC2API.CreateTags(ctx, &ec2.CreateTagsInput{
+					Resources: []string{standbyID},
+					Tags:      []ec2types.Tag{{Key: aws.String(tagKey), Value: aws.String("stale")}},
+				})).Error().ToNot(HaveOccurred())
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+				cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cloudProviderNodeClaim.Status.ProviderID).ToNot(HaveSuffix(standbyID))
+				Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+				Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+			},
+			Entry("EC2NodeClass", v1.WarmPoolNodeClassHashTagKey),
+			Entry("NodePool", v1.WarmPoolNodePoolHashTagKey),
+		)
+		It("should launch an instance if the NodePool doesn't have a warm pool", func() {
+			delete(nodePool.Annotations, v1.AnnotationWarmPoolSize)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).ToNot(HaveSuffix(standbyID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch an instance if the WarmPools feature gate is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{WarmPools: lo.ToPtr(false)}}))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).ToNot(HaveSuffix(standbyID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch an instance if no standby instance is compatible with the NodeClaim", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1b"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).ToNot(HaveSuffix(standbyID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch an instance if the standby instance fails to start", func() {
+			awsEnv.EC2API.StartInstancesBehavior.Error.Set(fmt.Errorf("failed"))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).ToNot(HaveSuffix(standbyID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+	})
+	Context("Launch Journal", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{LaunchJournal: lo.ToPtr(true)}}))
+		})
+		It("should record the client token of the launch on the NodeClaim before launching", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationLaunchClientToken))
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(aws.ToString(input.ClientToken)).To(Equal(nodeClaim.Annotations[v1.AnnotationLaunchClientToken]))
+		})
+		It("should adopt the instance of a journaled launch rather than launching another", func() {
+			instanceID := fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId:     aws.String(instanceID),
+				InstanceType:   "m5.large",
+				State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNamePending},
+				PrivateDnsName: aws.String(fake.PrivateDNSName()),
+				Placement:      &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				LaunchTime:     aws.Time(time.Now()),
+				Tags: []ec2types.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+					{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String(nodePool.Name)},
+					{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+					{Key: aws.String(v1.LaunchClientTokenTagKey), Value: aws.String("test-token")},
+				},
+			})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchClientToken: "test-token"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).To(HaveSuffix(instanceID))
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationLaunchClientToken, "test-token"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch with the journaled client token when the journaled launch didn't launch an instance", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchClientToken: "test-token"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(aws.ToString(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken)).To(Equal("test-token"))
+		})
+		It("should remove a launch which EC2 rejected from the journal", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "invalid parameter"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLaunchClientToken))
+		})
+		It("should remove a launch which failed for a lack of capacity from the journal", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: "insufficient capacity"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLaunchClientToken))
+		})
+		DescribeTable("should keep a launch in the journal when EC2 failed with an error which doesn't prove that no instance was launched",
+			func(code string) {
+				awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: code, Message: "failed"})
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+				_, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).To(HaveOccurred())
+
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationLaunchClientToken))
+			},
+			Entry("internal errors", "InternalError"),
+			Entry("unavailable services", "ServiceUnavailable"),
+			Entry("throttling errors", "RequestLimitExceeded"),
+		)
+		It("should keep a launch in the journal when it's unknown whether it launched an instance", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(fmt.Errorf("connection reset by peer"))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationLaunchClientToken))
+		})
+		It("should not journal launches when the launch journal is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLaunchClientToken))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken).To(BeNil())
+		})
+	})
+	Context("Max Node Size", func() {
+		It("should exclude instance types above the maximum node size", func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationMaxNodeCPU: "4", v1.AnnotationMaxNodeMemory: "16Gi"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTypes).ToNot(BeEmpty())
+			for _, it := range instanceTypes {
+				Expect(it.Capacity.Cpu().Cmp(resource.MustParse("4"))).To(BeNumerically("<=", 0))
+				Expect(it.Capacity.Memory().Cmp(resource.MustParse("16Gi"))).To(BeNumerically("<=", 0))
+			}
+		})
+		It("should not launch instances above the maximum node size for a large pod", func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationMaxNodeCPU: "4"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should ignore a maximum node size annotation which is invalid", func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationMaxNodeCPU: "4"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			expected, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expected).ToNot(BeEmpty())
+
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationMaxNodeMemory: "lots"})
+			ExpectApplied(ctx, env.Client, nodePool)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).
+				To(ConsistOf(lo.Map(expected, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })))
+		})
+	})
+	Context("Minimum Spot Pools", func() {
+		var spotNodeClaim *karpv1.NodeClaim
+		BeforeEach(func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationMinSpotPools: "2"})
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+			}
+			spotNodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						karpv1.NodePoolLabelKey:        nodePool.Name,
+						karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+						corev1.LabelInstanceTypeStable: "m5.large",
+						corev1.LabelTopologyZone:       "test-zone-1a",
+					},
+				},
+			})
+		})
+		overrideSubnets := func() []string {
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) string { return aws.ToString(o.SubnetId) })
+			})
+		}
+		It("should not launch into the spot pools used by the NodePool until it has the minimum spot pools", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, spotNodeClaim, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			subnets := overrideSubnets()
+			Expect(subnets).ToNot(BeEmpty())
+			Expect(subnets).ToNot(ContainElement("subnet-test1"))
+		})
+		It("should launch into the used spot pools once the NodePool has the minimum spot pools", func() {
+			nodePool.Annotations[v1.AnnotationMinSpotPools] = "1"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, spotNodeClaim, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideSubnets()).To(ContainElement("subnet-test1"))
+		})
+		It("should launch into the used spot pools when no other spot pool is available", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, spotNodeClaim, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideSubnets()).To(ConsistOf("subnet-test1"))
+		})
+		It("should fail to launch when the minimum spot pools is invalid", func() {
+			nodePool.Annotations[v1.AnnotationMinSpotPools] = "many"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+	})
+	Context("Spot Ratio", func() {
+		BeforeEach(func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationSpotRatio: "70"})
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+			}
+		})
+		existingNodeClaim := func(capacityType string) *karpv1.NodeClaim {
+			return coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						karpv1.NodePoolLabelKey:     nodePool.Name,
+						karpv1.CapacityTypeLabelKey: capacityType,
+					},
+				},
+				Status: karpv1.NodeClaimStatus{
+					Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				},
+			})
+		}
+		targetCapacityType := func() ec2types.DefaultTargetCapacityType {
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType
+		}
+		It("should launch spot capacity while the NodePool is below its spot ratio", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, existingNodeClaim(karpv1.CapacityTypeOnDemand), nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targetCapacityType()).To(Equal(ec2types.DefaultTargetCapacityTypeSpot))
+		})
+		It("should launch on-demand capacity once the NodePool reaches its spot ratio", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, existingNodeClaim(karpv1.CapacityTypeSpot), nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targetCapacityType()).To(Equal(ec2types.DefaultTargetCapacityTypeOnDemand))
+		})
+		It("should launch on-demand capacity when the spot ratio is zero", func() {
+			nodePool.Annotations[v1.AnnotationSpotRatio] = "0"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targetCapacityType()).To(Equal(ec2types.DefaultTargetCapacityTypeOnDemand))
+		})
+		It("should fail to launch when the spot ratio is invalid", func() {
+			nodePool.Annotations[v1.AnnotationSpotRatio] = "most"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+	})
 	Context("EC2 Context", func() {
 		contextID := "context-1234"
 		It("should set context on the CreateFleet request if specified on the NodePool", func() {
@@ -776,6 +1149,82 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(drifted).To(BeEmpty())
 		})
+		It("should return drifted if the NodeClaim was launched before a roll of its NodePool was requested", func() {
+			nodeClaim.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationRollRequestedAt: time.Now().UTC().Format(time.RFC3339)})
+			ExpectApplied(ctx, env.Client, nodePool)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.RollDrift))
+		})
+		It("should not return drifted if the NodeClaim was launched after a roll of its NodePool was requested", func() {
+			nodeClaim.CreationTimestamp = metav1.NewTime(time.Now())
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationRollRequestedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)})
+			ExpectApplied(ctx, env.Client, nodePool)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		It("should ignore an invalid roll request", func() {
+			nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationRollRequestedAt: "yesterday"})
+			ExpectApplied(ctx, env.Client, nodePool)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		Context("Spot Price", func() {
+			BeforeEach(func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPriceDrift: lo.ToPtr(true)}}))
+				nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+					karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeSpot,
+					corev1.LabelTopologyZone:    "test-zone-1a",
+				})
+				onDemandPrice, ok := awsEnv.PricingProvider.OnDemandPrice(ec2types.InstanceType(selectedInstanceType.Name))
+				Expect(ok).To(BeTrue())
+				now := time.Now()
+				awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+					SpotPriceHistory: []ec2types.SpotPrice{
+						{
+							AvailabilityZone: aws.String("test-zone-1a"),
+							InstanceType:     ec2types.InstanceType(selectedInstanceType.Name),
+							SpotPrice:        aws.String(fmt.Sprint(onDemandPrice * 2)),
+							Timestamp:        &now,
+						},
+						{
+							AvailabilityZone: aws.String("test-zone-1b"),
+							InstanceType:     ec2types.InstanceType(selectedInstanceType.Name),
+							SpotPrice:        aws.String(fmt.Sprint(onDemandPrice / 2)),
+							Timestamp:        &now,
+						},
+					},
+				})
+				Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+				awsEnv.InstanceTypeCache.Flush()
+			})
+			It("should return drifted if the spot price rose above the on-demand price", func() {
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.SpotPriceDrift))
+			})
+			It("should not return drifted if the SpotPriceDrift feature gate is disabled", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPriceDrift: lo.ToPtr(false)}}))
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted for on-demand nodes", func() {
+				nodeClaim.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeOnDemand
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if the spot price in the node's zone is below the on-demand price", func() {
+				nodeClaim.Labels[corev1.LabelTopologyZone] = "test-zone-1b"
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+		})
 		It("should return drifted if the AMI is not valid", func() {
 			// Instance is a reference to what we return in the GetInstances call
 			instance.ImageId = aws.String(fake.ImageID())
@@ -1009,6 +1458,7 @@ var _ = Describe("CloudProvider", func() {
 				Entry("Tags", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 				Entry("Context", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Context: lo.ToPtr("context-2")}}),
 				Entry("DetailedMonitoring", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+				Entry("KeyName", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{KeyName: aws.String("test-key-pair")}}),
 				Entry("InstanceStorePolicy", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStorePolicy: lo.ToPtr(v1.InstanceStorePolicyRAID0)}}),
 				Entry("AssociatePublicIPAddress", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssociatePublicIPAddress: lo.ToPtr(true)}}),
 				Entry("MetadataOptions HTTPEndpoint", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPEndpoint: lo.ToPtr("enabled")}}}),
@@ -1036,6 +1486,50 @@ var _ = Describe("CloudProvider", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(isDrifted).To(Equal(cloudprovider.NodeClassDrift))
 			})
+			It("should not return drifted when only blockDeviceMapping volumeSize increases and volumes are resized in place", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{InPlaceUpdates: lo.ToPtr(true)}}))
+				nodeClass.Spec.DriftPolicy = &v1.DriftPolicy{VolumeResize: lo.ToPtr(v1.VolumeResizePolicyInPlace)}
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClass.HashWithoutVolumeSizes()})
+				nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = resource.NewScaledQuantity(10, resource.Giga)
+				nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted when only blockDeviceMapping volumeSize increases and the InPlaceUpdates feature gate is disabled", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{InPlaceUpdates: lo.ToPtr(false)}}))
+				nodeClass.Spec.DriftPolicy = &v1.DriftPolicy{VolumeResize: lo.ToPtr(v1.VolumeResizePolicyInPlace)}
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClass.HashWithoutVolumeSizes()})
+				nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = resource.NewScaledQuantity(10, resource.Giga)
+				nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.NodeClassDrift))
+			})
+			It("should return drifted when blockDeviceMapping volumeSize decreases and volumes are resized in place", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{InPlaceUpdates: lo.ToPtr(true)}}))
+				nodeClass.Spec.DriftPolicy = &v1.DriftPolicy{VolumeResize: lo.ToPtr(v1.VolumeResizePolicyInPlace)}
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClass.HashWithoutVolumeSizes()})
+				nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = resource.NewScaledQuantity(1, resource.Giga)
+				nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+				awsEnv.EC2API.Volumes.Store("vol-test", ec2types.Volume{
+					VolumeId: aws.String("vol-test"),
+					Size:     aws.Int32(2),
+					Attachments: []ec2types.VolumeAttachment{{
+						InstanceId: instance.InstanceId,
+						Device:     aws.String("fakeName"),
+					}},
+				})
+
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.NodeClassDrift))
+			})
 			DescribeTable("should not return drifted if dynamic fields are updated",
 				func(changes v1.EC2NodeClass) {
 					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -1156,7 +1650,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(100),
 					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.InstanceProvider, awsEnv.VPCEndpointProvider, awsEnv.DHCPOptionsProvider, awsEnv.CapacityBlockProvider, awsEnv.PlacementGroupProvider, awsEnv.InstanceTypesProvider, awsEnv.QuotaProvider, fake.DefaultRegion, nil)
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
@@ -1173,7 +1667,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(11),
 					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.InstanceProvider, awsEnv.VPCEndpointProvider, awsEnv.DHCPOptionsProvider, awsEnv.CapacityBlockProvider, awsEnv.PlacementGroupProvider, awsEnv.InstanceTypesProvider, awsEnv.QuotaProvider, fake.DefaultRegion, nil)
 			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 				MaxPods: aws.Int32(1),
 			}
@@ -1214,7 +1708,7 @@ var _ = Describe("CloudProvider", func() {
 			}})
 			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.InstanceProvider, awsEnv.VPCEndpointProvider, awsEnv.DHCPOptionsProvider, awsEnv.CapacityBlockProvider, awsEnv.PlacementGroupProvider, awsEnv.InstanceTypesProvider, awsEnv.QuotaProvider, fake.DefaultRegion, nil)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			podSubnet1 := coretest.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, podSubnet1)
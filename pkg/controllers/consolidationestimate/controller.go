@@ -0,0 +1,249 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consolidationestimate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// evaluationInterval is the interval at which the consolidation of the NodePool is evaluated
+const evaluationInterval = time.Minute
+
+// Controller estimates the consolidation of NodePools. While a ConsolidationEstimate's observation period lasts, the
+// nodes which consolidation is estimated to disrupt are recorded in the ConsolidationEstimate's status. The controller
+// only reads the NodePool, so consolidation keeps running unless the NodePool's own disruption budgets block it. This
+// doesn't run the disruption controller's simulation. Consolidation is estimated node by node: a node is proposed for
+// deletion when it has no pods which would need to be rescheduled, and for replacement when its pods fit on a cheaper
+// instance type that the NodePool allows. Consolidation which moves pods onto other existing nodes isn't estimated.
+type Controller struct {
+	kubeClient      client.Client
+	cloudProvider   cloudprovider.CloudProvider
+	pricingProvider pricing.Provider
+	clk             clock.Clock
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, pricingProvider pricing.Provider, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		cloudProvider:   cloudProvider,
+		pricingProvider: pricingProvider,
+		clk:             clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, estimate *v1.ConsolidationEstimate) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "consolidationestimate")
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", estimate.Spec.NodePool))
+
+	if !estimate.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	stored := estimate.DeepCopy()
+	end := estimate.CreationTimestamp.Add(estimate.Spec.ObservationPeriod.Duration)
+	estimate.Status.ObservationEnd = &metav1.Time{Time: end}
+	var result reconcile.Result
+	if c.clk.Now().Before(end) {
+		if err := c.evaluate(ctx, estimate); err != nil {
+			return reconcile.Result{}, err
+		}
+		result = reconcile.Result{RequeueAfter: lo.Min([]time.Duration{evaluationInterval, end.Sub(c.clk.Now())})}
+	}
+	if !equality.Semantic.DeepEqual(stored, estimate) {
+		if err := c.kubeClient.Status().Patch(ctx, estimate, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching consolidationestimate status, %w", err))
+		}
+	}
+	return result, nil
+}
+
+// evaluate records the nodes that consolidation is estimated to disrupt
+func (c *Controller) evaluate(ctx context.Context, estimate *v1.ConsolidationEstimate) error {
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: estimate.Spec.NodePool}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			estimate.Status.Candidates = nil
+			estimate.Status.EstimatedHourlySavings = ""
+			return nil
+		}
+		return fmt.Errorf("getting nodepool, %w", err)
+	}
+	candidates, savings, err := c.candidates(ctx, estimate, nodePool)
+	if err != nil {
+		return err
+	}
+	estimate.Status.Candidates = candidates
+	estimate.Status.EstimatedHourlySavings = formatPrice(savings)
+	estimate.Status.LastEvaluated = &metav1.Time{Time: c.clk.Now()}
+	return nil
+}
+
+// candidates returns the nodes of the NodePool which consolidation is estimated to disrupt. Nodes which are
+// protected from disruption aren't candidates. It also returns the combined hourly savings of the candidates.
+func (c *Controller) candidates(ctx context.Context, estimate *v1.ConsolidationEstimate, nodePool *karpv1.NodePool) ([]v1.ConsolidationCandidate, float64, error) {
+	if nodePool.Spec.Disruption.ConsolidateAfter.Duration == nil {
+		return nil, 0, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return nil, 0, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	nodes := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodes, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return nil, 0, fmt.Errorf("listing nodes, %w", err)
+	}
+	podList := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, podList); err != nil {
+		return nil, 0, fmt.Errorf("listing pods, %w", err)
+	}
+	pods := map[string][]*corev1.Pod{}
+	for i := range podList.Items {
+		p := &podList.Items[i]
+		if p.Spec.NodeName == "" || podutils.IsTerminal(p) || podutils.IsOwnedByDaemonSet(p) || podutils.IsOwnedByNode(p) {
+			continue
+		}
+		pods[p.Spec.NodeName] = append(pods[p.Spec.NodeName], p)
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting instance types, %w", err)
+	}
+	nodesByName := lo.SliceToMap(nodes.Items, func(n corev1.Node) (string, corev1.Node) { return n.Name, n })
+	previous := lo.SliceToMap(estimate.Status.Candidates, func(candidate v1.ConsolidationCandidate) (string, metav1.Time) {
+		return candidate.NodeClaim + "/" + candidate.Action, candidate.FirstProposed
+	})
+	var candidates []v1.ConsolidationCandidate
+	var savings float64
+	for i := range nodeClaims.Items {
+		nodeClaim := &nodeClaims.Items[i]
+		node, ok := nodesByName[nodeClaim.Status.NodeName]
+		if !ok || !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if _, ok := node.Annotations[karpv1.DoNotDisruptAnnotationKey]; ok {
+			continue
+		}
+		if lo.ContainsBy(pods[node.Name], func(p *corev1.Pod) bool { return p.Annotations[karpv1.DoNotDisruptAnnotationKey] == "true" }) {
+			continue
+		}
+		price, ok := c.price(nodeClaim)
+		if !ok {
+			continue
+		}
+		candidate := v1.ConsolidationCandidate{
+			NodeClaim:    nodeClaim.Name,
+			Node:         node.Name,
+			InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+			CapacityType: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+			Pods:         lo.Map(pods[node.Name], func(p *corev1.Pod, _ int) string { return client.ObjectKeyFromObject(p).String() }),
+		}
+		switch {
+		case len(pods[node.Name]) == 0:
+			candidate.Action = v1.ConsolidationActionDelete
+			candidate.HourlySavings = formatPrice(price)
+			savings += price
+		case nodePool.Spec.Disruption.ConsolidationPolicy == karpv1.ConsolidationPolicyWhenEmptyOrUnderutilized:
+			replacement, replacementPrice, ok := cheapest(nodePool, instanceTypes, pods[node.Name])
+			if !ok || replacementPrice >= price || replacement == candidate.InstanceType {
+				continue
+			}
+			candidate.Action = v1.ConsolidationActionReplace
+			candidate.Replacement = replacement
+			candidate.HourlySavings = formatPrice(price - replacementPrice)
+			savings += price - replacementPrice
+		default:
+			continue
+		}
+		candidate.FirstProposed = lo.ValueOr(previous, candidate.NodeClaim+"/"+candidate.Action, metav1.Time{Time: c.clk.Now()})
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].NodeClaim < candidates[j].NodeClaim })
+	return candidates, savings, nil
+}
+
+// cheapest returns the cheapest instance type that the NodePool allows which the pods fit on, and its price
+func cheapest(nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType, pods []*corev1.Pod) (string, float64, bool) {
+	requests := resources.RequestsForPods(pods...)
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	name, price := "", math.MaxFloat64
+	for _, it := range instanceTypes {
+		if !resources.Fits(requests, it.Allocatable()) || requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+			continue
+		}
+		offerings := it.Offerings.Available().Compatible(requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		if p := offerings.Cheapest().Price; p < price {
+			name, price = it.Name, p
+		}
+	}
+	return name, price, name != ""
+}
+
+// price returns the hourly price of the NodeClaim's instance
+func (c *Controller) price(nodeClaim *karpv1.NodeClaim) (float64, bool) {
+	instanceType := ec2types.InstanceType(nodeClaim.Labels[corev1.LabelInstanceTypeStable])
+	switch nodeClaim.Labels[karpv1.CapacityTypeLabelKey] {
+	case karpv1.CapacityTypeSpot:
+		return c.pricingProvider.SpotPrice(instanceType, nodeClaim.Labels[corev1.LabelTopologyZone])
+	case karpv1.CapacityTypeOnDemand:
+		return c.pricingProvider.OnDemandPrice(instanceType)
+	}
+	return 0, false
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("consolidationestimate").
+		For(&v1.ConsolidationEstimate{}).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func formatPrice(price float64) string {
+	return fmt.Sprintf("%.4f", price)
+}
@@ -0,0 +1,254 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consolidationestimate_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/consolidationestimate"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var controller *consolidationestimate.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ConsolidationEstimate")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = consolidationestimate.NewController(env.Client, cloudProvider, awsEnv.PricingProvider, fakeClock)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	fakeClock.SetTime(time.Now())
+	awsEnv.Reset()
+	ec2InstanceTypeInfo := fake.MakeInstances()
+	awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: ec2InstanceTypeInfo,
+	})
+	awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+		InstanceTypeOfferings: fake.MakeInstanceOfferings(ec2InstanceTypeInfo),
+	})
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("ConsolidationEstimate", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	var estimate *v1.ConsolidationEstimate
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Disruption: karpv1.Disruption{
+					ConsolidationPolicy: karpv1.ConsolidationPolicyWhenEmptyOrUnderutilized,
+					ConsolidateAfter:    karpv1.MustParseNillableDuration("0s"),
+				},
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+						Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large", "m5.xlarge"}}},
+						},
+					},
+				},
+			},
+		})
+		estimate = &v1.ConsolidationEstimate{
+			ObjectMeta: metav1.ObjectMeta{Name: "estimate"},
+			Spec: v1.ConsolidationEstimateSpec{
+				NodePool:          nodePool.Name,
+				ObservationPeriod: metav1.Duration{Duration: 24 * time.Hour},
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+	})
+	nodeClaimAndNode := func(instanceType string) (*karpv1.NodeClaim, *corev1.Node) {
+		return coretest.NodeClaimAndNode(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool.Name,
+					corev1.LabelInstanceTypeStable: instanceType,
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				},
+			},
+		})
+	}
+	onDemandPrice := func(instanceType string) float64 {
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(ec2types.InstanceType(instanceType))
+		Expect(ok).To(BeTrue())
+		return price
+	}
+	boundPod := func(node *corev1.Node, annotations map[string]string) *corev1.Pod {
+		return coretest.Pod(coretest.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			NodeName:   node.Name,
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+		})
+	}
+	It("should not modify the NodePool", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node)
+		stored := ExpectExists(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Finalizers).To(BeEmpty())
+		Expect(estimate.Status.ObservationEnd.Time).To(BeTemporally("~", estimate.CreationTimestamp.Add(24*time.Hour), time.Second))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.ResourceVersion).To(Equal(stored.ResourceVersion))
+		Expect(nodePool.MustGetAllowedDisruptions(fakeClock, 10, karpv1.DisruptionReasonUnderutilized)).To(BeNumerically(">", 0))
+		Expect(ExpectExists(ctx, env.Client, node).Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+	})
+	It("should propose deleting empty nodes", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Status.Candidates).To(HaveLen(1))
+		Expect(estimate.Status.Candidates[0].NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(estimate.Status.Candidates[0].Node).To(Equal(node.Name))
+		Expect(estimate.Status.Candidates[0].Action).To(Equal(v1.ConsolidationActionDelete))
+		Expect(estimate.Status.Candidates[0].HourlySavings).To(Equal(fmt.Sprintf("%.4f", onDemandPrice("m5.large"))))
+		Expect(estimate.Status.EstimatedHourlySavings).To(Equal(fmt.Sprintf("%.4f", onDemandPrice("m5.large"))))
+		Expect(estimate.Status.LastEvaluated).ToNot(BeNil())
+	})
+	It("should propose replacing nodes whose pods fit on a cheaper instance type", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.xlarge")
+		pod := boundPod(node, nil)
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Status.Candidates).To(HaveLen(1))
+		Expect(estimate.Status.Candidates[0].Action).To(Equal(v1.ConsolidationActionReplace))
+		Expect(estimate.Status.Candidates[0].Replacement).To(Equal("m5.large"))
+		Expect(estimate.Status.Candidates[0].Pods).To(ConsistOf(fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)))
+		Expect(estimate.Status.Candidates[0].HourlySavings).To(Equal(fmt.Sprintf("%.4f", onDemandPrice("m5.xlarge")-onDemandPrice("m5.large"))))
+	})
+	It("should not propose replacing nodes when the NodePool only consolidates empty nodes", func() {
+		nodePool.Spec.Disruption.ConsolidationPolicy = karpv1.ConsolidationPolicyWhenEmpty
+		nodeClaim, node := nodeClaimAndNode("m5.xlarge")
+		ExpectApplied(ctx, env.Client, nodePool, estimate, nodeClaim, node, boundPod(node, nil))
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Status.Candidates).To(BeEmpty())
+		Expect(estimate.Status.EstimatedHourlySavings).To(Equal("0.0000"))
+	})
+	It("should not propose disrupting nodes when consolidation is disabled", func() {
+		nodePool.Spec.Disruption.ConsolidateAfter = karpv1.MustParseNillableDuration("Never")
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		ExpectApplied(ctx, env.Client, nodePool, estimate, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		Expect(ExpectExists(ctx, env.Client, estimate).Status.Candidates).To(BeEmpty())
+	})
+	It("should not propose disrupting nodes which are protected from disruption", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"})
+		protectedNodeClaim, protectedNode := nodeClaimAndNode("m5.xlarge")
+		pod := boundPod(protectedNode, map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node, protectedNodeClaim, protectedNode, pod)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+
+		Expect(ExpectExists(ctx, env.Client, estimate).Status.Candidates).To(BeEmpty())
+	})
+	It("should preserve when a candidate was first proposed", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+		firstProposed := ExpectExists(ctx, env.Client, estimate).Status.Candidates[0].FirstProposed
+
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Status.Candidates[0].FirstProposed.Time).To(BeTemporally("==", firstProposed.Time))
+		Expect(estimate.Status.LastEvaluated.Time).To(BeTemporally(">", firstProposed.Time))
+	})
+	It("should stop updating the estimate after the observation period", func() {
+		nodeClaim, node := nodeClaimAndNode("m5.large")
+		ExpectApplied(ctx, env.Client, estimate, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+		lastEvaluated := ExpectExists(ctx, env.Client, estimate).Status.LastEvaluated
+
+		fakeClock.Step(25 * time.Hour)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, estimate)
+		Expect(result.RequeueAfter).To(BeZero())
+		estimate = ExpectExists(ctx, env.Client, estimate)
+		Expect(estimate.Status.LastEvaluated.Time).To(BeTemporally("==", lastEvaluated.Time))
+		Expect(estimate.Status.Candidates).To(HaveLen(1))
+	})
+})
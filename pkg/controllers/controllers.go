@@ -16,22 +16,32 @@ package controllers
 
 import (
 	"context"
+	"strings"
 
 	"github.com/awslabs/operatorpkg/controller"
 	opevents "github.com/awslabs/operatorpkg/events"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/patrickmn/go-cache"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	nodeclass "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass"
 	nodeclasshash "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/hash"
 	controllersinstancetype "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype"
 	controllersinstancetypecapacity "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype/capacity"
+	controllersinstancetypeprefixdelegation "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype/prefixdelegation"
+	controllerslaunchtemplate "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/launchtemplate"
+	controllerspolicy "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/policy"
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
+	controllersspotplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotplacementscore"
 	ssminvalidation "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ssm/invalidation"
 	controllersversion "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/version"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
@@ -44,20 +54,48 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/utils/env"
 
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/consolidationestimate"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	nodeclaimboottime "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/boottime"
+	nodeclaimcapacityblock "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/capacityblock"
+	nodeclaimcostattribution "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/costattribution"
+	nodeclaimdisruptionprotection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/disruptionprotection"
 	nodeclaimgarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaimlabeling "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/labeling"
+	nodeclaimlifecycle "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/lifecycle"
+	nodeclaimreboot "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/reboot"
 	nodeclaimtagging "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagging"
+	nodeclaimvolumeresize "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/volumeresize"
+	nodepoolaudit "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/audit"
+	nodepoolcapacityforecast "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/capacityforecast"
+	nodepoolcapacitytyperatio "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/capacitytyperatio"
+	nodepoolcircuitbreaker "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/circuitbreaker"
+	nodepoolcomposition "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/composition"
+	nodepoolgeneration "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/generation"
+	nodepoolnodetemplate "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/nodetemplate"
+	nodepoolroll "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/roll"
+	nodepoolwarmpool "github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/warmpool"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepooltemplate"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/commitment"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/vpcendpoint"
 )
 
 func NewControllers(
@@ -66,6 +104,7 @@ func NewControllers(
 	cfg aws.Config,
 	clk clock.Clock,
 	kubeClient client.Client,
+	kubernetesInterface kubernetes.Interface,
 	recorder events.Recorder,
 	unavailableOfferings *awscache.UnavailableOfferings,
 	ssmCache *cache.Cache,
@@ -75,27 +114,105 @@ func NewControllers(
 	instanceProfileProvider instanceprofile.Provider,
 	instanceProvider instance.Provider,
 	pricingProvider pricing.Provider,
+	commitmentProvider commitment.Provider,
 	amiProvider amifamily.Provider,
 	launchTemplateProvider launchtemplate.Provider,
 	versionProvider *version.DefaultProvider,
-	instanceTypeProvider *instancetype.DefaultProvider) []controller.Controller {
+	instanceTypeProvider *instancetype.DefaultProvider,
+	vpcEndpointProvider vpcendpoint.Provider,
+	dhcpOptionsProvider dhcpoptions.Provider,
+	capacityBlockProvider capacityblock.Provider,
+	placementGroupProvider placementgroup.Provider,
+	policyProvider *policy.DefaultProvider,
+	spotPlacementScoreProvider spotplacementscore.Provider,
+	quotaProvider quota.Provider) []controller.Controller {
+	// nodeClassEvents requeues EC2NodeClasses when the interruption controller receives changes to the resources they select
+	nodeClassEvents := make(chan event.GenericEvent, 100)
 	controllers := []controller.Controller{
-		nodeclasshash.NewController(kubeClient),
-		nodeclass.NewController(kubeClient, recorder, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider),
+		nodeclasshash.NewController(kubeClient, launchTemplateProvider),
+		nodeclass.NewController(kubeClient, recorder, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider, instanceProvider, vpcEndpointProvider, dhcpOptionsProvider, capacityBlockProvider, placementGroupProvider, instanceTypeProvider, quotaProvider, cfg.Region, nodeClassEvents),
 		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
 		nodeclaimtagging.NewController(kubeClient, cloudProvider, instanceProvider),
-		controllerspricing.NewController(pricingProvider),
+		nodeclaimlabeling.NewController(kubeClient, cloudProvider, instanceProvider),
+		nodeclaimboottime.NewController(kubeClient, cloudProvider, clk, nodeclaimboottime.NewModel()),
+		nodeclaimcapacityblock.NewController(kubeClient, cloudProvider, clk, recorder),
+		nodeclaimdisruptionprotection.NewController(kubeClient, cloudProvider, instanceProvider, recorder),
+		nodeclaimcostattribution.NewController(kubeClient, cloudProvider, instanceProvider),
+		nodeclaimreboot.NewController(kubeClient, cloudProvider, instanceProvider, clk, recorder),
+		nodeclaimlifecycle.NewController(kubeClient, cloudProvider, clk),
+		nodepoolnodetemplate.NewController(kubeClient, cloudProvider, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system")),
+		nodepoolcapacitytyperatio.NewController(kubeClient),
+		nodepoolcapacityforecast.NewController(kubeClient, cloudProvider, subnetProvider, quotaProvider),
+		nodepoolroll.NewController(kubeClient, cloudProvider, recorder),
+		nodepoolgeneration.NewController(kubeClient, cloudProvider, recorder),
+		nodepoolaudit.NewController(kubeClient, cloudProvider),
+		nodepooltemplate.NewController(kubeClient, recorder, clk),
+		consolidationestimate.NewController(kubeClient, cloudProvider, pricingProvider, clk),
+		nodepoolcircuitbreaker.NewController(kubeClient, cloudProvider, recorder, clk),
+		nodepoolcomposition.NewController(kubeClient, cloudProvider, pricingProvider, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system")),
+		controllerspricing.NewController(kubeClient, recorder, pricingProvider, commitmentProvider),
 		controllersinstancetype.NewController(instanceTypeProvider),
 		controllersinstancetypecapacity.NewController(kubeClient, cloudProvider, instanceTypeProvider),
+		controllersspotplacementscore.NewController(spotPlacementScoreProvider),
+		controllersinstancetypeprefixdelegation.NewController(kubernetesInterface, instanceTypeProvider),
+		controllerslaunchtemplate.NewController(launchTemplateProvider),
 		ssminvalidation.NewController(ssmCache, amiProvider),
 		status.NewController[*v1.EC2NodeClass](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		opevents.NewController[*corev1.Node](kubeClient, clk),
 		controllersversion.NewController(versionProvider, versionProvider.UpdateVersionWithValidation),
 	}
-	if options.FromContext(ctx).InterruptionQueue != "" {
-		sqsapi := servicesqs.NewFromConfig(cfg)
-		out := lo.Must(sqsapi.GetQueueUrl(ctx, &servicesqs.GetQueueUrlInput{QueueName: lo.ToPtr(options.FromContext(ctx).InterruptionQueue)}))
-		controllers = append(controllers, interruption.NewController(kubeClient, cloudProvider, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), unavailableOfferings))
+	if options.FromContext(ctx).PolicyConfigMap != "" {
+		controllers = append(controllers, controllerspolicy.NewController(mgr.GetAPIReader(), policyProvider, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system")))
+	}
+	if options.FromContext(ctx).FeatureGates.InPlaceUpdates {
+		controllers = append(controllers, nodeclaimvolumeresize.NewController(kubeClient, cloudProvider, instanceProvider, recorder))
+	}
+	if options.FromContext(ctx).FeatureGates.WarmPools {
+		controllers = append(controllers, nodepoolwarmpool.NewController(kubeClient, cloudProvider, instanceProvider, clk), nodepoolwarmpool.NewTaintController(kubeClient))
+	}
+	if options.FromContext(ctx).FeatureGates.SpotPriceDrift {
+		controllers = append(controllers, controllerspricing.NewSpotController(pricingProvider))
+	}
+	if options.FromContext(ctx).FeatureGates.PrewarmLaunchTemplates {
+		controllers = append(controllers, controllerslaunchtemplate.NewPrewarmController(kubeClient, cloudProvider, instanceTypeProvider, launchTemplateProvider))
+	}
+	// Events which are delivered to more than one interruption queue are only handled by the first queue's controller
+	handledMessages := cache.New(awscache.HandledInterruptionMessagesTTL, awscache.DefaultCleanupInterval)
+	queueCfg := interruptionQueueConfig(ctx, cfg)
+	for _, queue := range options.FromContext(ctx).InterruptionQueues() {
+		controllers = append(controllers, interruption.NewController(kubeClient, cloudProvider, clk, recorder, newInterruptionQueueProvider(ctx, queueCfg, queue), handledMessages,
+			unavailableOfferings, subnetProvider, securityGroupProvider, amiProvider, nodeClassEvents))
 	}
 	return controllers
 }
+
+// interruptionQueueConfig returns the config used to poll the interruption queues. If interruption-queue-role-arn is
+// set, then the queues are polled with the credentials of the role, e.g. for queues in a different account.
+func interruptionQueueConfig(ctx context.Context, cfg aws.Config) aws.Config {
+	roleARN := options.FromContext(ctx).InterruptionQueueRoleARN
+	if roleARN == "" {
+		return cfg
+	}
+	cfg = cfg.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "karpenter-interruption"
+	}))
+	return cfg
+}
+
+// newInterruptionQueueProvider returns the provider of an interruption queue, which is either the name of a queue in
+// the cluster's region, or the URL or ARN of a queue in any region and account
+func newInterruptionQueueProvider(ctx context.Context, cfg aws.Config, queue string) *sqs.DefaultProvider {
+	if arn.IsARN(queue) {
+		queue = lo.Must(sqs.QueueURL(queue))
+	} else if !strings.Contains(queue, "://") {
+		out := lo.Must(servicesqs.NewFromConfig(cfg).GetQueueUrl(ctx, &servicesqs.GetQueueUrlInput{QueueName: lo.ToPtr(queue)}))
+		queue = lo.FromPtr(out.QueueUrl)
+	}
+	sqsapi := servicesqs.NewFromConfig(cfg, func(o *servicesqs.Options) {
+		if region := sqs.QueueRegion(queue); region != "" {
+			o.Region = region
+		}
+	})
+	return lo.Must(sqs.NewDefaultProvider(sqsapi, queue))
+}
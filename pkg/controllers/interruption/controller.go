@@ -22,9 +22,11 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/awslabs/operatorpkg/singleton"
+	gocache "github.com/patrickmn/go-cache"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/workqueue"
@@ -32,6 +34,7 @@ import (
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -41,10 +44,17 @@ import (
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/resourcechange"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -59,14 +69,21 @@ const (
 
 // Controller is an AWS interruption controller.
 // It continually polls an SQS queue for events from aws.ec2 and aws.health that
-// trigger node health events or node spot interruption/rebalance events.
+// trigger node health events or node spot interruption/rebalance events. Events from
+// aws.tag and CloudTrail for resources selected by EC2NodeClasses invalidate the cached resources.
+// When multiple interruption queues are configured, each queue is polled by its own controller.
 type Controller struct {
 	kubeClient                client.Client
 	cloudProvider             cloudprovider.CloudProvider
 	clk                       clock.Clock
 	recorder                  events.Recorder
 	sqsProvider               sqs.Provider
+	handledMessages           *gocache.Cache
 	unavailableOfferingsCache *cache.UnavailableOfferings
+	subnetProvider            subnet.Provider
+	securityGroupProvider     securitygroup.Provider
+	amiProvider               amifamily.Provider
+	nodeClassEvents           chan<- event.GenericEvent
 	parser                    *EventParser
 	cm                        *pretty.ChangeMonitor
 }
@@ -77,7 +94,12 @@ func NewController(
 	clk clock.Clock,
 	recorder events.Recorder,
 	sqsProvider sqs.Provider,
+	handledMessages *gocache.Cache,
 	unavailableOfferingsCache *cache.UnavailableOfferings,
+	subnetProvider subnet.Provider,
+	securityGroupProvider securitygroup.Provider,
+	amiProvider amifamily.Provider,
+	nodeClassEvents chan<- event.GenericEvent,
 ) *Controller {
 	return &Controller{
 		kubeClient:                kubeClient,
@@ -85,7 +107,12 @@ func NewController(
 		clk:                       clk,
 		recorder:                  recorder,
 		sqsProvider:               sqsProvider,
+		handledMessages:           handledMessages,
 		unavailableOfferingsCache: unavailableOfferingsCache,
+		subnetProvider:            subnetProvider,
+		securityGroupProvider:     securityGroupProvider,
+		amiProvider:               amiProvider,
+		nodeClassEvents:           nodeClassEvents,
 		parser:                    NewEventParser(DefaultParsers...),
 		cm:                        pretty.NewChangeMonitor(),
 	}
@@ -114,6 +141,11 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	}
 	errs := make([]error, len(sqsMessages))
 	workqueue.ParallelizeUntil(ctx, 10, len(sqsMessages), func(i int) {
+		if !isForCluster(ctx, sqsMessages[i]) {
+			// The queue is shared with other clusters, so the message is returned to the queue for them to handle
+			errs[i] = c.releaseMessage(ctx, sqsMessages[i])
+			return
+		}
 		msg, e := c.parseMessage(sqsMessages[i])
 		if e != nil {
 			// If we fail to parse, then we should delete the message but still log the error
@@ -121,7 +153,15 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
 			return
 		}
+		if !c.claimMessage(msg) {
+			// The event was delivered to another interruption queue as well, and has already been handled
+			log.FromContext(ctx).WithValues("event-id", msg.EventID()).V(1).Info("skipping interruption message which was already handled")
+			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
+			return
+		}
 		if e = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg); e != nil {
+			// The message is retried from this queue, so it must not be skipped as a duplicate when it's received again
+			c.handledMessages.Delete(msg.EventID())
 			errs[i] = fmt.Errorf("handling message, %w", e)
 			return
 		}
@@ -133,13 +173,32 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
-		Named("interruption").
+		Named(c.Name(ctx)).
 		WatchesRawSource(singleton.Source()).
 		Complete(singleton.AsReconciler(c))
 }
 
+// Name returns the name of the controller. Controller names must be unique, so the controllers of each queue are named
+// after their queue when multiple interruption queues are configured.
+func (c *Controller) Name(ctx context.Context) string {
+	if len(options.FromContext(ctx).InterruptionQueues()) <= 1 {
+		return "interruption"
+	}
+	return fmt.Sprintf("interruption.%s.%s", c.sqsProvider.Region(), c.sqsProvider.Name())
+}
+
+// claimMessage returns true if the message should be handled by this controller. Events which are delivered to
+// multiple interruption queues, e.g. while migrating between queues, are only handled by the first controller that
+// receives them.
+func (c *Controller) claimMessage(msg messages.Message) bool {
+	if msg.EventID() == "" {
+		return true
+	}
+	return c.handledMessages.Add(msg.EventID(), nil, cache.HandledInterruptionMessagesTTL) == nil
+}
+
 // parseMessage parses the passed SQS message into an internal Message interface
 func (c *Controller) parseMessage(raw *sqstypes.Message) (messages.Message, error) {
 	// No message to parse in this case
@@ -163,6 +222,13 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	if msg.Kind() == messages.NoOpKind {
 		return nil
 	}
+	if msg.Kind() == messages.ResourceChangeKind {
+		if err = c.handleResourceChange(ctx, msg.(resourcechange.Message)); err != nil {
+			return fmt.Errorf("invalidating cached resources, %w", err)
+		}
+		MessageLatency.Observe(time.Since(msg.StartTime()).Seconds(), nil)
+		return nil
+	}
 	for _, instanceID := range msg.EC2InstanceIDs() {
 		nodeClaim, ok := nodeClaimInstanceIDMap[instanceID]
 		if !ok {
@@ -180,9 +246,59 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	return nil
 }
 
+// handleResourceChange invalidates the cached resources of the changed type and requeues every EC2NodeClass, so that
+// the resolved resources in EC2NodeClass status reflect the change without waiting for the caches to expire
+func (c *Controller) handleResourceChange(ctx context.Context, msg resourcechange.Message) error {
+	switch msg.ResourceType {
+	case resourcechange.ResourceTypeSubnet:
+		c.subnetProvider.Invalidate()
+	case resourcechange.ResourceTypeSecurityGroup:
+		c.securityGroupProvider.Invalidate()
+	case resourcechange.ResourceTypeImage:
+		c.amiProvider.Invalidate()
+	}
+	log.FromContext(ctx).WithValues("resource-type", msg.ResourceType, "resources", msg.Resources).V(1).Info("invalidated cached resources from resource change message")
+	if c.nodeClassEvents == nil {
+		return nil
+	}
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	for i := range nodeClassList.Items {
+		c.nodeClassEvents <- event.GenericEvent{Object: &nodeClassList.Items[i]}
+	}
+	return nil
+}
+
+// isForCluster returns true if the passed SQS message is intended for this cluster. When an interruption queue message
+// attribute is configured, only messages whose attribute matches the cluster name are intended for this cluster.
+func isForCluster(ctx context.Context, msg *sqstypes.Message) bool {
+	attribute := options.FromContext(ctx).InterruptionQueueMessageAttribute
+	if attribute == "" {
+		return true
+	}
+	value, ok := msg.MessageAttributes[attribute]
+	return ok && aws.ToString(value.StringValue) == options.FromContext(ctx).ClusterName
+}
+
+// releaseMessage returns the passed SQS message to the queue and fires a metric for the release
+func (c *Controller) releaseMessage(ctx context.Context, msg *sqstypes.Message) error {
+	if err := c.sqsProvider.ReleaseSQSMessage(ctx, msg); err != nil {
+		return fmt.Errorf("releasing sqs message, %w", err)
+	}
+	ReleasedMessages.Inc(nil)
+	return nil
+}
+
 // deleteMessage removes the passed SQS message from the queue and fires a metric for the deletion
 func (c *Controller) deleteMessage(ctx context.Context, msg *sqstypes.Message) error {
 	if err := c.sqsProvider.DeleteSQSMessage(ctx, msg); err != nil {
+		// The receipt handle expires along with the visibility timeout of the message, after which the message is
+		// received again and skipped if it was already handled
+		if awserrors.IsNotFound(err) {
+			return nil
+		}
 		return fmt.Errorf("deleting sqs message, %w", err)
 	}
 	DeletedMessages.Inc(nil)
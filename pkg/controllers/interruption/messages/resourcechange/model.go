@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcechange
+
+import (
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// ResourceType is the type of an EC2 resource which can be selected by an EC2NodeClass
+type ResourceType string
+
+const (
+	ResourceTypeSubnet        ResourceType = "subnet"
+	ResourceTypeSecurityGroup ResourceType = "security-group"
+	ResourceTypeImage         ResourceType = "image"
+)
+
+var resourceTypes = []ResourceType{ResourceTypeSubnet, ResourceTypeSecurityGroup, ResourceTypeImage}
+
+// Message is a change to an EC2 resource which can be selected by an EC2NodeClass. It's parsed from either the AWS
+// EventBridge schema aws.tag@TagChangeOnResource v1 or aws.ec2@AWSAPICallViaCloudTrail v1.
+type Message struct {
+	messages.Metadata
+
+	ResourceType ResourceType
+}
+
+func (Message) EC2InstanceIDs() []string {
+	return []string{}
+}
+
+func (Message) Kind() messages.Kind {
+	return messages.ResourceChangeKind
+}
@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcechange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// apiCallResourceTypes are the EC2 API calls which change resources selected by EC2NodeClasses, other than tag
+// changes which are received as tag change events
+var apiCallResourceTypes = map[string]ResourceType{
+	"CreateSubnet":          ResourceTypeSubnet,
+	"DeleteSubnet":          ResourceTypeSubnet,
+	"ModifySubnetAttribute": ResourceTypeSubnet,
+	"CreateSecurityGroup":   ResourceTypeSecurityGroup,
+	"DeleteSecurityGroup":   ResourceTypeSecurityGroup,
+	"DeregisterImage":       ResourceTypeImage,
+}
+
+// TagChangeParser parses messages defined by the AWS EventBridge schema aws.tag@TagChangeOnResource v1
+type TagChangeParser struct{}
+
+type tagChangeMessage struct {
+	messages.Metadata
+
+	Detail struct {
+		Service      string `json:"service"`
+		ResourceType string `json:"resource-type"`
+	} `json:"detail"`
+}
+
+func (p TagChangeParser) Parse(raw string) (messages.Message, error) {
+	msg := tagChangeMessage{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarhsalling the message as TagChangeOnResource, %w", err)
+	}
+	// We ignore tag changes on resources which can't be selected by an EC2NodeClass
+	if msg.Detail.Service != "ec2" || !lo.Contains(resourceTypes, ResourceType(msg.Detail.ResourceType)) {
+		return nil, nil
+	}
+	return Message{Metadata: msg.Metadata, ResourceType: ResourceType(msg.Detail.ResourceType)}, nil
+}
+
+func (p TagChangeParser) Version() string {
+	return "0"
+}
+
+func (p TagChangeParser) Source() string {
+	return "aws.tag"
+}
+
+func (p TagChangeParser) DetailType() string {
+	return "Tag Change on Resource"
+}
+
+// APICallParser parses messages defined by the AWS EventBridge schema aws.ec2@AWSAPICallViaCloudTrail v1
+type APICallParser struct{}
+
+type apiCallMessage struct {
+	messages.Metadata
+
+	Detail struct {
+		EventName string `json:"eventName"`
+		ErrorCode string `json:"errorCode"`
+	} `json:"detail"`
+}
+
+func (p APICallParser) Parse(raw string) (messages.Message, error) {
+	msg := apiCallMessage{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarhsalling the message as AWSAPICallViaCloudTrail, %w", err)
+	}
+	resourceType, ok := apiCallResourceTypes[msg.Detail.EventName]
+	// We ignore API calls which failed or which don't change resources that can be selected by an EC2NodeClass
+	if !ok || msg.Detail.ErrorCode != "" {
+		return nil, nil
+	}
+	return Message{Metadata: msg.Metadata, ResourceType: resourceType}, nil
+}
+
+func (p APICallParser) Version() string {
+	return "0"
+}
+
+func (p APICallParser) Source() string {
+	return "aws.ec2"
+}
+
+func (p APICallParser) DetailType() string {
+	return "AWS API Call via CloudTrail"
+}
@@ -27,6 +27,7 @@ type Parser interface {
 }
 
 type Message interface {
+	EventID() string
 	EC2InstanceIDs() []string
 	Kind() Kind
 	StartTime() time.Time
@@ -40,6 +41,7 @@ const (
 	SpotInterruptionKind        Kind = "spot_interrupted"
 	InstanceStoppedKind         Kind = "instance_stopped"
 	InstanceTerminatedKind      Kind = "instance_terminated"
+	ResourceChangeKind          Kind = "resource_change"
 	NoOpKind                    Kind = "no_op"
 )
 
@@ -57,3 +59,8 @@ type Metadata struct {
 func (m Metadata) StartTime() time.Time {
 	return m.Time
 }
+
+// EventID returns the ID of the event, which is the same for every target that the event is delivered to
+func (m Metadata) EventID() string {
+	return m.ID
+}
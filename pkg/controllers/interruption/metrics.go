@@ -48,6 +48,16 @@ var (
 		},
 		[]string{},
 	)
+	ReleasedMessages = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "released_messages_total",
+			Help:      "Count of messages returned to the SQS queue because they were intended for another cluster.",
+		},
+		[]string{},
+	)
 	MessageLatency = opmetrics.NewPrometheusHistogram(
 		crmetrics.Registry,
 		prometheus.HistogramOpts{
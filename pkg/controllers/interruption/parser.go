@@ -23,6 +23,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/noop"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/resourcechange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
@@ -56,6 +57,8 @@ var (
 		spotinterruption.Parser{},
 		scheduledchange.Parser{},
 		rebalancerecommendation.Parser{},
+		resourcechange.TagChangeParser{},
+		resourcechange.APICallParser{},
 	}
 )
 
@@ -27,6 +27,7 @@ import (
 	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/aws/smithy-go"
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -41,6 +43,7 @@ import (
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
@@ -73,8 +76,10 @@ var env *coretest.Environment
 var sqsapi *fake.SQSAPI
 var sqsProvider *sqs.DefaultProvider
 var unavailableOfferingsCache *awscache.UnavailableOfferings
+var handledMessages *cache.Cache
 var fakeClock *clock.FakeClock
 var controller *interruption.Controller
+var nodeClassEvents chan event.GenericEvent
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -92,7 +97,10 @@ var _ = BeforeSuite(func() {
 	sqsProvider = lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/test-cluster", fake.DefaultRegion, fake.DefaultAccount)))
 	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
-	controller = interruption.NewController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache)
+	nodeClassEvents = make(chan event.GenericEvent, 10)
+	handledMessages = cache.New(awscache.HandledInterruptionMessagesTTL, awscache.DefaultCleanupInterval)
+	controller = interruption.NewController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, handledMessages, unavailableOfferingsCache,
+		awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, nodeClassEvents)
 })
 
 var _ = AfterSuite(func() {
@@ -102,6 +110,7 @@ var _ = AfterSuite(func() {
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	unavailableOfferingsCache.Flush()
+	handledMessages.Flush()
 	sqsapi.Reset()
 })
 
@@ -257,6 +266,183 @@ var _ = Describe("InterruptionHandling", func() {
 			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
 		})
 	})
+	Context("Resource Changes", func() {
+		var nodeClass *v1.EC2NodeClass
+		BeforeEach(func() {
+			nodeClass = test.EC2NodeClass()
+			ExpectApplied(ctx, env.Client, nodeClass)
+			_, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SubnetCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(awsEnv.SecurityGroupCache.ItemCount()).To(BeNumerically(">", 0))
+		})
+		AfterEach(func() {
+			for len(nodeClassEvents) > 0 {
+				<-nodeClassEvents
+			}
+		})
+		It("should invalidate cached subnets and requeue EC2NodeClasses when a subnet's tags change", func() {
+			ExpectMessagesCreated(tagChangeMessage("subnet", "subnet-test1"))
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(awsEnv.SubnetCache.ItemCount()).To(Equal(0))
+			Expect(awsEnv.SecurityGroupCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(nodeClassEvents).To(HaveLen(1))
+			Expect((<-nodeClassEvents).Object.GetName()).To(Equal(nodeClass.Name))
+		})
+		It("should invalidate cached security groups when a security group is deleted", func() {
+			ExpectMessagesCreated(apiCallMessage("DeleteSecurityGroup", ""))
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(awsEnv.SecurityGroupCache.ItemCount()).To(Equal(0))
+			Expect(awsEnv.SubnetCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(nodeClassEvents).To(HaveLen(1))
+		})
+		It("should ignore API calls which failed", func() {
+			ExpectMessagesCreated(apiCallMessage("DeleteSecurityGroup", "DependencyViolation"))
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(awsEnv.SecurityGroupCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(nodeClassEvents).To(BeEmpty())
+		})
+		It("should ignore tag changes on resources which aren't selected by EC2NodeClasses", func() {
+			ExpectMessagesCreated(tagChangeMessage("vpc", "vpc-test1"))
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(awsEnv.SubnetCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(awsEnv.SecurityGroupCache.ItemCount()).To(BeNumerically(">", 0))
+			Expect(nodeClassEvents).To(BeEmpty())
+		})
+	})
+	Context("Shared Queues", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueueMessageAttribute: lo.ToPtr("karpenter.sh/cluster")}))
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should handle messages with a message attribute matching the cluster name", func() {
+			ExpectMessagesCreatedWithAttributes(map[string]string{"karpenter.sh/cluster": options.FromContext(ctx).ClusterName},
+				spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(0))
+		})
+		It("should release messages with a message attribute for another cluster", func() {
+			ExpectMessagesCreatedWithAttributes(map[string]string{"karpenter.sh/cluster": "other-cluster"},
+				spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(0))
+			Expect(sqsapi.ChangeMessageVisibilityBehavior.SuccessfulCalls()).To(Equal(1))
+			input := sqsapi.ChangeMessageVisibilityBehavior.CalledWithInput.Pop()
+			Expect(input.VisibilityTimeout).To(BeNumerically("==", 0))
+		})
+		It("should release messages without the message attribute", func() {
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(0))
+			Expect(sqsapi.ChangeMessageVisibilityBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("Multiple Queues", func() {
+	var secondController *interruption.Controller
+	var secondProvider *sqs.DefaultProvider
+	var node *corev1.Node
+	var nodeClaim *karpv1.NodeClaim
+	BeforeEach(func() {
+		secondProvider = lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.us-east-1.amazonaws.com/%s/test-cluster", fake.DefaultAccount)))
+		cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+			env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+		secondController = interruption.NewController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), secondProvider, handledMessages, unavailableOfferingsCache,
+			awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, nodeClassEvents)
+		nodeClaim, node = coretest.NodeClaimAndNode(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+	})
+	It("should only handle an event once when it's delivered to multiple queues", func() {
+		msg := spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+		ExpectMessagesCreated(msg)
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+
+		// The NodeClaim is recreated so that handling the event again would delete it
+		nodeClaim, node = coretest.NodeClaimAndNode(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: "default"}},
+			Status:     karpv1.NodeClaimStatus{ProviderID: nodeClaim.Status.ProviderID},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectMessagesCreated(msg)
+		ExpectSingletonReconciled(ctx, secondController)
+		ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(2))
+	})
+	It("should handle different events from each queue", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectSingletonReconciled(ctx, secondController)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+	It("should name the controllers after their queue when multiple queues are configured", func() {
+		Expect(secondController.Name(ctx)).To(Equal("interruption"))
+		multiQueueCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue: lo.ToPtr(fmt.Sprintf("test-cluster,https://sqs.us-east-1.amazonaws.com/%s/test-cluster", fake.DefaultAccount)),
+		}))
+		Expect(controller.Name(multiQueueCtx)).To(Equal(fmt.Sprintf("interruption.%s.test-cluster", fake.DefaultRegion)))
+		Expect(secondController.Name(multiQueueCtx)).To(Equal("interruption.us-east-1.test-cluster"))
+	})
+	It("should resolve the region of a queue from its URL", func() {
+		Expect(secondProvider.Region()).To(Equal("us-east-1"))
+		Expect(sqs.QueueRegion("https://eu-west-1.queue.amazonaws.com/000000000000/test-cluster")).To(Equal("eu-west-1"))
+		Expect(sqs.QueueRegion("test-cluster")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("FIFO Queues", func() {
+	It("should set the message group and deduplication ID when sending to a FIFO queue", func() {
+		provider := lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/test-cluster.fifo", fake.DefaultRegion, fake.DefaultAccount)))
+		Expect(provider.FIFO()).To(BeTrue())
+		_, err := provider.SendMessage(ctx, spotInterruptionMessage(fake.InstanceID()))
+		Expect(err).ToNot(HaveOccurred())
+
+		input := sqsapi.SendMessageBehavior.CalledWithInput.Pop()
+		Expect(aws.ToString(input.MessageGroupId)).ToNot(BeEmpty())
+		Expect(aws.ToString(input.MessageDeduplicationId)).ToNot(BeEmpty())
+	})
+	It("should not set the message group when sending to a standard queue", func() {
+		Expect(sqsProvider.FIFO()).To(BeFalse())
+		_, err := sqsProvider.SendMessage(ctx, spotInterruptionMessage(fake.InstanceID()))
+		Expect(err).ToNot(HaveOccurred())
+
+		input := sqsapi.SendMessageBehavior.CalledWithInput.Pop()
+		Expect(input.MessageGroupId).To(BeNil())
+		Expect(input.MessageDeduplicationId).To(BeNil())
+	})
 })
 
 var _ = Describe("Error Handling", func() {
@@ -267,6 +453,17 @@ var _ = Describe("Error Handling", func() {
 		sqsapi.ReceiveMessageBehavior.Error.Set(smithyErrWithCode("AccessDenied"), fake.MaxCalls(0))
 		_ = ExpectSingletonReconcileFailed(ctx, controller)
 	})
+	It("should not return an error when the receipt handle of a message has expired", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
+		sqsapi.DeleteMessageBehavior.Error.Set(smithyErrWithCode("ReceiptHandleIsInvalid"))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(sqsapi.DeleteMessageBehavior.FailedCalls()).To(Equal(1))
+	})
+	It("should return an error when a message can't be deleted", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
+		sqsapi.DeleteMessageBehavior.Error.Set(smithyErrWithCode("AccessDenied"))
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+	})
 	It("should not return an error when deleting a nodeClaim that is already deleted", func() {
 		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
 		ExpectSingletonReconciled(ctx, controller)
@@ -287,6 +484,23 @@ func ExpectMessagesCreated(messages ...interface{}) {
 	)
 }
 
+func ExpectMessagesCreatedWithAttributes(attributes map[string]string, messages ...interface{}) {
+	raw := lo.Map(messages, func(m interface{}, _ int) *sqstypes.Message {
+		return &sqstypes.Message{
+			Body:      aws.String(string(lo.Must(json.Marshal(m)))),
+			MessageId: aws.String(string(uuid.NewUUID())),
+			MessageAttributes: lo.MapValues(attributes, func(v string, _ string) sqstypes.MessageAttributeValue {
+				return sqstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+			}),
+		}
+	})
+	sqsapi.ReceiveMessageBehavior.Output.Set(
+		&servicesqs.ReceiveMessageOutput{
+			Messages: lo.FromSlicePtr(raw),
+		},
+	)
+}
+
 func smithyErrWithCode(code string) smithy.APIError {
 	return &smithy.GenericAPIError{
 		Code:    code,
@@ -336,6 +550,42 @@ func stateChangeMessage(involvedInstanceID, state string) statechange.Message {
 	}
 }
 
+func tagChangeMessage(resourceType, resourceID string) map[string]any {
+	return map[string]any{
+		"version":     "0",
+		"account":     defaultAccountID,
+		"detail-type": "Tag Change on Resource",
+		"id":          string(uuid.NewUUID()),
+		"region":      fake.DefaultRegion,
+		"resources":   []string{fmt.Sprintf("arn:aws:ec2:%s:%s:%s/%s", fake.DefaultRegion, defaultAccountID, resourceType, resourceID)},
+		"source":      "aws.tag",
+		"time":        time.Now(),
+		"detail": map[string]any{
+			"changed-tag-keys": []string{"karpenter.sh/discovery"},
+			"service":          "ec2",
+			"resource-type":    resourceType,
+		},
+	}
+}
+
+func apiCallMessage(eventName, errorCode string) map[string]any {
+	return map[string]any{
+		"version":     "0",
+		"account":     defaultAccountID,
+		"detail-type": "AWS API Call via CloudTrail",
+		"id":          string(uuid.NewUUID()),
+		"region":      fake.DefaultRegion,
+		"resources":   []string{},
+		"source":      ec2Source,
+		"time":        time.Now(),
+		"detail": lo.OmitByValues(map[string]any{
+			"eventSource": "ec2.amazonaws.com",
+			"eventName":   eventName,
+			"errorCode":   errorCode,
+		}, []any{""}),
+	}
+}
+
 func scheduledChangeMessage(involvedInstanceID string) scheduledchange.Message {
 	return scheduledchange.Message{
 		Metadata: messages.Metadata{
@@ -0,0 +1,177 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package boottime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+)
+
+// Controller observes the time it takes for launched instances to become initialized nodes and feeds these boot
+// durations into a Model. When adaptive registration timeouts are enabled, NodeClaims which haven't registered
+// within the timeout learned for their instance family and AMI family are deleted so that the capacity can be
+// relaunched, rather than waiting for the registration TTL of the liveness controller.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	clk           clock.Clock
+	model         *Model
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, clk clock.Clock, model *Model) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		clk:           clk,
+		model:         model,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.boottime")
+
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched)
+	if launched == nil || !launched.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	if registered := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeRegistered); registered != nil && registered.IsTrue() {
+		if err := c.observeRegistration(ctx, nodeClaim, registered.LastTransitionTime.Sub(launched.LastTransitionTime.Time)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	key, err := c.keyFor(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if initialized := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeInitialized); initialized != nil && initialized.IsTrue() {
+		return reconcile.Result{}, client.IgnoreNotFound(c.observe(ctx, nodeClaim, key, initialized.LastTransitionTime.Sub(launched.LastTransitionTime.Time)))
+	}
+	if registered := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeRegistered); registered != nil && registered.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	if !options.FromContext(ctx).FeatureGates.AdaptiveRegistrationTTL {
+		return reconcile.Result{}, nil
+	}
+	timeout, ok := c.registrationTimeout(ctx, key)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	if remaining := timeout - c.clk.Since(launched.LastTransitionTime.Time); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).WithValues("instance-family", key.InstanceFamily, "ami-family", key.AMIFamily, "registration-timeout", timeout).
+		V(1).Info("deleting nodeclaim, learned registration timeout exceeded")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.boottime").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func (c *Controller) observe(ctx context.Context, nodeClaim *karpv1.NodeClaim, key Key, d time.Duration) error {
+	if ok, err := c.markObserved(ctx, nodeClaim, v1.AnnotationBootDurationObserved); !ok || err != nil {
+		return err
+	}
+	c.model.Observe(key, d)
+	BootDurationSeconds.Observe(d.Seconds(), map[string]string{
+		instanceFamilyLabel: key.InstanceFamily,
+		amiFamilyLabel:      key.AMIFamily,
+	})
+	if timeout, ok := c.registrationTimeout(ctx, key); ok {
+		RegistrationTimeoutSeconds.Set(timeout.Seconds(), map[string]string{
+			instanceFamilyLabel: key.InstanceFamily,
+			amiFamilyLabel:      key.AMIFamily,
+		})
+	}
+	return nil
+}
+
+// observeRegistration records the time it took for the node of a launched instance to register, which is the final
+// phase of launching a node for a NodeClaim
+func (c *Controller) observeRegistration(ctx context.Context, nodeClaim *karpv1.NodeClaim, d time.Duration) error {
+	if ok, err := c.markObserved(ctx, nodeClaim, v1.AnnotationRegistrationDurationObserved); !ok || err != nil {
+		return err
+	}
+	instance.ObserveLaunchPhase(nodeClaim.Labels[karpv1.NodePoolLabelKey], instance.PhaseNodeRegistration, d)
+	return nil
+}
+
+// markObserved annotates the NodeClaim with the annotation, which records that a duration of the NodeClaim has been
+// observed, so that it's observed at most once for the lifetime of the NodeClaim. It returns false if the NodeClaim
+// was already annotated.
+func (c *Controller) markObserved(ctx context.Context, nodeClaim *karpv1.NodeClaim, annotation string) (bool, error) {
+	if _, ok := nodeClaim.Annotations[annotation]; ok {
+		return false, nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{annotation: "true"})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return false, fmt.Errorf("patching nodeclaim, %w", err)
+	}
+	return true, nil
+}
+
+// registrationTimeout returns the registration timeout learned for the key, bounded by adaptive-registration-ttl-max
+func (c *Controller) registrationTimeout(ctx context.Context, key Key) (time.Duration, bool) {
+	timeout, ok := c.model.RegistrationTimeout(key)
+	if !ok {
+		return 0, false
+	}
+	return min(timeout, options.FromContext(ctx).AdaptiveRegistrationTTLMax), true
+}
+
+func (c *Controller) keyFor(ctx context.Context, nodeClaim *karpv1.NodeClaim) (Key, error) {
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return Key{}, fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	return Key{
+		InstanceFamily: nodeClaim.Labels[v1.LabelInstanceFamily],
+		AMIFamily:      nodeClass.AMIFamily(),
+	}, nil
+}
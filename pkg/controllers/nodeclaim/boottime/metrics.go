@@ -0,0 +1,52 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package boottime
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	instanceFamilyLabel = "instance_family"
+	amiFamilyLabel      = "ami_family"
+)
+
+var (
+	BootDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodeClaimSubsystem,
+			Name:      "boot_duration_seconds",
+			Help:      "Duration between an instance being launched and its node becoming initialized. Broken down by instance family and AMI family.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{instanceFamilyLabel, amiFamilyLabel},
+	)
+	RegistrationTimeoutSeconds = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodeClaimSubsystem,
+			Name:      "registration_timeout_seconds",
+			Help:      "Registration timeout learned from the observed boot durations. Broken down by instance family and AMI family.",
+		},
+		[]string{instanceFamilyLabel, amiFamilyLabel},
+	)
+)
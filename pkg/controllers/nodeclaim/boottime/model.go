@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package boottime
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSamples is the number of most recent boot durations that are retained for each key
+	maxSamples = 100
+	// minSamples is the number of boot durations that must be observed for a key before a registration timeout is learned
+	minSamples = 10
+	// quantile is the quantile of the observed boot durations that the registration timeout is derived from
+	quantile = 0.99
+	// headroom is the multiplier applied to the quantile to tolerate boot durations which haven't been observed yet
+	headroom = 1.5
+)
+
+// MinRegistrationTimeout is the lower bound of a learned registration timeout. Registration timeouts are never
+// shorter than this, regardless of how quickly instances of a family have booted in the past. The upper bound is
+// configured with the adaptive-registration-ttl-max setting.
+var MinRegistrationTimeout = 3 * time.Minute
+
+// Key identifies the population of instances whose boot durations are modeled together
+type Key struct {
+	InstanceFamily string
+	AMIFamily      string
+}
+
+// Model tracks the most recent boot durations for each (instance family, AMI family) pair and uses the
+// observed distribution to derive a registration timeout for newly launched instances.
+type Model struct {
+	mu      sync.RWMutex
+	samples map[Key][]time.Duration
+}
+
+func NewModel() *Model {
+	return &Model{samples: map[Key][]time.Duration{}}
+}
+
+// Observe records the boot duration of an instance, evicting the oldest sample for the key if the key is full
+func (m *Model) Observe(key Key, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	samples := append(m.samples[key], d)
+	if len(samples) > maxSamples {
+		samples = samples[len(samples)-maxSamples:]
+	}
+	m.samples[key] = samples
+}
+
+// RegistrationTimeout returns the learned registration timeout for the key. The second return value is false
+// if not enough boot durations have been observed for the key to learn a timeout.
+func (m *Model) RegistrationTimeout(key Key) (time.Duration, bool) {
+	m.mu.RLock()
+	samples := slices.Clone(m.samples[key])
+	m.mu.RUnlock()
+
+	if len(samples) < minSamples {
+		return 0, false
+	}
+	slices.Sort(samples)
+	idx := int(math.Ceil(quantile*float64(len(samples)))) - 1
+	timeout := time.Duration(float64(samples[idx]) * headroom)
+	return max(timeout, MinRegistrationTimeout), true
+}
+
+// Reset removes all observed boot durations
+func (m *Model) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samples = map[Key][]time.Duration{}
+}
@@ -0,0 +1,309 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package boottime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/boottime"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var model *boottime.Model
+var bootTimeController *boottime.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BootTimeController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	model = boottime.NewModel()
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	bootTimeController = boottime.NewController(env.Client, cloudProvider, fakeClock, model)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	model.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Model", func() {
+	key := boottime.Key{InstanceFamily: "m5", AMIFamily: v1.AMIFamilyAL2023}
+
+	It("should not learn a registration timeout until enough boot durations have been observed", func() {
+		for range 9 {
+			model.Observe(key, time.Minute)
+		}
+		_, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeFalse())
+		model.Observe(key, time.Minute)
+		_, ok = model.RegistrationTimeout(key)
+		Expect(ok).To(BeTrue())
+	})
+	It("should derive the registration timeout from the observed boot durations", func() {
+		for i := range 100 {
+			model.Observe(key, time.Duration(i+1)*5*time.Second)
+		}
+		// The 99th percentile of the boot durations is 495s, with 50% headroom
+		timeout, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeTrue())
+		Expect(timeout).To(Equal(time.Duration(742.5 * float64(time.Second))))
+	})
+	It("should only consider the most recent boot durations", func() {
+		for range 100 {
+			model.Observe(key, 10*time.Minute)
+		}
+		for range 100 {
+			model.Observe(key, 4*time.Minute)
+		}
+		timeout, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeTrue())
+		Expect(timeout).To(Equal(6 * time.Minute))
+	})
+	It("should not learn a registration timeout shorter than the minimum", func() {
+		for range 10 {
+			model.Observe(key, 30*time.Second)
+		}
+		timeout, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeTrue())
+		Expect(timeout).To(Equal(boottime.MinRegistrationTimeout))
+	})
+	It("should learn a registration timeout longer than the registration TTL", func() {
+		windowsKey := boottime.Key{InstanceFamily: "m5", AMIFamily: v1.AMIFamilyWindows2022}
+		for range 10 {
+			model.Observe(windowsKey, 20*time.Minute)
+		}
+		timeout, ok := model.RegistrationTimeout(windowsKey)
+		Expect(ok).To(BeTrue())
+		Expect(timeout).To(Equal(30 * time.Minute))
+	})
+	It("should model instance families and AMI families independently", func() {
+		for range 10 {
+			model.Observe(key, 4*time.Minute)
+		}
+		_, ok := model.RegistrationTimeout(boottime.Key{InstanceFamily: "c5", AMIFamily: v1.AMIFamilyAL2023})
+		Expect(ok).To(BeFalse())
+		_, ok = model.RegistrationTimeout(boottime.Key{InstanceFamily: "m5", AMIFamily: v1.AMIFamilyBottlerocket})
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("BootTimeController", func() {
+	var nodeClass *v1.EC2NodeClass
+	var key boottime.Key
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		key = boottime.Key{InstanceFamily: "m5", AMIFamily: nodeClass.AMIFamily()}
+		ExpectApplied(ctx, env.Client, nodeClass)
+	})
+
+	nodeClaimWithConditions := func(launched time.Time, conditions ...status.Condition) *karpv1.NodeClaim {
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelInstanceFamily: "m5",
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+		})
+		nodeClaim.Status.Conditions = append([]status.Condition{{
+			Type:               karpv1.ConditionTypeLaunched,
+			Status:             metav1.ConditionTrue,
+			Reason:             karpv1.ConditionTypeLaunched,
+			LastTransitionTime: metav1.NewTime(launched),
+		}}, conditions...)
+		return nodeClaim
+	}
+	initialized := func(t time.Time) status.Condition {
+		return status.Condition{
+			Type:               karpv1.ConditionTypeInitialized,
+			Status:             metav1.ConditionTrue,
+			Reason:             karpv1.ConditionTypeInitialized,
+			LastTransitionTime: metav1.NewTime(t),
+		}
+	}
+
+	It("should observe the boot duration of initialized nodeclaims", func() {
+		for range 10 {
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now(), initialized(fakeClock.Now().Add(4*time.Minute)))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+		}
+		timeout, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeTrue())
+		Expect(timeout).To(Equal(6 * time.Minute))
+	})
+	It("should only observe the boot duration of a nodeclaim once", func() {
+		nodeClaim := nodeClaimWithConditions(fakeClock.Now(), initialized(fakeClock.Now().Add(4*time.Minute)))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		for range 10 {
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+		}
+		_, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeFalse())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationBootDurationObserved, "true"))
+	})
+	It("should not observe the boot duration of a nodeclaim which has already been observed", func() {
+		nodeClaim := nodeClaimWithConditions(fakeClock.Now(), initialized(fakeClock.Now().Add(4*time.Minute)))
+		nodeClaim.Annotations = map[string]string{v1.AnnotationBootDurationObserved: "true"}
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		for range 9 {
+			model.Observe(key, 4*time.Minute)
+		}
+		ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+		_, ok := model.RegistrationTimeout(key)
+		Expect(ok).To(BeFalse())
+	})
+	It("should observe the node registration phase of a nodeclaim once", func() {
+		nodeClaim := nodeClaimWithConditions(fakeClock.Now(), status.Condition{
+			Type:               karpv1.ConditionTypeRegistered,
+			Status:             metav1.ConditionTrue,
+			Reason:             karpv1.ConditionTypeRegistered,
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(90 * time.Second)),
+		})
+		nodeClaim.Labels[karpv1.NodePoolLabelKey] = nodeClaim.Name
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		for range 3 {
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+		}
+		ExpectMetricHistogramSampleCountValue("karpenter_cloudprovider_launch_phase_duration_seconds", 1, map[string]string{
+			"nodepool": nodeClaim.Name,
+			"phase":    instance.PhaseNodeRegistration,
+		})
+	})
+	Context("Adaptive Registration TTL", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdaptiveRegistrationTTL: lo.ToPtr(true)}}))
+			for range 10 {
+				model.Observe(key, 2*time.Minute)
+			}
+		})
+		It("should delete a nodeclaim which exceeds the learned registration timeout", func() {
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-4 * time.Minute))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should requeue a nodeclaim which hasn't exceeded the learned registration timeout", func() {
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-time.Minute))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			result := ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			Expect(result.RequeueAfter).To(Equal(2 * time.Minute))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete a registered nodeclaim", func() {
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-4*time.Minute), status.Condition{
+				Type:               karpv1.ConditionTypeRegistered,
+				Status:             metav1.ConditionTrue,
+				Reason:             karpv1.ConditionTypeRegistered,
+				LastTransitionTime: metav1.NewTime(fakeClock.Now()),
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete a nodeclaim without a learned registration timeout", func() {
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-14 * time.Minute))
+			nodeClaim.Labels[v1.LabelInstanceFamily] = "c5"
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should bound the learned registration timeout", func() {
+			model.Reset()
+			for range 10 {
+				model.Observe(key, 20*time.Minute)
+			}
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-16 * time.Minute))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should use a learned registration timeout up to adaptive-registration-ttl-max", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				FeatureGates:               test.FeatureGates{AdaptiveRegistrationTTL: lo.ToPtr(true)},
+				AdaptiveRegistrationTTLMax: lo.ToPtr(time.Hour),
+			}))
+			model.Reset()
+			for range 10 {
+				model.Observe(key, 20*time.Minute)
+			}
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-16 * time.Minute))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			result := ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			Expect(result.RequeueAfter).To(Equal(14 * time.Minute))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete a nodeclaim when adaptive registration timeouts are disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdaptiveRegistrationTTL: lo.ToPtr(false)}}))
+			nodeClaim := nodeClaimWithConditions(fakeClock.Now().Add(-4 * time.Minute))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, bootTimeController, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+	})
+})
@@ -0,0 +1,154 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityblock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var capacityBlockController *capacityblock.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityBlockController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	capacityBlockController = capacityblock.NewController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}))
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CapacityBlockController", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+	var endTime time.Time
+
+	BeforeEach(func() {
+		endTime = fakeClock.Now().Add(6 * time.Hour).Truncate(time.Second)
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Status: v1.EC2NodeClassStatus{
+				CapacityBlocks: []v1.CapacityBlock{{
+					ID:                     "cr-test1",
+					InstanceType:           "p5.48xlarge",
+					Zone:                   "test-zone-1a",
+					State:                  "active",
+					StartTime:              metav1.NewTime(fakeClock.Now().Add(-time.Hour).Truncate(time.Second)),
+					EndTime:                metav1.NewTime(endTime),
+					AvailableInstanceCount: 1,
+				}},
+			},
+		})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.CapacityTypeLabelKey: v1.CapacityTypeCapacityBlock,
+					v1.LabelCapacityBlockID:     "cr-test1",
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+		})
+	})
+
+	It("should requeue nodeclaims until the capacity block drain time", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", endTime.Add(-v1.CapacityBlockDrainLeadTime).Sub(fakeClock.Now()), time.Second))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should delete nodeclaims once the capacity block drain time is reached", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.SetTime(endTime.Add(-v1.CapacityBlockDrainLeadTime))
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	It("should drain nodeclaims using the latest end time of the capacity block", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.SetTime(endTime.Add(-v1.CapacityBlockDrainLeadTime))
+		nodeClass.Status.CapacityBlocks[0].EndTime = metav1.NewTime(endTime.Add(24 * time.Hour))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 24*time.Hour, time.Second))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should ignore nodeclaims which weren't launched into a capacity block", func() {
+		delete(nodeClaim.Labels, v1.LabelCapacityBlockID)
+		nodeClaim.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeOnDemand
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.SetTime(endTime)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should ignore nodeclaims whose capacity block is no longer selected by the nodeclass", func() {
+		nodeClass.Status.CapacityBlocks = nil
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.SetTime(endTime)
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+})
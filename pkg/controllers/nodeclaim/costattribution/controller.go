@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package costattribution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// syncInterval is the interval at which the workloads of a node are attributed again, since pods are bound to and
+// removed from the node over its lifetime
+const syncInterval = 5 * time.Minute
+
+// Controller tags instances with the workload that their node runs, so that the cost of the instances can be
+// attributed to teams without external tooling. The scheduler doesn't record the pods which a NodeClaim was launched
+// for on the NodeClaim, so the tags can't be part of the instance's launch tags. Instead, the workload is resolved from
+// the pods which are bound to the node: it's the namespace and the value of the cost-attribution-label of the pods which
+// request the most CPU on the node. DaemonSet pods run on every node, so they aren't attributed.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.costattribution")
+
+	label := options.FromContext(ctx).CostAttributionLabel
+	if label == "" {
+		return reconcile.Result{}, nil
+	}
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.NodeName == "" {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	pods, err := nodeutils.GetReschedulablePods(ctx, c.kubeClient, node)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing pods, %w", err)
+	}
+	tags, ok := Attribute(pods, label)
+	if !ok {
+		return reconcile.Result{RequeueAfter: syncInterval}, nil
+	}
+	inst, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting instance, %w", err))
+	}
+	// A workload without the label is attributed to its namespace alone, so the label's tag of the previous workload is
+	// removed rather than left to attribute the instance to it
+	if _, ok := tags[label]; !ok {
+		if _, tagged := inst.Tags[label]; tagged {
+			if err := c.instanceProvider.DeleteTags(ctx, id, []string{label}); err != nil {
+				return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("untagging instance, %w", err))
+			}
+			log.FromContext(ctx).WithValues("tag", label).V(1).Info("untagged instance for cost attribution")
+		}
+	}
+	// Only the tags which changed are written, since CreateTags shares a rate limit with other mutating calls
+	tags = lo.OmitBy(tags, func(k, v string) bool { return inst.Tags[k] == v })
+	if len(tags) != 0 {
+		if err := c.instanceProvider.CreateTags(ctx, id, tags); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("tagging instance, %w", err))
+		}
+		log.FromContext(ctx).WithValues("tags", tags).V(1).Info("tagged instance for cost attribution")
+	}
+	return reconcile.Result{RequeueAfter: syncInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.costattribution").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		// Ok with using the default MaxConcurrentReconciles of 1 to avoid throttling from CreateTag write API
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// Attribute returns the cost attribution tags of a node which runs the pods. The pods are grouped by their namespace and
+// the value of the label, and the group whose pods request the most CPU is attributed, with ties broken by the group
+// whose pods request the most memory, and then by name. Pods without the label are attributed to their namespace
+// alone. It returns false if there are no pods to attribute.
+func Attribute(pods []*corev1.Pod, label string) (map[string]string, bool) {
+	type workload struct{ namespace, value string }
+	groups := lo.GroupBy(pods, func(pod *corev1.Pod) workload { return workload{pod.Namespace, pod.Labels[label]} })
+	if len(groups) == 0 {
+		return nil, false
+	}
+	requests := lo.MapValues(groups, func(pods []*corev1.Pod, _ workload) corev1.ResourceList { return resources.RequestsForPods(pods...) })
+	workloads := lo.Keys(groups)
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := requests[workloads[i]], requests[workloads[j]]
+		if cmp := a.Cpu().Cmp(*b.Cpu()); cmp != 0 {
+			return cmp > 0
+		}
+		if cmp := a.Memory().Cmp(*b.Memory()); cmp != 0 {
+			return cmp > 0
+		}
+		if workloads[i].namespace != workloads[j].namespace {
+			return workloads[i].namespace < workloads[j].namespace
+		}
+		return workloads[i].value < workloads[j].value
+	})
+	tags := map[string]string{v1.CostAttributionNamespaceTagKey: workloads[0].namespace}
+	if workloads[0].value != "" {
+		tags[label] = workloads[0].value
+	}
+	return tags, true
+}
@@ -0,0 +1,231 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package costattribution_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/costattribution"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var costAttributionController *costattribution.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CostAttributionController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	costAttributionController = costattribution.NewController(env.Client, cloudProvider, awsEnv.InstanceProvider)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CostAttributionLabel: lo.ToPtr("team")}))
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+// pod returns a pod in the namespace which requests the CPU, labeled with the team if it isn't empty
+func pod(namespace, team, cpu string) *corev1.Pod {
+	return coretest.Pod(coretest.PodOptions{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Labels:    lo.OmitByValues(map[string]string{"team": team}, []string{""}),
+		},
+		ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+	})
+}
+
+var _ = Describe("CostAttributionController", func() {
+	var instanceID string
+	var nodeClaim *karpv1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+			State: &ec2types.InstanceState{
+				Name: ec2types.InstanceStateNameRunning,
+			},
+			Tags: []ec2types.Tag{
+				{
+					Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
+					Value: aws.String("owned"),
+				},
+				{
+					Key:   aws.String(karpv1.NodePoolLabelKey),
+					Value: aws.String("default"),
+				},
+				{
+					Key:   aws.String(v1.EKSClusterNameTagKey),
+					Value: aws.String(options.FromContext(ctx).ClusterName),
+				},
+			},
+			PrivateDnsName: aws.String(fake.PrivateDNSName()),
+			Placement: &ec2types.Placement{
+				AvailabilityZone: aws.String(fake.DefaultRegion),
+			},
+			InstanceId:   aws.String(instanceID),
+			InstanceType: "m5.large",
+		})
+		for _, namespace := range []string{"payments", "search"} {
+			ExpectApplied(ctx, env.Client, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+		}
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+				NodeName:   node.Name,
+			},
+		})
+	})
+
+	tags := func() map[string]string {
+		instance, err := awsEnv.InstanceProvider.Get(ctx, instanceID)
+		Expect(err).ToNot(HaveOccurred())
+		return instance.Tags
+	}
+	bind := func(pods ...*corev1.Pod) {
+		for _, p := range pods {
+			ExpectApplied(ctx, env.Client, p)
+			ExpectManualBinding(ctx, env.Client, p, node)
+		}
+	}
+
+	It("should tag the instance with the workload whose pods request the most CPU", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		bind(pod("payments", "payments-team", "1"), pod("payments", "payments-team", "1"), pod("search", "search-team", "1500m"))
+		result := ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		Expect(tags()).To(HaveKeyWithValue(v1.CostAttributionNamespaceTagKey, "payments"))
+		Expect(tags()).To(HaveKeyWithValue("team", "payments-team"))
+	})
+	It("should retag the instance when its workload changes", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		payments := pod("payments", "payments-team", "1")
+		bind(payments, pod("search", "search-team", "500m"))
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(tags()).To(HaveKeyWithValue("team", "payments-team"))
+
+		ExpectDeleted(ctx, env.Client, payments)
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(tags()).To(HaveKeyWithValue(v1.CostAttributionNamespaceTagKey, "search"))
+		Expect(tags()).To(HaveKeyWithValue("team", "search-team"))
+	})
+	It("should only tag the namespace of a workload without the label", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		bind(pod("payments", "", "1"))
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+
+		Expect(tags()).To(HaveKeyWithValue(v1.CostAttributionNamespaceTagKey, "payments"))
+		Expect(tags()).ToNot(HaveKey("team"))
+	})
+	It("should remove the label's tag when the workload changes to one without the label", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		payments := pod("payments", "payments-team", "1")
+		bind(payments, pod("search", "", "500m"))
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(tags()).To(HaveKeyWithValue("team", "payments-team"))
+
+		ExpectDeleted(ctx, env.Client, payments)
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(tags()).To(HaveKeyWithValue(v1.CostAttributionNamespaceTagKey, "search"))
+		Expect(tags()).ToNot(HaveKey("team"))
+		Expect(awsEnv.EC2API.DeleteTagsBehavior.Calls()).To(Equal(1))
+	})
+	It("should not tag the instance when no pods are bound to its node", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(tags()).ToNot(HaveKey(v1.CostAttributionNamespaceTagKey))
+		Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(0))
+	})
+	It("should not tag the instance when the tags are up to date", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		bind(pod("payments", "payments-team", "1"))
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(1))
+	})
+	It("should not tag the instance when cost attribution is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		bind(pod("payments", "payments-team", "1"))
+		result := ExpectObjectReconciled(ctx, env.Client, costAttributionController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(tags()).ToNot(HaveKey(v1.CostAttributionNamespaceTagKey))
+	})
+})
+
+var _ = Describe("Attribute", func() {
+	It("should not attribute a node without pods", func() {
+		_, ok := costattribution.Attribute(nil, "team")
+		Expect(ok).To(BeFalse())
+	})
+	It("should break ties by name", func() {
+		tags, ok := costattribution.Attribute([]*corev1.Pod{pod("search", "search-team", "1"), pod("payments", "payments-team", "1")}, "team")
+		Expect(ok).To(BeTrue())
+		Expect(tags).To(Equal(map[string]string{v1.CostAttributionNamespaceTagKey: "payments", "team": "payments-team"}))
+	})
+	It("should attribute pods of a namespace with different label values separately", func() {
+		tags, ok := costattribution.Attribute([]*corev1.Pod{
+			pod("shared", "payments-team", "1"), pod("shared", "search-team", "500m"), pod("shared", "search-team", "250m"),
+		}, "team")
+		Expect(ok).To(BeTrue())
+		Expect(tags).To(Equal(map[string]string{v1.CostAttributionNamespaceTagKey: "shared", "team": "payments-team"}))
+	})
+})
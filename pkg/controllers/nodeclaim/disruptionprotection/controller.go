@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruptionprotection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// syncInterval is the interval at which instance tags are checked for changes made outside of the cluster
+const syncInterval = time.Minute
+
+// Controller keeps the karpenter.sh/do-not-disrupt annotation of a Node in sync with the karpenter.sh/do-not-disrupt
+// tag of its instance. This allows automation running outside of the cluster to protect nodes from disruption, e.g.
+// while a long running job completes, and to clear that protection afterwards.
+//
+// The value which was last synced is recorded on the NodeClaim so that the side which changed since the last sync
+// wins. If both sides disagree and were never synced, the node is protected.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+	recorder         events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+		recorder:         recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.disruptionprotection")
+
+	if !options.FromContext(ctx).FeatureGates.DisruptionProtectionTagSync {
+		return reconcile.Result{}, nil
+	}
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.NodeName == "" {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	inst, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting instance, %w", err))
+	}
+
+	annotated := node.Annotations[karpv1.DoNotDisruptAnnotationKey] == "true"
+	tagged := inst.Tags[v1.DoNotDisruptTagKey] == "true"
+	synced, ok := nodeClaim.Annotations[v1.AnnotationDoNotDisruptSynced]
+	protected := resolve(annotated, tagged, synced, ok)
+
+	if tagged != protected {
+		if err := c.instanceProvider.CreateTags(ctx, id, map[string]string{v1.DoNotDisruptTagKey: strconv.FormatBool(protected)}); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("tagging instance, %w", err))
+		}
+		c.recorder.Publish(InstanceTaggedEvent(node, id, protected))
+	}
+	if annotated != protected {
+		stored := node.DeepCopy()
+		if protected {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"})
+		} else {
+			delete(node.Annotations, karpv1.DoNotDisruptAnnotationKey)
+		}
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+		}
+		c.recorder.Publish(NodeAnnotatedEvent(node, id, protected))
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationDoNotDisruptSynced: strconv.FormatBool(protected)})
+	if !equality.Semantic.DeepEqual(nodeClaim, stored) {
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	// Changes to instance tags don't generate events in the cluster, so they're picked up periodically
+	return reconcile.Result{RequeueAfter: syncInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.disruptionprotection").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&corev1.Node{}, nodeclaimutils.NodeEventHandler(c.kubeClient, c.cloudProvider)).
+		// Ok with using the default MaxConcurrentReconciles of 1 to avoid throttling from CreateTag write API
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// resolve returns whether the node should be protected from disruption, given the current state of the node
+// annotation, the instance tag and the value which was last synced between them
+func resolve(annotated, tagged bool, synced string, ok bool) bool {
+	switch {
+	case annotated == tagged:
+		return annotated
+	case !ok:
+		// Neither side can be determined to be stale, so we err on the side of protecting the node
+		return true
+	case annotated != (synced == "true"):
+		// The annotation was changed in the cluster since the last sync
+		return annotated
+	default:
+		// The tag was changed outside of the cluster since the last sync
+		return tagged
+	}
+}
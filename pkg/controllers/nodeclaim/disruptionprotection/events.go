@@ -0,0 +1,47 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruptionprotection
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func InstanceTaggedEvent(node *corev1.Node, id string, protected bool) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DisruptionProtectionTagged",
+		Message:        fmt.Sprintf("Set tag %s=%t on instance %s to match the node annotation", v1.DoNotDisruptTagKey, protected, id),
+		DedupeValues:   []string{string(node.UID), strconv.FormatBool(protected)},
+	}
+}
+
+func NodeAnnotatedEvent(node *corev1.Node, id string, protected bool) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DisruptionProtectionAnnotated",
+		Message:        fmt.Sprintf("%s disruption protection to match the %s tag on instance %s", lo.Ternary(protected, "Enabled", "Disabled"), v1.DoNotDisruptTagKey, id),
+		DedupeValues:   []string{string(node.UID), strconv.FormatBool(protected)},
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruptionprotection_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/disruptionprotection"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var recorder *coretest.EventRecorder
+var disruptionProtectionController *disruptionprotection.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DisruptionProtectionController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	disruptionProtectionController = disruptionprotection.NewController(env.Client, cloudProvider, awsEnv.InstanceProvider, recorder)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{DisruptionProtectionTagSync: lo.ToPtr(true)}}))
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("DisruptionProtectionController", func() {
+	var instanceID string
+	var nodeClaim *karpv1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+			State: &ec2types.InstanceState{
+				Name: ec2types.InstanceStateNameRunning,
+			},
+			Tags: []ec2types.Tag{
+				{
+					Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
+					Value: aws.String("owned"),
+				},
+				{
+					Key:   aws.String(karpv1.NodePoolLabelKey),
+					Value: aws.String("default"),
+				},
+			},
+			PrivateDnsName: aws.String(fake.PrivateDNSName()),
+			Placement: &ec2types.Placement{
+				AvailabilityZone: aws.String(fake.DefaultRegion),
+			},
+			InstanceId:   aws.String(instanceID),
+			InstanceType: "m5.large",
+		})
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+				NodeName:   node.Name,
+			},
+		})
+	})
+
+	tag := func() (string, bool) {
+		instance, err := awsEnv.InstanceProvider.Get(ctx, instanceID)
+		Expect(err).ToNot(HaveOccurred())
+		value, ok := instance.Tags[v1.DoNotDisruptTagKey]
+		return value, ok
+	}
+	setTag := func(value string) {
+		Expect(awsEnv.InstanceProvider.CreateTags(ctx, instanceID, map[string]string{v1.DoNotDisruptTagKey: value})).To(Succeed())
+	}
+
+	It("should tag the instance when the node is annotated", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		value, ok := tag()
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("true"))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationDoNotDisruptSynced, "true"))
+		Expect(recorder.Calls("DisruptionProtectionTagged")).To(Equal(1))
+	})
+	It("should annotate the node when the instance is tagged", func() {
+		setTag("true")
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		Expect(recorder.Calls("DisruptionProtectionAnnotated")).To(Equal(1))
+	})
+	It("should clear the node annotation when the tag is cleared outside of the cluster", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		setTag("false")
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationDoNotDisruptSynced, "false"))
+	})
+	It("should clear the tag when the node annotation is removed", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		delete(node.Annotations, karpv1.DoNotDisruptAnnotationKey)
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+		value, _ := tag()
+		Expect(value).To(Equal("false"))
+	})
+	It("should protect the node when the annotation and tag disagree and were never synced", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "false"}
+		setTag("true")
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		value, _ := tag()
+		Expect(value).To(Equal("true"))
+	})
+	It("should not modify the instance or node when they're already in sync", func() {
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(0))
+		Expect(recorder.Calls("DisruptionProtectionTagged")).To(Equal(0))
+		Expect(recorder.Calls("DisruptionProtectionAnnotated")).To(Equal(0))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationDoNotDisruptSynced, "false"))
+	})
+	It("should not sync when disruption protection tag sync is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{DisruptionProtectionTagSync: lo.ToPtr(false)}}))
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+
+		_, ok := tag()
+		Expect(ok).To(BeFalse())
+	})
+	It("should not sync nodeclaims without a node", func() {
+		nodeClaim.Status.NodeName = ""
+		setTag("true")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationDoNotDisruptSynced))
+	})
+	It("should gracefully handle a missing instance", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		awsEnv.EC2API.Instances.Delete(instanceID)
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+	})
+	It("should not sync nodeclaims which are being deleted", func() {
+		nodeClaim.Finalizers = []string{"testing/finalizer"}
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, disruptionProtectionController, nodeClaim)
+		_, ok := tag()
+		Expect(ok).To(BeFalse())
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+	})
+})
@@ -36,6 +36,8 @@ import (
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 type Controller struct {
@@ -73,13 +75,20 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	clusterProviderIDs := sets.New(lo.FilterMap(clusterNodeClaims, func(nc *karpv1.NodeClaim, _ int) (string, bool) {
 		return nc.Status.ProviderID, nc.Status.ProviderID != ""
 	})...)
+	// Instances of launches which are recorded in the launch journal of a NodeClaim that hasn't launched yet are adopted
+	// by the NodeClaim when its launch is retried, so they aren't garbage collected
+	journaledClientTokens := sets.New(lo.FilterMap(clusterNodeClaims, func(nc *karpv1.NodeClaim, _ int) (string, bool) {
+		token := nc.Annotations[v1.AnnotationLaunchClientToken]
+		return token, token != "" && nc.Status.ProviderID == ""
+	})...)
 	nodeList := &corev1.NodeList{}
 	if err = c.kubeClient.List(ctx, nodeList); err != nil {
 		return reconcile.Result{}, err
 	}
 	errs := make([]error, len(cloudNodeClaims))
 	workqueue.ParallelizeUntil(ctx, 100, len(cloudNodeClaims), func(i int) {
-		if nc := cloudNodeClaims[i]; !clusterProviderIDs.Has(nc.Status.ProviderID) && time.Since(nc.CreationTimestamp.Time) > time.Second*30 {
+		if nc := cloudNodeClaims[i]; !clusterProviderIDs.Has(nc.Status.ProviderID) && !journaledClientTokens.Has(nc.Annotations[v1.AnnotationLaunchClientToken]) &&
+			time.Since(nc.CreationTimestamp.Time) > time.Second*30 {
 			errs[i] = c.garbageCollect(ctx, cloudNodeClaims[i], nodeList)
 		}
 	})
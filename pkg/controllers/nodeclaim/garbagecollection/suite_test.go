@@ -28,6 +28,7 @@ import (
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	karpcloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -143,6 +144,37 @@ var _ = Describe("GarbageCollection", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(karpcloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
 	})
+	It("should not delete a standby instance of a warm pool", func() {
+		// Launch time was 1m ago
+		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
+		instance.Tags = append(instance.Tags, ec2types.Tag{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String("default")})
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), *instance)
+
+		ExpectSingletonReconciled(ctx, garbageCollectionController)
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should not delete an instance of a journaled launch whose NodeClaim hasn't launched", func() {
+		// Launch time was 1m ago
+		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
+		instance.Tags = append(instance.Tags, ec2types.Tag{Key: aws.String(v1.LaunchClientTokenTagKey), Value: aws.String("test-token")})
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), *instance)
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.AnnotationLaunchClientToken: "test-token"},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectSingletonReconciled(ctx, garbageCollectionController)
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+	})
 	It("should delete an instance along with the node if there is no NodeClaim owner (to quicken scheduling)", func() {
 		// Launch time was 1m ago
 		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollInterval is the interval at which the tags of an instance are checked until its NodeClaim registers, since the
+// tags can be added by automation running outside of the cluster at any time after launch
+const pollInterval = 15 * time.Second
+
+// Controller labels NodeClaims with the tags of their instances whose keys start with one of the prefixes in the
+// instance-tag-labels setting. The labels are added until the NodeClaim registers, so that they're synced to the Node
+// at registration. Tags which are added after registration aren't reflected on the Node.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.labeling")
+
+	prefixes := options.FromContext(ctx).InstanceTagLabelPrefixes()
+	if len(prefixes) == 0 || !isLabelable(nodeClaim) {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	instance, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting instance, %w", err))
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, Labels(ctx, instance.Tags, prefixes))
+	if !equality.Semantic.DeepEqual(nodeClaim, stored) {
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.labeling").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// Labels returns the tags whose keys start with one of the prefixes as labels. Tags which aren't valid labels, or which
+// would set a label that Karpenter or the kubelet manages, are skipped.
+func Labels(ctx context.Context, tags map[string]string, prefixes []string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range tags {
+		if !lo.ContainsBy(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+			continue
+		}
+		if errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...); len(errs) != 0 {
+			log.FromContext(ctx).V(1).WithValues("tag", key).Info(fmt.Sprintf("skipping instance tag which isn't a valid label, %s", strings.Join(errs, ", ")))
+			continue
+		}
+		if karpv1.IsRestrictedNodeLabel(key) {
+			log.FromContext(ctx).V(1).WithValues("tag", key).Info("skipping instance tag which is a restricted label")
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// isLabelable returns true if the NodeClaim's instance has been launched and the NodeClaim hasn't registered yet
+func isLabelable(nc *karpv1.NodeClaim) bool {
+	return nc.DeletionTimestamp.IsZero() &&
+		nc.Status.ProviderID != "" &&
+		!nc.StatusConditions().Get(karpv1.ConditionTypeRegistered).IsTrue()
+}
@@ -0,0 +1,204 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// terminatedDeliveryTimeout is how long delivering the events of a NodeClaim whose instance has been terminated is
+// retried for before the NodeClaim is released anyway, so that an unavailable webhook can't block the deletion of NodeClaims indefinitely
+const terminatedDeliveryTimeout = 5 * time.Minute
+
+// Controller publishes the lifecycle transitions of NodeClaims to the HTTP targets in lifecycle-webhook-urls, so that
+// systems such as CMDBs, license servers and security scanners can track nodes without watching the Kubernetes API.
+//
+// The events which were delivered are recorded on the NodeClaim. Launched NodeClaims are held by a finalizer until the
+// Terminated event is delivered, which is once the instance of the NodeClaim has been terminated.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	clk           clock.Clock
+	httpClient    *http.Client
+	// failures records when delivering the events of each terminated NodeClaim first failed
+	failures *cache.Cache
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		clk:           clk,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		failures:      cache.New(2*terminatedDeliveryTimeout, time.Minute),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.lifecycle")
+
+	webhooks := options.FromContext(ctx).LifecycleWebhooks()
+	// NodeClaims are only tracked once they've launched, and are released if lifecycle webhooks are disabled
+	if launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched); len(webhooks) == 0 || launched == nil || !launched.IsTrue() {
+		return reconcile.Result{}, c.removeFinalizer(ctx, nodeClaim)
+	}
+	stored := nodeClaim.DeepCopy()
+	if nodeClaim.DeletionTimestamp.IsZero() {
+		controllerutil.AddFinalizer(nodeClaim, v1.LifecycleWebhookFinalizer)
+	}
+	delivered := recorded(nodeClaim)
+	var errs error
+	for _, payload := range c.transitions(ctx, nodeClaim) {
+		if lo.Contains(delivered, payload.Event) {
+			continue
+		}
+		if errs = c.publish(ctx, webhooks, payload); errs != nil {
+			break
+		}
+		delivered = append(delivered, payload.Event)
+	}
+	if len(delivered) > 0 {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.AnnotationLifecycleWebhookEvents: strings.Join(lo.Map(delivered, func(e Event, _ int) string { return string(e) }), ","),
+		})
+	}
+	if !equality.Semantic.DeepEqual(nodeClaim, stored) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the finalizer list
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+		}
+	}
+	if !isTerminated(nodeClaim) {
+		return reconcile.Result{}, errs
+	}
+	if errs == nil {
+		errs = c.publish(ctx, webhooks, NewPayload(options.FromContext(ctx).ClusterName, nodeClaim, EventTerminated, c.clk.Now()))
+	}
+	return reconcile.Result{}, c.release(ctx, nodeClaim, errs)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.lifecycle").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// transitions returns the payloads of the transitions which the NodeClaim has been through, other than being
+// terminated, in the order that they occur in
+func (c *Controller) transitions(ctx context.Context, nodeClaim *karpv1.NodeClaim) []Payload {
+	clusterName := options.FromContext(ctx).ClusterName
+	payloads := []Payload{
+		NewPayload(clusterName, nodeClaim, EventLaunched, nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched).LastTransitionTime.Time),
+	}
+	if registered := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeRegistered); registered != nil && registered.IsTrue() {
+		payloads = append(payloads, NewPayload(clusterName, nodeClaim, EventRegistered, registered.LastTransitionTime.Time))
+	}
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		payloads = append(payloads, NewPayload(clusterName, nodeClaim, EventTerminating, nodeClaim.DeletionTimestamp.Time))
+	}
+	return payloads
+}
+
+// release removes the finalizer of a NodeClaim whose instance has been terminated. If its events fail to be delivered,
+// they're retried for terminatedDeliveryTimeout before the NodeClaim is released anyway.
+func (c *Controller) release(ctx context.Context, nodeClaim *karpv1.NodeClaim, err error) error {
+	if err != nil {
+		failedAt, ok := c.failures.Get(string(nodeClaim.UID))
+		if !ok {
+			c.failures.SetDefault(string(nodeClaim.UID), c.clk.Now())
+			return err
+		}
+		if c.clk.Since(failedAt.(time.Time)) < terminatedDeliveryTimeout {
+			return err
+		}
+		log.FromContext(ctx).Error(err, "failed delivering events to lifecycle webhooks, releasing nodeclaim")
+	}
+	c.failures.Delete(string(nodeClaim.UID))
+	return c.removeFinalizer(ctx, nodeClaim)
+}
+
+// publish delivers the payload to each of the webhooks. Payloads are delivered to all webhooks again if any of them
+// fail, since events are delivered at least once.
+func (c *Controller) publish(ctx context.Context, webhooks []string, payload Payload) error {
+	signingKey := options.FromContext(ctx).LifecycleWebhookSigningKey
+	var errs error
+	for _, webhook := range webhooks {
+		if err := deliver(ctx, c.httpClient, webhook, signingKey, payload, c.clk.Now()); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("delivering %s event to %q, %w", payload.Event, webhook, err))
+		}
+	}
+	if errs == nil {
+		log.FromContext(ctx).WithValues("event", payload.Event).V(1).Info("delivered event to lifecycle webhooks")
+	}
+	return errs
+}
+
+func (c *Controller) removeFinalizer(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
+	if !controllerutil.ContainsFinalizer(nodeClaim, v1.LifecycleWebhookFinalizer) {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	controllerutil.RemoveFinalizer(nodeClaim, v1.LifecycleWebhookFinalizer)
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the finalizer list
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("removing finalizer, %w", err))
+	}
+	return nil
+}
+
+// isTerminated returns true once the instance of a NodeClaim has been terminated, which is when its termination
+// finalizer has been removed
+func isTerminated(nodeClaim *karpv1.NodeClaim) bool {
+	return !nodeClaim.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(nodeClaim, karpv1.TerminationFinalizer)
+}
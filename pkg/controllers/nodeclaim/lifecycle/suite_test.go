@@ -0,0 +1,319 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/lifecycle"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var lifecycleController *lifecycle.Controller
+var server *httptest.Server
+var receiver *webhookReceiver
+
+// webhookReceiver records the requests which are sent to a lifecycle webhook
+type webhookReceiver struct {
+	mu         sync.Mutex
+	statusCode int
+	requests   []*http.Request
+	payloads   []lifecycle.Payload
+	bodies     [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body := lo.Must(io.ReadAll(req.Body))
+	payload := lifecycle.Payload{}
+	lo.Must0(json.Unmarshal(body, &payload))
+	r.requests = append(r.requests, req)
+	r.payloads = append(r.payloads, payload)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.statusCode)
+}
+
+func (r *webhookReceiver) Events() []lifecycle.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lo.Map(r.payloads, func(p lifecycle.Payload, _ int) lifecycle.Event { return p.Event })
+}
+
+func (r *webhookReceiver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusCode = http.StatusOK
+	r.requests = nil
+	r.payloads = nil
+	r.bodies = nil
+}
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LifecycleWebhookController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	receiver = &webhookReceiver{statusCode: http.StatusOK}
+	server = httptest.NewServer(receiver)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	lifecycleController = lifecycle.NewController(env.Client, cloudProvider, fakeClock)
+})
+var _ = AfterSuite(func() {
+	server.Close()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		LifecycleWebhookURLs:       lo.ToPtr(server.URL),
+		LifecycleWebhookSigningKey: lo.ToPtr("test-signing-key"),
+	}))
+	awsEnv.Reset()
+	receiver.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("LifecycleWebhook", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        "default",
+					corev1.LabelInstanceTypeStable: "m5.large",
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				},
+				Finalizers: []string{karpv1.TerminationFinalizer},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: "aws:///test-zone-1a/i-0123456789abcdef0",
+				ImageID:    "ami-123",
+			},
+		})
+		nodeClaim.Status.Conditions = []status.Condition{{
+			Type:               karpv1.ConditionTypeLaunched,
+			Status:             metav1.ConditionTrue,
+			Reason:             karpv1.ConditionTypeLaunched,
+			LastTransitionTime: metav1.NewTime(fakeClock.Now()),
+		}}
+	})
+	registered := func(nodeClaim *karpv1.NodeClaim) {
+		nodeClaim.Status.NodeName = "test-node"
+		nodeClaim.Status.Conditions = append(nodeClaim.Status.Conditions, status.Condition{
+			Type:               karpv1.ConditionTypeRegistered,
+			Status:             metav1.ConditionTrue,
+			Reason:             karpv1.ConditionTypeRegistered,
+			LastTransitionTime: metav1.NewTime(fakeClock.Now()),
+		})
+	}
+	terminate := func(nodeClaim *karpv1.NodeClaim) *karpv1.NodeClaim {
+		GinkgoHelper()
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		stored := nodeClaim.DeepCopy()
+		controllerutil.RemoveFinalizer(nodeClaim, karpv1.TerminationFinalizer)
+		Expect(env.Client.Patch(ctx, nodeClaim, client.MergeFrom(stored))).To(Succeed())
+		return ExpectExists(ctx, env.Client, nodeClaim)
+	}
+
+	It("should deliver the launched event of a nodeclaim", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventLaunched}))
+		payload := receiver.payloads[0]
+		Expect(payload.ID).To(Equal(string(nodeClaim.UID) + "/Launched"))
+		Expect(payload.NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(payload.NodePool).To(Equal("default"))
+		Expect(payload.NodeClass).To(Equal(nodeClass.Name))
+		Expect(payload.InstanceID).To(Equal("i-0123456789abcdef0"))
+		Expect(payload.InstanceType).To(Equal("m5.large"))
+		Expect(payload.CapacityType).To(Equal(karpv1.CapacityTypeOnDemand))
+		Expect(payload.Zone).To(Equal("test-zone-1a"))
+		Expect(payload.ImageID).To(Equal("ami-123"))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Finalizers).To(ContainElement(v1.LifecycleWebhookFinalizer))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationLifecycleWebhookEvents, "Launched"))
+	})
+	It("should sign payloads with the signing key", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		Expect(receiver.requests).To(HaveLen(1))
+		req := receiver.requests[0]
+		Expect(req.Header.Get(lifecycle.EventHeader)).To(Equal("Launched"))
+		Expect(req.Header.Get(lifecycle.SignatureHeader)).To(Equal(lifecycle.Sign("test-signing-key", req.Header.Get(lifecycle.TimestampHeader), receiver.bodies[0])))
+	})
+	It("should not sign payloads without a signing key", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			LifecycleWebhookURLs: lo.ToPtr(server.URL),
+		}))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		Expect(receiver.requests).To(HaveLen(1))
+		Expect(receiver.requests[0].Header.Get(lifecycle.SignatureHeader)).To(BeEmpty())
+	})
+	It("should deliver each event once", func() {
+		registered(nodeClaim)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		for range 3 {
+			ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		}
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventLaunched, lifecycle.EventRegistered}))
+	})
+	It("should not deliver events for nodeclaims which haven't launched", func() {
+		nodeClaim.Status.Conditions = nil
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		Expect(receiver.Events()).To(BeEmpty())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Finalizers).ToNot(ContainElement(v1.LifecycleWebhookFinalizer))
+	})
+	It("should retry events which fail to be delivered", func() {
+		receiver.statusCode = http.StatusInternalServerError
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, lifecycleController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLifecycleWebhookEvents))
+
+		receiver.statusCode = http.StatusOK
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventLaunched, lifecycle.EventLaunched}))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationLifecycleWebhookEvents, "Launched"))
+	})
+	It("should deliver the terminating event and hold the nodeclaim until its instance is terminated", func() {
+		registered(nodeClaim)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventLaunched, lifecycle.EventRegistered, lifecycle.EventTerminating}))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Finalizers).To(ContainElement(v1.LifecycleWebhookFinalizer))
+	})
+	It("should deliver the terminated event and release the nodeclaim once its instance is terminated", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+
+		nodeClaim = terminate(nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventLaunched, lifecycle.EventTerminating, lifecycle.EventTerminated}))
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	It("should release the nodeclaim when its events can't be delivered", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		nodeClaim = terminate(nodeClaim)
+
+		receiver.statusCode = http.StatusServiceUnavailable
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, lifecycleController, nodeClaim)
+		fakeClock.Step(time.Minute)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, lifecycleController, nodeClaim)
+		ExpectExists(ctx, env.Client, nodeClaim)
+
+		fakeClock.Step(5 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	It("should retry the terminated event before releasing the nodeclaim", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		nodeClaim = terminate(nodeClaim)
+
+		receiver.statusCode = http.StatusServiceUnavailable
+		// The terminating event fails to be delivered first
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, lifecycleController, nodeClaim)
+		receiver.Reset()
+		receiver.statusCode = http.StatusOK
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+
+		Expect(receiver.Events()).To(Equal([]lifecycle.Event{lifecycle.EventTerminating, lifecycle.EventTerminated}))
+	})
+	It("should release nodeclaims when lifecycle webhooks are disabled", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Finalizers).To(ContainElement(v1.LifecycleWebhookFinalizer))
+
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectObjectReconciled(ctx, env.Client, lifecycleController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Finalizers).ToNot(ContainElement(v1.LifecycleWebhookFinalizer))
+	})
+})
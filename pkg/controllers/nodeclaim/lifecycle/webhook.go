@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Event is a transition in the lifecycle of a NodeClaim which is published to lifecycle webhooks
+type Event string
+
+const (
+	EventLaunched    Event = "Launched"
+	EventRegistered  Event = "Registered"
+	EventTerminating Event = "Terminating"
+	EventTerminated  Event = "Terminated"
+)
+
+// The headers of the requests which are sent to lifecycle webhooks
+const (
+	EventHeader     = "X-Karpenter-Event"
+	TimestampHeader = "X-Karpenter-Timestamp"
+	SignatureHeader = "X-Karpenter-Signature"
+)
+
+// Payload is the JSON body of the requests which are sent to lifecycle webhooks. Events are delivered at least once,
+// so receivers should deduplicate them by their ID.
+type Payload struct {
+	ID           string    `json:"id"`
+	Event        Event     `json:"event"`
+	Time         time.Time `json:"time"`
+	ClusterName  string    `json:"clusterName"`
+	NodeClaim    string    `json:"nodeClaim"`
+	NodePool     string    `json:"nodePool,omitempty"`
+	NodeClass    string    `json:"nodeClass,omitempty"`
+	Node         string    `json:"node,omitempty"`
+	ProviderID   string    `json:"providerID,omitempty"`
+	InstanceID   string    `json:"instanceID,omitempty"`
+	InstanceType string    `json:"instanceType,omitempty"`
+	CapacityType string    `json:"capacityType,omitempty"`
+	Zone         string    `json:"zone,omitempty"`
+	ImageID      string    `json:"imageID,omitempty"`
+}
+
+func NewPayload(clusterName string, nodeClaim *karpv1.NodeClaim, event Event, t time.Time) Payload {
+	instanceID, _ := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	payload := Payload{
+		ID:           fmt.Sprintf("%s/%s", nodeClaim.UID, event),
+		Event:        event,
+		Time:         t.UTC(),
+		ClusterName:  clusterName,
+		NodeClaim:    nodeClaim.Name,
+		NodePool:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		Node:         nodeClaim.Status.NodeName,
+		ProviderID:   nodeClaim.Status.ProviderID,
+		InstanceID:   instanceID,
+		InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+		CapacityType: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+		Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+		ImageID:      nodeClaim.Status.ImageID,
+	}
+	if nodeClaim.Spec.NodeClassRef != nil {
+		payload.NodeClass = nodeClaim.Spec.NodeClassRef.Name
+	}
+	return payload
+}
+
+// Sign returns the value of the signature header of a request to a lifecycle webhook. The signature is the
+// HMAC-SHA256 of the timestamp header and the body joined by a ".", so that receivers can reject replayed requests.
+func Sign(key string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver sends the payload to a lifecycle webhook. The timestamp header is the time the request is sent at rather
+// than the time of the event, since the event may be delivered again later.
+func deliver(ctx context.Context, httpClient *http.Client, url string, signingKey string, payload Payload, now time.Time) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshaling payload, %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request, %w", err)
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(payload.Event))
+	req.Header.Set(TimestampHeader, timestamp)
+	if signingKey != "" {
+		req.Header.Set(SignatureHeader, Sign(signingKey, timestamp, body))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request, %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recorded returns the events which have been delivered for the NodeClaim
+func recorded(nodeClaim *karpv1.NodeClaim) []Event {
+	var events []Event
+	for _, event := range strings.Split(nodeClaim.Annotations[v1.AnnotationLifecycleWebhookEvents], ",") {
+		if event != "" {
+			events = append(events, Event(event))
+		}
+	}
+	return events
+}
@@ -0,0 +1,188 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reboot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Controller reboots the instances of unhealthy nodes before they're replaced. NodePools whose workloads tolerate a
+// restart opt in with the karpenter.k8s.aws/reboot-after annotation, and nodes which match one of the repair policies
+// of the cloud provider for that long are rebooted once. If the node remains unhealthy after the reboot, it's left to
+// be replaced by node repair once the toleration duration of the repair policy elapses.
+//
+// The time of the reboot is recorded on the NodeClaim, and cleared once the node is healthy again, so that a node is
+// rebooted at most once each time it becomes unhealthy.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+	clk              clock.Clock
+	recorder         events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider, clk clock.Clock, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+		clk:              clk,
+		recorder:         recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.reboot")
+
+	nodePoolName, ok := nodeClaim.Labels[karpv1.NodePoolLabelKey]
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.NodeName == "" || !ok {
+		return reconcile.Result{}, nil
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePoolName}, nodePool); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting nodepool, %w", err))
+	}
+	policies := c.cloudProvider.RepairPolicies()
+	rebootAfter, ok, err := RebootAfter(nodePool, policies)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the NodePool has been updated.
+		log.FromContext(ctx).WithValues("NodePool", nodePool.Name).Error(err, "failed parsing reboot-after")
+		return reconcile.Result{}, nil
+	}
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	condition, unhealthy := UnhealthyCondition(node, policies)
+	if !unhealthy {
+		return reconcile.Result{}, c.patchRebootedAt(ctx, nodeClaim, nil)
+	}
+	if _, ok := nodeClaim.Annotations[v1.AnnotationRebootedAt]; ok {
+		// The node was already rebooted since it became unhealthy, so it's left to be replaced
+		return reconcile.Result{}, nil
+	}
+	if unhealthyFor := c.clk.Since(condition.LastTransitionTime.Time); unhealthyFor < rebootAfter {
+		return reconcile.Result{RequeueAfter: rebootAfter - unhealthyFor}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	if err := c.instanceProvider.Reboot(ctx, id); err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("rebooting instance, %w", err))
+	}
+	log.FromContext(ctx).WithValues("condition", condition.Type).Info("rebooted unhealthy instance")
+	c.recorder.Publish(InstanceRebootedEvent(node, id, condition.Type))
+	return reconcile.Result{}, c.patchRebootedAt(ctx, nodeClaim, lo.ToPtr(c.clk.Now()))
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.reboot").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&corev1.Node{}, nodeclaimutils.NodeEventHandler(c.kubeClient, c.cloudProvider)).
+		// Ok with using the default MaxConcurrentReconciles of 1 to avoid throttling from the RebootInstances write API
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// patchRebootedAt records the time that the instance of the NodeClaim was rebooted, or clears it if the time is nil
+func (c *Controller) patchRebootedAt(ctx context.Context, nodeClaim *karpv1.NodeClaim, rebootedAt *time.Time) error {
+	stored := nodeClaim.DeepCopy()
+	if rebootedAt != nil {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationRebootedAt: rebootedAt.UTC().Format(time.RFC3339)})
+	} else {
+		delete(nodeClaim.Annotations, v1.AnnotationRebootedAt)
+	}
+	if equality.Semantic.DeepEqual(nodeClaim, stored) {
+		return nil
+	}
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	return nil
+}
+
+// RebootAfter returns how long the nodes of the NodePool have to be unhealthy before their instances are rebooted, as
+// configured by the karpenter.k8s.aws/reboot-after annotation. False is returned if the NodePool doesn't reboot nodes.
+// The duration has to be shorter than the toleration durations of the repair policies, since node repair would
+// otherwise replace the node before it's rebooted.
+func RebootAfter(nodePool *karpv1.NodePool, policies []cloudprovider.RepairPolicy) (time.Duration, bool, error) {
+	value, ok := nodePool.Annotations[v1.AnnotationRebootAfter]
+	if !ok {
+		return 0, false, nil
+	}
+	rebootAfter, err := time.ParseDuration(value)
+	if err != nil || rebootAfter <= 0 {
+		return 0, false, fmt.Errorf("%s annotation must be a positive duration, got %q", v1.AnnotationRebootAfter, value)
+	}
+	if len(policies) > 0 {
+		toleration := lo.MinBy(policies, func(a, b cloudprovider.RepairPolicy) bool {
+			return a.TolerationDuration < b.TolerationDuration
+		}).TolerationDuration
+		if rebootAfter >= toleration {
+			return 0, false, fmt.Errorf("%s annotation must be shorter than the repair toleration of %s, got %q", v1.AnnotationRebootAfter, toleration, value)
+		}
+	}
+	return rebootAfter, true, nil
+}
+
+// UnhealthyCondition returns the condition of the node which matches a repair policy and has been unhealthy for the
+// longest time. False is returned if the node doesn't match any of the repair policies.
+func UnhealthyCondition(node *corev1.Node, policies []cloudprovider.RepairPolicy) (corev1.NodeCondition, bool) {
+	conditions := lo.Filter(node.Status.Conditions, func(condition corev1.NodeCondition, _ int) bool {
+		return lo.ContainsBy(policies, func(policy cloudprovider.RepairPolicy) bool {
+			return condition.Type == policy.ConditionType && condition.Status == policy.ConditionStatus
+		})
+	})
+	if len(conditions) == 0 {
+		return corev1.NodeCondition{}, false
+	}
+	return lo.MinBy(conditions, func(a, b corev1.NodeCondition) bool {
+		return a.LastTransitionTime.Before(&b.LastTransitionTime)
+	}), true
+}
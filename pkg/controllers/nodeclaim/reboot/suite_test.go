@@ -0,0 +1,217 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/reboot"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var cloudProvider *cloudprovider.CloudProvider
+var rebootController *reboot.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RebootController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	rebootController = reboot.NewController(env.Client, cloudProvider, awsEnv.InstanceProvider, fakeClock, recorder)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("RebootController", func() {
+	var instanceID string
+	var nodePool *karpv1.NodePool
+	var nodeClaim *karpv1.NodeClaim
+	var node *corev1.Node
+
+	withReady := func(status corev1.ConditionStatus, since time.Duration) {
+		node.Status.Conditions = []corev1.NodeCondition{{
+			Type:               corev1.NodeReady,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-since)),
+		}}
+	}
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.AnnotationRebootAfter: "5m"},
+			},
+		})
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+				NodeName:   node.Name,
+			},
+		})
+	})
+
+	It("should reboot the instance once the node has been unhealthy for the reboot-after duration", func() {
+		withReady(corev1.ConditionFalse, 6*time.Minute)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(1))
+		input := awsEnv.EC2API.RebootInstancesBehavior.CalledWithInput.Pop()
+		Expect(input.InstanceIds).To(ConsistOf(instanceID))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationRebootedAt))
+		Expect(recorder.Calls("InstanceRebooted")).To(Equal(1))
+	})
+	It("should requeue until the node has been unhealthy for the reboot-after duration", func() {
+		withReady(corev1.ConditionUnknown, 2*time.Minute)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		result := ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(result.RequeueAfter).To(Equal(3 * time.Minute))
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should not reboot the instance again when the node remains unhealthy after a reboot", func() {
+		withReady(corev1.ConditionFalse, 6*time.Minute)
+		nodeClaim.Annotations = map[string]string{v1.AnnotationRebootedAt: fakeClock.Now().Add(-time.Minute).Format(time.RFC3339)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should clear the reboot once the node is healthy again", func() {
+		withReady(corev1.ConditionTrue, time.Minute)
+		nodeClaim.Annotations = map[string]string{v1.AnnotationRebootedAt: fakeClock.Now().Add(-time.Minute).Format(time.RFC3339)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationRebootedAt))
+	})
+	It("should not reboot the instance when the NodePool doesn't opt in", func() {
+		delete(nodePool.Annotations, v1.AnnotationRebootAfter)
+		withReady(corev1.ConditionFalse, time.Hour)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should not reboot the instance when the reboot-after annotation is invalid", func() {
+		nodePool.Annotations[v1.AnnotationRebootAfter] = "soon"
+		withReady(corev1.ConditionFalse, time.Hour)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should not reboot the instance when reboot-after isn't shorter than the repair tolerations", func() {
+		// Node repair would replace the node first, so the reboot would never run
+		nodePool.Annotations[v1.AnnotationRebootAfter] = "10m"
+		withReady(corev1.ConditionFalse, 15*time.Minute)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should only accept reboot-after durations which elapse before node repair replaces the node", func() {
+		policies := cloudProvider.RepairPolicies()
+		nodePool.Annotations[v1.AnnotationRebootAfter] = "9m59s"
+		rebootAfter, ok, err := reboot.RebootAfter(nodePool, policies)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		for _, policy := range policies {
+			Expect(rebootAfter).To(BeNumerically("<", policy.TolerationDuration))
+		}
+		for _, value := range []string{"10m", "30m", "1h"} {
+			nodePool.Annotations[v1.AnnotationRebootAfter] = value
+			_, ok, err = reboot.RebootAfter(nodePool, policies)
+			Expect(err).To(HaveOccurred(), value)
+			Expect(ok).To(BeFalse())
+		}
+	})
+	It("should reboot the instance when a node monitoring agent condition is unhealthy", func() {
+		node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+			Type:               "KernelReady",
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-10 * time.Minute)),
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		Expect(awsEnv.EC2API.RebootInstancesBehavior.Calls()).To(Equal(1))
+	})
+	It("should not fail when the instance no longer exists", func() {
+		withReady(corev1.ConditionFalse, 6*time.Minute)
+		awsEnv.EC2API.RebootInstancesBehavior.Error.Set(&smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, rebootController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationRebootedAt))
+	})
+})
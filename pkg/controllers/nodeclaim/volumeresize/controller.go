@@ -0,0 +1,156 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumeresize
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Controller resizes the EBS volumes of nodes in place when the only change to their EC2NodeClass is an increase of
+// the volume sizes of its block device mappings, and the EC2NodeClass opts into in-place remediation through its
+// drift policy. Once the volumes are modified, the node is annotated so that a node agent expands its filesystems,
+// and the NodeClaim takes the hash of the EC2NodeClass so that it's no longer considered drifted.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+	recorder         events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+		recorder:         recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.volumeresize")
+
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.NodeName == "" || nodeClaim.Spec.NodeClassRef == nil {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting nodeclass, %w", err))
+	}
+	if !isResizable(nodeClaim, nodeClass) {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	volumes, err := c.instanceProvider.GetVolumes(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting volumes, %w", err)
+	}
+	resizes, ok := instance.VolumeResizes(nodeClass, volumes)
+	if !ok {
+		// Volumes can't be shrunk, so the NodeClaim is left to be replaced as drifted
+		return reconcile.Result{}, nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	volumeIDs := lo.Keys(resizes)
+	sort.Strings(volumeIDs)
+	for _, volumeID := range volumeIDs {
+		if err := c.instanceProvider.ModifyVolume(ctx, volumeID, resizes[volumeID]); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("resizing volume %s, %w", volumeID, err))
+		}
+	}
+	if len(volumeIDs) > 0 {
+		log.FromContext(ctx).WithValues("volumes", volumeIDs).Info("resized volumes in place")
+		c.recorder.Publish(VolumesResizedEvent(node, id, volumeIDs))
+	}
+	// The node agent expands the filesystems of the node whenever the annotation changes, so it's set to the hash of
+	// the EC2NodeClass which the volumes were resized for
+	stored := node.DeepCopy()
+	node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.AnnotationVolumeResizeRequested: nodeClass.Annotations[v1.AnnotationEC2NodeClassHash]})
+	if !equality.Semantic.DeepEqual(node, stored) {
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+		}
+	}
+	storedNodeClaim := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+		v1.AnnotationEC2NodeClassHash: nodeClass.Annotations[v1.AnnotationEC2NodeClassHash],
+	})
+	if !equality.Semantic.DeepEqual(nodeClaim, storedNodeClaim) {
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(storedNodeClaim)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.volumeresize").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.EC2NodeClass{}, nodeclaimutils.NodeClassEventHandler(c.kubeClient)).
+		// Ok with using the default MaxConcurrentReconciles of 1 to avoid throttling from the ModifyVolume write API
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// isResizable returns true if the EC2NodeClass resizes volumes in place, and the NodeClaim only differs from the
+// EC2NodeClass by the volume sizes of its block device mappings
+func isResizable(nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) bool {
+	if nodeClass.VolumeResizePolicy() != v1.VolumeResizePolicyInPlace {
+		return false
+	}
+	nodeClassHash, ok := nodeClass.Annotations[v1.AnnotationEC2NodeClassHash]
+	if !ok || nodeClass.Annotations[v1.AnnotationEC2NodeClassHashVersion] != nodeClaim.Annotations[v1.AnnotationEC2NodeClassHashVersion] {
+		return false
+	}
+	if nodeClaim.Annotations[v1.AnnotationEC2NodeClassHash] == nodeClassHash {
+		return false
+	}
+	hash, ok := nodeClaim.Annotations[v1.AnnotationEC2NodeClassHashWithoutVolumes]
+	return ok && hash == nodeClass.HashWithoutVolumeSizes()
+}
@@ -0,0 +1,197 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumeresize_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/volumeresize"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var recorder *coretest.EventRecorder
+var volumeResizeController *volumeresize.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VolumeResizeController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	volumeResizeController = volumeresize.NewController(env.Client, cloudProvider, awsEnv.InstanceProvider, recorder)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("VolumeResizeController", func() {
+	var instanceID string
+	var volumeID string
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+
+	volumeSize := func() int32 {
+		raw, ok := awsEnv.EC2API.Volumes.Load(volumeID)
+		Expect(ok).To(BeTrue())
+		return aws.ToInt32(raw.(ec2types.Volume).Size)
+	}
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		volumeID = "vol-0123456789abcdef0"
+		awsEnv.EC2API.Volumes.Store(volumeID, ec2types.Volume{
+			VolumeId: aws.String(volumeID),
+			Size:     aws.Int32(20),
+			Attachments: []ec2types.VolumeAttachment{{
+				InstanceId: aws.String(instanceID),
+				Device:     aws.String("/dev/xvda"),
+				VolumeId:   aws.String(volumeID),
+			}},
+		})
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				BlockDeviceMappings: []*v1.BlockDeviceMapping{{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("20Gi"))},
+				}},
+				DriftPolicy: &v1.DriftPolicy{VolumeResize: lo.ToPtr(v1.VolumeResizePolicyInPlace)},
+			},
+		})
+		launchedHash := nodeClass.Hash()
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = lo.ToPtr(resource.MustParse("50Gi"))
+		nodeClass.Annotations = map[string]string{
+			v1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+			v1.AnnotationEC2NodeClassHashVersion: v1.EC2NodeClassHashVersion,
+		}
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.AnnotationEC2NodeClassHash:               launchedHash,
+					v1.AnnotationEC2NodeClassHashVersion:        v1.EC2NodeClassHashVersion,
+					v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClass.HashWithoutVolumeSizes(),
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: "karpenter.k8s.aws",
+					Kind:  "EC2NodeClass",
+					Name:  nodeClass.Name,
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+			},
+		})
+	})
+
+	It("should resize the volumes in place when only the volume sizes increased", func() {
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, volumeResizeController, nodeClaim)
+
+		Expect(volumeSize()).To(BeNumerically("==", 50))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(v1.AnnotationVolumeResizeRequested, nodeClass.Hash()))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+		Expect(recorder.Calls("VolumesResized")).To(Equal(1))
+	})
+	It("should not resize the volumes when the EC2NodeClass replaces drifted nodes", func() {
+		nodeClass.Spec.DriftPolicy = nil
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, volumeResizeController, nodeClaim)
+
+		Expect(volumeSize()).To(BeNumerically("==", 20))
+		Expect(awsEnv.EC2API.ModifyVolumeBehavior.Calls()).To(Equal(0))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationVolumeResizeRequested))
+	})
+	It("should not resize the volumes when other fields of the EC2NodeClass changed", func() {
+		nodeClaim.Annotations[v1.AnnotationEC2NodeClassHashWithoutVolumes] = "123456"
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, volumeResizeController, nodeClaim)
+
+		Expect(awsEnv.EC2API.ModifyVolumeBehavior.Calls()).To(Equal(0))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations[v1.AnnotationEC2NodeClassHash]).ToNot(Equal(nodeClass.Hash()))
+	})
+	It("should not resize the volumes when a volume would shrink", func() {
+		raw, _ := awsEnv.EC2API.Volumes.Load(volumeID)
+		volume := raw.(ec2types.Volume)
+		volume.Size = aws.Int32(100)
+		awsEnv.EC2API.Volumes.Store(volumeID, volume)
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, volumeResizeController, nodeClaim)
+
+		Expect(awsEnv.EC2API.ModifyVolumeBehavior.Calls()).To(Equal(0))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations[v1.AnnotationEC2NodeClassHash]).ToNot(Equal(nodeClass.Hash()))
+	})
+	It("should not resize the volumes before the node has registered", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, volumeResizeController, nodeClaim)
+		Expect(awsEnv.EC2API.ModifyVolumeBehavior.Calls()).To(Equal(0))
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -27,6 +28,7 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 )
 
@@ -39,6 +41,9 @@ func (a *AMI) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconc
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting amis, %w", err)
 	}
+	if err := a.checkPinnedAMIs(ctx, nodeClass); err != nil {
+		return reconcile.Result{}, err
+	}
 	if len(amis) == 0 {
 		nodeClass.Status.AMIs = nil
 		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeAMIsReady, "AMINotFound", "AMISelector did not match any AMIs")
@@ -58,13 +63,102 @@ func (a *AMI) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconc
 			return reqs[i].Key < reqs[j].Key
 		})
 		return v1.AMI{
-			Name:         ami.Name,
-			ID:           ami.AmiID,
-			Deprecated:   ami.Deprecated,
-			Requirements: reqs,
+			Name:            ami.Name,
+			ID:              ami.AmiID,
+			Deprecated:      ami.Deprecated,
+			FastLaunchState: ami.FastLaunchState,
+			Requirements:    reqs,
 		}
 	})
+	a.enableFastLaunch(ctx, nodeClass, amis)
+	if unencrypted := unencryptedAMIs(ctx, amis); len(unencrypted) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeAMIsReady, "AMINotEncrypted",
+			fmt.Sprintf("AMIs %s aren't encrypted, which require-encryption requires", strings.Join(unencrypted, ", ")))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
 
 	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeAMIsReady)
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
+
+// checkPinnedAMIs surfaces the AMIs which are pinned by id in the amiSelectorTerms, but have been deregistered or
+// deprecated, through the PinnedAMIsAvailable condition and the pinned AMI unavailable metric
+func (a *AMI) checkPinnedAMIs(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+	PinnedAMIUnavailable.DeletePartialMatch(map[string]string{ec2NodeClassLabel: nodeClass.Name})
+	if !lo.ContainsBy(nodeClass.Spec.AMISelectorTerms, func(term v1.AMISelectorTerm) bool { return term.ID != "" }) {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypePinnedAMIsAvailable)
+		return nil
+	}
+	unavailable, err := a.amiProvider.UnavailablePinnedAMIs(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("getting unavailable pinned amis, %w", err)
+	}
+	if len(unavailable) == 0 {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypePinnedAMIsAvailable)
+		return nil
+	}
+	for id, reason := range unavailable {
+		PinnedAMIUnavailable.Set(1, map[string]string{ec2NodeClassLabel: nodeClass.Name, imageIDLabel: id, reasonLabel: reason})
+	}
+	var messages []string
+	for reason, message := range map[string]string{amifamily.PinnedAMINotFound: "no longer exist", amifamily.PinnedAMIDeprecated: "are deprecated"} {
+		if ids := lo.Keys(lo.PickByValues(unavailable, []string{reason})); len(ids) != 0 {
+			sort.Strings(ids)
+			messages = append(messages, fmt.Sprintf("AMIs %s %s", strings.Join(ids, ", "), message))
+		}
+	}
+	sort.Strings(messages)
+	// Missing AMIs are reported over deprecated ones, since instances can still launch from deprecated AMIs
+	reason := lo.Ternary(lo.Contains(lo.Values(unavailable), amifamily.PinnedAMINotFound), amifamily.PinnedAMINotFound, amifamily.PinnedAMIDeprecated)
+	nodeClass.StatusConditions().SetFalse(v1.ConditionTypePinnedAMIsAvailable, reason, strings.Join(messages, "; "))
+	return nil
+}
+
+// unencryptedAMIs returns the IDs of the AMIs whose snapshots aren't encrypted when require-encryption is enabled
+func unencryptedAMIs(ctx context.Context, amis amifamily.AMIs) []string {
+	if !options.FromContext(ctx).RequireEncryption {
+		return nil
+	}
+	ids := lo.Uniq(lo.FilterMap(amis, func(ami amifamily.AMI, _ int) (string, bool) { return ami.AmiID, !ami.Encrypted }))
+	sort.Strings(ids)
+	return ids
+}
+
+// enableFastLaunch enables EC2 Fast Launch on the Windows AMIs that don't have it configured when requested by the
+// EC2NodeClass. Fast Launch can only be enabled on AMIs which the account owns, so it's not attempted for AWS-owned or
+// shared AMIs. Failures are surfaced through the FastLaunchEnabled condition rather than failing AMI resolution, since
+// instances can still launch from the AMIs without Fast Launch.
+func (a *AMI) enableFastLaunch(ctx context.Context, nodeClass *v1.EC2NodeClass, amis amifamily.AMIs) {
+	windows := lo.UniqBy(lo.Filter(amis, func(ami amifamily.AMI, _ int) bool { return ami.Windows }), func(ami amifamily.AMI) string { return ami.AmiID })
+	if nodeClass.Spec.WindowsFastLaunch == nil || !lo.FromPtr(nodeClass.Spec.WindowsFastLaunch.Enabled) || len(windows) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeFastLaunchEnabled)
+		return
+	}
+	var unowned []string
+	for _, ami := range windows {
+		if ami.FastLaunchState != "" {
+			continue
+		}
+		if !ami.Owned {
+			unowned = append(unowned, ami.AmiID)
+			continue
+		}
+		state, err := a.amiProvider.EnableFastLaunch(ctx, ami.AmiID, nodeClass.Spec.WindowsFastLaunch)
+		if err != nil {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeFastLaunchEnabled, "EnableFastLaunchFailed", err.Error())
+			return
+		}
+		for i := range nodeClass.Status.AMIs {
+			if nodeClass.Status.AMIs[i].ID == ami.AmiID {
+				nodeClass.Status.AMIs[i].FastLaunchState = state
+			}
+		}
+	}
+	if len(unowned) != 0 {
+		sort.Strings(unowned)
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeFastLaunchEnabled, "AMINotOwned",
+			fmt.Sprintf("AMIs %s aren't owned by the account, so fast launch can't be enabled on them", strings.Join(unowned, ", ")))
+		return
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeFastLaunchEnabled)
+}
@@ -26,6 +26,8 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -741,4 +743,299 @@ var _ = Describe("NodeClass AMI Status Controller", func() {
 			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeAMIsReady)).To(BeTrue())
 		})
 	})
+	Context("Windows Fast Launch", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{
+				Tags: map[string]string{"Name": "windows"},
+			}}
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+				Images: []ec2types.Image{
+					{
+						Name:         aws.String("windows-fast-launch"),
+						ImageId:      aws.String("ami-windows-fast-launch"),
+						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Platform:     ec2types.PlatformValuesWindows,
+						Tags: []ec2types.Tag{
+							{Key: aws.String("Name"), Value: aws.String("windows")},
+						},
+					},
+					{
+						Name:         aws.String("windows"),
+						ImageId:      aws.String("ami-windows"),
+						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+						Architecture: "arm64",
+						Platform:     ec2types.PlatformValuesWindows,
+						Tags: []ec2types.Tag{
+							{Key: aws.String("Name"), Value: aws.String("windows")},
+						},
+					},
+				},
+			})
+			awsEnv.EC2API.DescribeFastLaunchImagesOutput.Set(&ec2.DescribeFastLaunchImagesOutput{
+				FastLaunchImages: []ec2types.DescribeFastLaunchImagesSuccessItem{
+					{
+						ImageId: aws.String("ami-windows-fast-launch"),
+						State:   ec2types.FastLaunchStateCodeEnabled,
+					},
+				},
+			})
+		})
+		It("should report the fast launch state of windows AMIs", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.AMIs).To(HaveLen(2))
+			states := lo.SliceToMap(nodeClass.Status.AMIs, func(ami v1.AMI) (string, string) { return ami.ID, ami.FastLaunchState })
+			Expect(states).To(Equal(map[string]string{
+				"ami-windows-fast-launch": string(ec2types.FastLaunchStateCodeEnabled),
+				"ami-windows":             "",
+			}))
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(0))
+		})
+		It("should enable fast launch on windows AMIs which don't have it configured", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{
+				Enabled:             lo.ToPtr(true),
+				TargetResourceCount: lo.ToPtr[int32](10),
+				MaxParallelLaunches: lo.ToPtr[int32](6),
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.CalledWithInput.Len()).To(Equal(1))
+			input := awsEnv.EC2API.EnableFastLaunchBehavior.CalledWithInput.Pop()
+			Expect(lo.FromPtr(input.ImageId)).To(Equal("ami-windows"))
+			Expect(lo.FromPtr(input.ResourceType)).To(Equal(string(ec2types.FastLaunchResourceTypeSnapshot)))
+			Expect(lo.FromPtr(input.MaxParallelLaunches)).To(BeNumerically("==", 6))
+			Expect(lo.FromPtr(input.SnapshotConfiguration.TargetResourceCount)).To(BeNumerically("==", 10))
+
+			states := lo.SliceToMap(nodeClass.Status.AMIs, func(ami v1.AMI) (string, string) { return ami.ID, ami.FastLaunchState })
+			Expect(states).To(Equal(map[string]string{
+				"ami-windows-fast-launch": string(ec2types.FastLaunchStateCodeEnabled),
+				"ami-windows":             string(ec2types.FastLaunchStateCodeEnabling),
+			}))
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeAMIsReady)).To(BeTrue())
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeFastLaunchEnabled)).To(BeTrue())
+		})
+		It("should not re-enable fast launch on AMIs which it was already enabled on", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(1))
+			states := lo.SliceToMap(nodeClass.Status.AMIs, func(ami v1.AMI) (string, string) { return ami.ID, ami.FastLaunchState })
+			Expect(states["ami-windows"]).To(Equal(string(ec2types.FastLaunchStateCodeEnabling)))
+		})
+		It("should not enable fast launch on non-windows AMIs", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{
+				Tags: map[string]string{"Name": "amd64-standard"},
+			}}
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+				Images: []ec2types.Image{
+					{
+						Name:         aws.String("amd64-standard"),
+						ImageId:      aws.String("ami-amd64-standard"),
+						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
+							{Key: aws.String("Name"), Value: aws.String("amd64-standard")},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(0))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeFastLaunchEnabled)).To(BeNil())
+		})
+		It("should not enable fast launch on windows AMIs which the account doesn't own", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			output := awsEnv.EC2API.DescribeImagesOutput.Clone()
+			for i := range output.Images {
+				output.Images[i].OwnerId = aws.String("801119661308")
+				output.Images[i].ImageOwnerAlias = aws.String("amazon")
+			}
+			awsEnv.EC2API.DescribeImagesOutput.Set(output)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(0))
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeFastLaunchEnabled)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("AMINotOwned"))
+			Expect(condition.Message).To(ContainSubstring("ami-windows"))
+		})
+		It("should enable fast launch on windows AMIs which the account owns", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			output := awsEnv.EC2API.DescribeImagesOutput.Clone()
+			for i := range output.Images {
+				output.Images[i].OwnerId = aws.String(fake.AccountID)
+			}
+			awsEnv.EC2API.DescribeImagesOutput.Set(output)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(1))
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeFastLaunchEnabled)).To(BeTrue())
+		})
+		It("should not retry enabling fast launch on an AMI which it failed for", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			awsEnv.EC2API.EnableFastLaunchBehavior.Error.Set(fmt.Errorf("unauthorized"))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(awsEnv.EC2API.EnableFastLaunchBehavior.Calls()).To(Equal(1))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeFastLaunchEnabled).Reason).To(Equal("EnableFastLaunchFailed"))
+		})
+		It("should surface a failure to enable fast launch without failing AMI resolution", func() {
+			nodeClass.Spec.WindowsFastLaunch = &v1.WindowsFastLaunch{Enabled: lo.ToPtr(true)}
+			awsEnv.EC2API.EnableFastLaunchBehavior.Error.Set(fmt.Errorf("unauthorized"))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady).IsTrue()).To(BeTrue())
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeFastLaunchEnabled)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("EnableFastLaunchFailed"))
+		})
+	})
+	Context("Encryption", func() {
+		image := func(name string, encrypted bool) ec2types.Image {
+			return ec2types.Image{
+				Name:         aws.String(name),
+				ImageId:      aws.String(fmt.Sprintf("ami-%s", name)),
+				CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+				Architecture: lo.Ternary(encrypted, ec2types.ArchitectureValuesX8664, ec2types.ArchitectureValuesArm64),
+				BlockDeviceMappings: []ec2types.BlockDeviceMapping{{
+					DeviceName: aws.String("/dev/xvda"),
+					Ebs:        &ec2types.EbsBlockDevice{SnapshotId: aws.String(fmt.Sprintf("snap-%s", name)), Encrypted: aws.Bool(encrypted)},
+				}},
+				Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+			}
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{RequireEncryption: lo.ToPtr(true)}))
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should set AMIsReady to true when the AMIs are encrypted", func() {
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{image("encrypted", true)}})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady).IsTrue()).To(BeTrue())
+		})
+		It("should set AMIsReady to false when an AMI isn't encrypted", func() {
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{image("encrypted", true), image("unencrypted", false)}})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.AMIs).To(HaveLen(2))
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("AMINotEncrypted"))
+			Expect(condition.Message).To(ContainSubstring("ami-unencrypted"))
+			Expect(condition.Message).ToNot(ContainSubstring("ami-encrypted"))
+		})
+		It("should not require encrypted AMIs when requireEncryption is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{image("unencrypted", false)}})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady).IsTrue()).To(BeTrue())
+		})
+	})
+	Context("Pinned AMIs", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-amd64-standard"}}
+		})
+		It("should set PinnedAMIsAvailable to true when the pinned AMIs are available", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable).IsTrue()).To(BeTrue())
+			_, found := FindMetricWithLabelValues("karpenter_ec2nodeclasses_pinned_ami_unavailable", map[string]string{"ec2nodeclass": nodeClass.Name})
+			Expect(found).To(BeFalse())
+		})
+		It("should not set PinnedAMIsAvailable when AMIs aren't pinned by id", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable)).To(BeNil())
+		})
+		It("should set PinnedAMIsAvailable to false when a pinned AMI no longer exists", func() {
+			nodeClass.Spec.AMISelectorTerms = append(nodeClass.Spec.AMISelectorTerms, v1.AMISelectorTerm{ID: "ami-deregistered"})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("PinnedAMINotFound"))
+			Expect(condition.Message).To(Equal("AMIs ami-deregistered no longer exist"))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady).IsTrue()).To(BeTrue())
+			m, found := FindMetricWithLabelValues("karpenter_ec2nodeclasses_pinned_ami_unavailable", map[string]string{
+				"ec2nodeclass": nodeClass.Name,
+				"image_id":     "ami-deregistered",
+				"reason":       "PinnedAMINotFound",
+			})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 1))
+		})
+		It("should set PinnedAMIsAvailable to false when a pinned AMI is deprecated", func() {
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+				Name:            aws.String("amd64-standard"),
+				ImageId:         aws.String("ami-amd64-standard"),
+				CreationDate:    aws.String(time.Now().Add(-time.Hour).Format(time.RFC3339)),
+				DeprecationTime: aws.String(time.Now().Add(-time.Minute).Format(time.RFC3339)),
+				Architecture:    "x86_64",
+			}}})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("PinnedAMIDeprecated"))
+			Expect(condition.Message).To(Equal("AMIs ami-amd64-standard are deprecated"))
+			_, found := FindMetricWithLabelValues("karpenter_ec2nodeclasses_pinned_ami_unavailable", map[string]string{
+				"ec2nodeclass": nodeClass.Name,
+				"image_id":     "ami-amd64-standard",
+				"reason":       "PinnedAMIDeprecated",
+			})
+			Expect(found).To(BeTrue())
+		})
+		It("should resolve the fallback alias when a pinned AMI no longer exists", func() {
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", k8sVersion): "ami-amd64-standard-new",
+			}
+			nodeClass.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyAL2023)
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-deregistered", FallbackAlias: "al2023@latest"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(ami v1.AMI, _ int) string { return ami.ID })).To(ConsistOf("ami-amd64-standard-new"))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady).IsTrue()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable).Reason).To(Equal("PinnedAMINotFound"))
+		})
+		It("should not resolve the fallback alias when the pinned AMI exists", func() {
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", k8sVersion): "ami-amd64-standard-new",
+			}
+			nodeClass.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyAL2023)
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-amd64-standard", FallbackAlias: "al2023@latest"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(ami v1.AMI, _ int) string { return ami.ID })).To(ConsistOf("ami-amd64-standard"))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePinnedAMIsAvailable).IsTrue()).To(BeTrue())
+		})
+	})
 })
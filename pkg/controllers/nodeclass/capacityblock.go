@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityblock"
+)
+
+type CapacityBlock struct {
+	capacityBlockProvider capacityblock.Provider
+}
+
+// Reconcile resolves the Capacity Blocks for ML which are selected by the EC2NodeClass. Capacity blocks are optional,
+// so they don't gate the readiness of the EC2NodeClass.
+func (c *CapacityBlock) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.CapacityBlockSelectorTerms) == 0 {
+		nodeClass.Status.CapacityBlocks = nil
+		return reconcile.Result{}, nil
+	}
+	reservations, err := c.capacityBlockProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting capacity blocks, %w", err)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if !aws.ToTime(reservations[i].StartDate).Equal(aws.ToTime(reservations[j].StartDate)) {
+			return aws.ToTime(reservations[i].StartDate).Before(aws.ToTime(reservations[j].StartDate))
+		}
+		return aws.ToString(reservations[i].CapacityReservationId) < aws.ToString(reservations[j].CapacityReservationId)
+	})
+	nodeClass.Status.CapacityBlocks = lo.Map(reservations, func(reservation ec2types.CapacityReservation, _ int) v1.CapacityBlock {
+		return v1.CapacityBlock{
+			ID:                     aws.ToString(reservation.CapacityReservationId),
+			InstanceType:           aws.ToString(reservation.InstanceType),
+			Zone:                   aws.ToString(reservation.AvailabilityZone),
+			State:                  string(reservation.State),
+			StartTime:              metav1.NewTime(aws.ToTime(reservation.StartDate)),
+			EndTime:                metav1.NewTime(aws.ToTime(reservation.EndDate)),
+			AvailableInstanceCount: aws.ToInt32(reservation.AvailableInstanceCount),
+		}
+	})
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
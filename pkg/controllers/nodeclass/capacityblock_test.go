@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Capacity Block Status Controller", func() {
+	var start time.Time
+	BeforeEach(func() {
+		start = time.Now().Truncate(time.Second)
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{CapacityReservations: []ec2types.CapacityReservation{
+			{
+				CapacityReservationId:  aws.String("cr-test2"),
+				ReservationType:        ec2types.CapacityReservationTypeCapacityBlock,
+				State:                  ec2types.CapacityReservationStateScheduled,
+				InstanceType:           aws.String("p5.48xlarge"),
+				AvailabilityZone:       aws.String("test-zone-1b"),
+				StartDate:              aws.Time(start.Add(24 * time.Hour)),
+				EndDate:                aws.Time(start.Add(48 * time.Hour)),
+				AvailableInstanceCount: aws.Int32(4),
+				Tags:                   []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			},
+			{
+				CapacityReservationId:  aws.String("cr-test1"),
+				ReservationType:        ec2types.CapacityReservationTypeCapacityBlock,
+				State:                  ec2types.CapacityReservationStateActive,
+				InstanceType:           aws.String("p5.48xlarge"),
+				AvailabilityZone:       aws.String("test-zone-1a"),
+				StartDate:              aws.Time(start),
+				EndDate:                aws.Time(start.Add(24 * time.Hour)),
+				AvailableInstanceCount: aws.Int32(2),
+				Tags:                   []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			},
+		}})
+	})
+	It("Should update EC2NodeClass status for Capacity Blocks, ordered by start time", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{Tags: map[string]string{"team": "ml"}}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityBlocks).To(HaveLen(2))
+		Expect(nodeClass.Status.CapacityBlocks[0].ID).To(Equal("cr-test1"))
+		Expect(nodeClass.Status.CapacityBlocks[0].InstanceType).To(Equal("p5.48xlarge"))
+		Expect(nodeClass.Status.CapacityBlocks[0].Zone).To(Equal("test-zone-1a"))
+		Expect(nodeClass.Status.CapacityBlocks[0].State).To(Equal("active"))
+		Expect(nodeClass.Status.CapacityBlocks[0].AvailableInstanceCount).To(BeNumerically("==", 2))
+		Expect(nodeClass.Status.CapacityBlocks[0].StartTime.Time).To(BeTemporally("==", start))
+		Expect(nodeClass.Status.CapacityBlocks[0].EndTime.Time).To(BeTemporally("==", start.Add(24*time.Hour)))
+		Expect(nodeClass.Status.CapacityBlocks[1].ID).To(Equal("cr-test2"))
+		Expect(nodeClass.Status.CapacityBlocks[1].State).To(Equal("scheduled"))
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("Should select Capacity Blocks by id", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test2"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityBlocks).To(HaveLen(1))
+		Expect(nodeClass.Status.CapacityBlocks[0].ID).To(Equal("cr-test2"))
+	})
+	It("Should clear Capacity Blocks from the status when the selector terms are removed", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test1"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityBlocks).To(HaveLen(1))
+
+		nodeClass.Spec.CapacityBlockSelectorTerms = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityBlocks).To(BeEmpty())
+	})
+	It("Should not gate readiness when no Capacity Blocks are selected", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-missing"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityBlocks).To(BeEmpty())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Capacity Block", func() {
+	It("should only be launchable while active, started, and before the drain time", func() {
+		now := time.Now()
+		capacityBlock := v1.CapacityBlock{
+			State:                  "active",
+			StartTime:              metav1.NewTime(now.Add(-time.Hour)),
+			EndTime:                metav1.NewTime(now.Add(2 * time.Hour)),
+			AvailableInstanceCount: 1,
+		}
+		Expect(capacityBlock.Launchable(now)).To(BeTrue())
+		Expect(capacityBlock.Launchable(now.Add(-2 * time.Hour))).To(BeFalse())
+		Expect(capacityBlock.Launchable(now.Add(time.Hour + time.Minute))).To(BeFalse())
+		capacityBlock.AvailableInstanceCount = 0
+		Expect(capacityBlock.Launchable(now)).To(BeFalse())
+		capacityBlock.AvailableInstanceCount = 1
+		capacityBlock.State = "scheduled"
+		Expect(capacityBlock.Launchable(now)).To(BeFalse())
+	})
+})
@@ -38,17 +38,26 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/awslabs/operatorpkg/reasonable"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/vpcendpoint"
 )
 
 type nodeClassReconciler interface {
@@ -59,28 +68,57 @@ type Controller struct {
 	kubeClient             client.Client
 	recorder               events.Recorder
 	launchTemplateProvider launchtemplate.Provider
+	instanceProvider       instance.Provider
 
 	ami             *AMI
 	instanceProfile *InstanceProfile
+	nodeRole        *NodeRole
 	subnet          *Subnet
+	registry        *Registry
+	dns             *DNS
 	securityGroup   *SecurityGroup
+	capacityBlock   *CapacityBlock
+	placementGroup  *PlacementGroup
+	zonalResources  *ZonalResources
+	quotas          *Quotas
 	validation      *Validation
+	launchDryRun    *LaunchDryRun
+	dependents      *Dependents
+	instanceTypes   *InstanceTypeSummary
 	readiness       *Readiness //TODO : Remove this when we have sub status conditions
+
+	// nodeClassEvents requeues EC2NodeClasses when the resources they select change
+	nodeClassEvents <-chan event.GenericEvent
 }
 
 func NewController(kubeClient client.Client, recorder events.Recorder, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
-	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
+	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider,
+	instanceProvider instance.Provider, vpcEndpointProvider vpcendpoint.Provider, dhcpOptionsProvider dhcpoptions.Provider, capacityBlockProvider capacityblock.Provider,
+	placementGroupProvider placementgroup.Provider, instanceTypeProvider instancetype.Provider, quotaProvider quota.Provider, region string,
+	nodeClassEvents <-chan event.GenericEvent) *Controller {
 
 	return &Controller{
 		kubeClient:             kubeClient,
 		recorder:               recorder,
 		launchTemplateProvider: launchTemplateProvider,
+		instanceProvider:       instanceProvider,
 		ami:                    &AMI{amiProvider: amiProvider},
 		subnet:                 &Subnet{subnetProvider: subnetProvider},
+		registry:               &Registry{region: region, subnetProvider: subnetProvider, vpcEndpointProvider: vpcEndpointProvider},
+		dns:                    &DNS{subnetProvider: subnetProvider, dhcpOptionsProvider: dhcpOptionsProvider},
 		securityGroup:          &SecurityGroup{securityGroupProvider: securityGroupProvider},
+		capacityBlock:          &CapacityBlock{capacityBlockProvider: capacityBlockProvider},
+		placementGroup:         &PlacementGroup{placementGroupProvider: placementGroupProvider},
+		zonalResources:         &ZonalResources{placementGroupProvider: placementGroupProvider},
+		quotas:                 &Quotas{kubeClient: kubeClient, instanceTypeProvider: instanceTypeProvider, quotaProvider: quotaProvider},
 		instanceProfile:        &InstanceProfile{instanceProfileProvider: instanceProfileProvider},
-		validation:             &Validation{},
+		nodeRole:               &NodeRole{instanceProfileProvider: instanceProfileProvider},
+		validation:             &Validation{subnetProvider: subnetProvider},
+		launchDryRun:           &LaunchDryRun{instanceProvider: instanceProvider},
+		dependents:             &Dependents{kubeClient: kubeClient},
+		instanceTypes:          &InstanceTypeSummary{instanceTypeProvider: instanceTypeProvider},
 		readiness:              &Readiness{launchTemplateProvider: launchTemplateProvider},
+		nodeClassEvents:        nodeClassEvents,
 	}
 }
 
@@ -116,9 +154,19 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	for _, reconciler := range []nodeClassReconciler{
 		c.ami,
 		c.subnet,
+		c.registry,
+		c.dns,
 		c.securityGroup,
+		c.capacityBlock,
+		c.placementGroup,
+		c.zonalResources,
+		c.quotas,
 		c.instanceProfile,
+		c.nodeRole,
 		c.validation,
+		c.launchDryRun,
+		c.dependents,
+		c.instanceTypes,
 		c.readiness,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
@@ -152,18 +200,27 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1.EC2NodeClass) (
 	if err := c.kubeClient.List(ctx, nodeClaims, nodeclaimutils.ForNodeClass(nodeClass)); err != nil {
 		return reconcile.Result{}, fmt.Errorf("listing nodeclaims that are using nodeclass, %w", err)
 	}
+	orphan := lo.FromPtr(nodeClass.Spec.DeletionPolicy) == v1.DeletionPolicyOrphan
 	if len(nodeClaims.Items) > 0 {
-		c.recorder.Publish(WaitingOnNodeClaimTerminationEvent(nodeClass, lo.Map(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) string { return nc.Name })))
-		return reconcile.Result{RequeueAfter: time.Minute * 10}, nil // periodically fire the event
+		if !orphan {
+			c.recorder.Publish(WaitingOnNodeClaimTerminationEvent(nodeClass, lo.Map(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) string { return nc.Name })))
+			return reconcile.Result{RequeueAfter: time.Minute * 10}, nil // periodically fire the event
+		}
+		if err := c.orphan(ctx, nodeClass, nodeClaims.Items); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
-	if nodeClass.Spec.Role != "" {
+	// Orphaned instances continue to use the instance profile, so it's left in place for them to be adopted with
+	if nodeClass.Spec.Role != "" && !orphan {
+		// The instance profile can't be deleted while it's still in use, which is retried after a delay
 		if _, err := c.instanceProfile.Finalize(ctx, nodeClass); err != nil {
-			return reconcile.Result{}, err
+			return awserrors.Requeue(ctx, err)
 		}
 	}
 	if err := c.launchTemplateProvider.DeleteAll(ctx, nodeClass); err != nil {
 		return reconcile.Result{}, fmt.Errorf("deleting launch templates, %w", err)
 	}
+	PinnedAMIUnavailable.DeletePartialMatch(map[string]string{ec2NodeClassLabel: nodeClass.Name})
 	controllerutil.RemoveFinalizer(nodeClass, v1.TerminationFinalizer)
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
 		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
@@ -181,7 +238,7 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1.EC2NodeClass) (
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
-	return controllerruntime.NewControllerManagedBy(m).
+	b := controllerruntime.NewControllerManagedBy(m).
 		Named(c.Name()).
 		For(&v1.EC2NodeClass{}).
 		Watches(
@@ -193,7 +250,8 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 				}
 				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: nc.Spec.NodeClassRef.Name}}}
 			}),
-			// Watch for NodeClaim deletion events
+			// Watch for NodeClaim deletion events. The dependents of the NodeClass pick up created NodeClaims when it's next
+			// reconciled, rather than on every NodeClaim creation.
 			builder.WithPredicates(predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool { return false },
 				UpdateFunc: func(e event.UpdateEvent) bool { return false },
@@ -203,6 +261,9 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 		WithOptions(controller.Options{
 			RateLimiter:             reasonable.RateLimiter(),
 			MaxConcurrentReconciles: 10,
-		}).
-		Complete(reconcile.AsReconciler(m.GetClient(), c))
+		})
+	if c.nodeClassEvents != nil {
+		b = b.WatchesRawSource(source.Channel(c.nodeClassEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
@@ -0,0 +1,74 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Dependents Controller", func() {
+	nodeClaimFor := func(nc *v1.EC2NodeClass, name string) *karpv1.NodeClaim {
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nc).Group,
+					Kind:  object.GVK(nc).Kind,
+					Name:  nc.Name,
+				},
+			},
+		})
+		nodeClaim.Name = name
+		return nodeClaim
+	}
+	It("should report no dependents when the nodeclass has no nodeclaims", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Dependents).ToNot(BeNil())
+		Expect(nodeClass.Status.Dependents.Count).To(BeZero())
+		Expect(nodeClass.Status.Dependents.NodeClaims).To(BeEmpty())
+	})
+	It("should report the nodeclaims which use the nodeclass", func() {
+		other := coretest.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaimFor(nodeClass, "nodeclaim-b"), nodeClaimFor(nodeClass, "nodeclaim-a"), other)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Dependents.Count).To(Equal(2))
+		Expect(nodeClass.Status.Dependents.NodeClaims).To(Equal([]string{"nodeclaim-a", "nodeclaim-b"}))
+	})
+	It("should limit the number of nodeclaims which are listed", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		for i := range v1.MaxDependentNodeClaims + 5 {
+			ExpectApplied(ctx, env.Client, nodeClaimFor(nodeClass, fmt.Sprintf("nodeclaim-%02d", i)))
+		}
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Dependents.Count).To(Equal(v1.MaxDependentNodeClaims + 5))
+		Expect(nodeClass.Status.Dependents.NodeClaims).To(HaveLen(v1.MaxDependentNodeClaims))
+		Expect(nodeClass.Status.Dependents.NodeClaims[0]).To(Equal("nodeclaim-00"))
+		Expect(lo.Uniq(nodeClass.Status.Dependents.NodeClaims)).To(HaveLen(v1.MaxDependentNodeClaims))
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// DNS surfaces whether nodes launched with the EC2NodeClass are likely to be able to resolve the cluster endpoint. Nodes
+// commonly never join the cluster when the DHCP options of their VPC set custom DNS servers which don't forward to the
+// Route 53 Resolver of the VPC, since the private cluster endpoint is only resolvable through it.
+type DNS struct {
+	subnetProvider      subnet.Provider
+	dhcpOptionsProvider dhcpoptions.Provider
+}
+
+func (d *DNS) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	// The DNS servers of the EC2NodeClass replace the DNS servers from the DHCP options
+	if nodeClass.Spec.DNS != nil {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeDHCPOptionsCompatible)
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	subnets, err := d.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting subnets, %w", err)
+	}
+	vpcIDs := lo.Uniq(lo.FilterMap(subnets, func(s ec2types.Subnet, _ int) (string, bool) {
+		return lo.FromPtr(s.VpcId), s.VpcId != nil
+	}))
+	sort.Strings(vpcIDs)
+	for _, vpcID := range vpcIDs {
+		servers, err := d.dhcpOptionsProvider.DomainNameServers(ctx, vpcID)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("getting dhcp options, %w", err)
+		}
+		if len(servers) != 0 && !lo.Contains(servers, dhcpoptions.AmazonProvidedDNS) {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeDHCPOptionsCompatible, "CustomDNSServers",
+				fmt.Sprintf("DHCP options of VPC %s set the DNS servers %s, which must resolve the cluster endpoint for nodes to join the cluster; configure spec.dns if they don't", vpcID, strings.Join(servers, ", ")))
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeDHCPOptionsCompatible)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
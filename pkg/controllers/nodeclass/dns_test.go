@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass DNS Status Controller", func() {
+	dhcpOptions := func(servers ...string) {
+		awsEnv.EC2API.DescribeVpcsOutput.Set(&ec2.DescribeVpcsOutput{
+			Vpcs: []ec2types.Vpc{{VpcId: aws.String("vpc-test1"), DhcpOptionsId: aws.String("dopt-test")}},
+		})
+		awsEnv.EC2API.DescribeDhcpOptionsOutput.Set(&ec2.DescribeDhcpOptionsOutput{
+			DhcpOptions: []ec2types.DhcpOptions{{
+				DhcpOptionsId: aws.String("dopt-test"),
+				DhcpConfigurations: []ec2types.DhcpConfiguration{{
+					Key: aws.String("domain-name-servers"),
+					Values: lo.Map(servers, func(s string, _ int) ec2types.AttributeValue {
+						return ec2types.AttributeValue{Value: aws.String(s)}
+					}),
+				}},
+			}},
+		})
+	}
+	It("should set DHCPOptionsCompatible to true when the VPC uses the default DHCP options", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeDHCPOptionsCompatible).IsTrue()).To(BeTrue())
+	})
+	It("should set DHCPOptionsCompatible to true when the DHCP options include the Amazon provided DNS", func() {
+		dhcpOptions(dhcpoptions.AmazonProvidedDNS, "10.1.0.10")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeDHCPOptionsCompatible).IsTrue()).To(BeTrue())
+	})
+	It("should set DHCPOptionsCompatible to false when the DHCP options only set custom DNS servers", func() {
+		dhcpOptions("10.1.0.10", "10.1.0.11")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeDHCPOptionsCompatible)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("CustomDNSServers"))
+		Expect(condition.Message).To(ContainSubstring("vpc-test1"))
+		Expect(condition.Message).To(ContainSubstring("10.1.0.10, 10.1.0.11"))
+	})
+	It("should set DHCPOptionsCompatible to true when the EC2NodeClass configures DNS servers", func() {
+		dhcpOptions("10.1.0.10")
+		nodeClass.Spec.DNS = &v1.DNS{Nameservers: []string{"10.0.0.2"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeDHCPOptionsCompatible).IsTrue()).To(BeTrue())
+	})
+	It("should not gate the readiness of the nodeClass", func() {
+		dhcpOptions("10.1.0.10")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeDHCPOptionsCompatible).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+})
@@ -19,6 +19,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
@@ -34,3 +35,13 @@ func WaitingOnNodeClaimTerminationEvent(nodeClass *v1.EC2NodeClass, names []stri
 		DedupeValues:   []string{string(nodeClass.UID)},
 	}
 }
+
+func OrphanedNodeClaimEvent(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClass,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "OrphanedNodeClaim",
+		Message:        fmt.Sprintf("Orphaned NodeClaim %s, its instance %s was left running", nodeClaim.Name, nodeClaim.Status.ProviderID),
+		DedupeValues:   []string{string(nodeClass.UID), nodeClaim.Name},
+	}
+}
@@ -32,15 +32,18 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 )
 
 type Controller struct {
-	kubeClient client.Client
+	kubeClient             client.Client
+	launchTemplateProvider launchtemplate.Provider
 }
 
-func NewController(kubeClient client.Client) *Controller {
+func NewController(kubeClient client.Client, launchTemplateProvider launchtemplate.Provider) *Controller {
 	return &Controller{
-		kubeClient: kubeClient,
+		kubeClient:             kubeClient,
+		launchTemplateProvider: launchTemplateProvider,
 	}
 }
 
@@ -64,6 +67,11 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass)
 			return reconcile.Result{}, err
 		}
 	}
+	// The cached launch templates of the EC2NodeClass were rendered from its previous spec, so they're evicted rather
+	// than kept until they expire
+	if hash, ok := stored.Annotations[v1.AnnotationEC2NodeClassHash]; ok && hash != nodeClass.Annotations[v1.AnnotationEC2NodeClassHash] {
+		c.launchTemplateProvider.InvalidateNodeClass(ctx, nodeClass)
+	}
 
 	return reconcile.Result{}, nil
 }
@@ -103,7 +111,8 @@ func (c *Controller) updateNodeClaimHash(ctx context.Context, nodeClass *v1.EC2N
 			// Since the hashing mechanism has changed we will not be able to determine if the drifted status of the NodeClaim has changed
 			if nc.StatusConditions().Get(karpv1.ConditionTypeDrifted) == nil {
 				nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-					v1.AnnotationEC2NodeClassHash: nodeClass.Hash(),
+					v1.AnnotationEC2NodeClassHash:               nodeClass.Hash(),
+					v1.AnnotationEC2NodeClassHashWithoutVolumes: nodeClass.HashWithoutVolumeSizes(),
 				})
 			}
 
@@ -16,6 +16,7 @@ package hash_test
 
 import (
 	"context"
+	"net"
 	"testing"
 
 	"github.com/samber/lo"
@@ -60,7 +61,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 
-	hashController = hash.NewController(env.Client)
+	hashController = hash.NewController(env.Client, awsEnv.LaunchTemplateProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -140,6 +141,56 @@ var _ = Describe("NodeClass Hash Controller", func() {
 		Entry("MetadataOptions Drift", &v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPEndpoint: aws.String("disabled")}}}),
 		Entry("Context Drift", &v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
 	)
+	It("should evict the cached launch templates of the EC2NodeClass when its hash changes", func() {
+		nodeClass = test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, hashController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("10.0.100.10")
+		awsEnv.LaunchTemplateProvider.ClusterEndpoint = "https://test-cluster"
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		launchTemplates, err := awsEnv.LaunchTemplateProvider.EnsureAll(ctx, nodeClass, coretest.NodeClaim(), instanceTypes, karpv1.CapacityTypeOnDemand, map[string]string{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launchTemplates).ToNot(BeEmpty())
+
+		nodeClass.Spec.UserData = aws.String("userdata-test-2")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, hashController, nodeClass)
+		for _, lt := range launchTemplates {
+			_, ok := awsEnv.LaunchTemplateCache.Get(lt.Name)
+			Expect(ok).To(BeFalse())
+		}
+		// The launch templates are left for garbage collection, since launches which started before the change may use them
+		count := 0
+		awsEnv.EC2API.LaunchTemplates.Range(func(_, _ any) bool { count++; return true })
+		Expect(count).To(Equal(len(launchTemplates)))
+	})
+	It("should not evict the cached launch templates of the EC2NodeClass when its hash doesn't change", func() {
+		nodeClass = test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, hashController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("10.0.100.10")
+		awsEnv.LaunchTemplateProvider.ClusterEndpoint = "https://test-cluster"
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		launchTemplates, err := awsEnv.LaunchTemplateProvider.EnsureAll(ctx, nodeClass, coretest.NodeClaim(), instanceTypes, karpv1.CapacityTypeOnDemand, map[string]string{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launchTemplates).ToNot(BeEmpty())
+
+		ExpectObjectReconciled(ctx, env.Client, hashController, nodeClass)
+		for _, lt := range launchTemplates {
+			_, ok := awsEnv.LaunchTemplateCache.Get(lt.Name)
+			Expect(ok).To(BeTrue())
+		}
+	})
 	It("should not update the drift hash when dynamic field is updated", func() {
 		ExpectApplied(ctx, env.Client, nodeClass)
 		ExpectObjectReconciled(ctx, env.Client, hashController, nodeClass)
@@ -235,6 +286,7 @@ var _ = Describe("NodeClass Hash Controller", func() {
 		Expect(nodeClaimOne.Annotations).To(HaveKeyWithValue(v1.AnnotationEC2NodeClassHashVersion, v1.EC2NodeClassHashVersion))
 		Expect(nodeClaimTwo.Annotations).To(HaveKeyWithValue(v1.AnnotationEC2NodeClassHash, expectedHash))
 		Expect(nodeClaimTwo.Annotations).To(HaveKeyWithValue(v1.AnnotationEC2NodeClassHashVersion, v1.EC2NodeClassHashVersion))
+		Expect(nodeClaimOne.Annotations).To(HaveKeyWithValue(v1.AnnotationEC2NodeClassHashWithoutVolumes, nodeClass.HashWithoutVolumeSizes()))
 	})
 	It("should not update ec2nodeclass-hash on all NodeClaims when the ec2nodeclass-hash-version matches the controller hash version", func() {
 		nodeClass.Annotations = map[string]string{
@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+)
+
+// InstanceTypeSummary surfaces the max pods, allocatable resources and price that instance types are resolved with for
+// the AMI family and kubelet configuration of the EC2NodeClass, so that overhead calculations can be verified without
+// launching nodes. The instance types are selected with the karpenter.k8s.aws/instance-type-summary annotation, whose
+// value is either the number of cheapest instance types to summarize or a comma-separated list of instance types.
+type InstanceTypeSummary struct {
+	instanceTypeProvider instancetype.Provider
+}
+
+func (i *InstanceTypeSummary) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	selector, ok := nodeClass.Annotations[v1.AnnotationInstanceTypeSummary]
+	if !ok {
+		nodeClass.Status.InstanceTypes = nil
+		return reconcile.Result{}, nil
+	}
+	// The instance types are resolved from the zones of the subnets
+	if len(nodeClass.Status.Subnets) == 0 {
+		return reconcile.Result{}, nil
+	}
+	instanceTypes, err := i.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing instance types, %w", err)
+	}
+	nodeClass.Status.InstanceTypes = lo.Map(selectInstanceTypes(instanceTypes, selector), func(it *cloudprovider.InstanceType, _ int) v1.InstanceTypeSummary {
+		return summarize(it)
+	})
+	return reconcile.Result{}, nil
+}
+
+// selectInstanceTypes returns the instance types which are named by the selector, or the cheapest instance types if the
+// selector is a number. At most MaxSummarizedInstanceTypes are returned.
+func selectInstanceTypes(instanceTypes []*cloudprovider.InstanceType, selector string) []*cloudprovider.InstanceType {
+	if count, err := strconv.Atoi(strings.TrimSpace(selector)); err == nil {
+		available := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			return len(it.Offerings.Available()) != 0
+		})
+		sort.SliceStable(available, func(a, b int) bool {
+			if priceA, priceB := available[a].Offerings.Available().Cheapest().Price, available[b].Offerings.Available().Cheapest().Price; priceA != priceB {
+				return priceA < priceB
+			}
+			return available[a].Name < available[b].Name
+		})
+		return lo.Subset(available, 0, uint(lo.Clamp(count, 0, v1.MaxSummarizedInstanceTypes)))
+	}
+	names := sets.New(lo.FilterMap(strings.Split(selector, ","), func(name string, _ int) (string, bool) {
+		return strings.TrimSpace(name), strings.TrimSpace(name) != ""
+	})...)
+	selected := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool { return names.Has(it.Name) })
+	sort.Slice(selected, func(a, b int) bool { return selected[a].Name < selected[b].Name })
+	return lo.Subset(selected, 0, v1.MaxSummarizedInstanceTypes)
+}
+
+func summarize(it *cloudprovider.InstanceType) v1.InstanceTypeSummary {
+	summary := v1.InstanceTypeSummary{
+		Name:    it.Name,
+		MaxPods: it.Capacity.Pods().Value(),
+		Allocatable: corev1.ResourceList(lo.PickBy(it.Allocatable(), func(_ corev1.ResourceName, quantity resource.Quantity) bool {
+			return !quantity.IsZero()
+		})),
+	}
+	if available := it.Offerings.Available(); len(available) != 0 {
+		// Prices are rounded to the precision that EC2 publishes them with
+		summary.Price = strconv.FormatFloat(math.Round(available.Cheapest().Price*1e6)/1e6, 'f', -1, 64)
+	}
+	return summary
+}
@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"strconv"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Instance Type Summary Controller", func() {
+	BeforeEach(func() {
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+	It("should not summarize instance types without the annotation", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.InstanceTypes).To(BeEmpty())
+	})
+	It("should summarize the instance types which are named by the annotation", func() {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationInstanceTypeSummary: "m5.large, c5.xlarge,unknown.large"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(lo.Map(nodeClass.Status.InstanceTypes, func(s v1.InstanceTypeSummary, _ int) string { return s.Name })).To(Equal([]string{"c5.xlarge", "m5.large"}))
+
+		summary := nodeClass.Status.InstanceTypes[1]
+		Expect(summary.MaxPods).To(BeNumerically("==", 29))
+		Expect(summary.Price).ToNot(BeEmpty())
+		Expect(summary.Allocatable).To(HaveKey(corev1.ResourceCPU))
+		Expect(summary.Allocatable).To(HaveKey(corev1.ResourceMemory))
+		Expect(summary.Allocatable.Cpu().MilliValue()).To(BeNumerically("<", 2000))
+	})
+	It("should summarize the cheapest instance types when the annotation is a number", func() {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationInstanceTypeSummary: "3"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.InstanceTypes).To(HaveLen(3))
+		prices := lo.Map(nodeClass.Status.InstanceTypes, func(s v1.InstanceTypeSummary, _ int) float64 {
+			return lo.Must(strconv.ParseFloat(s.Price, 64))
+		})
+		Expect(prices[0]).To(BeNumerically("<=", prices[1]))
+		Expect(prices[1]).To(BeNumerically("<=", prices[2]))
+	})
+	It("should limit the number of instance types which are summarized", func() {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationInstanceTypeSummary: "1000"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(len(nodeClass.Status.InstanceTypes)).To(BeNumerically("<=", v1.MaxSummarizedInstanceTypes))
+	})
+	It("should clear the summary when the annotation is removed", func() {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationInstanceTypeSummary: "m5.large"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.InstanceTypes).To(HaveLen(1))
+
+		delete(nodeClass.Annotations, v1.AnnotationInstanceTypeSummary)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.InstanceTypes).To(BeEmpty())
+	})
+})
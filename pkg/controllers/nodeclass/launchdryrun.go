@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+)
+
+// dryRunNodePool is the value of the NodePool tag in launch dry runs. Instances are tagged with the NodePool that they
+// were launched for, which a dry run of an EC2NodeClass doesn't have.
+const dryRunNodePool = "launch-dry-run"
+
+// LaunchDryRun surfaces IAM and parameter errors which would fail launches with the EC2NodeClass when the EC2NodeClass
+// changes, rather than on the next launch. Dry runs are only repeated when the EC2NodeClass changes, so permissions
+// which are removed from the controller afterwards aren't detected until the next change.
+type LaunchDryRun struct {
+	instanceProvider instance.Provider
+}
+
+func (l *LaunchDryRun) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if !options.FromContext(ctx).FeatureGates.LaunchDryRun {
+		return reconcile.Result{}, nil
+	}
+	if condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded); condition != nil && !condition.IsUnknown() &&
+		condition.ObservedGeneration == nodeClass.Generation {
+		return reconcile.Result{}, nil
+	}
+	// The representative configuration is launched into the resolved subnets with the tags of the EC2NodeClass, so the
+	// dry run waits for the subnets to resolve and the tags to pass validation
+	if len(nodeClass.Status.Subnets) == 0 || !nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	// The dry run is made with the profile's defaults, like the launches of the EC2NodeClass
+	defaulted := nodeClass.DeepCopy()
+	defaulted.SetDefaults(ctx)
+	err := l.instanceProvider.DryRun(ctx, defaulted, lo.Assign(nodeClass.Spec.Tags, map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		karpv1.NodePoolLabelKey: dryRunNodePool,
+		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
+		v1.LabelNodeClass:       nodeClass.Name,
+	}))
+	if err == nil {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeLaunchDryRunSucceeded)
+		return reconcile.Result{}, nil
+	}
+	// Errors returned by EC2 are caused by the configuration or the controller's permissions, and are surfaced on the
+	// condition. Throttling and other transient errors are retried.
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && !awserrors.IsRetryable(err) {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeLaunchDryRunSucceeded, strings.ReplaceAll(apiErr.ErrorCode(), ".", ""),
+			fmt.Sprintf("CreateFleet dry run failed, %s", apiErr.ErrorMessage()))
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{}, fmt.Errorf("making create fleet dry run, %w", err)
+}
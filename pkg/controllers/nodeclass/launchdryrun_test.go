@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/smithy-go"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Launch Dry Run Status Controller", func() {
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{LaunchDryRun: lo.ToPtr(true)}}))
+	})
+	AfterEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+	})
+	It("should set LaunchDryRunSucceeded to true when the dry run succeeds", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded).IsTrue()).To(BeTrue())
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(lo.FromPtr(input.DryRun)).To(BeTrue())
+		Expect(input.LaunchTemplateConfigs[0].Overrides).To(HaveLen(len(lo.UniqBy(nodeClass.Status.Subnets, func(s v1.Subnet) string { return s.Zone }))))
+		Expect(input.TagSpecifications[0].Tags).To(ContainElement(HaveField("Key", HaveValue(Equal(v1.LabelNodeClass)))))
+	})
+	It("should set LaunchDryRunSucceeded to false when the controller isn't authorized to launch instances", func() {
+		awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{
+			Code:    "UnauthorizedOperation",
+			Message: "You are not authorized to perform this operation.",
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("UnauthorizedOperation"))
+		Expect(condition.Message).To(Equal("CreateFleet dry run failed, You are not authorized to perform this operation."))
+	})
+	It("should strip invalid characters from the reason", func() {
+		awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{
+			Code:    "InvalidSubnetID.NotFound",
+			Message: "The subnet ID 'subnet-test1' does not exist",
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded).Reason).To(Equal("InvalidSubnetIDNotFound"))
+	})
+	It("should retry when the dry run is throttled", func() {
+		awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: "RequestLimitExceeded"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded)).To(BeNil())
+	})
+	It("should only repeat the dry run when the nodeClass changes", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		nodeClass.Spec.Tags = map[string]string{"team": "ml"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+	})
+	It("should not make a dry run when launch dry runs are disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded)).To(BeNil())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+	})
+	It("should not gate the readiness of the nodeClass", func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchDryRunSucceeded).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+})
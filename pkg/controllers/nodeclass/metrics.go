@@ -0,0 +1,41 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	ec2NodeClassSubsystem = "ec2nodeclasses"
+	ec2NodeClassLabel     = "ec2nodeclass"
+	imageIDLabel          = "image_id"
+	reasonLabel           = "reason"
+)
+
+var PinnedAMIUnavailable = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: ec2NodeClassSubsystem,
+		Name:      "pinned_ami_unavailable",
+		Help:      "Whether an AMI which is pinned by id in the amiSelectorTerms of an EC2NodeClass is unavailable. Labeled by EC2NodeClass, image id and reason, which is PinnedAMINotFound or PinnedAMIDeprecated.",
+	},
+	[]string{ec2NodeClassLabel, imageIDLabel, reasonLabel},
+)
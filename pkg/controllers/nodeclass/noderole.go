@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+)
+
+// nodeRolePermission is a group of actions which nodes commonly need their role to allow, and the status condition
+// which surfaces whether the role allows them
+type nodeRolePermission struct {
+	conditionType string
+	actions       []string
+	// required returns whether nodes launched with the EC2NodeClass need the actions
+	required func(*v1.EC2NodeClass) bool
+}
+
+var nodeRolePermissions = []nodeRolePermission{
+	{
+		conditionType: v1.ConditionTypeNodeRoleECRPullAllowed,
+		actions:       []string{"ecr:GetAuthorizationToken", "ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer"},
+		required:      func(*v1.EC2NodeClass) bool { return true },
+	},
+	{
+		conditionType: v1.ConditionTypeNodeRoleDescribeClusterAllowed,
+		actions:       []string{"eks:DescribeCluster"},
+		// nodeadm, which bootstraps AL2023 nodes, may call DescribeCluster when its config doesn't include the cluster details
+		required: func(nodeClass *v1.EC2NodeClass) bool { return nodeClass.AMIFamily() == v1.AMIFamilyAL2023 },
+	},
+	{
+		conditionType: v1.ConditionTypeNodeRoleEBSCSIAllowed,
+		actions: []string{"ec2:CreateVolume", "ec2:DeleteVolume", "ec2:AttachVolume", "ec2:DetachVolume",
+			"ec2:DescribeVolumes", "ec2:DescribeInstances", "ec2:CreateTags"},
+		required: func(*v1.EC2NodeClass) bool { return true },
+	},
+}
+
+// NodeRole simulates the policies of the node role with the IAM policy simulator, surfacing the actions which nodes
+// commonly need but the role doesn't allow. Nodes which are missing these permissions join the cluster but fail to pull
+// images or attach volumes, which is otherwise only noticed once pods fail to start.
+type NodeRole struct {
+	instanceProfileProvider instanceprofile.Provider
+}
+
+func (n *NodeRole) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if !options.FromContext(ctx).FeatureGates.SimulateNodeRolePermissions || nodeClass.Status.InstanceProfile == "" {
+		return reconcile.Result{}, nil
+	}
+	permissions := lo.Filter(nodeRolePermissions, func(p nodeRolePermission, _ int) bool { return p.required(nodeClass) })
+	denied, err := n.instanceProfileProvider.DeniedActions(ctx, nodeClass.Status.InstanceProfile, lo.FlatMap(permissions, func(p nodeRolePermission, _ int) []string {
+		return p.actions
+	}))
+	if err != nil {
+		for _, p := range permissions {
+			nodeClass.StatusConditions().SetUnknownWithReason(p.conditionType, "SimulationFailed", fmt.Sprintf("Failed to simulate the policies of the node role, %s", err))
+		}
+		return reconcile.Result{}, fmt.Errorf("simulating node role permissions, %w", err)
+	}
+	for _, p := range permissions {
+		if missing := lo.Intersect(p.actions, denied); len(missing) != 0 {
+			nodeClass.StatusConditions().SetFalse(p.conditionType, "ActionsDenied", fmt.Sprintf("Node role is not allowed to perform %s", strings.Join(missing, ", ")))
+			continue
+		}
+		nodeClass.StatusConditions().SetTrue(p.conditionType)
+	}
+	return reconcile.Result{}, nil
+}
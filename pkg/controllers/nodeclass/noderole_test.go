@@ -0,0 +1,129 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Node Role Status Controller", func() {
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SimulateNodeRolePermissions: lo.ToPtr(true)}}))
+		nodeClass.Spec.Role = "test-role"
+	})
+	AfterEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+	})
+	It("should set the node role conditions to true when the role is allowed every action", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleECRPullAllowed).IsTrue()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleEBSCSIAllowed).IsTrue()).To(BeTrue())
+
+		input := awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop()
+		Expect(aws.ToString(input.PolicySourceArn)).To(Equal(fmt.Sprintf("arn:aws:iam::%s:role/test-role", fake.DefaultAccount)))
+		Expect(input.ActionNames).To(ContainElements("ecr:GetAuthorizationToken", "ec2:AttachVolume"))
+	})
+	It("should set a node role condition to false when the role isn't allowed one of its actions", func() {
+		awsEnv.IAMAPI.DeniedActions.Insert("ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleECRPullAllowed)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("ActionsDenied"))
+		Expect(condition.Message).To(Equal("Node role is not allowed to perform ecr:BatchGetImage, ecr:GetDownloadUrlForLayer"))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleEBSCSIAllowed).IsTrue()).To(BeTrue())
+	})
+	It("should only check DescribeCluster for AL2023 nodeClasses", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop().ActionNames).ToNot(ContainElement("eks:DescribeCluster"))
+		Expect(ExpectExists(ctx, env.Client, nodeClass).StatusConditions().Get(v1.ConditionTypeNodeRoleDescribeClusterAllowed)).To(BeNil())
+
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+		awsEnv.IAMAPI.DeniedActions.Insert("eks:DescribeCluster")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop().ActionNames).To(ContainElement("eks:DescribeCluster"))
+		Expect(ExpectExists(ctx, env.Client, nodeClass).StatusConditions().Get(v1.ConditionTypeNodeRoleDescribeClusterAllowed).IsFalse()).To(BeTrue())
+	})
+	It("should cache the simulation for the role", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.Calls()).To(Equal(1))
+	})
+	It("should set the node role conditions to unknown when the simulation fails", func() {
+		awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.Error.Set(fmt.Errorf("not authorized to perform iam:SimulatePrincipalPolicy"))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleECRPullAllowed)
+		Expect(condition.IsUnknown()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("SimulationFailed"))
+	})
+	It("should not simulate the node role when the simulation is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.Calls()).To(Equal(0))
+		Expect(ExpectExists(ctx, env.Client, nodeClass).StatusConditions().Get(v1.ConditionTypeNodeRoleECRPullAllowed)).To(BeNil())
+	})
+	It("should not gate the readiness of the nodeClass", func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				Role: "test-role",
+			},
+		})
+		awsEnv.IAMAPI.DeniedActions.Insert("ecr:GetAuthorizationToken")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNodeRoleECRPullAllowed).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+})
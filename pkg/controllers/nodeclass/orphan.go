@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// orphanedTagKeys are the tags which mark an instance as owned by Karpenter. They're removed from orphaned instances so
+// that garbage collection doesn't terminate them once their NodeClaims are gone.
+var orphanedTagKeys = []string{v1.NodePoolTagKey, v1.NodeClassTagKey, v1.NodeClaimTagKey}
+
+// orphan releases the NodeClaims from Karpenter's management without terminating their instances
+func (c *Controller) orphan(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaims []karpv1.NodeClaim) error {
+	var errs error
+	for i := range nodeClaims {
+		if err := c.orphanNodeClaim(ctx, &nodeClaims[i]); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("orphaning nodeclaim %s, %w", nodeClaims[i].Name, err))
+			continue
+		}
+		log.FromContext(ctx).WithValues("NodeClaim", client.ObjectKeyFromObject(&nodeClaims[i]), "provider-id", nodeClaims[i].Status.ProviderID).Info("orphaned nodeclaim")
+		c.recorder.Publish(OrphanedNodeClaimEvent(nodeClass, &nodeClaims[i]))
+	}
+	return errs
+}
+
+// orphanNodeClaim removes Karpenter's ownership tags from the instance, detaches the node from the NodeClaim, and then
+// deletes the NodeClaim without running its termination finalizer. Each step is idempotent so that a failed attempt
+// can be retried.
+func (c *Controller) orphanNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
+	if nodeClaim.Status.ProviderID != "" {
+		id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+		if err != nil {
+			return fmt.Errorf("parsing instance id, %w", err)
+		}
+		if err := c.instanceProvider.DeleteTags(ctx, id, orphanedTagKeys); err != nil && !cloudprovider.IsNodeClaimNotFoundError(err) {
+			return err
+		}
+	}
+	if nodeClaim.Status.NodeName != "" {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("getting node, %w", err)
+		} else if err == nil {
+			if err := c.releaseNode(ctx, node, nodeClaim); err != nil {
+				return err
+			}
+		}
+	}
+	stored := nodeClaim.DeepCopy()
+	controllerutil.RemoveFinalizer(nodeClaim, karpv1.TerminationFinalizer)
+	if !equality.Semantic.DeepEqual(stored, nodeClaim) {
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("removing termination finalizer, %w", err))
+		}
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("deleting nodeclaim, %w", err))
+	}
+	return nil
+}
+
+// releaseNode removes the owner reference, labels and finalizer which tie the node to the NodeClaim, so that the node
+// isn't garbage collected or drained when the NodeClaim is deleted
+func (c *Controller) releaseNode(ctx context.Context, node *corev1.Node, nodeClaim *karpv1.NodeClaim) error {
+	stored := node.DeepCopy()
+	node.OwnerReferences = lo.Reject(node.OwnerReferences, func(o metav1.OwnerReference, _ int) bool { return o.UID == nodeClaim.UID })
+	delete(node.Labels, karpv1.NodePoolLabelKey)
+	delete(node.Labels, karpv1.NodeRegisteredLabelKey)
+	delete(node.Labels, karpv1.NodeInitializedLabelKey)
+	controllerutil.RemoveFinalizer(node, karpv1.TerminationFinalizer)
+	if equality.Semantic.DeepEqual(stored, node) {
+		return nil
+	}
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("releasing node, %w", err))
+	}
+	return nil
+}
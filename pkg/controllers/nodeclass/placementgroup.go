@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+)
+
+type PlacementGroup struct {
+	placementGroupProvider placementgroup.Provider
+}
+
+// Reconcile resolves the placement group which is selected by the EC2NodeClass. The placement group is only resolved
+// when exactly one placement group is selected. The ZonalResourcesValid condition surfaces why it isn't resolved.
+func (p *PlacementGroup) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if nodeClass.Spec.PlacementGroup == nil {
+		nodeClass.Status.PlacementGroup = nil
+		return reconcile.Result{}, nil
+	}
+	placementGroups, err := p.placementGroupProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting placement groups, %w", err)
+	}
+	if len(placementGroups) != 1 {
+		nodeClass.Status.PlacementGroup = nil
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	nodeClass.Status.PlacementGroup = &v1.ResolvedPlacementGroup{
+		ID:             aws.ToString(placementGroups[0].GroupId),
+		Name:           aws.ToString(placementGroups[0].GroupName),
+		Strategy:       string(placementGroups[0].Strategy),
+		PartitionCount: aws.ToInt32(placementGroups[0].PartitionCount),
+		SpreadLevel:    string(placementGroups[0].SpreadLevel),
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
@@ -0,0 +1,133 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Placement Group Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.DescribePlacementGroupsOutput.Set(&ec2.DescribePlacementGroupsOutput{PlacementGroups: []ec2types.PlacementGroup{
+			{
+				GroupId:        aws.String("pg-test1"),
+				GroupName:      aws.String("test-partition"),
+				Strategy:       ec2types.PlacementStrategyPartition,
+				State:          ec2types.PlacementGroupStateAvailable,
+				PartitionCount: aws.Int32(3),
+				Tags:           []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			},
+			{
+				GroupId:     aws.String("pg-test2"),
+				GroupName:   aws.String("test-spread"),
+				Strategy:    ec2types.PlacementStrategySpread,
+				State:       ec2types.PlacementGroupStateAvailable,
+				SpreadLevel: ec2types.SpreadLevelRack,
+				Tags:        []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("web")}},
+			},
+			{
+				GroupId:   aws.String("pg-test3"),
+				GroupName: aws.String("test-cluster"),
+				Strategy:  ec2types.PlacementStrategyCluster,
+				State:     ec2types.PlacementGroupStateAvailable,
+				Tags:      []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			},
+		}})
+	})
+	It("should resolve the placement group selected by name", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-partition"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(Equal(&v1.ResolvedPlacementGroup{
+			ID:             "pg-test1",
+			Name:           "test-partition",
+			Strategy:       v1.PlacementGroupStrategyPartition,
+			PartitionCount: 3,
+		}))
+		Expect(nodeClass.PlacementGroupPartitions()).To(BeEquivalentTo(3))
+	})
+	It("should resolve the placement group selected by tags", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"team": "web"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(Equal(&v1.ResolvedPlacementGroup{
+			ID:          "pg-test2",
+			Name:        "test-spread",
+			Strategy:    v1.PlacementGroupStrategySpread,
+			SpreadLevel: "rack",
+		}))
+		Expect(nodeClass.PlacementGroupName()).To(Equal("test-spread"))
+		Expect(nodeClass.PlacementGroupPartitions()).To(BeZero())
+	})
+	It("should not resolve the placement group when it doesn't exist", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"team": "data"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+	})
+	It("should not resolve the placement group when multiple placement groups match the tags", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"team": "ml"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+	})
+	It("should clear the resolved placement group when it's removed from the spec", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-cluster"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+
+		nodeClass.Spec.PlacementGroup = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
+)
+
+// Quotas validates the limits of the NodePools which launch instances with the EC2NodeClass against the service quotas
+// of the account, so that a NodePool which can never reach its limits is surfaced before launches fail with
+// VcpuLimitExceeded errors. Quotas are shared by every NodePool of the account, so each NodePool is validated against
+// the whole quota, and NodePools which don't have a cpu limit aren't validated.
+type Quotas struct {
+	kubeClient           client.Client
+	instanceTypeProvider instancetype.Provider
+	quotaProvider        quota.Provider
+}
+
+func (q *Quotas) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if !options.FromContext(ctx).FeatureGates.ValidateQuotas {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeQuotasSufficient)
+		return reconcile.Result{}, nil
+	}
+	// The instance types that the NodePools can launch are resolved from the zones of the subnets
+	if len(nodeClass.Status.Subnets) == 0 {
+		return reconcile.Result{}, nil
+	}
+	nodePools, err := q.nodePools(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	instanceTypes, err := q.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing instance types, %w", err)
+	}
+	var messages []string
+	for _, nodePool := range nodePools {
+		limit, ok := nodePool.Spec.Limits[corev1.ResourceCPU]
+		if !ok {
+			continue
+		}
+		message, err := q.validate(ctx, nodeClass, nodePool, instanceTypes, float64(limit.MilliValue())/1000)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if message != "" {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeQuotasSufficient, "QuotaExceeded", strings.Join(messages, "; "))
+	} else {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeQuotasSufficient)
+	}
+	return reconcile.Result{RequeueAfter: 10 * time.Minute}, nil
+}
+
+// validate returns a message describing the quotas which prevent the NodePool from launching up to its cpu limit, or
+// an empty message if the quotas are sufficient
+func (q *Quotas) validate(ctx context.Context, nodeClass *v1.EC2NodeClass, nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType, cpuLimit float64) (string, error) {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	quotas := map[quota.Quota]struct{}{}
+	minCPU := math.MaxFloat64
+	for _, it := range instanceTypes {
+		if requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+			continue
+		}
+		offerings := it.Offerings.Compatible(requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		for _, o := range offerings {
+			if vcpuQuota, ok := quota.VCPUQuota(it.Requirements.Get(v1.LabelInstanceFamily).Any(), o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()); ok {
+				quotas[vcpuQuota] = struct{}{}
+			}
+		}
+		if cpu := float64(it.Capacity.Cpu().MilliValue()) / 1000; cpu > 0 {
+			minCPU = math.Min(minCPU, cpu)
+		}
+	}
+	// NodePools which can only launch instances without vCPU quotas aren't limited
+	if len(quotas) == 0 {
+		return "", nil
+	}
+	var vcpus float64
+	var names []string
+	for vcpuQuota := range quotas {
+		value, ok, err := q.quotaProvider.Get(ctx, vcpuQuota)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", nil
+		}
+		vcpus += value
+		names = append(names, fmt.Sprintf("%s: %s", vcpuQuota, formatQuota(value)))
+	}
+	sort.Strings(names)
+	if cpuLimit > vcpus {
+		return fmt.Sprintf("NodePool %s has a cpu limit of %s, but the vCPU quotas of the instance types it can launch only allow %s vCPUs (%s)",
+			nodePool.Name, formatQuota(cpuLimit), formatQuota(vcpus), strings.Join(names, ", ")), nil
+	}
+	// The most storage is used when the NodePool reaches its cpu limit with the smallest instance type it can launch
+	nodes := math.Ceil(cpuLimit / minCPU)
+	storage := map[string]float64{}
+	for _, bdm := range nodeClass.Spec.BlockDeviceMappings {
+		if bdm.EBS == nil || bdm.EBS.VolumeSize == nil || bdm.EBS.VolumeType == nil {
+			continue
+		}
+		storage[*bdm.EBS.VolumeType] += float64(bdm.EBS.VolumeSize.Value()) / math.Pow(1024, 4)
+	}
+	for _, volumeType := range lo.Keys(storage) {
+		storageQuota, ok := quota.VolumeStorageQuota(volumeType)
+		if !ok {
+			continue
+		}
+		value, ok, err := q.quotaProvider.Get(ctx, storageQuota)
+		if err != nil {
+			return "", err
+		}
+		if required := storage[volumeType] * nodes; ok && required > value {
+			return fmt.Sprintf("NodePool %s can launch up to %s nodes within its cpu limit, whose %s volumes need %s TiB, but %s is %s TiB",
+				nodePool.Name, formatQuota(nodes), volumeType, formatQuota(required), storageQuota, formatQuota(value)), nil
+		}
+	}
+	return "", nil
+}
+
+// nodePools returns the NodePools which launch instances with the EC2NodeClass
+func (q *Quotas) nodePools(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]*karpv1.NodePool, error) {
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := q.kubeClient.List(ctx, nodePoolList); err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	gvk := object.GVK(nodeClass)
+	nodePools := lo.FilterMap(nodePoolList.Items, func(np karpv1.NodePool, _ int) (*karpv1.NodePool, bool) {
+		ref := np.Spec.Template.Spec.NodeClassRef
+		return &np, np.DeletionTimestamp.IsZero() && ref != nil && ref.Group == gvk.Group && ref.Kind == gvk.Kind && ref.Name == nodeClass.Name
+	})
+	sort.Slice(nodePools, func(i, j int) bool { return nodePools[i].Name < nodePools[j].Name })
+	return nodePools, nil
+}
+
+func formatQuota(value float64) string {
+	return fmt.Sprintf("%g", math.Round(value*100)/100)
+}
@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Quotas Status Controller", func() {
+	var nodePool *karpv1.NodePool
+	var onDemandStandard, spotStandard quota.Quota
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{ValidateQuotas: lo.ToPtr(true)}}))
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+						Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceFamily, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5"}}},
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+						},
+					},
+				},
+				Limits: karpv1.Limits{corev1.ResourceCPU: resource.MustParse("1000")},
+			},
+		})
+		onDemandStandard, _ = quota.VCPUQuota("m5", karpv1.CapacityTypeOnDemand)
+		spotStandard, _ = quota.VCPUQuota("m5", karpv1.CapacityTypeSpot)
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+	AfterEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+	})
+	It("should not set the condition when quotas aren't validated", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 64)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient)).To(BeNil())
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(Equal(0))
+	})
+	It("should set the condition to true when the vCPU quota allows the cpu limit", func() {
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 1024)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when the cpu limit exceeds the vCPU quota", func() {
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 64)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("QuotaExceeded"))
+		Expect(condition.Message).To(ContainSubstring(nodePool.Name))
+		Expect(condition.Message).To(ContainSubstring(onDemandStandard.Code))
+	})
+	It("should sum the vCPU quotas of the capacity types that the NodePool can launch", func() {
+		nodePool.Spec.Template.Spec.Requirements[1].Values = []string{karpv1.CapacityTypeOnDemand, karpv1.CapacityTypeSpot}
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 512)
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, spotStandard.Code, 512)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient).IsTrue()).To(BeTrue())
+	})
+	It("should not validate NodePools without a cpu limit", func() {
+		nodePool.Spec.Limits = nil
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 64)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient).IsTrue()).To(BeTrue())
+	})
+	It("should not validate quotas which aren't available in the region", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when the volumes exceed the EBS storage quota", func() {
+		nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{{
+			DeviceName: lo.ToPtr("/dev/xvda"),
+			EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("2Ti")), VolumeType: lo.ToPtr("gp3")},
+			RootVolume: true,
+		}}
+		gp3, _ := quota.VolumeStorageQuota("gp3")
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 1024)
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEBS, gp3.Code, 1)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeQuotasSufficient)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring(gp3.Code))
+	})
+})
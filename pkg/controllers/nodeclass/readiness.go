@@ -41,5 +41,12 @@ func (n Readiness) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (r
 			return reconcile.Result{}, fmt.Errorf("failed to detect the cluster CIDR, %w", err)
 		}
 	}
+	// Readiness gates are managed by external controllers. We only surface gates which haven't been reported yet so
+	// that the Ready condition reflects that we are still waiting on them.
+	for _, gate := range nodeClass.Spec.ReadinessGates {
+		if nodeClass.StatusConditions().Get(gate.ConditionType) == nil {
+			nodeClass.StatusConditions().SetUnknownWithReason(gate.ConditionType, "AwaitingReadinessGate", fmt.Sprintf("Waiting for %s to be reported", gate.ConditionType))
+		}
+	}
 	return reconcile.Result{}, nil
 }
@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/vpcendpoint"
+)
+
+// ecrRegistryPattern matches private ECR registry hostnames, e.g. 111122223333.dkr.ecr.us-west-2.amazonaws.com, and
+// captures the region of the registry
+var ecrRegistryPattern = regexp.MustCompile(`\b[0-9]{12}\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com`)
+
+// ecrServices are the services which require a VPC endpoint for nodes in an isolated VPC to pull images from ECR.
+// Image layers are served from S3.
+var ecrServices = []string{"ecr.api", "ecr.dkr", "s3"}
+
+// Registry surfaces whether nodes launched with the EC2NodeClass are likely to be able to pull images from ECR. Image
+// pulls commonly fail after the node joins the cluster when registry mirrors (e.g. ECR pull-through caches) referenced
+// in userData are in a different region than the nodes, or when an isolated VPC is missing the VPC endpoints for ECR.
+type Registry struct {
+	region              string
+	subnetProvider      subnet.Provider
+	vpcEndpointProvider vpcendpoint.Provider
+}
+
+func (r *Registry) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if regions := r.mismatchedRegions(nodeClass); len(regions) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeRegistriesReachable, "RegistryRegionMismatch",
+			fmt.Sprintf("UserData references ECR registries in %s, but nodes are launched in %s", strings.Join(regions, ", "), r.region))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	if options.FromContext(ctx).IsolatedVPC {
+		subnets, err := r.subnetProvider.List(ctx, nodeClass)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("getting subnets, %w", err)
+		}
+		vpcIDs := lo.Uniq(lo.FilterMap(subnets, func(s ec2types.Subnet, _ int) (string, bool) {
+			return lo.FromPtr(s.VpcId), s.VpcId != nil
+		}))
+		sort.Strings(vpcIDs)
+		for _, vpcID := range vpcIDs {
+			endpoints, err := r.vpcEndpointProvider.List(ctx, vpcID)
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("getting vpc endpoints, %w", err)
+			}
+			if missing := r.missingServices(endpoints); len(missing) != 0 {
+				nodeClass.StatusConditions().SetFalse(v1.ConditionTypeRegistriesReachable, "VPCEndpointsNotFound",
+					fmt.Sprintf("VPC %s does not have VPC endpoints for %s, which are required to pull images from ECR in an isolated VPC", vpcID, strings.Join(missing, ", ")))
+				return reconcile.Result{RequeueAfter: time.Minute}, nil
+			}
+		}
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeRegistriesReachable)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+// mismatchedRegions returns the regions of the ECR registries referenced in userData which differ from the region
+// that nodes are launched in
+func (r *Registry) mismatchedRegions(nodeClass *v1.EC2NodeClass) []string {
+	regions := lo.Uniq(lo.FilterMap(ecrRegistryPattern.FindAllStringSubmatch(lo.FromPtr(nodeClass.Spec.UserData), -1), func(match []string, _ int) (string, bool) {
+		return match[1], match[1] != r.region
+	}))
+	sort.Strings(regions)
+	return regions
+}
+
+// missingServices returns the ECR services which don't have a VPC endpoint in the region
+func (r *Registry) missingServices(endpoints []ec2types.VpcEndpoint) []string {
+	return lo.Reject(ecrServices, func(service string, _ int) bool {
+		// Service names are of the form com.amazonaws.<region>.<service>, with a partition specific prefix in some partitions
+		return lo.ContainsBy(endpoints, func(endpoint ec2types.VpcEndpoint) bool {
+			return strings.HasSuffix(lo.FromPtr(endpoint.ServiceName), fmt.Sprintf(".%s.%s", r.region, service))
+		})
+	})
+}
@@ -0,0 +1,148 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Registry Status Controller", func() {
+	vpcEndpoint := func(service string) ec2types.VpcEndpoint {
+		return ec2types.VpcEndpoint{
+			VpcEndpointId: aws.String(fmt.Sprintf("vpce-%s", service)),
+			VpcId:         aws.String("vpc-test1"),
+			ServiceName:   aws.String(fmt.Sprintf("com.amazonaws.%s.%s", fake.DefaultRegion, service)),
+			State:         ec2types.StateAvailable,
+		}
+	}
+	AfterEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+	})
+	It("should set RegistriesReachable to true when userData doesn't reference ECR registries", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsTrue()).To(BeTrue())
+	})
+	It("should set RegistriesReachable to true when userData references ECR registries in the same region", func() {
+		nodeClass.Spec.UserData = lo.ToPtr(fmt.Sprintf(`[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["https://111122223333.dkr.ecr.%s.amazonaws.com/docker-hub"]`, fake.DefaultRegion))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsTrue()).To(BeTrue())
+	})
+	It("should set RegistriesReachable to false when userData references ECR registries in another region", func() {
+		nodeClass.Spec.UserData = lo.ToPtr(`[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["https://111122223333.dkr.ecr.eu-west-1.amazonaws.com/docker-hub"]
+[plugins."io.containerd.grpc.v1.cri".registry.mirrors."quay.io"]
+  endpoint = ["https://111122223333.dkr.ecr.ap-south-1.amazonaws.com/quay"]`)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("RegistryRegionMismatch"))
+		Expect(condition.Message).To(Equal(fmt.Sprintf("UserData references ECR registries in ap-south-1, eu-west-1, but nodes are launched in %s", fake.DefaultRegion)))
+	})
+	It("should not gate the readiness of the nodeClass", func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				UserData: lo.ToPtr("https://111122223333.dkr.ecr.eu-west-1.amazonaws.com"),
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should not check for VPC endpoints when the VPC isn't isolated", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsTrue()).To(BeTrue())
+	})
+	Context("Isolated VPC", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{IsolatedVPC: lo.ToPtr(true)}))
+		})
+		It("should set RegistriesReachable to true when the VPC has endpoints for ECR", func() {
+			awsEnv.EC2API.DescribeVpcEndpointsOutput.Set(&ec2.DescribeVpcEndpointsOutput{
+				VpcEndpoints: []ec2types.VpcEndpoint{vpcEndpoint("ecr.api"), vpcEndpoint("ecr.dkr"), vpcEndpoint("s3")},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsTrue()).To(BeTrue())
+		})
+		It("should set RegistriesReachable to false when the VPC is missing endpoints for ECR", func() {
+			awsEnv.EC2API.DescribeVpcEndpointsOutput.Set(&ec2.DescribeVpcEndpointsOutput{
+				VpcEndpoints: []ec2types.VpcEndpoint{vpcEndpoint("ecr.api")},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("VPCEndpointsNotFound"))
+			Expect(condition.Message).To(ContainSubstring("vpc-test1"))
+			Expect(condition.Message).To(ContainSubstring("ecr.dkr, s3"))
+		})
+		It("should ignore VPC endpoints for ECR in other regions", func() {
+			endpoints := lo.Map([]string{"ecr.api", "ecr.dkr", "s3"}, func(service string, _ int) ec2types.VpcEndpoint {
+				endpoint := vpcEndpoint(service)
+				endpoint.ServiceName = aws.String(fmt.Sprintf("com.amazonaws.eu-west-1.%s", service))
+				return endpoint
+			})
+			awsEnv.EC2API.DescribeVpcEndpointsOutput.Set(&ec2.DescribeVpcEndpointsOutput{VpcEndpoints: endpoints})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeRegistriesReachable).IsFalse()).To(BeTrue())
+		})
+	})
+})
@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
@@ -37,9 +38,12 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (rec
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting subnets, %w", err)
 	}
+	if nodeClass.PrivateSubnetsOnly() {
+		subnets = lo.Reject(subnets, func(s ec2types.Subnet, _ int) bool { return lo.FromPtr(s.MapPublicIpOnLaunch) })
+	}
 	if len(subnets) == 0 {
 		nodeClass.Status.Subnets = nil
-		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSubnetsReady, "SubnetsNotFound", "SubnetSelector did not match any Subnets")
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSubnetsReady, "SubnetsNotFound", lo.Ternary(nodeClass.PrivateSubnetsOnly(), "SubnetSelector did not match any private Subnets", "SubnetSelector did not match any Subnets"))
 		// If users have omitted the necessary tags from their Subnets and later add them, we need to reprocess the information.
 		// Returning 'ok' in this case means that the nodeclass will remain in an unready state until the component is restarted.
 		return reconcile.Result{RequeueAfter: time.Minute}, nil
@@ -50,11 +54,13 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (rec
 		}
 		return *subnets[i].SubnetId < *subnets[j].SubnetId
 	})
+	clusterNames := options.FromContext(ctx).DiscoveryClusterNames(time.Now())
 	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet ec2types.Subnet, _ int) v1.Subnet {
 		return v1.Subnet{
 			ID:     *ec2subnet.SubnetId,
 			Zone:   *ec2subnet.AvailabilityZone,
 			ZoneID: *ec2subnet.AvailabilityZoneId,
+			Weight: subnet.Weight(nodeClass.Spec.SubnetSelectorTerms, ec2subnet, clusterNames),
 		}
 	})
 	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSubnetsReady)
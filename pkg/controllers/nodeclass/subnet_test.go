@@ -79,6 +79,46 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
 	})
+	It("Should resolve the weights of the subnets from the subnet selector terms", func() {
+		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+			{Tags: map[string]string{"*": "*"}},
+			{Tags: map[string]string{"Name": "test-subnet-?", "foo": "bar"}, Weight: lo.ToPtr[int32](10)},
+			{ID: "subnet-test2", Weight: lo.ToPtr[int32](50)},
+			{ID: "subnet-test3", Weight: lo.ToPtr[int32](20)},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		weights := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, int32) { return s.ID, s.Weight })
+		Expect(weights).To(Equal(map[string]int32{"subnet-test1": 10, "subnet-test2": 50, "subnet-test3": 20, "subnet-test4": 0}))
+	})
+	It("Should only resolve private subnets for the secure profile", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfileSecure)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
+			{
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "tstz1-1a",
+			},
+			{
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "tstz1-1c",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+	})
+	It("Should resolve public subnets for the secure profile when associatePublicIPAddress is true", func() {
+		nodeClass.Spec.Profile = lo.ToPtr(v1.ProfileSecure)
+		nodeClass.Spec.AssociatePublicIPAddress = lo.ToPtr(true)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(HaveLen(4))
+	})
 	It("Should have the correct ordering for the Subnets", func() {
 		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
 			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(20)},
@@ -25,9 +25,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/smithy-go"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -73,6 +76,15 @@ var _ = BeforeSuite(func() {
 		awsEnv.AMIProvider,
 		awsEnv.InstanceProfileProvider,
 		awsEnv.LaunchTemplateProvider,
+		awsEnv.InstanceProvider,
+		awsEnv.VPCEndpointProvider,
+		awsEnv.DHCPOptionsProvider,
+		awsEnv.CapacityBlockProvider,
+		awsEnv.PlacementGroupProvider,
+		awsEnv.InstanceTypesProvider,
+		awsEnv.QuotaProvider,
+		fake.DefaultRegion,
+		nil,
 	)
 })
 
@@ -202,6 +214,27 @@ var _ = Describe("NodeClass Termination", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		ExpectNotFound(ctx, env.Client, nodeClass)
 	})
+	It("should requeue the deletion of the NodeClass while the instance profile is still in use", func() {
+		awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+			profileName: {
+				InstanceProfileName: aws.String(profileName),
+			},
+		}
+		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		awsEnv.IAMAPI.DeleteInstanceProfileBehavior.Error.Set(&smithy.GenericAPIError{Code: "DeleteConflict", Message: "Cannot delete entity, must remove roles from instance profile first."})
+		res := ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(1))
+		ExpectExists(ctx, env.Client, nodeClass)
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
+		ExpectNotFound(ctx, env.Client, nodeClass)
+	})
 	It("should succeed to delete the NodeClass when the instance profile doesn't exist", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
@@ -264,6 +297,111 @@ var _ = Describe("NodeClass Termination", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		ExpectNotFound(ctx, env.Client, nodeClass)
 	})
+	Context("Orphan Deletion Policy", func() {
+		var instanceID string
+		var nodeClaim *karpv1.NodeClaim
+		var node *corev1.Node
+		BeforeEach(func() {
+			nodeClass.Spec.DeletionPolicy = lo.ToPtr(v1.DeletionPolicyOrphan)
+			instanceID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Tags: []ec2types.Tag{
+					{Key: aws.String(v1.NodePoolTagKey), Value: aws.String("default")},
+					{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+					{Key: aws.String(v1.NodeClaimTagKey), Value: aws.String("default")},
+					{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+				},
+			})
+			nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{karpv1.TerminationFinalizer},
+				},
+				Spec: karpv1.NodeClaimSpec{
+					NodeClassRef: &karpv1.NodeClassReference{
+						Group: object.GVK(nodeClass).Group,
+						Kind:  object.GVK(nodeClass).Kind,
+						Name:  nodeClass.Name,
+					},
+				},
+				Status: karpv1.NodeClaimStatus{
+					ProviderID: fake.ProviderID(instanceID),
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			node = coretest.Node(coretest.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{karpv1.TerminationFinalizer},
+					Labels: map[string]string{
+						karpv1.NodePoolLabelKey:       "default",
+						karpv1.NodeRegisteredLabelKey: "true",
+						"team":                        "analytics",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: object.GVK(nodeClaim).GroupVersion().String(),
+						Kind:       object.GVK(nodeClaim).Kind,
+						Name:       nodeClaim.Name,
+						UID:        nodeClaim.UID,
+					}},
+				},
+				ProviderID: fake.ProviderID(instanceID),
+			})
+			ExpectApplied(ctx, env.Client, node)
+			nodeClaim.Status.NodeName = node.Name
+			ExpectApplied(ctx, env.Client, nodeClaim)
+		})
+		It("should release the nodeclaims without terminating their instances", func() {
+			controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			ExpectNotFound(ctx, env.Client, nodeClass, nodeClaim)
+
+			raw, ok := awsEnv.EC2API.Instances.Load(instanceID)
+			Expect(ok).To(BeTrue())
+			instance := raw.(ec2types.Instance)
+			Expect(instance.State.Name).To(Equal(ec2types.InstanceStateNameRunning))
+			tags := lo.Map(instance.Tags, func(t ec2types.Tag, _ int) string { return lo.FromPtr(t.Key) })
+			Expect(tags).To(ConsistOf(v1.EKSClusterNameTagKey))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(BeZero())
+
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Finalizers).ToNot(ContainElement(karpv1.TerminationFinalizer))
+			Expect(node.OwnerReferences).To(BeEmpty())
+			Expect(node.Labels).ToNot(HaveKey(karpv1.NodePoolLabelKey))
+			Expect(node.Labels).ToNot(HaveKey(karpv1.NodeRegisteredLabelKey))
+			Expect(node.Labels).To(HaveKeyWithValue("team", "analytics"))
+		})
+		It("should not delete the instance profile", func() {
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+				profileName: {
+					InstanceProfileName: aws.String(profileName),
+					Roles: []iamtypes.Role{
+						{
+							RoleId:   aws.String(fake.RoleID()),
+							RoleName: aws.String(nodeClass.Spec.Role),
+						},
+					},
+				},
+			}
+			controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			ExpectNotFound(ctx, env.Client, nodeClass)
+			Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(1))
+		})
+		It("should not delete the EC2NodeClass if the instance can't be untagged", func() {
+			controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			awsEnv.EC2API.DeleteTagsBehavior.Error.Set(fmt.Errorf("failed"))
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, controller, nodeClass)
+			ExpectExists(ctx, env.Client, nodeClass)
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+	})
 	It("should not call the IAM API when deleting a NodeClass with an instanceProfile specified", func() {
 		awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 			profileName: {
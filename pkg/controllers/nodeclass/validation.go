@@ -17,15 +17,22 @@ package nodeclass
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily/bootstrap"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
-type Validation struct{}
+type Validation struct {
+	subnetProvider subnet.Provider
+}
 
 func (n Validation) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
 	if offendingTag, found := lo.FindKeyBy(nodeClass.Spec.Tags, func(k string, v string) bool {
@@ -40,6 +47,110 @@ func (n Validation) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (
 			fmt.Sprintf("%q tag does not pass tag validation requirements", offendingTag))
 		return reconcile.Result{}, reconcile.TerminalError(fmt.Errorf("%q tag does not pass tag validation requirements", offendingTag))
 	}
+	violations, err := n.configRuleViolations(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(violations) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeValidationSucceeded, "ConfigRuleViolation", strings.Join(violations, "; "))
+		return reconcile.Result{}, nil
+	}
+	settings, err := bootstrap.ModeledKubeletSettings(nodeClass.Spec.KubeletConfigurationPatch)
+	if err != nil {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeValidationSucceeded, "KubeletConfigurationPatchInvalid", err.Error())
+		return reconcile.Result{}, nil
+	}
+	if len(settings) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeValidationSucceeded, "KubeletConfigurationPatchInvalid",
+			fmt.Sprintf("kubeletConfigurationPatch can't set %s, which must be set in kubelet", strings.Join(settings, ", ")))
+		return reconcile.Result{}, nil
+	}
+	if violations := encryptionViolations(ctx, nodeClass); len(violations) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeValidationSucceeded, "EncryptionPolicyViolation", strings.Join(violations, "; "))
+		return reconcile.Result{}, nil
+	}
 	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeValidationSucceeded)
 	return reconcile.Result{}, nil
 }
+
+// configRuleViolations evaluates the instances which the EC2NodeClass launches against the AWS Config managed rules in
+// the preflight-config-rules setting, so that launches which would be flagged as noncompliant, or denied by an
+// organization policy enforcing the same rule, fail before they're attempted. Launches are evaluated with the defaults
+// of the EC2NodeClass's profile, like the launch templates that they use.
+func (n Validation) configRuleViolations(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]string, error) {
+	defaulted := nodeClass.DeepCopy()
+	defaulted.SetDefaults(ctx)
+	var violations []string
+	for _, rule := range options.FromContext(ctx).ConfigRules() {
+		var violation string
+		switch rule {
+		case options.ConfigRuleEncryptedVolumes:
+			if bdm, ok := lo.Find(defaulted.Spec.BlockDeviceMappings, func(bdm *v1.BlockDeviceMapping) bool {
+				// The encryption of volumes which are created from snapshots is inherited from the snapshot, unless it's set
+				return bdm != nil && bdm.EBS != nil && lo.Ternary(bdm.EBS.Encrypted != nil, !lo.FromPtr(bdm.EBS.Encrypted), bdm.EBS.SnapshotID == nil)
+			}); ok {
+				violation = fmt.Sprintf("the volume of block device mapping %s isn't encrypted", lo.FromPtr(bdm.DeviceName))
+			}
+		case options.ConfigRuleIMDSv2:
+			if defaulted.Spec.MetadataOptions != nil && lo.FromPtr(defaulted.Spec.MetadataOptions.HTTPTokens) == "optional" {
+				violation = "metadataOptions.httpTokens is optional"
+			}
+		case options.ConfigRuleDetailedMonitoring:
+			if !lo.FromPtr(defaulted.Spec.DetailedMonitoring) {
+				violation = "detailedMonitoring isn't enabled"
+			}
+		case options.ConfigRuleNoPublicIP:
+			public, err := n.assignsPublicIPs(ctx, defaulted)
+			if err != nil {
+				return nil, err
+			}
+			if public {
+				violation = "instances are assigned public IP addresses"
+			}
+		}
+		if violation != "" {
+			violations = append(violations, fmt.Sprintf("violates AWS Config rule %s, %s", rule, violation))
+		}
+	}
+	return violations, nil
+}
+
+// assignsPublicIPs returns true if instances which are launched with the EC2NodeClass are assigned public IP addresses.
+// Unless associatePublicIPAddress is set, this is decided by the subnets that the instances are launched into.
+func (n Validation) assignsPublicIPs(ctx context.Context, nodeClass *v1.EC2NodeClass) (bool, error) {
+	if nodeClass.Spec.AssociatePublicIPAddress != nil {
+		return *nodeClass.Spec.AssociatePublicIPAddress, nil
+	}
+	subnets, err := n.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return false, fmt.Errorf("listing subnets, %w", err)
+	}
+	return lo.ContainsBy(subnets, func(s ec2types.Subnet) bool { return lo.FromPtr(s.MapPublicIpOnLaunch) }), nil
+}
+
+// encryptionViolations evaluates the block device mappings of the EC2NodeClass against the require-encryption and
+// encryption-kms-key-arns settings. The AMIs that the EC2NodeClass resolves are evaluated when they're resolved.
+func encryptionViolations(ctx context.Context, nodeClass *v1.EC2NodeClass) []string {
+	if !options.FromContext(ctx).RequireEncryption {
+		return nil
+	}
+	keys := options.FromContext(ctx).EncryptionKMSKeys()
+	// Without block device mappings, instances are launched with the encrypted root volume of the AMI family, which
+	// is encrypted with the default EBS key
+	if len(nodeClass.Spec.BlockDeviceMappings) == 0 && len(keys) != 0 {
+		return []string{"blockDeviceMappings must be set to encrypt volumes with one of the keys in encryption-kms-key-arns"}
+	}
+	var violations []string
+	for _, bdm := range nodeClass.Spec.BlockDeviceMappings {
+		if bdm == nil || bdm.EBS == nil {
+			continue
+		}
+		// The encryption of volumes which are created from snapshots is inherited from the snapshot, unless it's set
+		if lo.Ternary(bdm.EBS.Encrypted != nil, !lo.FromPtr(bdm.EBS.Encrypted), bdm.EBS.SnapshotID == nil) {
+			violations = append(violations, fmt.Sprintf("the volume of block device mapping %s isn't encrypted", lo.FromPtr(bdm.DeviceName)))
+		} else if len(keys) != 0 && !lo.Contains(keys, lo.FromPtr(bdm.EBS.KMSKeyID)) {
+			violations = append(violations, fmt.Sprintf("the volume of block device mapping %s isn't encrypted with one of the keys in encryption-kms-key-arns", lo.FromPtr(bdm.DeviceName)))
+		}
+	}
+	return violations
+}
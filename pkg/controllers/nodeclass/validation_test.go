@@ -15,10 +15,13 @@ limitations under the License.
 package nodeclass_test
 
 import (
+	"github.com/aws/aws-sdk-go-v2/aws"
 	status "github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -58,7 +61,7 @@ var _ = Describe("NodeClass Validation Status Controller", func() {
 		err := ExpectObjectReconcileFailed(ctx, env.Client, controller, nodeClass)
 		Expect(err).To(HaveOccurred())
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
-		Expect(nodeClass.Status.Conditions).To(HaveLen(6))
+		Expect(nodeClass.Status.Conditions).To(HaveLen(7))
 		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsFalse()).To(BeTrue())
 		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
 		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).Message).To(Equal("ValidationSucceeded=False"))
@@ -78,4 +81,160 @@ var _ = Describe("NodeClass Validation Status Controller", func() {
 		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
 		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
 	})
+	Context("Kubelet Configuration Patch", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.Tags = map[string]string{}
+		})
+		It("should pass validation when the patch only sets settings which instance types aren't modeled with", func() {
+			nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(`{"cpuManagerPolicy":"static"}`)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+		DescribeTable("should fail validation when the patch sets settings which instance types are modeled with", func(patch string, settings string) {
+			nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(patch)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("KubeletConfigurationPatchInvalid"))
+			Expect(condition.Message).To(ContainSubstring(settings))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+		},
+			Entry("AL2023", `{"cpuManagerPolicy":"static","maxPods":50,"kubeReserved":{"cpu":"1"}}`, "kubeReserved, maxPods"),
+			Entry("Bottlerocket", `{"max-pods":50,"eviction-hard":{"memory.available":"5%"}}`, "eviction-hard, max-pods"),
+			Entry("system reserved", `{"systemReserved":{"memory":"1Gi"}}`, "systemReserved"),
+		)
+	})
+	Context("Config Rules", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PreflightConfigRules: lo.ToPtr("ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK,EC2_INSTANCE_DETAILED_MONITORING_ENABLED,EC2_INSTANCE_NO_PUBLIC_IP"),
+			}))
+			nodeClass.Spec.Tags = map[string]string{}
+			nodeClass.Spec.AssociatePublicIPAddress = lo.ToPtr(false)
+			nodeClass.Spec.DetailedMonitoring = lo.ToPtr(true)
+			nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvda"),
+				EBS:        &v1.BlockDevice{Encrypted: lo.ToPtr(true)},
+			}}
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should pass validation when the nodeClass complies with the config rules", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+		It("should fail validation when the nodeClass launches unencrypted volumes", func() {
+			nodeClass.Spec.BlockDeviceMappings = append(nodeClass.Spec.BlockDeviceMappings, &v1.BlockDeviceMapping{
+				DeviceName: aws.String("/dev/xvdb"),
+				EBS:        &v1.BlockDevice{Encrypted: lo.ToPtr(false)},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("ConfigRuleViolation"))
+			Expect(condition.Message).To(ContainSubstring("ENCRYPTED_VOLUMES"))
+			Expect(condition.Message).To(ContainSubstring("/dev/xvdb"))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+		})
+		It("should fail validation when the nodeClass doesn't require IMDSv2", func() {
+			nodeClass.Spec.MetadataOptions = &v1.MetadataOptions{HTTPTokens: lo.ToPtr("optional")}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).Message).To(ContainSubstring("EC2_IMDSV2_CHECK"))
+		})
+		It("should fail validation when the nodeClass disables detailed monitoring", func() {
+			nodeClass.Spec.DetailedMonitoring = lo.ToPtr(false)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).Message).To(ContainSubstring("EC2_INSTANCE_DETAILED_MONITORING_ENABLED"))
+		})
+		It("should fail validation when the subnets of the nodeClass assign public IP addresses", func() {
+			nodeClass.Spec.AssociatePublicIPAddress = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).Message).To(ContainSubstring("EC2_INSTANCE_NO_PUBLIC_IP"))
+		})
+		It("should not evaluate config rules which aren't enabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			nodeClass.Spec.DetailedMonitoring = lo.ToPtr(false)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+	})
+	Context("Encryption Policy", func() {
+		key := "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{RequireEncryption: lo.ToPtr(true)}))
+			nodeClass.Spec.Tags = map[string]string{}
+			nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvda"),
+				EBS:        &v1.BlockDevice{Encrypted: lo.ToPtr(true), KMSKeyID: lo.ToPtr(key)},
+			}}
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should pass validation when the volumes are encrypted", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+		It("should fail validation when a volume isn't encrypted", func() {
+			nodeClass.Spec.BlockDeviceMappings = append(nodeClass.Spec.BlockDeviceMappings, &v1.BlockDeviceMapping{
+				DeviceName: aws.String("/dev/xvdb"),
+				EBS:        &v1.BlockDevice{Encrypted: lo.ToPtr(false)},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("EncryptionPolicyViolation"))
+			Expect(condition.Message).To(ContainSubstring("/dev/xvdb"))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+		})
+		It("should pass validation when the volumes are encrypted with an allowed key", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{RequireEncryption: lo.ToPtr(true), EncryptionKMSKeyARNs: lo.ToPtr(key)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+		It("should fail validation when a volume isn't encrypted with an allowed key", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{RequireEncryption: lo.ToPtr(true), EncryptionKMSKeyARNs: lo.ToPtr(key)}))
+			nodeClass.Spec.BlockDeviceMappings = append(nodeClass.Spec.BlockDeviceMappings, &v1.BlockDeviceMapping{
+				DeviceName: aws.String("/dev/xvdb"),
+				EBS:        &v1.BlockDevice{Encrypted: lo.ToPtr(true)},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Message).To(ContainSubstring("/dev/xvdb isn't encrypted with one of the keys"))
+		})
+		It("should fail validation when the default volumes are used with allowed keys", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{RequireEncryption: lo.ToPtr(true), EncryptionKMSKeyARNs: lo.ToPtr(key)}))
+			nodeClass.Spec.BlockDeviceMappings = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeValidationSucceeded).IsFalse()).To(BeTrue())
+		})
+	})
 })
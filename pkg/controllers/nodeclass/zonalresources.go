@@ -0,0 +1,110 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+)
+
+// ZonalResources validates the regional and zonal resources which are referenced by the EC2NodeClass against the zones
+// of its subnets. Otherwise, a placement group which doesn't exist only surfaces as failed launches, and a capacity
+// block in a zone without a subnet is silently never launched into.
+type ZonalResources struct {
+	placementGroupProvider placementgroup.Provider
+}
+
+func (z *ZonalResources) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if nodeClass.Spec.PlacementGroup == nil && len(nodeClass.Status.CapacityBlocks) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeZonalResourcesValid)
+		return reconcile.Result{}, nil
+	}
+	// The resources are validated against the zones of the resolved subnets, so validation waits for them to resolve
+	if len(nodeClass.Status.Subnets) == 0 {
+		return reconcile.Result{}, nil
+	}
+	zones := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) string { return s.Zone })...)
+	if nodeClass.Spec.PlacementGroup != nil {
+		placementGroups, err := z.placementGroupProvider.List(ctx, nodeClass)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("getting placement groups, %w", err)
+		}
+		if reason, message := validatePlacementGroup(nodeClass.Spec.PlacementGroup, placementGroups, zones); reason != "" {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeZonalResourcesValid, reason, message)
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+	if mismatched := lo.Reject(nodeClass.Status.CapacityBlocks, func(cb v1.CapacityBlock, _ int) bool { return zones.Has(cb.Zone) }); len(mismatched) != 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeZonalResourcesValid, "CapacityBlockZoneMismatch",
+			fmt.Sprintf("Capacity blocks %s are in zones without a selected subnet", strings.Join(lo.Map(mismatched, func(cb v1.CapacityBlock, _ int) string {
+				return fmt.Sprintf("%s (%s)", cb.ID, cb.Zone)
+			}), ", ")))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeZonalResourcesValid)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+// validatePlacementGroup returns the reason and message of the condition when instances can't be launched into the
+// selected placement group from the zones of the subnets
+func validatePlacementGroup(spec *v1.PlacementGroup, placementGroups []ec2types.PlacementGroup, zones sets.Set[string]) (string, string) {
+	if len(placementGroups) == 0 {
+		if spec.Name != "" {
+			return "PlacementGroupNotFound", fmt.Sprintf("Placement group %s was not found", spec.Name)
+		}
+		return "PlacementGroupNotFound", fmt.Sprintf("No placement group matches the tags %s", formatTags(spec.Tags))
+	}
+	if len(placementGroups) > 1 {
+		names := lo.Map(placementGroups, func(pg ec2types.PlacementGroup, _ int) string { return aws.ToString(pg.GroupName) })
+		sort.Strings(names)
+		return "PlacementGroupAmbiguous", fmt.Sprintf("Placement groups %s match the tags %s, but exactly one must match", strings.Join(names, ", "), formatTags(spec.Tags))
+	}
+	placementGroup := placementGroups[0]
+	name := aws.ToString(placementGroup.GroupName)
+	switch {
+	case placementGroup.State != ec2types.PlacementGroupStateAvailable:
+		return "PlacementGroupNotAvailable", fmt.Sprintf("Placement group %s is %s", name, placementGroup.State)
+	case spec.Partitions != 0 && placementGroup.Strategy != ec2types.PlacementStrategyPartition:
+		return "PlacementGroupStrategyMismatch", fmt.Sprintf("Placement group %s has the %s strategy, but instances are assigned to partitions", name, placementGroup.Strategy)
+	case aws.ToInt32(placementGroup.PartitionCount) < spec.Partitions:
+		return "PlacementGroupPartitionsMismatch", fmt.Sprintf("Placement group %s has %d partitions, but instances are assigned to %d partitions",
+			name, aws.ToInt32(placementGroup.PartitionCount), spec.Partitions)
+	// The instances of a cluster placement group are all in the zone of its first instance, so launches into the other
+	// zones of the subnets fail
+	case placementGroup.Strategy == ec2types.PlacementStrategyCluster && zones.Len() > 1:
+		return "PlacementGroupZoneMismatch", fmt.Sprintf("Cluster placement group %s can only hold instances in a single zone, but the subnets are in %s",
+			name, strings.Join(sets.List(zones), ", "))
+	}
+	return "", ""
+}
+
+// formatTags formats the tags of a selector deterministically for condition messages
+func formatTags(tags map[string]string) string {
+	keys := lo.Keys(tags)
+	sort.Strings(keys)
+	return strings.Join(lo.Map(keys, func(k string, _ int) string { return fmt.Sprintf("%s=%s", k, tags[k]) }), ", ")
+}
@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Zonal Resources Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"Name": "test-subnet-1"},
+					},
+					{
+						Tags: map[string]string{"Name": "test-subnet-2"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.DescribePlacementGroupsOutput.Set(&ec2.DescribePlacementGroupsOutput{PlacementGroups: []ec2types.PlacementGroup{
+			{
+				GroupId:        aws.String("pg-test1"),
+				GroupName:      aws.String("test-partition"),
+				Strategy:       ec2types.PlacementStrategyPartition,
+				State:          ec2types.PlacementGroupStateAvailable,
+				PartitionCount: aws.Int32(3),
+				Tags:           []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}, {Key: aws.String("tier"), Value: aws.String("training")}},
+			},
+			{
+				GroupId:   aws.String("pg-test2"),
+				GroupName: aws.String("test-cluster"),
+				Strategy:  ec2types.PlacementStrategyCluster,
+				State:     ec2types.PlacementGroupStateAvailable,
+				Tags:      []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			},
+			{
+				GroupId:        aws.String("pg-test3"),
+				GroupName:      aws.String("test-deleting"),
+				Strategy:       ec2types.PlacementStrategyPartition,
+				State:          ec2types.PlacementGroupStateDeleting,
+				PartitionCount: aws.Int32(3),
+			},
+		}})
+	})
+	capacityBlocks := func(zones ...string) *ec2.DescribeCapacityReservationsOutput {
+		return &ec2.DescribeCapacityReservationsOutput{CapacityReservations: lo.Map(zones, func(zone string, i int) ec2types.CapacityReservation {
+			return ec2types.CapacityReservation{
+				CapacityReservationId:  aws.String(lo.Ternary(i == 0, "cr-test1", "cr-test2")),
+				ReservationType:        ec2types.CapacityReservationTypeCapacityBlock,
+				State:                  ec2types.CapacityReservationStateActive,
+				InstanceType:           aws.String("p5.48xlarge"),
+				AvailabilityZone:       aws.String(zone),
+				StartDate:              aws.Time(time.Now().Add(-time.Hour)),
+				EndDate:                aws.Time(time.Now().Add(24 * time.Hour)),
+				AvailableInstanceCount: aws.Int32(1),
+				Tags:                   []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("ml")}},
+			}
+		})}
+	}
+	It("should not set the condition when no zonal resources are referenced", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid)).To(BeNil())
+	})
+	It("should set the condition to true when the placement group can be launched into", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-partition", Partitions: 3}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to true when the placement group is selected by tags", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"team": "ml", "tier": "training"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsTrue()).To(BeTrue())
+	})
+	DescribeTable("should set the condition to false when the placement group can't be launched into",
+		func(placementGroup v1.PlacementGroup, reason string) {
+			nodeClass.Spec.PlacementGroup = &placementGroup
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsFalse()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).Reason).To(Equal(reason))
+			// The condition is informational, so it doesn't gate readiness
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+		},
+		Entry("when it doesn't exist", v1.PlacementGroup{Name: "test-missing", Partitions: 3}, "PlacementGroupNotFound"),
+		Entry("when it's being deleted", v1.PlacementGroup{Name: "test-deleting", Partitions: 3}, "PlacementGroupNotAvailable"),
+		Entry("when it isn't a partition placement group", v1.PlacementGroup{Name: "test-cluster", Partitions: 3}, "PlacementGroupStrategyMismatch"),
+		Entry("when it has fewer partitions", v1.PlacementGroup{Name: "test-partition", Partitions: 5}, "PlacementGroupPartitionsMismatch"),
+		Entry("when no placement group matches the tags", v1.PlacementGroup{Tags: map[string]string{"team": "web"}}, "PlacementGroupNotFound"),
+		Entry("when multiple placement groups match the tags", v1.PlacementGroup{Tags: map[string]string{"team": "ml"}}, "PlacementGroupAmbiguous"),
+		Entry("when it's a cluster placement group and the subnets span zones", v1.PlacementGroup{Name: "test-cluster"}, "PlacementGroupZoneMismatch"),
+	)
+	It("should set the condition to true when a cluster placement group's subnets are in a single zone", func() {
+		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-cluster"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to true when the capacity blocks are in the zones of the subnets", func() {
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(capacityBlocks("test-zone-1a", "test-zone-1b"))
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{Tags: map[string]string{"team": "ml"}}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when a capacity block is in a zone without a subnet", func() {
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(capacityBlocks("test-zone-1a", "test-zone-1c"))
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{Tags: map[string]string{"team": "ml"}}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("CapacityBlockZoneMismatch"))
+		Expect(condition.Message).To(Equal("Capacity blocks cr-test2 (test-zone-1c) are in zones without a selected subnet"))
+	})
+	It("should clear the condition when the zonal resources are removed", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-missing", Partitions: 3}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid).IsFalse()).To(BeTrue())
+
+		nodeClass.Spec.PlacementGroup = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonalResourcesValid)).To(BeNil())
+	})
+})
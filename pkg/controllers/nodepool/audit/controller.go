@@ -0,0 +1,204 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// actionOrder orders the entries of a NodeClaim which were recorded at the same time
+var actionOrder = map[string]int{
+	v1.AuditActionLaunched:   0,
+	v1.AuditActionDisrupted:  1,
+	v1.AuditActionTerminated: 2,
+}
+
+// Controller maintains a ProvisioningAudit for each NodePool, which records the most recent launches, disruptions and
+// terminations of the NodePool's NodeClaims. Entries are derived from the status conditions and deletion timestamps of
+// the NodeClaims, so the audit is rebuilt from the cluster state after a restart and actions are never recorded twice.
+// The entries are kept ordered by time and bounded to the configured size, discarding the oldest entries first.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.audit")
+
+	size := options.FromContext(ctx).ProvisioningAuditSize
+	if size == 0 || !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	audit, err := c.getOrCreate(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	stored := audit.DeepCopy()
+	audit.Status.Entries = Merge(audit.Status.Entries, lo.FlatMap(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) []v1.ProvisioningAuditEntry {
+		return Entries(&nc)
+	}), size)
+	if !equality.Semantic.DeepEqual(stored, audit) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the list of entries
+		if err := c.kubeClient.Status().Patch(ctx, audit, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching provisioningaudit status, %w", err))
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// getOrCreate returns the ProvisioningAudit of the NodePool, creating it if it doesn't exist. The ProvisioningAudit is
+// owned by the NodePool so that it's garbage collected when the NodePool is deleted.
+func (c *Controller) getOrCreate(ctx context.Context, nodePool *karpv1.NodePool) (*v1.ProvisioningAudit, error) {
+	audit := &v1.ProvisioningAudit{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePool.Name}, audit); err == nil {
+		return audit, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting provisioningaudit, %w", err)
+	}
+	audit = &v1.ProvisioningAudit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodePool.Name,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         object.GVK(nodePool).GroupVersion().String(),
+				Kind:               object.GVK(nodePool).Kind,
+				Name:               nodePool.Name,
+				UID:                nodePool.UID,
+				BlockOwnerDeletion: lo.ToPtr(true),
+			}},
+		},
+		Spec: v1.ProvisioningAuditSpec{NodePool: nodePool.Name},
+	}
+	if err := c.kubeClient.Create(ctx, audit); err != nil {
+		return nil, fmt.Errorf("creating provisioningaudit, %w", err)
+	}
+	return audit, nil
+}
+
+// Entries returns the actions which have been taken on the NodeClaim
+func Entries(nodeClaim *karpv1.NodeClaim) []v1.ProvisioningAuditEntry {
+	entry := v1.ProvisioningAuditEntry{
+		NodeClaim:    nodeClaim.Name,
+		Node:         nodeClaim.Status.NodeName,
+		InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+		CapacityType: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+		Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+		ImageID:      nodeClaim.Status.ImageID,
+	}
+	if nodeClaim.Status.ProviderID != "" {
+		entry.InstanceID, _ = utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	}
+	var entries []v1.ProvisioningAuditEntry
+	if launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched); launched.IsTrue() {
+		e := entry
+		e.Action, e.Time = v1.AuditActionLaunched, launched.LastTransitionTime
+		entries = append(entries, e)
+	}
+	disruption := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeDisruptionReason)
+	if disruption.IsTrue() {
+		e := entry
+		e.Action, e.Reason, e.Time = v1.AuditActionDisrupted, disruption.Reason, disruption.LastTransitionTime
+		entries = append(entries, e)
+	}
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		e := entry
+		e.Action, e.Time = v1.AuditActionTerminated, *nodeClaim.DeletionTimestamp
+		if disruption.IsTrue() {
+			e.Reason = disruption.Reason
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Merge adds the observed entries which haven't been recorded yet to the recorded entries, and returns the newest
+// entries up to the size, ordered from oldest to newest. Entries are identified by their NodeClaim and action, and
+// the recorded values are kept for entries which were already recorded, since the NodeClaim may have changed since.
+func Merge(recorded, observed []v1.ProvisioningAuditEntry, size int) []v1.ProvisioningAuditEntry {
+	key := func(e v1.ProvisioningAuditEntry) string { return e.NodeClaim + "/" + e.Action }
+	entries := lo.UniqBy(append(append([]v1.ProvisioningAuditEntry{}, recorded...), observed...), key)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(&entries[j].Time) {
+			return entries[i].Time.Before(&entries[j].Time)
+		}
+		return actionOrder[entries[i].Action] < actionOrder[entries[j].Action]
+	})
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	return entries
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.audit").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(
+			&karpv1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				name, ok := o.GetLabels()[karpv1.NodePoolLabelKey]
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
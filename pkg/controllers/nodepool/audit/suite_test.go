@@ -0,0 +1,212 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var controller *audit.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ProvisioningAudit")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv := test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = audit.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvisioningAuditSize: lo.ToPtr(5)}))
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("ProvisioningAudit", func() {
+	var nodePool *karpv1.NodePool
+	var nodeClaim *karpv1.NodeClaim
+	var instanceID string
+
+	BeforeEach(func() {
+		nodeClass := test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		instanceID = fake.InstanceID()
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool.Name,
+					corev1.LabelInstanceTypeStable: "m5.large",
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+				NodeName:   "test-node",
+				ImageID:    "ami-123",
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+	})
+
+	It("should not create a ProvisioningAudit when the audit is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectNotFound(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+	})
+	It("should create a ProvisioningAudit owned by the NodePool", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		provisioningAudit := ExpectExists(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+		Expect(provisioningAudit.Spec.NodePool).To(Equal(nodePool.Name))
+		Expect(provisioningAudit.OwnerReferences).To(HaveLen(1))
+		Expect(provisioningAudit.OwnerReferences[0].UID).To(Equal(nodePool.UID))
+		Expect(provisioningAudit.Status.Entries).To(BeEmpty())
+	})
+	It("should record launched NodeClaims with their AWS identifiers", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		provisioningAudit := ExpectExists(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+		Expect(provisioningAudit.Status.Entries).To(HaveLen(1))
+		entry := provisioningAudit.Status.Entries[0]
+		Expect(entry.Action).To(Equal(v1.AuditActionLaunched))
+		Expect(entry.NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(entry.Node).To(Equal("test-node"))
+		Expect(entry.InstanceID).To(Equal(instanceID))
+		Expect(entry.InstanceType).To(Equal("m5.large"))
+		Expect(entry.CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+		Expect(entry.Zone).To(Equal("test-zone-1a"))
+		Expect(entry.ImageID).To(Equal("ami-123"))
+	})
+	It("should record disruptions and terminations with the disruption reason", func() {
+		nodeClaim.Finalizers = []string{karpv1.TerminationFinalizer}
+		nodeClaim.StatusConditions().SetTrueWithReason(karpv1.ConditionTypeDisruptionReason, "Drifted", "Drifted")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		provisioningAudit := ExpectExists(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+		Expect(lo.Map(provisioningAudit.Status.Entries, func(e v1.ProvisioningAuditEntry, _ int) string { return e.Action })).To(Equal([]string{
+			v1.AuditActionLaunched, v1.AuditActionDisrupted, v1.AuditActionTerminated,
+		}))
+		Expect(provisioningAudit.Status.Entries[1].Reason).To(Equal("Drifted"))
+		Expect(provisioningAudit.Status.Entries[2].Reason).To(Equal("Drifted"))
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+	})
+	It("should retain entries after their NodeClaims are removed", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectDeleted(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		provisioningAudit := ExpectExists(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+		Expect(provisioningAudit.Status.Entries).To(HaveLen(1))
+		Expect(provisioningAudit.Status.Entries[0].NodeClaim).To(Equal(nodeClaim.Name))
+	})
+	It("should not record an action twice", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		provisioningAudit := ExpectExists(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+		Expect(provisioningAudit.Status.Entries).To(HaveLen(1))
+	})
+	It("should ignore NodePools which don't reference an EC2NodeClass", func() {
+		nodePool.Spec.Template.Spec.NodeClassRef.Kind = "OtherNodeClass"
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectNotFound(ctx, env.Client, &v1.ProvisioningAudit{ObjectMeta: metav1.ObjectMeta{Name: nodePool.Name}})
+	})
+	Context("Merge", func() {
+		entry := func(nodeClaim, action string, t time.Time) v1.ProvisioningAuditEntry {
+			return v1.ProvisioningAuditEntry{NodeClaim: nodeClaim, Action: action, Time: metav1.NewTime(t)}
+		}
+		It("should keep the newest entries up to the size", func() {
+			now := time.Now().Truncate(time.Second)
+			var observed []v1.ProvisioningAuditEntry
+			for i := 0; i < 8; i++ {
+				observed = append(observed, entry(fmt.Sprintf("nodeclaim-%d", i), v1.AuditActionLaunched, now.Add(time.Duration(i)*time.Minute)))
+			}
+			entries := audit.Merge(nil, observed, 5)
+			Expect(lo.Map(entries, func(e v1.ProvisioningAuditEntry, _ int) string { return e.NodeClaim })).To(Equal([]string{
+				"nodeclaim-3", "nodeclaim-4", "nodeclaim-5", "nodeclaim-6", "nodeclaim-7",
+			}))
+			// Entries which were discarded aren't recorded again
+			Expect(audit.Merge(entries, observed, 5)).To(Equal(entries))
+		})
+		It("should keep the recorded values of entries which were already recorded", func() {
+			now := time.Now().Truncate(time.Second)
+			recorded := entry("nodeclaim-0", v1.AuditActionLaunched, now)
+			recorded.Node = "node-0"
+			observed := entry("nodeclaim-0", v1.AuditActionLaunched, now)
+			entries := audit.Merge([]v1.ProvisioningAuditEntry{recorded}, []v1.ProvisioningAuditEntry{observed}, 5)
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Node).To(Equal("node-0"))
+		})
+	})
+})
@@ -0,0 +1,272 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityforecast
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// refreshInterval is the interval at which the forecasts are recomputed, since NodeClaims are launched and subnet IP
+// addresses are used without the NodePool changing
+const refreshInterval = time.Minute
+
+// Controller publishes the pods and vCPUs that each NodePool can still launch nodes for before it reaches its limits,
+// the vCPU quotas of the account or the available IP addresses of its subnets, so that alerts can fire before pods are
+// left pending rather than after. The forecast of each constraint is an upper bound: it's the capacity of the most
+// instances of a single instance type that the NodePool can launch which fit into what's left of the constraint.
+type Controller struct {
+	kubeClient     client.Client
+	cloudProvider  cloudprovider.CloudProvider
+	subnetProvider subnet.Provider
+	quotaProvider  quota.Provider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, subnetProvider subnet.Provider, quotaProvider quota.Provider) *Controller {
+	return &Controller{
+		kubeClient:     kubeClient,
+		cloudProvider:  cloudProvider,
+		subnetProvider: subnetProvider,
+		quotaProvider:  quotaProvider,
+	}
+}
+
+// budget is what's left of each resource before a constraint is reached. Resources which aren't constrained are
+// unlimited.
+type budget struct {
+	vcpus  float64
+	memory float64
+	pods   float64
+	ips    float64
+}
+
+func unlimited() budget {
+	return budget{vcpus: math.Inf(1), memory: math.Inf(1), pods: math.Inf(1), ips: math.Inf(1)}
+}
+
+// Reconcile reconciles by request rather than by object, so that the forecasts of NodePools which were deleted are
+// removed
+func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.capacityforecast")
+
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, req.NamespacedName, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			deleteForecasts(req.Name)
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		deleteForecasts(nodePool.Name)
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if errors.IsNotFound(err) {
+			deleteForecasts(nodePool.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("resolving instance types, %w", err)
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil && len(it.Offerings.Compatible(requirements).Available()) > 0
+	})
+
+	forecasts := map[string]capacity{}
+	if b, ok := limitsBudget(nodePool); ok {
+		forecasts[ConstraintLimits] = forecast(instanceTypes, b)
+	}
+	if options.FromContext(ctx).FeatureGates.ValidateQuotas {
+		f, ok, err := c.quotasForecast(ctx, requirements, instanceTypes)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if ok {
+			forecasts[ConstraintQuotas] = f
+		}
+	}
+	b, err := c.subnetIPsBudget(ctx, nodeClass, requirements)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	forecasts[ConstraintSubnetIPs] = forecast(instanceTypes, b)
+
+	for _, constraint := range []string{ConstraintLimits, ConstraintQuotas, ConstraintSubnetIPs} {
+		labels := map[string]string{nodePoolLabel: nodePool.Name, constraintLabel: constraint}
+		f, ok := forecasts[constraint]
+		if !ok {
+			ForecastPods.Delete(labels)
+			ForecastVCPUs.Delete(labels)
+			continue
+		}
+		ForecastPods.Set(f.pods, labels)
+		ForecastVCPUs.Set(f.vcpus, labels)
+	}
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+// limitsBudget returns what's left of the cpu, memory and pods limits of the NodePool. The second return value is
+// false if the NodePool doesn't limit any of them.
+func limitsBudget(nodePool *karpv1.NodePool) (budget, bool) {
+	b := unlimited()
+	remaining := func(name corev1.ResourceName) (float64, bool) {
+		limit, ok := nodePool.Spec.Limits[name]
+		if !ok {
+			return math.Inf(1), false
+		}
+		used := nodePool.Status.Resources[name]
+		return math.Max(0, limit.AsApproximateFloat64()-used.AsApproximateFloat64()), true
+	}
+	vcpus, cpuLimited := remaining(corev1.ResourceCPU)
+	memory, memoryLimited := remaining(corev1.ResourceMemory)
+	pods, podsLimited := remaining(corev1.ResourcePods)
+	b.vcpus, b.memory, b.pods = vcpus, memory, pods
+	return b, cpuLimited || memoryLimited || podsLimited
+}
+
+// quotasForecast returns the forecast of the vCPU quotas of the instance types and capacity types that the NodePool can
+// launch. Each quota is forecast on its own, since an instance only uses the quota of its own family and capacity type,
+// and the forecast is the largest of them. The quotas are shared by every instance in the account, but only the
+// instances of the cluster's NodeClaims are counted against them. Quotas which aren't available in the region aren't
+// forecast. The second return value is false if none of the instance types is limited by a quota which is available.
+func (c *Controller) quotasForecast(ctx context.Context, requirements scheduling.Requirements, instanceTypes []*cloudprovider.InstanceType) (capacity, bool, error) {
+	quotas := map[quota.Quota][]*cloudprovider.InstanceType{}
+	for _, it := range instanceTypes {
+		for _, capacityType := range lo.Uniq(lo.Map(it.Offerings.Compatible(requirements).Available(), func(o cloudprovider.Offering, _ int) string {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
+		})) {
+			if vcpuQuota, ok := quota.VCPUQuota(it.Requirements.Get(v1.LabelInstanceFamily).Any(), capacityType); ok {
+				quotas[vcpuQuota] = append(quotas[vcpuQuota], it)
+			}
+		}
+	}
+	remaining := map[quota.Quota]float64{}
+	for vcpuQuota := range quotas {
+		value, ok, err := c.quotaProvider.Get(ctx, vcpuQuota)
+		if err != nil {
+			return capacity{}, false, err
+		}
+		if ok {
+			remaining[vcpuQuota] = value
+		}
+	}
+	if len(remaining) == 0 {
+		return capacity{}, false, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims); err != nil {
+		return capacity{}, false, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	for _, nodeClaim := range nodeClaims.Items {
+		vcpuQuota, ok := quota.VCPUQuota(nodeClaim.Labels[v1.LabelInstanceFamily], nodeClaim.Labels[karpv1.CapacityTypeLabelKey])
+		if _, counted := remaining[vcpuQuota]; ok && counted {
+			remaining[vcpuQuota] -= nodeClaim.Status.Capacity.Cpu().AsApproximateFloat64()
+		}
+	}
+	var f capacity
+	for vcpuQuota, vcpus := range remaining {
+		b := unlimited()
+		b.vcpus = math.Max(0, vcpus)
+		quotaForecast := forecast(quotas[vcpuQuota], b)
+		f.pods = math.Max(f.pods, quotaForecast.pods)
+		f.vcpus = math.Max(f.vcpus, quotaForecast.vcpus)
+	}
+	return f, true, nil
+}
+
+// subnetIPsBudget returns the available IP addresses of the subnets of the EC2NodeClass in the zones that the NodePool
+// can launch into
+func (c *Controller) subnetIPsBudget(ctx context.Context, nodeClass *v1.EC2NodeClass, requirements scheduling.Requirements) (budget, error) {
+	subnets, err := c.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return budget{}, fmt.Errorf("listing subnets, %w", err)
+	}
+	b := unlimited()
+	b.ips = 0
+	for _, s := range subnets {
+		if requirements.Get(corev1.LabelTopologyZone).Has(lo.FromPtr(s.AvailabilityZone)) {
+			b.ips += float64(lo.FromPtr(s.AvailableIpAddressCount))
+		}
+	}
+	return b, nil
+}
+
+// capacity is the pods and vCPUs that a NodePool can still launch nodes for before it reaches a constraint
+type capacity struct {
+	pods  float64
+	vcpus float64
+}
+
+// forecast returns the pods and vCPUs of the most instances of a single instance type which fit into the budget. Each
+// instance uses an IP address for itself and for each of its pods.
+func forecast(instanceTypes []*cloudprovider.InstanceType, b budget) capacity {
+	var pods, vcpus float64
+	for _, it := range instanceTypes {
+		itVCPUs := it.Capacity.Cpu().AsApproximateFloat64()
+		itMemory := it.Capacity.Memory().AsApproximateFloat64()
+		itPods := it.Capacity.Pods().AsApproximateFloat64()
+		if itVCPUs == 0 || itMemory == 0 || itPods == 0 {
+			continue
+		}
+		instances := math.Floor(lo.Min([]float64{b.vcpus / itVCPUs, b.memory / itMemory, b.pods / itPods, b.ips / (itPods + 1)}))
+		pods = math.Max(pods, instances*itPods)
+		vcpus = math.Max(vcpus, instances*itVCPUs)
+	}
+	return capacity{pods: pods, vcpus: vcpus}
+}
+
+func deleteForecasts(nodePool string) {
+	ForecastPods.DeletePartialMatch(map[string]string{nodePoolLabel: nodePool})
+	ForecastVCPUs.DeletePartialMatch(map[string]string{nodePoolLabel: nodePool})
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.capacityforecast").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(c)
+}
@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityforecast
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	nodePoolSubsystem = "nodepools"
+	nodePoolLabel     = "nodepool"
+	constraintLabel   = "constraint"
+
+	ConstraintLimits    = "limits"
+	ConstraintQuotas    = "quotas"
+	ConstraintSubnetIPs = "subnet_ips"
+)
+
+var (
+	ForecastPods = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "capacity_forecast_pods",
+			Help:      "The number of pods that a NodePool can still launch nodes for before it reaches a constraint. Labeled by NodePool and constraint, which is one of limits, quotas or subnet_ips.",
+		},
+		[]string{nodePoolLabel, constraintLabel},
+	)
+	ForecastVCPUs = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "capacity_forecast_vcpus",
+			Help:      "The number of vCPUs that a NodePool can still launch before it reaches a constraint. Labeled by NodePool and constraint, which is one of limits, quotas or subnet_ips.",
+		},
+		[]string{nodePoolLabel, constraintLabel},
+	)
+)
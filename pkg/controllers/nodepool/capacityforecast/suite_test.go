@@ -0,0 +1,227 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityforecast_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/object"
+	opstatus "github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/capacityforecast"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *capacityforecast.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityForecast")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = capacityforecast.NewController(env.Client, cloudProvider, awsEnv.SubnetProvider, awsEnv.QuotaProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CapacityForecast", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Status: v1.EC2NodeClassStatus{
+				InstanceProfile: "test-profile",
+				SecurityGroups:  []v1.SecurityGroup{{ID: "sg-test1", Name: "securityGroup-test1"}},
+				Subnets: []v1.Subnet{
+					{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+					{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b"},
+				},
+			},
+		})
+		nodeClass.StatusConditions().SetTrue(opstatus.ConditionReady)
+		// The NodePool launches on-demand m5.large instances, which have 2 vCPUs and 29 pods, into test-zone-1a, whose
+		// subnet has 100 available IP addresses
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+						Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}}},
+						},
+					},
+				},
+			},
+		})
+	})
+	labels := func(constraint string) map[string]string {
+		return map[string]string{"nodepool": nodePool.Name, "constraint": constraint}
+	}
+
+	It("should forecast the capacity left before the subnet IP addresses are used", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		result := ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		// Each instance uses 30 IP addresses, so 3 instances fit into the 100 available IP addresses
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 87, labels(capacityforecast.ConstraintSubnetIPs))
+		ExpectMetricGaugeValue(capacityforecast.ForecastVCPUs, 6, labels(capacityforecast.ConstraintSubnetIPs))
+	})
+	It("should forecast the capacity left before the limits of the nodepool are reached", func() {
+		nodePool.Spec.Limits = karpv1.Limits{corev1.ResourceCPU: resource.MustParse("20")}
+		nodePool.Status.Resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("16")}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 58, labels(capacityforecast.ConstraintLimits))
+		ExpectMetricGaugeValue(capacityforecast.ForecastVCPUs, 4, labels(capacityforecast.ConstraintLimits))
+	})
+	It("should forecast no capacity once the limits of the nodepool are reached", func() {
+		nodePool.Spec.Limits = karpv1.Limits{corev1.ResourcePods: resource.MustParse("100")}
+		nodePool.Status.Resources = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("87")}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 0, labels(capacityforecast.ConstraintLimits))
+		ExpectMetricGaugeValue(capacityforecast.ForecastVCPUs, 0, labels(capacityforecast.ConstraintLimits))
+	})
+	It("should not forecast the limits of a nodepool without limits", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 87, labels(capacityforecast.ConstraintSubnetIPs))
+
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_capacity_forecast_pods", labels(capacityforecast.ConstraintLimits))
+		Expect(found).To(BeFalse())
+	})
+	It("should forecast the capacity left before the vcpu quotas are used by the cluster's nodeclaims", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{ValidateQuotas: lo.ToPtr(true)}}))
+		onDemandStandard, _ := quota.VCPUQuota("m5", karpv1.CapacityTypeOnDemand)
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 16)
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:     "other",
+					karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeOnDemand,
+					v1.LabelInstanceFamily:      "c5",
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(fake.InstanceID()),
+				Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+
+		// The standard quota is shared with the c5 nodeclaim of the other nodepool, which leaves 6 vCPUs
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 87, labels(capacityforecast.ConstraintQuotas))
+		ExpectMetricGaugeValue(capacityforecast.ForecastVCPUs, 6, labels(capacityforecast.ConstraintQuotas))
+	})
+	It("should forecast each vcpu quota on its own", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{ValidateQuotas: lo.ToPtr(true)}}))
+		nodePool.Spec.Template.Spec.Requirements[0].Values = []string{"m5.large", "inf2.xlarge"}
+		onDemandStandard, _ := quota.VCPUQuota("m5", karpv1.CapacityTypeOnDemand)
+		onDemandInf, _ := quota.VCPUQuota("inf2", karpv1.CapacityTypeOnDemand)
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandStandard.Code, 4)
+		awsEnv.ServiceQuotasAPI.SetQuota(quota.ServiceCodeEC2, onDemandInf.Code, 4)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+
+		// Each quota only fits 4 vCPUs, even though the NodePool can launch into both
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 58, labels(capacityforecast.ConstraintQuotas))
+		ExpectMetricGaugeValue(capacityforecast.ForecastVCPUs, 4, labels(capacityforecast.ConstraintQuotas))
+	})
+	It("should not forecast the quotas when quotas aren't validated", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(Equal(0))
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_capacity_forecast_vcpus", labels(capacityforecast.ConstraintQuotas))
+		Expect(found).To(BeFalse())
+	})
+	It("should remove the forecasts of a nodepool which was deleted", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 87, labels(capacityforecast.ConstraintSubnetIPs))
+
+		ExpectDeleted(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_capacity_forecast_pods", labels(capacityforecast.ConstraintSubnetIPs))
+		Expect(found).To(BeFalse())
+	})
+	It("should remove the forecasts of a nodepool whose ec2nodeclass doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		ExpectMetricGaugeValue(capacityforecast.ForecastPods, 87, labels(capacityforecast.ConstraintSubnetIPs))
+
+		ExpectDeleted(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKeyFromObject(nodePool))
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_capacity_forecast_pods", labels(capacityforecast.ConstraintSubnetIPs))
+		Expect(found).To(BeFalse())
+	})
+})
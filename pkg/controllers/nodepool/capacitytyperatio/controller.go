@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacitytyperatio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+)
+
+// refreshInterval is the interval at which the actual spot ratio is recomputed, since NodeClaims are launched and
+// registered without the NodePool changing
+const refreshInterval = time.Minute
+
+// Controller publishes the target and actual spot ratio of NodePools which configure the
+// karpenter.k8s.aws/spot-ratio annotation. The ratio itself is maintained when NodeClaims are launched by the cloud
+// provider; this controller only makes the drift between the target and the fleet observable.
+type Controller struct {
+	kubeClient client.Client
+}
+
+func NewController(kubeClient client.Client) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.capacitytyperatio")
+
+	ratio, ok, err := instance.SpotRatio(nodePool)
+	if err != nil {
+		// We don't throw an error here since the annotation won't become valid until the NodePool is updated
+		log.FromContext(ctx).Error(err, "failed parsing spot ratio")
+	}
+	if err != nil || !ok || !nodePool.DeletionTimestamp.IsZero() {
+		SpotRatioTarget.Delete(map[string]string{nodePoolLabel: nodePool.Name})
+		SpotRatioActual.Delete(map[string]string{nodePoolLabel: nodePool.Name})
+		return reconcile.Result{}, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	SpotRatioTarget.Set(float64(ratio)/100, map[string]string{nodePoolLabel: nodePool.Name})
+	if spot, onDemand := instance.CapacityTypeCPUs(nodeClaims.Items); spot+onDemand > 0 {
+		SpotRatioActual.Set(float64(spot)/float64(spot+onDemand), map[string]string{nodePoolLabel: nodePool.Name})
+	} else {
+		SpotRatioActual.Delete(map[string]string{nodePoolLabel: nodePool.Name})
+	}
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.capacitytyperatio").
+		For(&karpv1.NodePool{}).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
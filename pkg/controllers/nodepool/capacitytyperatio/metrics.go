@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacitytyperatio
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	nodePoolSubsystem = "nodepools"
+	nodePoolLabel     = "nodepool"
+)
+
+var (
+	SpotRatioTarget = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "spot_ratio_target",
+			Help:      "The share of the vCPUs of a NodePool which should run on spot capacity, as configured by its spot ratio. Labeled by NodePool.",
+		},
+		[]string{nodePoolLabel},
+	)
+	SpotRatioActual = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "spot_ratio_actual",
+			Help:      "The share of the vCPUs of a NodePool which run on spot capacity, for NodePools which configure a spot ratio. Labeled by NodePool.",
+		},
+		[]string{nodePoolLabel},
+	)
+)
@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacitytyperatio_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/object"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/capacitytyperatio"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var controller *capacitytyperatio.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityTypeRatio")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	controller = capacitytyperatio.NewController(env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CapacityTypeRatio", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.AnnotationSpotRatio: "70"},
+			},
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+	})
+	nodeClaim := func(capacityType string, cpu string) *karpv1.NodeClaim {
+		return coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:     nodePool.Name,
+					karpv1.CapacityTypeLabelKey: capacityType,
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(fake.InstanceID()),
+				Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		})
+	}
+
+	It("should publish the target and actual spot ratio of the nodepool", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool,
+			nodeClaim(karpv1.CapacityTypeSpot, "4"),
+			nodeClaim(karpv1.CapacityTypeSpot, "2"),
+			nodeClaim(karpv1.CapacityTypeOnDemand, "2"),
+		)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		ExpectMetricGaugeValue(capacitytyperatio.SpotRatioTarget, 0.7, map[string]string{"nodepool": nodePool.Name})
+		ExpectMetricGaugeValue(capacitytyperatio.SpotRatioActual, 0.75, map[string]string{"nodepool": nodePool.Name})
+	})
+	It("should not count nodeclaims which belong to other nodepools", func() {
+		other := nodeClaim(karpv1.CapacityTypeOnDemand, "8")
+		other.Labels[karpv1.NodePoolLabelKey] = "other"
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim(karpv1.CapacityTypeSpot, "2"), other)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		ExpectMetricGaugeValue(capacitytyperatio.SpotRatioActual, 1, map[string]string{"nodepool": nodePool.Name})
+	})
+	It("should remove the metrics when the nodepool no longer configures a spot ratio", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim(karpv1.CapacityTypeSpot, "2"))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectMetricGaugeValue(capacitytyperatio.SpotRatioTarget, 0.7, map[string]string{"nodepool": nodePool.Name})
+
+		delete(nodePool.Annotations, v1.AnnotationSpotRatio)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_spot_ratio_target", map[string]string{"nodepool": nodePool.Name})
+		Expect(found).To(BeFalse())
+		_, found = FindMetricWithLabelValues("karpenter_nodepools_spot_ratio_actual", map[string]string{"nodepool": nodePool.Name})
+		Expect(found).To(BeFalse())
+	})
+	It("should not publish metrics when the spot ratio is invalid", func() {
+		nodePool.Annotations[v1.AnnotationSpotRatio] = "150"
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_spot_ratio_target", map[string]string{"nodepool": nodePool.Name})
+		Expect(found).To(BeFalse())
+	})
+})
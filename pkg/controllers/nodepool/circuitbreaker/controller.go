@@ -0,0 +1,284 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// minDeletions is the number of node deletions which must be observed within the window before the circuit
+	// breaker trips, so that replacing a single node of a small NodePool doesn't trip it
+	minDeletions = 3
+
+	// syncInterval is the interval at which the circuit breaker is evaluated, and at which new nodes of a NodePool
+	// whose circuit breaker is tripped are paused
+	syncInterval = 30 * time.Second
+)
+
+// Controller trips a circuit breaker on a NodePool when the rate at which its nodes are deleted exceeds the configured
+// fraction of the NodePool within the configured window. Runaway deletions are usually caused by a misconfiguration
+// which creates a feedback loop, e.g. an EC2NodeClass change which drifts every node. While the circuit breaker is
+// tripped, every node of the NodePool is annotated with karpenter.sh/do-not-disrupt, which pauses voluntary
+// disruption. The circuit breaker stays tripped until an operator acknowledges it by copying the value of the
+// karpenter.k8s.aws/circuit-breaker-tripped annotation to the karpenter.k8s.aws/circuit-breaker-acknowledged
+// annotation on the NodePool. Forceful disruption, like expiration, interruption and NodeClaims or nodes which are
+// deleted directly, doesn't respect karpenter.sh/do-not-disrupt, so it isn't paused, although it's counted toward the
+// deletions which trip the circuit breaker.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+	clk           clock.Clock
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+		clk:           clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.circuitbreaker")
+
+	if options.FromContext(ctx).TerminationCircuitBreakerThreshold == 0 || !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	if tripped, ok := nodePool.Annotations[v1.AnnotationCircuitBreakerTripped]; ok {
+		if nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] == tripped {
+			return reconcile.Result{RequeueAfter: syncInterval}, c.reset(ctx, nodePool, nodeClaims.Items)
+		}
+		return reconcile.Result{RequeueAfter: syncInterval}, c.pause(ctx, nodePool)
+	}
+	deletions, fleet, err := c.observe(ctx, nodePool, nodeClaims.Items)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	CircuitBreakerTripped.Set(0, map[string]string{nodePoolLabel: nodePool.Name})
+	if deletions < minDeletions || float64(deletions)/float64(fleet) <= options.FromContext(ctx).TerminationCircuitBreakerThreshold {
+		return reconcile.Result{RequeueAfter: syncInterval}, nil
+	}
+	return reconcile.Result{RequeueAfter: syncInterval}, c.trip(ctx, nodePool, deletions, fleet)
+}
+
+// observe records the NodeClaims of the NodePool which started deleting on the NodePool, so that deletions are still
+// counted after the controller restarts. Deletions are forgotten once they fall outside of the window and their
+// NodeClaim has been removed, so that a NodeClaim which takes longer than the window to delete isn't counted again.
+// It returns the number of deletions within the window, and the size of the fleet that they were deleted from.
+func (c *Controller) observe(ctx context.Context, nodePool *karpv1.NodePool, nodeClaims []karpv1.NodeClaim) (int, int, error) {
+	window := options.FromContext(ctx).TerminationCircuitBreakerWindow
+	deletions := deletionsFor(ctx, nodePool)
+	names := sets.New(lo.Map(nodeClaims, func(nc karpv1.NodeClaim, _ int) string { return nc.Name })...)
+	for _, nc := range nodeClaims {
+		if _, ok := deletions[nc.Name]; !ok && !nc.DeletionTimestamp.IsZero() {
+			deletions[nc.Name] = c.clk.Now()
+		}
+	}
+	for name, t := range deletions {
+		if !names.Has(name) && c.clk.Since(t) > window {
+			delete(deletions, name)
+		}
+	}
+	stored := nodePool.DeepCopy()
+	if err := setDeletions(nodePool, deletions); err != nil {
+		return 0, 0, err
+	}
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+			return 0, 0, client.IgnoreNotFound(fmt.Errorf("annotating nodepool, %w", err))
+		}
+	}
+	recent := sets.KeySet(lo.PickBy(deletions, func(_ string, t time.Time) bool { return c.clk.Since(t) <= window }))
+	return recent.Len(), recent.Union(names).Len(), nil
+}
+
+// trip records that the circuit breaker of the NodePool tripped on the NodePool and pauses its nodes
+func (c *Controller) trip(ctx context.Context, nodePool *karpv1.NodePool, deletions, fleet int) error {
+	stored := nodePool.DeepCopy()
+	nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationCircuitBreakerTripped: c.clk.Now().UTC().Format(time.RFC3339)})
+	if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("annotating nodepool, %w", err))
+	}
+	log.FromContext(ctx).WithValues("deletions", deletions, "nodes", fleet, "window", options.FromContext(ctx).TerminationCircuitBreakerWindow).Info("tripped termination circuit breaker, pausing voluntary disruption until acknowledged")
+	c.recorder.Publish(CircuitBreakerTrippedEvent(nodePool, deletions, fleet))
+	return c.pause(ctx, nodePool)
+}
+
+// reset releases the nodes which were paused by the circuit breaker and clears its annotations from the NodePool. The
+// NodeClaims which are deleting are acknowledged, so that the deletions which tripped the circuit breaker, and the
+// forceful deletions which weren't paused while it was tripped, don't trip it again. Nodes whose
+// karpenter.sh/do-not-disrupt annotation was changed while the circuit breaker was tripped keep it.
+func (c *Controller) reset(ctx context.Context, nodePool *karpv1.NodePool, nodeClaims []karpv1.NodeClaim) error {
+	if err := c.updateNodes(ctx, nodePool, func(node *corev1.Node) {
+		if _, ok := node.Annotations[v1.AnnotationCircuitBreakerPaused]; ok {
+			delete(node.Annotations, v1.AnnotationCircuitBreakerPaused)
+			if node.Annotations[karpv1.DoNotDisruptAnnotationKey] == "true" {
+				delete(node.Annotations, karpv1.DoNotDisruptAnnotationKey)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	stored := nodePool.DeepCopy()
+	delete(nodePool.Annotations, v1.AnnotationCircuitBreakerTripped)
+	delete(nodePool.Annotations, v1.AnnotationCircuitBreakerAcknowledged)
+	if err := setDeletions(nodePool, acknowledged(nodeClaims)); err != nil {
+		return err
+	}
+	if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("annotating nodepool, %w", err))
+	}
+	CircuitBreakerTripped.Set(0, map[string]string{nodePoolLabel: nodePool.Name})
+	log.FromContext(ctx).Info("reset termination circuit breaker, resuming voluntary disruption")
+	c.recorder.Publish(CircuitBreakerResetEvent(nodePool))
+	return nil
+}
+
+// pause protects every node of the NodePool from voluntary disruption. Nodes which were already protected aren't
+// marked as paused, so that their protection is retained when the circuit breaker is reset.
+func (c *Controller) pause(ctx context.Context, nodePool *karpv1.NodePool) error {
+	CircuitBreakerTripped.Set(1, map[string]string{nodePoolLabel: nodePool.Name})
+	return c.updateNodes(ctx, nodePool, func(node *corev1.Node) {
+		if _, ok := node.Annotations[karpv1.DoNotDisruptAnnotationKey]; !ok {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{
+				karpv1.DoNotDisruptAnnotationKey:  "true",
+				v1.AnnotationCircuitBreakerPaused: "true",
+			})
+		}
+	})
+}
+
+// deletionsFor returns the deletions which are recorded on the NodePool, keyed by NodeClaim name. Deletions which
+// can't be parsed are discarded, since they only delay the circuit breaker by up to a window.
+func deletionsFor(ctx context.Context, nodePool *karpv1.NodePool) map[string]time.Time {
+	deletions := map[string]time.Time{}
+	raw, ok := nodePool.Annotations[v1.AnnotationCircuitBreakerDeletions]
+	if !ok {
+		return deletions
+	}
+	if err := json.Unmarshal([]byte(raw), &deletions); err != nil {
+		log.FromContext(ctx).Error(err, "failed parsing circuit breaker deletions, discarding them")
+		return map[string]time.Time{}
+	}
+	return deletions
+}
+
+// setDeletions records the deletions on the NodePool, and removes the annotation when there are none
+func setDeletions(nodePool *karpv1.NodePool, deletions map[string]time.Time) error {
+	if len(deletions) == 0 {
+		delete(nodePool.Annotations, v1.AnnotationCircuitBreakerDeletions)
+		return nil
+	}
+	raw, err := json.Marshal(deletions)
+	if err != nil {
+		return fmt.Errorf("marshaling circuit breaker deletions, %w", err)
+	}
+	nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationCircuitBreakerDeletions: string(raw)})
+	return nil
+}
+
+// acknowledged returns the NodeClaims which are deleting as deletions at the zero time, which falls outside of every
+// window. They're kept until their NodeClaims are removed, so that they aren't observed as new deletions.
+func acknowledged(nodeClaims []karpv1.NodeClaim) map[string]time.Time {
+	return lo.SliceToMap(lo.Filter(nodeClaims, func(nc karpv1.NodeClaim, _ int) bool { return !nc.DeletionTimestamp.IsZero() }),
+		func(nc karpv1.NodeClaim) (string, time.Time) { return nc.Name, time.Time{} })
+}
+
+func (c *Controller) updateNodes(ctx context.Context, nodePool *karpv1.NodePool, update func(*corev1.Node)) error {
+	nodes := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodes, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return fmt.Errorf("listing nodes, %w", err)
+	}
+	for i := range nodes.Items {
+		stored := nodes.Items[i].DeepCopy()
+		update(&nodes.Items[i])
+		if equality.Semantic.DeepEqual(stored, &nodes.Items[i]) {
+			continue
+		}
+		if err := c.kubeClient.Patch(ctx, &nodes.Items[i], client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				continue
+			}
+			return fmt.Errorf("annotating node, %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.circuitbreaker").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(
+			&karpv1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				name, ok := o.GetLabels()[karpv1.NodePoolLabelKey]
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+			}),
+			// Watch for NodeClaims starting to delete, so that deletions are observed before the NodeClaims are removed
+			builder.WithPredicates(predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool { return false },
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.GetDeletionTimestamp().IsZero() && !e.ObjectNew.GetDeletionTimestamp().IsZero()
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool { return false },
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func CircuitBreakerTrippedEvent(nodePool *karpv1.NodePool, deletions, fleet int) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "CircuitBreakerTripped",
+		Message: fmt.Sprintf("Paused voluntary disruption after %d of %d nodes were deleted, set the %s annotation to the value of the %s annotation to resume",
+			deletions, fleet, v1.AnnotationCircuitBreakerAcknowledged, v1.AnnotationCircuitBreakerTripped),
+		DedupeValues: []string{string(nodePool.UID)},
+	}
+}
+
+func CircuitBreakerResetEvent(nodePool *karpv1.NodePool) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "CircuitBreakerReset",
+		Message:        "Resumed voluntary disruption after the circuit breaker was acknowledged",
+		DedupeValues:   []string{string(nodePool.UID)},
+	}
+}
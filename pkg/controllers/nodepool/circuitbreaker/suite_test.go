@@ -0,0 +1,291 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/circuitbreaker"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var cloudProvider *cloudprovider.CloudProvider
+var fakeClock *clock.FakeClock
+var controller *circuitbreaker.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CircuitBreaker")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv := test.NewEnvironment(ctx, env)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	fakeClock = clock.NewFakeClock(time.Now())
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		TerminationCircuitBreakerThreshold: lo.ToPtr(0.2),
+		TerminationCircuitBreakerWindow:    lo.ToPtr(10 * time.Minute),
+	}))
+	fakeClock.SetTime(time.Now())
+	controller = circuitbreaker.NewController(env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), fakeClock)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CircuitBreaker", func() {
+	var nodePool *karpv1.NodePool
+	var nodeClaims []*karpv1.NodeClaim
+	var nodes []*corev1.Node
+
+	BeforeEach(func() {
+		nodeClass := test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		nodeClaims = nil
+		nodes = nil
+		for i := 0; i < 10; i++ {
+			nodeClaims = append(nodeClaims, coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       fmt.Sprintf("nodeclaim-%d", i),
+					Labels:     map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+					Finalizers: []string{karpv1.TerminationFinalizer},
+				},
+			}))
+			nodes = append(nodes, coretest.Node(coretest.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   fmt.Sprintf("node-%d", i),
+					Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+				},
+			}))
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		for i := range nodeClaims {
+			ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+		}
+	})
+	deleteNodeClaims := func(count int) {
+		for _, nc := range nodeClaims[:count] {
+			Expect(env.Client.Delete(ctx, nc)).To(Succeed())
+		}
+	}
+
+	It("should trip when deletions exceed the threshold", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.AnnotationCircuitBreakerTripped, fakeClock.Now().UTC().Format(time.RFC3339)))
+		for _, node := range nodes {
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+			Expect(node.Annotations).To(HaveKey(v1.AnnotationCircuitBreakerPaused))
+		}
+	})
+	It("should not trip when deletions don't exceed the threshold", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should not trip when fewer than the minimum number of nodes are deleted", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			TerminationCircuitBreakerThreshold: lo.ToPtr(0.1),
+			TerminationCircuitBreakerWindow:    lo.ToPtr(10 * time.Minute),
+		}))
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should forget deletions which fall outside of the window", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		fakeClock.Step(11 * time.Minute)
+		Expect(env.Client.Delete(ctx, nodeClaims[2])).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should count deletions of nodeclaims which have since been removed", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		Expect(env.Client.Delete(ctx, nodeClaims[2])).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).To(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should count deletions which were observed before the controller restarted", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).To(HaveKey(v1.AnnotationCircuitBreakerDeletions))
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		controller = circuitbreaker.NewController(env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), fakeClock)
+		Expect(env.Client.Delete(ctx, nodeClaims[2])).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).To(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should not count nodeclaims which take longer than the window to delete again", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		fakeClock.Step(11 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(env.Client.Delete(ctx, nodeClaims[2])).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should forget deletions outside of the window once their nodeclaims are removed", func() {
+		deleteNodeClaims(2)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		fakeClock.Step(11 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerDeletions))
+	})
+	It("should not remove do-not-disrupt from nodes which it didn't pause", func() {
+		nodes[0].Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodes[0])
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] = nodePool.Annotations[v1.AnnotationCircuitBreakerTripped]
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		Expect(ExpectExists(ctx, env.Client, nodes[1]).Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+	})
+	It("should not remove do-not-disrupt when it was changed while tripped", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodes[0] = ExpectExists(ctx, env.Client, nodes[0])
+		nodes[0].Annotations[karpv1.DoNotDisruptAnnotationKey] = "false"
+		ExpectApplied(ctx, env.Client, nodes[0])
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] = nodePool.Annotations[v1.AnnotationCircuitBreakerTripped]
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodes[0] = ExpectExists(ctx, env.Client, nodes[0])
+		Expect(nodes[0].Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "false"))
+		Expect(nodes[0].Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerPaused))
+	})
+	It("should pause nodes which launch while tripped", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name}},
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, node).Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+	})
+	It("should stay tripped until the trip is acknowledged", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] = "2006-01-02T15:04:05Z"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).To(HaveKey(v1.AnnotationCircuitBreakerTripped))
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+	})
+	It("should resume disruption when the trip is acknowledged", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] = nodePool.Annotations[v1.AnnotationCircuitBreakerTripped]
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerAcknowledged))
+		for _, node := range nodes {
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+			Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerPaused))
+		}
+
+		// The deletions which tripped the circuit breaker shouldn't trip it again, even after the controller restarts
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+		controller = circuitbreaker.NewController(env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), fakeClock)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should not trip on deletions which started while it was tripped once it's acknowledged", func() {
+		deleteNodeClaims(3)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		for _, nc := range nodeClaims[3:6] {
+			Expect(env.Client.Delete(ctx, nc)).To(Succeed())
+		}
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Annotations[v1.AnnotationCircuitBreakerAcknowledged] = nodePool.Annotations[v1.AnnotationCircuitBreakerTripped]
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+	It("should not trip when the circuit breaker is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{TerminationCircuitBreakerThreshold: lo.ToPtr(0.0)}))
+		deleteNodeClaims(10)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(ExpectExists(ctx, env.Client, nodePool).Annotations).ToNot(HaveKey(v1.AnnotationCircuitBreakerTripped))
+	})
+})
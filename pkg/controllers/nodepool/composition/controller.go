@@ -0,0 +1,199 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/reasonable"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+const (
+	// The keys of the ConfigMap that the fleet composition is published to. Every key except the total holds a JSON
+	// object of Entries keyed by the value of the dimension.
+	TotalKey         = "total"
+	InstanceTypesKey = "instance-types"
+	CapacityTypesKey = "capacity-types"
+	ZonesKey         = "zones"
+	ImagesKey        = "images"
+
+	// refreshInterval is the interval at which the fleet composition is recomputed, since both the nodes of the
+	// NodePool and the spot prices change over time
+	refreshInterval = time.Minute
+)
+
+// Entry is the number of nodes in a slice of a NodePool's fleet and their combined hourly price. Nodes whose price
+// isn't known are counted but don't contribute to the price.
+type Entry struct {
+	Count       int     `json:"count"`
+	HourlyPrice float64 `json:"hourlyPrice"`
+}
+
+func (e *Entry) add(price float64) {
+	e.Count++
+	e.HourlyPrice += price
+}
+
+// Controller publishes the composition of the nodes that each NodePool has launched to a ConfigMap. The composition is
+// computed from the NodeClaims of the NodePool and the pricing provider, so that dashboards don't need to join
+// node metrics with pricing data to answer what a NodePool is running and what it costs.
+type Controller struct {
+	kubeClient      client.Client
+	cloudProvider   cloudprovider.CloudProvider
+	pricingProvider pricing.Provider
+	namespace       string
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, pricingProvider pricing.Provider, namespace string) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		cloudProvider:   cloudProvider,
+		pricingProvider: pricingProvider,
+		namespace:       namespace,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.composition")
+
+	if !options.FromContext(ctx).FeatureGates.PublishFleetComposition || !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	data, err := c.composition(nodeClaims.Items)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The ConfigMap is owned by the NodePool so that it's garbage collected when the NodePool is deleted
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(nodePool),
+			Namespace: c.namespace,
+			Labels:    map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: object.GVK(nodePool).GroupVersion().String(),
+				Kind:       object.GVK(nodePool).Kind,
+				Name:       nodePool.Name,
+				UID:        nodePool.UID,
+			}},
+		},
+		Data: data,
+	}
+	if err := c.kubeClient.Patch(ctx, configMap, client.Apply, client.FieldOwner("karpenter"), client.ForceOwnership); err != nil {
+		return reconcile.Result{}, fmt.Errorf("applying fleet composition configmap, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+// composition returns the ConfigMap data summarizing the NodeClaims. NodeClaims which haven't been launched yet aren't
+// included, since their instance type, capacity type and zone haven't been resolved.
+func (c *Controller) composition(nodeClaims []karpv1.NodeClaim) (map[string]string, error) {
+	total := &Entry{}
+	dimensions := map[string]map[string]*Entry{
+		InstanceTypesKey: {},
+		CapacityTypesKey: {},
+		ZonesKey:         {},
+		ImagesKey:        {},
+	}
+	for i := range nodeClaims {
+		if nodeClaims[i].Status.ProviderID == "" {
+			continue
+		}
+		price := c.price(&nodeClaims[i])
+		total.add(price)
+		for key, value := range map[string]string{
+			InstanceTypesKey: nodeClaims[i].Labels[corev1.LabelInstanceTypeStable],
+			CapacityTypesKey: nodeClaims[i].Labels[karpv1.CapacityTypeLabelKey],
+			ZonesKey:         nodeClaims[i].Labels[corev1.LabelTopologyZone],
+			ImagesKey:        nodeClaims[i].Status.ImageID,
+		} {
+			if value == "" {
+				continue
+			}
+			if _, ok := dimensions[key][value]; !ok {
+				dimensions[key][value] = &Entry{}
+			}
+			dimensions[key][value].add(price)
+		}
+	}
+	data := map[string]string{}
+	for key, value := range map[string]any{
+		TotalKey:         total,
+		InstanceTypesKey: dimensions[InstanceTypesKey],
+		CapacityTypesKey: dimensions[CapacityTypesKey],
+		ZonesKey:         dimensions[ZonesKey],
+		ImagesKey:        dimensions[ImagesKey],
+	} {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s, %w", key, err)
+		}
+		data[key] = string(raw)
+	}
+	return data, nil
+}
+
+// price returns the hourly price of the NodeClaim's instance, or zero if the price isn't known
+func (c *Controller) price(nodeClaim *karpv1.NodeClaim) float64 {
+	instanceType := ec2types.InstanceType(nodeClaim.Labels[corev1.LabelInstanceTypeStable])
+	var price float64
+	switch nodeClaim.Labels[karpv1.CapacityTypeLabelKey] {
+	case karpv1.CapacityTypeSpot:
+		price, _ = c.pricingProvider.SpotPrice(instanceType, nodeClaim.Labels[corev1.LabelTopologyZone])
+	case karpv1.CapacityTypeOnDemand:
+		price, _ = c.pricingProvider.OnDemandPrice(instanceType)
+	}
+	return price
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.composition").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// ConfigMapName returns the name of the ConfigMap that the fleet composition of the NodePool is published to
+func ConfigMapName(nodePool *karpv1.NodePool) string {
+	return fmt.Sprintf("nodepool-%s-fleet-composition", nodePool.Name)
+}
@@ -0,0 +1,193 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/composition"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const namespace = "default"
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *composition.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Composition")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = composition.NewController(env.Client, cloudProvider, awsEnv.PricingProvider, namespace)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PublishFleetComposition: lo.ToPtr(true)}}))
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Composition", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+	})
+	nodeClaim := func(instanceType, capacityType, zone, imageID string) *karpv1.NodeClaim {
+		return coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool.Name,
+					corev1.LabelInstanceTypeStable: instanceType,
+					karpv1.CapacityTypeLabelKey:    capacityType,
+					corev1.LabelTopologyZone:       zone,
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(fake.InstanceID()),
+				ImageID:    imageID,
+			},
+		})
+	}
+	configMap := func() *corev1.ConfigMap {
+		return ExpectExists(ctx, env.Client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: composition.ConfigMapName(nodePool), Namespace: namespace}})
+	}
+	entries := func(cm *corev1.ConfigMap, key string) map[string]composition.Entry {
+		out := map[string]composition.Entry{}
+		Expect(json.Unmarshal([]byte(cm.Data[key]), &out)).To(Succeed())
+		return out
+	}
+
+	It("should publish the fleet composition of the nodepool", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool,
+			nodeClaim("m5.large", karpv1.CapacityTypeOnDemand, "test-zone-1a", "ami-1"),
+			nodeClaim("m5.large", karpv1.CapacityTypeSpot, "test-zone-1b", "ami-1"),
+			nodeClaim("c6g.large", karpv1.CapacityTypeOnDemand, "test-zone-1a", "ami-2"),
+		)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		cm := configMap()
+		Expect(cm.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+		Expect(cm.OwnerReferences).To(HaveLen(1))
+		Expect(cm.OwnerReferences[0].UID).To(Equal(nodePool.UID))
+
+		total := composition.Entry{}
+		Expect(json.Unmarshal([]byte(cm.Data[composition.TotalKey]), &total)).To(Succeed())
+		Expect(total.Count).To(Equal(3))
+
+		instanceTypes := entries(cm, composition.InstanceTypesKey)
+		Expect(lo.Keys(instanceTypes)).To(ConsistOf("m5.large", "c6g.large"))
+		Expect(instanceTypes["m5.large"].Count).To(Equal(2))
+		Expect(instanceTypes["c6g.large"].Count).To(Equal(1))
+		Expect(entries(cm, composition.CapacityTypesKey)[karpv1.CapacityTypeOnDemand].Count).To(Equal(2))
+		Expect(entries(cm, composition.CapacityTypesKey)[karpv1.CapacityTypeSpot].Count).To(Equal(1))
+		Expect(entries(cm, composition.ZonesKey)["test-zone-1a"].Count).To(Equal(2))
+		Expect(entries(cm, composition.ZonesKey)["test-zone-1b"].Count).To(Equal(1))
+		Expect(entries(cm, composition.ImagesKey)["ami-1"].Count).To(Equal(2))
+		Expect(entries(cm, composition.ImagesKey)["ami-2"].Count).To(Equal(1))
+	})
+	It("should price nodes using the on-demand and spot prices of their offering", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool,
+			nodeClaim("m5.large", karpv1.CapacityTypeOnDemand, "test-zone-1a", "ami-1"),
+			nodeClaim("m5.large", karpv1.CapacityTypeSpot, "test-zone-1b", "ami-1"),
+		)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		onDemandPrice, ok := awsEnv.PricingProvider.OnDemandPrice(ec2types.InstanceTypeM5Large)
+		Expect(ok).To(BeTrue())
+		spotPrice, ok := awsEnv.PricingProvider.SpotPrice(ec2types.InstanceTypeM5Large, "test-zone-1b")
+		Expect(ok).To(BeTrue())
+
+		cm := configMap()
+		Expect(entries(cm, composition.CapacityTypesKey)[karpv1.CapacityTypeOnDemand].HourlyPrice).To(BeNumerically("~", onDemandPrice))
+		Expect(entries(cm, composition.CapacityTypesKey)[karpv1.CapacityTypeSpot].HourlyPrice).To(BeNumerically("~", spotPrice))
+		Expect(entries(cm, composition.InstanceTypesKey)["m5.large"].HourlyPrice).To(BeNumerically("~", onDemandPrice+spotPrice))
+	})
+	It("should not include nodeclaims which haven't launched or belong to other nodepools", func() {
+		unlaunched := nodeClaim("m5.large", karpv1.CapacityTypeOnDemand, "test-zone-1a", "")
+		unlaunched.Status.ProviderID = ""
+		other := nodeClaim("m5.large", karpv1.CapacityTypeOnDemand, "test-zone-1a", "ami-1")
+		other.Labels[karpv1.NodePoolLabelKey] = "other"
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, unlaunched, other)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		cm := configMap()
+		Expect(cm.Data[composition.TotalKey]).To(MatchJSON(`{"count": 0, "hourlyPrice": 0}`))
+		Expect(cm.Data[composition.InstanceTypesKey]).To(MatchJSON(`{}`))
+	})
+	It("should not publish the fleet composition when disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PublishFleetComposition: lo.ToPtr(false)}}))
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectNotFound(ctx, env.Client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: composition.ConfigMapName(nodePool), Namespace: namespace}})
+	})
+})
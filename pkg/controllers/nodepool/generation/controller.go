@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+)
+
+const (
+	// ConditionTypeCurrentGeneration is the NodePool status condition which is false when the NodePool can launch
+	// instance types of previous generation families, or of families whose retirement has been announced
+	ConditionTypeCurrentGeneration = "CurrentGeneration"
+
+	// refreshInterval is the interval at which the instance types that a NodePool can launch are re-evaluated, since
+	// the instance type catalog changes independently of the NodePool
+	refreshInterval = time.Hour
+)
+
+// Controller warns when a NodePool resolves to instance types of aging families, so that fleets are migrated off of
+// them before they're retired. The families are listed in the generated PreviousGenerationFamilies table. The result is
+// published as the CurrentGeneration status condition of the NodePool, and a warning event is published whenever the
+// set of aging families that the NodePool can launch changes.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.generation")
+
+	if !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting instance types, %w", err))
+	}
+	families := PreviousGenerationFamilies(nodePool, instanceTypes)
+	stored := nodePool.DeepCopy()
+	if len(families) == 0 {
+		nodePool.StatusConditions().SetTrue(ConditionTypeCurrentGeneration)
+	} else {
+		nodePool.StatusConditions().SetFalse(ConditionTypeCurrentGeneration, "PreviousGenerationFamilies", message(families))
+	}
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodepool status, %w", err))
+		}
+		if len(families) != 0 {
+			c.recorder.Publish(PreviousGenerationFamiliesEvent(nodePool, families))
+		}
+	}
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.generation").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// PreviousGenerationFamilies returns the aging families of the instance types that the NodePool can launch, mapped to
+// the reason that they're aging
+func PreviousGenerationFamilies(nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType) map[string]string {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	families := map[string]string{}
+	for _, it := range instanceTypes {
+		if reqs.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+			continue
+		}
+		if family, reason, ok := instancetype.PreviousGeneration(it); ok {
+			families[family] = reason
+		}
+	}
+	return families
+}
+
+func message(families map[string]string) string {
+	return fmt.Sprintf("NodePool can launch instance types of aging families, %s", strings.Join(lo.Map(sets.List(sets.KeySet(families)), func(family string, _ int) string {
+		return fmt.Sprintf("%s (%s)", family, families[family])
+	}), ", "))
+}
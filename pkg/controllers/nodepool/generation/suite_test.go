@@ -0,0 +1,168 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/generation"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var cloudProvider *ec2CloudProvider
+var controller *generation.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Generation")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = &ec2CloudProvider{CloudProvider: fake.NewCloudProvider()}
+	controller = generation.NewController(env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+// ec2CloudProvider is a fake cloud provider which manages the NodePools that reference EC2NodeClasses
+type ec2CloudProvider struct {
+	*fake.CloudProvider
+}
+
+func (*ec2CloudProvider) GetSupportedNodeClasses() []status.Object {
+	return []status.Object{&v1.EC2NodeClass{}}
+}
+
+func instanceType(name, family string) *corecloudprovider.InstanceType {
+	return fake.NewInstanceTypeWithCustomRequirement(fake.InstanceTypeOptions{Name: name}, scheduling.NewRequirement(v1.LabelInstanceFamily, corev1.NodeSelectorOpIn, family))
+}
+
+var _ = Describe("Generation", func() {
+	var nodePool *karpv1.NodePool
+
+	BeforeEach(func() {
+		nodeClass := test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+	})
+
+	It("should set the condition to true when the NodePool only resolves to current generation families", func() {
+		cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{instanceType("m7i.large", "m7i")}
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(generation.ConditionTypeCurrentGeneration).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when the NodePool resolves to previous generation families", func() {
+		cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{
+			instanceType("m7i.large", "m7i"),
+			instanceType("m3.large", "m3"),
+			instanceType("c3.large", "c3"),
+		}
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := nodePool.StatusConditions().Get(generation.ConditionTypeCurrentGeneration)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("PreviousGenerationFamilies"))
+		Expect(condition.Message).To(ContainSubstring("c3 (previous generation), m3 (previous generation)"))
+	})
+	It("should ignore previous generation families which the NodePool's requirements exclude", func() {
+		cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{
+			instanceType("m7i.large", "m7i"),
+			instanceType("m3.large", "m3"),
+		}
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceFamily, Operator: corev1.NodeSelectorOpIn, Values: []string{"m7i"}},
+		}}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(generation.ConditionTypeCurrentGeneration).IsTrue()).To(BeTrue())
+	})
+	It("should ignore NodePools which don't reference an EC2NodeClass", func() {
+		nodePool.Spec.Template.Spec.NodeClassRef.Kind = "OtherNodeClass"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(generation.ConditionTypeCurrentGeneration)).To(BeNil())
+	})
+})
+
+var _ = Describe("ExcludePreviousGeneration", func() {
+	It("should only keep previous generation instance types which the NodePool explicitly selects", func() {
+		nodePool := coretest.NodePool()
+		instanceTypes := []*corecloudprovider.InstanceType{
+			instanceType("m7i.large", "m7i"),
+			instanceType("m3.large", "m3"),
+			instanceType("c3.large", "c3"),
+			instanceType("r3.large", "r3"),
+		}
+		Expect(lo.Map(instancetype.ExcludePreviousGeneration(nodePool, instanceTypes), func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("m7i.large"))
+
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceFamily, Operator: corev1.NodeSelectorOpIn, Values: []string{"m7i", "m3"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"c3.large"}}},
+		}
+		Expect(lo.Map(instancetype.ExcludePreviousGeneration(nodePool, instanceTypes), func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("m7i.large", "m3.large", "c3.large"))
+	})
+})
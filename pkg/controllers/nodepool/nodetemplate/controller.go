@@ -0,0 +1,163 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodetemplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// The prefixes of the node-template tags which the cluster-autoscaler uses to build template nodes when scaling
+	// node groups up from zero
+	labelPrefix    = "k8s.io/cluster-autoscaler/node-template/label/"
+	taintPrefix    = "k8s.io/cluster-autoscaler/node-template/taint/"
+	resourcePrefix = "k8s.io/cluster-autoscaler/node-template/resources/"
+
+	// refreshInterval is the interval at which the node templates are regenerated, since the instance type catalog
+	// changes as offerings become available or unavailable
+	refreshInterval = 5 * time.Minute
+)
+
+// Controller publishes the template node of each instance type that a NodePool can launch to a ConfigMap. Template
+// nodes are published using the node-template format that the cluster-autoscaler uses for scale-from-zero, which lets
+// tooling that understands that format (e.g. schedulers, capacity planners and dashboards) reason about nodes that
+// don't exist yet.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	namespace     string
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, namespace string) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		namespace:     namespace,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.nodetemplate")
+
+	if !options.FromContext(ctx).FeatureGates.PublishNodeTemplates || !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting instance types, %w", err))
+	}
+	data := map[string]string{}
+	for _, it := range instanceTypes {
+		template, ok := NodeTemplate(nodePool, it)
+		if !ok {
+			continue
+		}
+		raw, err := json.Marshal(template)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("marshaling node template, %w", err)
+		}
+		data[it.Name] = string(raw)
+	}
+	// The ConfigMap is owned by the NodePool so that it's garbage collected when the NodePool is deleted
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(nodePool),
+			Namespace: c.namespace,
+			Labels:    map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: object.GVK(nodePool).GroupVersion().String(),
+				Kind:       object.GVK(nodePool).Kind,
+				Name:       nodePool.Name,
+				UID:        nodePool.UID,
+			}},
+		},
+		Data: data,
+	}
+	if err := c.kubeClient.Patch(ctx, configMap, client.Apply, client.FieldOwner("karpenter"), client.ForceOwnership); err != nil {
+		return reconcile.Result{}, fmt.Errorf("applying node template configmap, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.nodetemplate").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// ConfigMapName returns the name of the ConfigMap that the node templates of the NodePool are published to
+func ConfigMapName(nodePool *karpv1.NodePool) string {
+	return fmt.Sprintf("nodepool-%s-node-templates", nodePool.Name)
+}
+
+// NodeTemplate returns the cluster-autoscaler node-template tags for a node of the instance type launched by the
+// NodePool. Only labels that have a single value for every node of the instance type are included. The second return
+// value is false if the NodePool can't launch the instance type.
+func NodeTemplate(nodePool *karpv1.NodePool, it *cloudprovider.InstanceType) (map[string]string, bool) {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	if reqs.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) != nil || len(it.Offerings.Compatible(reqs).Available()) == 0 {
+		return nil, false
+	}
+	for _, req := range it.Requirements {
+		reqs.Add(req)
+	}
+	template := map[string]string{}
+	for key, value := range nodePool.Spec.Template.Labels {
+		template[labelPrefix+key] = value
+	}
+	for key, req := range reqs {
+		if req.Len() == 1 {
+			template[labelPrefix+key] = req.Values()[0]
+		}
+	}
+	template[labelPrefix+karpv1.NodePoolLabelKey] = nodePool.Name
+	for _, taint := range nodePool.Spec.Template.Spec.Taints {
+		template[taintPrefix+taint.Key] = fmt.Sprintf("%s:%s", taint.Value, taint.Effect)
+	}
+	for name, quantity := range lo.PickBy(it.Allocatable(), func(_ corev1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }) {
+		template[resourcePrefix+string(name)] = quantity.String()
+	}
+	return template, true
+}
@@ -0,0 +1,181 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodetemplate_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/nodetemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const namespace = "default"
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *nodetemplate.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NodeTemplate")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = nodetemplate.NewController(env.Client, cloudProvider, namespace)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PublishNodeTemplates: lo.ToPtr(true)}}))
+	awsEnv.Reset()
+	ec2InstanceTypeInfo := fake.MakeInstances()
+	awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: ec2InstanceTypeInfo,
+	})
+	awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+		InstanceTypeOfferings: fake.MakeInstanceOfferings(ec2InstanceTypeInfo),
+	})
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("NodeTemplate", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					ObjectMeta: karpv1.ObjectMeta{
+						Labels: map[string]string{"team": "analytics"},
+					},
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+						Taints: []corev1.Taint{{Key: "example.com/dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}},
+						Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+							{
+								NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+									Key:      corev1.LabelInstanceTypeStable,
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   []string{"m5.large", "c6g.large"},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	})
+	configMap := func() *corev1.ConfigMap {
+		return ExpectExists(ctx, env.Client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nodetemplate.ConfigMapName(nodePool), Namespace: namespace}})
+	}
+	template := func(cm *corev1.ConfigMap, instanceType string) map[string]string {
+		raw, ok := cm.Data[instanceType]
+		Expect(ok).To(BeTrue())
+		out := map[string]string{}
+		Expect(json.Unmarshal([]byte(raw), &out)).To(Succeed())
+		return out
+	}
+
+	It("should publish a node template for each instance type the nodepool can launch", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		cm := configMap()
+		Expect(lo.Keys(cm.Data)).To(ConsistOf("m5.large", "c6g.large"))
+		Expect(cm.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+		Expect(cm.OwnerReferences).To(HaveLen(1))
+		Expect(cm.OwnerReferences[0].UID).To(Equal(nodePool.UID))
+	})
+	It("should publish labels, taints and resources in the cluster-autoscaler node-template format", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		t := template(configMap(), "c6g.large")
+		Expect(t).To(HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/label/"+karpv1.NodePoolLabelKey, nodePool.Name))
+		Expect(t).To(HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/label/team", "analytics"))
+		Expect(t).To(HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/label/"+corev1.LabelInstanceTypeStable, "c6g.large"))
+		Expect(t).To(HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/label/"+corev1.LabelArchStable, karpv1.ArchitectureArm64))
+		Expect(t).To(HaveKeyWithValue("k8s.io/cluster-autoscaler/node-template/taint/example.com/dedicated", "batch:NoSchedule"))
+		Expect(t).To(HaveKey("k8s.io/cluster-autoscaler/node-template/resources/cpu"))
+		Expect(t).To(HaveKey("k8s.io/cluster-autoscaler/node-template/resources/memory"))
+		Expect(t).To(HaveKey("k8s.io/cluster-autoscaler/node-template/resources/pods"))
+	})
+	It("should only publish labels which have a single value", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		t := template(configMap(), "m5.large")
+		Expect(t).ToNot(HaveKey("k8s.io/cluster-autoscaler/node-template/label/" + corev1.LabelTopologyZone))
+		Expect(t).ToNot(HaveKey("k8s.io/cluster-autoscaler/node-template/label/" + karpv1.CapacityTypeLabelKey))
+	})
+	It("should not publish node templates when disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PublishNodeTemplates: lo.ToPtr(false)}}))
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectNotFound(ctx, env.Client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nodetemplate.ConfigMapName(nodePool), Namespace: namespace}})
+	})
+	It("should not publish node templates when the nodeclass doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectNotFound(ctx, env.Client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nodetemplate.ConfigMapName(nodePool), Namespace: namespace}})
+	})
+})
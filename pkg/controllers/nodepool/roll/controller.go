@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+const (
+	// ConditionTypeRolled is the NodePool status condition which surfaces the progress of a requested roll
+	ConditionTypeRolled = "Rolled"
+
+	// syncInterval is the interval at which the progress of a roll is refreshed while nodes remain to be replaced
+	syncInterval = 30 * time.Second
+)
+
+// Controller surfaces the progress of a roll of a NodePool, which is requested by setting the
+// karpenter.k8s.aws/roll-requested-at annotation on the NodePool to an RFC3339 timestamp. The cloud provider reports
+// every NodeClaim of the NodePool which was created before that time as drifted, so the nodes are replaced by drift
+// at the rate allowed by the NodePool's disruption budgets. The progress is reported through the Rolled status
+// condition of the NodePool, which is true once every node launched before the request has been replaced.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *karpv1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.roll")
+
+	if !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	stored := nodePool.DeepCopy()
+	requestedAt, requested, err := utils.RollRequestedAt(nodePool)
+	var pending int
+	switch {
+	case !requested:
+		_ = nodePool.StatusConditions().Clear(ConditionTypeRolled)
+	case err != nil:
+		nodePool.StatusConditions().SetFalse(ConditionTypeRolled, "InvalidRollRequest", fmt.Sprintf("%s must be an RFC3339 timestamp", v1.AnnotationRollRequestedAt))
+	default:
+		nodeClaims := &karpv1.NodeClaimList{}
+		if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePool.Name}); err != nil {
+			return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+		}
+		pending = lo.CountBy(nodeClaims.Items, func(nc karpv1.NodeClaim) bool { return nc.CreationTimestamp.Time.Before(requestedAt) })
+		if pending == 0 {
+			nodePool.StatusConditions().SetTrue(ConditionTypeRolled)
+		} else {
+			nodePool.StatusConditions().SetUnknownWithReason(ConditionTypeRolled, "RollInProgress",
+				fmt.Sprintf("%d of %d nodes were launched before the roll was requested and are pending replacement", pending, len(nodeClaims.Items)))
+		}
+	}
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodepool status, %w", err))
+		}
+		if requested && err == nil && pending == 0 && !stored.StatusConditions().IsTrue(ConditionTypeRolled) {
+			log.FromContext(ctx).WithValues("requested-at", requestedAt).Info("completed roll")
+			c.recorder.Publish(RollCompletedEvent(nodePool))
+		}
+	}
+	if requested && err == nil && pending > 0 {
+		return reconcile.Result{RequeueAfter: syncInterval}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.roll").
+		For(&karpv1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(
+			&karpv1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				name, ok := o.GetLabels()[karpv1.NodePoolLabelKey]
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+			}),
+			// The progress of a roll only changes when NodeClaims are created or removed
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool { return false },
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
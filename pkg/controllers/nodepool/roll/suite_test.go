@@ -0,0 +1,170 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roll_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/roll"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var controller *roll.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Roll")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv := test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = roll.NewController(env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Roll", func() {
+	var nodePool *karpv1.NodePool
+	var nodeClaims []*karpv1.NodeClaim
+
+	BeforeEach(func() {
+		nodeClass := test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		nodeClaims = nil
+		for i := 0; i < 3; i++ {
+			nodeClaims = append(nodeClaims, coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   fmt.Sprintf("nodeclaim-%d", i),
+					Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name},
+				},
+			}))
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		for i := range nodeClaims {
+			ExpectApplied(ctx, env.Client, nodeClaims[i])
+		}
+	})
+	requestRoll := func(requestedAt string) {
+		nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.AnnotationRollRequestedAt: requestedAt})
+		ExpectApplied(ctx, env.Client, nodePool)
+	}
+
+	It("should not set the condition when a roll isn't requested", func() {
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled)).To(BeNil())
+	})
+	It("should report the nodes which are pending replacement", func() {
+		requestRoll(time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := nodePool.StatusConditions().Get(roll.ConditionTypeRolled)
+		Expect(condition.IsUnknown()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("RollInProgress"))
+		Expect(condition.Message).To(ContainSubstring("3 of 3 nodes"))
+
+		ExpectDeleted(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled).Message).To(ContainSubstring("1 of 1 nodes"))
+	})
+	It("should complete the roll once every node launched before the request is replaced", func() {
+		requestRoll(time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectDeleted(ctx, env.Client, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled).IsTrue()).To(BeTrue())
+	})
+	It("should not count nodes launched after the roll was requested", func() {
+		requestRoll(time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled).IsTrue()).To(BeTrue())
+	})
+	It("should reject a roll request which isn't a timestamp", func() {
+		requestRoll("now")
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := nodePool.StatusConditions().Get(roll.ConditionTypeRolled)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("InvalidRollRequest"))
+	})
+	It("should clear the condition when the roll request is removed", func() {
+		requestRoll(time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		delete(nodePool.Annotations, v1.AnnotationRollRequestedAt)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled)).To(BeNil())
+	})
+	It("should ignore NodePools which don't reference an EC2NodeClass", func() {
+		nodePool.Spec.Template.Spec.NodeClassRef.Kind = "OtherNodeClass"
+		requestRoll(time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(roll.ConditionTypeRolled)).To(BeNil())
+	})
+})
@@ -0,0 +1,285 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+)
+
+const (
+	// initializationTimeout is how long the node of a standby instance may take to become ready before the instance is
+	// terminated and replaced, so that a standby instance which fails to initialize is neither billed indefinitely nor
+	// resumed for a NodeClaim
+	initializationTimeout = 10 * time.Minute
+	requeueInterval       = 30 * time.Second
+)
+
+// Controller maintains the warm pools of NodePools which are annotated with karpenter.k8s.aws/warm-pool-size. A warm
+// pool is a set of stopped standby instances, which are launched from the NodePool's template and stopped once their
+// nodes initialize, so that NodeClaims of the NodePool can resume them instead of waiting for new instances to boot.
+type Controller struct {
+	kubeClient       client.Client
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider instance.Provider
+	clk              clock.Clock
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+		clk:              clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.warmpool")
+
+	standby, err := c.instanceProvider.ListStandby(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing standby instances, %w", err)
+	}
+	nodePoolList := &karpv1.NodePoolList{}
+	if err = c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodeList := &corev1.NodeList{}
+	if err = c.kubeClient.List(ctx, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	// Standby instances which are already terminating don't count toward the size of their warm pool
+	standby = lo.Reject(standby, func(i *instance.Instance, _ int) bool { return i.State == ec2types.InstanceStateNameShuttingDown })
+	pools := lo.GroupBy(standby, func(i *instance.Instance) string { return i.Tags[v1.WarmPoolTagKey] })
+	var errs []error
+	for _, nodePool := range nodePoolList.Items {
+		size := 0
+		if nodePool.DeletionTimestamp.IsZero() && nodepoolutils.IsManaged(&nodePool, c.cloudProvider) {
+			if size, err = instance.WarmPoolSize(&nodePool); err != nil {
+				log.FromContext(ctx).WithValues("NodePool", nodePool.Name).Error(err, "failed resolving warm pool size")
+				continue
+			}
+		}
+		errs = append(errs, c.reconcile(ctx, &nodePool, pools[nodePool.Name], size, nodeList)...)
+		delete(pools, nodePool.Name)
+	}
+	// The standby instances of deleted NodePools are terminated
+	for _, instances := range pools {
+		errs = append(errs, c.terminate(ctx, instances, nodeList)...)
+	}
+	if err = multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueInterval}, nil
+}
+
+// reconcile terminates the standby instances which failed to initialize, were launched from a stale EC2NodeClass or
+// NodePool, or are in excess of the NodePool's warm pool size, stops the standby instances which have initialized and
+// launches standby instances until the warm pool has the requested size
+func (c *Controller) reconcile(ctx context.Context, nodePool *karpv1.NodePool, standby []*instance.Instance, size int, nodeList *corev1.NodeList) []error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", nodePool.Name))
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return []error{fmt.Errorf("getting ec2nodeclass, %w", err)}
+		}
+		nodeClass = nil
+	}
+	// Standby instances which failed to initialize are replaced rather than stopped, since their nodes may never become
+	// ready when they're resumed. Standby instances which were launched from a previous version of the EC2NodeClass or
+	// the NodePool's template are replaced as well, since they can't be resumed without being drifted.
+	failed, standby := lo.FilterReject(standby, func(i *instance.Instance, _ int) bool {
+		return c.failedToInitialize(i, nodeList) || stale(i, nodePool, nodeClass)
+	})
+	errs := c.terminate(ctx, failed, nodeList)
+	// Stopped instances are retained over running instances, since they're ready to be resumed
+	sort.SliceStable(standby, func(i, j int) bool {
+		return standby[i].State == ec2types.InstanceStateNameStopped && standby[j].State != ec2types.InstanceStateNameStopped
+	})
+	if len(standby) > size {
+		errs = append(errs, c.terminate(ctx, standby[size:], nodeList)...)
+		standby = standby[:size]
+	}
+	for _, i := range standby {
+		if err := c.stop(ctx, i, nodeList); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if missing := size - len(standby); missing > 0 {
+		if err := c.launch(ctx, nodePool, nodeClass, missing); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// stop stops a running standby instance once its node is ready. The node is deleted, since the instance registers
+// again when it's resumed.
+func (c *Controller) stop(ctx context.Context, i *instance.Instance, nodeList *corev1.NodeList) error {
+	if i.State != ec2types.InstanceStateNameRunning {
+		return nil
+	}
+	node, ready := readyNode(i, nodeList)
+	if !ready {
+		return nil
+	}
+	if err := c.instanceProvider.Stop(ctx, i.ID); err != nil {
+		return err
+	}
+	log.FromContext(ctx).WithValues("id", i.ID, "instance-type", i.Type).Info("stopped standby instance")
+	return client.IgnoreNotFound(c.kubeClient.Delete(ctx, &node))
+}
+
+// failedToInitialize returns true if a running standby instance's node didn't become ready within the initialization
+// timeout
+func (c *Controller) failedToInitialize(i *instance.Instance, nodeList *corev1.NodeList) bool {
+	if i.State != ec2types.InstanceStateNameRunning {
+		return false
+	}
+	_, ready := readyNode(i, nodeList)
+	return !ready && c.clk.Since(i.LaunchTime) >= initializationTimeout
+}
+
+// stale returns true if a standby instance was launched from a different version of the EC2NodeClass or the NodePool's
+// template than the current one
+func stale(i *instance.Instance, nodePool *karpv1.NodePool, nodeClass *v1.EC2NodeClass) bool {
+	if nodeClass == nil {
+		return false
+	}
+	return i.Tags[v1.WarmPoolNodeClassHashTagKey] != nodeClass.Hash() || i.Tags[v1.WarmPoolNodePoolHashTagKey] != nodePool.Hash()
+}
+
+// readyNode returns the node of a standby instance, and whether the node is ready
+func readyNode(i *instance.Instance, nodeList *corev1.NodeList) (corev1.Node, bool) {
+	node, found := lo.Find(nodeList.Items, func(n corev1.Node) bool { return n.Spec.ProviderID == providerID(i) })
+	return node, found && nodeutils.GetCondition(&node, corev1.NodeReady).Status == corev1.ConditionTrue
+}
+
+func (c *Controller) terminate(ctx context.Context, instances []*instance.Instance, nodeList *corev1.NodeList) []error {
+	var errs []error
+	for _, i := range instances {
+		if err := c.instanceProvider.Delete(ctx, i.ID); cloudprovider.IgnoreNodeClaimNotFoundError(err) != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.FromContext(ctx).WithValues("id", i.ID, "instance-type", i.Type).Info("terminated standby instance")
+		if node, found := lo.Find(nodeList.Items, func(n corev1.Node) bool { return n.Spec.ProviderID == providerID(i) }); found {
+			if err := c.kubeClient.Delete(ctx, &node); client.IgnoreNotFound(err) != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// launch launches standby instances from the NodePool's template. Standby instances are always on-demand, since spot
+// instances can't be stopped.
+func (c *Controller) launch(ctx context.Context, nodePool *karpv1.NodePool, nodeClass *v1.EC2NodeClass, count int) error {
+	if nodeClass == nil || !nodeClass.DeletionTimestamp.IsZero() || !nodeClass.StatusConditions().Root().IsTrue() {
+		return nil
+	}
+	nodeClaim := NodeClaim(nodePool)
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return fmt.Errorf("resolving instance types, %w", err)
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil && len(it.Offerings.Compatible(requirements).Available()) > 0
+	})
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+	tags := lo.Assign(nodeClass.Spec.Tags, map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		karpv1.NodePoolLabelKey: nodePool.Name,
+		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
+		v1.LabelNodeClass:       nodeClass.Name,
+		v1.WarmPoolTagKey:       nodePool.Name,
+		// The hashes are compared when standby instances are resumed, so that NodeClaims aren't drifted as they launch
+		v1.WarmPoolNodeClassHashTagKey: nodeClass.Hash(),
+		v1.WarmPoolNodePoolHashTagKey:  nodePool.Hash(),
+	})
+	var errs []error
+	for range count {
+		i, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim.DeepCopy(), tags, instanceTypes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("launching standby instance, %w", err))
+			continue
+		}
+		log.FromContext(ctx).WithValues("id", i.ID, "instance-type", i.Type, "zone", i.Zone).Info("launched standby instance")
+	}
+	return multierr.Combine(errs...)
+}
+
+// NodeClaim returns the NodeClaim that the standby instances of the NodePool's warm pool are launched for. The
+// NodeClaim is never created, it only carries the NodePool's template to the instance provider. Standby instances
+// register with the standby taint, which is removed once they're resumed for a NodeClaim.
+func NodeClaim(nodePool *karpv1.NodePool) *karpv1.NodeClaim {
+	return &karpv1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      lo.Assign(nodePool.Spec.Template.Labels, map[string]string{karpv1.NodePoolLabelKey: nodePool.Name}),
+			Annotations: lo.Assign(nodePool.Spec.Template.Annotations),
+		},
+		Spec: karpv1.NodeClaimSpec{
+			Taints:        nodePool.Spec.Template.Spec.Taints,
+			StartupTaints: append(lo.Flatten([][]corev1.Taint{nodePool.Spec.Template.Spec.StartupTaints}), v1.StandbyTaint),
+			NodeClassRef:  nodePool.Spec.Template.Spec.NodeClassRef,
+			// NodePools which only launch spot instances can't have warm pools, since their requirements are incompatible
+			Requirements: append(lo.Flatten([][]karpv1.NodeSelectorRequirementWithMinValues{nodePool.Spec.Template.Spec.Requirements}), karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+				Key:      karpv1.CapacityTypeLabelKey,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{karpv1.CapacityTypeOnDemand},
+			}}),
+		},
+	}
+}
+
+func providerID(i *instance.Instance) string {
+	return fmt.Sprintf("aws:///%s/%s", i.Zone, i.ID)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.warmpool").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,302 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	opstatus "github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepool/warmpool"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var controller *warmpool.Controller
+var taintController *warmpool.TaintController
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WarmPool")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimProviderIDFieldIndexer(ctx)))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	controller = warmpool.NewController(env.Client, cloudProvider, awsEnv.InstanceProvider, fakeClock)
+	taintController = warmpool.NewTaintController(env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	fakeClock.SetTime(time.Now())
+	awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("10.0.100.10")
+	awsEnv.LaunchTemplateProvider.ClusterEndpoint = "https://test-cluster"
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("WarmPool", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+
+	standby := func(state ec2types.InstanceStateName, launchTime time.Time) string {
+		id := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(id, ec2types.Instance{
+			InstanceId:   aws.String(id),
+			InstanceType: "m5.large",
+			State:        &ec2types.InstanceState{Name: state},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+			LaunchTime:   aws.Time(launchTime),
+			Tags: []ec2types.Tag{
+				{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+				{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+				{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String(nodePool.Name)},
+				{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+				{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String(nodePool.Name)},
+				{Key: aws.String(v1.WarmPoolNodeClassHashTagKey), Value: aws.String(nodeClass.Hash())},
+				{Key: aws.String(v1.WarmPoolNodePoolHashTagKey), Value: aws.String(nodePool.Hash())},
+			},
+		})
+		return id
+	}
+	state := func(id string) ec2types.InstanceStateName {
+		raw, ok := awsEnv.EC2API.Instances.Load(id)
+		Expect(ok).To(BeTrue())
+		return raw.(ec2types.Instance).State.Name
+	}
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Status: v1.EC2NodeClassStatus{
+				InstanceProfile: "test-profile",
+				SecurityGroups:  []v1.SecurityGroup{{ID: "sg-test1", Name: "securityGroup-test1"}},
+				Subnets: []v1.Subnet{
+					{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+					{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b"},
+				},
+			},
+		})
+		nodeClass.StatusConditions().SetTrue(opstatus.ConditionReady)
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		nodePool.Annotations = map[string]string{v1.AnnotationWarmPoolSize: "2"}
+		_, err := awsEnv.SubnetProvider.List(ctx, nodeClass) // Hydrate the subnet cache
+		Expect(err).To(BeNil())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+	It("should launch on-demand standby instances until the warm pool has its size", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		standby(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(input.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(ec2types.DefaultTargetCapacityTypeOnDemand))
+		tags := lo.SliceToMap(input.TagSpecifications[0].Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+		Expect(tags).To(HaveKeyWithValue(v1.WarmPoolTagKey, nodePool.Name))
+		Expect(tags).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+		Expect(tags).To(HaveKeyWithValue(v1.WarmPoolNodeClassHashTagKey, nodeClass.Hash()))
+		Expect(tags).To(HaveKeyWithValue(v1.WarmPoolNodePoolHashTagKey, nodePool.Hash()))
+	})
+	It("should launch standby instances which register with the standby taint", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+			userData, err := base64.StdEncoding.DecodeString(aws.ToString(input.LaunchTemplateData.UserData))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(userData)).To(ContainSubstring(v1.StandbyTaint.Key))
+		})
+	})
+	It("should not let pods bind to the nodes of standby instances", func() {
+		nodeClaim := warmpool.NodeClaim(nodePool)
+		Expect(nodeClaim.Spec.StartupTaints).To(ContainElement(v1.StandbyTaint))
+		node := coretest.Node(coretest.NodeOptions{Taints: append(nodeClaim.Spec.Taints, nodeClaim.Spec.StartupTaints...)})
+		Expect(scheduling.Taints(node.Spec.Taints).Tolerates(coretest.Pod())).ToNot(Succeed())
+		// The NodePool's template isn't modified
+		Expect(nodePool.Spec.Template.Spec.StartupTaints).ToNot(ContainElement(v1.StandbyTaint))
+	})
+	It("should not launch standby instances for NodePools without a warm pool", func() {
+		nodePool.Annotations = nil
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+	})
+	It("should not launch standby instances for NodePools which only launch spot instances", func() {
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+	})
+	It("should stop a standby instance and delete its node once the node is ready", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		id := standby(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(id)})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(id)).To(Equal(ec2types.InstanceStateNameStopped))
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not stop a standby instance before its node is ready", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		id := standby(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(id), ReadyStatus: corev1.ConditionFalse})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(id)).To(Equal(ec2types.InstanceStateNameRunning))
+		ExpectExists(ctx, env.Client, node)
+	})
+	It("should terminate and replace a standby instance which fails to initialize", func() {
+		nodePool.Annotations[v1.AnnotationWarmPoolSize] = "1"
+		id := standby(ec2types.InstanceStateNameRunning, fakeClock.Now().Add(-15*time.Minute))
+		node := coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(id), ReadyStatus: corev1.ConditionFalse})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(id)).To(Equal(ec2types.InstanceStateNameTerminated))
+		ExpectNotFound(ctx, env.Client, node)
+		Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+	})
+	It("should terminate standby instances in excess of the warm pool size, retaining stopped instances", func() {
+		nodePool.Annotations[v1.AnnotationWarmPoolSize] = "1"
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		running := standby(ec2types.InstanceStateNamePending, fakeClock.Now())
+		stopped := standby(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(running)).To(Equal(ec2types.InstanceStateNameTerminated))
+		Expect(state(stopped)).To(Equal(ec2types.InstanceStateNameStopped))
+	})
+	DescribeTable("should terminate and replace standby instances which were launched from a stale template",
+		func(tagKey string) {
+			nodePool.Annotations[v1.AnnotationWarmPoolSize] = "1"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			id := standby(ec2types.InstanceStateNameStopped, fakeClock.Now())
+			Expect(awsEnv.EC2API.CreateTags(ctx, &ec2.CreateTagsInput{
+				Resources: []string{id},
+				Tags:      []ec2types.Tag{{Key: aws.String(tagKey), Value: aws.String("stale")}},
+			})).Error().ToNot(HaveOccurred())
+			ExpectSingletonReconciled(ctx, controller)
+
+			Expect(state(id)).To(Equal(ec2types.InstanceStateNameTerminated))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		},
+		Entry("EC2NodeClass", v1.WarmPoolNodeClassHashTagKey),
+		Entry("NodePool", v1.WarmPoolNodePoolHashTagKey),
+	)
+	It("should terminate the standby instances of NodePools which no longer have a warm pool", func() {
+		nodePool.Annotations = nil
+		id := standby(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(id)).To(Equal(ec2types.InstanceStateNameTerminated))
+	})
+	It("should terminate the standby instances of deleted NodePools", func() {
+		id := standby(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(state(id)).To(Equal(ec2types.InstanceStateNameTerminated))
+	})
+})
+
+var _ = Describe("StandbyTaint", func() {
+	It("should remove the standby taint from the node of a resumed standby instance", func() {
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{Status: karpv1.NodeClaimStatus{ProviderID: fake.ProviderID(fake.InstanceID())}})
+		node := coretest.Node(coretest.NodeOptions{
+			ProviderID: nodeClaim.Status.ProviderID,
+			Taints:     []corev1.Taint{v1.StandbyTaint, {Key: "test", Effect: corev1.TaintEffectNoSchedule}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, taintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(corev1.Taint{Key: "test", Effect: corev1.TaintEffectNoSchedule}))
+	})
+	It("should keep the standby taint on the node of a standby instance", func() {
+		node := coretest.Node(coretest.NodeOptions{
+			ProviderID: fake.ProviderID(fake.InstanceID()),
+			Taints:     []corev1.Taint{v1.StandbyTaint},
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, taintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.StandbyTaint))
+	})
+})
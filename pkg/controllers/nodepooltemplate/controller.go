@@ -0,0 +1,312 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepooltemplate
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// syncInterval is the interval at which the batches of a NodePoolTemplate are evaluated, so that idle batches are
+// deleted once their TTL expires
+const syncInterval = 30 * time.Second
+
+// Controller stamps out a NodePool and EC2NodeClass for each batch of pods which selects a NodePoolTemplate. Pods
+// select a NodePoolTemplate with the karpenter.k8s.aws/nodepool-template label, and their batch with a
+// karpenter.k8s.aws/batch node selector. Each batch gets a copy of the EC2NodeClass referenced by the NodePoolTemplate
+// with the NodePoolTemplate's overrides applied, so that the resources of the batch can be tagged independently.
+// A batch is idle once it has no pods which haven't completed and no NodeClaims, and its NodePool and EC2NodeClass are
+// deleted once it's been idle for the NodePoolTemplate's TTL. Since any pod can select a batch, a NodePool or
+// EC2NodeClass is only ever updated or deleted when it's controlled by the NodePoolTemplate.
+type Controller struct {
+	kubeClient client.Client
+	recorder   events.Recorder
+	clk        clock.Clock
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		clk:        clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepooltemplate")
+
+	if !nodePoolTemplate.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	active, err := c.activeBatches(ctx, nodePoolTemplate)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList, client.MatchingLabels{v1.LabelNodePoolTemplate: nodePoolTemplate.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	// The labels of a NodePool can be set by anyone who can write it, so only the NodePools that the NodePoolTemplate
+	// controls are considered batches of the NodePoolTemplate
+	nodePools := lo.SliceToMap(lo.Filter(nodePoolList.Items, func(np karpv1.NodePool, _ int) bool {
+		return controlledBy(&np, nodePoolTemplate)
+	}), func(np karpv1.NodePool) (string, *karpv1.NodePool) {
+		return np.Labels[v1.LabelBatch], lo.ToPtr(np)
+	})
+	batches := sets.List(active.Union(sets.KeySet(nodePools)))
+	var errs error
+	var names []string
+	for _, batch := range batches {
+		exists, err := c.reconcileBatch(ctx, nodePoolTemplate, batch, active.Has(batch), nodePools[batch])
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("reconciling batch %q, %w", batch, err))
+		}
+		if exists {
+			names = append(names, Name(nodePoolTemplate, batch))
+		}
+	}
+	sort.Strings(names)
+	stored := nodePoolTemplate.DeepCopy()
+	nodePoolTemplate.Status.NodePools = names
+	if !equality.Semantic.DeepEqual(stored, nodePoolTemplate) {
+		if err := c.kubeClient.Status().Patch(ctx, nodePoolTemplate, client.MergeFrom(stored)); err != nil {
+			errs = multierr.Append(errs, client.IgnoreNotFound(fmt.Errorf("patching nodepooltemplate status, %w", err)))
+		}
+	}
+	return reconcile.Result{RequeueAfter: syncInterval}, errs
+}
+
+// activeBatches returns the batches of the NodePoolTemplate which have pods that haven't completed
+func (c *Controller) activeBatches(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate) (sets.Set[string], error) {
+	pods := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, pods, client.MatchingLabels{v1.LabelNodePoolTemplate: nodePoolTemplate.Name}); err != nil {
+		return nil, fmt.Errorf("listing pods, %w", err)
+	}
+	batches := sets.New[string]()
+	for i := range pods.Items {
+		if batch, ok := pods.Items[i].Spec.NodeSelector[v1.LabelBatch]; ok && !podutils.IsTerminal(&pods.Items[i]) {
+			batches.Insert(batch)
+		}
+	}
+	return batches, nil
+}
+
+// reconcileBatch creates or updates the NodePool and EC2NodeClass of the batch, or deletes them once the batch has been
+// idle for the NodePoolTemplate's TTL. It returns whether the NodePool of the batch exists once reconciled.
+func (c *Controller) reconcileBatch(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate, batch string, active bool, nodePool *karpv1.NodePool) (bool, error) {
+	name := Name(nodePoolTemplate, batch)
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("batch", batch, "NodePool", klog.KRef("", name)))
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 || len(name) > validation.DNS1123LabelMaxLength {
+		c.recorder.Publish(InvalidBatchEvent(nodePoolTemplate, batch, name))
+		return false, nil
+	}
+	// The NodePool of the batch is recreated once the previous NodePool has finished deleting
+	if nodePool != nil && !nodePool.DeletionTimestamp.IsZero() {
+		return true, nil
+	}
+	var idleSince time.Time
+	if !active && nodePool != nil {
+		nodeClaims := &karpv1.NodeClaimList{}
+		if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: name}); err != nil {
+			return true, fmt.Errorf("listing nodeclaims, %w", err)
+		}
+		if len(nodeClaims.Items) == 0 {
+			idleSince = c.clk.Now()
+			if t, err := time.Parse(time.RFC3339, nodePool.Annotations[v1.AnnotationIdleSince]); err == nil {
+				idleSince = t
+			}
+			if c.clk.Since(idleSince) >= nodePoolTemplate.Spec.TTLAfterIdle.Duration {
+				return false, c.deleteBatch(ctx, nodePoolTemplate, batch, name)
+			}
+		}
+	}
+	// A NodePool or EC2NodeClass with the name of the batch may already exist with another owner, in which case it's
+	// left as it is
+	for _, obj := range []client.Object{&karpv1.NodePool{}, &v1.EC2NodeClass{}} {
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nodePool != nil, fmt.Errorf("getting %s, %w", object.GVK(obj).Kind, err)
+		}
+		if !controlledBy(obj, nodePoolTemplate) {
+			c.recorder.Publish(ConflictingBatchEvent(nodePoolTemplate, batch, object.GVK(obj).Kind, name))
+			return nodePool != nil, nil
+		}
+	}
+	if err := c.applyNodeClass(ctx, nodePoolTemplate, batch, name); err != nil {
+		return nodePool != nil, err
+	}
+	if err := c.applyNodePool(ctx, nodePoolTemplate, batch, name, idleSince); err != nil {
+		return nodePool != nil, err
+	}
+	if nodePool == nil {
+		log.FromContext(ctx).Info("created nodepool for batch")
+		c.recorder.Publish(BatchCreatedEvent(nodePoolTemplate, batch, name))
+	}
+	return true, nil
+}
+
+// applyNodeClass applies a copy of the EC2NodeClass referenced by the NodePoolTemplate for the batch, with the
+// NodePoolTemplate's overrides and the batch tag applied
+func (c *Controller) applyNodeClass(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate, batch, name string) error {
+	base := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolTemplate.Spec.NodePool.Template.Spec.NodeClassRef.Name}, base); err != nil {
+		return fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	nodeClass := &v1.EC2NodeClass{
+		TypeMeta:   metav1.TypeMeta{APIVersion: object.GVK(base).GroupVersion().String(), Kind: object.GVK(base).Kind},
+		ObjectMeta: c.objectMeta(nodePoolTemplate, batch, name),
+		Spec:       *base.Spec.DeepCopy(),
+	}
+	var overrides map[string]string
+	if nodePoolTemplate.Spec.NodeClass != nil {
+		overrides = nodePoolTemplate.Spec.NodeClass.Tags
+	}
+	nodeClass.Spec.Tags = lo.Assign(base.Spec.Tags, overrides, map[string]string{v1.BatchTagKey: batch})
+	if err := c.kubeClient.Patch(ctx, nodeClass, client.Apply, client.FieldOwner("karpenter")); err != nil {
+		return fmt.Errorf("applying ec2nodeclass, %w", err)
+	}
+	return nil
+}
+
+// applyNodePool applies the NodePool of the batch. Nodes launched by the NodePool are labeled with the batch, so that
+// only the pods of the batch select them.
+func (c *Controller) applyNodePool(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate, batch, name string, idleSince time.Time) error {
+	nodePool := &karpv1.NodePool{
+		TypeMeta:   metav1.TypeMeta{APIVersion: object.GVK(&karpv1.NodePool{}).GroupVersion().String(), Kind: object.GVK(&karpv1.NodePool{}).Kind},
+		ObjectMeta: c.objectMeta(nodePoolTemplate, batch, name),
+		Spec:       *nodePoolTemplate.Spec.NodePool.DeepCopy(),
+	}
+	if !idleSince.IsZero() {
+		nodePool.Annotations = map[string]string{v1.AnnotationIdleSince: idleSince.UTC().Format(time.RFC3339)}
+	}
+	nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{v1.LabelBatch: batch})
+	nodePool.Spec.Template.Spec.NodeClassRef.Name = name
+	if err := c.kubeClient.Patch(ctx, nodePool, client.Apply, client.FieldOwner("karpenter")); err != nil {
+		return fmt.Errorf("applying nodepool, %w", err)
+	}
+	return nil
+}
+
+// deleteBatch deletes the NodePool and EC2NodeClass of an idle batch. Each is only deleted if it's controlled by the
+// NodePoolTemplate, and the delete is preconditioned on its UID so that an object which is recreated in the meantime
+// isn't deleted.
+func (c *Controller) deleteBatch(ctx context.Context, nodePoolTemplate *v1.NodePoolTemplate, batch, name string) error {
+	for _, obj := range []client.Object{&karpv1.NodePool{}, &v1.EC2NodeClass{}} {
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("getting %s, %w", object.GVK(obj).Kind, err)
+		}
+		if !controlledBy(obj, nodePoolTemplate) {
+			continue
+		}
+		if err := c.kubeClient.Delete(ctx, obj, client.Preconditions{UID: lo.ToPtr(obj.GetUID())}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("deleting %s, %w", object.GVK(obj).Kind, err)
+		}
+	}
+	log.FromContext(ctx).WithValues("ttl-after-idle", nodePoolTemplate.Spec.TTLAfterIdle.Duration).Info("deleted nodepool for idle batch")
+	c.recorder.Publish(BatchDeletedEvent(nodePoolTemplate, batch, name))
+	return nil
+}
+
+// objectMeta returns the metadata of the NodePool and EC2NodeClass of the batch. They're owned by the NodePoolTemplate
+// so that they're garbage collected when the NodePoolTemplate is deleted.
+func (c *Controller) objectMeta(nodePoolTemplate *v1.NodePoolTemplate, batch, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name: name,
+		Labels: map[string]string{
+			v1.LabelNodePoolTemplate: nodePoolTemplate.Name,
+			v1.LabelBatch:            batch,
+		},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion:         object.GVK(nodePoolTemplate).GroupVersion().String(),
+			Kind:               object.GVK(nodePoolTemplate).Kind,
+			Name:               nodePoolTemplate.Name,
+			UID:                nodePoolTemplate.UID,
+			Controller:         lo.ToPtr(true),
+			BlockOwnerDeletion: lo.ToPtr(true),
+		}},
+	}
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepooltemplate").
+		For(&v1.NodePoolTemplate{}).
+		Owns(&karpv1.NodePool{}).
+		Watches(
+			&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				name, ok := o.GetLabels()[v1.LabelNodePoolTemplate]
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// Name returns the name of the NodePool and EC2NodeClass of a batch of the NodePoolTemplate. The name is suffixed with
+// a hash of the NodePoolTemplate and batch, since NodePoolTemplate and batch names may contain dashes and so a name
+// without the hash could be produced by more than one NodePoolTemplate and batch.
+func Name(nodePoolTemplate *v1.NodePoolTemplate, batch string) string {
+	// A slash can't appear in either a NodePoolTemplate name or a label value, so it separates them unambiguously
+	hash := sha256.Sum256([]byte(nodePoolTemplate.Name + "/" + batch))
+	return fmt.Sprintf("%s-%s-%x", nodePoolTemplate.Name, batch, hash[:4])
+}
+
+// controlledBy returns whether the object's controller is the NodePoolTemplate
+func controlledBy(obj client.Object, nodePoolTemplate *v1.NodePoolTemplate) bool {
+	owner := metav1.GetControllerOf(obj)
+	return owner != nil && owner.UID == nodePoolTemplate.UID
+}
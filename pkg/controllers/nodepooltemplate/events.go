@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepooltemplate
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func BatchCreatedEvent(nodePoolTemplate *v1.NodePoolTemplate, batch, nodePool string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePoolTemplate,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "BatchCreated",
+		Message:        fmt.Sprintf("Created NodePool %s for batch %s", nodePool, batch),
+		DedupeValues:   []string{string(nodePoolTemplate.UID), batch},
+	}
+}
+
+func BatchDeletedEvent(nodePoolTemplate *v1.NodePoolTemplate, batch, nodePool string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePoolTemplate,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "BatchDeleted",
+		Message:        fmt.Sprintf("Deleted NodePool %s after batch %s was idle for %s", nodePool, batch, nodePoolTemplate.Spec.TTLAfterIdle.Duration),
+		DedupeValues:   []string{string(nodePoolTemplate.UID), batch},
+	}
+}
+
+func InvalidBatchEvent(nodePoolTemplate *v1.NodePoolTemplate, batch, nodePool string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePoolTemplate,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidBatch",
+		Message:        fmt.Sprintf("Cannot create NodePool %s for batch %s, the name must be a DNS subdomain of at most 63 characters", nodePool, batch),
+		DedupeValues:   []string{string(nodePoolTemplate.UID), batch},
+	}
+}
+
+func ConflictingBatchEvent(nodePoolTemplate *v1.NodePoolTemplate, batch, kind, name string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePoolTemplate,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "ConflictingBatch",
+		Message:        fmt.Sprintf("Cannot create %s %s for batch %s, it already exists and isn't owned by this NodePoolTemplate", kind, name, batch),
+		DedupeValues:   []string{string(nodePoolTemplate.UID), batch},
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepooltemplate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodepooltemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var controller *nodepooltemplate.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NodePoolTemplate")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	fakeClock.SetTime(time.Now())
+	controller = nodepooltemplate.NewController(env.Client, events.NewRecorder(&record.FakeRecorder{}), fakeClock)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("NodePoolTemplate", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePoolTemplate *v1.NodePoolTemplate
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				Tags: map[string]string{"team": "ml", "cost-center": "shared"},
+			},
+		})
+		nodePoolTemplate = &v1.NodePoolTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "training"},
+			Spec: v1.NodePoolTemplateSpec{
+				NodePool: karpv1.NodePoolSpec{
+					Template: karpv1.NodeClaimTemplate{
+						Spec: karpv1.NodeClaimTemplateSpec{
+							NodeClassRef: &karpv1.NodeClassReference{
+								Group: object.GVK(nodeClass).Group,
+								Kind:  object.GVK(nodeClass).Kind,
+								Name:  nodeClass.Name,
+							},
+							Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+								{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+							},
+						},
+					},
+				},
+				NodeClass: &v1.NodeClassOverrides{
+					Tags: map[string]string{"cost-center": "training"},
+				},
+				TTLAfterIdle: metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePoolTemplate)
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, nodePoolTemplate)
+	})
+	pod := func(batch string, phase corev1.PodPhase) *corev1.Pod {
+		return coretest.Pod(coretest.PodOptions{
+			ObjectMeta:   metav1.ObjectMeta{Labels: map[string]string{v1.LabelNodePoolTemplate: nodePoolTemplate.Name}},
+			NodeSelector: map[string]string{v1.LabelBatch: batch},
+			Phase:        phase,
+		})
+	}
+	nodePoolFor := func(batch string) *karpv1.NodePool {
+		return &karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: nodepooltemplate.Name(nodePoolTemplate, batch)}}
+	}
+	nodeClassFor := func(batch string) *v1.EC2NodeClass {
+		return &v1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: nodepooltemplate.Name(nodePoolTemplate, batch)}}
+	}
+
+	It("should create a NodePool and EC2NodeClass for each batch", func() {
+		ExpectApplied(ctx, env.Client, pod("job-a", corev1.PodPending), pod("job-a", corev1.PodPending), pod("job-b", corev1.PodRunning))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		for _, batch := range []string{"job-a", "job-b"} {
+			nodePool := ExpectExists(ctx, env.Client, nodePoolFor(batch))
+			Expect(nodePool.Labels).To(HaveKeyWithValue(v1.LabelNodePoolTemplate, nodePoolTemplate.Name))
+			Expect(nodePool.Labels).To(HaveKeyWithValue(v1.LabelBatch, batch))
+			Expect(nodePool.Spec.Template.Labels).To(HaveKeyWithValue(v1.LabelBatch, batch))
+			Expect(nodePool.Spec.Template.Spec.NodeClassRef.Name).To(Equal(nodepooltemplate.Name(nodePoolTemplate, batch)))
+			Expect(nodePool.Spec.Template.Spec.Requirements).To(Equal(nodePoolTemplate.Spec.NodePool.Template.Spec.Requirements))
+			Expect(nodePool.OwnerReferences).To(ConsistOf(HaveField("UID", nodePoolTemplate.UID)))
+
+			ec2NodeClass := ExpectExists(ctx, env.Client, nodeClassFor(batch))
+			Expect(ec2NodeClass.Spec.Tags).To(Equal(map[string]string{
+				"team":         "ml",
+				"cost-center":  "training",
+				v1.BatchTagKey: batch,
+			}))
+			Expect(ec2NodeClass.Spec.SubnetSelectorTerms).To(Equal(nodeClass.Spec.SubnetSelectorTerms))
+			Expect(ec2NodeClass.OwnerReferences).To(ConsistOf(HaveField("UID", nodePoolTemplate.UID)))
+		}
+		nodePoolTemplate = ExpectExists(ctx, env.Client, nodePoolTemplate)
+		Expect(nodePoolTemplate.Status.NodePools).To(ConsistOf(nodepooltemplate.Name(nodePoolTemplate, "job-a"), nodepooltemplate.Name(nodePoolTemplate, "job-b")))
+	})
+	It("should not create a NodePool for pods which have completed or don't select a batch", func() {
+		ExpectApplied(ctx, env.Client, pod("job-a", corev1.PodSucceeded), pod("job-b", corev1.PodFailed))
+		ExpectApplied(ctx, env.Client, coretest.Pod(coretest.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelNodePoolTemplate: nodePoolTemplate.Name}},
+		}))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		nodePools := &karpv1.NodePoolList{}
+		Expect(env.Client.List(ctx, nodePools)).To(Succeed())
+		Expect(nodePools.Items).To(BeEmpty())
+	})
+	It("should not create a NodePool when its name would be too long", func() {
+		ExpectApplied(ctx, env.Client, pod(strings.Repeat("a", 60), corev1.PodPending))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		nodePools := &karpv1.NodePoolList{}
+		Expect(env.Client.List(ctx, nodePools)).To(Succeed())
+		Expect(nodePools.Items).To(BeEmpty())
+	})
+	It("should not produce the same name for different templates and batches", func() {
+		Expect(nodepooltemplate.Name(&v1.NodePoolTemplate{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, "b-c")).
+			ToNot(Equal(nodepooltemplate.Name(&v1.NodePoolTemplate{ObjectMeta: metav1.ObjectMeta{Name: "a-b"}}, "c")))
+	})
+	It("should not update a NodePool or EC2NodeClass with the name of a batch which it doesn't own", func() {
+		existingNodeClass := test.EC2NodeClass(v1.EC2NodeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: nodepooltemplate.Name(nodePoolTemplate, "job-a")},
+			Spec:       v1.EC2NodeClassSpec{Tags: map[string]string{"team": "payments"}},
+		})
+		ExpectApplied(ctx, env.Client, existingNodeClass, pod("job-a", corev1.PodPending))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		ExpectNotFound(ctx, env.Client, nodePoolFor("job-a"))
+		existingNodeClass = ExpectExists(ctx, env.Client, existingNodeClass)
+		Expect(existingNodeClass.Spec.Tags).To(Equal(map[string]string{"team": "payments"}))
+		Expect(existingNodeClass.OwnerReferences).To(BeEmpty())
+		nodePoolTemplate = ExpectExists(ctx, env.Client, nodePoolTemplate)
+		Expect(nodePoolTemplate.Status.NodePools).To(BeEmpty())
+		ExpectDeleted(ctx, env.Client, existingNodeClass)
+	})
+	It("should not consider a NodePool which it doesn't own a batch, even if it has the template's labels", func() {
+		existingNodePool := coretest.NodePool(karpv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelNodePoolTemplate: nodePoolTemplate.Name, v1.LabelBatch: "job-a"}},
+		})
+		ExpectApplied(ctx, env.Client, existingNodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		existingNodePool = ExpectExists(ctx, env.Client, existingNodePool)
+		Expect(existingNodePool.Annotations).ToNot(HaveKey(v1.AnnotationIdleSince))
+		ExpectNotFound(ctx, env.Client, nodePoolFor("job-a"))
+		ExpectDeleted(ctx, env.Client, existingNodePool)
+	})
+	It("should update the EC2NodeClass of a batch when the referenced EC2NodeClass changes", func() {
+		ExpectApplied(ctx, env.Client, pod("job-a", corev1.PodPending))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+
+		nodeClass.Spec.Tags["team"] = "research"
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+		Expect(ExpectExists(ctx, env.Client, nodeClassFor("job-a")).Spec.Tags).To(HaveKeyWithValue("team", "research"))
+	})
+	Context("Garbage Collection", func() {
+		var p *corev1.Pod
+		BeforeEach(func() {
+			p = pod("job-a", corev1.PodRunning)
+			ExpectApplied(ctx, env.Client, p)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			ExpectExists(ctx, env.Client, nodePoolFor("job-a"))
+		})
+		It("should delete the NodePool and EC2NodeClass once the batch has been idle for the TTL", func() {
+			p.Status.Phase = corev1.PodSucceeded
+			ExpectApplied(ctx, env.Client, p)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			Expect(ExpectExists(ctx, env.Client, nodePoolFor("job-a")).Annotations).To(HaveKeyWithValue(v1.AnnotationIdleSince, fakeClock.Now().UTC().Format(time.RFC3339)))
+
+			fakeClock.Step(5 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			ExpectExists(ctx, env.Client, nodePoolFor("job-a"))
+
+			fakeClock.Step(6 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			ExpectNotFound(ctx, env.Client, nodePoolFor("job-a"), nodeClassFor("job-a"))
+			nodePoolTemplate = ExpectExists(ctx, env.Client, nodePoolTemplate)
+			Expect(nodePoolTemplate.Status.NodePools).To(BeEmpty())
+		})
+		It("should not consider a batch idle while its NodePool has NodeClaims", func() {
+			ExpectDeleted(ctx, env.Client, p)
+			ExpectApplied(ctx, env.Client, coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: nodepooltemplate.Name(nodePoolTemplate, "job-a")}},
+			}))
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			Expect(ExpectExists(ctx, env.Client, nodePoolFor("job-a")).Annotations).ToNot(HaveKey(v1.AnnotationIdleSince))
+
+			fakeClock.Step(time.Hour)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			ExpectExists(ctx, env.Client, nodePoolFor("job-a"))
+		})
+		It("should no longer consider a batch idle when it has new pods", func() {
+			ExpectDeleted(ctx, env.Client, p)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			Expect(ExpectExists(ctx, env.Client, nodePoolFor("job-a")).Annotations).To(HaveKey(v1.AnnotationIdleSince))
+
+			fakeClock.Step(5 * time.Minute)
+			ExpectApplied(ctx, env.Client, pod("job-a", corev1.PodPending))
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			Expect(ExpectExists(ctx, env.Client, nodePoolFor("job-a")).Annotations).ToNot(HaveKey(v1.AnnotationIdleSince))
+
+			fakeClock.Step(time.Hour)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePoolTemplate)
+			ExpectExists(ctx, env.Client, nodePoolFor("job-a"))
+		})
+	})
+})
@@ -174,4 +174,74 @@ var _ = Describe("CapacityCache", func() {
 		mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*options.FromContext(ctx).VMMemoryOverheadPercent/1024/1024)))))
 		Expect(i.Capacity.Memory().Value()).To(Equal(mem.Value()), "Expected capacity to match VMMemoryOverheadPercent calculation")
 	})
+	Context("Learned VM Memory Overhead", func() {
+		It("should apply the overhead observed on a node to the other instance types in its family", func() {
+			learnCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				VMMemoryOverheadPercent: lo.ToPtr[float64](0.075),
+				FeatureGates:            test.FeatureGates{LearnVMMemoryOverhead: lo.ToPtr(true)},
+			}))
+			ExpectObjectReconciled(learnCtx, env.Client, controller, node)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(learnCtx, nodeClass)
+			Expect(err).To(BeNil())
+
+			// The t3.medium node reports 3840Mi of the 8192Mi from fake.MakeInstances()
+			sibling, ok := lo.Find(instanceTypes, func(i *karpcloudprovider.InstanceType) bool {
+				return i.Name == "t3.large"
+			})
+			Expect(ok).To(BeTrue())
+			Expect(sibling.Capacity.Memory().Cmp(resource.MustParse("3840Mi"))).To(BeZero())
+
+			// Instance types in other families still use VM_MEMORY_OVERHEAD_PERCENT
+			other, ok := lo.Find(instanceTypes, func(i *karpcloudprovider.InstanceType) bool {
+				return i.Name == "m5.large"
+			})
+			Expect(ok).To(BeTrue())
+			mem := resources.Quantity(fmt.Sprintf("%dMi", 8192))
+			mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*0.075/1024/1024)))))
+			Expect(other.Capacity.Memory().Value()).To(Equal(mem.Value()))
+		})
+		It("should apply a newly learned overhead to instance types which were already listed", func() {
+			learnCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				VMMemoryOverheadPercent: lo.ToPtr[float64](0.075),
+				FeatureGates:            test.FeatureGates{LearnVMMemoryOverhead: lo.ToPtr(true)},
+			}))
+			_, err := awsEnv.InstanceTypesProvider.List(learnCtx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectObjectReconciled(learnCtx, env.Client, controller, node)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(learnCtx, nodeClass)
+			Expect(err).To(BeNil())
+			sibling, ok := lo.Find(instanceTypes, func(i *karpcloudprovider.InstanceType) bool {
+				return i.Name == "t3.large"
+			})
+			Expect(ok).To(BeTrue())
+			Expect(sibling.Capacity.Memory().Cmp(resource.MustParse("3840Mi"))).To(BeZero())
+		})
+		It("should prefer a configured override over the learned overhead", func() {
+			learnCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				VMMemoryOverheadPercent:          lo.ToPtr[float64](0.075),
+				VMMemoryOverheadPercentOverrides: lo.ToPtr("t3.large=0.5"),
+				FeatureGates:                     test.FeatureGates{LearnVMMemoryOverhead: lo.ToPtr(true)},
+			}))
+			ExpectObjectReconciled(learnCtx, env.Client, controller, node)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(learnCtx, nodeClass)
+			Expect(err).To(BeNil())
+			i, ok := lo.Find(instanceTypes, func(i *karpcloudprovider.InstanceType) bool {
+				return i.Name == "t3.large"
+			})
+			Expect(ok).To(BeTrue())
+			Expect(i.Capacity.Memory().Cmp(resource.MustParse("4096Mi"))).To(BeZero())
+		})
+		It("should not learn the overhead when learning is disabled", func() {
+			ExpectObjectReconciled(ctx, env.Client, controller, node)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			i, ok := lo.Find(instanceTypes, func(i *karpcloudprovider.InstanceType) bool {
+				return i.Name == "t3.large"
+			})
+			Expect(ok).To(BeTrue())
+			mem := resources.Quantity(fmt.Sprintf("%dMi", 8192))
+			mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*0.075/1024/1024)))))
+			Expect(i.Capacity.Memory().Value()).To(Equal(mem.Value()))
+		})
+	})
 })
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 )
 
@@ -47,11 +48,11 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		c.instanceTypeProvider.UpdateInstanceTypes,
 		c.instanceTypeProvider.UpdateInstanceTypeOfferings,
 	}
+	sources := []string{health.InstanceTypes, health.Offerings}
 	errs := make([]error, len(work))
 	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
-		if err := f(ctx); err != nil {
-			errs[i] = err
-		}
+		errs[i] = f(ctx)
+		health.Providers.Observe(sources[i], errs[i])
 	})
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating instancetype, %w", err)
@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prefixdelegation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"k8s.io/client-go/kubernetes"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+)
+
+// Controller detects whether the VPC CNI has prefix delegation enabled, so that the max pods of instance types are
+// computed from the IPv4 prefixes of their ENIs rather than their IPv4 addresses
+type Controller struct {
+	kubernetesInterface  kubernetes.Interface
+	instanceTypeProvider *instancetype.DefaultProvider
+}
+
+func NewController(kubernetesInterface kubernetes.Interface, instanceTypeProvider *instancetype.DefaultProvider) *Controller {
+	return &Controller{
+		kubernetesInterface:  kubernetesInterface,
+		instanceTypeProvider: instanceTypeProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.instancetype.prefixdelegation")
+
+	enabled, err := instancetype.PrefixDelegationEnabled(ctx, c.kubernetesInterface)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("detecting prefix delegation, %w", err)
+	}
+	c.instanceTypeProvider.SetPrefixDelegation(ctx, enabled)
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	// Includes a default exponential failure rate limiter of base: time.Millisecond, and max: 1000*time.Second
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.instancetype.prefixdelegation").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prefixdelegation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype/prefixdelegation"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *prefixdelegation.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PrefixDelegation")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = prefixdelegation.NewController(env.KubernetesInterface, awsEnv.InstanceTypesProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	awsEnv.InstanceTypesProvider.SetPrefixDelegation(ctx, false)
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("PrefixDelegation", func() {
+	var nodeClass *v1.EC2NodeClass
+	var daemonSet *appsv1.DaemonSet
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		labels := map[string]string{"k8s-app": instancetype.VPCCNIDaemonSet}
+		daemonSet = &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: instancetype.VPCCNIDaemonSet, Namespace: instancetype.VPCCNINamespace},
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{
+						Name:  instancetype.VPCCNIDaemonSet,
+						Image: "amazon-k8s-cni",
+						Env:   []corev1.EnvVar{{Name: instancetype.PrefixDelegationEnv, Value: "true"}},
+					}}},
+				},
+			},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, daemonSet)
+	})
+	pods := func(name string) int64 {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == name })
+		Expect(ok).To(BeTrue())
+		return it.Capacity.Pods().Value()
+	}
+	It("should compute max pods from prefixes when prefix delegation is enabled", func() {
+		ExpectApplied(ctx, env.Client, daemonSet)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pods("m5.large")).To(BeNumerically("==", 110))
+		Expect(pods("m5.metal")).To(BeNumerically("==", 250))
+	})
+	It("should compute max pods from addresses when prefix delegation is disabled", func() {
+		daemonSet.Spec.Template.Spec.Containers[0].Env[0].Value = "false"
+		ExpectApplied(ctx, env.Client, daemonSet)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pods("m5.large")).To(BeNumerically("==", 29))
+	})
+	It("should resolve prefix delegation from a configmap", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "amazon-vpc-cni", Namespace: instancetype.VPCCNINamespace},
+			Data:       map[string]string{"enable-prefix-delegation": "true"},
+		}
+		daemonSet.Spec.Template.Spec.Containers[0].Env[0] = corev1.EnvVar{Name: instancetype.PrefixDelegationEnv, ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name}, Key: "enable-prefix-delegation"},
+		}}
+		ExpectApplied(ctx, env.Client, configMap, daemonSet)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pods("m5.large")).To(BeNumerically("==", 110))
+		ExpectDeleted(ctx, env.Client, configMap)
+	})
+	It("should not use prefix delegation for instance types which aren't nitro", func() {
+		ExpectApplied(ctx, env.Client, daemonSet)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pods("p3.8xlarge")).To(BeNumerically("==", 234))
+	})
+	It("should not use prefix delegation when the vpc cni isn't installed", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pods("m5.large")).To(BeNumerically("==", 29))
+	})
+})
@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+const (
+	// GracePeriod is the time after their creation that launch templates aren't garbage collected for, so that a
+	// launch template which was just created for a launch isn't deleted before it's used
+	GracePeriod = time.Hour
+	// QuotaUtilizationThreshold is the share of the launch template quota above which an error is logged, since
+	// launches fail once the quota is exhausted
+	QuotaUtilizationThreshold = 0.8
+)
+
+// Controller garbage collects the launch templates which Karpenter created for the cluster but no longer uses, and
+// tracks the number of launch templates in the region against the launch template quota
+type Controller struct {
+	launchTemplateProvider launchtemplate.Provider
+}
+
+func NewController(launchTemplateProvider launchtemplate.Provider) *Controller {
+	return &Controller{
+		launchTemplateProvider: launchTemplateProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.launchtemplate")
+
+	deleted, err := c.launchTemplateProvider.GarbageCollect(ctx, GracePeriod)
+	GarbageCollected.Add(float64(deleted), nil)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("garbage collecting launch templates, %w", err)
+	}
+	total, owned, err := c.launchTemplateProvider.Count(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("counting launch templates, %w", err)
+	}
+	Count.Set(float64(owned), map[string]string{ownerLabel: ownerKarpenter})
+	Count.Set(float64(total-owned), map[string]string{ownerLabel: ownerOther})
+	utilization := float64(total) / launchtemplate.Quota
+	QuotaUtilization.Set(utilization, nil)
+	if utilization >= QuotaUtilizationThreshold {
+		log.FromContext(ctx).WithValues("count", total, "owned", owned, "quota", launchtemplate.Quota).Error(
+			fmt.Errorf("launch templates are at %.0f%% of the quota", utilization*100), "launch templates are approaching the quota, launches will fail once it's reached")
+	}
+	return reconcile.Result{RequeueAfter: 10 * time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.launchtemplate").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
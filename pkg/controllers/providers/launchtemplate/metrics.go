@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	launchTemplateSubsystem = "launch_templates"
+	ownerLabel              = "owner"
+	ownerKarpenter          = "karpenter"
+	ownerOther              = "other"
+)
+
+var (
+	Count = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchTemplateSubsystem,
+			Name:      "count",
+			Help:      "Number of launch templates in the region. Labeled by owner, which is karpenter for the launch templates that Karpenter created for the cluster, and other otherwise.",
+		},
+		[]string{ownerLabel},
+	)
+	QuotaUtilization = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchTemplateSubsystem,
+			Name:      "quota_utilization",
+			Help:      "Share of the launch template quota of the region which is used, between 0 and 1.",
+		},
+		[]string{},
+	)
+	GarbageCollected = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchTemplateSubsystem,
+			Name:      "garbage_collected_total",
+			Help:      "Number of launch templates which Karpenter created for the cluster and garbage collected because they were no longer in use.",
+		},
+		[]string{},
+	)
+)
@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// PrewarmInterval is the interval that launch templates are prewarmed at. Launch templates expire from the cache once
+// they haven't been used for awscache.DefaultTTL, so they're ensured again before they expire.
+const PrewarmInterval = awscache.DefaultTTL / 2
+
+// PrewarmController creates the launch templates that the launches of each NodePool are expected to use ahead of the
+// launches, so that launches find them in the cache rather than waiting on CreateLaunchTemplate. The launch templates
+// are rendered from the NodePool's template for the cheapest instance types of each capacity type that it allows, the
+// same way that launches render them.
+type PrewarmController struct {
+	kubeClient             client.Client
+	cloudProvider          cloudprovider.CloudProvider
+	instanceTypeProvider   instancetype.Provider
+	launchTemplateProvider launchtemplate.Provider
+}
+
+func NewPrewarmController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, instanceTypeProvider instancetype.Provider,
+	launchTemplateProvider launchtemplate.Provider) *PrewarmController {
+	return &PrewarmController{
+		kubeClient:             kubeClient,
+		cloudProvider:          cloudProvider,
+		instanceTypeProvider:   instanceTypeProvider,
+		launchTemplateProvider: launchTemplateProvider,
+	}
+}
+
+func (c *PrewarmController) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.launchtemplate.prewarm")
+
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	// Failures to prewarm aren't retried with a backoff, since launches create the launch templates they need anyway and
+	// the launch templates of the other NodePools would expire in the meantime
+	for i := range nodePoolList.Items {
+		nodePool := &nodePoolList.Items[i]
+		if !nodePool.DeletionTimestamp.IsZero() || !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+			continue
+		}
+		if err := c.prewarm(ctx, nodePool); err != nil {
+			log.FromContext(ctx).WithValues("NodePool", nodePool.Name).Error(err, "failed prewarming launch templates")
+		}
+	}
+	return reconcile.Result{RequeueAfter: PrewarmInterval}, nil
+}
+
+func (c *PrewarmController) prewarm(ctx context.Context, nodePool *karpv1.NodePool) error {
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("getting ec2nodeclass, %w", err))
+	}
+	// The launch templates of partitioned placement groups depend on the partition that each launch is assigned to
+	if !nodeClass.DeletionTimestamp.IsZero() || !nodeClass.StatusConditions().Root().IsTrue() || nodeClass.PlacementGroupPartitions() > 0 {
+		return nil
+	}
+	nodeClass.SetDefaults(ctx)
+	// NodeClaims are created from the NodePool's template with the labels of their NodePool and EC2NodeClass, which are
+	// rendered into the launch templates through the kubelet's node labels
+	nodeClaim := nodePool.Spec.Template.ToNodeClaim()
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+		karpv1.NodePoolLabelKey: nodePool.Name,
+		karpv1.NodeClassLabelKey(nodePool.Spec.Template.Spec.NodeClassRef.GroupKind()): nodeClass.Name,
+	})
+	tags, err := instance.Tags(ctx, nodeClass, nodeClaim)
+	if err != nil {
+		return err
+	}
+	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("listing instance types, %w", err)
+	}
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return reqs.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil
+	})
+	var errs error
+	for _, capacityType := range []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand} {
+		if !reqs.Get(karpv1.CapacityTypeLabelKey).Has(capacityType) {
+			continue
+		}
+		capacityTypeReqs := scheduling.NewRequirements(reqs.Values()...)
+		capacityTypeReqs[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+		compatible := cloudprovider.InstanceTypes(instanceTypes).Compatible(capacityTypeReqs)
+		if len(compatible) == 0 {
+			continue
+		}
+		compatible = lo.Slice(compatible.OrderByPrice(capacityTypeReqs), 0, instance.MaxInstanceTypes)
+		if _, err := c.launchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, compatible, capacityType, tags); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("ensuring %s launch templates, %w", capacityType, err))
+		}
+	}
+	return errs
+}
+
+func (c *PrewarmController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.launchtemplate.prewarm").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
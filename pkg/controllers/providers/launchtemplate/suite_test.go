@@ -0,0 +1,226 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	opstatus "github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	controllerslaunchtemplate "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllerslaunchtemplate.Controller
+var prewarmController *controllerslaunchtemplate.PrewarmController
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchTemplate")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllerslaunchtemplate.NewController(awsEnv.LaunchTemplateProvider)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	prewarmController = controllerslaunchtemplate.NewPrewarmController(env.Client, cloudProvider, awsEnv.InstanceTypesProvider, awsEnv.LaunchTemplateProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+
+	awsEnv.Reset()
+	awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("10.0.100.10")
+	awsEnv.LaunchTemplateProvider.ClusterEndpoint = "https://test-cluster"
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("LaunchTemplate", func() {
+	storeLaunchTemplate := func(name string, createTime time.Time, tags map[string]string) *string {
+		ltName := aws.String(name)
+		awsEnv.EC2API.LaunchTemplates.Store(ltName, ec2types.LaunchTemplate{
+			LaunchTemplateName: ltName,
+			LaunchTemplateId:   aws.String(fake.LaunchTemplateID()),
+			CreateTime:         aws.Time(createTime),
+			Tags: lo.MapToSlice(tags, func(k, v string) ec2types.Tag {
+				return ec2types.Tag{Key: aws.String(k), Value: aws.String(v)}
+			}),
+		})
+		return ltName
+	}
+	exists := func(name *string) bool {
+		_, ok := awsEnv.EC2API.LaunchTemplates.Load(name)
+		return ok
+	}
+	ownerTags := map[string]string{v1.LaunchTemplateOwnerTagKey: "test-cluster", v1.EKSClusterNameTagKey: "test-cluster"}
+
+	It("should garbage collect launch templates which are owned by the cluster and aren't in use", func() {
+		name := storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now().Add(-2*time.Hour), ownerTags)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(exists(name)).To(BeFalse())
+	})
+	It("should not garbage collect launch templates within the grace period", func() {
+		name := storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now().Add(-time.Minute), ownerTags)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(exists(name)).To(BeTrue())
+	})
+	It("should not garbage collect launch templates which are in use", func() {
+		name := storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now().Add(-2*time.Hour), ownerTags)
+		awsEnv.LaunchTemplateCache.SetDefault(*name, ec2types.LaunchTemplate{LaunchTemplateName: name})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(exists(name)).To(BeTrue())
+	})
+	It("should not garbage collect launch templates which are owned by another cluster", func() {
+		name := storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now().Add(-2*time.Hour), map[string]string{v1.LaunchTemplateOwnerTagKey: "other-cluster"})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(exists(name)).To(BeTrue())
+	})
+	It("should not garbage collect launch templates which weren't created by Karpenter", func() {
+		untagged := storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now().Add(-2*time.Hour), map[string]string{v1.EKSClusterNameTagKey: "test-cluster"})
+		unprefixed := storeLaunchTemplate("my-launch-template", time.Now().Add(-2*time.Hour), ownerTags)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(exists(untagged)).To(BeTrue())
+		Expect(exists(unprefixed)).To(BeTrue())
+	})
+	It("should track the number of launch templates against the quota", func() {
+		storeLaunchTemplate("karpenter.k8s.aws/1234", time.Now(), ownerTags)
+		storeLaunchTemplate("karpenter.k8s.aws/5678", time.Now(), ownerTags)
+		storeLaunchTemplate("my-launch-template", time.Now(), nil)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectMetricGaugeValue(controllerslaunchtemplate.Count, 2, map[string]string{"owner": "karpenter"})
+		ExpectMetricGaugeValue(controllerslaunchtemplate.Count, 1, map[string]string{"owner": "other"})
+		ExpectMetricGaugeValue(controllerslaunchtemplate.QuotaUtilization, 3.0/5000, map[string]string{})
+	})
+})
+
+var _ = Describe("Prewarm", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	isSpot := func(input *ec2.CreateLaunchTemplateInput) bool {
+		return lo.ContainsBy(input.LaunchTemplateData.TagSpecifications, func(s ec2types.LaunchTemplateTagSpecificationRequest) bool {
+			return s.ResourceType == ec2types.ResourceTypeSpotInstancesRequest
+		})
+	}
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodeClass.StatusConditions().SetTrue(opstatus.ConditionReady)
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+	It("should create the launch templates of each capacity type that a NodePool allows", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, prewarmController)
+
+		var inputs []*ec2.CreateLaunchTemplateInput
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) { inputs = append(inputs, input) })
+		Expect(lo.ContainsBy(inputs, isSpot)).To(BeTrue())
+		Expect(lo.ContainsBy(inputs, func(input *ec2.CreateLaunchTemplateInput) bool { return !isSpot(input) })).To(BeTrue())
+		for _, input := range inputs {
+			_, ok := awsEnv.LaunchTemplateCache.Get(*input.LaunchTemplateName)
+			Expect(ok).To(BeTrue())
+			Expect(input.LaunchTemplateData.TagSpecifications[0].Tags).To(ContainElement(ec2types.Tag{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String(nodePool.Name)}))
+		}
+	})
+	It("should only create the launch templates of the capacity types that a NodePool allows", func() {
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, prewarmController)
+
+		var inputs []*ec2.CreateLaunchTemplateInput
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) { inputs = append(inputs, input) })
+		Expect(inputs).ToNot(BeEmpty())
+		Expect(lo.ContainsBy(inputs, isSpot)).To(BeFalse())
+	})
+	It("should not create launch templates again while they're cached", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, prewarmController)
+		created := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()
+		Expect(created).ToNot(BeZero())
+
+		ExpectSingletonReconciled(ctx, prewarmController)
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(created))
+	})
+	It("should not create launch templates for EC2NodeClasses which aren't ready", func() {
+		nodeClass.StatusConditions().SetFalse(opstatus.ConditionReady, "NotReady", "NotReady")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, prewarmController)
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+	})
+	It("should not create launch templates for EC2NodeClasses with partitioned placement groups", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 3}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, prewarmController)
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+	})
+})
@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
+)
+
+// pollInterval is the interval that the launch policy ConfigMap is read at, so that changes to the launch policies
+// take effect without restarting Karpenter
+const pollInterval = 30 * time.Second
+
+// Controller reloads the launch policies from the ConfigMap named by the policy-configmap setting. The ConfigMap is
+// read directly from the API server so that Karpenter doesn't need to watch ConfigMaps in its namespace.
+type Controller struct {
+	kubeReader     client.Reader
+	policyProvider *policy.DefaultProvider
+	namespace      string
+}
+
+func NewController(kubeReader client.Reader, policyProvider *policy.DefaultProvider, namespace string) *Controller {
+	return &Controller{
+		kubeReader:     kubeReader,
+		policyProvider: policyProvider,
+		namespace:      namespace,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.policy")
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.kubeReader.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: options.FromContext(ctx).PolicyConfigMap}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).WithValues("configmap", options.FromContext(ctx).PolicyConfigMap).V(1).Info("launch policy configmap not found, allowing all offerings")
+			c.policyProvider.Reset()
+			return reconcile.Result{RequeueAfter: pollInterval}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting launch policy configmap, %w", err)
+	}
+	if err := c.policyProvider.Update(ctx, configMap.ResourceVersion, configMap.Data); err != nil {
+		return reconcile.Result{}, fmt.Errorf("updating launch policies, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.policy").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
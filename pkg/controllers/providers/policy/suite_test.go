@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllerspolicy "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/policy"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllerspolicy.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policy")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PolicyConfigMap: lo.ToPtr("karpenter-launch-policies")}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllerspolicy.NewController(env.Client, awsEnv.PolicyProvider, "default")
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Policy", func() {
+	var configMap *corev1.ConfigMap
+	launch := func(mutate ...func(*policy.Launch)) policy.Launch {
+		l := policy.Launch{
+			NodePool:     "default",
+			NodeClass:    "default",
+			InstanceType: "m5.large",
+			Zone:         "test-zone-1a",
+			CapacityType: "on-demand",
+			Architecture: "amd64",
+			SubnetID:     "subnet-test1",
+		}
+		for _, m := range mutate {
+			m(&l)
+		}
+		return l
+	}
+	BeforeEach(func() {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "karpenter-launch-policies", Namespace: "default"},
+			Data: map[string]string{
+				"public-subnets.cedar": `forbid (principal, action == Action::"Launch", resource) when { context.publicSubnet };`,
+			},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, configMap)
+	})
+	It("should allow all offerings when the configmap doesn't exist", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.PublicSubnet = true }))).To(BeTrue())
+	})
+	It("should deny offerings which are forbidden by a policy", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.PublicSubnet = true }))).To(BeFalse())
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch())).To(BeTrue())
+	})
+	It("should deny offerings when any document forbids them", func() {
+		configMap.Data["families.cedar"] = `forbid (principal == NodePool::"default", action, resource) unless { ["m5", "c5"].contains(context.instanceFamily) };`
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.InstanceType = "c5.xlarge" }))).To(BeTrue())
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.InstanceType = "r5.large" }))).To(BeFalse())
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.InstanceType = "r5.large"; l.NodePool = "other" }))).To(BeTrue())
+	})
+	It("should ignore keys which aren't cedar documents", func() {
+		configMap.Data["README"] = "policies which are enforced across every NodePool"
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch())).To(BeTrue())
+	})
+	It("should reload policies when the configmap changes", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.CapacityType = "spot" }))).To(BeTrue())
+
+		configMap.Data["spot.cedar"] = `forbid (principal, action, resource) when { context.capacityType == "spot" };`
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.CapacityType = "spot" }))).To(BeFalse())
+	})
+	It("should retain the previous policies when a document fails to parse", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+
+		configMap.Data["invalid.cedar"] = `forbid (principal, action, resource) when {`
+		ExpectApplied(ctx, env.Client, configMap)
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.PublicSubnet = true }))).To(BeFalse())
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch())).To(BeTrue())
+	})
+	It("should allow all offerings when the configmap is deleted", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectDeleted(ctx, env.Client, configMap)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PolicyProvider.Allowed(ctx, launch(func(l *policy.Launch) { l.PublicSubnet = true }))).To(BeTrue())
+	})
+})
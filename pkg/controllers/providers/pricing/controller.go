@@ -17,26 +17,58 @@ package pricing
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
 	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
 	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
+	"github.com/aws/karpenter-provider-aws/pkg/health"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/commitment"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 )
 
+// Offering is an instance type, capacity type and zone that nodes are running on
+type Offering struct {
+	InstanceType string
+	CapacityType string
+	Zone         string
+}
+
+// Controller refreshes the spot, on-demand and committed prices of instance types. After each refresh, the prices of
+// the offerings that nodes are running on are diffed against the snapshot taken after the previous refresh, so that
+// price changes are recorded as metrics and, beyond the configured threshold, published as events on the NodePools of
+// the nodes.
 type Controller struct {
-	pricingProvider pricing.Provider
+	kubeClient         client.Client
+	recorder           events.Recorder
+	pricingProvider    pricing.Provider
+	commitmentProvider commitment.Provider
+
+	mu       sync.Mutex
+	snapshot map[Offering]float64
 }
 
-func NewController(pricingProvider pricing.Provider) *Controller {
+func NewController(kubeClient client.Client, recorder events.Recorder, pricingProvider pricing.Provider, commitmentProvider commitment.Provider) *Controller {
 	return &Controller{
-		pricingProvider: pricingProvider,
+		kubeClient:         kubeClient,
+		recorder:           recorder,
+		pricingProvider:    pricingProvider,
+		commitmentProvider: commitmentProvider,
 	}
 }
 
@@ -47,18 +79,116 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		c.pricingProvider.UpdateSpotPricing,
 		c.pricingProvider.UpdateOnDemandPricing,
 	}
+	if options.FromContext(ctx).FeatureGates.CommitmentAwarePricing {
+		work = append(work, c.updateCommittedPricing)
+	}
 	errs := make([]error, len(work))
 	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
 		if err := f(ctx); err != nil {
 			errs[i] = err
 		}
 	})
-	if err := multierr.Combine(errs...); err != nil {
+	err := multierr.Combine(errs...)
+	health.Providers.Observe(health.Pricing, err)
+	// Prices which were refreshed are diffed even if other prices failed to refresh
+	if diffErr := c.diffPrices(ctx); diffErr != nil {
+		log.FromContext(ctx).Error(diffErr, "failed diffing prices")
+	}
+	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating pricing, %w", err)
 	}
 	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
 }
 
+// updateCommittedPricing updates the effective prices of the instance types which the account has committed to with
+// Savings Plans and Reserved Instances
+func (c *Controller) updateCommittedPricing(ctx context.Context) error {
+	prices, err := c.commitmentProvider.Prices(ctx)
+	if err != nil {
+		return fmt.Errorf("getting committed prices, %w", err)
+	}
+	c.pricingProvider.SetCommittedPrices(prices)
+	log.FromContext(ctx).WithValues("instance-type-count", len(prices)).V(1).Info("updated committed pricing")
+	return nil
+}
+
+// diffPrices snapshots the prices of the offerings that nodes are running on, and records the changes since the
+// previous snapshot. Nothing is recorded for the first snapshot, since the prices which are known before the first
+// refresh are static estimates.
+func (c *Controller) diffPrices(ctx context.Context) error {
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims); err != nil {
+		return fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	// usage is the number of nodes of each NodePool which are running on each offering
+	usage := map[Offering]map[string]int{}
+	for _, nc := range nodeClaims.Items {
+		offering := Offering{
+			InstanceType: nc.Labels[corev1.LabelInstanceTypeStable],
+			CapacityType: nc.Labels[karpv1.CapacityTypeLabelKey],
+			Zone:         nc.Labels[corev1.LabelTopologyZone],
+		}
+		nodePool, ok := nc.Labels[karpv1.NodePoolLabelKey]
+		if !ok || offering.InstanceType == "" || offering.CapacityType == "" || offering.Zone == "" {
+			continue
+		}
+		if usage[offering] == nil {
+			usage[offering] = map[string]int{}
+		}
+		usage[offering][nodePool]++
+	}
+	snapshot := map[Offering]float64{}
+	for offering := range usage {
+		if price, ok := c.price(offering); ok {
+			snapshot[offering] = price
+		}
+	}
+	c.mu.Lock()
+	previous := c.snapshot
+	c.snapshot = snapshot
+	c.mu.Unlock()
+
+	threshold := options.FromContext(ctx).PriceChangeThreshold
+	for offering, price := range snapshot {
+		previousPrice, ok := previous[offering]
+		if !ok || previousPrice == price {
+			continue
+		}
+		PriceChanges.Inc(map[string]string{
+			instanceTypeLabel: offering.InstanceType,
+			capacityTypeLabel: offering.CapacityType,
+			zoneLabel:         offering.Zone,
+			directionLabel:    lo.Ternary(price > previousPrice, "increase", "decrease"),
+		})
+		if threshold == 0 || previousPrice == 0 || math.Abs(price-previousPrice)/previousPrice < threshold {
+			continue
+		}
+		log.FromContext(ctx).WithValues("instance-type", offering.InstanceType, "capacity-type", offering.CapacityType, "zone", offering.Zone,
+			"previous-price", previousPrice, "price", price).Info("price changed")
+		for name, nodes := range usage[offering] {
+			nodePool := &karpv1.NodePool{}
+			if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: name}, nodePool); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					return fmt.Errorf("getting nodepool, %w", err)
+				}
+				continue
+			}
+			c.recorder.Publish(PriceChangedEvent(nodePool, offering, previousPrice, price, nodes))
+		}
+	}
+	return nil
+}
+
+func (c *Controller) price(offering Offering) (float64, bool) {
+	switch offering.CapacityType {
+	case karpv1.CapacityTypeSpot:
+		return c.pricingProvider.SpotPrice(ec2types.InstanceType(offering.InstanceType), offering.Zone)
+	case karpv1.CapacityTypeOnDemand:
+		return c.pricingProvider.OnDemandPrice(ec2types.InstanceType(offering.InstanceType))
+	}
+	return 0, false
+}
+
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("providers.pricing").
@@ -0,0 +1,34 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func PriceChangedEvent(nodePool *karpv1.NodePool, offering Offering, previousPrice, price float64, nodes int) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "PriceChanged",
+		Message: fmt.Sprintf("Price of %s %s in %s changed from %.4f to %.4f (%+.1f%%), affecting %d nodes",
+			offering.CapacityType, offering.InstanceType, offering.Zone, previousPrice, price, (price-previousPrice)/previousPrice*100, nodes),
+		DedupeValues: []string{string(nodePool.UID), offering.InstanceType, offering.CapacityType, offering.Zone, fmt.Sprint(price)},
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	pricingSubsystem  = "pricing"
+	instanceTypeLabel = "instance_type"
+	capacityTypeLabel = "capacity_type"
+	zoneLabel         = "zone"
+	directionLabel    = "direction"
+)
+
+var PriceChanges = opmetrics.NewPrometheusCounter(
+	crmetrics.Registry,
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: pricingSubsystem,
+		Name:      "price_changes_total",
+		Help:      "Number of times the price of an offering that nodes are running on changed after a pricing refresh. Labeled by instance type, capacity type, zone and direction of the change.",
+	},
+	[]string{instanceTypeLabel, capacityTypeLabel, zoneLabel, directionLabel},
+)
@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// SpotController refreshes spot prices far more often than the pricing controller, so that spot nodes whose spot
+// price rose above the on-demand price are drifted within minutes of the price change
+type SpotController struct {
+	pricingProvider pricing.Provider
+}
+
+func NewSpotController(pricingProvider pricing.Provider) *SpotController {
+	return &SpotController{
+		pricingProvider: pricingProvider,
+	}
+}
+
+func (c *SpotController) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.pricing.spot")
+
+	if err := c.pricingProvider.UpdateSpotPricing(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("updating spot pricing, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+func (c *SpotController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.pricing.spot").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -26,13 +26,19 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	savingsplanstypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -47,6 +53,7 @@ var ctx context.Context
 var stop context.CancelFunc
 var env *coretest.Environment
 var awsEnv *test.Environment
+var recorder *coretest.EventRecorder
 var controller *controllerspricing.Controller
 
 func TestAWS(t *testing.T) {
@@ -61,7 +68,8 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
-	controller = controllerspricing.NewController(awsEnv.PricingProvider)
+	recorder = coretest.NewEventRecorder()
+	controller = controllerspricing.NewController(env.Client, recorder, awsEnv.PricingProvider, awsEnv.CommitmentProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -74,6 +82,7 @@ var _ = BeforeEach(func() {
 	ctx = options.ToContext(ctx, test.Options())
 
 	awsEnv.Reset()
+	recorder.Reset()
 })
 
 var _ = AfterEach(func() {
@@ -111,6 +120,13 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically(">", 0))
 	})
+	It("should report the pricing update failure in the provider health", func() {
+		awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+		status := health.Providers.Statuses()[health.Pricing]
+		Expect(status.LastRefreshed).To(BeNil())
+		Expect(status.LastError).To(ContainSubstring("failed"))
+	})
 	It("should update on-demand pricing with response from the pricing API", func() {
 		// modify our API before creating the pricing provider as it performs an initial update on creation. The pricing
 		// API provides on-demand prices, the ec2 API provides spot prices
@@ -210,6 +226,26 @@ var _ = Describe("Pricing", func() {
 		_, ok = awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1b")
 		Expect(ok).ToNot(BeTrue())
 	})
+	It("should refresh spot prices with the spot controller", func() {
+		now := time.Now()
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []ec2types.SpotPrice{
+				{
+					AvailabilityZone: aws.String("test-zone-1a"),
+					InstanceType:     "c98.large",
+					SpotPrice:        aws.String("1.20"),
+					Timestamp:        &now,
+				},
+			},
+		})
+		spotController := controllerspricing.NewSpotController(awsEnv.PricingProvider)
+		result := ExpectSingletonReconciled(ctx, spotController)
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+		price, ok := awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.20))
+	})
 	It("should respond with false if price doesn't exist in zone", func() {
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
@@ -301,7 +337,7 @@ var _ = Describe("Pricing", func() {
 	})
 	It("should update on-demand pricing with response from the pricing API when in the CN partition", func() {
 		tmpPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "cn-anywhere-1")
-		tmpController := controllerspricing.NewController(tmpPricingProvider)
+		tmpController := controllerspricing.NewController(env.Client, recorder, tmpPricingProvider, awsEnv.CommitmentProvider)
 
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
@@ -330,4 +366,209 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
+	It("should return static on-demand data when in a partition without the pricing API", func() {
+		tmpPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "us-gov-west-1")
+		tmpController := controllerspricing.NewController(env.Client, recorder, tmpPricingProvider, awsEnv.CommitmentProvider)
+
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{
+				fake.NewOnDemandPrice("c98.large", 1.20),
+				fake.NewOnDemandPrice("m5.large", 1.23),
+			},
+		})
+		ExpectSingletonReconciled(ctx, tmpController)
+
+		_, ok := tmpPricingProvider.OnDemandPrice("c98.large")
+		Expect(ok).To(BeFalse())
+		price, ok := tmpPricingProvider.OnDemandPrice("m5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", pricing.InitialOnDemandPricesUSGov["us-gov-west-1"]["m5.large"]))
+	})
+	Context("Commitment Aware Pricing", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{CommitmentAwarePricing: lo.ToPtr(true)}}))
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c98.large",
+						SpotPrice:        aws.String("0.50"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+					fake.NewOnDemandPrice("c99.large", 1.23),
+				},
+			})
+		})
+		It("should lower the on-demand price of instance types covered by reserved instances", func() {
+			awsEnv.EC2API.DescribeReservedInstancesBehavior.Output.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:    "c98.large",
+						InstanceTenancy: ec2types.TenancyDefault,
+						Duration:        aws.Int64(365 * 24 * 3600),
+						FixedPrice:      aws.Float32(876),
+						UsagePrice:      aws.Float32(0),
+						RecurringCharges: []ec2types.RecurringCharge{
+							{Amount: aws.Float64(0.5), Frequency: ec2types.RecurringChargeFrequencyHourly},
+						},
+					},
+					{
+						InstanceType:    "c99.large",
+						InstanceTenancy: ec2types.TenancyDedicated,
+						Duration:        aws.Int64(365 * 24 * 3600),
+						UsagePrice:      aws.Float32(0.1),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 0.6, 0.0001))
+
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+		})
+		It("should lower the on-demand price of instance types covered by savings plans", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansBehavior.Output.Set(&savingsplans.DescribeSavingsPlansOutput{
+				SavingsPlans: []savingsplanstypes.SavingsPlan{
+					{SavingsPlanId: aws.String("sp-compute"), SavingsPlanType: savingsplanstypes.SavingsPlanTypeCompute},
+					{SavingsPlanId: aws.String("sp-sagemaker"), SavingsPlanType: savingsplanstypes.SavingsPlanTypeSagemaker},
+				},
+			})
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesBehavior.Output.Set(&savingsplans.DescribeSavingsPlanRatesOutput{
+				SavingsPlanId: aws.String("sp-compute"),
+				SearchResults: []savingsplanstypes.SavingsPlanRate{
+					{
+						Rate:       aws.String("0.8"),
+						Unit:       savingsplanstypes.SavingsPlanRateUnitHours,
+						Properties: []savingsplanstypes.SavingsPlanRateProperty{{Name: savingsplanstypes.SavingsPlanRatePropertyKeyInstanceType, Value: aws.String("c99.large")}},
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.8))
+
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+
+			Expect(awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesBehavior.Calls()).To(Equal(1))
+			input := awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesBehavior.CalledWithInput.Pop()
+			Expect(aws.ToString(input.SavingsPlanId)).To(Equal("sp-compute"))
+		})
+		It("should not raise the on-demand price when the committed price is higher", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansBehavior.Output.Set(&savingsplans.DescribeSavingsPlansOutput{
+				SavingsPlans: []savingsplanstypes.SavingsPlan{{SavingsPlanId: aws.String("sp-compute"), SavingsPlanType: savingsplanstypes.SavingsPlanTypeCompute}},
+			})
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesBehavior.Output.Set(&savingsplans.DescribeSavingsPlanRatesOutput{
+				SearchResults: []savingsplanstypes.SavingsPlanRate{
+					{
+						Rate:       aws.String("2.00"),
+						Unit:       savingsplanstypes.SavingsPlanRateUnitHours,
+						Properties: []savingsplanstypes.SavingsPlanRateProperty{{Name: savingsplanstypes.SavingsPlanRatePropertyKeyInstanceType, Value: aws.String("c98.large")}},
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should ignore commitments when commitment aware pricing is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{CommitmentAwarePricing: lo.ToPtr(false)}}))
+			awsEnv.EC2API.DescribeReservedInstancesBehavior.Output.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{InstanceType: "c98.large", Duration: aws.Int64(365 * 24 * 3600), UsagePrice: aws.Float32(0.1)},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+			Expect(awsEnv.EC2API.DescribeReservedInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.SavingsPlansAPI.DescribeSavingsPlansBehavior.Calls()).To(Equal(0))
+		})
+		It("should fail to reconcile when the savings plans can't be described", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansBehavior.Error.Set(fmt.Errorf("failed"))
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+	})
+	Context("Price Changes", func() {
+		var nodePool *karpv1.NodePool
+		var priceChangeController *controllerspricing.Controller
+		setPrices := func(onDemand, spot float64) {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{{
+					AvailabilityZone: aws.String("test-zone-1a"),
+					InstanceType:     "c99.large",
+					SpotPrice:        aws.String(fmt.Sprint(spot)),
+					Timestamp:        &now,
+				}},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{fake.NewOnDemandPrice("c99.large", onDemand)},
+			})
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PriceChangeThreshold: lo.ToPtr(0.1)}))
+			priceChangeController = controllerspricing.NewController(env.Client, recorder, awsEnv.PricingProvider, awsEnv.CommitmentProvider)
+			nodePool = coretest.NodePool()
+			nodeClaims := []*karpv1.NodeClaim{
+				coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool.Name,
+					corev1.LabelInstanceTypeStable: "c99.large",
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				}}}),
+				coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool.Name,
+					corev1.LabelInstanceTypeStable: "c99.large",
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				}}}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1])
+			setPrices(1.00, 0.40)
+			ExpectSingletonReconciled(ctx, priceChangeController)
+		})
+		It("should not publish events for the first snapshot", func() {
+			Expect(recorder.Calls("PriceChanged")).To(Equal(0))
+		})
+		It("should publish events when prices change beyond the threshold", func() {
+			setPrices(1.25, 0.30)
+			ExpectSingletonReconciled(ctx, priceChangeController)
+			Expect(recorder.Calls("PriceChanged")).To(Equal(2))
+			Expect(recorder.DetectedEvent("Price of on-demand c99.large in test-zone-1a changed from 1.0000 to 1.2500 (+25.0%), affecting 1 nodes")).To(BeTrue())
+			Expect(recorder.DetectedEvent("Price of spot c99.large in test-zone-1a changed from 0.4000 to 0.3000 (-25.0%), affecting 1 nodes")).To(BeTrue())
+		})
+		It("should not publish events when prices change within the threshold", func() {
+			setPrices(1.05, 0.41)
+			ExpectSingletonReconciled(ctx, priceChangeController)
+			Expect(recorder.Calls("PriceChanged")).To(Equal(0))
+		})
+		It("should not publish events when the threshold is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			setPrices(2.00, 0.80)
+			ExpectSingletonReconciled(ctx, priceChangeController)
+			Expect(recorder.Calls("PriceChanged")).To(Equal(0))
+		})
+	})
 })
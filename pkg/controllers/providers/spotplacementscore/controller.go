@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore
+
+import (
+	"context"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
+)
+
+// Controller refreshes the spot placement scores of the instance families which spot instances are launched for, so
+// that launches read the scores without waiting for GetSpotPlacementScores. Families are refreshed shortly after
+// they're first launched, and again once their scores expire.
+type Controller struct {
+	spotPlacementScoreProvider spotplacementscore.Provider
+}
+
+func NewController(spotPlacementScoreProvider spotplacementscore.Provider) *Controller {
+	return &Controller{
+		spotPlacementScoreProvider: spotPlacementScoreProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.spotplacementscore")
+
+	if !options.FromContext(ctx).FeatureGates.SpotPlacementScores {
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	// Failures aren't retried with backoff, since the failed families aren't refreshed again until their scores expire
+	if err := c.spotPlacementScoreProvider.UpdateScores(ctx); err != nil {
+		log.FromContext(ctx).Error(err, "failed updating spot placement scores")
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.spotplacementscore").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllersspotplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotplacementscore"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllersspotplacementscore.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SpotPlacementScore")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllersspotplacementscore.NewController(awsEnv.SpotPlacementScoreProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPlacementScores: lo.ToPtr(true)}}))
+	awsEnv.Reset()
+	awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+		SpotPlacementScores: []ec2types.SpotPlacementScore{
+			{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(3)},
+			{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+		},
+	})
+})
+
+var _ = Describe("SpotPlacementScore", func() {
+	It("should refresh the scores of the instance families which were launched", func() {
+		Expect(awsEnv.SpotPlacementScoreProvider.Scores(ctx, []string{"m5.large", "m5.xlarge"})).To(BeEmpty())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Pop().InstanceTypes).To(ConsistOf("m5.large", "m5.xlarge"))
+		Expect(awsEnv.SpotPlacementScoreProvider.Scores(ctx, []string{"m5.large"})).To(Equal(map[string]int32{"tstz1-1a": 3, "tstz1-1b": 9}))
+	})
+	It("should not refresh scores which haven't expired", func() {
+		awsEnv.SpotPlacementScoreProvider.Scores(ctx, []string{"m5.large"})
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(1))
+	})
+	It("should not refresh scores when spot placement scores are disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPlacementScores: lo.ToPtr(false)}}))
+		awsEnv.SpotPlacementScoreProvider.Scores(ctx, []string{"m5.large"})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(0))
+	})
+})
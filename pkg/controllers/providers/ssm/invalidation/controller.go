@@ -83,6 +83,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		}
 		parameter := amiIDsToParameters[ami.AmiID]
 		c.cache.Delete(parameter.CacheKey())
+		ssm.ParameterInvalidationsTotal.Inc(map[string]string{"parameter": parameter.Name, "reason": ssm.InvalidationReasonDeprecated})
 	}
 	return reconcile.Result{RequeueAfter: 30 * time.Minute}, nil
 }
@@ -0,0 +1,218 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// Interval is the interval that the AWS client metrics are aggregated over and published at
+const Interval = time.Minute
+
+// The names of the metrics which are published for each AWS operation
+const (
+	Calls          = "Calls"
+	Attempts       = "Attempts"
+	Throttles      = "Throttles"
+	Errors         = "Errors"
+	LatencyAverage = "LatencyAverage"
+	LatencyMaximum = "LatencyMaximum"
+)
+
+type operation struct {
+	service string
+	name    string
+}
+
+type stats struct {
+	calls      int
+	attempts   int
+	throttles  int
+	errors     int
+	latencySum time.Duration
+	latencyMax time.Duration
+}
+
+// Publisher aggregates the calls that the AWS clients make to each operation, and writes them at each interval as
+// CloudWatch embedded metric format (EMF) records. CloudWatch Logs extracts the metrics from the records once they're
+// shipped to a log group, e.g. by the CloudWatch agent or Fluent Bit.
+type Publisher struct {
+	mu          sync.Mutex
+	writer      io.Writer
+	clock       clock.Clock
+	namespace   string
+	clusterName string
+	stats       map[operation]*stats
+}
+
+func NewPublisher(writer io.Writer, clk clock.Clock, namespace, clusterName string) *Publisher {
+	return &Publisher{
+		writer:      writer,
+		clock:       clk,
+		namespace:   namespace,
+		clusterName: clusterName,
+		stats:       map[operation]*stats{},
+	}
+}
+
+// APIOptions returns the smithy middleware stack mutators which record the calls of an AWS client. Calls are recorded
+// once they've been retried, while attempts and throttles are recorded for every attempt.
+func (p *Publisher) APIOptions() []func(*middleware.Stack) error {
+	return []func(*middleware.Stack) error{
+		func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("EMFCallMetrics", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := p.clock.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				p.ObserveCall(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), p.clock.Since(start), err)
+				return out, metadata, err
+			}), middleware.After)
+		},
+		func(stack *middleware.Stack) error {
+			return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("EMFAttemptMetrics", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleFinalize(ctx, in)
+				p.ObserveAttempt(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), err)
+				return out, metadata, err
+			}), "Retry", middleware.After)
+		},
+	}
+}
+
+// ObserveCall records a call to an operation, which took the latency including its retries
+func (p *Publisher) ObserveCall(service, name string, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.statsFor(service, name)
+	s.calls++
+	if err != nil {
+		s.errors++
+	}
+	s.latencySum += latency
+	if latency > s.latencyMax {
+		s.latencyMax = latency
+	}
+}
+
+// ObserveAttempt records an attempt of a call to an operation
+func (p *Publisher) ObserveAttempt(service, name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.statsFor(service, name)
+	s.attempts++
+	if awserrors.Classify(err) == awserrors.ClassThrottled {
+		s.throttles++
+	}
+}
+
+func (p *Publisher) statsFor(service, name string) *stats {
+	op := operation{service: service, name: name}
+	s, ok := p.stats[op]
+	if !ok {
+		s = &stats{}
+		p.stats[op] = s
+	}
+	return s
+}
+
+// Flush writes a record with the metrics of each operation which was called since the last flush
+func (p *Publisher) Flush() error {
+	p.mu.Lock()
+	current := p.stats
+	p.stats = map[operation]*stats{}
+	p.mu.Unlock()
+
+	ops := make([]operation, 0, len(current))
+	for op := range current {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].service < ops[j].service || (ops[i].service == ops[j].service && ops[i].name < ops[j].name)
+	})
+	timestamp := p.clock.Now().UnixMilli()
+	for _, op := range ops {
+		raw, err := json.Marshal(p.record(op, current[op], timestamp))
+		if err != nil {
+			return fmt.Errorf("marshaling emf record, %w", err)
+		}
+		if _, err := p.writer.Write(append(raw, '\n')); err != nil {
+			return fmt.Errorf("writing emf record, %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) record(op operation, s *stats, timestamp int64) map[string]any {
+	latencyAverage := float64(0)
+	if s.calls > 0 {
+		latencyAverage = float64(s.latencySum.Milliseconds()) / float64(s.calls)
+	}
+	return map[string]any{
+		"_aws": map[string]any{
+			"Timestamp": timestamp,
+			"CloudWatchMetrics": []map[string]any{{
+				"Namespace":  p.namespace,
+				"Dimensions": [][]string{{"ClusterName", "Service", "Operation"}},
+				"Metrics": []map[string]string{
+					{"Name": Calls, "Unit": "Count"},
+					{"Name": Attempts, "Unit": "Count"},
+					{"Name": Throttles, "Unit": "Count"},
+					{"Name": Errors, "Unit": "Count"},
+					{"Name": LatencyAverage, "Unit": "Milliseconds"},
+					{"Name": LatencyMaximum, "Unit": "Milliseconds"},
+				},
+			}},
+		},
+		"ClusterName":  p.clusterName,
+		"Service":      op.service,
+		"Operation":    op.name,
+		Calls:          s.calls,
+		Attempts:       s.attempts,
+		Throttles:      s.throttles,
+		Errors:         s.errors,
+		LatencyAverage: latencyAverage,
+		LatencyMaximum: s.latencyMax.Milliseconds(),
+	}
+}
+
+// Start flushes the metrics at each interval until the context is cancelled, and flushes them a final time on shutdown
+func (p *Publisher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return p.Flush()
+		case <-p.clock.After(Interval):
+			if err := p.Flush(); err != nil {
+				log.FromContext(ctx).Error(err, "failed publishing aws client metrics")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection is false so that every replica publishes the metrics of the calls that it makes
+func (p *Publisher) NeedLeaderElection() bool {
+	return false
+}
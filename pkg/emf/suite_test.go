@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/emf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EMF")
+}
+
+var _ = Describe("EMF", func() {
+	var fakeClock *clock.FakeClock
+	var buffer *bytes.Buffer
+	var publisher *emf.Publisher
+	records := func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			record := map[string]any{}
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		buffer = &bytes.Buffer{}
+		publisher = emf.NewPublisher(buffer, fakeClock, "Karpenter", "test-cluster")
+	})
+	It("should publish a record for each operation", func() {
+		publisher.ObserveAttempt("EC2", "CreateFleet", nil)
+		publisher.ObserveCall("EC2", "CreateFleet", 100*time.Millisecond, nil)
+		publisher.ObserveAttempt("EC2", "DescribeInstances", nil)
+		publisher.ObserveCall("EC2", "DescribeInstances", 20*time.Millisecond, nil)
+		Expect(publisher.Flush()).To(Succeed())
+
+		records := records()
+		Expect(records).To(HaveLen(2))
+		Expect(records[0]["Operation"]).To(Equal("CreateFleet"))
+		Expect(records[1]["Operation"]).To(Equal("DescribeInstances"))
+		Expect(records[0]["ClusterName"]).To(Equal("test-cluster"))
+		Expect(records[0]["Service"]).To(Equal("EC2"))
+		Expect(records[0][emf.Calls]).To(BeNumerically("==", 1))
+		Expect(records[0][emf.LatencyMaximum]).To(BeNumerically("==", 100))
+
+		metadata := records[0]["_aws"].(map[string]any)
+		Expect(metadata["Timestamp"]).To(BeNumerically("==", fakeClock.Now().UnixMilli()))
+		directive := metadata["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		Expect(directive["Namespace"]).To(Equal("Karpenter"))
+		Expect(directive["Dimensions"]).To(Equal([]any{[]any{"ClusterName", "Service", "Operation"}}))
+		Expect(directive["Metrics"]).To(HaveLen(6))
+	})
+	It("should count throttled attempts and failed calls", func() {
+		publisher.ObserveAttempt("EC2", "CreateFleet", &smithy.GenericAPIError{Code: "RequestLimitExceeded"})
+		publisher.ObserveAttempt("EC2", "CreateFleet", &smithy.GenericAPIError{Code: "RequestLimitExceeded"})
+		publisher.ObserveAttempt("EC2", "CreateFleet", &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+		publisher.ObserveCall("EC2", "CreateFleet", 3*time.Second, fmt.Errorf("failed"))
+		publisher.ObserveAttempt("EC2", "CreateFleet", nil)
+		publisher.ObserveCall("EC2", "CreateFleet", time.Second, nil)
+		Expect(publisher.Flush()).To(Succeed())
+
+		records := records()
+		Expect(records).To(HaveLen(1))
+		Expect(records[0][emf.Calls]).To(BeNumerically("==", 2))
+		Expect(records[0][emf.Attempts]).To(BeNumerically("==", 4))
+		Expect(records[0][emf.Throttles]).To(BeNumerically("==", 2))
+		Expect(records[0][emf.Errors]).To(BeNumerically("==", 1))
+		Expect(records[0][emf.LatencyAverage]).To(BeNumerically("==", 2000))
+		Expect(records[0][emf.LatencyMaximum]).To(BeNumerically("==", 3000))
+	})
+	It("should only publish the calls since the last flush", func() {
+		publisher.ObserveCall("EC2", "CreateFleet", time.Second, nil)
+		Expect(publisher.Flush()).To(Succeed())
+		buffer.Reset()
+		Expect(publisher.Flush()).To(Succeed())
+		Expect(records()).To(BeEmpty())
+	})
+})
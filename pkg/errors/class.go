@@ -0,0 +1,177 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Class is the category of an AWS error, which determines how the operation which returned it is retried
+type Class string
+
+const (
+	// ClassUnknown errors aren't AWS errors, or have an error code which isn't classified
+	ClassUnknown Class = "Unknown"
+	// ClassInsufficientCapacity errors signify that capacity is temporarily unable to be launched
+	ClassInsufficientCapacity Class = "InsufficientCapacity"
+	// ClassThrottled errors signify that the request rate exceeded the API limits
+	ClassThrottled Class = "Throttled"
+	// ClassTransient errors signify that the service failed to handle a request which may succeed when it's repeated
+	ClassTransient Class = "Transient"
+	// ClassUnauthorized errors signify that the controller isn't allowed to perform the operation
+	ClassUnauthorized Class = "Unauthorized"
+	// ClassInvalidParameter errors signify that the request is invalid, which is usually caused by the configuration
+	ClassInvalidParameter Class = "InvalidParameter"
+	// ClassDependencyViolation errors signify that a resource can't be changed or deleted while it's still in use
+	ClassDependencyViolation Class = "DependencyViolation"
+	// ClassNotFound errors signify that a resource doesn't exist
+	ClassNotFound Class = "NotFound"
+	// ClassAlreadyExists errors signify that a resource already exists
+	ClassAlreadyExists Class = "AlreadyExists"
+)
+
+// dependencyViolationRequeueInterval is the interval that operations which failed with a dependency violation are
+// retried at. The resources which depend on the resource are usually being deleted, so these operations succeed shortly.
+const dependencyViolationRequeueInterval = 30 * time.Second
+
+var (
+	// This is not an exhaustive list, add to it as needed
+	unauthorizedErrorCodes = sets.New[string](
+		"AccessDenied",
+		"AccessDeniedException",
+		"AuthFailure",
+		"UnauthorizedOperation",
+		"InvalidClientTokenId",
+		"UnrecognizedClientException",
+		"ExpiredToken",
+		"ExpiredTokenException",
+		"OptInRequired",
+	)
+	invalidParameterErrorCodes = sets.New[string](
+		"InvalidParameter",
+		"InvalidParameterCombination",
+		"InvalidParameterValue",
+		"MissingParameter",
+		"ValidationError",
+		"ValidationException",
+	)
+	dependencyViolationErrorCodes = sets.New[string](
+		"DependencyViolation",
+		"DeleteConflict",
+		"DeleteConflictException",
+		"ResourceInUse",
+		"ResourceInUseException",
+	)
+	transientErrorCodes = sets.New[string](
+		"InternalError",
+		"InternalFailure",
+		"ServiceUnavailable",
+		"Unavailable",
+	)
+)
+
+// Classify returns the class of the err if it's an AWS error (even if it's wrapped), or ClassUnknown otherwise
+func Classify(err error) Class {
+	if err == nil {
+		return ClassUnknown
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return ClassifyCode(apiErr.ErrorCode())
+	}
+	return ClassUnknown
+}
+
+// ClassifyCode returns the class of an AWS error code. Codes which are classified by more specific sets, such as
+// "InvalidInstanceID.NotFound", take precedence over the "Invalid" prefix which EC2 uses for invalid parameters.
+func ClassifyCode(code string) Class {
+	_, throttled := retry.DefaultThrottleErrorCodes[code]
+	_, retryable := retry.DefaultRetryableErrorCodes[code]
+	switch {
+	case notFoundErrorCodes.Has(code):
+		return ClassNotFound
+	case alreadyExistsErrorCodes.Has(code):
+		return ClassAlreadyExists
+	case unfulfillableCapacityErrorCodes.Has(code):
+		return ClassInsufficientCapacity
+	case throttled:
+		return ClassThrottled
+	case retryable, transientErrorCodes.Has(code):
+		return ClassTransient
+	case unauthorizedErrorCodes.Has(code):
+		return ClassUnauthorized
+	case dependencyViolationErrorCodes.Has(code):
+		return ClassDependencyViolation
+	case invalidParameterErrorCodes.Has(code), strings.HasPrefix(code, "Invalid"):
+		return ClassInvalidParameter
+	}
+	return ClassUnknown
+}
+
+// Retryable returns true if an operation which failed with an error of the class may succeed when it's repeated
+// without changes
+func (c Class) Retryable() bool {
+	switch c {
+	case ClassInsufficientCapacity, ClassThrottled, ClassTransient, ClassDependencyViolation:
+		return true
+	}
+	return false
+}
+
+// Terminal returns true if an operation which failed with an error of the class can't succeed until the configuration
+// or the controller's permissions change
+func (c Class) Terminal() bool {
+	switch c {
+	case ClassUnauthorized, ClassInvalidParameter:
+		return true
+	}
+	return false
+}
+
+// IsRetryable returns true if the err is an AWS error (even if it's wrapped) and the operation which returned it may
+// succeed when it's repeated without changes
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// IsTerminal returns true if the err is an AWS error (even if it's wrapped) and the operation which returned it can't
+// succeed until the configuration or the controller's permissions change
+func IsTerminal(err error) bool {
+	return Classify(err).Terminal()
+}
+
+// Requeue returns the result of a reconcile which failed with the err. Dependency violations are expected while the
+// resources which depend on a resource are deleted, so they're requeued after a delay rather than reported as errors.
+// All other errors are returned so that they're retried with the controller's exponential backoff, which also backs
+// off throttled and terminal errors.
+func Requeue(ctx context.Context, err error) (reconcile.Result, error) {
+	if err == nil {
+		return reconcile.Result{}, nil
+	}
+	if Classify(err) == ClassDependencyViolation {
+		log.FromContext(ctx).V(1).WithValues("error", err.Error()).Info("waiting on dependent resources")
+		return reconcile.Result{RequeueAfter: dependencyViolationRequeueInterval}, nil
+	}
+	return reconcile.Result{}, err
+}
@@ -24,6 +24,7 @@ import (
 
 const (
 	launchTemplateNameNotFoundCode = "InvalidLaunchTemplateName.NotFoundException"
+	dryRunOperationCode            = "DryRunOperation"
 )
 
 var (
@@ -33,7 +34,9 @@ var (
 		launchTemplateNameNotFoundCode,
 		"InvalidLaunchTemplateId.NotFound",
 		"QueueDoesNotExist",
+		"ReceiptHandleIsInvalid",
 		"NoSuchEntity",
+		"NoSuchResourceException",
 	)
 	alreadyExistsErrorCodes = sets.New[string](
 		"EntityAlreadyExists",
@@ -54,14 +57,7 @@ var (
 // wrapped) and is a known to mean "not found" (as opposed to a more
 // serious or unexpected error)
 func IsNotFound(err error) bool {
-	if err == nil {
-		return false
-	}
-	var apiErr smithy.APIError
-	if errors.As(err, &apiErr) {
-		return notFoundErrorCodes.Has(apiErr.ErrorCode())
-	}
-	return false
+	return Classify(err) == ClassNotFound
 }
 
 func IgnoreNotFound(err error) error {
@@ -72,14 +68,7 @@ func IgnoreNotFound(err error) error {
 }
 
 func IsAlreadyExists(err error) bool {
-	if err == nil {
-		return false
-	}
-	var apiErr smithy.APIError
-	if errors.As(err, &apiErr) {
-		return alreadyExistsErrorCodes.Has(apiErr.ErrorCode())
-	}
-	return false
+	return Classify(err) == ClassAlreadyExists
 }
 
 func IgnoreAlreadyExists(err error) error {
@@ -93,7 +82,7 @@ func IgnoreAlreadyExists(err error) error {
 // capacity is temporarily unavailable for launching.
 // This could be due to account limits, insufficient ec2 capacity, etc.
 func IsUnfulfillableCapacity(err ec2types.CreateFleetError) bool {
-	return unfulfillableCapacityErrorCodes.Has(*err.ErrorCode)
+	return ClassifyCode(*err.ErrorCode) == ClassInsufficientCapacity
 }
 
 func IsLaunchTemplateNotFound(err error) bool {
@@ -106,3 +95,22 @@ func IsLaunchTemplateNotFound(err error) bool {
 	}
 	return false
 }
+
+// IsDryRunError returns true if the err is the error that EC2 returns for a DryRun request which would have succeeded
+func IsDryRunError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == dryRunOperationCode
+	}
+	return false
+}
+
+func IgnoreDryRunError(err error) error {
+	if IsDryRunError(err) {
+		return nil
+	}
+	return err
+}
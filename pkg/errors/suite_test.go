@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestErrors(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Errors")
+}
+
+func apiError(code string) error {
+	return fmt.Errorf("calling api, %w", &smithy.GenericAPIError{Code: code, Message: "error"})
+}
+
+var _ = Describe("Errors", func() {
+	DescribeTable("should classify AWS error codes",
+		func(code string, class awserrors.Class) {
+			Expect(awserrors.Classify(apiError(code))).To(Equal(class))
+		},
+		Entry("insufficient capacity", "InsufficientInstanceCapacity", awserrors.ClassInsufficientCapacity),
+		Entry("vcpu limits", "VcpuLimitExceeded", awserrors.ClassInsufficientCapacity),
+		Entry("ec2 throttling", "RequestLimitExceeded", awserrors.ClassThrottled),
+		Entry("iam throttling", "Throttling", awserrors.ClassThrottled),
+		Entry("internal errors", "InternalError", awserrors.ClassTransient),
+		Entry("unauthorized operations", "UnauthorizedOperation", awserrors.ClassUnauthorized),
+		Entry("denied access", "AccessDenied", awserrors.ClassUnauthorized),
+		Entry("invalid parameter values", "InvalidParameterValue", awserrors.ClassInvalidParameter),
+		Entry("malformed ids", "InvalidAMIID.Malformed", awserrors.ClassInvalidParameter),
+		Entry("dependency violations", "DependencyViolation", awserrors.ClassDependencyViolation),
+		Entry("iam delete conflicts", "DeleteConflict", awserrors.ClassDependencyViolation),
+		Entry("missing instances", "InvalidInstanceID.NotFound", awserrors.ClassNotFound),
+		Entry("missing iam entities", "NoSuchEntity", awserrors.ClassNotFound),
+		Entry("existing iam entities", "EntityAlreadyExists", awserrors.ClassAlreadyExists),
+		Entry("unclassified codes", "SomethingUnexpected", awserrors.ClassUnknown),
+	)
+	It("should classify errors which aren't AWS errors as unknown", func() {
+		Expect(awserrors.Classify(nil)).To(Equal(awserrors.ClassUnknown))
+		Expect(awserrors.Classify(fmt.Errorf("failed"))).To(Equal(awserrors.ClassUnknown))
+	})
+	It("should only retry errors which may succeed without changes", func() {
+		Expect(awserrors.IsRetryable(apiError("RequestLimitExceeded"))).To(BeTrue())
+		Expect(awserrors.IsRetryable(apiError("InsufficientInstanceCapacity"))).To(BeTrue())
+		Expect(awserrors.IsRetryable(apiError("UnauthorizedOperation"))).To(BeFalse())
+		Expect(awserrors.IsRetryable(apiError("SomethingUnexpected"))).To(BeFalse())
+	})
+	It("should only consider errors caused by configuration or permissions to be terminal", func() {
+		Expect(awserrors.IsTerminal(apiError("UnauthorizedOperation"))).To(BeTrue())
+		Expect(awserrors.IsTerminal(apiError("InvalidParameterCombination"))).To(BeTrue())
+		Expect(awserrors.IsTerminal(apiError("RequestLimitExceeded"))).To(BeFalse())
+		Expect(awserrors.IsTerminal(apiError("InvalidInstanceID.NotFound"))).To(BeFalse())
+		Expect(awserrors.IsTerminal(fmt.Errorf("failed"))).To(BeFalse())
+	})
+	It("should classify fleet errors", func() {
+		Expect(awserrors.IsUnfulfillableCapacity(ec2types.CreateFleetError{ErrorCode: lo.ToPtr("InsufficientInstanceCapacity")})).To(BeTrue())
+		Expect(awserrors.IsUnfulfillableCapacity(ec2types.CreateFleetError{ErrorCode: lo.ToPtr("UnauthorizedOperation")})).To(BeFalse())
+	})
+	Context("Requeue", func() {
+		It("should requeue dependency violations after a delay without an error", func() {
+			res, err := awserrors.Requeue(context.Background(), apiError("DependencyViolation"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+		})
+		It("should return all other errors", func() {
+			for _, code := range []string{"RequestLimitExceeded", "UnauthorizedOperation", "SomethingUnexpected"} {
+				res, err := awserrors.Requeue(context.Background(), apiError(code))
+				Expect(err).To(HaveOccurred())
+				Expect(res.RequeueAfter).To(BeZero())
+			}
+		})
+		It("should not requeue when there's no error", func() {
+			res, err := awserrors.Requeue(context.Background(), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.IsZero()).To(BeTrue())
+		})
+	})
+})
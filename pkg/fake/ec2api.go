@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 
 	"sigs.k8s.io/karpenter/pkg/test"
@@ -52,19 +53,38 @@ type EC2Behavior struct {
 	DescribeSecurityGroupsOutput        AtomicPtr[ec2.DescribeSecurityGroupsOutput]
 	DescribeInstanceTypesOutput         AtomicPtr[ec2.DescribeInstanceTypesOutput]
 	DescribeInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
-	DescribeAvailabilityZonesOutput     AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
-	DescribeSpotPriceHistoryInput       AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
-	DescribeSpotPriceHistoryOutput      AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
-	CreateFleetBehavior                 MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
-	TerminateInstancesBehavior          MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
-	DescribeInstancesBehavior           MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
-	CreateTagsBehavior                  MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
-	CalledWithCreateLaunchTemplateInput AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
-	CalledWithDescribeImagesInput       AtomicPtrSlice[ec2.DescribeImagesInput]
-	Instances                           sync.Map
-	LaunchTemplates                     sync.Map
-	InsufficientCapacityPools           atomic.Slice[CapacityPool]
-	NextError                           AtomicError
+	// DescribeOutpostInstanceTypeOfferingsOutput is returned for offerings with the outpost location type, which are
+	// otherwise empty
+	DescribeOutpostInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
+	DescribeAvailabilityZonesOutput            AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
+	DescribeSpotPriceHistoryInput              AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
+	DescribeSpotPriceHistoryOutput             AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
+	CreateFleetBehavior                        MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
+	TerminateInstancesBehavior                 MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
+	StartInstancesBehavior                     MockedFunction[ec2.StartInstancesInput, ec2.StartInstancesOutput]
+	StopInstancesBehavior                      MockedFunction[ec2.StopInstancesInput, ec2.StopInstancesOutput]
+	RebootInstancesBehavior                    MockedFunction[ec2.RebootInstancesInput, ec2.RebootInstancesOutput]
+	ModifyInstanceAttributeBehavior            MockedFunction[ec2.ModifyInstanceAttributeInput, ec2.ModifyInstanceAttributeOutput]
+	DescribeInstancesBehavior                  MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
+	CreateTagsBehavior                         MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
+	DeleteTagsBehavior                         MockedFunction[ec2.DeleteTagsInput, ec2.DeleteTagsOutput]
+	DescribeFastLaunchImagesOutput             AtomicPtr[ec2.DescribeFastLaunchImagesOutput]
+	EnableFastLaunchBehavior                   MockedFunction[ec2.EnableFastLaunchInput, ec2.EnableFastLaunchOutput]
+	DescribeVpcEndpointsOutput                 AtomicPtr[ec2.DescribeVpcEndpointsOutput]
+	DescribeVpcsOutput                         AtomicPtr[ec2.DescribeVpcsOutput]
+	DescribeDhcpOptionsOutput                  AtomicPtr[ec2.DescribeDhcpOptionsOutput]
+	DescribeCapacityReservationsOutput         AtomicPtr[ec2.DescribeCapacityReservationsOutput]
+	DescribePlacementGroupsOutput              AtomicPtr[ec2.DescribePlacementGroupsOutput]
+	GetSpotPlacementScoresBehavior             MockedFunction[ec2.GetSpotPlacementScoresInput, ec2.GetSpotPlacementScoresOutput]
+	DescribeReservedInstancesBehavior          MockedFunction[ec2.DescribeReservedInstancesInput, ec2.DescribeReservedInstancesOutput]
+	ModifyVolumeBehavior                       MockedFunction[ec2.ModifyVolumeInput, ec2.ModifyVolumeOutput]
+	CalledWithCreateLaunchTemplateInput        AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
+	CalledWithDescribeImagesInput              AtomicPtrSlice[ec2.DescribeImagesInput]
+	Instances                                  sync.Map
+	LaunchTemplates                            sync.Map
+	Volumes                                    sync.Map
+	InsufficientCapacityPools                  atomic.Slice[CapacityPool]
+	NextError                                  AtomicError
 }
 
 type EC2API struct {
@@ -88,10 +108,26 @@ func (e *EC2API) Reset() {
 	e.DescribeSecurityGroupsOutput.Reset()
 	e.DescribeInstanceTypesOutput.Reset()
 	e.DescribeInstanceTypeOfferingsOutput.Reset()
+	e.DescribeOutpostInstanceTypeOfferingsOutput.Reset()
 	e.DescribeAvailabilityZonesOutput.Reset()
 	e.CreateFleetBehavior.Reset()
 	e.TerminateInstancesBehavior.Reset()
+	e.StartInstancesBehavior.Reset()
+	e.StopInstancesBehavior.Reset()
+	e.RebootInstancesBehavior.Reset()
+	e.ModifyInstanceAttributeBehavior.Reset()
 	e.DescribeInstancesBehavior.Reset()
+	e.DeleteTagsBehavior.Reset()
+	e.DescribeFastLaunchImagesOutput.Reset()
+	e.EnableFastLaunchBehavior.Reset()
+	e.DescribeVpcEndpointsOutput.Reset()
+	e.DescribeVpcsOutput.Reset()
+	e.DescribeDhcpOptionsOutput.Reset()
+	e.DescribeCapacityReservationsOutput.Reset()
+	e.DescribePlacementGroupsOutput.Reset()
+	e.GetSpotPlacementScoresBehavior.Reset()
+	e.DescribeReservedInstancesBehavior.Reset()
+	e.ModifyVolumeBehavior.Reset()
 	e.CalledWithCreateLaunchTemplateInput.Reset()
 	e.CalledWithDescribeImagesInput.Reset()
 	e.DescribeSpotPriceHistoryInput.Reset()
@@ -104,6 +140,10 @@ func (e *EC2API) Reset() {
 		e.LaunchTemplates.Delete(k)
 		return true
 	})
+	e.Volumes.Range(func(k, v any) bool {
+		e.Volumes.Delete(k)
+		return true
+	})
 	e.InsufficientCapacityPools.Reset()
 	e.NextError.Reset()
 }
@@ -111,6 +151,12 @@ func (e *EC2API) Reset() {
 // nolint: gocyclo
 func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ ...func(*ec2.Options)) (*ec2.CreateFleetOutput, error) {
 	return e.CreateFleetBehavior.Invoke(input, func(input *ec2.CreateFleetInput) (*ec2.CreateFleetOutput, error) {
+		if lo.FromPtr(input.DryRun) {
+			return nil, &smithy.GenericAPIError{
+				Code:    "DryRunOperation",
+				Message: "Request would have succeeded, but DryRun flag is set.",
+			}
+		}
 		if input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName == nil {
 			return nil, fmt.Errorf("missing launch template name")
 		}
@@ -154,6 +200,7 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 						PrivateDnsName:        aws.String(randomdata.IpV4Address()),
 						InstanceType:          input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
 						SpotInstanceRequestId: spotInstanceRequestID,
+						InstanceLifecycle:     lo.Ternary(string(input.TargetCapacitySpecification.DefaultTargetCapacityType) == v1.CapacityTypeCapacityBlock, ec2types.InstanceLifecycleType(v1.CapacityTypeCapacityBlock), ""),
 						State: &ec2types.InstanceState{
 							Name: instanceState,
 						},
@@ -200,10 +247,15 @@ func (e *EC2API) TerminateInstances(_ context.Context, input *ec2.TerminateInsta
 	return e.TerminateInstancesBehavior.Invoke(input, func(input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
 		var instanceStateChanges []ec2types.InstanceStateChange
 		for _, id := range input.InstanceIds {
-			if _, ok := e.Instances.LoadAndDelete(id); ok {
+			if raw, ok := e.Instances.Load(id); ok {
+				// Terminated instances continue to be described, like they are by EC2
+				instance := raw.(ec2types.Instance)
+				previousState := lo.FromPtrOr(instance.State, ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning, Code: aws.Int32(16)})
+				instance.State = &ec2types.InstanceState{Name: ec2types.InstanceStateNameTerminated, Code: aws.Int32(48)}
+				e.Instances.Store(id, instance)
 				instanceStateChanges = append(instanceStateChanges, ec2types.InstanceStateChange{
-					PreviousState: &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning, Code: aws.Int32(16)},
-					CurrentState:  &ec2types.InstanceState{Name: ec2types.InstanceStateNameShuttingDown, Code: aws.Int32(32)},
+					PreviousState: &previousState,
+					CurrentState:  lo.Ternary(previousState.Name == ec2types.InstanceStateNameTerminated, instance.State, &ec2types.InstanceState{Name: ec2types.InstanceStateNameShuttingDown, Code: aws.Int32(32)}),
 					InstanceId:    aws.String(id),
 				})
 			}
@@ -212,6 +264,51 @@ func (e *EC2API) TerminateInstances(_ context.Context, input *ec2.TerminateInsta
 	})
 }
 
+func (e *EC2API) StartInstances(_ context.Context, input *ec2.StartInstancesInput, _ ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return e.StartInstancesBehavior.Invoke(input, func(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+		return &ec2.StartInstancesOutput{StartingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNamePending)}, nil
+	})
+}
+
+func (e *EC2API) StopInstances(_ context.Context, input *ec2.StopInstancesInput, _ ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return e.StopInstancesBehavior.Invoke(input, func(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+		return &ec2.StopInstancesOutput{StoppingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNameStopped)}, nil
+	})
+}
+
+func (e *EC2API) RebootInstances(_ context.Context, input *ec2.RebootInstancesInput, _ ...func(*ec2.Options)) (*ec2.RebootInstancesOutput, error) {
+	return e.RebootInstancesBehavior.Invoke(input, func(input *ec2.RebootInstancesInput) (*ec2.RebootInstancesOutput, error) {
+		return &ec2.RebootInstancesOutput{}, nil
+	})
+}
+
+func (e *EC2API) ModifyInstanceAttribute(_ context.Context, input *ec2.ModifyInstanceAttributeInput, _ ...func(*ec2.Options)) (*ec2.ModifyInstanceAttributeOutput, error) {
+	return e.ModifyInstanceAttributeBehavior.Invoke(input, func(input *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+		return &ec2.ModifyInstanceAttributeOutput{}, nil
+	})
+}
+
+// setInstanceStates moves the instances with the passed ids to the state, and returns their state changes
+func (e *EC2API) setInstanceStates(ids []string, state ec2types.InstanceStateName) []ec2types.InstanceStateChange {
+	var instanceStateChanges []ec2types.InstanceStateChange
+	for _, id := range ids {
+		raw, ok := e.Instances.Load(id)
+		if !ok {
+			continue
+		}
+		instance := raw.(ec2types.Instance)
+		previousState := lo.FromPtr(instance.State)
+		instance.State = &ec2types.InstanceState{Name: state}
+		e.Instances.Store(id, instance)
+		instanceStateChanges = append(instanceStateChanges, ec2types.InstanceStateChange{
+			PreviousState: &previousState,
+			CurrentState:  instance.State,
+			InstanceId:    aws.String(id),
+		})
+	}
+	return instanceStateChanges
+}
+
 func (e *EC2API) CreateLaunchTemplate(_ context.Context, input *ec2.CreateLaunchTemplateInput, _ ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -247,6 +344,24 @@ func (e *EC2API) CreateTags(_ context.Context, input *ec2.CreateTagsInput, _ ...
 	})
 }
 
+func (e *EC2API) DeleteTags(_ context.Context, input *ec2.DeleteTagsInput, _ ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return e.DeleteTagsBehavior.Invoke(input, func(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+		// Remove the passed tag keys from the passed in instances
+		for _, id := range input.Resources {
+			raw, ok := e.Instances.Load(id)
+			if !ok {
+				return nil, fmt.Errorf("instance with id '%s' does not exist", id)
+			}
+			instance := raw.(ec2types.Instance)
+			instance.Tags = lo.Reject(instance.Tags, func(tag ec2types.Tag, _ int) bool {
+				return lo.ContainsBy(input.Tags, func(t ec2types.Tag) bool { return lo.FromPtr(t.Key) == lo.FromPtr(tag.Key) })
+			})
+			e.Instances.Swap(lo.FromPtr(instance.InstanceId), instance)
+		}
+		return nil, nil
+	})
+}
+
 func (e *EC2API) DescribeInstances(_ context.Context, input *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return e.DescribeInstancesBehavior.Invoke(input, func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 		var instances []ec2types.Instance
@@ -326,6 +441,7 @@ func (e *EC2API) DescribeImages(_ context.Context, input *ec2.DescribeImagesInpu
 	if !e.DescribeImagesOutput.IsNil() {
 		describeImagesOutput := e.DescribeImagesOutput.Clone()
 		describeImagesOutput.Images = FilterDescribeImages(describeImagesOutput.Images, input.Filters)
+		describeImagesOutput.Images = FilterDescribeImagesByOwners(describeImagesOutput.Images, input.Owners)
 		return describeImagesOutput, nil
 	}
 	if input.Filters[0].Values[0] == "invalid" {
@@ -343,6 +459,169 @@ func (e *EC2API) DescribeImages(_ context.Context, input *ec2.DescribeImagesInpu
 	}, nil
 }
 
+func (e *EC2API) DescribeFastLaunchImages(_ context.Context, input *ec2.DescribeFastLaunchImagesInput, _ ...func(*ec2.Options)) (*ec2.DescribeFastLaunchImagesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeFastLaunchImagesOutput.IsNil() {
+		return &ec2.DescribeFastLaunchImagesOutput{}, nil
+	}
+	output := e.DescribeFastLaunchImagesOutput.Clone()
+	output.FastLaunchImages = lo.Filter(output.FastLaunchImages, func(image ec2types.DescribeFastLaunchImagesSuccessItem, _ int) bool {
+		return len(input.ImageIds) == 0 || lo.Contains(input.ImageIds, lo.FromPtr(image.ImageId))
+	})
+	return output, nil
+}
+
+func (e *EC2API) DescribeVpcEndpoints(_ context.Context, input *ec2.DescribeVpcEndpointsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeVpcEndpointsOutput.IsNil() {
+		return &ec2.DescribeVpcEndpointsOutput{}, nil
+	}
+	output := e.DescribeVpcEndpointsOutput.Clone()
+	output.VpcEndpoints = lo.Filter(output.VpcEndpoints, func(endpoint ec2types.VpcEndpoint, _ int) bool {
+		for _, filter := range input.Filters {
+			if lo.FromPtr(filter.Name) == "vpc-id" && !lo.Contains(filter.Values, lo.FromPtr(endpoint.VpcId)) {
+				return false
+			}
+		}
+		return true
+	})
+	return output, nil
+}
+
+func (e *EC2API) DescribeVpcs(_ context.Context, input *ec2.DescribeVpcsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeVpcsOutput.IsNil() {
+		return &ec2.DescribeVpcsOutput{}, nil
+	}
+	output := e.DescribeVpcsOutput.Clone()
+	output.Vpcs = lo.Filter(output.Vpcs, func(vpc ec2types.Vpc, _ int) bool {
+		return len(input.VpcIds) == 0 || lo.Contains(input.VpcIds, lo.FromPtr(vpc.VpcId))
+	})
+	return output, nil
+}
+
+func (e *EC2API) DescribeDhcpOptions(_ context.Context, input *ec2.DescribeDhcpOptionsInput, _ ...func(*ec2.Options)) (*ec2.DescribeDhcpOptionsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeDhcpOptionsOutput.IsNil() {
+		return &ec2.DescribeDhcpOptionsOutput{}, nil
+	}
+	output := e.DescribeDhcpOptionsOutput.Clone()
+	output.DhcpOptions = lo.Filter(output.DhcpOptions, func(options ec2types.DhcpOptions, _ int) bool {
+		return len(input.DhcpOptionsIds) == 0 || lo.Contains(input.DhcpOptionsIds, lo.FromPtr(options.DhcpOptionsId))
+	})
+	return output, nil
+}
+
+func (e *EC2API) DescribeCapacityReservations(_ context.Context, input *ec2.DescribeCapacityReservationsInput, _ ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeCapacityReservationsOutput.IsNil() {
+		return &ec2.DescribeCapacityReservationsOutput{}, nil
+	}
+	output := e.DescribeCapacityReservationsOutput.Clone()
+	output.CapacityReservations = lo.Filter(output.CapacityReservations, func(reservation ec2types.CapacityReservation, _ int) bool {
+		if len(input.CapacityReservationIds) > 0 && !lo.Contains(input.CapacityReservationIds, lo.FromPtr(reservation.CapacityReservationId)) {
+			return false
+		}
+		return Filter(input.Filters, lo.FromPtr(reservation.CapacityReservationId), "", reservation.Tags)
+	})
+	return output, nil
+}
+
+func (e *EC2API) DescribePlacementGroups(_ context.Context, input *ec2.DescribePlacementGroupsInput, _ ...func(*ec2.Options)) (*ec2.DescribePlacementGroupsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribePlacementGroupsOutput.IsNil() {
+		return &ec2.DescribePlacementGroupsOutput{}, nil
+	}
+	output := e.DescribePlacementGroupsOutput.Clone()
+	output.PlacementGroups = lo.Filter(output.PlacementGroups, func(placementGroup ec2types.PlacementGroup, _ int) bool {
+		return Filter(input.Filters, lo.FromPtr(placementGroup.GroupId), lo.FromPtr(placementGroup.GroupName), placementGroup.Tags)
+	})
+	return output, nil
+}
+
+// GetSpotPlacementScores returns no scores by default, which doesn't prioritize any zone
+func (e *EC2API) GetSpotPlacementScores(_ context.Context, input *ec2.GetSpotPlacementScoresInput, _ ...func(*ec2.Options)) (*ec2.GetSpotPlacementScoresOutput, error) {
+	return e.GetSpotPlacementScoresBehavior.Invoke(input, func(_ *ec2.GetSpotPlacementScoresInput) (*ec2.GetSpotPlacementScoresOutput, error) {
+		return &ec2.GetSpotPlacementScoresOutput{}, nil
+	})
+}
+
+// DescribeReservedInstances returns no reserved instances by default
+func (e *EC2API) DescribeReservedInstances(_ context.Context, input *ec2.DescribeReservedInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error) {
+	return e.DescribeReservedInstancesBehavior.Invoke(input, func(_ *ec2.DescribeReservedInstancesInput) (*ec2.DescribeReservedInstancesOutput, error) {
+		return &ec2.DescribeReservedInstancesOutput{}, nil
+	})
+}
+
+// DescribeVolumes returns the stored volumes, filtered by the instance that they're attached to
+func (e *EC2API) DescribeVolumes(_ context.Context, input *ec2.DescribeVolumesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	instanceIDs := lo.FlatMap(input.Filters, func(f ec2types.Filter, _ int) []string {
+		return lo.Ternary(lo.FromPtr(f.Name) == "attachment.instance-id", f.Values, nil)
+	})
+	var volumes []ec2types.Volume
+	e.Volumes.Range(func(_, v any) bool {
+		volume := v.(ec2types.Volume)
+		if len(instanceIDs) == 0 || lo.ContainsBy(volume.Attachments, func(a ec2types.VolumeAttachment) bool {
+			return lo.Contains(instanceIDs, lo.FromPtr(a.InstanceId))
+		}) {
+			volumes = append(volumes, volume)
+		}
+		return true
+	})
+	return &ec2.DescribeVolumesOutput{Volumes: volumes}, nil
+}
+
+// ModifyVolume updates the size of a stored volume
+func (e *EC2API) ModifyVolume(_ context.Context, input *ec2.ModifyVolumeInput, _ ...func(*ec2.Options)) (*ec2.ModifyVolumeOutput, error) {
+	return e.ModifyVolumeBehavior.Invoke(input, func(input *ec2.ModifyVolumeInput) (*ec2.ModifyVolumeOutput, error) {
+		raw, ok := e.Volumes.Load(lo.FromPtr(input.VolumeId))
+		if !ok {
+			return nil, &smithy.GenericAPIError{Code: "InvalidVolume.NotFound"}
+		}
+		volume := raw.(ec2types.Volume)
+		volume.Size = input.Size
+		e.Volumes.Store(lo.FromPtr(input.VolumeId), volume)
+		return &ec2.ModifyVolumeOutput{VolumeModification: &ec2types.VolumeModification{
+			VolumeId:          input.VolumeId,
+			TargetSize:        input.Size,
+			ModificationState: ec2types.VolumeModificationStateModifying,
+		}}, nil
+	})
+}
+
+func (e *EC2API) EnableFastLaunch(_ context.Context, input *ec2.EnableFastLaunchInput, _ ...func(*ec2.Options)) (*ec2.EnableFastLaunchOutput, error) {
+	return e.EnableFastLaunchBehavior.Invoke(input, func(input *ec2.EnableFastLaunchInput) (*ec2.EnableFastLaunchOutput, error) {
+		return &ec2.EnableFastLaunchOutput{
+			ImageId:             input.ImageId,
+			ResourceType:        ec2types.FastLaunchResourceTypeSnapshot,
+			MaxParallelLaunches: input.MaxParallelLaunches,
+			State:               ec2types.FastLaunchStateCodeEnabling,
+		}, nil
+	})
+}
+
 func (e *EC2API) DescribeLaunchTemplates(_ context.Context, input *ec2.DescribeLaunchTemplatesInput, _ ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplatesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -354,12 +633,12 @@ func (e *EC2API) DescribeLaunchTemplates(_ context.Context, input *ec2.DescribeL
 	output := &ec2.DescribeLaunchTemplatesOutput{}
 	e.LaunchTemplates.Range(func(key, value interface{}) bool {
 		launchTemplate := value.(ec2types.LaunchTemplate)
-		if lo.Contains(aws.StringSlice(input.LaunchTemplateNames), launchTemplate.LaunchTemplateName) || len(input.Filters) != 0 && Filter(input.Filters, aws.ToString(launchTemplate.LaunchTemplateId), aws.ToString(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
+		if len(input.LaunchTemplateNames) == 0 && len(input.Filters) == 0 || lo.Contains(aws.StringSlice(input.LaunchTemplateNames), launchTemplate.LaunchTemplateName) || len(input.Filters) != 0 && Filter(input.Filters, aws.ToString(launchTemplate.LaunchTemplateId), aws.ToString(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
 			output.LaunchTemplates = append(output.LaunchTemplates, launchTemplate)
 		}
 		return true
 	})
-	if len(input.Filters) != 0 {
+	if len(input.LaunchTemplateNames) == 0 {
 		return output, nil
 	}
 	if len(output.LaunchTemplates) == 0 {
@@ -393,6 +672,7 @@ func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsIn
 	subnets := []ec2types.Subnet{
 		{
 			SubnetId:                aws.String("subnet-test1"),
+			VpcId:                   aws.String("vpc-test1"),
 			AvailabilityZone:        aws.String("test-zone-1a"),
 			AvailabilityZoneId:      aws.String("tstz1-1a"),
 			AvailableIpAddressCount: aws.Int32(100),
@@ -404,6 +684,7 @@ func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsIn
 		},
 		{
 			SubnetId:                aws.String("subnet-test2"),
+			VpcId:                   aws.String("vpc-test1"),
 			AvailabilityZone:        aws.String("test-zone-1b"),
 			AvailabilityZoneId:      aws.String("tstz1-1b"),
 			AvailableIpAddressCount: aws.Int32(100),
@@ -415,6 +696,7 @@ func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsIn
 		},
 		{
 			SubnetId:                aws.String("subnet-test3"),
+			VpcId:                   aws.String("vpc-test1"),
 			AvailabilityZone:        aws.String("test-zone-1c"),
 			AvailabilityZoneId:      aws.String("tstz1-1c"),
 			AvailableIpAddressCount: aws.Int32(100),
@@ -426,6 +708,7 @@ func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsIn
 		},
 		{
 			SubnetId:                aws.String("subnet-test4"),
+			VpcId:                   aws.String("vpc-test1"),
 			AvailabilityZone:        aws.String("test-zone-1a-local"),
 			AvailabilityZoneId:      aws.String("tstz1-1alocal"),
 			AvailableIpAddressCount: aws.Int32(100),
@@ -496,7 +779,7 @@ func (e *EC2API) DescribeAvailabilityZones(context.Context, *ec2.DescribeAvailab
 		{ZoneName: aws.String("test-zone-1a"), ZoneId: aws.String("tstz1-1a"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1b"), ZoneId: aws.String("tstz1-1b"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1c"), ZoneId: aws.String("tstz1-1c"), ZoneType: aws.String("availability-zone")},
-		{ZoneName: aws.String("test-zone-1a-local"), ZoneId: aws.String("tstz1-1alocal"), ZoneType: aws.String("local-zone")},
+		{ZoneName: aws.String("test-zone-1a-local"), ZoneId: aws.String("tstz1-1alocal"), ZoneType: aws.String("local-zone"), ParentZoneName: aws.String("test-zone-1a")},
 	}}, nil
 }
 
@@ -511,11 +794,17 @@ func (e *EC2API) DescribeInstanceTypes(_ context.Context, _ *ec2.DescribeInstanc
 	return defaultDescribeInstanceTypesOutput, nil
 }
 
-func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, _ *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
 	}
+	if input.LocationType == ec2types.LocationTypeOutpost {
+		if !e.DescribeOutpostInstanceTypeOfferingsOutput.IsNil() {
+			return e.DescribeOutpostInstanceTypeOfferingsOutput.Clone(), nil
+		}
+		return &ec2.DescribeInstanceTypeOfferingsOutput{}, nil
+	}
 	if !e.DescribeInstanceTypeOfferingsOutput.IsNil() {
 		return e.DescribeInstanceTypeOfferingsOutput.Clone(), nil
 	}
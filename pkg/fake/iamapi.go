@@ -25,6 +25,7 @@ import (
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/aws/smithy-go"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
@@ -40,6 +41,7 @@ type IAMAPIBehavior struct {
 	AddRoleToInstanceProfileBehavior      MockedFunction[iam.AddRoleToInstanceProfileInput, iam.AddRoleToInstanceProfileOutput]
 	TagInstanceProfileBehavior            MockedFunction[iam.TagInstanceProfileInput, iam.TagInstanceProfileOutput]
 	RemoveRoleFromInstanceProfileBehavior MockedFunction[iam.RemoveRoleFromInstanceProfileInput, iam.RemoveRoleFromInstanceProfileOutput]
+	SimulatePrincipalPolicyBehavior       MockedFunction[iam.SimulatePrincipalPolicyInput, iam.SimulatePrincipalPolicyOutput]
 }
 
 type IAMAPI struct {
@@ -49,10 +51,12 @@ type IAMAPI struct {
 	IAMAPIBehavior
 
 	InstanceProfiles map[string]*iamtypes.InstanceProfile
+	// DeniedActions are the actions which the policy simulator evaluates as denied for every role
+	DeniedActions sets.Set[string]
 }
 
 func NewIAMAPI() *IAMAPI {
-	return &IAMAPI{InstanceProfiles: map[string]*iamtypes.InstanceProfile{}}
+	return &IAMAPI{InstanceProfiles: map[string]*iamtypes.InstanceProfile{}, DeniedActions: sets.New[string]()}
 }
 
 func (s *IAMAPI) Reset() {
@@ -61,6 +65,8 @@ func (s *IAMAPI) Reset() {
 	s.DeleteInstanceProfileBehavior.Reset()
 	s.AddRoleToInstanceProfileBehavior.Reset()
 	s.RemoveRoleFromInstanceProfileBehavior.Reset()
+	s.SimulatePrincipalPolicyBehavior.Reset()
+	s.DeniedActions = sets.New[string]()
 	s.InstanceProfiles = map[string]*iamtypes.InstanceProfile{}
 }
 
@@ -160,7 +166,11 @@ func (s *IAMAPI) AddRoleToInstanceProfile(_ context.Context, input *iam.AddRoleT
 						aws.ToString(input.InstanceProfileName)),
 				}
 			}
-			i.Roles = append(i.Roles, iamtypes.Role{RoleId: aws.String(RoleID()), RoleName: input.RoleName})
+			i.Roles = append(i.Roles, iamtypes.Role{
+				RoleId:   aws.String(RoleID()),
+				RoleName: input.RoleName,
+				Arn:      aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", DefaultAccount, aws.ToString(input.RoleName))),
+			})
 			return nil, nil
 		}
 		return nil, &smithy.GenericAPIError{
@@ -197,3 +207,19 @@ func (s *IAMAPI) RemoveRoleFromInstanceProfile(_ context.Context, input *iam.Rem
 		}
 	})
 }
+
+func (s *IAMAPI) SimulatePrincipalPolicy(_ context.Context, input *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	return s.SimulatePrincipalPolicyBehavior.Invoke(input, func(input *iam.SimulatePrincipalPolicyInput) (*iam.SimulatePrincipalPolicyOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		return &iam.SimulatePrincipalPolicyOutput{
+			EvaluationResults: lo.Map(input.ActionNames, func(action string, _ int) iamtypes.EvaluationResult {
+				return iamtypes.EvaluationResult{
+					EvalActionName: aws.String(action),
+					EvalDecision:   lo.Ternary(s.DeniedActions.Has(action), iamtypes.PolicyEvaluationDecisionTypeImplicitDeny, iamtypes.PolicyEvaluationDecisionTypeAllowed),
+				}
+			}),
+		}, nil
+	})
+}
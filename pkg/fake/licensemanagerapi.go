@@ -0,0 +1,55 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/licensemanager"
+	licensemanagertypes "github.com/aws/aws-sdk-go-v2/service/licensemanager/types"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// LicenseManagerAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type LicenseManagerAPIBehavior struct {
+	GetLicenseConfigurationBehavior MockedFunction[licensemanager.GetLicenseConfigurationInput, licensemanager.GetLicenseConfigurationOutput]
+}
+
+type LicenseManagerAPI struct {
+	sdk.LicenseManagerAPI
+	LicenseManagerAPIBehavior
+}
+
+func NewLicenseManagerAPI() *LicenseManagerAPI {
+	return &LicenseManagerAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (l *LicenseManagerAPI) Reset() {
+	l.GetLicenseConfigurationBehavior.Reset()
+}
+
+// GetLicenseConfiguration returns a license configuration without a license count by default, which never limits launches
+func (l *LicenseManagerAPI) GetLicenseConfiguration(_ context.Context, input *licensemanager.GetLicenseConfigurationInput, _ ...func(*licensemanager.Options)) (*licensemanager.GetLicenseConfigurationOutput, error) {
+	return l.GetLicenseConfigurationBehavior.Invoke(input, func(input *licensemanager.GetLicenseConfigurationInput) (*licensemanager.GetLicenseConfigurationOutput, error) {
+		return &licensemanager.GetLicenseConfigurationOutput{
+			LicenseConfigurationArn: input.LicenseConfigurationArn,
+			LicenseCountingType:     licensemanagertypes.LicenseCountingTypeInstance,
+		}, nil
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// SavingsPlansAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type SavingsPlansAPIBehavior struct {
+	DescribeSavingsPlansBehavior     MockedFunction[savingsplans.DescribeSavingsPlansInput, savingsplans.DescribeSavingsPlansOutput]
+	DescribeSavingsPlanRatesBehavior MockedFunction[savingsplans.DescribeSavingsPlanRatesInput, savingsplans.DescribeSavingsPlanRatesOutput]
+}
+
+type SavingsPlansAPI struct {
+	sdk.SavingsPlansAPI
+	SavingsPlansAPIBehavior
+}
+
+func NewSavingsPlansAPI() *SavingsPlansAPI {
+	return &SavingsPlansAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *SavingsPlansAPI) Reset() {
+	s.DescribeSavingsPlansBehavior.Reset()
+	s.DescribeSavingsPlanRatesBehavior.Reset()
+}
+
+// DescribeSavingsPlans returns no savings plans by default
+func (s *SavingsPlansAPI) DescribeSavingsPlans(_ context.Context, input *savingsplans.DescribeSavingsPlansInput, _ ...func(*savingsplans.Options)) (*savingsplans.DescribeSavingsPlansOutput, error) {
+	return s.DescribeSavingsPlansBehavior.Invoke(input, func(_ *savingsplans.DescribeSavingsPlansInput) (*savingsplans.DescribeSavingsPlansOutput, error) {
+		return &savingsplans.DescribeSavingsPlansOutput{}, nil
+	})
+}
+
+// DescribeSavingsPlanRates returns no rates by default
+func (s *SavingsPlansAPI) DescribeSavingsPlanRates(_ context.Context, input *savingsplans.DescribeSavingsPlanRatesInput, _ ...func(*savingsplans.Options)) (*savingsplans.DescribeSavingsPlanRatesOutput, error) {
+	return s.DescribeSavingsPlanRatesBehavior.Invoke(input, func(_ *savingsplans.DescribeSavingsPlanRatesInput) (*savingsplans.DescribeSavingsPlanRatesOutput, error) {
+		return &savingsplans.DescribeSavingsPlanRatesOutput{}, nil
+	})
+}
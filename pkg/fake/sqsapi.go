@@ -21,6 +21,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+
+	"sigs.k8s.io/karpenter/pkg/test"
 )
 
 const (
@@ -30,9 +32,11 @@ const (
 // SQSBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type SQSBehavior struct {
-	GetQueueURLBehavior    MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
-	ReceiveMessageBehavior MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
-	DeleteMessageBehavior  MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	GetQueueURLBehavior             MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
+	ReceiveMessageBehavior          MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
+	DeleteMessageBehavior           MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	SendMessageBehavior             MockedFunction[sqs.SendMessageInput, sqs.SendMessageOutput]
+	ChangeMessageVisibilityBehavior MockedFunction[sqs.ChangeMessageVisibilityInput, sqs.ChangeMessageVisibilityOutput]
 }
 
 type SQSAPI struct {
@@ -46,6 +50,8 @@ func (s *SQSAPI) Reset() {
 	s.GetQueueURLBehavior.Reset()
 	s.ReceiveMessageBehavior.Reset()
 	s.DeleteMessageBehavior.Reset()
+	s.SendMessageBehavior.Reset()
+	s.ChangeMessageVisibilityBehavior.Reset()
 }
 
 //nolint:revive,stylecheck
@@ -68,3 +74,17 @@ func (s *SQSAPI) DeleteMessage(_ context.Context, input *sqs.DeleteMessageInput,
 		return nil, nil
 	})
 }
+
+func (s *SQSAPI) SendMessage(_ context.Context, input *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	return s.SendMessageBehavior.Invoke(input, func(_ *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+		return &sqs.SendMessageOutput{
+			MessageId: aws.String(test.RandomName()),
+		}, nil
+	})
+}
+
+func (s *SQSAPI) ChangeMessageVisibility(_ context.Context, input *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	return s.ChangeMessageVisibilityBehavior.Invoke(input, func(_ *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+		return nil, nil
+	})
+}
@@ -30,6 +30,9 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 )
 
+// AccountID is the account which the fake APIs act on behalf of
+const AccountID = "000000000000"
+
 func InstanceID() string {
 	return fmt.Sprintf("i-%s", randomdata.Alphanumeric(17))
 }
@@ -108,6 +111,20 @@ func FilterDescribeImages(images []ec2types.Image, filters []ec2types.Filter) []
 	})
 }
 
+// FilterDescribeImagesByOwners filters the images by the owners of a DescribeImages request. Owners match the owner id
+// or owner alias of an image, and "self" matches the images owned by AccountID. Images without an owner id match any
+// owners, so that only tests which exercise ownership need to set it.
+func FilterDescribeImagesByOwners(images []ec2types.Image, owners []string) []ec2types.Image {
+	return lo.Filter(images, func(image ec2types.Image, _ int) bool {
+		if len(owners) == 0 || image.OwnerId == nil {
+			return true
+		}
+		return lo.ContainsBy(owners, func(owner string) bool {
+			return owner == aws.ToString(image.OwnerId) || owner == aws.ToString(image.ImageOwnerAlias) || (owner == "self" && aws.ToString(image.OwnerId) == AccountID)
+		})
+	})
+}
+
 //nolint:gocyclo
 func Filter(filters []ec2types.Filter, id, name string, tags []ec2types.Tag) bool {
 	return lo.EveryBy(filters, func(filter ec2types.Filter) bool {
@@ -228,3 +245,17 @@ func MakeInstanceOfferings(instanceTypes []ec2types.InstanceTypeInfo) []ec2types
 	}
 	return instanceTypeOfferings
 }
+
+// MakeZonalInstanceOfferings offers each of the instance types in each of the zones
+func MakeZonalInstanceOfferings(instanceTypes []ec2types.InstanceTypeInfo, zones ...string) []ec2types.InstanceTypeOffering {
+	var instanceTypeOfferings []ec2types.InstanceTypeOffering
+	for _, instanceType := range instanceTypes {
+		for _, zone := range zones {
+			instanceTypeOfferings = append(instanceTypeOfferings, ec2types.InstanceTypeOffering{
+				InstanceType: instanceType.InstanceType,
+				Location:     aws.String(zone),
+			})
+		}
+	}
+	return instanceTypeOfferings
+}
@@ -35,6 +35,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "supported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AWS"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"arm64"},
@@ -65,6 +66,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(10),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -80,6 +84,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -118,7 +123,8 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				},
 			},
 			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
-				TotalSizeInGB: aws.Int64(4000),
+				EncryptionSupport: "required",
+				TotalSizeInGB:     aws.Int64(4000),
 			},
 			NetworkInfo: &ec2types.NetworkInfo{
 				EfaInfo: &ec2types.EfaInfo{
@@ -128,6 +134,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(true),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -155,6 +164,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AMD"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -193,13 +203,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				},
 			},
 			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
-				TotalSizeInGB: aws.Int64(2400),
+				EncryptionSupport: "required",
+				TotalSizeInGB:     aws.Int64(2400),
 			},
 			NetworkInfo: &ec2types.NetworkInfo{
 				MaximumNetworkInterfaces:     aws.Int32(8),
 				Ipv4AddressesPerInterface:    aws.Int32(30),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -215,6 +229,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "supported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -253,7 +268,8 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				},
 			},
 			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
-				TotalSizeInGB: aws.Int64(900),
+				EncryptionSupport: "required",
+				TotalSizeInGB:     aws.Int64(900),
 			},
 			NetworkInfo: &ec2types.NetworkInfo{
 				EfaInfo: &ec2types.EfaInfo{
@@ -263,6 +279,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(true),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -278,6 +297,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AMD"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -323,6 +343,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -338,6 +361,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AMD"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -383,6 +407,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -398,6 +425,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -428,6 +456,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(10),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -443,6 +474,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(true),
 			Hypervisor:                    "",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -473,6 +505,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -488,6 +523,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "supported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -518,6 +554,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -533,6 +572,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "supported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -559,7 +599,8 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				NvmeSupport:         "required",
 			},
 			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
-				TotalSizeInGB: aws.Int64(7600),
+				EncryptionSupport: "required",
+				TotalSizeInGB:     aws.Int64(7600),
 			},
 			NetworkInfo: &ec2types.NetworkInfo{
 				EfaInfo: &ec2types.EfaInfo{
@@ -569,6 +610,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(true),
+				EfaSupported:                 aws.Bool(true),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -588,6 +632,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "xen",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -630,6 +675,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(30),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "supported",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -645,6 +693,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -675,6 +724,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(12),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -690,6 +742,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AWS"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"arm64"},
@@ -720,6 +773,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(6),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -735,6 +791,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AWS"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"arm64"},
@@ -765,6 +822,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(4),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -780,6 +840,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("AWS"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"arm64"},
@@ -810,6 +871,9 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(false),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
@@ -825,6 +889,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    "nitro",
+			NitroEnclavesSupport:          "unsupported",
 			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:             aws.String("Intel"),
 				SupportedArchitectures:   []ec2types.ArchitectureType{"x86_64"},
@@ -866,13 +931,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				},
 			},
 			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
-				TotalSizeInGB: aws.Int64(474),
+				EncryptionSupport: "required",
+				TotalSizeInGB:     aws.Int64(474),
 			},
 			NetworkInfo: &ec2types.NetworkInfo{
 				MaximumNetworkInterfaces:     aws.Int32(4),
 				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(true),
 				DefaultNetworkCardIndex:      aws.Int32(0),
+				EnaSupport:                   "required",
+				EnaSrdSupported:              aws.Bool(false),
+				EfaSupported:                 aws.Bool(false),
 				NetworkCards: []ec2types.NetworkCardInfo{
 					{
 						NetworkCardIndex:         aws.Int32(0),
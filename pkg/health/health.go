@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+)
+
+// Path is the path of the metrics server that the freshness of the data cached by providers is served at
+const Path = "/healthz/providers"
+
+// The sources of data which providers cache from AWS
+const (
+	InstanceTypes  = "instance-types"
+	Offerings      = "offerings"
+	Pricing        = "pricing"
+	AMIs           = "amis"
+	Subnets        = "subnets"
+	SecurityGroups = "security-groups"
+	SSM            = "ssm"
+)
+
+// staleAfter is the time after the last successful refresh of each source that its data is considered stale, if
+// refreshing it has been failing since. Sources which are refreshed periodically are allowed to miss a refresh.
+var staleAfter = map[string]time.Duration{
+	InstanceTypes:  24 * time.Hour,
+	Offerings:      24 * time.Hour,
+	Pricing:        24 * time.Hour,
+	AMIs:           time.Hour,
+	Subnets:        time.Hour,
+	SecurityGroups: time.Hour,
+	SSM:            48 * time.Hour,
+}
+
+// Providers tracks the freshness of the data cached by the providers of the operator
+var Providers = NewRegistry(clock.RealClock{})
+
+// Status is the freshness of the data of a source
+type Status struct {
+	// LastRefreshed is when the data was last refreshed successfully
+	LastRefreshed *time.Time `json:"lastRefreshed,omitempty"`
+	// Age is the time since the data was last refreshed successfully
+	Age string `json:"age,omitempty"`
+	// LastError is the error of the last refresh which failed
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is when the last refresh which failed was attempted
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	// Stale is true when refreshing the data has been failing for longer than the source is expected to be refreshed in
+	Stale bool `json:"stale"`
+}
+
+type source struct {
+	lastRefreshed time.Time
+	lastError     error
+	lastErrorTime time.Time
+}
+
+// Registry records the result of each refresh of the sources of cached data, and serves their freshness as JSON. The
+// response has a 503 status code when any source is stale so that it can be used by readiness probes.
+type Registry struct {
+	mu      sync.RWMutex
+	clock   clock.Clock
+	started time.Time
+	sources map[string]*source
+}
+
+func NewRegistry(clk clock.Clock) *Registry {
+	return &Registry{
+		clock:   clk,
+		started: clk.Now(),
+		sources: map[string]*source{},
+	}
+}
+
+// Observe records the result of refreshing the data of a source from AWS. A nil error records a successful refresh.
+func (r *Registry) Observe(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sources[name]
+	if !ok {
+		s = &source{}
+		r.sources[name] = s
+	}
+	if err != nil {
+		s.lastError = err
+		s.lastErrorTime = r.clock.Now()
+		return
+	}
+	s.lastRefreshed = r.clock.Now()
+}
+
+// Statuses returns the freshness of every source, including the sources which haven't been refreshed yet
+func (r *Registry) Statuses() map[string]Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := map[string]Status{}
+	for name := range staleAfter {
+		statuses[name] = Status{}
+	}
+	for name, s := range r.sources {
+		status := Status{}
+		if !s.lastRefreshed.IsZero() {
+			status.LastRefreshed = lo.ToPtr(s.lastRefreshed)
+			status.Age = r.clock.Since(s.lastRefreshed).Round(time.Second).String()
+		}
+		if s.lastError != nil {
+			status.LastError = s.lastError.Error()
+			status.LastErrorTime = lo.ToPtr(s.lastErrorTime)
+		}
+		// Sources which have never been refreshed are considered to be as fresh as the operator, so that they aren't
+		// stale while the operator is starting up
+		if d, ok := staleAfter[name]; ok && s.lastErrorTime.After(s.lastRefreshed) {
+			status.Stale = r.clock.Since(lo.Ternary(s.lastRefreshed.IsZero(), r.started, s.lastRefreshed)) > d
+		}
+		statuses[name] = status
+	}
+	return statuses
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	statuses := r.Statuses()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lo.Ternary(lo.SomeBy(lo.Values(statuses), func(s Status) bool { return s.Stale }), http.StatusServiceUnavailable, http.StatusOK))
+	_ = json.NewEncoder(w).Encode(statuses)
+}
+
+// Reset forgets the results of every refresh
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = r.clock.Now()
+	r.sources = map[string]*source{}
+}
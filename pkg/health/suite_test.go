@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/health"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health")
+}
+
+var _ = Describe("Health", func() {
+	var fakeClock *clock.FakeClock
+	var registry *health.Registry
+	serve := func() (int, map[string]health.Status) {
+		recorder := httptest.NewRecorder()
+		registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, health.Path, nil))
+		statuses := map[string]health.Status{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &statuses)).To(Succeed())
+		return recorder.Code, statuses
+	}
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		registry = health.NewRegistry(fakeClock)
+	})
+	It("should report every source before any of them are refreshed", func() {
+		code, statuses := serve()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(statuses).To(HaveKey(health.InstanceTypes))
+		Expect(statuses).To(HaveKey(health.Offerings))
+		Expect(statuses).To(HaveKey(health.Pricing))
+		Expect(statuses).To(HaveKey(health.AMIs))
+		Expect(statuses).To(HaveKey(health.Subnets))
+		Expect(statuses).To(HaveKey(health.SecurityGroups))
+		Expect(statuses).To(HaveKey(health.SSM))
+		Expect(statuses[health.Pricing].LastRefreshed).To(BeNil())
+	})
+	It("should report the age of a source since it was last refreshed", func() {
+		registry.Observe(health.Pricing, nil)
+		fakeClock.Step(90 * time.Minute)
+		code, statuses := serve()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(statuses[health.Pricing].LastRefreshed).ToNot(BeNil())
+		Expect(statuses[health.Pricing].Age).To(Equal("1h30m0s"))
+		Expect(statuses[health.Pricing].Stale).To(BeFalse())
+	})
+	It("should report the last error of a source", func() {
+		registry.Observe(health.Subnets, nil)
+		registry.Observe(health.Subnets, fmt.Errorf("throttled"))
+		_, statuses := serve()
+		Expect(statuses[health.Subnets].LastError).To(Equal("throttled"))
+		Expect(statuses[health.Subnets].LastErrorTime).ToNot(BeNil())
+		Expect(statuses[health.Subnets].Stale).To(BeFalse())
+	})
+	It("should report a source as stale when refreshing it has been failing for too long", func() {
+		registry.Observe(health.Subnets, nil)
+		fakeClock.Step(2 * time.Hour)
+		registry.Observe(health.Subnets, fmt.Errorf("throttled"))
+		code, statuses := serve()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(statuses[health.Subnets].Stale).To(BeTrue())
+		Expect(statuses[health.Pricing].Stale).To(BeFalse())
+	})
+	It("should not report a source as stale when it's refreshed successfully after failing", func() {
+		registry.Observe(health.Subnets, nil)
+		fakeClock.Step(2 * time.Hour)
+		registry.Observe(health.Subnets, fmt.Errorf("throttled"))
+		registry.Observe(health.Subnets, nil)
+		code, statuses := serve()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(statuses[health.Subnets].Stale).To(BeFalse())
+		Expect(statuses[health.Subnets].LastError).To(Equal("throttled"))
+	})
+	It("should not report a source as stale when data which hasn't been cached for long fails to refresh", func() {
+		registry.Observe(health.Pricing, nil)
+		fakeClock.Step(2 * time.Hour)
+		registry.Observe(health.Pricing, fmt.Errorf("pricing api unavailable"))
+		code, _ := serve()
+		Expect(code).To(Equal(http.StatusOK))
+	})
+	It("should report a source which has never been refreshed as stale once it has been failing for too long", func() {
+		registry.Observe(health.AMIs, fmt.Errorf("unauthorized"))
+		code, _ := serve()
+		Expect(code).To(Equal(http.StatusOK))
+		fakeClock.Step(2 * time.Hour)
+		registry.Observe(health.AMIs, fmt.Errorf("unauthorized"))
+		code, statuses := serve()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(statuses[health.AMIs].LastRefreshed).To(BeNil())
+		Expect(statuses[health.AMIs].Stale).To(BeTrue())
+	})
+	It("should forget every refresh when reset", func() {
+		registry.Observe(health.AMIs, fmt.Errorf("unauthorized"))
+		fakeClock.Step(2 * time.Hour)
+		registry.Reset()
+		_, statuses := serve()
+		Expect(statuses[health.AMIs].LastError).To(BeEmpty())
+		Expect(statuses[health.AMIs].Stale).To(BeFalse())
+	})
+})
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// WithHTTPClient configures the connection pool of the HTTP client of the AWS config from the options, and wraps it so
+// that the connections which requests are sent on are counted by whether they were reused. Since the wrapped client
+// isn't a BuildableClient, the AWS clients share it rather than each building their own transport, so connections to
+// an endpoint are reused across the clients which call it. The client is only wrapped once the config is loaded, so
+// that the custom CA bundle of the config is kept.
+func WithHTTPClient(ctx context.Context, cfg aws.Config) aws.Config {
+	opts := options.FromContext(ctx)
+	client, ok := cfg.HTTPClient.(*awshttp.BuildableClient)
+	if !ok {
+		client = awshttp.NewBuildableClient()
+	}
+	client = client.WithTransportOptions(func(tr *http.Transport) {
+		tr.MaxIdleConnsPerHost = opts.AWSClientMaxIdleConnsPerHost
+		// The idle connections to every endpoint are kept open, rather than being capped across endpoints
+		tr.MaxIdleConns = 0
+		tr.IdleConnTimeout = opts.AWSClientIdleConnTimeout
+		if opts.AWSClientDisableHTTP2 {
+			tr.ForceAttemptHTTP2 = false
+			tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	})
+	cfg.HTTPClient = &connectionTrackingClient{HTTPClient: client}
+	return cfg
+}
+
+type connectionTrackingClient struct {
+	aws.HTTPClient
+}
+
+func (c *connectionTrackingClient) Do(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			AWSClientConnectionsTotal.Inc(map[string]string{
+				hostLabel:   req.URL.Host,
+				reusedLabel: strconv.FormatBool(info.Reused),
+			})
+		},
+	}
+	return c.HTTPClient.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
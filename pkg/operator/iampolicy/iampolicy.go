@@ -0,0 +1,170 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package iampolicy generates the IAM policy that the Karpenter controller needs for the settings it's configured with.
+// The actions are derived from the API interfaces that Karpenter calls AWS through, so that the policy stays in lockstep
+// with the calls that Karpenter makes.
+package iampolicy
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Document is an IAM policy document
+type Document struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+type Statement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource string   `json:"Resource"`
+}
+
+// apis are the API interfaces that Karpenter calls AWS through, keyed by the service prefix of their IAM actions
+var apis = map[string]reflect.Type{
+	"ec2":             reflect.TypeFor[sdk.EC2API](),
+	"eks":             reflect.TypeFor[sdk.EKSAPI](),
+	"iam":             reflect.TypeFor[sdk.IAMAPI](),
+	"license-manager": reflect.TypeFor[sdk.LicenseManagerAPI](),
+	"pricing":         reflect.TypeFor[sdk.PricingAPI](),
+	"savingsplans":    reflect.TypeFor[sdk.SavingsPlansAPI](),
+	"servicequotas":   reflect.TypeFor[sdk.ServiceQuotasAPI](),
+	"sqs":             reflect.TypeFor[sdk.SQSAPI](),
+	"ssm":             reflect.TypeFor[sdk.SSMAPI](),
+}
+
+// sids are the statement IDs of the actions of each service which Karpenter always calls
+var sids = map[string]string{
+	"ec2":             "AllowEC2Actions",
+	"eks":             "AllowEKSActions",
+	"iam":             "AllowIAMActions",
+	"license-manager": "AllowLicenseManagerActions",
+	"pricing":         "AllowPricingActions",
+	"savingsplans":    "AllowSavingsPlansActions",
+	"servicequotas":   "AllowServiceQuotasActions",
+	"sqs":             "AllowSQSActions",
+	"ssm":             "AllowSSMActions",
+}
+
+// dependentActions are the actions which are authorized along with an action, but which aren't operations of the API
+// interfaces, e.g. because they're made outside of them or because EC2 authorizes them on behalf of the call
+var dependentActions = map[string][]string{
+	"ec2:CreateFleet":              {"ec2:RunInstances"},
+	"iam:AddRoleToInstanceProfile": {"iam:PassRole"},
+	"sqs:ReceiveMessage":           {"sqs:GetQueueUrl"},
+}
+
+// feature is a group of actions which Karpenter only calls when a setting enables them
+type feature struct {
+	sid      string
+	enabled  func(*options.Options) bool
+	actions  []string
+	resource func(*options.Options) string
+}
+
+var features = []feature{
+	{
+		sid:     "AllowPricingReadActions",
+		enabled: func(o *options.Options) bool { return !o.IsolatedVPC },
+		actions: []string{"pricing:GetProducts"},
+	},
+	{
+		// The queues are polled with the credentials of the interruption queue role when it's set
+		sid: "AllowInterruptionQueueActions",
+		enabled: func(o *options.Options) bool {
+			return len(o.InterruptionQueues()) != 0 && o.InterruptionQueueRoleARN == ""
+		},
+		actions: []string{"sqs:ChangeMessageVisibility", "sqs:DeleteMessage", "sqs:ReceiveMessage", "sqs:SendMessage"},
+	},
+	{
+		sid: "AllowInterruptionQueueRoleAssumption",
+		enabled: func(o *options.Options) bool {
+			return len(o.InterruptionQueues()) != 0 && o.InterruptionQueueRoleARN != ""
+		},
+		actions:  []string{"sts:AssumeRole"},
+		resource: func(o *options.Options) string { return o.InterruptionQueueRoleARN },
+	},
+	{
+		sid:     "AllowSpotPlacementScores",
+		enabled: func(o *options.Options) bool { return o.FeatureGates.SpotPlacementScores },
+		actions: []string{"ec2:GetSpotPlacementScores"},
+	},
+	{
+		sid:     "AllowCommitmentReadActions",
+		enabled: func(o *options.Options) bool { return o.FeatureGates.CommitmentAwarePricing },
+		actions: []string{"ec2:DescribeReservedInstances", "savingsplans:DescribeSavingsPlanRates", "savingsplans:DescribeSavingsPlans"},
+	},
+	{
+		sid:     "AllowServiceQuotasReadActions",
+		enabled: func(o *options.Options) bool { return o.FeatureGates.ValidateQuotas },
+		actions: []string{"servicequotas:GetServiceQuota"},
+	},
+	{
+		sid:     "AllowNodeRolePolicySimulation",
+		enabled: func(o *options.Options) bool { return o.FeatureGates.SimulateNodeRolePermissions },
+		actions: []string{"iam:SimulatePrincipalPolicy"},
+	},
+}
+
+// Actions returns the IAM actions of the operations of an API interface, e.g. ec2:DescribeImages for the DescribeImages
+// method of EC2API
+func Actions(service string, api reflect.Type) []string {
+	actions := make([]string, 0, api.NumMethod())
+	for i := range api.NumMethod() {
+		actions = append(actions, service+":"+api.Method(i).Name)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Policy returns the IAM policy that the Karpenter controller needs for the options. The actions which Karpenter calls
+// regardless of the options are grouped by service, followed by a statement for each enabled feature.
+func Policy(opts *options.Options) Document {
+	gated := lo.SliceToMap(lo.FlatMap(features, func(f feature, _ int) []string { return f.actions }), func(a string) (string, struct{}) {
+		return a, struct{}{}
+	})
+	var statements []Statement
+	for _, service := range lo.Keys(apis) {
+		if actions := lo.Reject(Actions(service, apis[service]), func(a string, _ int) bool { return lo.HasKey(gated, a) }); len(actions) != 0 {
+			statements = append(statements, statement(sids[service], actions, "*"))
+		}
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].Sid < statements[j].Sid })
+	for _, f := range features {
+		if !f.enabled(opts) {
+			continue
+		}
+		resource := "*"
+		if f.resource != nil {
+			resource = f.resource(opts)
+		}
+		statements = append(statements, statement(f.sid, f.actions, resource))
+	}
+	return Document{Version: "2012-10-17", Statement: statements}
+}
+
+func statement(sid string, actions []string, resource string) Statement {
+	actions = lo.Uniq(lo.FlatMap(actions, func(a string, _ int) []string { return append([]string{a}, dependentActions[a]...) }))
+	sort.Strings(actions)
+	return Statement{Sid: sid, Effect: "Allow", Action: actions, Resource: resource}
+}
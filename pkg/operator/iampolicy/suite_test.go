@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iampolicy_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/iampolicy"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestIAMPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IAMPolicy")
+}
+
+func actions(policy iampolicy.Document) []string {
+	return lo.FlatMap(policy.Statement, func(s iampolicy.Statement, _ int) []string { return s.Action })
+}
+
+var _ = Describe("IAMPolicy", func() {
+	It("should allow the actions which are always called", func() {
+		policy := iampolicy.Policy(&options.Options{})
+		Expect(policy.Version).To(Equal("2012-10-17"))
+		Expect(actions(policy)).To(ContainElements("ec2:CreateFleet", "ec2:DescribeImages", "eks:DescribeCluster", "iam:CreateInstanceProfile", "ssm:GetParameter", "pricing:GetProducts"))
+		Expect(actions(policy)).ToNot(ContainElements("sqs:ReceiveMessage", "ec2:GetSpotPlacementScores", "iam:SimulatePrincipalPolicy", "servicequotas:GetServiceQuota"))
+	})
+	It("should allow the actions which are authorized along with an operation", func() {
+		Expect(actions(iampolicy.Policy(&options.Options{}))).To(ContainElements("ec2:RunInstances", "iam:PassRole"))
+	})
+	It("should allow the interruption queue actions when interruption handling is enabled", func() {
+		policy := iampolicy.Policy(&options.Options{InterruptionQueue: "karpenter-interruption"})
+		statement, ok := lo.Find(policy.Statement, func(s iampolicy.Statement) bool { return s.Sid == "AllowInterruptionQueueActions" })
+		Expect(ok).To(BeTrue())
+		Expect(statement.Action).To(ContainElements("sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:GetQueueUrl"))
+	})
+	It("should allow assuming the interruption queue role instead of the interruption queue actions when it's set", func() {
+		policy := iampolicy.Policy(&options.Options{InterruptionQueue: "karpenter-interruption", InterruptionQueueRoleARN: "arn:aws:iam::111122223333:role/KarpenterInterruption"})
+		Expect(actions(policy)).ToNot(ContainElement("sqs:ReceiveMessage"))
+		statement, ok := lo.Find(policy.Statement, func(s iampolicy.Statement) bool { return s.Sid == "AllowInterruptionQueueRoleAssumption" })
+		Expect(ok).To(BeTrue())
+		Expect(statement.Action).To(Equal([]string{"sts:AssumeRole"}))
+		Expect(statement.Resource).To(Equal("arn:aws:iam::111122223333:role/KarpenterInterruption"))
+	})
+	It("should not allow pricing actions in an isolated VPC", func() {
+		Expect(actions(iampolicy.Policy(&options.Options{IsolatedVPC: true}))).ToNot(ContainElement("pricing:GetProducts"))
+	})
+	It("should allow the actions of features when they're enabled", func() {
+		Expect(actions(iampolicy.Policy(&options.Options{
+			FeatureGates: options.FeatureGates{
+				SpotPlacementScores:         true,
+				CommitmentAwarePricing:      true,
+				ValidateQuotas:              true,
+				SimulateNodeRolePermissions: true,
+			},
+		}))).To(ContainElements(
+			"ec2:GetSpotPlacementScores",
+			"ec2:DescribeReservedInstances",
+			"savingsplans:DescribeSavingsPlans",
+			"servicequotas:GetServiceQuota",
+			"iam:SimulatePrincipalPolicy",
+		))
+	})
+	It("should allow every operation of the APIs when every feature is enabled", func() {
+		policy := actions(iampolicy.Policy(&options.Options{
+			InterruptionQueue: "karpenter-interruption",
+			FeatureGates: options.FeatureGates{
+				SpotPlacementScores:         true,
+				CommitmentAwarePricing:      true,
+				ValidateQuotas:              true,
+				SimulateNodeRolePermissions: true,
+			},
+		}))
+		for service, api := range map[string]reflect.Type{
+			"ec2":             reflect.TypeFor[sdk.EC2API](),
+			"eks":             reflect.TypeFor[sdk.EKSAPI](),
+			"iam":             reflect.TypeFor[sdk.IAMAPI](),
+			"license-manager": reflect.TypeFor[sdk.LicenseManagerAPI](),
+			"pricing":         reflect.TypeFor[sdk.PricingAPI](),
+			"savingsplans":    reflect.TypeFor[sdk.SavingsPlansAPI](),
+			"servicequotas":   reflect.TypeFor[sdk.ServiceQuotasAPI](),
+			"sqs":             reflect.TypeFor[sdk.SQSAPI](),
+			"ssm":             reflect.TypeFor[sdk.SSMAPI](),
+		} {
+			Expect(policy).To(ContainElements(iampolicy.Actions(service, api)))
+		}
+	})
+})
@@ -0,0 +1,52 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	featureGateLabel       = "feature_gate"
+	hostLabel              = "host"
+	reusedLabel            = "reused"
+)
+
+var FeatureGateEnabled = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: cloudProviderSubsystem,
+		Name:      "feature_gate_enabled",
+		Help:      "Whether a feature gate of the AWS provider is enabled (1) or disabled (0). Labeled by feature gate.",
+	},
+	[]string{featureGateLabel},
+)
+
+var AWSClientConnectionsTotal = opmetrics.NewPrometheusCounter(
+	crmetrics.Registry,
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: cloudProviderSubsystem,
+		Name:      "aws_client_connections_total",
+		Help:      "The number of connections that requests of the AWS clients were sent on. Labeled by the host of the endpoint and whether the connection was reused from the idle connection pool.",
+	},
+	[]string{hostLabel, reusedLabel},
+)
@@ -15,14 +15,15 @@ limitations under the License.
 package operator
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	stdlog "log"
 	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/middleware"
@@ -31,9 +32,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/licensemanager"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 
-	"github.com/aws/smithy-go"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	"github.com/awslabs/operatorpkg/option"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -48,6 +53,7 @@ import (
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/operator"
+	"sigs.k8s.io/karpenter/pkg/utils/env"
 
 	prometheusv2 "github.com/jonathan-innis/aws-sdk-go-prometheus/v2"
 
@@ -55,17 +61,32 @@ import (
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/emf"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/commitment"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/license"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/vpcendpoint"
+	"github.com/aws/karpenter-provider-aws/pkg/throttling"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 func init() {
@@ -75,23 +96,46 @@ func init() {
 // Operator is injected into the AWS CloudProvider's factories
 type Operator struct {
 	*operator.Operator
-	Config                    aws.Config
-	UnavailableOfferingsCache *awscache.UnavailableOfferings
-	SSMCache                  *cache.Cache
-	SubnetProvider            subnet.Provider
-	SecurityGroupProvider     securitygroup.Provider
-	InstanceProfileProvider   instanceprofile.Provider
-	AMIProvider               amifamily.Provider
-	AMIResolver               amifamily.Resolver
-	LaunchTemplateProvider    launchtemplate.Provider
-	PricingProvider           pricing.Provider
-	VersionProvider           *version.DefaultProvider
-	InstanceTypesProvider     *instancetype.DefaultProvider
-	InstanceProvider          instance.Provider
-	SSMProvider               ssmp.Provider
+	Config                     aws.Config
+	UnavailableOfferingsCache  *awscache.UnavailableOfferings
+	SSMCache                   *cache.Cache
+	SubnetProvider             subnet.Provider
+	SecurityGroupProvider      securitygroup.Provider
+	InstanceProfileProvider    instanceprofile.Provider
+	AMIProvider                amifamily.Provider
+	AMIResolver                amifamily.Resolver
+	LaunchTemplateProvider     launchtemplate.Provider
+	PricingProvider            pricing.Provider
+	CommitmentProvider         commitment.Provider
+	VersionProvider            *version.DefaultProvider
+	InstanceTypesProvider      *instancetype.DefaultProvider
+	InstanceProvider           instance.Provider
+	SSMProvider                ssmp.Provider
+	LicenseProvider            license.Provider
+	VPCEndpointProvider        vpcendpoint.Provider
+	DHCPOptionsProvider        dhcpoptions.Provider
+	CapacityBlockProvider      capacityblock.Provider
+	PlacementGroupProvider     placementgroup.Provider
+	PolicyProvider             *policy.DefaultProvider
+	SpotPlacementScoreProvider *spotplacementscore.DefaultProvider
+	QuotaProvider              *quota.DefaultProvider
 }
 
-func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
+// Options are optional extension points which can be used when constructing the Operator
+type Options struct {
+	// APIOptions are smithy middleware stack mutators which are registered on every AWS client constructed by the
+	// operator. This can be used for org-specific request signing, header injection, or auditing.
+	APIOptions []func(*smithymiddleware.Stack) error
+}
+
+// WithAPIOptions registers smithy middleware stack mutators on every AWS client constructed by the operator
+func WithAPIOptions(apiOptions ...func(*smithymiddleware.Stack) error) option.Function[Options] {
+	return func(o *Options) {
+		o.APIOptions = append(o.APIOptions, apiOptions...)
+	}
+}
+
+func NewOperator(ctx context.Context, operator *operator.Operator, opts ...option.Function[Options]) (context.Context, *Operator) {
 	kubeletCompatibilityAnnotationKey := fmt.Sprintf("%s/%s", apis.CompatibilityGroup, "v1beta1-kubelet-conversion")
 	// we are going to panic if any of the customer nodepools contain
 	// compatibility.karpenter.sh/v1beta1-kubelet-conversion
@@ -107,7 +151,25 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		stdlog.Fatalf("The kubelet compatibility annotation, %s, is not supported on Karpenter v1.1+. Please refer to the upgrade guide in the docs. The following NodePools still have the compatibility annotation: %s", kubeletCompatibilityAnnotationKey, strings.Join(npNames, ", "))
 	}
 
-	cfg := prometheusv2.WithPrometheusMetrics(WithUserAgent(lo.Must(config.LoadDefaultConfig(ctx))), crmetrics.Registry)
+	for gate, enabled := range options.FromContext(ctx).FeatureGates.Map() {
+		FeatureGateEnabled.Set(lo.Ternary[float64](enabled, 1, 0), map[string]string{featureGateLabel: gate})
+	}
+
+	var loadOptions []func(*config.LoadOptions) error
+	if options.FromContext(ctx).UseFIPSEndpoints {
+		loadOptions = append(loadOptions, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	cfg := WithOptions(prometheusv2.WithPrometheusMetrics(WithUserAgent(WithHTTPClient(ctx, lo.Must(config.LoadDefaultConfig(ctx, loadOptions...)))), crmetrics.Registry), opts...)
+	// Throttled attempts are tracked for every AWS client, while only the batched EC2 calls are rate limited
+	throttlingController := throttling.NewController(operator.Clock, options.FromContext(ctx).FeatureGates.AWSClientAdaptiveThrottling)
+	cfg.APIOptions = append(cfg.APIOptions, throttlingController.APIOptions()...)
+	// The AWS client metrics are also published in CloudWatch embedded metric format, so that they can be correlated
+	// with the service-side throttling metrics of the account
+	if namespace := options.FromContext(ctx).ClientMetricsEMFNamespace; namespace != "" {
+		emfPublisher := emf.NewPublisher(os.Stdout, operator.Clock, namespace, options.FromContext(ctx).ClusterName)
+		cfg.APIOptions = append(cfg.APIOptions, emfPublisher.APIOptions()...)
+		lo.Must0(operator.Manager.Add(emfPublisher))
+	}
 	if cfg.Region == "" {
 		log.FromContext(ctx).V(1).Info("retrieving region from IMDS")
 		region := lo.Must(imds.NewFromConfig(cfg).GetRegion(ctx, nil))
@@ -115,7 +177,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	}
 	ec2api := ec2.NewFromConfig(cfg)
 	eksapi := eks.NewFromConfig(cfg)
-	log.FromContext(ctx).WithValues("region", cfg.Region).V(1).Info("discovered region")
+	log.FromContext(ctx).WithValues("region", cfg.Region, "partition", utils.Partition(cfg.Region)).V(1).Info("discovered region")
 	if err := CheckEC2Connectivity(ctx, ec2api); err != nil {
 		log.FromContext(ctx).Error(err, "ec2 api connectivity check failed")
 		os.Exit(1)
@@ -136,6 +198,17 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	} else {
 		log.FromContext(ctx).WithValues("kube-dns-ip", kubeDNSIP).V(1).Info("discovered kube dns")
 	}
+	// In environments which can't reach the EC2 and pricing APIs, the instance types, offerings and prices are served
+	// from an offering snapshot which was exported from an environment which can
+	var offeringsAPI sdk.EC2API = ec2api
+	offeringSnapshot, err := OfferingSnapshot(ctx, operator.KubernetesInterface, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system"), cfg.Region)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed loading offering snapshot")
+		os.Exit(1)
+	}
+	if offeringSnapshot != nil {
+		offeringsAPI = snapshot.NewEC2API(ec2api, offeringSnapshot, operator.Clock)
+	}
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
 	ssmCache := cache.New(awscache.SSMCacheTTL, awscache.DefaultCleanupInterval)
 
@@ -145,15 +218,23 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	pricingProvider := pricing.NewDefaultProvider(
 		ctx,
 		pricing.NewAPI(cfg),
-		ec2api,
+		offeringsAPI,
 		cfg.Region,
 	)
+	if offeringSnapshot != nil {
+		pricingProvider.SetStaticOnDemandPrices(offeringSnapshot.OnDemandPrices)
+	}
+	commitmentProvider := commitment.NewDefaultProvider(ec2api, savingsplans.NewFromConfig(cfg, func(o *savingsplans.Options) {
+		// The Savings Plans API doesn't have FIPS endpoints
+		o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateDisabled
+	}), cfg.Region)
+	quotaProvider := quota.NewDefaultProvider(servicequotas.NewFromConfig(cfg), cache.New(awscache.ServiceQuotasTTL, awscache.DefaultCleanupInterval))
 	versionProvider := version.NewDefaultProvider(operator.KubernetesInterface, eksapi)
 	// Ensure we're able to hydrate the version before starting any reliant controllers.
 	// Version updates are hydrated asynchronously after this, in the event of a failure
 	// the previously resolved value will be used.
 	lo.Must0(versionProvider.UpdateVersion(ctx))
-	ssmProvider := ssmp.NewDefaultProvider(ssm.NewFromConfig(cfg), ssmCache)
+	ssmProvider := ssmp.NewDefaultProvider(ssm.NewFromConfig(cfg), ssmCache, cfg.Region)
 	amiProvider := amifamily.NewDefaultProvider(operator.Clock, versionProvider, ssmProvider, ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
 	amiResolver := amifamily.NewDefaultResolver()
 	launchTemplateProvider := launchtemplate.NewDefaultProvider(
@@ -172,10 +253,17 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	instanceTypeProvider := instancetype.NewDefaultProvider(
 		cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
 		cache.New(awscache.DiscoveredCapacityCacheTTL, awscache.DefaultCleanupInterval),
-		ec2api,
+		offeringsAPI,
 		subnetProvider,
 		instancetype.NewDefaultResolver(cfg.Region, pricingProvider, unavailableOfferingsCache),
 	)
+	licenseProvider := license.NewDefaultProvider(licensemanager.NewFromConfig(cfg), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	vpcEndpointProvider := vpcendpoint.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	dhcpOptionsProvider := dhcpoptions.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	capacityBlockProvider := capacityblock.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	placementGroupProvider := placementgroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	policyProvider := policy.NewDefaultProvider()
+	spotPlacementScoreProvider := spotplacementscore.NewDefaultProvider(ec2api, cfg.Region, cache.New(awscache.SpotPlacementScoresTTL, awscache.DefaultCleanupInterval))
 	instanceProvider := instance.NewDefaultProvider(
 		ctx,
 		cfg.Region,
@@ -183,27 +271,53 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		unavailableOfferingsCache,
 		subnetProvider,
 		launchTemplateProvider,
+		licenseProvider,
+		policyProvider,
+		spotPlacementScoreProvider,
 	)
+	// The freshness of the data which providers cache is served by the metrics server so that it can be consumed by
+	// readiness probes and external monitors
+	lo.Must0(operator.Manager.AddMetricsServerExtraHandler(health.Path, health.Providers))
+	// The SSM parameters which AMIs are resolved from are served so that unexpected AMI drift can be traced to a change
+	lo.Must0(operator.Manager.AddMetricsServerExtraHandler(ssmp.DebugPath, ssmProvider))
+	// The offerings which are unavailable for launch are served so that it can be seen why instance types aren't launched
+	lo.Must0(operator.Manager.AddMetricsServerExtraHandler(awscache.UnavailableOfferingsDebugPath, unavailableOfferingsCache))
 
 	return ctx, &Operator{
-		Operator:                  operator,
-		Config:                    cfg,
-		UnavailableOfferingsCache: unavailableOfferingsCache,
-		SSMCache:                  ssmCache,
-		SubnetProvider:            subnetProvider,
-		SecurityGroupProvider:     securityGroupProvider,
-		InstanceProfileProvider:   instanceProfileProvider,
-		AMIProvider:               amiProvider,
-		AMIResolver:               amiResolver,
-		VersionProvider:           versionProvider,
-		LaunchTemplateProvider:    launchTemplateProvider,
-		PricingProvider:           pricingProvider,
-		InstanceTypesProvider:     instanceTypeProvider,
-		InstanceProvider:          instanceProvider,
-		SSMProvider:               ssmProvider,
+		Operator:                   operator,
+		Config:                     cfg,
+		UnavailableOfferingsCache:  unavailableOfferingsCache,
+		SSMCache:                   ssmCache,
+		SubnetProvider:             subnetProvider,
+		SecurityGroupProvider:      securityGroupProvider,
+		InstanceProfileProvider:    instanceProfileProvider,
+		AMIProvider:                amiProvider,
+		AMIResolver:                amiResolver,
+		VersionProvider:            versionProvider,
+		LaunchTemplateProvider:     launchTemplateProvider,
+		PricingProvider:            pricingProvider,
+		CommitmentProvider:         commitmentProvider,
+		InstanceTypesProvider:      instanceTypeProvider,
+		InstanceProvider:           instanceProvider,
+		SSMProvider:                ssmProvider,
+		LicenseProvider:            licenseProvider,
+		VPCEndpointProvider:        vpcEndpointProvider,
+		DHCPOptionsProvider:        dhcpOptionsProvider,
+		CapacityBlockProvider:      capacityBlockProvider,
+		PlacementGroupProvider:     placementGroupProvider,
+		PolicyProvider:             policyProvider,
+		SpotPlacementScoreProvider: spotPlacementScoreProvider,
+		QuotaProvider:              quotaProvider,
 	}
 }
 
+// WithOptions registers the extension points of the operator options on the AWS config, so that they're used by every
+// AWS client constructed from the config
+func WithOptions(cfg aws.Config, opts ...option.Function[Options]) aws.Config {
+	cfg.APIOptions = append(cfg.APIOptions, option.Resolve(opts...).APIOptions...)
+	return cfg
+}
+
 // WithUserAgent adds a karpenter specific user-agent string to AWS session
 func WithUserAgent(cfg aws.Config) aws.Config {
 	userAgent := fmt.Sprintf("karpenter.sh-%s", operator.Version)
@@ -219,11 +333,7 @@ func CheckEC2Connectivity(ctx context.Context, api sdk.EC2API) error {
 	_, err := api.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
 		DryRun: aws.Bool(true),
 	})
-	var apiErr smithy.APIError
-	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "DryRunOperation" {
-		return nil
-	}
-	return err
+	return awserrors.IgnoreDryRunError(err)
 }
 
 func ResolveClusterEndpoint(ctx context.Context, eksAPI sdk.EKSAPI) (string, error) {
@@ -259,6 +369,36 @@ func GetCABundle(ctx context.Context, restConfig *rest.Config) (*string, error)
 	return lo.ToPtr(base64.StdEncoding.EncodeToString(transportConfig.TLS.CAData)), nil
 }
 
+// OfferingSnapshot loads the offering snapshot from the ConfigMap named by the offering-snapshot-configmap setting. A
+// nil snapshot is returned if the setting isn't set.
+func OfferingSnapshot(ctx context.Context, kubernetesInterface kubernetes.Interface, namespace, region string) (*snapshot.Snapshot, error) {
+	name := options.FromContext(ctx).OfferingSnapshotConfigMap
+	if name == "" {
+		return nil, nil
+	}
+	configMap, err := kubernetesInterface.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting offering snapshot configmap, %w", err)
+	}
+	data, ok := configMap.BinaryData[snapshot.ConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("offering snapshot configmap %s is missing the %s key", name, snapshot.ConfigMapKey)
+	}
+	s, err := snapshot.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if s.Region != region {
+		return nil, fmt.Errorf("offering snapshot was exported from %s, expected %s", s.Region, region)
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("configmap", name, "created-at", s.CreatedAt, "instance-type-count", len(s.InstanceTypes)))
+	if s.Stale(time.Now()) {
+		log.FromContext(ctx).Info("offering snapshot is stale, it may be missing instance types and have outdated prices, export a new snapshot")
+	}
+	log.FromContext(ctx).Info("loaded offering snapshot, instance types, offerings and prices won't be discovered from the EC2 and pricing APIs")
+	return s, nil
+}
+
 func KubeDNSIP(ctx context.Context, kubernetesInterface kubernetes.Interface) (net.IP, error) {
 	if kubernetesInterface == nil {
 		return nil, fmt.Errorf("no K8s client provided")
@@ -0,0 +1,231 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	cliflag "k8s.io/component-base/cli/flag"
+)
+
+// The feature gates of incubating features of the AWS provider. New features which aren't ready to be enabled by default
+// should be gated here, rather than with a flag of their own, so that they're enabled and disabled in the same way.
+const (
+	// FeatureGateInPlaceUpdates resizes the EBS volumes of nodes in place when their EC2NodeClass opts into it through
+	// its drift policy, rather than replacing the nodes
+	FeatureGateInPlaceUpdates = "InPlaceUpdates"
+	// FeatureGateWarmPools maintains the warm pools of NodePools annotated with karpenter.k8s.aws/warm-pool-size, and
+	// resumes their standby instances for new NodeClaims
+	FeatureGateWarmPools = "WarmPools"
+	// FeatureGateSpotPriceDrift refreshes spot prices every few minutes, and drifts spot nodes whose spot price rose
+	// above the on-demand price of their instance type, so that they're replaced with cheaper capacity
+	FeatureGateSpotPriceDrift = "SpotPriceDrift"
+	// FeatureGateLaunchJournal records the client token of each launch on its NodeClaim before the instance is launched,
+	// so that a launch which is retried after a controller restart adopts the instance that was already launched rather
+	// than launching another
+	FeatureGateLaunchJournal = "LaunchJournal"
+	// FeatureGateAWSClientAdaptiveThrottling rate limits the CreateFleet, DescribeInstances and TerminateInstances calls
+	// which are batched once EC2 throttles them, rather than retrying them until their retries are exhausted
+	FeatureGateAWSClientAdaptiveThrottling = "AWSClientAdaptiveThrottling"
+	// FeatureGateAdvertiseNetworkBandwidth advertises the network bandwidth of each instance type as the
+	// networking.k8s.aws/bandwidth-mbps extended resource, so that pods can request network bandwidth
+	FeatureGateAdvertiseNetworkBandwidth = "AdvertiseNetworkBandwidth"
+	// FeatureGateAdvertiseSecondaryENIs advertises the ENIs of each instance type which aren't used for pod networking as
+	// the networking.k8s.aws/secondary-eni extended resource, so that pods can request them, e.g. for Multus
+	FeatureGateAdvertiseSecondaryENIs = "AdvertiseSecondaryENIs"
+	// FeatureGateAdvertiseNetworkCards advertises the number of network cards of each instance type as the
+	// networking.k8s.aws/network-card extended resource, so that pods can request network cards
+	FeatureGateAdvertiseNetworkCards = "AdvertiseNetworkCards"
+	// FeatureGateAdaptiveRegistrationTTL deletes NodeClaims which fail to register once they exceed a registration timeout
+	// learned from the boot durations observed for their instance family and AMI family, which never exceeds
+	// adaptive-registration-ttl-max
+	FeatureGateAdaptiveRegistrationTTL = "AdaptiveRegistrationTTL"
+	// FeatureGateDisruptionProtectionTagSync keeps the karpenter.sh/do-not-disrupt annotation of each node in sync with
+	// the karpenter.sh/do-not-disrupt tag of its instance, so that disruption protection can be set or cleared from
+	// outside the cluster
+	FeatureGateDisruptionProtectionTagSync = "DisruptionProtectionTagSync"
+	// FeatureGatePublishNodeTemplates publishes the template node of each instance type that a NodePool can launch to a
+	// ConfigMap in the Karpenter namespace, using the cluster-autoscaler scale-from-zero node-template format
+	FeatureGatePublishNodeTemplates = "PublishNodeTemplates"
+	// FeatureGatePublishFleetComposition publishes the composition of the nodes that each NodePool has launched, counted
+	// and priced by instance type, capacity type, zone and AMI, to a ConfigMap in the Karpenter namespace
+	FeatureGatePublishFleetComposition = "PublishFleetComposition"
+	// FeatureGateLaunchDryRun makes a DryRun CreateFleet request with a representative configuration of each EC2NodeClass
+	// when the EC2NodeClass changes, and publishes the result as the LaunchDryRunSucceeded status condition
+	FeatureGateLaunchDryRun = "LaunchDryRun"
+	// FeatureGateSimulateNodeRolePermissions evaluates the policies of each EC2NodeClass's node role with the IAM policy
+	// simulator, and publishes the actions which nodes commonly need but the role doesn't allow as status conditions of
+	// the EC2NodeClass
+	FeatureGateSimulateNodeRolePermissions = "SimulateNodeRolePermissions"
+	// FeatureGateSpotPlacementScores prioritizes spot launches toward the zones with the highest EC2 spot placement scores
+	// for the instance types being launched, which reduces insufficient capacity errors during large spot scale-ups
+	FeatureGateSpotPlacementScores = "SpotPlacementScores"
+	// FeatureGateCommitmentAwarePricing lowers the prices of instance types which the account has committed to with
+	// Savings Plans or Reserved Instances to their effective committed price, so that launch and consolidation decisions
+	// prefer already committed capacity
+	FeatureGateCommitmentAwarePricing = "CommitmentAwarePricing"
+	// FeatureGateValidateQuotas validates the cpu limits of the NodePools which launch instances with each EC2NodeClass
+	// against the vCPU and EBS storage quotas of the account, and publishes the result as the QuotasSufficient status
+	// condition
+	FeatureGateValidateQuotas = "ValidateQuotas"
+	// FeatureGateLearnVMMemoryOverhead uses the VM memory overhead observed on the registered nodes of each instance
+	// family for the instance types of the family which haven't been launched yet, unless an override is configured for
+	// them in vm-memory-overhead-percent-overrides
+	FeatureGateLearnVMMemoryOverhead = "LearnVMMemoryOverhead"
+	// FeatureGateAdvertiseEBSPerformance advertises the baseline EBS throughput and IOPS of each instance type as the
+	// storage.k8s.aws/ebs-throughput-mbps and storage.k8s.aws/ebs-iops extended resources, so that pods can request EBS
+	// performance
+	FeatureGateAdvertiseEBSPerformance = "AdvertiseEBSPerformance"
+	// FeatureGatePrewarmLaunchTemplates creates launch templates ahead of launches for the instance types and capacity
+	// types of each NodePool, so that launches don't wait on creating them
+	FeatureGatePrewarmLaunchTemplates = "PrewarmLaunchTemplates"
+	// FeatureGateODCRFirst launches on-demand instances into the open On-Demand Capacity Reservations which match their
+	// instance type and zone before launching them without a reservation, rather than only using the reservations that the
+	// lowest price instance type happens to match
+	FeatureGateODCRFirst = "ODCRFirst"
+)
+
+// DefaultFeatureGates are the feature gates which aws-feature-gates defaults to
+var DefaultFeatureGates = map[string]bool{
+	FeatureGateInPlaceUpdates:              false,
+	FeatureGateWarmPools:                   false,
+	FeatureGateSpotPriceDrift:              false,
+	FeatureGateLaunchJournal:               false,
+	FeatureGateAWSClientAdaptiveThrottling: false,
+	FeatureGateAdvertiseNetworkBandwidth:   false,
+	FeatureGateAdvertiseSecondaryENIs:      false,
+	FeatureGateAdvertiseNetworkCards:       false,
+	FeatureGateAdaptiveRegistrationTTL:     false,
+	FeatureGateDisruptionProtectionTagSync: false,
+	FeatureGatePublishNodeTemplates:        false,
+	FeatureGatePublishFleetComposition:     false,
+	FeatureGateLaunchDryRun:                false,
+	FeatureGateSimulateNodeRolePermissions: false,
+	FeatureGateSpotPlacementScores:         false,
+	FeatureGateCommitmentAwarePricing:      false,
+	FeatureGateValidateQuotas:              false,
+	FeatureGateLearnVMMemoryOverhead:       false,
+	FeatureGateAdvertiseEBSPerformance:     false,
+	FeatureGatePrewarmLaunchTemplates:      false,
+	FeatureGateODCRFirst:                   false,
+}
+
+type FeatureGates struct {
+	inputStr string
+
+	InPlaceUpdates              bool
+	WarmPools                   bool
+	SpotPriceDrift              bool
+	LaunchJournal               bool
+	AWSClientAdaptiveThrottling bool
+	AdvertiseNetworkBandwidth   bool
+	AdvertiseSecondaryENIs      bool
+	AdvertiseNetworkCards       bool
+	AdaptiveRegistrationTTL     bool
+	DisruptionProtectionTagSync bool
+	PublishNodeTemplates        bool
+	PublishFleetComposition     bool
+	LaunchDryRun                bool
+	SimulateNodeRolePermissions bool
+	SpotPlacementScores         bool
+	CommitmentAwarePricing      bool
+	ValidateQuotas              bool
+	LearnVMMemoryOverhead       bool
+	AdvertiseEBSPerformance     bool
+	PrewarmLaunchTemplates      bool
+	ODCRFirst                   bool
+}
+
+// ParseFeatureGates parses a comma-separated list of Gate=true|false pairs. Gates which aren't listed take their
+// default values, and gates which aren't known are rejected, so that a typo doesn't silently leave a feature disabled.
+func ParseFeatureGates(gateStr string) (FeatureGates, error) {
+	gateMap := map[string]bool{}
+	gates := FeatureGates{inputStr: gateStr}
+
+	// Parses feature gates with the upstream mechanism, like the feature gates of karpenter-core
+	if err := cliflag.NewMapStringBool(&gateMap).Set(gateStr); err != nil {
+		return gates, err
+	}
+	if unknown := lo.Without(lo.Keys(gateMap), lo.Keys(DefaultFeatureGates)...); len(unknown) != 0 {
+		sort.Strings(unknown)
+		return gates, fmt.Errorf("unknown feature gates %v, supported feature gates are %v", unknown, featureGateNames())
+	}
+	gateMap = lo.Assign(DefaultFeatureGates, gateMap)
+	gates.InPlaceUpdates = gateMap[FeatureGateInPlaceUpdates]
+	gates.WarmPools = gateMap[FeatureGateWarmPools]
+	gates.SpotPriceDrift = gateMap[FeatureGateSpotPriceDrift]
+	gates.LaunchJournal = gateMap[FeatureGateLaunchJournal]
+	gates.AWSClientAdaptiveThrottling = gateMap[FeatureGateAWSClientAdaptiveThrottling]
+	gates.AdvertiseNetworkBandwidth = gateMap[FeatureGateAdvertiseNetworkBandwidth]
+	gates.AdvertiseSecondaryENIs = gateMap[FeatureGateAdvertiseSecondaryENIs]
+	gates.AdvertiseNetworkCards = gateMap[FeatureGateAdvertiseNetworkCards]
+	gates.AdaptiveRegistrationTTL = gateMap[FeatureGateAdaptiveRegistrationTTL]
+	gates.DisruptionProtectionTagSync = gateMap[FeatureGateDisruptionProtectionTagSync]
+	gates.PublishNodeTemplates = gateMap[FeatureGatePublishNodeTemplates]
+	gates.PublishFleetComposition = gateMap[FeatureGatePublishFleetComposition]
+	gates.LaunchDryRun = gateMap[FeatureGateLaunchDryRun]
+	gates.SimulateNodeRolePermissions = gateMap[FeatureGateSimulateNodeRolePermissions]
+	gates.SpotPlacementScores = gateMap[FeatureGateSpotPlacementScores]
+	gates.CommitmentAwarePricing = gateMap[FeatureGateCommitmentAwarePricing]
+	gates.ValidateQuotas = gateMap[FeatureGateValidateQuotas]
+	gates.LearnVMMemoryOverhead = gateMap[FeatureGateLearnVMMemoryOverhead]
+	gates.AdvertiseEBSPerformance = gateMap[FeatureGateAdvertiseEBSPerformance]
+	gates.PrewarmLaunchTemplates = gateMap[FeatureGatePrewarmLaunchTemplates]
+	gates.ODCRFirst = gateMap[FeatureGateODCRFirst]
+	return gates, nil
+}
+
+// Map returns the state of every feature gate by its name
+func (g FeatureGates) Map() map[string]bool {
+	return map[string]bool{
+		FeatureGateInPlaceUpdates:              g.InPlaceUpdates,
+		FeatureGateWarmPools:                   g.WarmPools,
+		FeatureGateSpotPriceDrift:              g.SpotPriceDrift,
+		FeatureGateLaunchJournal:               g.LaunchJournal,
+		FeatureGateAWSClientAdaptiveThrottling: g.AWSClientAdaptiveThrottling,
+		FeatureGateAdvertiseNetworkBandwidth:   g.AdvertiseNetworkBandwidth,
+		FeatureGateAdvertiseSecondaryENIs:      g.AdvertiseSecondaryENIs,
+		FeatureGateAdvertiseNetworkCards:       g.AdvertiseNetworkCards,
+		FeatureGateAdaptiveRegistrationTTL:     g.AdaptiveRegistrationTTL,
+		FeatureGateDisruptionProtectionTagSync: g.DisruptionProtectionTagSync,
+		FeatureGatePublishNodeTemplates:        g.PublishNodeTemplates,
+		FeatureGatePublishFleetComposition:     g.PublishFleetComposition,
+		FeatureGateLaunchDryRun:                g.LaunchDryRun,
+		FeatureGateSimulateNodeRolePermissions: g.SimulateNodeRolePermissions,
+		FeatureGateSpotPlacementScores:         g.SpotPlacementScores,
+		FeatureGateCommitmentAwarePricing:      g.CommitmentAwarePricing,
+		FeatureGateValidateQuotas:              g.ValidateQuotas,
+		FeatureGateLearnVMMemoryOverhead:       g.LearnVMMemoryOverhead,
+		FeatureGateAdvertiseEBSPerformance:     g.AdvertiseEBSPerformance,
+		FeatureGatePrewarmLaunchTemplates:      g.PrewarmLaunchTemplates,
+		FeatureGateODCRFirst:                   g.ODCRFirst,
+	}
+}
+
+// DefaultFeatureGatesString returns the default of aws-feature-gates, with the default value of every gate
+func DefaultFeatureGatesString() string {
+	return strings.Join(lo.Map(featureGateNames(), func(name string, _ int) string {
+		return fmt.Sprintf("%s=%t", name, DefaultFeatureGates[name])
+	}), ",")
+}
+
+func featureGateNames() []string {
+	names := lo.Keys(DefaultFeatureGates)
+	sort.Strings(names)
+	return names
+}
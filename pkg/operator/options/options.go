@@ -20,6 +20,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
@@ -33,15 +36,83 @@ func init() {
 
 type optionsKey struct{}
 
+const (
+	// ArchitecturePreferenceCost launches the cheapest offerings regardless of their architecture
+	ArchitecturePreferenceCost = "cost"
+	// ArchitecturePreferenceARM64 prioritizes arm64 offerings over amd64 offerings
+	ArchitecturePreferenceARM64 = "arm64"
+)
+
+// The AWS Config managed rules which EC2NodeClasses can be evaluated against before launching
+const (
+	// ConfigRuleEncryptedVolumes requires the EBS volumes of instances to be encrypted
+	ConfigRuleEncryptedVolumes = "ENCRYPTED_VOLUMES"
+	// ConfigRuleIMDSv2 requires instances to require IMDSv2 session tokens
+	ConfigRuleIMDSv2 = "EC2_IMDSV2_CHECK"
+	// ConfigRuleDetailedMonitoring requires instances to have detailed monitoring enabled
+	ConfigRuleDetailedMonitoring = "EC2_INSTANCE_DETAILED_MONITORING_ENABLED"
+	// ConfigRuleNoPublicIP requires instances not to be assigned public IP addresses
+	ConfigRuleNoPublicIP = "EC2_INSTANCE_NO_PUBLIC_IP"
+)
+
+var SupportedConfigRules = []string{ConfigRuleEncryptedVolumes, ConfigRuleIMDSv2, ConfigRuleDetailedMonitoring, ConfigRuleNoPublicIP}
+
+// The categories of instance types which the instance-type-policy can exclude
+const (
+	// InstanceTypeCategoryPreviousGeneration are the instance types of previous generation families, and of families whose
+	// retirement has been announced
+	InstanceTypeCategoryPreviousGeneration = "previous-generation"
+	// InstanceTypeCategoryMetal are the bare metal instance types
+	InstanceTypeCategoryMetal = "metal"
+	// InstanceTypeCategoryBurstable are the burstable performance instance types
+	InstanceTypeCategoryBurstable = "burstable"
+)
+
+var InstanceTypeCategories = []string{InstanceTypeCategoryPreviousGeneration, InstanceTypeCategoryMetal, InstanceTypeCategoryBurstable}
+
 type Options struct {
-	ClusterCABundle         string
-	ClusterName             string
-	ClusterEndpoint         string
-	IsolatedVPC             bool
-	EKSControlPlane         bool
-	VMMemoryOverheadPercent float64
-	InterruptionQueue       string
-	ReservedENIs            int
+	ClusterCABundle                    string
+	ClusterName                        string
+	ClusterEndpoint                    string
+	IsolatedVPC                        bool
+	EKSControlPlane                    bool
+	VMMemoryOverheadPercent            float64
+	InterruptionQueue                  string
+	ReservedENIs                       int
+	AdaptiveRegistrationTTLMax         time.Duration
+	ArchitecturePreference             string
+	InterruptionQueueMessageAttribute  string
+	PolicyConfigMap                    string
+	TerminationCircuitBreakerThreshold float64
+	TerminationCircuitBreakerWindow    time.Duration
+	OfferingSnapshotConfigMap          string
+	ProvisioningAuditSize              int
+	VMMemoryOverheadPercentOverrides   string
+	LifecycleWebhookURLs               string
+	LifecycleWebhookSigningKey         string
+	MigrationClusterName               string
+	MigrationEndTime                   string
+	InterruptionQueueRoleARN           string
+	ClientMetricsEMFNamespace          string
+	ExcludePreviousGenerationFamilies  bool
+	PriceChangeThreshold               float64
+	PreflightConfigRules               string
+	InstanceTypePolicy                 string
+	RequireEncryption                  bool
+	EncryptionKMSKeyARNs               string
+	SSMParameterPrefix                 string
+	InstanceTagLabels                  string
+	UseFIPSEndpoints                   bool
+	AWSClientMaxIdleConnsPerHost       int
+	AWSClientIdleConnTimeout           time.Duration
+	AWSClientDisableHTTP2              bool
+	UnavailableOfferingsTTLs           string
+	CostAttributionLabel               string
+	FeatureGates                       FeatureGates
+
+	// vmMemoryOverheadPercentOverrides is vm-memory-overhead-percent-overrides parsed once during Parse, since the
+	// overrides are looked up on the instance type resolution hot path
+	vmMemoryOverheadPercentOverrides map[string]float64
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -51,8 +122,40 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.BoolVarWithEnv(&o.IsolatedVPC, "isolated-vpc", "ISOLATED_VPC", false, "If true, then assume we can't reach AWS services which don't have a VPC endpoint. This also has the effect of disabling look-ups to the AWS on-demand pricing endpoint.")
 	fs.BoolVarWithEnv(&o.EKSControlPlane, "eks-control-plane", "EKS_CONTROL_PLANE", false, "Marking this true means that your cluster is running with an EKS control plane and Karpenter should attempt to discover cluster details from the DescribeCluster API ")
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", utils.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types when cached information is unavailable.")
-	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is the name of the SQS queue used for processing interruption events from EC2. Interruption handling is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
+	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is the name of the SQS queue used for processing interruption events from EC2. A comma-separated list of queue names, queue URLs or queue ARNs can be specified to poll multiple queues, e.g. one per region or account. Interruption handling is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
 	fs.IntVar(&o.ReservedENIs, "reserved-enis", env.WithDefaultInt("RESERVED_ENIS", 0), "Reserved ENIs are not included in the calculations for max-pods or kube-reserved. This is most often used in the VPC CNI custom networking setup https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html.")
+	fs.DurationVar(&o.AdaptiveRegistrationTTLMax, "adaptive-registration-ttl-max", env.WithDefaultDuration("ADAPTIVE_REGISTRATION_TTL_MAX", 15*time.Minute), "The upper bound of the registration timeouts learned by the AdaptiveRegistrationTTL feature gate. The registration TTL of the liveness check, which deletes NodeClaims that haven't registered after 15 minutes, still applies to NodeClaims whose learned timeout is longer.")
+	fs.StringVar(&o.ArchitecturePreference, "architecture-preference", env.WithDefaultString("ARCHITECTURE_PREFERENCE", ArchitecturePreferenceCost), "The architecture preference used when a NodeClaim can be launched on both amd64 and arm64 instance types. \"cost\" launches the cheapest offerings regardless of architecture, while \"arm64\" prioritizes arm64 offerings and only falls back to amd64 offerings when no arm64 capacity is available.")
+	fs.StringVar(&o.InterruptionQueueMessageAttribute, "interruption-queue-message-attribute", env.WithDefaultString("INTERRUPTION_QUEUE_MESSAGE_ATTRIBUTE", ""), "The name of an SQS message attribute which identifies the cluster that an interruption message is intended for. If set, only messages whose attribute matches the cluster name are handled, and all other messages are returned to the queue for other clusters. This allows a single interruption queue to be shared by multiple clusters.")
+	fs.StringVar(&o.PolicyConfigMap, "policy-configmap", env.WithDefaultString("POLICY_CONFIGMAP", ""), "The name of a ConfigMap in the Karpenter namespace containing Cedar launch policies, which are evaluated over the offerings of every launch. Offerings denied by a forbid policy aren't launched.")
+	fs.Float64Var(&o.TerminationCircuitBreakerThreshold, "termination-circuit-breaker-threshold", utils.WithDefaultFloat64("TERMINATION_CIRCUIT_BREAKER_THRESHOLD", 0), "The fraction of a NodePool's nodes which can be deleted within the termination-circuit-breaker-window before voluntary disruption of the NodePool is paused until the pause is acknowledged. Set to 0 to disable the circuit breaker.")
+	fs.DurationVar(&o.TerminationCircuitBreakerWindow, "termination-circuit-breaker-window", env.WithDefaultDuration("TERMINATION_CIRCUIT_BREAKER_WINDOW", 10*time.Minute), "The window over which node deletions are counted by the termination circuit breaker.")
+	fs.StringVar(&o.OfferingSnapshotConfigMap, "offering-snapshot-configmap", env.WithDefaultString("OFFERING_SNAPSHOT_CONFIGMAP", ""), "The name of a ConfigMap in the Karpenter namespace containing an offering snapshot, which replaces the instance types, offerings and prices that Karpenter discovers from the EC2 and pricing APIs. Used in air-gapped environments which can't reach these APIs.")
+	fs.IntVar(&o.ProvisioningAuditSize, "provisioning-audit-size", env.WithDefaultInt("PROVISIONING_AUDIT_SIZE", 0), "The number of provisioning and disruption actions that are retained in the ProvisioningAudit of each NodePool. If zero, then ProvisioningAudits are not maintained.")
+	fs.StringVar(&o.VMMemoryOverheadPercentOverrides, "vm-memory-overhead-percent-overrides", env.WithDefaultString("VM_MEMORY_OVERHEAD_PERCENT_OVERRIDES", ""), "A comma-separated list of instance-type-or-family=percent pairs, e.g. r7i=0.05,m5.metal=0.02, which override vm-memory-overhead-percent for instance types and families. An override for an instance type takes precedence over an override for its family.")
+	fs.StringVar(&o.LifecycleWebhookURLs, "lifecycle-webhook-urls", env.WithDefaultString("LIFECYCLE_WEBHOOK_URLS", ""), "A comma-separated list of HTTP(S) URLs which are sent a JSON payload when a NodeClaim is launched, registered, starts terminating and is terminated. Lifecycle webhooks are disabled if not specified.")
+	fs.StringVar(&o.LifecycleWebhookSigningKey, "lifecycle-webhook-signing-key", env.WithDefaultString("LIFECYCLE_WEBHOOK_SIGNING_KEY", ""), "The key used to sign the payloads of lifecycle webhooks with HMAC-SHA256. The signature is sent in the X-Karpenter-Signature header. Payloads are not signed if not specified.")
+	fs.StringVar(&o.MigrationClusterName, "migration-cluster-name", env.WithDefaultString("MIGRATION_CLUSTER_NAME", ""), "The previous name of the cluster while it is being migrated to cluster-name. Until migration-end-time, subnets and security groups whose karpenter.sh/discovery tag is the previous name are discovered as if they belonged to the cluster. Instances, launch templates and interruption messages of the previous name are never treated as the cluster's.")
+	fs.StringVar(&o.MigrationEndTime, "migration-end-time", env.WithDefaultString("MIGRATION_END_TIME", ""), "The time, in RFC3339 format, at which resources tagged with migration-cluster-name are no longer treated as belonging to the cluster. Required if migration-cluster-name is set.")
+	fs.StringVar(&o.InterruptionQueueRoleARN, "interruption-queue-role-arn", env.WithDefaultString("INTERRUPTION_QUEUE_ROLE_ARN", ""), "The ARN of an IAM role which is assumed to poll the interruption queues, e.g. when interruption events are routed through a centralized EventBridge bus to a queue in a different account. If not specified, the queues are polled with the controller's credentials.")
+	fs.StringVar(&o.ClientMetricsEMFNamespace, "client-metrics-emf-namespace", env.WithDefaultString("CLIENT_METRICS_EMF_NAMESPACE", ""), "The CloudWatch namespace of the AWS client metrics which are written to stdout every minute in CloudWatch embedded metric format (EMF), with the calls, attempts, throttles, errors and latency of each AWS operation. The metrics are extracted by CloudWatch Logs once the logs are shipped to a log group. EMF client metrics are disabled if not specified.")
+	fs.BoolVarWithEnv(&o.ExcludePreviousGenerationFamilies, "exclude-previous-generation-families", "EXCLUDE_PREVIOUS_GENERATION_FAMILIES", false, "If true, then the instance types of previous generation families, and of families whose retirement has been announced, are excluded from the instance types that NodePools can launch, unless a NodePool explicitly selects them by instance family or instance type.")
+	fs.Float64Var(&o.PriceChangeThreshold, "price-change-threshold", utils.WithDefaultFloat64("PRICE_CHANGE_THRESHOLD", 0), "The fraction by which the price of an instance type that nodes are running on must change after a pricing refresh for an event to be published on the NodePools of the nodes, e.g. 0.1 for a change of 10%. Price changes are always recorded in the karpenter_pricing_price_changes_total metric. Set to 0 to disable price change events.")
+	fs.StringVar(&o.PreflightConfigRules, "preflight-config-rules", env.WithDefaultString("PREFLIGHT_CONFIG_RULES", ""), "A comma-separated list of AWS Config managed rules, e.g. ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK, which each EC2NodeClass is evaluated against before launching. An EC2NodeClass whose launches would violate a rule fails validation and doesn't launch instances. Supported rules are ENCRYPTED_VOLUMES, EC2_IMDSV2_CHECK, EC2_INSTANCE_DETAILED_MONITORING_ENABLED and EC2_INSTANCE_NO_PUBLIC_IP.")
+	fs.StringVar(&o.InstanceTypePolicy, "instance-type-policy", env.WithDefaultString("INSTANCE_TYPE_POLICY", ""), "A comma-separated list of instance types, instance families and instance type categories, e.g. previous-generation,metal,t2,m5.24xlarge, which are excluded from every NodePool. Supported categories are previous-generation, metal and burstable.")
+	fs.BoolVarWithEnv(&o.RequireEncryption, "require-encryption", "REQUIRE_ENCRYPTION", false, "If true, then the EBS volumes of instances and the snapshots of the AMIs that they're launched from must be encrypted. An EC2NodeClass with a block device mapping whose volume isn't encrypted fails validation, and an EC2NodeClass which resolves an unencrypted AMI isn't ready, so neither launches instances.")
+	fs.StringVar(&o.EncryptionKMSKeyARNs, "encryption-kms-key-arns", env.WithDefaultString("ENCRYPTION_KMS_KEY_ARNS", ""), "A comma-separated list of KMS key ARNs which the block device mappings of EC2NodeClasses must encrypt volumes with when require-encryption is enabled. If not specified, volumes can be encrypted with any key.")
+	fs.StringVar(&o.SSMParameterPrefix, "ssm-parameter-prefix", env.WithDefaultString("SSM_PARAMETER_PREFIX", ""), "The path that the public SSM parameters which AMI aliases are resolved from are published under, e.g. /aws/service. Set this in partitions and regions which publish the parameters under a different path, or to a path which the parameters are mirrored to. If not specified, the parameters are resolved from /aws/service.")
+	fs.StringVar(&o.InstanceTagLabels, "instance-tag-labels", env.WithDefaultString("INSTANCE_TAG_LABELS", ""), "A comma-separated list of prefixes of instance tag keys, e.g. compliance.example.com/, whose tags are reflected as labels of the node when it registers. This allows automation which tags instances between launch and registration to label nodes. Tags which aren't valid labels or which use a restricted label domain are skipped. Instance tags aren't reflected as labels if not specified.")
+	fs.BoolVarWithEnv(&o.UseFIPSEndpoints, "aws-use-fips-endpoints", "AWS_USE_FIPS_ENDPOINTS", false, "If true, then the FIPS endpoints of the AWS APIs are used, e.g. in FIPS-mandated environments. The pricing and Savings Plans APIs, which don't have FIPS endpoints, are still called through their standard endpoints. The endpoints of the partition of the region are always used, so this isn't needed to run in the aws-cn, aws-us-gov or aws-iso partitions.")
+	fs.IntVar(&o.AWSClientMaxIdleConnsPerHost, "aws-client-max-idle-conns-per-host", env.WithDefaultInt("AWS_CLIENT_MAX_IDLE_CONNS_PER_HOST", 10), "The number of idle connections to each AWS API endpoint which are kept open for reuse by the AWS clients. Requests which are made while every kept connection is in use open a new connection, which requires a TLS handshake, and the connection is closed after the request if the pool is full. Raise this if karpenter_cloudprovider_aws_client_connections_total shows that few connections are reused.")
+	fs.DurationVar(&o.AWSClientIdleConnTimeout, "aws-client-idle-conn-timeout", env.WithDefaultDuration("AWS_CLIENT_IDLE_CONN_TIMEOUT", 90*time.Second), "The duration that an idle connection to an AWS API endpoint is kept open for reuse before it's closed. Connections which are closed by the endpoint or by a NAT gateway before the timeout are reopened when they're next used.")
+	fs.BoolVarWithEnv(&o.AWSClientDisableHTTP2, "aws-client-disable-http2", "AWS_CLIENT_DISABLE_HTTP2", false, "If true, then the AWS clients only use HTTP/1.1. By default, HTTP/2 is negotiated with the AWS API endpoints which support it, so that concurrent requests share a connection. Disable HTTP/2 if a proxy between Karpenter and the endpoints doesn't support it.")
+	fs.StringVar(&o.UnavailableOfferingsTTLs, "unavailable-offerings-ttls", env.WithDefaultString("UNAVAILABLE_OFFERINGS_TTLS", ""), "A comma-separated list of reason=duration pairs, e.g. InsufficientInstanceCapacity=5m,MaxSpotInstanceCountExceeded=15m, which override how long an offering is unavailable for launch after it's marked as unavailable for the reason. The reasons are the error codes of CreateFleet, e.g. InsufficientInstanceCapacity, and the kinds of spot interruption messages, e.g. spot_interrupted. Offerings are unavailable for 3 minutes for other reasons.")
+	fs.StringVar(&o.CostAttributionLabel, "cost-attribution-label", env.WithDefaultString("COST_ATTRIBUTION_LABEL", ""), "The key of a pod label, e.g. team, that instances are tagged with for cost attribution. Each instance is tagged with the namespace and the value of the label of the workload whose pods request the most CPU on its node, as the karpenter.k8s.aws/cost-attribution-namespace tag and a tag whose key is the label key. Cost attribution tags are disabled if not specified.")
+
+	// Incubating features of the AWS provider are gated here, separately from the feature-gates of karpenter-core
+	fs.StringVar(&o.FeatureGates.inputStr, "aws-feature-gates", env.WithDefaultString("AWS_FEATURE_GATES", DefaultFeatureGatesString()), fmt.Sprintf("Incubating features of the AWS provider can be enabled / disabled using feature gates. Current options are: %s", strings.Join(featureGateNames(), ", ")))
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
@@ -65,6 +168,13 @@ func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
 	if err := o.Validate(); err != nil {
 		return fmt.Errorf("validating options, %w", err)
 	}
+	// The overrides are valid, since they were validated above
+	o.vmMemoryOverheadPercentOverrides, _ = parseVMMemoryOverheadPercentOverrides(o.VMMemoryOverheadPercentOverrides)
+	gates, err := ParseFeatureGates(o.FeatureGates.inputStr)
+	if err != nil {
+		return fmt.Errorf("parsing aws feature gates, %w", err)
+	}
+	o.FeatureGates = gates
 	return nil
 }
 
@@ -83,3 +193,163 @@ func FromContext(ctx context.Context) *Options {
 	}
 	return retval.(*Options)
 }
+
+// InterruptionQueues returns the names or URLs of the SQS queues in the interruption-queue setting. Each queue is
+// polled independently.
+func (o Options) InterruptionQueues() []string {
+	var queues []string
+	for _, queue := range strings.Split(o.InterruptionQueue, ",") {
+		if queue = strings.TrimSpace(queue); queue != "" {
+			queues = append(queues, queue)
+		}
+	}
+	return queues
+}
+
+// DiscoveryClusterNames returns the cluster names which subnets and security groups are discovered by. While the cluster
+// is being migrated from a previous name, this includes migration-cluster-name until migration-end-time. Resources that
+// Karpenter owns, like instances and launch templates, are only those tagged with cluster-name, so that the resources of
+// the previous cluster are never garbage collected or terminated.
+func (o Options) DiscoveryClusterNames(now time.Time) []string {
+	names := []string{o.ClusterName}
+	if o.MigrationClusterName == "" || o.MigrationClusterName == o.ClusterName {
+		return names
+	}
+	if end, err := time.Parse(time.RFC3339, o.MigrationEndTime); err != nil || !now.Before(end) {
+		return names
+	}
+	return append(names, o.MigrationClusterName)
+}
+
+// LifecycleWebhooks returns the URLs in the lifecycle-webhook-urls setting
+func (o Options) LifecycleWebhooks() []string {
+	var urls []string
+	for _, u := range strings.Split(o.LifecycleWebhookURLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// VMMemoryOverheadPercentFor returns the VM memory overhead percent that applies to the instance type, preferring an
+// override for the instance type, then an override for its family, then vm-memory-overhead-percent.
+func (o Options) VMMemoryOverheadPercentFor(instanceType string) float64 {
+	if percent, ok := o.VMMemoryOverheadPercentOverride(instanceType); ok {
+		return percent
+	}
+	return o.VMMemoryOverheadPercent
+}
+
+// ConfigRules returns the AWS Config managed rules in the preflight-config-rules setting
+func (o Options) ConfigRules() []string {
+	var rules []string
+	for _, rule := range strings.Split(o.PreflightConfigRules, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// VMMemoryOverheadPercentOverride returns the override in vm-memory-overhead-percent-overrides which applies to the
+// instance type, if any. The overrides are only parsed here for Options which weren't created by Parse, e.g. in tests.
+// Malformed entries are ignored since they're rejected during validation.
+func (o Options) VMMemoryOverheadPercentOverride(instanceType string) (float64, bool) {
+	overrides := o.vmMemoryOverheadPercentOverrides
+	if overrides == nil {
+		overrides, _ = parseVMMemoryOverheadPercentOverrides(o.VMMemoryOverheadPercentOverrides)
+	}
+	if percent, ok := overrides[instanceType]; ok {
+		return percent, true
+	}
+	family, _, _ := strings.Cut(instanceType, ".")
+	percent, ok := overrides[family]
+	return percent, ok
+}
+
+// InstanceTypePolicyExclusions returns the instance types, instance families and instance type categories in the
+// instance-type-policy setting
+func (o Options) InstanceTypePolicyExclusions() []string {
+	var exclusions []string
+	for _, exclusion := range strings.Split(o.InstanceTypePolicy, ",") {
+		if exclusion = strings.TrimSpace(exclusion); exclusion != "" {
+			exclusions = append(exclusions, exclusion)
+		}
+	}
+	return exclusions
+}
+
+func parseVMMemoryOverheadPercentOverrides(value string) (map[string]float64, error) {
+	overrides := map[string]float64{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, rawPercent, ok := strings.Cut(entry, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("%q is not of the form <instance-type-or-family>=<percent>", entry)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSpace(rawPercent), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q has an invalid percent, %w", entry, err)
+		}
+		if percent < 0 || percent >= 1 {
+			return nil, fmt.Errorf("%q has a percent outside of [0, 1)", entry)
+		}
+		overrides[key] = percent
+	}
+	return overrides, nil
+}
+
+// UnavailableOfferingsTTL returns the TTL in unavailable-offerings-ttls of offerings which are marked as unavailable
+// for the reason, if any. Malformed entries are ignored since they're rejected during validation.
+func (o Options) UnavailableOfferingsTTL(reason string) (time.Duration, bool) {
+	ttls, _ := parseUnavailableOfferingsTTLs(o.UnavailableOfferingsTTLs)
+	ttl, ok := ttls[reason]
+	return ttl, ok
+}
+
+func parseUnavailableOfferingsTTLs(value string) (map[string]time.Duration, error) {
+	ttls := map[string]time.Duration{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		reason, rawTTL, ok := strings.Cut(entry, "=")
+		if reason = strings.TrimSpace(reason); !ok || reason == "" {
+			return nil, fmt.Errorf("%q is not of the form <reason>=<duration>", entry)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(rawTTL))
+		if err != nil {
+			return nil, fmt.Errorf("%q has an invalid duration, %w", entry, err)
+		}
+		if ttl <= 0 {
+			return nil, fmt.Errorf("%q has a duration which isn't positive", entry)
+		}
+		ttls[reason] = ttl
+	}
+	return ttls, nil
+}
+
+// EncryptionKMSKeys returns the KMS key ARNs in the encryption-kms-key-arns setting
+func (o Options) EncryptionKMSKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(o.EncryptionKMSKeyARNs, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// InstanceTagLabelPrefixes returns the prefixes of instance tag keys in the instance-tag-labels setting
+func (o Options) InstanceTagLabelPrefixes() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(o.InstanceTagLabels, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
@@ -17,16 +17,43 @@ package options
 import (
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 func (o Options) Validate() error {
 	return multierr.Combine(
 		o.validateEndpoint(),
 		o.validateVMMemoryOverheadPercent(),
+		o.validateVMMemoryOverheadPercentOverrides(),
+		o.validateUnavailableOfferingsTTLs(),
 		o.validateReservedENIs(),
+		o.validateProvisioningAuditSize(),
 		o.validateRequiredFields(),
+		o.validateArchitecturePreference(),
+		o.validateTerminationCircuitBreaker(),
+		o.validatePriceChangeThreshold(),
+		o.validateInterruptionQueues(),
+		o.validateInterruptionQueueRoleARN(),
+		o.validateLifecycleWebhooks(),
+		o.validateMigration(),
+		o.validatePreflightConfigRules(),
+		o.validateInstanceTypePolicy(),
+		o.validateEncryptionKMSKeyARNs(),
+		o.validateSSMParameterPrefix(),
+		o.validateInstanceTagLabels(),
+		o.validateCostAttributionLabel(),
+		o.validateAdaptiveRegistrationTTLMax(),
+		o.validateAWSClient(),
 	)
 }
 
@@ -43,6 +70,173 @@ func (o Options) validateEndpoint() error {
 	return nil
 }
 
+func (o Options) validateSSMParameterPrefix() error {
+	if o.SSMParameterPrefix == "" {
+		return nil
+	}
+	if !strings.HasPrefix(o.SSMParameterPrefix, "/") || strings.HasSuffix(o.SSMParameterPrefix, "/") {
+		return fmt.Errorf("ssm-parameter-prefix must start with a '/' and must not end with a '/'")
+	}
+	return nil
+}
+
+func (o Options) validateInstanceTagLabels() error {
+	for _, prefix := range o.InstanceTagLabelPrefixes() {
+		// A prefix which ends in a restricted label domain, e.g. karpenter.sh/, would only select tags which are skipped
+		if karpv1.IsRestrictedNodeLabel(prefix + "label") {
+			return fmt.Errorf("instance-tag-labels prefix %q selects restricted labels", prefix)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateCostAttributionLabel() error {
+	if o.CostAttributionLabel == "" {
+		return nil
+	}
+	if errs := validation.IsQualifiedName(o.CostAttributionLabel); len(errs) != 0 {
+		return fmt.Errorf("cost-attribution-label %q is not a valid label key, %s", o.CostAttributionLabel, strings.Join(errs, ", "))
+	}
+	// The label key is also the key of the instance tag, so it can't be a tag which Karpenter manages
+	for _, exp := range v1.RestrictedTagPatterns {
+		if exp.MatchString(o.CostAttributionLabel) {
+			return fmt.Errorf("cost-attribution-label %q is a restricted tag key", o.CostAttributionLabel)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateEncryptionKMSKeyARNs() error {
+	keys := o.EncryptionKMSKeys()
+	if len(keys) != 0 && !o.RequireEncryption {
+		return fmt.Errorf("encryption-kms-key-arns can only be set when require-encryption is enabled")
+	}
+	for _, key := range keys {
+		if a, err := arn.Parse(key); err != nil || a.Service != "kms" || !strings.HasPrefix(a.Resource, "key/") {
+			return fmt.Errorf("%q is not the ARN of a KMS key", key)
+		}
+	}
+	return nil
+}
+
+// instanceTypeOrFamily matches instance family names, e.g. m5, and instance type names, e.g. m5.24xlarge
+var instanceTypeOrFamily = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)?$`)
+
+func (o Options) validateInstanceTypePolicy() error {
+	for _, exclusion := range o.InstanceTypePolicyExclusions() {
+		if !lo.Contains(InstanceTypeCategories, exclusion) && !instanceTypeOrFamily.MatchString(exclusion) {
+			return fmt.Errorf("%q is not an instance type, instance family or one of the instance-type-policy categories %s", exclusion, strings.Join(InstanceTypeCategories, ", "))
+		}
+	}
+	return nil
+}
+
+func (o Options) validatePreflightConfigRules() error {
+	for _, rule := range o.ConfigRules() {
+		if !lo.Contains(SupportedConfigRules, rule) {
+			return fmt.Errorf("%q is not a supported preflight-config-rules rule, must be one of %s", rule, strings.Join(SupportedConfigRules, ", "))
+		}
+	}
+	return nil
+}
+
+func (o Options) validatePriceChangeThreshold() error {
+	if o.PriceChangeThreshold < 0 {
+		return fmt.Errorf("price-change-threshold cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateMigration() error {
+	if o.MigrationClusterName == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, o.MigrationEndTime); err != nil {
+		return fmt.Errorf("migration-end-time must be an RFC3339 time when migration-cluster-name is set, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateLifecycleWebhooks() error {
+	for _, webhook := range o.LifecycleWebhooks() {
+		if webhookURL, err := url.Parse(webhook); err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Hostname() == "" {
+			return fmt.Errorf("%q is not a valid lifecycle-webhook-urls URL", webhook)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateVMMemoryOverheadPercentOverrides() error {
+	if _, err := parseVMMemoryOverheadPercentOverrides(o.VMMemoryOverheadPercentOverrides); err != nil {
+		return fmt.Errorf("invalid vm-memory-overhead-percent-overrides, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateUnavailableOfferingsTTLs() error {
+	if _, err := parseUnavailableOfferingsTTLs(o.UnavailableOfferingsTTLs); err != nil {
+		return fmt.Errorf("invalid unavailable-offerings-ttls, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateProvisioningAuditSize() error {
+	if o.ProvisioningAuditSize < 0 {
+		return fmt.Errorf("provisioning-audit-size cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionQueues() error {
+	seen := map[string]bool{}
+	for _, queue := range o.InterruptionQueues() {
+		if seen[queue] {
+			return fmt.Errorf("interruption-queue %q is specified more than once", queue)
+		}
+		seen[queue] = true
+		if arn.IsARN(queue) {
+			if a, err := arn.Parse(queue); err != nil || a.Service != "sqs" {
+				return fmt.Errorf("%q is not a valid interruption-queue ARN", queue)
+			}
+			continue
+		}
+		if !strings.Contains(queue, "://") {
+			continue
+		}
+		if queueURL, err := url.Parse(queue); err != nil || !queueURL.IsAbs() || queueURL.Hostname() == "" {
+			return fmt.Errorf("%q is not a valid interruption-queue URL", queue)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionQueueRoleARN() error {
+	if o.InterruptionQueueRoleARN == "" {
+		return nil
+	}
+	if a, err := arn.Parse(o.InterruptionQueueRoleARN); err != nil || a.Service != "iam" || !strings.HasPrefix(a.Resource, "role/") {
+		return fmt.Errorf("%q is not a valid interruption-queue-role-arn", o.InterruptionQueueRoleARN)
+	}
+	return nil
+}
+
+func (o Options) validateTerminationCircuitBreaker() error {
+	if o.TerminationCircuitBreakerThreshold < 0 || o.TerminationCircuitBreakerThreshold > 1 {
+		return fmt.Errorf("termination-circuit-breaker-threshold must be between 0 and 1")
+	}
+	if o.TerminationCircuitBreakerWindow <= 0 {
+		return fmt.Errorf("termination-circuit-breaker-window must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateArchitecturePreference() error {
+	if o.ArchitecturePreference != ArchitecturePreferenceCost && o.ArchitecturePreference != ArchitecturePreferenceARM64 {
+		return fmt.Errorf("%q is not a valid architecture-preference, must be one of %q or %q", o.ArchitecturePreference, ArchitecturePreferenceCost, ArchitecturePreferenceARM64)
+	}
+	return nil
+}
+
 func (o Options) validateVMMemoryOverheadPercent() error {
 	if o.VMMemoryOverheadPercent < 0 {
 		return fmt.Errorf("vm-memory-overhead-percent cannot be negative")
@@ -63,3 +257,20 @@ func (o Options) validateRequiredFields() error {
 	}
 	return nil
 }
+
+func (o Options) validateAdaptiveRegistrationTTLMax() error {
+	if o.AdaptiveRegistrationTTLMax <= 0 {
+		return fmt.Errorf("adaptive-registration-ttl-max must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateAWSClient() error {
+	if o.AWSClientMaxIdleConnsPerHost <= 0 {
+		return fmt.Errorf("aws-client-max-idle-conns-per-host must be positive")
+	}
+	if o.AWSClientIdleConnTimeout <= 0 {
+		return fmt.Errorf("aws-client-idle-conn-timeout must be positive")
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
@@ -62,16 +63,76 @@ var _ = Describe("Options", func() {
 			"--isolated-vpc",
 			"--vm-memory-overhead-percent", "0.1",
 			"--interruption-queue", "env-cluster",
-			"--reserved-enis", "10")
+			"--reserved-enis", "10",
+			"--adaptive-registration-ttl-max", "20m",
+			"--architecture-preference", "arm64",
+			"--interruption-queue-message-attribute", "karpenter.sh/cluster",
+			"--policy-configmap", "karpenter-launch-policies",
+			"--termination-circuit-breaker-threshold", "0.2",
+			"--termination-circuit-breaker-window", "5m",
+			"--offering-snapshot-configmap", "karpenter-offering-snapshot",
+			"--provisioning-audit-size", "50",
+			"--vm-memory-overhead-percent-overrides", "r7i=0.05,m5.metal=0.02",
+			"--lifecycle-webhook-urls", "https://example.com/karpenter",
+			"--lifecycle-webhook-signing-key", "test-signing-key",
+			"--migration-cluster-name", "previous-cluster",
+			"--migration-end-time", "2030-01-01T00:00:00Z",
+			"--interruption-queue-role-arn", "arn:aws:iam::000000000000:role/KarpenterInterruption",
+			"--client-metrics-emf-namespace", "Karpenter",
+			"--exclude-previous-generation-families",
+			"--price-change-threshold", "0.1",
+			"--preflight-config-rules", "ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK",
+			"--instance-type-policy", "metal,t2",
+			"--require-encryption",
+			"--encryption-kms-key-arns", "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			"--ssm-parameter-prefix", "/karpenter/mirror",
+			"--instance-tag-labels", "compliance.example.com/,team",
+			"--aws-use-fips-endpoints",
+			"--aws-client-max-idle-conns-per-host", "50",
+			"--aws-client-idle-conn-timeout", "5m",
+			"--aws-client-disable-http2",
+			"--unavailable-offerings-ttls", "InsufficientInstanceCapacity=5m",
+			"--cost-attribution-label", "team",
+			"--aws-feature-gates", "WarmPools=true,LaunchJournal=true,AWSClientAdaptiveThrottling=true,AdvertiseNetworkBandwidth=true,AdvertiseSecondaryENIs=true,AdvertiseNetworkCards=true,AdaptiveRegistrationTTL=true,DisruptionProtectionTagSync=true,PublishNodeTemplates=true,PublishFleetComposition=true,LaunchDryRun=true,SimulateNodeRolePermissions=true,SpotPlacementScores=true,CommitmentAwarePricing=true,ValidateQuotas=true,LearnVMMemoryOverhead=true,AdvertiseEBSPerformance=true,PrewarmLaunchTemplates=true,ODCRFirst=true")
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			ClusterCABundle:                    lo.ToPtr("env-bundle"),
+			ClusterName:                        lo.ToPtr("env-cluster"),
+			ClusterEndpoint:                    lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                        lo.ToPtr(true),
+			VMMemoryOverheadPercent:            lo.ToPtr[float64](0.1),
+			InterruptionQueue:                  lo.ToPtr("env-cluster"),
+			ReservedENIs:                       lo.ToPtr(10),
+			AdaptiveRegistrationTTLMax:         lo.ToPtr(20 * time.Minute),
+			ArchitecturePreference:             lo.ToPtr("arm64"),
+			InterruptionQueueMessageAttribute:  lo.ToPtr("karpenter.sh/cluster"),
+			PolicyConfigMap:                    lo.ToPtr("karpenter-launch-policies"),
+			TerminationCircuitBreakerThreshold: lo.ToPtr[float64](0.2),
+			TerminationCircuitBreakerWindow:    lo.ToPtr[time.Duration](5 * time.Minute),
+			OfferingSnapshotConfigMap:          lo.ToPtr("karpenter-offering-snapshot"),
+			ProvisioningAuditSize:              lo.ToPtr(50),
+			VMMemoryOverheadPercentOverrides:   lo.ToPtr("r7i=0.05,m5.metal=0.02"),
+			LifecycleWebhookURLs:               lo.ToPtr("https://example.com/karpenter"),
+			LifecycleWebhookSigningKey:         lo.ToPtr("test-signing-key"),
+			MigrationClusterName:               lo.ToPtr("previous-cluster"),
+			MigrationEndTime:                   lo.ToPtr("2030-01-01T00:00:00Z"),
+			InterruptionQueueRoleARN:           lo.ToPtr("arn:aws:iam::000000000000:role/KarpenterInterruption"),
+			ClientMetricsEMFNamespace:          lo.ToPtr("Karpenter"),
+			ExcludePreviousGenerationFamilies:  lo.ToPtr(true),
+			PriceChangeThreshold:               lo.ToPtr(0.1),
+			PreflightConfigRules:               lo.ToPtr("ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK"),
+			InstanceTypePolicy:                 lo.ToPtr("metal,t2"),
+			RequireEncryption:                  lo.ToPtr(true),
+			EncryptionKMSKeyARNs:               lo.ToPtr("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			SSMParameterPrefix:                 lo.ToPtr("/karpenter/mirror"),
+			InstanceTagLabels:                  lo.ToPtr("compliance.example.com/,team"),
+			UseFIPSEndpoints:                   lo.ToPtr(true),
+			AWSClientMaxIdleConnsPerHost:       lo.ToPtr(50),
+			AWSClientIdleConnTimeout:           lo.ToPtr(5 * time.Minute),
+			AWSClientDisableHTTP2:              lo.ToPtr(true),
+			UnavailableOfferingsTTLs:           lo.ToPtr("InsufficientInstanceCapacity=5m"),
+			CostAttributionLabel:               lo.ToPtr("team"),
+			FeatureGates:                       test.FeatureGates{WarmPools: lo.ToPtr(true), LaunchJournal: lo.ToPtr(true), AWSClientAdaptiveThrottling: lo.ToPtr(true), AdvertiseNetworkBandwidth: lo.ToPtr(true), AdvertiseSecondaryENIs: lo.ToPtr(true), AdvertiseNetworkCards: lo.ToPtr(true), AdaptiveRegistrationTTL: lo.ToPtr(true), DisruptionProtectionTagSync: lo.ToPtr(true), PublishNodeTemplates: lo.ToPtr(true), PublishFleetComposition: lo.ToPtr(true), LaunchDryRun: lo.ToPtr(true), SimulateNodeRolePermissions: lo.ToPtr(true), SpotPlacementScores: lo.ToPtr(true), CommitmentAwarePricing: lo.ToPtr(true), ValidateQuotas: lo.ToPtr(true), LearnVMMemoryOverhead: lo.ToPtr(true), AdvertiseEBSPerformance: lo.ToPtr(true), PrewarmLaunchTemplates: lo.ToPtr(true), ODCRFirst: lo.ToPtr(true)},
 		}))
 	})
 	It("should correctly fallback to env vars when CLI flags aren't set", func() {
@@ -82,6 +143,36 @@ var _ = Describe("Options", func() {
 		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT", "0.1")
 		os.Setenv("INTERRUPTION_QUEUE", "env-cluster")
 		os.Setenv("RESERVED_ENIS", "10")
+		os.Setenv("ADAPTIVE_REGISTRATION_TTL_MAX", "20m")
+		os.Setenv("ARCHITECTURE_PREFERENCE", "arm64")
+		os.Setenv("INTERRUPTION_QUEUE_MESSAGE_ATTRIBUTE", "karpenter.sh/cluster")
+		os.Setenv("POLICY_CONFIGMAP", "karpenter-launch-policies")
+		os.Setenv("TERMINATION_CIRCUIT_BREAKER_THRESHOLD", "0.2")
+		os.Setenv("TERMINATION_CIRCUIT_BREAKER_WINDOW", "5m")
+		os.Setenv("OFFERING_SNAPSHOT_CONFIGMAP", "karpenter-offering-snapshot")
+		os.Setenv("PROVISIONING_AUDIT_SIZE", "50")
+		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT_OVERRIDES", "r7i=0.05,m5.metal=0.02")
+		os.Setenv("LIFECYCLE_WEBHOOK_URLS", "https://example.com/karpenter")
+		os.Setenv("LIFECYCLE_WEBHOOK_SIGNING_KEY", "test-signing-key")
+		os.Setenv("MIGRATION_CLUSTER_NAME", "previous-cluster")
+		os.Setenv("MIGRATION_END_TIME", "2030-01-01T00:00:00Z")
+		os.Setenv("INTERRUPTION_QUEUE_ROLE_ARN", "arn:aws:iam::000000000000:role/KarpenterInterruption")
+		os.Setenv("CLIENT_METRICS_EMF_NAMESPACE", "Karpenter")
+		os.Setenv("EXCLUDE_PREVIOUS_GENERATION_FAMILIES", "true")
+		os.Setenv("PRICE_CHANGE_THRESHOLD", "0.1")
+		os.Setenv("PREFLIGHT_CONFIG_RULES", "ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK")
+		os.Setenv("INSTANCE_TYPE_POLICY", "metal,t2")
+		os.Setenv("REQUIRE_ENCRYPTION", "true")
+		os.Setenv("ENCRYPTION_KMS_KEY_ARNS", "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab")
+		os.Setenv("SSM_PARAMETER_PREFIX", "/karpenter/mirror")
+		os.Setenv("INSTANCE_TAG_LABELS", "compliance.example.com/,team")
+		os.Setenv("AWS_USE_FIPS_ENDPOINTS", "true")
+		os.Setenv("AWS_CLIENT_MAX_IDLE_CONNS_PER_HOST", "50")
+		os.Setenv("AWS_CLIENT_IDLE_CONN_TIMEOUT", "5m")
+		os.Setenv("AWS_CLIENT_DISABLE_HTTP2", "true")
+		os.Setenv("UNAVAILABLE_OFFERINGS_TTLS", "InsufficientInstanceCapacity=5m")
+		os.Setenv("COST_ATTRIBUTION_LABEL", "team")
+		os.Setenv("AWS_FEATURE_GATES", "WarmPools=true,LaunchJournal=true,AWSClientAdaptiveThrottling=true,AdvertiseNetworkBandwidth=true,AdvertiseSecondaryENIs=true,AdvertiseNetworkCards=true,AdaptiveRegistrationTTL=true,DisruptionProtectionTagSync=true,PublishNodeTemplates=true,PublishFleetComposition=true,LaunchDryRun=true,SimulateNodeRolePermissions=true,SpotPlacementScores=true,CommitmentAwarePricing=true,ValidateQuotas=true,LearnVMMemoryOverhead=true,AdvertiseEBSPerformance=true,PrewarmLaunchTemplates=true,ODCRFirst=true")
 
 		// Add flags after we set the environment variables so that the parsing logic correctly refers
 		// to the new environment variable values
@@ -89,13 +180,43 @@ var _ = Describe("Options", func() {
 		err := opts.Parse(fs)
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			ClusterCABundle:                    lo.ToPtr("env-bundle"),
+			ClusterName:                        lo.ToPtr("env-cluster"),
+			ClusterEndpoint:                    lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                        lo.ToPtr(true),
+			VMMemoryOverheadPercent:            lo.ToPtr[float64](0.1),
+			InterruptionQueue:                  lo.ToPtr("env-cluster"),
+			ReservedENIs:                       lo.ToPtr(10),
+			AdaptiveRegistrationTTLMax:         lo.ToPtr(20 * time.Minute),
+			ArchitecturePreference:             lo.ToPtr("arm64"),
+			InterruptionQueueMessageAttribute:  lo.ToPtr("karpenter.sh/cluster"),
+			PolicyConfigMap:                    lo.ToPtr("karpenter-launch-policies"),
+			TerminationCircuitBreakerThreshold: lo.ToPtr[float64](0.2),
+			TerminationCircuitBreakerWindow:    lo.ToPtr[time.Duration](5 * time.Minute),
+			OfferingSnapshotConfigMap:          lo.ToPtr("karpenter-offering-snapshot"),
+			ProvisioningAuditSize:              lo.ToPtr(50),
+			VMMemoryOverheadPercentOverrides:   lo.ToPtr("r7i=0.05,m5.metal=0.02"),
+			LifecycleWebhookURLs:               lo.ToPtr("https://example.com/karpenter"),
+			LifecycleWebhookSigningKey:         lo.ToPtr("test-signing-key"),
+			MigrationClusterName:               lo.ToPtr("previous-cluster"),
+			MigrationEndTime:                   lo.ToPtr("2030-01-01T00:00:00Z"),
+			InterruptionQueueRoleARN:           lo.ToPtr("arn:aws:iam::000000000000:role/KarpenterInterruption"),
+			ClientMetricsEMFNamespace:          lo.ToPtr("Karpenter"),
+			ExcludePreviousGenerationFamilies:  lo.ToPtr(true),
+			PriceChangeThreshold:               lo.ToPtr(0.1),
+			PreflightConfigRules:               lo.ToPtr("ENCRYPTED_VOLUMES,EC2_IMDSV2_CHECK"),
+			InstanceTypePolicy:                 lo.ToPtr("metal,t2"),
+			RequireEncryption:                  lo.ToPtr(true),
+			EncryptionKMSKeyARNs:               lo.ToPtr("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			SSMParameterPrefix:                 lo.ToPtr("/karpenter/mirror"),
+			InstanceTagLabels:                  lo.ToPtr("compliance.example.com/,team"),
+			UseFIPSEndpoints:                   lo.ToPtr(true),
+			AWSClientMaxIdleConnsPerHost:       lo.ToPtr(50),
+			AWSClientIdleConnTimeout:           lo.ToPtr(5 * time.Minute),
+			AWSClientDisableHTTP2:              lo.ToPtr(true),
+			UnavailableOfferingsTTLs:           lo.ToPtr("InsufficientInstanceCapacity=5m"),
+			CostAttributionLabel:               lo.ToPtr("team"),
+			FeatureGates:                       test.FeatureGates{WarmPools: lo.ToPtr(true), LaunchJournal: lo.ToPtr(true), AWSClientAdaptiveThrottling: lo.ToPtr(true), AdvertiseNetworkBandwidth: lo.ToPtr(true), AdvertiseSecondaryENIs: lo.ToPtr(true), AdvertiseNetworkCards: lo.ToPtr(true), AdaptiveRegistrationTTL: lo.ToPtr(true), DisruptionProtectionTagSync: lo.ToPtr(true), PublishNodeTemplates: lo.ToPtr(true), PublishFleetComposition: lo.ToPtr(true), LaunchDryRun: lo.ToPtr(true), SimulateNodeRolePermissions: lo.ToPtr(true), SpotPlacementScores: lo.ToPtr(true), CommitmentAwarePricing: lo.ToPtr(true), ValidateQuotas: lo.ToPtr(true), LearnVMMemoryOverhead: lo.ToPtr(true), AdvertiseEBSPerformance: lo.ToPtr(true), PrewarmLaunchTemplates: lo.ToPtr(true), ODCRFirst: lo.ToPtr(true)},
 		}))
 	})
 
@@ -107,6 +228,10 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when adaptiveRegistrationTTLMax is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--adaptive-registration-ttl-max", "0s")
+			Expect(err).To(HaveOccurred())
+		})
 		It("should fail when clusterEndpoint is invalid (not absolute)", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cluster-endpoint", "00000000000000000000000.gr7.us-west-2.eks.amazonaws.com")
 			Expect(err).To(HaveOccurred())
@@ -115,10 +240,185 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent", "-0.01")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when awsClientMaxIdleConnsPerHost is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-client-max-idle-conns-per-host", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsClientIdleConnTimeout is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-client-idle-conn-timeout", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when a vmMemoryOverheadPercentOverride is malformed", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent-overrides", "r7i")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent-overrides", "r7i=five")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when a vmMemoryOverheadPercentOverride is not a fraction", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent-overrides", "r7i=1.5")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent-overrides", "r7i=-0.01")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an unavailableOfferingsTTL is malformed", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--unavailable-offerings-ttls", "InsufficientInstanceCapacity")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--unavailable-offerings-ttls", "InsufficientInstanceCapacity=five")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--unavailable-offerings-ttls", "InsufficientInstanceCapacity=0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when costAttributionLabel is not a valid label key", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cost-attribution-label", "cost center")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when costAttributionLabel is a restricted tag key", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cost-attribution-label", "karpenter.sh/nodepool")
+			Expect(err).To(HaveOccurred())
+		})
 		It("should fail when reservedENIs is negative", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--reserved-enis", "-1")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when provisioningAuditSize is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--provisioning-audit-size", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when architecturePreference is not a known preference", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--architecture-preference", "x86_64")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when terminationCircuitBreakerThreshold is not a fraction", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--termination-circuit-breaker-threshold", "1.5")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--termination-circuit-breaker-threshold", "-0.1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when priceChangeThreshold is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--price-change-threshold", "-0.1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when terminationCircuitBreakerWindow is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--termination-circuit-breaker-window", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an interruption queue is specified more than once", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "test-queue,test-queue")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an interruption queue URL is invalid", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "test-queue,https:///000000000000/test-queue")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when a preflight config rule isn't supported", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--preflight-config-rules", "ENCRYPTED_VOLUMES,S3_BUCKET_VERSIONING_ENABLED")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an instance type policy exclusion isn't an instance type, instance family or category", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-type-policy", "metal,M5 Large")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an encryption KMS key ARN isn't the ARN of a KMS key", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--require-encryption", "--encryption-kms-key-arns", "alias/aws/ebs")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--require-encryption", "--encryption-kms-key-arns", "arn:aws:kms:us-west-2:111122223333:alias/ebs")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when encryptionKMSKeyARNs is set without requireEncryption", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--encryption-kms-key-arns", "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when ssmParameterPrefix isn't an absolute path", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ssm-parameter-prefix", "aws/service")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--ssm-parameter-prefix", "/aws/service/")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an instanceTagLabels prefix selects restricted labels", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-tag-labels", "compliance.example.com/,karpenter.sh/")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-tag-labels", "topology.kubernetes.io/")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an aws feature gate is unknown or malformed", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-feature-gates", "UnknownFeature=true")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-feature-gates", "WarmPools")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when a lifecycle webhook URL is invalid", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--lifecycle-webhook-urls", "https://example.com/karpenter,example.com/karpenter")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an interruption queue ARN isn't the ARN of an SQS queue", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "arn:aws:sns:us-east-1:000000000000:test-queue")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionQueueRoleARN isn't the ARN of an IAM role", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue-role-arn", "KarpenterInterruption")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue-role-arn", "arn:aws:iam::000000000000:user/KarpenterInterruption")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when migrationClusterName is set without a migrationEndTime", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--migration-cluster-name", "previous-cluster")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--migration-cluster-name", "previous-cluster", "--migration-end-time", "tomorrow")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	It("should default the aws feature gates which aren't set", func() {
+		gates, err := options.ParseFeatureGates("WarmPools=true")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gates.InPlaceUpdates).To(BeFalse())
+		Expect(gates.WarmPools).To(BeTrue())
+		Expect(lo.Keys(gates.Map())).To(ConsistOf(lo.Keys(options.DefaultFeatureGates)))
+		Expect(gates.Map()).To(HaveKeyWithValue(options.FeatureGateWarmPools, true))
+		Expect(lo.OmitByKeys(gates.Map(), []string{options.FeatureGateWarmPools})).To(HaveEach(BeFalse()))
+	})
+	It("should split the interruption queue into a list of queues", func() {
+		opts.AddFlags(fs)
+		err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "test-queue, https://sqs.us-east-1.amazonaws.com/000000000000/test-queue,arn:aws:sqs:us-west-2:111111111111:test-queue")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.InterruptionQueues()).To(Equal([]string{"test-queue", "https://sqs.us-east-1.amazonaws.com/000000000000/test-queue", "arn:aws:sqs:us-west-2:111111111111:test-queue"}))
+	})
+	It("should include the migration cluster name until the migration end time", func() {
+		opts.AddFlags(fs)
+		err := opts.Parse(fs, "--cluster-name", "test-cluster", "--migration-cluster-name", "previous-cluster", "--migration-end-time", "2030-01-01T00:00:00Z")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.DiscoveryClusterNames(time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC))).To(Equal([]string{"test-cluster", "previous-cluster"}))
+		Expect(opts.DiscoveryClusterNames(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))).To(Equal([]string{"test-cluster"}))
+	})
+	It("should prefer instance type overrides over family overrides for the VM memory overhead percent", func() {
+		opts.AddFlags(fs)
+		err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent", "0.075", "--vm-memory-overhead-percent-overrides", "m5=0.05, m5.metal=0.02")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.VMMemoryOverheadPercentFor("m5.metal")).To(Equal(0.02))
+		Expect(opts.VMMemoryOverheadPercentFor("m5.large")).To(Equal(0.05))
+		Expect(opts.VMMemoryOverheadPercentFor("c5.large")).To(Equal(0.075))
+		_, ok := opts.VMMemoryOverheadPercentOverride("c5.large")
+		Expect(ok).To(BeFalse())
+	})
+	It("should resolve the TTL of unavailable offerings by reason", func() {
+		opts.AddFlags(fs)
+		err := opts.Parse(fs, "--cluster-name", "test-cluster", "--unavailable-offerings-ttls", "InsufficientInstanceCapacity=5m, MaxSpotInstanceCountExceeded=15m")
+		Expect(err).ToNot(HaveOccurred())
+		ttl, ok := opts.UnavailableOfferingsTTL("InsufficientInstanceCapacity")
+		Expect(ok).To(BeTrue())
+		Expect(ttl).To(Equal(5 * time.Minute))
+		ttl, ok = opts.UnavailableOfferingsTTL("MaxSpotInstanceCountExceeded")
+		Expect(ok).To(BeTrue())
+		Expect(ttl).To(Equal(15 * time.Minute))
+		_, ok = opts.UnavailableOfferingsTTL("VcpuLimitExceeded")
+		Expect(ok).To(BeFalse())
+	})
+	It("should parse the VM memory overhead percent overrides once", func() {
+		opts.AddFlags(fs)
+		err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent-overrides", "m5=0.05")
+		Expect(err).ToNot(HaveOccurred())
+		// The overrides that were parsed are used, rather than parsing the setting again on every lookup
+		opts.VMMemoryOverheadPercentOverrides = "m5=0.01"
+		Expect(opts.VMMemoryOverheadPercentFor("m5.large")).To(Equal(0.05))
 	})
 })
 
@@ -131,4 +431,54 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	Expect(optsA.VMMemoryOverheadPercent).To(Equal(optsB.VMMemoryOverheadPercent))
 	Expect(optsA.InterruptionQueue).To(Equal(optsB.InterruptionQueue))
 	Expect(optsA.ReservedENIs).To(Equal(optsB.ReservedENIs))
+	Expect(optsA.AdaptiveRegistrationTTLMax).To(Equal(optsB.AdaptiveRegistrationTTLMax))
+	Expect(optsA.ArchitecturePreference).To(Equal(optsB.ArchitecturePreference))
+	Expect(optsA.InterruptionQueueMessageAttribute).To(Equal(optsB.InterruptionQueueMessageAttribute))
+	Expect(optsA.PolicyConfigMap).To(Equal(optsB.PolicyConfigMap))
+	Expect(optsA.TerminationCircuitBreakerThreshold).To(Equal(optsB.TerminationCircuitBreakerThreshold))
+	Expect(optsA.TerminationCircuitBreakerWindow).To(Equal(optsB.TerminationCircuitBreakerWindow))
+	Expect(optsA.OfferingSnapshotConfigMap).To(Equal(optsB.OfferingSnapshotConfigMap))
+	Expect(optsA.ProvisioningAuditSize).To(Equal(optsB.ProvisioningAuditSize))
+	Expect(optsA.VMMemoryOverheadPercentOverrides).To(Equal(optsB.VMMemoryOverheadPercentOverrides))
+	Expect(optsA.LifecycleWebhookURLs).To(Equal(optsB.LifecycleWebhookURLs))
+	Expect(optsA.LifecycleWebhookSigningKey).To(Equal(optsB.LifecycleWebhookSigningKey))
+	Expect(optsA.MigrationClusterName).To(Equal(optsB.MigrationClusterName))
+	Expect(optsA.MigrationEndTime).To(Equal(optsB.MigrationEndTime))
+	Expect(optsA.InterruptionQueueRoleARN).To(Equal(optsB.InterruptionQueueRoleARN))
+	Expect(optsA.ClientMetricsEMFNamespace).To(Equal(optsB.ClientMetricsEMFNamespace))
+	Expect(optsA.ExcludePreviousGenerationFamilies).To(Equal(optsB.ExcludePreviousGenerationFamilies))
+	Expect(optsA.PriceChangeThreshold).To(Equal(optsB.PriceChangeThreshold))
+	Expect(optsA.PreflightConfigRules).To(Equal(optsB.PreflightConfigRules))
+	Expect(optsA.InstanceTypePolicy).To(Equal(optsB.InstanceTypePolicy))
+	Expect(optsA.RequireEncryption).To(Equal(optsB.RequireEncryption))
+	Expect(optsA.EncryptionKMSKeyARNs).To(Equal(optsB.EncryptionKMSKeyARNs))
+	Expect(optsA.SSMParameterPrefix).To(Equal(optsB.SSMParameterPrefix))
+	Expect(optsA.InstanceTagLabels).To(Equal(optsB.InstanceTagLabels))
+	Expect(optsA.UseFIPSEndpoints).To(Equal(optsB.UseFIPSEndpoints))
+	Expect(optsA.AWSClientMaxIdleConnsPerHost).To(Equal(optsB.AWSClientMaxIdleConnsPerHost))
+	Expect(optsA.AWSClientIdleConnTimeout).To(Equal(optsB.AWSClientIdleConnTimeout))
+	Expect(optsA.AWSClientDisableHTTP2).To(Equal(optsB.AWSClientDisableHTTP2))
+	Expect(optsA.UnavailableOfferingsTTLs).To(Equal(optsB.UnavailableOfferingsTTLs))
+	Expect(optsA.CostAttributionLabel).To(Equal(optsB.CostAttributionLabel))
+	Expect(optsA.FeatureGates.InPlaceUpdates).To(Equal(optsB.FeatureGates.InPlaceUpdates))
+	Expect(optsA.FeatureGates.WarmPools).To(Equal(optsB.FeatureGates.WarmPools))
+	Expect(optsA.FeatureGates.SpotPriceDrift).To(Equal(optsB.FeatureGates.SpotPriceDrift))
+	Expect(optsA.FeatureGates.LaunchJournal).To(Equal(optsB.FeatureGates.LaunchJournal))
+	Expect(optsA.FeatureGates.AWSClientAdaptiveThrottling).To(Equal(optsB.FeatureGates.AWSClientAdaptiveThrottling))
+	Expect(optsA.FeatureGates.AdvertiseNetworkBandwidth).To(Equal(optsB.FeatureGates.AdvertiseNetworkBandwidth))
+	Expect(optsA.FeatureGates.AdvertiseSecondaryENIs).To(Equal(optsB.FeatureGates.AdvertiseSecondaryENIs))
+	Expect(optsA.FeatureGates.AdvertiseNetworkCards).To(Equal(optsB.FeatureGates.AdvertiseNetworkCards))
+	Expect(optsA.FeatureGates.AdaptiveRegistrationTTL).To(Equal(optsB.FeatureGates.AdaptiveRegistrationTTL))
+	Expect(optsA.FeatureGates.DisruptionProtectionTagSync).To(Equal(optsB.FeatureGates.DisruptionProtectionTagSync))
+	Expect(optsA.FeatureGates.PublishNodeTemplates).To(Equal(optsB.FeatureGates.PublishNodeTemplates))
+	Expect(optsA.FeatureGates.PublishFleetComposition).To(Equal(optsB.FeatureGates.PublishFleetComposition))
+	Expect(optsA.FeatureGates.LaunchDryRun).To(Equal(optsB.FeatureGates.LaunchDryRun))
+	Expect(optsA.FeatureGates.SimulateNodeRolePermissions).To(Equal(optsB.FeatureGates.SimulateNodeRolePermissions))
+	Expect(optsA.FeatureGates.SpotPlacementScores).To(Equal(optsB.FeatureGates.SpotPlacementScores))
+	Expect(optsA.FeatureGates.CommitmentAwarePricing).To(Equal(optsB.FeatureGates.CommitmentAwarePricing))
+	Expect(optsA.FeatureGates.ValidateQuotas).To(Equal(optsB.FeatureGates.ValidateQuotas))
+	Expect(optsA.FeatureGates.LearnVMMemoryOverhead).To(Equal(optsB.FeatureGates.LearnVMMemoryOverhead))
+	Expect(optsA.FeatureGates.AdvertiseEBSPerformance).To(Equal(optsB.FeatureGates.AdvertiseEBSPerformance))
+	Expect(optsA.FeatureGates.PrewarmLaunchTemplates).To(Equal(optsB.FeatureGates.PrewarmLaunchTemplates))
+	Expect(optsA.FeatureGates.ODCRFirst).To(Equal(optsB.FeatureGates.ODCRFirst))
 }
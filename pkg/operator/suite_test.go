@@ -17,12 +17,20 @@ package operator_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/samber/lo"
 
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -104,4 +112,85 @@ var _ = Describe("Operator", func() {
 		_, err := awscontext.ResolveClusterEndpoint(ctx, fakeEKSAPI)
 		Expect(err).To(HaveOccurred())
 	})
+	Context("API Options", func() {
+		var requests []*http.Request
+		var cfg aws.Config
+
+		BeforeEach(func() {
+			requests = nil
+			cfg = aws.Config{
+				Region:      "us-west-2",
+				Credentials: aws.AnonymousCredentials{},
+				Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+				// Requests are recorded instead of being sent to AWS
+				HTTPClient: smithyhttp.ClientDoFunc(func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req)
+					return nil, errors.New("request recorded")
+				}),
+			}
+		})
+		It("should register middleware on AWS clients constructed from the config", func() {
+			headerMiddleware := func(stack *smithymiddleware.Stack) error {
+				return stack.Build.Add(smithymiddleware.BuildMiddlewareFunc("TestHeader", func(ctx context.Context, in smithymiddleware.BuildInput, next smithymiddleware.BuildHandler) (smithymiddleware.BuildOutput, smithymiddleware.Metadata, error) {
+					if req, ok := in.Request.(*smithyhttp.Request); ok {
+						req.Header.Set("X-Test-Header", "test-value")
+					}
+					return next.HandleBuild(ctx, in)
+				}), smithymiddleware.After)
+			}
+			cfg = awscontext.WithOptions(cfg, awscontext.WithAPIOptions(headerMiddleware))
+
+			_, err := ec2.NewFromConfig(cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+			Expect(err).To(HaveOccurred())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Header.Get("X-Test-Header")).To(Equal("test-value"))
+		})
+		It("should allow middleware to short-circuit requests", func() {
+			var operations []string
+			auditMiddleware := func(stack *smithymiddleware.Stack) error {
+				return stack.Initialize.Add(smithymiddleware.InitializeMiddlewareFunc("TestAudit", func(ctx context.Context, in smithymiddleware.InitializeInput, _ smithymiddleware.InitializeHandler) (smithymiddleware.InitializeOutput, smithymiddleware.Metadata, error) {
+					operations = append(operations, smithymiddleware.GetOperationName(ctx))
+					return smithymiddleware.InitializeOutput{}, smithymiddleware.Metadata{}, errors.New("denied")
+				}), smithymiddleware.Before)
+			}
+			cfg = awscontext.WithOptions(cfg, awscontext.WithAPIOptions(auditMiddleware))
+
+			_, err := ec2.NewFromConfig(cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+			Expect(err).To(MatchError(ContainSubstring("denied")))
+			Expect(operations).To(ConsistOf("DescribeInstances"))
+			Expect(requests).To(BeEmpty())
+		})
+		It("should not register middleware without options", func() {
+			Expect(awscontext.WithOptions(cfg).APIOptions).To(BeEmpty())
+		})
+	})
+	Context("HTTP Client", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/xml")
+				fmt.Fprint(w, "<DescribeInstancesResponse></DescribeInstancesResponse>")
+			}))
+			awscontext.AWSClientConnectionsTotal.Reset()
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		It("should reuse connections across the AWS clients constructed from the config", func() {
+			cfg := awscontext.WithHTTPClient(ctx, aws.Config{
+				Region:       "us-west-2",
+				Credentials:  aws.AnonymousCredentials{},
+				BaseEndpoint: aws.String(server.URL),
+			})
+			for range 3 {
+				_, err := ec2.NewFromConfig(cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			host := strings.TrimPrefix(server.URL, "http://")
+			ExpectMetricCounterValue(awscontext.AWSClientConnectionsTotal, 1, map[string]string{"host": host, "reused": "false"})
+			ExpectMetricCounterValue(awscontext.AWSClientConnectionsTotal, 2, map[string]string{"host": host, "reused": "true"})
+		})
+	})
 })
@@ -42,17 +42,17 @@ type AL2 struct {
 func (a AL2) DescribeImageQuery(ctx context.Context, ssmProvider ssm.Provider, k8sVersion string, amiVersion string) (DescribeImageQuery, error) {
 	ids := map[string][]Variant{}
 	for path, variants := range map[string][]Variant{
-		fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2/%s/image_id", k8sVersion, lo.Ternary(
+		fmt.Sprintf(ssm.PublicParameterPrefix+"/eks/optimized-ami/%s/amazon-linux-2/%s/image_id", k8sVersion, lo.Ternary(
 			amiVersion == v1.AliasVersionLatest,
 			"recommended",
 			fmt.Sprintf("amazon-eks-node-%s-%s", k8sVersion, amiVersion),
 		)): {VariantStandard},
-		fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-arm64/%s/image_id", k8sVersion, lo.Ternary(
+		fmt.Sprintf(ssm.PublicParameterPrefix+"/eks/optimized-ami/%s/amazon-linux-2-arm64/%s/image_id", k8sVersion, lo.Ternary(
 			amiVersion == v1.AliasVersionLatest,
 			"recommended",
 			fmt.Sprintf("amazon-eks-arm64-node-%s-%s", k8sVersion, amiVersion),
 		)): {VariantStandard},
-		fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-gpu/%s/image_id", k8sVersion, lo.Ternary(
+		fmt.Sprintf(ssm.PublicParameterPrefix+"/eks/optimized-ami/%s/amazon-linux-2-gpu/%s/image_id", k8sVersion, lo.Ternary(
 			amiVersion == v1.AliasVersionLatest,
 			"recommended",
 			fmt.Sprintf("amazon-eks-gpu-node-%s-%s", k8sVersion, amiVersion),
@@ -90,14 +90,16 @@ func (a AL2) DescribeImageQuery(ctx context.Context, ssmProvider ssm.Provider, k
 func (a AL2) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
 	return bootstrap.EKS{
 		Options: bootstrap.Options{
-			ClusterName:         a.Options.ClusterName,
-			ClusterEndpoint:     a.Options.ClusterEndpoint,
-			KubeletConfig:       kubeletConfig,
-			Taints:              taints,
-			Labels:              labels,
-			CABundle:            caBundle,
-			CustomUserData:      customUserData,
-			InstanceStorePolicy: instanceStorePolicy,
+			ClusterName:             a.Options.ClusterName,
+			ClusterEndpoint:         a.Options.ClusterEndpoint,
+			KubeletConfig:           kubeletConfig,
+			Taints:                  taints,
+			Labels:                  labels,
+			CABundle:                caBundle,
+			CustomUserData:          customUserData,
+			InstanceStorePolicy:     instanceStorePolicy,
+			InstanceStoreSecureWipe: a.Options.InstanceStoreSecureWipe,
+			DNS:                     a.Options.DNS,
 		},
 	}
 }
@@ -75,21 +75,24 @@ func (a AL2023) resolvePath(architecture, variant, k8sVersion, amiVersion string
 		"recommended",
 		fmt.Sprintf("amazon-eks-node-al2023-%s-%s-%s-%s", architecture, variant, k8sVersion, amiVersion),
 	)
-	return fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/%s/%s/%s/image_id", k8sVersion, architecture, variant, name)
+	return fmt.Sprintf(ssm.PublicParameterPrefix+"/eks/optimized-ami/%s/amazon-linux-2023/%s/%s/%s/image_id", k8sVersion, architecture, variant, name)
 }
 
 func (a AL2023) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
 	return bootstrap.Nodeadm{
 		Options: bootstrap.Options{
-			ClusterName:         a.Options.ClusterName,
-			ClusterEndpoint:     a.Options.ClusterEndpoint,
-			ClusterCIDR:         a.Options.ClusterCIDR,
-			KubeletConfig:       kubeletConfig,
-			Taints:              taints,
-			Labels:              labels,
-			CABundle:            caBundle,
-			CustomUserData:      customUserData,
-			InstanceStorePolicy: instanceStorePolicy,
+			ClusterName:               a.Options.ClusterName,
+			ClusterEndpoint:           a.Options.ClusterEndpoint,
+			ClusterCIDR:               a.Options.ClusterCIDR,
+			KubeletConfig:             kubeletConfig,
+			Taints:                    taints,
+			Labels:                    labels,
+			CABundle:                  caBundle,
+			CustomUserData:            customUserData,
+			InstanceStorePolicy:       instanceStorePolicy,
+			InstanceStoreSecureWipe:   a.Options.InstanceStoreSecureWipe,
+			DNS:                       a.Options.DNS,
+			KubeletConfigurationPatch: a.Options.KubeletConfigurationPatch,
 		},
 	}
 }
@@ -25,11 +25,14 @@ import (
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -41,6 +44,9 @@ import (
 
 type Provider interface {
 	List(ctx context.Context, nodeClass *v1.EC2NodeClass) (AMIs, error)
+	UnavailablePinnedAMIs(ctx context.Context, nodeClass *v1.EC2NodeClass) (map[string]string, error)
+	EnableFastLaunch(ctx context.Context, amiID string, fastLaunch *v1.WindowsFastLaunch) (string, error)
+	Invalidate()
 }
 
 type DefaultProvider struct {
@@ -52,16 +58,24 @@ type DefaultProvider struct {
 	cm              *pretty.ChangeMonitor
 	versionProvider version.Provider
 	ssmProvider     ssm.Provider
+	// fastLaunchStates are the Fast Launch states of the AMIs which Fast Launch was enabled on, keyed by AMI id. They
+	// outlive the cached AMIs which were resolved before Fast Launch was enabled.
+	fastLaunchStates *cache.Cache
+	// fastLaunchFailures are the errors from enabling Fast Launch on AMIs, keyed by AMI id, so that it isn't retried
+	// for an AMI until the error expires
+	fastLaunchFailures *cache.Cache
 }
 
-func NewDefaultProvider(clk clock.Clock, versionProvider version.Provider, ssmProvider ssm.Provider, ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(clk clock.Clock, versionProvider version.Provider, ssmProvider ssm.Provider, ec2api sdk.EC2API, amiCache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
-		clk:             clk,
-		cache:           cache,
-		ec2api:          ec2api,
-		cm:              pretty.NewChangeMonitor(),
-		versionProvider: versionProvider,
-		ssmProvider:     ssmProvider,
+		clk:                clk,
+		cache:              amiCache,
+		ec2api:             ec2api,
+		cm:                 pretty.NewChangeMonitor(),
+		versionProvider:    versionProvider,
+		ssmProvider:        ssmProvider,
+		fastLaunchStates:   cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+		fastLaunchFailures: cache.New(awscache.FastLaunchFailureTTL, awscache.DefaultCleanupInterval),
 	}
 }
 
@@ -77,6 +91,16 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	if err != nil {
 		return nil, err
 	}
+	fallbacks, err := p.fallbackAMIs(ctx, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	amis = append(amis, fallbacks...)
+	for i := range amis {
+		if state, ok := p.fastLaunchStates.Get(amis[i].AmiID); ok && amis[i].FastLaunchState == "" {
+			amis[i].FastLaunchState = state.(string)
+		}
+	}
 	amis.Sort()
 	uniqueAMIs := lo.Uniq(lo.Map(amis, func(a AMI, _ int) string { return a.AmiID }))
 	if p.cm.HasChanged(fmt.Sprintf("amis/%s", nodeClass.Name), uniqueAMIs) {
@@ -142,6 +166,65 @@ func (p *DefaultProvider) DescribeImageQueries(ctx context.Context, nodeClass *v
 	return queries, nil
 }
 
+// UnavailablePinnedAMIs returns the reasons that the AMIs which are pinned by id in the amiSelectorTerms of the
+// EC2NodeClass are unavailable, keyed by AMI id. AMIs which are available aren't included.
+func (p *DefaultProvider) UnavailablePinnedAMIs(ctx context.Context, nodeClass *v1.EC2NodeClass) (map[string]string, error) {
+	p.Lock()
+	defer p.Unlock()
+	return p.unavailablePinnedAMIs(ctx, nodeClass)
+}
+
+// unavailablePinnedAMIs describes each pinned AMI on its own, rather than checking for it in the AMIs resolved for the
+// EC2NodeClass, since AMIs with the same requirements are deduplicated when they're resolved together.
+func (p *DefaultProvider) unavailablePinnedAMIs(ctx context.Context, nodeClass *v1.EC2NodeClass) (map[string]string, error) {
+	unavailable := map[string]string{}
+	for _, term := range nodeClass.Spec.AMISelectorTerms {
+		if term.ID == "" {
+			continue
+		}
+		amis, err := p.amis(ctx, []DescribeImageQuery{{Filters: []ec2types.Filter{{Name: aws.String("image-id"), Values: []string{term.ID}}}}})
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(amis) == 0:
+			unavailable[term.ID] = PinnedAMINotFound
+		case lo.EveryBy(amis, func(ami AMI) bool { return ami.Deprecated }):
+			unavailable[term.ID] = PinnedAMIDeprecated
+		}
+	}
+	return unavailable, nil
+}
+
+// fallbackAMIs resolves the fallbackAlias of each id term whose AMI no longer exists. AMIs which are deprecated can
+// still be launched, so they don't fall back.
+func (p *DefaultProvider) fallbackAMIs(ctx context.Context, nodeClass *v1.EC2NodeClass) (AMIs, error) {
+	if !lo.ContainsBy(nodeClass.Spec.AMISelectorTerms, func(term v1.AMISelectorTerm) bool { return term.FallbackAlias != "" }) {
+		return nil, nil
+	}
+	unavailable, err := p.unavailablePinnedAMIs(ctx, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	var fallbacks AMIs
+	for _, term := range nodeClass.Spec.AMISelectorTerms {
+		alias := term.Fallback()
+		if alias == nil || unavailable[term.ID] != PinnedAMINotFound {
+			continue
+		}
+		query, err := GetAMIFamily(alias.Family, nil).DescribeImageQuery(ctx, p.ssmProvider, p.versionProvider.Get(ctx), alias.Version)
+		if err != nil {
+			return nil, fmt.Errorf("resolving fallback alias %s for %s, %w", alias, term.ID, err)
+		}
+		amis, err := p.amis(ctx, []DescribeImageQuery{query})
+		if err != nil {
+			return nil, err
+		}
+		fallbacks = append(fallbacks, amis...)
+	}
+	return fallbacks, nil
+}
+
 //nolint:gocyclo
 func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery) (AMIs, error) {
 	hash, err := hashstructure.Hash(queries, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
@@ -159,6 +242,7 @@ func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery
 		for paginator.HasMorePages() {
 			page, err := paginator.NextPage(ctx)
 			if err != nil {
+				health.Providers.Observe(health.AMIs, err)
 				return nil, fmt.Errorf("describing images, %w", err)
 			}
 			for _, image := range page.Images {
@@ -180,6 +264,8 @@ func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery
 						AmiID:        lo.FromPtr(image.ImageId),
 						CreationDate: lo.FromPtr(image.CreationDate),
 						Deprecated:   candidateDeprecated,
+						Windows:      image.Platform == ec2types.PlatformValuesWindows,
+						Encrypted:    encrypted(image),
 						Requirements: reqs,
 					}
 					if v, ok := images[reqsHash]; ok {
@@ -192,10 +278,108 @@ func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery
 			}
 		}
 	}
+	// Failing to resolve the Fast Launch state shouldn't prevent the AMIs from being used to launch instances
+	if err := p.resolveFastLaunchStates(ctx, images); err != nil {
+		log.FromContext(ctx).Error(err, "failed resolving fast launch state for windows amis")
+	}
+	if err := p.resolveOwnedAMIs(ctx, images); err != nil {
+		log.FromContext(ctx).Error(err, "failed resolving owner of windows amis")
+	}
 	p.cache.SetDefault(fmt.Sprintf("%d", hash), AMIs(lo.Values(images)))
+	health.Providers.Observe(health.AMIs, nil)
 	return lo.Values(images), nil
 }
 
+// resolveFastLaunchStates populates the EC2 Fast Launch state of each Windows AMI. AMIs which don't have Fast Launch
+// configured aren't returned by DescribeFastLaunchImages and are left with an empty state.
+func (p *DefaultProvider) resolveFastLaunchStates(ctx context.Context, images map[uint64]AMI) error {
+	ids := lo.Uniq(lo.FilterMap(lo.Values(images), func(ami AMI, _ int) (string, bool) { return ami.AmiID, ami.Windows }))
+	if len(ids) == 0 {
+		return nil
+	}
+	states := map[string]string{}
+	paginator := ec2.NewDescribeFastLaunchImagesPaginator(p.ec2api, &ec2.DescribeFastLaunchImagesInput{ImageIds: ids})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing fast launch images, %w", err)
+		}
+		for _, image := range page.FastLaunchImages {
+			states[lo.FromPtr(image.ImageId)] = string(image.State)
+		}
+	}
+	for k, ami := range images {
+		ami.FastLaunchState = states[ami.AmiID]
+		images[k] = ami
+	}
+	return nil
+}
+
+// resolveOwnedAMIs marks the Windows AMIs which are owned by the account, since Fast Launch can't be enabled on AMIs
+// which are owned by AWS or shared from other accounts. Ownership isn't resolved for other AMIs.
+func (p *DefaultProvider) resolveOwnedAMIs(ctx context.Context, images map[uint64]AMI) error {
+	ids := lo.Uniq(lo.FilterMap(lo.Values(images), func(ami AMI, _ int) (string, bool) { return ami.AmiID, ami.Windows }))
+	if len(ids) == 0 {
+		return nil
+	}
+	owned := sets.New[string]()
+	paginator := ec2.NewDescribeImagesPaginator(p.ec2api, &ec2.DescribeImagesInput{
+		Owners:  []string{"self"},
+		Filters: []ec2types.Filter{{Name: aws.String("image-id"), Values: ids}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing owned images, %w", err)
+		}
+		owned.Insert(lo.Map(page.Images, func(image ec2types.Image, _ int) string { return lo.FromPtr(image.ImageId) })...)
+	}
+	for k, ami := range images {
+		ami.Owned = owned.Has(ami.AmiID)
+		images[k] = ami
+	}
+	return nil
+}
+
+// Invalidate removes the cached AMIs so that changes to AMIs are discovered on the next List
+func (p *DefaultProvider) Invalidate() {
+	p.cache.Flush()
+}
+
+// EnableFastLaunch enables EC2 Fast Launch for the AMI, returning the resulting Fast Launch state. Failures are
+// remembered, so that enabling Fast Launch on the AMI is only retried once they expire.
+func (p *DefaultProvider) EnableFastLaunch(ctx context.Context, amiID string, fastLaunch *v1.WindowsFastLaunch) (string, error) {
+	if err, ok := p.fastLaunchFailures.Get(amiID); ok {
+		return "", err.(error)
+	}
+	input := &ec2.EnableFastLaunchInput{
+		ImageId:             aws.String(amiID),
+		ResourceType:        aws.String(string(ec2types.FastLaunchResourceTypeSnapshot)),
+		MaxParallelLaunches: fastLaunch.MaxParallelLaunches,
+	}
+	if fastLaunch.TargetResourceCount != nil {
+		input.SnapshotConfiguration = &ec2types.FastLaunchSnapshotConfigurationRequest{
+			TargetResourceCount: fastLaunch.TargetResourceCount,
+		}
+	}
+	out, err := p.ec2api.EnableFastLaunch(ctx, input)
+	if err != nil {
+		err = fmt.Errorf("enabling fast launch for %s, %w", amiID, err)
+		p.fastLaunchFailures.SetDefault(amiID, err)
+		return "", err
+	}
+	// The cached AMIs reflect the Fast Launch state from before it was enabled, so track it until they are re-resolved
+	p.fastLaunchStates.SetDefault(amiID, string(out.State))
+	log.FromContext(ctx).WithValues("id", amiID).V(1).Info("enabled fast launch for ami")
+	return string(out.State), nil
+}
+
+// Reset clears the Fast Launch states and failures which were recorded when enabling Fast Launch
+func (p *DefaultProvider) Reset() {
+	p.fastLaunchStates.Flush()
+	p.fastLaunchFailures.Flush()
+}
+
 // MapToInstanceTypes returns a map of AMIIDs that are the most recent on creationDate to compatible instancetypes
 func MapToInstanceTypes(instanceTypes []*cloudprovider.InstanceType, amis []v1.AMI) map[string][]*cloudprovider.InstanceType {
 	amiIDs := map[string][]*cloudprovider.InstanceType{}
@@ -239,3 +423,11 @@ func compareAMI(i, j AMI) int {
 	// If all attributes are are equal, both AMIs are exactly identical
 	return 0
 }
+
+// encrypted returns true if the EBS snapshots of the image are encrypted
+func encrypted(image ec2types.Image) bool {
+	snapshots := lo.FilterMap(image.BlockDeviceMappings, func(bdm ec2types.BlockDeviceMapping, _ int) (*ec2types.EbsBlockDevice, bool) {
+		return bdm.Ebs, bdm.Ebs != nil
+	})
+	return len(snapshots) != 0 && lo.EveryBy(snapshots, func(ebs *ec2types.EbsBlockDevice) bool { return lo.FromPtr(ebs.Encrypted) })
+}
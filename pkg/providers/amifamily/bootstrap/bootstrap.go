@@ -21,23 +21,146 @@ import (
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 // Options is the node bootstrapping parameters passed from Karpenter to the provisioning node
 type Options struct {
-	ClusterName         string
-	ClusterEndpoint     string
-	ClusterCIDR         *string
-	KubeletConfig       *v1.KubeletConfiguration
-	Taints              []corev1.Taint    `hash:"set"`
-	Labels              map[string]string `hash:"set"`
-	CABundle            *string
-	ContainerRuntime    *string
-	CustomUserData      *string
-	InstanceStorePolicy *v1.InstanceStorePolicy
+	ClusterName             string
+	ClusterEndpoint         string
+	ClusterCIDR             *string
+	KubeletConfig           *v1.KubeletConfiguration
+	Taints                  []corev1.Taint    `hash:"set"`
+	Labels                  map[string]string `hash:"set"`
+	CABundle                *string
+	ContainerRuntime        *string
+	CustomUserData          *string
+	InstanceStorePolicy     *v1.InstanceStorePolicy
+	InstanceStoreSecureWipe bool
+	WindowsGMSA             *v1.WindowsGMSA
+	DNS                     *v1.DNS
+	// KubeletConfigurationPatch is merged into the generated kubelet configuration, see mergeKubeletConfigurationPatch
+	KubeletConfigurationPatch *apiextensionsv1.JSON
+}
+
+// instanceStoreSecureWipeScript installs a systemd unit which discards all data on the instance store disks when the
+// instance shuts down. The unit is ordered after the local filesystems and before containerd and the kubelet, so that
+// it's stopped once containerd and the kubelet have stopped writing to the disks.
+const instanceStoreSecureWipeScript = `#!/bin/bash -xe
+cat <<'UNIT' > /etc/systemd/system/karpenter-instance-store-wipe.service
+[Unit]
+Description=Discard all data on instance store disks on shutdown
+DefaultDependencies=no
+After=local-fs.target
+Before=containerd.service kubelet.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/true
+ExecStop=/bin/bash -c 'for d in $(readlink -f /dev/disk/by-id/nvme-Amazon_EC2_NVMe_Instance_Storage_* | sort -u); do blkdiscard -f "$d" || blkdiscard "$d"; done'
+TimeoutStopSec=300
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+systemctl daemon-reload
+systemctl enable --now karpenter-instance-store-wipe.service
+`
+
+// dnsScript configures the nodes to resolve names with the DNS servers of the EC2NodeClass before they bootstrap. When
+// names are resolved with systemd-resolved, as on AL2023, every domain is routed to the DNS servers. Otherwise
+// resolv.conf is written, and dhclient is configured to keep the DNS servers when the DHCP lease is renewed.
+func (o Options) dnsScript() string {
+	if o.DNS == nil {
+		return ""
+	}
+	var script strings.Builder
+	script.WriteString("#!/bin/bash -xe\n")
+	script.WriteString("if systemctl is-active --quiet systemd-resolved; then\n")
+	script.WriteString("mkdir -p /etc/systemd/resolved.conf.d\n")
+	script.WriteString("cat <<'CONF' > /etc/systemd/resolved.conf.d/karpenter-dns.conf\n")
+	script.WriteString("[Resolve]\n")
+	script.WriteString(fmt.Sprintf("DNS=%s\n", strings.Join(o.DNS.Nameservers, " ")))
+	script.WriteString(fmt.Sprintf("Domains=%s\n", strings.Join(append([]string{"~."}, o.DNS.SearchDomains...), " ")))
+	script.WriteString("CONF\n")
+	script.WriteString("systemctl restart systemd-resolved\n")
+	script.WriteString("else\n")
+	script.WriteString("cat <<'CONF' > /etc/resolv.conf\n")
+	for _, nameserver := range o.DNS.Nameservers {
+		script.WriteString(fmt.Sprintf("nameserver %s\n", nameserver))
+	}
+	if len(o.DNS.SearchDomains) > 0 {
+		script.WriteString(fmt.Sprintf("search %s\n", strings.Join(o.DNS.SearchDomains, " ")))
+	}
+	script.WriteString("CONF\n")
+	script.WriteString(fmt.Sprintf("echo 'supersede domain-name-servers %s;' >> /etc/dhcp/dhclient.conf\n", strings.Join(o.DNS.Nameservers, ", ")))
+	if len(o.DNS.SearchDomains) > 0 {
+		script.WriteString(fmt.Sprintf("echo 'supersede domain-search %s;' >> /etc/dhcp/dhclient.conf\n", strings.Join(lo.Map(o.DNS.SearchDomains, func(d string, _ int) string { return fmt.Sprintf("\"%s\"", d) }), ", ")))
+	}
+	script.WriteString("fi\n")
+	return script.String()
+}
+
+// modeledKubeletSettings are the kubelet settings which Karpenter computes the capacity and allocatable resources of
+// instance types from, by their AL2023 and Bottlerocket names. They can only be set through the kubelet field of the
+// EC2NodeClass, since the resources of nodes would otherwise diverge from the instance types they were launched for.
+var modeledKubeletSettings = sets.New(
+	"maxPods", "podsPerCore", "kubeReserved", "systemReserved", "evictionHard", "evictionSoft",
+	"max-pods", "pods-per-core", "kube-reserved", "system-reserved", "eviction-hard", "eviction-soft",
+)
+
+// ModeledKubeletSettings returns the settings in the kubelet configuration patch which Karpenter models instance types
+// with, and which must be set through the kubelet field of the EC2NodeClass instead
+func ModeledKubeletSettings(kubeletConfigurationPatch *apiextensionsv1.JSON) ([]string, error) {
+	if kubeletConfigurationPatch == nil || len(kubeletConfigurationPatch.Raw) == 0 {
+		return nil, nil
+	}
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(kubeletConfigurationPatch.Raw, &patch); err != nil {
+		return nil, fmt.Errorf("decoding kubelet configuration patch, %w", err)
+	}
+	settings := lo.Filter(lo.Keys(patch), func(k string, _ int) bool { return modeledKubeletSettings.Has(k) })
+	sort.Strings(settings)
+	return settings, nil
+}
+
+// mergeKubeletConfigurationPatch merges the kubelet configuration patch of the EC2NodeClass into the generated kubelet
+// configuration. Objects are merged recursively, null values remove the key and any other value replaces it, like a
+// JSON merge patch. Numbers are decoded as int64 where they're integers, so that they're encoded as integers again.
+// Modeled kubelet settings are dropped from the patch, since EC2NodeClasses which set them fail validation.
+func (o Options) mergeKubeletConfigurationPatch(config map[string]interface{}) (map[string]interface{}, error) {
+	if o.KubeletConfigurationPatch == nil || len(o.KubeletConfigurationPatch.Raw) == 0 {
+		return config, nil
+	}
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(o.KubeletConfigurationPatch.Raw, &patch); err != nil {
+		return nil, fmt.Errorf("decoding kubelet configuration patch, %w", err)
+	}
+	return mergePatch(config, lo.OmitByKeys(patch, modeledKubeletSettings.UnsortedList())), nil
+}
+
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(target, k)
+		case map[string]interface{}:
+			nested, _ := target[k].(map[string]interface{})
+			target[k] = mergePatch(nested, v)
+		default:
+			target[k] = v
+		}
+	}
+	return target
 }
 
 func (o Options) kubeletExtraArgs() (args []string) {
@@ -20,6 +20,7 @@ import (
 	"strconv"
 
 	"github.com/imdario/mergo"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/samber/lo"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -93,5 +94,31 @@ func (b Bottlerocket) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("constructing toml UserData %w", err)
 	}
+	if b.KubeletConfigurationPatch != nil {
+		if script, err = b.patchKubernetesSettings(script); err != nil {
+			return "", fmt.Errorf("patching kubernetes settings, %w", err)
+		}
+	}
 	return base64.StdEncoding.EncodeToString(script), nil
 }
+
+// patchKubernetesSettings merges the kubelet configuration patch into the settings.kubernetes table of the config, after
+// the settings that Karpenter manages have been applied.
+func (b Bottlerocket) patchKubernetesSettings(config []byte) ([]byte, error) {
+	c := map[string]interface{}{}
+	if err := toml.Unmarshal(config, &c); err != nil {
+		return nil, err
+	}
+	settings, _ := c["settings"].(map[string]interface{})
+	if settings == nil {
+		settings = map[string]interface{}{}
+		c["settings"] = settings
+	}
+	kubernetes, _ := settings["kubernetes"].(map[string]interface{})
+	kubernetes, err := b.mergeKubeletConfigurationPatch(kubernetes)
+	if err != nil {
+		return nil, err
+	}
+	settings["kubernetes"] = kubernetes
+	return toml.Marshal(c)
+}
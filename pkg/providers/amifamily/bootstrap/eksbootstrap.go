@@ -44,7 +44,12 @@ const (
 )
 
 func (e EKS) Script() (string, error) {
-	userData, err := e.mergeCustomUserData(lo.Compact([]string{lo.FromPtr(e.CustomUserData), e.eksBootstrapScript()})...)
+	userData, err := e.mergeCustomUserData(lo.Compact([]string{
+		lo.FromPtr(e.CustomUserData),
+		e.dnsScript(),
+		lo.Ternary(e.InstanceStoreSecureWipe, instanceStoreSecureWipeScript, ""),
+		e.eksBootstrapScript(),
+	})...)
 	if err != nil {
 		return "", err
 	}
@@ -45,6 +45,18 @@ func (n Nodeadm) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("parsing custom UserData, %w", err)
 	}
+	if dnsScript := n.dnsScript(); dnsScript != "" {
+		customEntries = append(customEntries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     dnsScript,
+		})
+	}
+	if n.InstanceStoreSecureWipe {
+		customEntries = append(customEntries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     instanceStoreSecureWipeScript,
+		})
+	}
 	mimeArchive := mime.Archive(append(customEntries, mime.Entry{
 		ContentType: mime.ContentTypeNodeConfig,
 		Content:     nodeConfigYAML,
@@ -118,7 +130,20 @@ func (n Nodeadm) generateInlineKubeletConfiguration() (map[string]runtime.RawExt
 	kubeConfigMap["registerWithTaints"] = runtime.RawExtension{
 		Raw: lo.Must(json.Marshal(n.Taints)),
 	}
-	return kubeConfigMap, nil
+	if n.KubeletConfigurationPatch == nil {
+		return kubeConfigMap, nil
+	}
+	config := map[string]interface{}{}
+	if err = json.Unmarshal(lo.Must(json.Marshal(kubeConfigMap)), &config); err != nil {
+		return nil, err
+	}
+	config, err = n.mergeKubeletConfigurationPatch(config)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapValues(config, func(v interface{}, _ string) runtime.RawExtension {
+		return runtime.RawExtension{Raw: lo.Must(json.Marshal(v))}
+	}), nil
 }
 
 // parseUserData returns a slice of MIMEEntrys corresponding to each entry in the custom UserData. If the custom
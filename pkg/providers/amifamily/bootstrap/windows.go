@@ -36,6 +36,7 @@ func (w Windows) Script() (string, error) {
 	if customUserData != "" {
 		userData.WriteString(customUserData + "\n")
 	}
+	userData.WriteString(w.gmsaScript())
 
 	userData.WriteString("[string]$EKSBootstrapScriptFile = \"$env:ProgramFiles\\Amazon\\EKS\\Start-EKSBootstrap.ps1\"\n")
 	userData.WriteString(fmt.Sprintf(`& $EKSBootstrapScriptFile -EKSClusterName '%s' -APIServerEndpoint '%s'`, w.ClusterName, w.ClusterEndpoint))
@@ -51,3 +52,22 @@ func (w Windows) Script() (string, error) {
 	userData.WriteString("\n</powershell>")
 	return base64.StdEncoding.EncodeToString(userData.Bytes()), nil
 }
+
+// gmsaScript configures the node to resolve the Active Directory domain of the gMSAs that its containers run as. The
+// DNS servers are set on the primary network adapter, since the HNS networks of the containers inherit its DNS
+// configuration.
+func (w Windows) gmsaScript() string {
+	if w.WindowsGMSA == nil {
+		return ""
+	}
+	var script strings.Builder
+	if len(w.WindowsGMSA.DNSServers) > 0 {
+		script.WriteString(fmt.Sprintf("Set-DnsClientServerAddress -InterfaceIndex (Get-NetAdapter -Physical | Where-Object Status -eq 'Up' | Sort-Object ifIndex | Select-Object -First 1).ifIndex -ServerAddresses %s\n", powershellArray(w.WindowsGMSA.DNSServers)))
+	}
+	script.WriteString(fmt.Sprintf("Set-DnsClientGlobalSetting -SuffixSearchList ((Get-DnsClientGlobalSetting).SuffixSearchList + %s)\n", powershellArray([]string{w.WindowsGMSA.DomainName})))
+	return script.String()
+}
+
+func powershellArray(values []string) string {
+	return fmt.Sprintf("@(%s)", strings.Join(lo.Map(values, func(v string, _ int) string { return fmt.Sprintf("'%s'", v) }), ","))
+}
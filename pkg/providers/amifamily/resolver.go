@@ -23,6 +23,7 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -50,12 +51,18 @@ type DefaultResolver struct{}
 
 // Options define the static launch template parameters
 type Options struct {
-	ClusterName         string
-	ClusterEndpoint     string
-	ClusterCIDR         *string
-	InstanceProfile     string
-	CABundle            *string `hash:"ignore"`
-	InstanceStorePolicy *v1.InstanceStorePolicy
+	ClusterName             string
+	ClusterEndpoint         string
+	ClusterCIDR             *string
+	InstanceProfile         string
+	CABundle                *string `hash:"ignore"`
+	InstanceStorePolicy     *v1.InstanceStorePolicy
+	InstanceStoreSecureWipe bool
+	WindowsGMSA             *v1.WindowsGMSA
+	DNS                     *v1.DNS
+	// KubeletConfigurationPatch is merged into the generated kubelet configuration of the AL2023 and Bottlerocket
+	// AMI families
+	KubeletConfigurationPatch *apiextensionsv1.JSON
 	// Level-triggered fields that may change out of sync.
 	SecurityGroups           []v1.SecurityGroup
 	Tags                     map[string]string
@@ -63,19 +70,26 @@ type Options struct {
 	KubeDNSIP                net.IP
 	AssociatePublicIPAddress *bool
 	NodeClassName            string
+	LicenseConfigurationARN  string
+	PlacementGroup           string
+	PlacementPartition       int32
+	CapacityReservationID    string
 }
 
 // LaunchTemplate holds the dynamically generated launch template parameters
 type LaunchTemplate struct {
 	*Options
-	UserData            bootstrap.Bootstrapper
-	BlockDeviceMappings []*v1.BlockDeviceMapping
-	MetadataOptions     *v1.MetadataOptions
-	AMIID               string
-	InstanceTypes       []*cloudprovider.InstanceType `hash:"ignore"`
-	DetailedMonitoring  bool
-	EFACount            int
-	CapacityType        string
+	UserData              bootstrap.Bootstrapper
+	BlockDeviceMappings   []*v1.BlockDeviceMapping
+	MetadataOptions       *v1.MetadataOptions
+	AMIID                 string
+	InstanceTypes         []*cloudprovider.InstanceType `hash:"ignore"`
+	DetailedMonitoring    bool
+	TerminationProtection bool
+	EnclavesEnabled       bool
+	KeyName               string
+	EFACount              int
+	CapacityType          string
 }
 
 // AMIFamily can be implemented to override the default logic for generating dynamic launch template parameters
@@ -166,6 +180,8 @@ func GetAMIFamily(amiFamily string, options *Options) AMIFamily {
 		return &Windows{Options: options, Version: v1.Windows2019, Build: v1.Windows2019Build}
 	case v1.AMIFamilyWindows2022:
 		return &Windows{Options: options, Version: v1.Windows2022, Build: v1.Windows2022Build}
+	case v1.AMIFamilyWindows2025:
+		return &Windows{Options: options, Version: v1.Windows2025, Build: v1.Windows2025Build}
 	case v1.AMIFamilyCustom:
 		return &Custom{Options: options}
 	case v1.AMIFamilyAL2023:
@@ -237,13 +253,27 @@ func (r DefaultResolver) resolveLaunchTemplate(nodeClass *v1.EC2NodeClass, nodeC
 		BlockDeviceMappings: nodeClass.Spec.BlockDeviceMappings,
 		MetadataOptions:     nodeClass.Spec.MetadataOptions,
 		DetailedMonitoring:  aws.ToBool(nodeClass.Spec.DetailedMonitoring),
-		AMIID:               amiID,
-		InstanceTypes:       instanceTypes,
-		EFACount:            efaCount,
-		CapacityType:        capacityType,
+		// Spot instances can't be protected from termination
+		TerminationProtection: aws.ToBool(nodeClass.Spec.TerminationProtection) && capacityType != karpv1.CapacityTypeSpot,
+		EnclavesEnabled:       nodeClass.EnclavesEnabled(),
+		KeyName:               aws.ToString(nodeClass.Spec.KeyName),
+		AMIID:                 amiID,
+		InstanceTypes:         instanceTypes,
+		EFACount:              efaCount,
+		CapacityType:          capacityType,
 	}
 	if len(resolved.BlockDeviceMappings) == 0 {
 		resolved.BlockDeviceMappings = amiFamily.DefaultBlockDeviceMappings()
+		if nodeClass.Spec.Profile != nil {
+			// The default block device mappings are shared, so the profile's defaults are applied to copies of them
+			resolved.BlockDeviceMappings = lo.Map(resolved.BlockDeviceMappings, func(bdm *v1.BlockDeviceMapping, _ int) *v1.BlockDeviceMapping {
+				bdm = bdm.DeepCopy()
+				if bdm.EBS != nil {
+					nodeClass.Spec.Profile.SetBlockDeviceDefaults(bdm.EBS)
+				}
+				return bdm
+			})
+		}
 	}
 	if resolved.MetadataOptions == nil {
 		resolved.MetadataOptions = amiFamily.DefaultMetadataOptions()
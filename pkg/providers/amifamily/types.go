@@ -32,15 +32,27 @@ import (
 )
 
 type AMI struct {
-	Name         string
-	AmiID        string
-	CreationDate string
-	Deprecated   bool
-	Requirements scheduling.Requirements
+	Name            string
+	AmiID           string
+	CreationDate    string
+	Deprecated      bool
+	Windows         bool
+	Encrypted       bool
+	Owned           bool
+	FastLaunchState string
+	Requirements    scheduling.Requirements
 }
 
 type AMIs []AMI
 
+const (
+	// PinnedAMINotFound is the reason that an AMI which is pinned by id is unavailable when it's been deregistered,
+	// or isn't shared with the account anymore
+	PinnedAMINotFound = "PinnedAMINotFound"
+	// PinnedAMIDeprecated is the reason that an AMI which is pinned by id is unavailable when it's past its deprecation time
+	PinnedAMIDeprecated = "PinnedAMIDeprecated"
+)
+
 // Sort orders the AMIs by creation date in descending order.
 // If creation date is nil or two AMIs have the same creation date, the AMIs will be sorted by ID, which is guaranteed to be unique, in ascending order.
 func (a AMIs) Sort() {
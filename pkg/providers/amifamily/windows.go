@@ -39,7 +39,7 @@ import (
 type Windows struct {
 	DefaultFamily
 	*Options
-	// Version is the major version of Windows Server (2019 or 2022).
+	// Version is the major version of Windows Server (2019, 2022 or 2025).
 	// Only the core version of each version is supported by Karpenter, so this field only indicates the year.
 	Version string
 	// Build is a specific build code associated with the Version
@@ -48,7 +48,7 @@ type Windows struct {
 
 func (w Windows) DescribeImageQuery(ctx context.Context, ssmProvider ssm.Provider, k8sVersion string, amiVersion string) (DescribeImageQuery, error) {
 	imageID, err := ssmProvider.Get(ctx, ssm.Parameter{
-		Name:      fmt.Sprintf("/aws/service/ami-windows-latest/Windows_Server-%s-English-%s-EKS_Optimized-%s/image_id", w.Version, v1.WindowsCore, k8sVersion),
+		Name:      fmt.Sprintf(ssm.PublicParameterPrefix+"/ami-windows-latest/Windows_Server-%s-English-%s-EKS_Optimized-%s/image_id", w.Version, v1.WindowsCore, k8sVersion),
 		IsMutable: true,
 	})
 	if err != nil {
@@ -79,6 +79,7 @@ func (w Windows) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev
 			Labels:          labels,
 			CABundle:        caBundle,
 			CustomUserData:  customUserData,
+			WindowsGMSA:     w.Options.WindowsGMSA,
 		},
 	}
 }
@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityblock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// states are the states of the capacity blocks which instances are, or will be, launched into. Capacity blocks in
+// any other state have ended or will never start.
+var states = []ec2types.CapacityReservationState{
+	ec2types.CapacityReservationStatePaymentPending,
+	ec2types.CapacityReservationStateScheduled,
+	ec2types.CapacityReservationStateActive,
+}
+
+type Provider interface {
+	List(context.Context, *v1.EC2NodeClass) ([]ec2types.CapacityReservation, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cache:  cache,
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+// List returns the Capacity Blocks for ML which are selected by the EC2NodeClass and haven't ended. Capacity
+// reservations which aren't capacity blocks are never returned, even if they're selected.
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.CapacityReservation, error) {
+	p.Lock()
+	defer p.Unlock()
+	if len(nodeClass.Spec.CapacityBlockSelectorTerms) == 0 {
+		return nil, nil
+	}
+	inputs := getInputs(nodeClass.Spec.CapacityBlockSelectorTerms)
+	hash, err := hashstructure.Hash(inputs, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if capacityBlocks, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
+		// so that modifications to the ordering of the data don't affect the original
+		return append([]ec2types.CapacityReservation{}, capacityBlocks.([]ec2types.CapacityReservation)...), nil
+	}
+	capacityBlocks := map[string]ec2types.CapacityReservation{}
+	for _, input := range inputs {
+		paginator := ec2.NewDescribeCapacityReservationsPaginator(p.ec2api, input)
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing capacity reservations, %w", err)
+			}
+			for _, reservation := range out.CapacityReservations {
+				if reservation.ReservationType != ec2types.CapacityReservationTypeCapacityBlock || !lo.Contains(states, reservation.State) {
+					continue
+				}
+				capacityBlocks[aws.ToString(reservation.CapacityReservationId)] = reservation
+			}
+		}
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(capacityBlocks))
+	if p.cm.HasChanged(fmt.Sprintf("capacity-blocks/%s", nodeClass.Name), lo.Keys(capacityBlocks)) {
+		log.FromContext(ctx).WithValues("capacity-blocks", lo.Keys(capacityBlocks)).V(1).Info("discovered capacity blocks")
+	}
+	return lo.Values(capacityBlocks), nil
+}
+
+func getInputs(terms []v1.CapacityBlockSelectorTerm) []*ec2.DescribeCapacityReservationsInput {
+	var inputs []*ec2.DescribeCapacityReservationsInput
+	var ids []string
+	for _, term := range terms {
+		if term.ID != "" {
+			ids = append(ids, term.ID)
+			continue
+		}
+		var filters []ec2types.Filter
+		for k, v := range term.Tags {
+			if v == "*" {
+				filters = append(filters, ec2types.Filter{
+					Name:   aws.String("tag-key"),
+					Values: []string{k},
+				})
+			} else {
+				filters = append(filters, ec2types.Filter{
+					Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+					Values: []string{v},
+				})
+			}
+		}
+		inputs = append(inputs, &ec2.DescribeCapacityReservationsInput{Filters: filters})
+	}
+	if len(ids) > 0 {
+		inputs = append(inputs, &ec2.DescribeCapacityReservationsInput{CapacityReservationIds: ids})
+	}
+	return inputs
+}
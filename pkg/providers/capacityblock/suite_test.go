@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityblock_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var nodeClass *v1.EC2NodeClass
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityBlockProvider")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	nodeClass = test.EC2NodeClass()
+	awsEnv.Reset()
+	awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{CapacityReservations: []ec2types.CapacityReservation{
+		capacityReservation("cr-test1", ec2types.CapacityReservationTypeCapacityBlock, ec2types.CapacityReservationStateActive, map[string]string{"team": "ml"}),
+		capacityReservation("cr-test2", ec2types.CapacityReservationTypeCapacityBlock, ec2types.CapacityReservationStateScheduled, map[string]string{"team": "ml"}),
+		capacityReservation("cr-test3", ec2types.CapacityReservationTypeCapacityBlock, ec2types.CapacityReservationStateExpired, map[string]string{"team": "ml"}),
+		capacityReservation("cr-test4", ec2types.CapacityReservationTypeDefault, ec2types.CapacityReservationStateActive, map[string]string{"team": "ml"}),
+		capacityReservation("cr-test5", ec2types.CapacityReservationTypeCapacityBlock, ec2types.CapacityReservationStateActive, map[string]string{"team": "research"}),
+	}})
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func capacityReservation(id string, reservationType ec2types.CapacityReservationType, state ec2types.CapacityReservationState, tags map[string]string) ec2types.CapacityReservation {
+	return ec2types.CapacityReservation{
+		CapacityReservationId:  aws.String(id),
+		ReservationType:        reservationType,
+		State:                  state,
+		InstanceType:           aws.String("p5.48xlarge"),
+		AvailabilityZone:       aws.String("test-zone-1a"),
+		StartDate:              aws.Time(time.Now().Add(-time.Hour)),
+		EndDate:                aws.Time(time.Now().Add(24 * time.Hour)),
+		AvailableInstanceCount: aws.Int32(2),
+		Tags: lo.MapToSlice(tags, func(k, v string) ec2types.Tag {
+			return ec2types.Tag{Key: aws.String(k), Value: aws.String(v)}
+		}),
+	}
+}
+
+func ids(reservations []ec2types.CapacityReservation) []string {
+	return lo.Map(reservations, func(r ec2types.CapacityReservation, _ int) string { return aws.ToString(r.CapacityReservationId) })
+}
+
+var _ = Describe("CapacityBlockProvider", func() {
+	It("should not select any capacity blocks without capacity block selector terms", func() {
+		capacityBlocks, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacityBlocks).To(BeEmpty())
+	})
+	It("should select capacity blocks by id", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test1"}, {ID: "cr-test5"}}
+		capacityBlocks, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids(capacityBlocks)).To(ConsistOf("cr-test1", "cr-test5"))
+	})
+	It("should select capacity blocks by tags", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{Tags: map[string]string{"team": "ml"}}}
+		capacityBlocks, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids(capacityBlocks)).To(ConsistOf("cr-test1", "cr-test2"))
+	})
+	It("should ignore capacity reservations which aren't capacity blocks", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test4"}}
+		capacityBlocks, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacityBlocks).To(BeEmpty())
+	})
+	It("should ignore capacity blocks which have ended", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test3"}}
+		capacityBlocks, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacityBlocks).To(BeEmpty())
+	})
+	It("should return an error when describing capacity reservations fails", func() {
+		nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{ID: "cr-test1"}}
+		awsEnv.EC2API.NextError.Set(fmt.Errorf("failed"))
+		_, err := awsEnv.CapacityBlockProvider.List(ctx, nodeClass)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,181 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commitment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	savingsplanstypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// linuxProductDescriptions are the product descriptions of the reserved instances which apply to the Linux instances
+// that Karpenter launches
+var linuxProductDescriptions = []ec2types.RIProductDescription{
+	ec2types.RIProductDescriptionLinuxUnix,
+	ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+}
+
+type Provider interface {
+	Prices(context.Context) (map[ec2types.InstanceType]float64, error)
+}
+
+// DefaultProvider discovers the capacity that the account has already committed to with Savings Plans and Reserved
+// Instances, and the effective hourly price of each instance type which the commitments apply to.
+type DefaultProvider struct {
+	ec2api          sdk.EC2API
+	savingsPlansAPI sdk.SavingsPlansAPI
+	region          string
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, savingsPlansAPI sdk.SavingsPlansAPI, region string) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api:          ec2api,
+		savingsPlansAPI: savingsPlansAPI,
+		region:          region,
+	}
+}
+
+// Prices returns the lowest effective hourly price of each instance type which is covered by an active Savings Plan or
+// Reserved Instance in the region, for Linux instances with shared tenancy
+func (p *DefaultProvider) Prices(ctx context.Context) (map[ec2types.InstanceType]float64, error) {
+	prices := map[ec2types.InstanceType]float64{}
+	reservedInstancePrices, err := p.reservedInstancePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	savingsPlanPrices, err := p.savingsPlanPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, committed := range []map[ec2types.InstanceType]float64{reservedInstancePrices, savingsPlanPrices} {
+		for instanceType, price := range committed {
+			if existing, ok := prices[instanceType]; !ok || price < existing {
+				prices[instanceType] = price
+			}
+		}
+	}
+	return prices, nil
+}
+
+// reservedInstancePrices returns the effective hourly price of the active Reserved Instances, which amortizes the
+// upfront price over the term of the reservation
+func (p *DefaultProvider) reservedInstancePrices(ctx context.Context) (map[ec2types.InstanceType]float64, error) {
+	out, err := p.ec2api.DescribeReservedInstances(ctx, &ec2.DescribeReservedInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("state"), Values: []string{string(ec2types.ReservedInstanceStateActive)}},
+			{Name: aws.String("product-description"), Values: lo.Map(linuxProductDescriptions, func(d ec2types.RIProductDescription, _ int) string { return string(d) })},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing reserved instances, %w", err)
+	}
+	prices := map[ec2types.InstanceType]float64{}
+	for _, ri := range out.ReservedInstances {
+		if ri.InstanceTenancy != "" && ri.InstanceTenancy != ec2types.TenancyDefault {
+			continue
+		}
+		hours := float64(lo.FromPtr(ri.Duration)) / 3600
+		if hours == 0 {
+			continue
+		}
+		price := float64(lo.FromPtr(ri.FixedPrice))/hours + float64(lo.FromPtr(ri.UsagePrice))
+		for _, charge := range ri.RecurringCharges {
+			if charge.Frequency == ec2types.RecurringChargeFrequencyHourly {
+				price += lo.FromPtr(charge.Amount)
+			}
+		}
+		if existing, ok := prices[ri.InstanceType]; !ok || price < existing {
+			prices[ri.InstanceType] = price
+		}
+	}
+	return prices, nil
+}
+
+// savingsPlanPrices returns the rates of the active Compute and EC2 Instance Savings Plans for the instance types in the
+// region
+func (p *DefaultProvider) savingsPlanPrices(ctx context.Context) (map[ec2types.InstanceType]float64, error) {
+	var plans []savingsplanstypes.SavingsPlan
+	input := &savingsplans.DescribeSavingsPlansInput{
+		States: []savingsplanstypes.SavingsPlanState{savingsplanstypes.SavingsPlanStateActive},
+	}
+	for {
+		out, err := p.savingsPlansAPI.DescribeSavingsPlans(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("describing savings plans, %w", err)
+		}
+		plans = append(plans, out.SavingsPlans...)
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	prices := map[ec2types.InstanceType]float64{}
+	for _, plan := range plans {
+		if plan.SavingsPlanType != savingsplanstypes.SavingsPlanTypeCompute && plan.SavingsPlanType != savingsplanstypes.SavingsPlanTypeEc2Instance {
+			continue
+		}
+		if err := p.savingsPlanRates(ctx, aws.ToString(plan.SavingsPlanId), prices); err != nil {
+			return nil, err
+		}
+	}
+	return prices, nil
+}
+
+func (p *DefaultProvider) savingsPlanRates(ctx context.Context, savingsPlanID string, prices map[ec2types.InstanceType]float64) error {
+	input := &savingsplans.DescribeSavingsPlanRatesInput{
+		SavingsPlanId: aws.String(savingsPlanID),
+		Filters: []savingsplanstypes.SavingsPlanRateFilter{
+			{Name: savingsplanstypes.SavingsPlanRateFilterNameRegion, Values: []string{p.region}},
+			{Name: savingsplanstypes.SavingsPlanRateFilterNameProductType, Values: []string{string(savingsplanstypes.SavingsPlanProductTypeEc2)}},
+			{Name: savingsplanstypes.SavingsPlanRateFilterNameProductDescription, Values: []string{string(ec2types.RIProductDescriptionLinuxUnix)}},
+			{Name: savingsplanstypes.SavingsPlanRateFilterNameTenancy, Values: []string{"shared"}},
+		},
+	}
+	for {
+		out, err := p.savingsPlansAPI.DescribeSavingsPlanRates(ctx, input)
+		if err != nil {
+			return fmt.Errorf("describing rates of savings plan %s, %w", savingsPlanID, err)
+		}
+		for _, rate := range out.SearchResults {
+			property, ok := lo.Find(rate.Properties, func(p savingsplanstypes.SavingsPlanRateProperty) bool {
+				return p.Name == savingsplanstypes.SavingsPlanRatePropertyKeyInstanceType
+			})
+			if !ok || rate.Unit != savingsplanstypes.SavingsPlanRateUnitHours {
+				continue
+			}
+			price, err := strconv.ParseFloat(aws.ToString(rate.Rate), 64)
+			if err != nil {
+				continue
+			}
+			instanceType := ec2types.InstanceType(aws.ToString(property.Value))
+			if existing, ok := prices[instanceType]; !ok || price < existing {
+				prices[instanceType] = price
+			}
+		}
+		if out.NextToken == nil {
+			return nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
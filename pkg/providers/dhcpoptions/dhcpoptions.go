@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dhcpoptions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// AmazonProvidedDNS is the DNS server value of DHCP options which resolve names with the Route 53 Resolver of the VPC
+const AmazonProvidedDNS = "AmazonProvidedDNS"
+
+type Provider interface {
+	DomainNameServers(context.Context, string) ([]string, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cache:  cache,
+	}
+}
+
+// DomainNameServers returns the DNS servers in the DHCP options of the VPC, which are empty if the VPC isn't associated
+// with DHCP options
+func (p *DefaultProvider) DomainNameServers(ctx context.Context, vpcID string) ([]string, error) {
+	p.Lock()
+	defer p.Unlock()
+	if servers, ok := p.cache.Get(vpcID); ok {
+		return append([]string{}, servers.([]string)...), nil
+	}
+	vpcs, err := p.ec2api.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+	if err != nil {
+		return nil, fmt.Errorf("describing vpc %s, %w", vpcID, err)
+	}
+	var servers []string
+	if len(vpcs.Vpcs) != 0 && vpcs.Vpcs[0].DhcpOptionsId != nil && lo.FromPtr(vpcs.Vpcs[0].DhcpOptionsId) != "default" {
+		out, err := p.ec2api.DescribeDhcpOptions(ctx, &ec2.DescribeDhcpOptionsInput{DhcpOptionsIds: []string{lo.FromPtr(vpcs.Vpcs[0].DhcpOptionsId)}})
+		if err != nil {
+			return nil, fmt.Errorf("describing dhcp options for %s, %w", vpcID, err)
+		}
+		for _, options := range out.DhcpOptions {
+			for _, configuration := range options.DhcpConfigurations {
+				if lo.FromPtr(configuration.Key) == "domain-name-servers" {
+					servers = append(servers, lo.Map(configuration.Values, func(v ec2types.AttributeValue, _ int) string { return lo.FromPtr(v.Value) })...)
+				}
+			}
+		}
+	}
+	p.cache.SetDefault(vpcID, servers)
+	return append([]string{}, servers...), nil
+}
@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// SpotRatio returns the percentage of the vCPUs of the NodePool which should run on spot capacity, with the rest
+// running on on-demand capacity, as configured by the karpenter.k8s.aws/spot-ratio annotation. False is returned if
+// the NodePool doesn't target a ratio.
+func SpotRatio(nodePool *karpv1.NodePool) (int, bool, error) {
+	value, ok := nodePool.Annotations[v1.AnnotationSpotRatio]
+	if !ok {
+		return 0, false, nil
+	}
+	ratio, err := strconv.Atoi(value)
+	if err != nil || ratio < 0 || ratio > 100 {
+		return 0, false, fmt.Errorf("%s annotation must be an integer percentage between 0 and 100, got %q", v1.AnnotationSpotRatio, value)
+	}
+	return ratio, true, nil
+}
+
+// CapacityTypeCPUs returns the vCPUs of the launched NodeClaims which run on spot and on-demand capacity. NodeClaims
+// which are being deleted aren't counted, since they're already being replaced.
+func CapacityTypeCPUs(nodeClaims []karpv1.NodeClaim) (spot, onDemand int64) {
+	for _, nc := range nodeClaims {
+		if !nc.DeletionTimestamp.IsZero() {
+			continue
+		}
+		switch nc.Labels[karpv1.CapacityTypeLabelKey] {
+		case karpv1.CapacityTypeSpot:
+			spot += nc.Status.Capacity.Cpu().Value()
+		case karpv1.CapacityTypeOnDemand:
+			onDemand += nc.Status.Capacity.Cpu().Value()
+		}
+	}
+	return spot, onDemand
+}
+
+// BalanceCapacityTypes narrows the offerings of the instance types to the capacity type which moves the vCPUs of a
+// NodePool towards its spot ratio: spot while the share of spot vCPUs is below the ratio, and on-demand otherwise.
+// Instance types are only narrowed when the requirements allow both capacity types and an offering of the preferred
+// capacity type remains available, otherwise the instance types are returned unchanged.
+func BalanceCapacityTypes(ratio int, spot, onDemand int64, reqs scheduling.Requirements, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	capacityTypes := reqs.Get(karpv1.CapacityTypeLabelKey)
+	if !capacityTypes.Has(karpv1.CapacityTypeSpot) || !capacityTypes.Has(karpv1.CapacityTypeOnDemand) {
+		return instanceTypes
+	}
+	preferred := karpv1.CapacityTypeOnDemand
+	if spot*100 < int64(ratio)*(spot+onDemand) || (spot+onDemand == 0 && ratio > 0) {
+		preferred = karpv1.CapacityTypeSpot
+	}
+	other := lo.Ternary(preferred == karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand, karpv1.CapacityTypeSpot)
+	balanced := lo.FilterMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) (*cloudprovider.InstanceType, bool) {
+		offerings := cloudprovider.Offerings(lo.Reject(it.Offerings, func(o cloudprovider.Offering, _ int) bool {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == other
+		}))
+		if len(offerings) == len(it.Offerings) {
+			return it, true
+		}
+		// The instance types are shared with the instance type provider's cache, so the offerings are narrowed on a copy
+		return &cloudprovider.InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Offerings:    offerings,
+			Capacity:     it.Capacity,
+			Overhead:     it.Overhead,
+		}, len(offerings.Compatible(reqs).Available()) > 0
+	})
+	if !lo.ContainsBy(balanced, func(it *cloudprovider.InstanceType) bool {
+		return lo.ContainsBy(it.Offerings.Compatible(reqs).Available(), func(o cloudprovider.Offering) bool {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == preferred
+		})
+	}) {
+		return instanceTypes
+	}
+	return balanced
+}
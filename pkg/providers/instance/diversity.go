@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// spotPool is the capacity pool of a spot instance, which is reclaimed as a whole when EC2 needs the capacity back
+type spotPool struct {
+	instanceType string
+	zone         string
+}
+
+// MinSpotPools returns the number of distinct spot capacity pools that the spot nodes of the NodePool are spread
+// across, as configured by the karpenter.k8s.aws/min-spot-pools annotation
+func MinSpotPools(nodePool *karpv1.NodePool) (int, error) {
+	value, ok := nodePool.Annotations[v1.AnnotationMinSpotPools]
+	if !ok {
+		return 0, nil
+	}
+	pools, err := strconv.Atoi(value)
+	if err != nil || pools < 0 {
+		return 0, fmt.Errorf("%s annotation must be a non-negative integer, got %q", v1.AnnotationMinSpotPools, value)
+	}
+	return pools, nil
+}
+
+// DiversifySpotPools removes the spot offerings in the capacity pools that are already used by the spot NodeClaims of
+// a NodePool, until the NodeClaims are spread across the minimum number of pools. Instance types are only narrowed when
+// an unused spot pool remains available to the requirements, otherwise the instance types are returned unchanged.
+func DiversifySpotPools(minPools int, nodeClaims []karpv1.NodeClaim, reqs scheduling.Requirements, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	used := sets.New[spotPool]()
+	for _, nc := range nodeClaims {
+		if !nc.DeletionTimestamp.IsZero() || nc.Labels[karpv1.CapacityTypeLabelKey] != karpv1.CapacityTypeSpot || nc.Labels[corev1.LabelInstanceTypeStable] == "" {
+			continue
+		}
+		used.Insert(spotPool{instanceType: nc.Labels[corev1.LabelInstanceTypeStable], zone: nc.Labels[corev1.LabelTopologyZone]})
+	}
+	if used.Len() == 0 || used.Len() >= minPools {
+		return instanceTypes
+	}
+	diversified := lo.FilterMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) (*cloudprovider.InstanceType, bool) {
+		offerings := cloudprovider.Offerings(lo.Reject(it.Offerings, func(o cloudprovider.Offering, _ int) bool {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == karpv1.CapacityTypeSpot &&
+				used.Has(spotPool{instanceType: it.Name, zone: o.Requirements.Get(corev1.LabelTopologyZone).Any()})
+		}))
+		if len(offerings) == len(it.Offerings) {
+			return it, true
+		}
+		// The instance types are shared with the instance type provider's cache, so the offerings are narrowed on a copy
+		return &cloudprovider.InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Offerings:    offerings,
+			Capacity:     it.Capacity,
+			Overhead:     it.Overhead,
+		}, len(offerings.Compatible(reqs).Available()) > 0
+	})
+	if !lo.ContainsBy(diversified, func(it *cloudprovider.InstanceType) bool {
+		return lo.ContainsBy(it.Offerings.Compatible(reqs).Available(), func(o cloudprovider.Offering) bool {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == karpv1.CapacityTypeSpot
+		})
+	}) {
+		return instanceTypes
+	}
+	return diversified
+}
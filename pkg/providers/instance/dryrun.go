@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// dryRunLaunchTemplateID is the launch template referenced by DryRun launches. Launch templates are only created when a
+// NodeClaim is launched, so DryRun launches reference a placeholder rather than the launch templates of the EC2NodeClass.
+const dryRunLaunchTemplateID = "lt-0123456789abcdef0"
+
+// DryRun makes a DryRun CreateFleet request with a representative configuration of the EC2NodeClass: its resolved
+// subnets, its context, and the tags that instances are launched with. It returns nil if EC2 reports that the request
+// would have succeeded, and otherwise the error that EC2 returned, e.g. because the controller isn't authorized to launch
+// instances with the tags or a parameter is invalid.
+func (p *DefaultProvider) DryRun(ctx context.Context, nodeClass *v1.EC2NodeClass, tags map[string]string) error {
+	instanceType := lo.Ternary(isARM64(nodeClass), ec2types.InstanceTypeM6gLarge, ec2types.InstanceTypeM5Large)
+	_, err := p.ec2api.CreateFleet(ctx, &ec2.CreateFleetInput{
+		DryRun:  aws.Bool(true),
+		Type:    ec2types.FleetTypeInstant,
+		Context: nodeClass.Spec.Context,
+		LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{{
+			LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
+				LaunchTemplateId: aws.String(dryRunLaunchTemplateID),
+				Version:          aws.String("$Latest"),
+			},
+			Overrides: lo.Map(lo.UniqBy(nodeClass.Status.Subnets, func(s v1.Subnet) string { return s.Zone }), func(s v1.Subnet, _ int) ec2types.FleetLaunchTemplateOverridesRequest {
+				return ec2types.FleetLaunchTemplateOverridesRequest{
+					InstanceType:     instanceType,
+					SubnetId:         aws.String(s.ID),
+					AvailabilityZone: aws.String(s.Zone),
+				}
+			}),
+		}},
+		TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+			DefaultTargetCapacityType: ec2types.DefaultTargetCapacityTypeOnDemand,
+			TotalTargetCapacity:       aws.Int32(1),
+		},
+		OnDemandOptions: &ec2types.OnDemandOptionsRequest{AllocationStrategy: ec2types.FleetOnDemandAllocationStrategyLowestPrice},
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: utils.MergeTags(tags)},
+			{ResourceType: ec2types.ResourceTypeVolume, Tags: utils.MergeTags(tags)},
+			{ResourceType: ec2types.ResourceTypeFleet, Tags: utils.MergeTags(tags)},
+		},
+	})
+	return awserrors.IgnoreDryRunError(err)
+}
+
+// isARM64 returns true if the EC2NodeClass only resolved arm64 AMIs
+func isARM64(nodeClass *v1.EC2NodeClass) bool {
+	return len(nodeClass.Status.AMIs) > 0 && lo.EveryBy(nodeClass.Status.AMIs, func(ami v1.AMI) bool {
+		return lo.ContainsBy(ami.Requirements, func(r corev1.NodeSelectorRequirement) bool {
+			return r.Key == corev1.LabelArchStable && r.Operator == corev1.NodeSelectorOpIn && lo.Contains(r.Values, karpv1.ArchitectureArm64)
+		})
+	})
+}
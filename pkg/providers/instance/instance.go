@@ -20,7 +20,10 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 
@@ -28,6 +31,7 @@ import (
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
@@ -43,6 +47,9 @@ import (
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/license"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -52,7 +59,16 @@ import (
 
 const (
 	instanceTypeFlexibilityThreshold = 5 // falling back to on-demand without flexibility risks insufficient capacity errors
-	maxInstanceTypes                 = 60
+	// MaxInstanceTypes is the number of the cheapest instance types that a launch considers
+	MaxInstanceTypes = 60
+	// launchedInstanceTTL bounds how long a launched instance is tracked while waiting for it to be described
+	launchedInstanceTTL = 15 * time.Minute
+	// terminatingInstanceTTL bounds how long an instance is tracked while waiting for its termination to be confirmed.
+	// EC2 continues to describe terminated instances for about an hour.
+	terminatingInstanceTTL = time.Hour
+	// terminationRetryInterval is the interval after which termination is retried for instances which are stuck
+	// terminating
+	terminationRetryInterval = 5 * time.Minute
 )
 
 var (
@@ -71,29 +87,66 @@ var (
 type Provider interface {
 	Create(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim, map[string]string, []*cloudprovider.InstanceType) (*Instance, error)
 	Get(context.Context, string) (*Instance, error)
+	GetByClientToken(context.Context, string) (*Instance, error)
 	List(context.Context) ([]*Instance, error)
 	Delete(context.Context, string) error
 	CreateTags(context.Context, string, map[string]string) error
+	DeleteTags(context.Context, string, []string) error
+	DryRun(context.Context, *v1.EC2NodeClass, map[string]string) error
+	ListStandby(context.Context) ([]*Instance, error)
+	Stop(context.Context, string) error
+	Resume(context.Context, *karpv1.NodeClaim, string, string, []*cloudprovider.InstanceType) (*Instance, error)
+	GetVolumes(context.Context, string) ([]Volume, error)
+	ModifyVolume(context.Context, string, int32) error
+	Reboot(context.Context, string) error
 }
 
 type DefaultProvider struct {
-	region                 string
-	ec2api                 sdk.EC2API
-	unavailableOfferings   *cache.UnavailableOfferings
-	subnetProvider         subnet.Provider
-	launchTemplateProvider launchtemplate.Provider
-	ec2Batcher             *batcher.EC2API
+	region                     string
+	ec2api                     sdk.EC2API
+	unavailableOfferings       *cache.UnavailableOfferings
+	subnetProvider             subnet.Provider
+	launchTemplateProvider     launchtemplate.Provider
+	licenseProvider            license.Provider
+	policyProvider             policy.Provider
+	spotPlacementScoreProvider spotplacementscore.Provider
+	ec2Batcher                 *batcher.EC2API
+	// launched tracks instances which have been launched but not yet described, so that the time it takes for EC2 to
+	// confirm the instance can be observed
+	launched *gocache.Cache
+	// terminating tracks instances which termination has been requested for, with the time that termination was last
+	// requested. These instances are only reported as not found once they're confirmed to be terminated.
+	terminating *gocache.Cache
+	// placementPartitions tracks the next partition to assign for each placement group, so that launches are spread
+	// across the partitions that a NodeClaim is compatible with
+	placementPartitions   map[string]int32
+	placementPartitionsMu sync.Mutex
+	// resumeMu serializes the resumption of standby instances, so that a standby instance is only resumed for a single
+	// NodeClaim
+	resumeMu sync.Mutex
+}
+
+type launchRecord struct {
+	nodePool   string
+	launchTime time.Time
 }
 
 func NewDefaultProvider(ctx context.Context, region string, ec2api sdk.EC2API, unavailableOfferings *cache.UnavailableOfferings,
-	subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider) *DefaultProvider {
+	subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider, licenseProvider license.Provider, policyProvider policy.Provider,
+	spotPlacementScoreProvider spotplacementscore.Provider) *DefaultProvider {
 	return &DefaultProvider{
-		region:                 region,
-		ec2api:                 ec2api,
-		unavailableOfferings:   unavailableOfferings,
-		subnetProvider:         subnetProvider,
-		launchTemplateProvider: launchTemplateProvider,
-		ec2Batcher:             batcher.EC2(ctx, ec2api),
+		region:                     region,
+		ec2api:                     ec2api,
+		unavailableOfferings:       unavailableOfferings,
+		subnetProvider:             subnetProvider,
+		launchTemplateProvider:     launchTemplateProvider,
+		licenseProvider:            licenseProvider,
+		policyProvider:             policyProvider,
+		spotPlacementScoreProvider: spotPlacementScoreProvider,
+		ec2Batcher:                 batcher.EC2(ctx, ec2api),
+		launched:                   gocache.New(launchedInstanceTTL, time.Minute),
+		terminating:                gocache.New(terminatingInstanceTTL, time.Minute),
+		placementPartitions:        map[string]int32{},
 	}
 }
 
@@ -103,10 +156,41 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1.EC2NodeClass
 	if !schedulingRequirements.HasMinValues() {
 		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
 	}
-	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
+	licenseConfigurationARN, licensed := nodeClaim.Annotations[v1.AnnotationLicenseConfigurationARN]
+	if licensed {
+		var err error
+		if instanceTypes, err = p.filterLicensedInstanceTypes(ctx, licenseConfigurationARN, instanceTypes); err != nil {
+			return nil, err
+		}
+	}
+	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, MaxInstanceTypes)
 	if err != nil {
 		return nil, cloudprovider.NewCreateError(fmt.Errorf("truncating instance types, %w", err), "Error truncating instance types based on the passed-in requirements")
 	}
+	// Launching outside of the placement group would silently break the placement that its workloads rely on
+	if nodeClass.Spec.PlacementGroup != nil && nodeClass.PlacementGroupName() == "" {
+		return nil, cloudprovider.NewCreateError(fmt.Errorf("placement group hasn't been resolved"), "Placement group of the EC2NodeClass hasn't been resolved")
+	}
+	var partition int32
+	if partitions := nodeClass.PlacementGroupPartitions(); partitions > 0 {
+		if partition, err = p.nextPlacementPartition(nodeClass.PlacementGroupName(), partitions, schedulingRequirements); err != nil {
+			return nil, cloudprovider.NewCreateError(err, "NodeClaim is incompatible with the partitions of the placement group")
+		}
+		// The partition is passed to the launch template through the NodeClaim labels, so that it's also registered
+		// as a label of the node
+		nodeClaim = nodeClaim.DeepCopy()
+		nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{v1.LabelPlacementPartition: fmt.Sprint(partition)})
+	}
+	var capacityBlockID string
+	if p.getCapacityType(nodeClaim, instanceTypes) == v1.CapacityTypeCapacityBlock {
+		capacityBlockID = selectCapacityBlock(nodeClass, nodeClaim, instanceTypes)
+		// The capacity block is passed to the launch template through the NodeClaim requirements, which also restricts
+		// the launch to the instance type and zone of the capacity block
+		nodeClaim = nodeClaim.DeepCopy()
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelCapacityBlockID, Operator: corev1.NodeSelectorOpIn, Values: []string{capacityBlockID}},
+		})
+	}
 	fleetInstance, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	if awserrors.IsLaunchTemplateNotFound(err) {
 		// retry once if launch template is not found. This allows karpenter to generate a new LT if the
@@ -116,14 +200,119 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1.EC2NodeClass
 	if err != nil {
 		return nil, err
 	}
+	if licensed {
+		// The launched instance consumed licenses, so the remaining seats must be refreshed before the next launch
+		p.licenseProvider.Invalidate(licenseConfigurationARN)
+	}
+	p.launched.SetDefault(fleetInstance.InstanceIds[0], launchRecord{nodePool: nodeClaim.Labels[karpv1.NodePoolLabelKey], launchTime: time.Now()})
 	efaEnabled := lo.Contains(lo.Keys(nodeClaim.Spec.Resources.Requests), v1.ResourceEFA)
-	return NewInstanceFromFleet(fleetInstance, tags, efaEnabled), nil
+	instance := NewInstanceFromFleet(fleetInstance, tags, efaEnabled)
+	instance.PartitionNumber = partition
+	if capacityBlockID != "" {
+		instance.CapacityType = v1.CapacityTypeCapacityBlock
+		instance.CapacityBlockID = capacityBlockID
+	}
+	return instance, nil
+}
+
+// selectCapacityBlock selects the capacity block which an instance is launched into, out of the capacity blocks with
+// available offerings that are compatible with the NodeClaim. The capacity block which ends last is selected, so that
+// the node is launched into the capacity block which it can run in for the longest.
+func selectCapacityBlock(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) string {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeCapacityBlock)
+	ids := sets.New[string]()
+	for _, it := range instanceTypes {
+		for _, offering := range it.Offerings.Available() {
+			if requirements.Compatible(offering.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil {
+				ids.Insert(offering.Requirements.Get(v1.LabelCapacityBlockID).Any())
+			}
+		}
+	}
+	capacityBlocks := lo.Filter(nodeClass.Status.CapacityBlocks, func(capacityBlock v1.CapacityBlock, _ int) bool {
+		return ids.Has(capacityBlock.ID)
+	})
+	sort.Slice(capacityBlocks, func(i, j int) bool {
+		if !capacityBlocks[i].EndTime.Equal(&capacityBlocks[j].EndTime) {
+			return capacityBlocks[i].EndTime.After(capacityBlocks[j].EndTime.Time)
+		}
+		return capacityBlocks[i].ID < capacityBlocks[j].ID
+	})
+	if len(capacityBlocks) == 0 {
+		// The offerings are resolved from the status of the EC2NodeClass, so this is only reached if the capacity
+		// blocks changed in between
+		return sets.List(ids)[0]
+	}
+	return capacityBlocks[0].ID
+}
+
+// nextPlacementPartition assigns partitions of the placement group round-robin, skipping the partitions which the
+// NodeClaim's requirements don't allow
+func (p *DefaultProvider) nextPlacementPartition(placementGroup string, partitions int32, reqs scheduling.Requirements) (int32, error) {
+	p.placementPartitionsMu.Lock()
+	defer p.placementPartitionsMu.Unlock()
+	for i := range partitions {
+		partition := (p.placementPartitions[placementGroup]+i)%partitions + 1
+		if reqs.Get(v1.LabelPlacementPartition).Has(fmt.Sprint(partition)) {
+			p.placementPartitions[placementGroup] = partition % partitions
+			return partition, nil
+		}
+	}
+	return 0, fmt.Errorf("no partition of placement group %q satisfies requirement %s", placementGroup, reqs.Get(v1.LabelPlacementPartition))
 }
 
 func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error) {
+	instance, err := p.describe(ctx, id, instanceStateFilter)
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		if _, ok := p.terminating.Get(id); ok {
+			// The instance no longer matches the state filter, but it's only reported as not found once it's confirmed
+			// to be terminated
+			return p.confirmTerminated(ctx, id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.terminating.Get(id); ok {
+		if err := p.terminate(ctx, instance); err != nil {
+			log.FromContext(ctx).Error(err, "failed retrying instance termination")
+		}
+	}
+	p.confirm(instance)
+	return instance, nil
+}
+
+// GetByClientToken returns the instance which was launched with the passed client token, so that a launch which is
+// recorded in the launch journal of a NodeClaim can adopt the instance that it launched before the controller restarted
+func (p *DefaultProvider) GetByClientToken(ctx context.Context, token string) (*Instance, error) {
+	out, err := p.ec2api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1.LaunchClientTokenTagKey)),
+				Values: []string{token},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1.EKSClusterNameTagKey)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+			instanceStateFilter,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing ec2 instances, %w", err)
+	}
+	instances, err := instancesFromOutput(out)
+	if err != nil {
+		return nil, err
+	}
+	return instances[0], nil
+}
+
+// describe returns the instance with the passed ID, if it matches the filters
+func (p *DefaultProvider) describe(ctx context.Context, id string, filters ...ec2types.Filter) (*Instance, error) {
 	out, err := p.ec2Batcher.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: []string{id},
-		Filters:     []ec2types.Filter{instanceStateFilter},
+		Filters:     filters,
 	})
 	if awserrors.IsNotFound(err) {
 		return nil, cloudprovider.NewNodeClaimNotFoundError(err)
@@ -170,27 +359,107 @@ func (p *DefaultProvider) List(ctx context.Context) ([]*Instance, error) {
 		out.Reservations = append(out.Reservations, page.Reservations...)
 	}
 	instances, err := instancesFromOutput(out)
+	// Standby instances of warm pools aren't the instances of any NodeClaim until they're resumed, so they must not be
+	// garbage collected
+	instances = lo.Reject(instances, func(instance *Instance, _ int) bool { return instance.Standby() })
+	for _, instance := range instances {
+		p.confirm(instance)
+	}
 	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
 }
 
+// confirm observes the time it took for a launched instance to be described for the first time
+func (p *DefaultProvider) confirm(instance *Instance) {
+	record, ok := p.launched.Get(instance.ID)
+	if !ok {
+		return
+	}
+	p.launched.Delete(instance.ID)
+	ObserveLaunchPhase(record.(launchRecord).nodePool, PhaseInstanceConfirmation, time.Since(record.(launchRecord).launchTime))
+}
+
+// Delete requests the termination of the instance. A NodeClaimNotFoundError is only returned once the instance is
+// confirmed to be terminated, rather than as soon as EC2 accepts the termination request, so that NodeClaims aren't
+// finalized while their instances are still running.
 func (p *DefaultProvider) Delete(ctx context.Context, id string) error {
+	instance, err := p.describe(ctx, id)
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		if _, ok := p.launched.Get(id); ok {
+			// The instance was launched but hasn't been described yet, so it may not be visible due to EC2's eventual
+			// consistency
+			return fmt.Errorf("terminating instance, instance was launched but isn't described yet")
+		}
+		p.terminating.Delete(id)
+		return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already terminated"))
+	}
+	if err != nil {
+		return fmt.Errorf("terminating instance, %w", err)
+	}
+	return p.terminate(ctx, instance)
+}
+
+// terminate requests the termination of the instance, unless termination was requested recently. Instances which are
+// still not terminated after the termination retry interval are stuck terminating and have their termination retried.
+func (p *DefaultProvider) terminate(ctx context.Context, instance *Instance) error {
+	if instance.State == ec2types.InstanceStateNameTerminated {
+		p.terminating.Delete(instance.ID)
+		return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already terminated"))
+	}
+	if requested, ok := p.terminating.Get(instance.ID); ok {
+		if time.Since(requested.(time.Time)) < terminationRetryInterval {
+			return nil
+		}
+		TerminationsStalledTotal.Inc(map[string]string{nodePoolLabel: instance.Tags[karpv1.NodePoolLabelKey]})
+		log.FromContext(ctx).WithValues("id", instance.ID, "state", instance.State, "termination-requested", requested).Info("retrying termination of instance stuck terminating")
+	}
+	// The termination protection of the instance is only removed once Karpenter terminates it, e.g. after its node is
+	// drained, so that it can't be terminated out of band in the meantime
+	if instance.Tags[v1.TerminationProtectionTagKey] == "true" {
+		if err := p.removeTerminationProtection(ctx, instance.ID); err != nil {
+			return err
+		}
+	}
 	if _, err := p.ec2Batcher.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
-		InstanceIds: []string{id},
+		InstanceIds: []string{instance.ID},
+	}); err != nil {
+		return fmt.Errorf("terminating instance, %w", err)
+	}
+	p.terminating.SetDefault(instance.ID, time.Now())
+	return nil
+}
+
+func (p *DefaultProvider) removeTerminationProtection(ctx context.Context, id string) error {
+	if _, err := p.ec2api.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:            aws.String(id),
+		DisableApiTermination: &ec2types.AttributeBooleanValue{Value: aws.Bool(false)},
 	}); err != nil {
 		if awserrors.IsNotFound(err) {
-			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already terminated"))
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("removing termination protection, %w", err))
 		}
-		if _, e := p.Get(ctx, id); e != nil {
-			if cloudprovider.IsNodeClaimNotFoundError(e) {
-				return e
-			}
-			err = multierr.Append(err, e)
-		}
-		return fmt.Errorf("terminating instance, %w", err)
+		return fmt.Errorf("removing termination protection, %w", err)
 	}
 	return nil
 }
 
+// confirmTerminated returns a NodeClaimNotFoundError if the instance is described in the terminated state. Otherwise,
+// the instance is still terminating.
+func (p *DefaultProvider) confirmTerminated(ctx context.Context, id string) (*Instance, error) {
+	instance, err := p.describe(ctx, id)
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		// EC2 continues to describe terminated instances for some time, so an instance which isn't described at all
+		// may not be visible due to eventual consistency. The error isn't wrapped, since termination isn't confirmed.
+		return nil, fmt.Errorf("confirming instance termination, instance isn't described")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("confirming instance termination, %w", err)
+	}
+	if instance.State != ec2types.InstanceStateNameTerminated {
+		return instance, nil
+	}
+	p.terminating.Delete(id)
+	return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance terminated"))
+}
+
 func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[string]string) error {
 	ec2Tags := lo.MapToSlice(tags, func(key, value string) ec2types.Tag {
 		return ec2types.Tag{Key: aws.String(key), Value: aws.String(value)}
@@ -207,7 +476,56 @@ func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[st
 	return nil
 }
 
+func (p *DefaultProvider) DeleteTags(ctx context.Context, id string, keys []string) error {
+	if _, err := p.ec2api.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{id},
+		Tags: lo.Map(keys, func(key string, _ int) ec2types.Tag {
+			return ec2types.Tag{Key: aws.String(key)}
+		}),
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("untagging instance, %w", err))
+		}
+		return fmt.Errorf("untagging instance, %w", err)
+	}
+	return nil
+}
+
+// Reboot requests a reboot of the instance. The reboot is asynchronous, and EC2 performs a hard reboot if the operating
+// system doesn't shut down cleanly within four minutes.
+func (p *DefaultProvider) Reboot(ctx context.Context, id string) error {
+	if _, err := p.ec2api.RebootInstances(ctx, &ec2.RebootInstancesInput{InstanceIds: []string{id}}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("rebooting instance, %w", err))
+		}
+		return fmt.Errorf("rebooting instance, %w", err)
+	}
+	return nil
+}
+
+// filterLicensedInstanceTypes removes the instance types which can't be launched with the licenses that remain available
+// within the license configuration. If no licenses remain for any of the instance types, the launch is blocked rather
+// than letting EC2 reject it or exceeding a soft license limit.
+func (p *DefaultProvider) filterLicensedInstanceTypes(ctx context.Context, arn string, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, error) {
+	seats, err := p.licenseProvider.Seats(ctx, arn)
+	if err != nil {
+		return nil, cloudprovider.NewCreateError(fmt.Errorf("getting license seats, %w", err), "Error getting license seats")
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return seats.Allows(it)
+	})
+	if len(instanceTypes) == 0 {
+		license.ExhaustedTotal.Inc(license.Labels(arn))
+		return nil, cloudprovider.NewCreateError(fmt.Errorf("license configuration %q has %d remaining seats", arn, lo.FromPtr(seats.Remaining)), "License configuration has no remaining seats")
+	}
+	return instanceTypes, nil
+}
+
 func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (ec2types.CreateFleetInstance, error) {
+	preferences, err := preferencesFor(nodeClaim)
+	if err != nil {
+		return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("parsing %s annotation, %w", v1.AnnotationInstancePreferences, err), "Error parsing instance preferences")
+	}
 	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
 	zonalSubnets, err := p.subnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, capacityType)
 	if err != nil {
@@ -215,13 +533,36 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 	}
 
 	// Get Launch Template Configs, which may differ due to GPU or Architecture requirements
+	start := time.Now()
 	launchTemplateConfigs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityType, tags)
+	ObserveLaunchPhase(nodeClaim.Labels[karpv1.NodePoolLabelKey], PhaseLaunchTemplateRendering, time.Since(start))
 	if err != nil {
 		return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("getting launch template configs, %w", err), "Error getting launch template configs")
 	}
 	if err := p.checkODFallback(nodeClaim, instanceTypes, launchTemplateConfigs); err != nil {
 		log.FromContext(ctx).Error(err, "failed while checking on-demand fallback")
 	}
+	// When arm64, specific offerings, weighted zones, or zones with a higher spot placement score are preferred,
+	// overrides are prioritized rather than being launched purely based on price
+	preferARM64 := options.FromContext(ctx).ArchitecturePreference == options.ArchitecturePreferenceARM64 && isArchitectureFlexible(instanceTypes)
+	weights := getZonalWeights(zonalSubnets)
+	scores := p.getZonalSpotPlacementScores(ctx, launchTemplateConfigs, zonalSubnets, capacityType)
+	prioritized := preferARM64 || len(preferences) > 0 || len(weights) > 0 || len(scores) > 0
+	if prioritized {
+		prioritize(launchTemplateConfigs, instanceTypes, capacityType, preferARM64, preferences, weights, scores)
+	}
+	// Instances which are launched with termination protection are tagged, so that the protection is removed before
+	// they're terminated
+	instanceTags := tags
+	if lo.FromPtr(nodeClass.Spec.TerminationProtection) && capacityType != karpv1.CapacityTypeSpot {
+		instanceTags = lo.Assign(instanceTags, map[string]string{v1.TerminationProtectionTagKey: "true"})
+	}
+	// Launches which are recorded in the launch journal are made with the journaled client token, and the instance is
+	// tagged with it so that the launch can be reconciled if the controller restarts before the NodeClaim is updated
+	clientToken, journaled := nodeClaim.Annotations[v1.AnnotationLaunchClientToken]
+	if journaled {
+		instanceTags = lo.Assign(instanceTags, map[string]string{v1.LaunchClientTokenTagKey: clientToken})
+	}
 	// Create fleet
 	createFleetInput := &ec2.CreateFleetInput{
 		Type:                  ec2types.FleetTypeInstant,
@@ -232,21 +573,38 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 			TotalTargetCapacity:       aws.Int32(1),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
-			{ResourceType: ec2types.ResourceTypeInstance, Tags: utils.MergeTags(tags)},
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: utils.MergeTags(instanceTags)},
 			{ResourceType: ec2types.ResourceTypeVolume, Tags: utils.MergeTags(tags)},
 			{ResourceType: ec2types.ResourceTypeFleet, Tags: utils.MergeTags(tags)},
 		},
 	}
+	if journaled {
+		createFleetInput.ClientToken = aws.String(clientToken)
+	}
 	if capacityType == karpv1.CapacityTypeSpot {
-		createFleetInput.SpotOptions = &ec2types.SpotOptionsRequest{AllocationStrategy: ec2types.SpotAllocationStrategyPriceCapacityOptimized}
-	} else {
-		createFleetInput.OnDemandOptions = &ec2types.OnDemandOptionsRequest{AllocationStrategy: ec2types.FleetOnDemandAllocationStrategyLowestPrice}
+		createFleetInput.SpotOptions = &ec2types.SpotOptionsRequest{AllocationStrategy: lo.Ternary(prioritized,
+			ec2types.SpotAllocationStrategyCapacityOptimizedPrioritized, ec2types.SpotAllocationStrategyPriceCapacityOptimized)}
+	} else if capacityType == karpv1.CapacityTypeOnDemand {
+		createFleetInput.OnDemandOptions = &ec2types.OnDemandOptionsRequest{AllocationStrategy: lo.Ternary(prioritized,
+			ec2types.FleetOnDemandAllocationStrategyPrioritized, ec2types.FleetOnDemandAllocationStrategyLowestPrice)}
+		// Open capacity reservations which match an override are used before the allocation strategy is applied to the
+		// overrides without a reservation
+		if options.FromContext(ctx).FeatureGates.ODCRFirst {
+			createFleetInput.OnDemandOptions.CapacityReservationOptions = &ec2types.CapacityReservationOptionsRequest{
+				UsageStrategy: ec2types.FleetCapacityReservationUsageStrategyUseCapacityReservationsFirst,
+			}
+		}
 	}
 
+	start = time.Now()
 	createFleetOutput, err := p.ec2Batcher.CreateFleet(ctx, createFleetInput)
+	ObserveLaunchPhase(nodeClaim.Labels[karpv1.NodePoolLabelKey], PhaseCreateFleet, time.Since(start))
 	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType)
 	if err != nil {
 		conditionMessage := "Error creating fleet"
+		if class := awserrors.Classify(err); class != awserrors.ClassUnknown {
+			conditionMessage = fmt.Sprintf("Error creating fleet (%s)", class)
+		}
 		if awserrors.IsLaunchTemplateNotFound(err) {
 			for _, lt := range launchTemplateConfigs {
 				p.launchTemplateProvider.InvalidateCache(ctx, aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateName), aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateId))
@@ -263,9 +621,118 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 	if len(createFleetOutput.Instances) == 0 || len(createFleetOutput.Instances[0].InstanceIds) == 0 {
 		return ec2types.CreateFleetInstance{}, combineFleetErrors(createFleetOutput.Errors)
 	}
+	if it, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return it.Name == string(createFleetOutput.Instances[0].InstanceType)
+	}); ok {
+		LaunchesTotal.Inc(map[string]string{
+			nodePoolLabel:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+			architectureLabel: it.Requirements.Get(corev1.LabelArchStable).Any(),
+			flexibleLabel:     strconv.FormatBool(isArchitectureFlexible(instanceTypes)),
+		})
+	}
 	return createFleetOutput.Instances[0], nil
 }
 
+// isArchitectureFlexible returns true if the instance types include both amd64 and arm64 instance types, i.e. the
+// pods which the NodeClaim is launched for tolerate both architectures
+func isArchitectureFlexible(instanceTypes []*cloudprovider.InstanceType) bool {
+	architectures := sets.New[string]()
+	for _, it := range instanceTypes {
+		architectures.Insert(it.Requirements.Get(corev1.LabelArchStable).Values()...)
+	}
+	return architectures.HasAll(karpv1.ArchitectureAmd64, karpv1.ArchitectureArm64)
+}
+
+// getZonalWeights returns the weight of each zone that the overrides launch into, from the weights of the subnet
+// selector terms. When every zone has the same weight, no weights are returned, since no zone is preferred.
+func getZonalWeights(zonalSubnets map[string]*subnet.Subnet) map[string]int32 {
+	weights := lo.MapValues(zonalSubnets, func(s *subnet.Subnet, _ string) int32 { return s.Weight })
+	if len(lo.Uniq(lo.Values(weights))) <= 1 {
+		return nil
+	}
+	return weights
+}
+
+// getZonalSpotPlacementScores returns the spot placement score of each zone that the overrides launch into, keyed by
+// zone name. Scores are only returned for spot launches when spot placement scores are enabled, and only if the zones
+// differ in score, since equal scores don't bias the launch. Scores are refreshed in the background, so the launch
+// doesn't wait for them, and launches for instance families without scores yet aren't biased.
+func (p *DefaultProvider) getZonalSpotPlacementScores(ctx context.Context, launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest,
+	zonalSubnets map[string]*subnet.Subnet, capacityType string) map[string]int32 {
+	if capacityType != karpv1.CapacityTypeSpot || !options.FromContext(ctx).FeatureGates.SpotPlacementScores {
+		return nil
+	}
+	instanceTypes := sets.New[string]()
+	for _, ltc := range launchTemplateConfigs {
+		for _, override := range ltc.Overrides {
+			instanceTypes.Insert(string(override.InstanceType))
+		}
+	}
+	zoneIDScores := p.spotPlacementScoreProvider.Scores(ctx, sets.List(instanceTypes))
+	scores := map[string]int32{}
+	for zone, s := range zonalSubnets {
+		if score, ok := zoneIDScores[s.ZoneID]; ok {
+			scores[zone] = score
+		}
+	}
+	if len(lo.Uniq(lo.Values(scores))) <= 1 {
+		return nil
+	}
+	return scores
+}
+
+// prioritize assigns priorities to the launch template overrides so that cheaper offerings are launched first, after
+// their prices are weighted by the preferences. When arm64 is preferred, arm64 offerings are launched before amd64
+// offerings. When zone weights are given, offerings in zones with a higher weight are launched before offerings in zones
+// with a lower weight. When spot placement scores are given, offerings in zones with a higher score are launched before
+// offerings in zones with a lower score. Lower values have a higher priority.
+func prioritize(launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest, instanceTypes []*cloudprovider.InstanceType, capacityType string,
+	preferARM64 bool, preferences []Preference, weights map[string]int32, scores map[string]int32) {
+	architectures := map[string]string{}
+	prices := map[string]float64{}
+	for _, it := range instanceTypes {
+		architectures[it.Name] = it.Requirements.Get(corev1.LabelArchStable).Any()
+		for _, o := range it.Offerings.Available() {
+			if o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != capacityType {
+				continue
+			}
+			key := it.Name + "/" + o.Requirements.Get(corev1.LabelTopologyZone).Any()
+			weighted := o.Price * weight(preferences, it, o)
+			if price, ok := prices[key]; !ok || weighted < price {
+				prices[key] = weighted
+			}
+		}
+	}
+	var overrides []*ec2types.FleetLaunchTemplateOverridesRequest
+	for i := range launchTemplateConfigs {
+		for j := range launchTemplateConfigs[i].Overrides {
+			overrides = append(overrides, &launchTemplateConfigs[i].Overrides[j])
+		}
+	}
+	sort.SliceStable(overrides, func(i, j int) bool {
+		iARM64 := architectures[string(overrides[i].InstanceType)] == karpv1.ArchitectureArm64
+		jARM64 := architectures[string(overrides[j].InstanceType)] == karpv1.ArchitectureArm64
+		if preferARM64 && iARM64 != jARM64 {
+			return iARM64
+		}
+		iWeight := weights[aws.ToString(overrides[i].AvailabilityZone)]
+		jWeight := weights[aws.ToString(overrides[j].AvailabilityZone)]
+		if iWeight != jWeight {
+			return iWeight > jWeight
+		}
+		iScore := scores[aws.ToString(overrides[i].AvailabilityZone)]
+		jScore := scores[aws.ToString(overrides[j].AvailabilityZone)]
+		if iScore != jScore {
+			return iScore > jScore
+		}
+		return prices[string(overrides[i].InstanceType)+"/"+aws.ToString(overrides[i].AvailabilityZone)] <
+			prices[string(overrides[j].InstanceType)+"/"+aws.ToString(overrides[j].AvailabilityZone)]
+	})
+	for i, override := range overrides {
+		override.Priority = aws.Float64(float64(i))
+	}
+}
+
 func (p *DefaultProvider) checkODFallback(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest) error {
 	// only evaluate for on-demand fallback if the capacity type for the request is OD and both OD and spot are allowed in requirements
 	if p.getCapacityType(nodeClaim, instanceTypes) != karpv1.CapacityTypeOnDemand || !scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot) {
@@ -295,9 +762,13 @@ func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClas
 	}
 	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+	denied := 0
 	for _, launchTemplate := range launchTemplates {
+		overrides := p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, requirements, launchTemplate.ImageID)
+		allowed := p.filterDeniedOverrides(ctx, nodeClass, nodeClaim, launchTemplate.InstanceTypes, zonalSubnets, capacityType, overrides)
+		denied += len(overrides) - len(allowed)
 		launchTemplateConfig := ec2types.FleetLaunchTemplateConfigRequest{
-			Overrides: p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, requirements, launchTemplate.ImageID),
+			Overrides: allowed,
 			LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 				LaunchTemplateName: aws.String(launchTemplate.Name),
 				Version:            aws.String("$Latest"),
@@ -308,6 +779,9 @@ func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClas
 		}
 	}
 	if len(launchTemplateConfigs) == 0 {
+		if denied > 0 {
+			return nil, fmt.Errorf("all %d capacity offerings were denied by launch policies", denied)
+		}
 		return nil, fmt.Errorf("no capacity offerings are currently available given the constraints")
 	}
 	return launchTemplateConfigs, nil
@@ -353,6 +827,29 @@ func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceTy
 	return overrides
 }
 
+// filterDeniedOverrides removes the overrides for offerings which are denied by the launch policies
+func (p *DefaultProvider) filterDeniedOverrides(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType,
+	zonalSubnets map[string]*subnet.Subnet, capacityType string, overrides []ec2types.FleetLaunchTemplateOverridesRequest) []ec2types.FleetLaunchTemplateOverridesRequest {
+	return lo.Filter(overrides, func(override ec2types.FleetLaunchTemplateOverridesRequest, _ int) bool {
+		it, _ := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == string(override.InstanceType) })
+		launch := policy.Launch{
+			NodePool:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+			NodeClass:    nodeClass.Name,
+			InstanceType: string(override.InstanceType),
+			Zone:         lo.FromPtr(override.AvailabilityZone),
+			CapacityType: capacityType,
+			SubnetID:     lo.FromPtr(override.SubnetId),
+		}
+		if it != nil {
+			launch.Architecture = it.Requirements.Get(corev1.LabelArchStable).Any()
+		}
+		if s, ok := zonalSubnets[launch.Zone]; ok {
+			launch.PublicSubnet = s.Public
+		}
+		return p.policyProvider.Allowed(ctx, launch)
+	})
+}
+
 func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, errors []ec2types.CreateFleetError, capacityType string) {
 	for _, err := range errors {
 		if awserrors.IsUnfulfillableCapacity(err) {
@@ -361,17 +858,21 @@ func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, e
 	}
 }
 
-// getCapacityType selects spot if both constraints are flexible and there is an
-// available offering. The AWS Cloud Provider defaults to [ on-demand ], so spot
-// must be explicitly included in capacity type requirements.
+// getCapacityType selects capacity-block, and then spot, if both constraints are flexible and there is an
+// available offering. Capacity blocks are paid for upfront, so they're always selected over spot. The AWS Cloud
+// Provider defaults to [ on-demand ], so capacity-block and spot must be explicitly included in capacity type
+// requirements.
 func (p *DefaultProvider) getCapacityType(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) string {
-	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
-	if requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot) {
-		requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot)
+	for _, capacityType := range []string{v1.CapacityTypeCapacityBlock, karpv1.CapacityTypeSpot} {
+		requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+		if !requirements.Get(karpv1.CapacityTypeLabelKey).Has(capacityType) {
+			continue
+		}
+		requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
 		for _, instanceType := range instanceTypes {
 			for _, offering := range instanceType.Offerings.Available() {
 				if requirements.Compatible(offering.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil {
-					return karpv1.CapacityTypeSpot
+					return capacityType
 				}
 			}
 		}
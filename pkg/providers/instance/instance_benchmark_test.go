@@ -0,0 +1,144 @@
+//go:build test_performance
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// benchmarkNodePoolCount is the number of NodePools that NodeClaims are launched for
+const benchmarkNodePoolCount = 20
+
+// BenchmarkLaunchPlan measures the construction of the CreateFleet launch plan for a NodeClaim of each NodePool, from
+// the filtering of the instance types through to the prioritization of the launch template overrides. The launch
+// templates and the CreateFleet call itself aren't included.
+func BenchmarkLaunchPlan(b *testing.B) {
+	ctx := coreoptions.ToContext(context.Background(), coretest.Options())
+	ctx = options.ToContext(ctx, &options.Options{ClusterName: "benchmark", IsolatedVPC: true, VMMemoryOverheadPercent: 0.075})
+	instanceTypes := benchmarkInstanceTypes(ctx, b)
+	nodeClaims := benchmarkNodeClaims()
+	zonalSubnets := map[string]*subnet.Subnet{
+		"test-zone-1a": {ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a", Weight: 3},
+		"test-zone-1b": {ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b", Weight: 2},
+		"test-zone-1c": {ID: "subnet-test3", Zone: "test-zone-1c", ZoneID: "tstz1-1c", Weight: 1},
+	}
+	weights := getZonalWeights(zonalSubnets)
+	p := &DefaultProvider{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	overrides := 0
+	for range b.N {
+		overrides = 0
+		for _, nodeClaim := range nodeClaims {
+			filtered := p.filterInstanceTypes(nodeClaim, instanceTypes)
+			capacityType := p.getCapacityType(nodeClaim, filtered)
+			requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+			requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+			launchTemplateConfigs := []ec2types.FleetLaunchTemplateConfigRequest{{
+				Overrides: p.getOverrides(filtered, zonalSubnets, requirements, "ami-test1"),
+				LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateName: aws.String("karpenter.k8s.aws/benchmark"),
+					Version:            aws.String("$Latest"),
+				},
+			}}
+			prioritize(launchTemplateConfigs, filtered, capacityType, false, nil, weights, nil)
+			overrides += len(launchTemplateConfigs[0].Overrides)
+		}
+	}
+	b.ReportMetric(float64(len(instanceTypes)), "instancetypes")
+	b.ReportMetric(float64(overrides), "overrides/op")
+}
+
+// benchmarkInstanceTypes resolves every instance type that has static pricing data (700+ instance types) in each of
+// three zones
+func benchmarkInstanceTypes(ctx context.Context, b *testing.B) []*cloudprovider.InstanceType {
+	ec2api := fake.NewEC2API()
+	instanceTypes := fake.MakeInstances()
+	ec2api.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instanceTypes})
+	ec2api.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+		InstanceTypeOfferings: fake.MakeZonalInstanceOfferings(instanceTypes, "test-zone-1a", "test-zone-1b", "test-zone-1c"),
+	})
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+		cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	pricingProvider := pricing.NewDefaultProvider(ctx, &fake.PricingAPI{}, ec2api, fake.DefaultRegion)
+	provider := instancetype.NewDefaultProvider(cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.DiscoveredCapacityCacheTTL, awscache.DefaultCleanupInterval),
+		ec2api, subnetProvider, instancetype.NewDefaultResolver(fake.DefaultRegion, pricingProvider, awscache.NewUnavailableOfferings()))
+	if err := provider.UpdateInstanceTypes(ctx); err != nil {
+		b.Fatalf("updating instance types, %s", err)
+	}
+	if err := provider.UpdateInstanceTypeOfferings(ctx); err != nil {
+		b.Fatalf("updating instance type offerings, %s", err)
+	}
+	// The instance provider can't depend on the test package, so the EC2NodeClass is constructed with only the status
+	// that instance types are resolved from
+	nodeClass := &v1.EC2NodeClass{Status: v1.EC2NodeClassStatus{
+		Subnets: lo.Map([]string{"test-zone-1a", "test-zone-1b", "test-zone-1c"}, func(zone string, i int) v1.Subnet {
+			return v1.Subnet{ID: fmt.Sprintf("subnet-test%d", i+1), Zone: zone, ZoneID: fmt.Sprintf("tstz1-%s", zone[len(zone)-2:])}
+		}),
+		AMIs: []v1.AMI{{ID: "ami-test1", Requirements: []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.ArchitectureAmd64}},
+		}}},
+	}}
+	its, err := provider.List(ctx, nodeClass)
+	if err != nil {
+		b.Fatalf("listing instance types, %s", err)
+	}
+	return its
+}
+
+// benchmarkNodeClaims returns a NodeClaim for each NodePool, whose requirements differ by capacity type, instance
+// category, instance generation and zone
+func benchmarkNodeClaims() []*karpv1.NodeClaim {
+	capacityTypes := [][]string{{karpv1.CapacityTypeOnDemand}, {karpv1.CapacityTypeSpot}, {karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}
+	categories := [][]string{{"c"}, {"m"}, {"r"}, {"c", "m", "r"}, {"t"}}
+	zones := [][]string{{"test-zone-1a"}, {"test-zone-1a", "test-zone-1b", "test-zone-1c"}}
+	return lo.Times(benchmarkNodePoolCount, func(i int) *karpv1.NodeClaim {
+		return coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{
+				Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: capacityTypes[i%len(capacityTypes)]}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceCategory, Operator: corev1.NodeSelectorOpIn, Values: categories[i%len(categories)]}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceGeneration, Operator: corev1.NodeSelectorOpGt, Values: []string{fmt.Sprint(2 + i%3)}}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: zones[i%len(zones)]}},
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	nodePoolLabel          = "nodepool"
+	architectureLabel      = "architecture"
+	flexibleLabel          = "architecture_flexible"
+	phaseLabel             = "phase"
+
+	// The phases of launching a node for a NodeClaim, in the order that they occur
+	PhaseOfferingResolution      = "offering_resolution"
+	PhaseLaunchTemplateRendering = "launch_template_rendering"
+	PhaseCreateFleet             = "create_fleet"
+	PhaseInstanceConfirmation    = "instance_confirmation"
+	PhaseNodeRegistration        = "node_registration"
+)
+
+var (
+	LaunchesTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "instance_launches_total",
+			Help:      "Number of instances launched. Labeled by NodePool, the architecture of the launched instance, and whether the NodeClaim could have been launched on both amd64 and arm64 instance types.",
+		},
+		[]string{nodePoolLabel, architectureLabel, flexibleLabel},
+	)
+	LaunchPhaseDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "launch_phase_duration_seconds",
+			Help:      "Duration of each phase of launching a node for a NodeClaim. Labeled by NodePool and phase: offering_resolution, launch_template_rendering, create_fleet, instance_confirmation, and node_registration.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{nodePoolLabel, phaseLabel},
+	)
+	TerminationsStalledTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "instance_terminations_stalled_total",
+			Help:      "Number of times termination was retried for an instance which was stuck terminating. Labeled by NodePool.",
+		},
+		[]string{nodePoolLabel},
+	)
+)
+
+// ObserveLaunchPhase records the duration of a phase of launching a node for a NodeClaim of the NodePool
+func ObserveLaunchPhase(nodePool, phase string, d time.Duration) {
+	LaunchPhaseDurationSeconds.Observe(d.Seconds(), map[string]string{
+		nodePoolLabel: nodePool,
+		phaseLabel:    phase,
+	})
+}
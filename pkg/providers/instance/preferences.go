@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// Preference weights the offerings which match a requirement when the offerings of a launch are ranked. The price of
+// a matching offering is multiplied by the weight, so a weight of 0.9 prefers an offering unless another offering is
+// more than 10% cheaper, and a weight above 1 deprioritizes an offering. The weights of every matching preference are
+// multiplied together.
+type Preference struct {
+	corev1.NodeSelectorRequirement `json:",inline"`
+	Weight                         float64 `json:"weight"`
+}
+
+// ParsePreferences parses the preferences of the karpenter.k8s.aws/instance-preferences annotation, which is a JSON
+// list of requirements and their weights
+func ParsePreferences(raw string) ([]Preference, error) {
+	var preferences []Preference
+	if err := json.Unmarshal([]byte(raw), &preferences); err != nil {
+		return nil, fmt.Errorf("unmarshaling preferences, %w", err)
+	}
+	for i, p := range preferences {
+		if p.Weight <= 0 {
+			return nil, fmt.Errorf("preference %d has weight %v, weights must be positive", i, p.Weight)
+		}
+		if err := karpv1.ValidateRequirement(karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: p.NodeSelectorRequirement}); err != nil {
+			return nil, fmt.Errorf("preference %d is invalid, %w", i, err)
+		}
+	}
+	return preferences, nil
+}
+
+// preferencesFor returns the preferences of the NodeClaim's karpenter.k8s.aws/instance-preferences annotation
+func preferencesFor(nodeClaim *karpv1.NodeClaim) ([]Preference, error) {
+	raw, ok := nodeClaim.Annotations[v1.AnnotationInstancePreferences]
+	if !ok {
+		return nil, nil
+	}
+	return ParsePreferences(raw)
+}
+
+// weight returns the product of the weights of the preferences which the offering of the instance type matches
+func weight(preferences []Preference, instanceType *cloudprovider.InstanceType, offering cloudprovider.Offering) float64 {
+	w := 1.0
+	if len(preferences) == 0 {
+		return w
+	}
+	requirements := scheduling.NewRequirements(append(instanceType.Requirements.Values(), offering.Requirements.Values()...)...)
+	for _, p := range preferences {
+		if requirements.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(p.Key, p.Operator, p.Values...))) == nil {
+			w *= p.Weight
+		}
+	}
+	return w
+}
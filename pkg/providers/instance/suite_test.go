@@ -16,16 +16,22 @@ package instance_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/licensemanager"
+	licensemanagertypes "github.com/aws/aws-sdk-go-v2/service/licensemanager/types"
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -41,6 +47,8 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/license"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -198,6 +206,35 @@ var _ = Describe("InstanceProvider", func() {
 				},
 			)
 		}
+		// Provision instances of the cluster's previous name, which aren't owned by the cluster during a migration
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			MigrationClusterName: lo.ToPtr("previous-cluster"),
+			MigrationEndTime:     lo.ToPtr(time.Now().Add(time.Hour).Format(time.RFC3339)),
+		}))
+		for i := 0; i < 20; i++ {
+			instanceID := fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(
+				instanceID,
+				ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
+					},
+					Tags: []ec2types.Tag{
+						{Key: aws.String("kubernetes.io/cluster/previous-cluster"), Value: aws.String("owned")},
+						{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String("default")},
+						{Key: aws.String(v1.LabelNodeClass), Value: aws.String("default")},
+						{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String("previous-cluster")},
+					},
+					PrivateDnsName: aws.String(fake.PrivateDNSName()),
+					Placement: &ec2types.Placement{
+						AvailabilityZone: aws.String(fake.DefaultRegion),
+					},
+					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
+					InstanceId:   lo.ToPtr(instanceID),
+					InstanceType: "m5.large",
+				},
+			)
+		}
 		instances, err := awsEnv.InstanceProvider.List(ctx)
 		Expect(err).To(BeNil())
 		Expect(instances).To(HaveLen(20))
@@ -205,4 +242,809 @@ var _ = Describe("InstanceProvider", func() {
 		retrievedIDs := sets.New[string](lo.Map(instances, func(i *instance.Instance, _ int) string { return i.ID })...)
 		Expect(ids.Equal(retrievedIDs)).To(BeTrue())
 	})
+	Context("Architecture Preference", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "c6g.large", "t4g.medium"}, i.Name)
+			})
+		})
+		overrides := func(input *ec2.CreateFleetInput) []ec2types.FleetLaunchTemplateOverridesRequest {
+			return lo.FlatMap(input.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []ec2types.FleetLaunchTemplateOverridesRequest {
+				return ltc.Overrides
+			})
+		}
+		It("should launch the cheapest offerings regardless of architecture by default", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+			for _, override := range overrides(createFleetInput) {
+				Expect(override.Priority).To(BeNil())
+			}
+			Expect(createFleetInput.OnDemandOptions.CapacityReservationOptions).To(BeNil())
+		})
+		It("should use capacity reservations first when ODCRFirst is enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{ODCRFirst: lo.ToPtr(true)}}))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+			Expect(createFleetInput.OnDemandOptions.CapacityReservationOptions).ToNot(BeNil())
+			Expect(createFleetInput.OnDemandOptions.CapacityReservationOptions.UsageStrategy).To(Equal(ec2types.FleetCapacityReservationUsageStrategyUseCapacityReservationsFirst))
+		})
+		It("should prioritize arm64 offerings when arm64 is preferred", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ArchitecturePreference: lo.ToPtr(options.ArchitecturePreferenceARM64)}))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyPrioritized))
+
+			arm64 := sets.New("c6g.large", "t4g.medium")
+			lowestAMD64Priority := math.MaxFloat64
+			highestARM64Priority := -1.0
+			for _, override := range overrides(createFleetInput) {
+				Expect(override.Priority).ToNot(BeNil())
+				if arm64.Has(string(override.InstanceType)) {
+					highestARM64Priority = math.Max(highestARM64Priority, aws.ToFloat64(override.Priority))
+				} else {
+					lowestAMD64Priority = math.Min(lowestAMD64Priority, aws.ToFloat64(override.Priority))
+				}
+			}
+			Expect(highestARM64Priority).To(BeNumerically(">=", 0))
+			Expect(highestARM64Priority).To(BeNumerically("<", lowestAMD64Priority))
+		})
+		It("should use the capacity-optimized-prioritized strategy for spot when arm64 is preferred", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ArchitecturePreference: lo.ToPtr(options.ArchitecturePreferenceARM64)}))
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+			}
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimizedPrioritized))
+		})
+		It("should not prioritize offerings when the instance types only support a single architecture", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ArchitecturePreference: lo.ToPtr(options.ArchitecturePreferenceARM64)}))
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Requirements.Get(corev1.LabelArchStable).Has(karpv1.ArchitectureAmd64)
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+		})
+		It("should count launches by NodePool and architecture", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ArchitecturePreference: lo.ToPtr(options.ArchitecturePreferenceARM64)}))
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			launched, ok := lo.Find(instanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == string(inst.Type) })
+			Expect(ok).To(BeTrue())
+			ExpectMetricCounterValue(instance.LaunchesTotal, 1, map[string]string{
+				"nodepool":              nodePool.Name,
+				"architecture":          launched.Requirements.Get(corev1.LabelArchStable).Any(),
+				"architecture_flexible": "true",
+			})
+		})
+	})
+	Context("Instance Preferences", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "c6g.large", "t4g.medium"}, i.Name)
+			})
+		})
+		priorities := func(input *ec2.CreateFleetInput) map[string]float64 {
+			out := map[string]float64{}
+			for _, ltc := range input.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(override.Priority).ToNot(BeNil())
+					if p, ok := out[string(override.InstanceType)]; !ok || aws.ToFloat64(override.Priority) < p {
+						out[string(override.InstanceType)] = aws.ToFloat64(override.Priority)
+					}
+				}
+			}
+			return out
+		}
+		It("should prioritize the offerings which match a preference with a low weight", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationInstancePreferences: `[{"key": "karpenter.k8s.aws/instance-family", "operator": "In", "values": ["m5"], "weight": 0.01}]`,
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyPrioritized))
+			p := priorities(createFleetInput)
+			Expect(math.Max(p["m5.large"], p["m5.xlarge"])).To(BeNumerically("<", math.Min(p["c6g.large"], p["t4g.medium"])))
+		})
+		It("should deprioritize the offerings which match a preference with a high weight", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationInstancePreferences: `[{"key": "node.kubernetes.io/instance-type", "operator": "In", "values": ["t4g.medium"], "weight": 100}]`,
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			p := priorities(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())
+			Expect(p["t4g.medium"]).To(BeNumerically(">", lo.Max([]float64{p["m5.large"], p["m5.xlarge"], p["c6g.large"]})))
+		})
+		It("should use the capacity-optimized-prioritized strategy for spot when offerings are preferred", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationInstancePreferences: `[{"key": "karpenter.k8s.aws/instance-family", "operator": "In", "values": ["m5"], "weight": 0.9}]`,
+			})
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+			}
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimizedPrioritized))
+		})
+		DescribeTable("should fail to launch when the preferences are invalid",
+			func(raw string) {
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationInstancePreferences: raw})
+				_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+				var createErr *corecloudprovider.CreateError
+				Expect(errors.As(err, &createErr)).To(BeTrue())
+				Expect(createErr.ConditionMessage).To(Equal("Error parsing instance preferences"))
+				Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+			},
+			Entry("malformed JSON", `[{"key": "karpenter.k8s.aws/instance-family"`),
+			Entry("non-positive weight", `[{"key": "karpenter.k8s.aws/instance-family", "operator": "In", "values": ["m5"], "weight": 0}]`),
+			Entry("invalid operator", `[{"key": "karpenter.k8s.aws/instance-family", "operator": "Prefer", "values": ["m5"], "weight": 0.9}]`),
+		)
+	})
+	Context("Spot Placement Scores", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPlacementScores: lo.ToPtr(true)}}))
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "c6g.large", "t4g.medium"}, i.Name)
+			})
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(3)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+					{AvailabilityZoneId: aws.String("tstz1-1c"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(6)},
+				},
+			})
+		})
+		zonePriorities := func(input *ec2.CreateFleetInput) map[string]float64 {
+			out := map[string]float64{}
+			for _, ltc := range input.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(override.Priority).ToNot(BeNil())
+					zone := aws.ToString(override.AvailabilityZone)
+					if p, ok := out[zone]; !ok || aws.ToFloat64(override.Priority) > p {
+						out[zone] = aws.ToFloat64(override.Priority)
+					}
+				}
+			}
+			return out
+		}
+		It("should prioritize the offerings in zones with a higher spot placement score", func() {
+			awsEnv.SpotPlacementScoreProvider.Scores(ctx, lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name }))
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			// Scores are requested once per instance family
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(3))
+			var requested []string
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.ForEach(func(input *ec2.GetSpotPlacementScoresInput) {
+				Expect(input.RegionNames).To(ConsistOf(fake.DefaultRegion))
+				Expect(aws.ToBool(input.SingleAvailabilityZone)).To(BeTrue())
+				Expect(aws.ToInt32(input.TargetCapacity)).To(BeNumerically(">", 1))
+				requested = append(requested, input.InstanceTypes...)
+			})
+			Expect(requested).To(ConsistOf("m5.large", "m5.xlarge", "c6g.large", "t4g.medium"))
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimizedPrioritized))
+			p := zonePriorities(createFleetInput)
+			Expect(p["test-zone-1b"]).To(BeNumerically("<", p["test-zone-1c"]))
+			Expect(p["test-zone-1c"]).To(BeNumerically("<", p["test-zone-1a"]))
+		})
+		It("should launch without waiting for spot placement scores, and refresh them in the background", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(0))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyPriceCapacityOptimized))
+
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput = awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimizedPrioritized))
+		})
+		It("should reuse the spot placement scores of an instance family until they expire", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			// Launching a subset of the instance types doesn't request new scores
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes[:1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(3))
+		})
+		It("should bound the number of instance families that spot placement scores are requested for", func() {
+			var names []string
+			for i := range spotplacementscore.MaxFamilies + 5 {
+				names = append(names, fmt.Sprintf("f%d.large", i))
+			}
+			awsEnv.SpotPlacementScoreProvider.Scores(ctx, names)
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(spotplacementscore.MaxFamilies))
+		})
+		It("should not get spot placement scores when they're disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotPlacementScores: lo.ToPtr(false)}}))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(0))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyPriceCapacityOptimized))
+		})
+		It("should not get spot placement scores for on-demand launches", func() {
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+			}
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should launch without prioritizing offerings when getting spot placement scores fails", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Error.Set(fmt.Errorf("rate exceeded"))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).ToNot(Succeed())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyPriceCapacityOptimized))
+		})
+		It("should launch without prioritizing offerings when every zone has the same spot placement score", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(5)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(5)},
+					{AvailabilityZoneId: aws.String("tstz1-1c"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(5)},
+				},
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyPriceCapacityOptimized))
+		})
+	})
+	Context("Subnet Weights", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "c6g.large", "t4g.medium"}, i.Name)
+			})
+		})
+		It("should prioritize the offerings in zones with a higher weight", func() {
+			nodeClass.Status.Subnets[2].Weight = 50
+			nodeClass.Status.Subnets[1].Weight = 10
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyPrioritized))
+			zones := map[string][]float64{}
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					zones[aws.ToString(override.AvailabilityZone)] = append(zones[aws.ToString(override.AvailabilityZone)], aws.ToFloat64(override.Priority))
+				}
+			}
+			// Offerings in the other zones are still launched into when the weighted zones don't have capacity
+			Expect(zones).To(HaveLen(3))
+			Expect(lo.Max(zones["test-zone-1c"])).To(BeNumerically("<", lo.Min(zones["test-zone-1b"])))
+			Expect(lo.Max(zones["test-zone-1b"])).To(BeNumerically("<", lo.Min(zones["test-zone-1a"])))
+		})
+		It("should launch without prioritizing offerings when every zone has the same weight", func() {
+			for i := range nodeClass.Status.Subnets {
+				nodeClass.Status.Subnets[i].Weight = 50
+			}
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+		})
+	})
+	Context("Launch Phases", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should observe the launch template rendering and CreateFleet phases", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectMetricHistogramSampleCountValue("karpenter_cloudprovider_launch_phase_duration_seconds", 1, map[string]string{
+				"nodepool": nodePool.Name,
+				"phase":    instance.PhaseLaunchTemplateRendering,
+			})
+			ExpectMetricHistogramSampleCountValue("karpenter_cloudprovider_launch_phase_duration_seconds", 1, map[string]string{
+				"nodepool": nodePool.Name,
+				"phase":    instance.PhaseCreateFleet,
+			})
+		})
+		It("should observe the instance confirmation phase once when the instance is first described", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			for range 3 {
+				_, err = awsEnv.InstanceProvider.Get(ctx, inst.ID)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			ExpectMetricHistogramSampleCountValue("karpenter_cloudprovider_launch_phase_duration_seconds", 1, map[string]string{
+				"nodepool": nodePool.Name,
+				"phase":    instance.PhaseInstanceConfirmation,
+			})
+		})
+	})
+	Context("Termination", func() {
+		var instanceID string
+
+		BeforeEach(func() {
+			instanceID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId:     aws.String(instanceID),
+				InstanceType:   "m5.large",
+				State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				PrivateDnsName: aws.String(fake.PrivateDNSName()),
+				Placement:      &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+				LaunchTime:     aws.Time(time.Now().Add(-time.Hour)),
+			})
+		})
+		// instanceTags returns the tags of the instance launched by the last CreateFleet request
+		instanceTags := func() map[string]string {
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			spec, ok := lo.Find(input.TagSpecifications, func(s ec2types.TagSpecification) bool { return s.ResourceType == ec2types.ResourceTypeInstance })
+			Expect(ok).To(BeTrue())
+			return lo.SliceToMap(spec.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+		}
+		setState := func(state ec2types.InstanceStateName) {
+			inst := lo.Must(awsEnv.EC2API.Instances.Load(instanceID)).(ec2types.Instance)
+			inst.State = &ec2types.InstanceState{Name: state}
+			awsEnv.EC2API.Instances.Store(instanceID, inst)
+		}
+
+		It("should not report the instance as not found until it's terminated", func() {
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+
+			setState(ec2types.InstanceStateNameShuttingDown)
+			inst, err := awsEnv.InstanceProvider.Get(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.State).To(Equal(ec2types.InstanceStateNameShuttingDown))
+
+			setState(ec2types.InstanceStateNameTerminated)
+			_, err = awsEnv.InstanceProvider.Get(ctx, instanceID)
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(awsEnv.InstanceProvider.Delete(ctx, instanceID))).To(BeTrue())
+		})
+		It("should not report a terminating instance as not found when it isn't described", func() {
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+			awsEnv.EC2API.Instances.Delete(instanceID)
+
+			_, err := awsEnv.InstanceProvider.Get(ctx, instanceID)
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		})
+		It("should not request termination again while the instance is shutting down", func() {
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+			setState(ec2types.InstanceStateNameShuttingDown)
+			for range 3 {
+				Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+				_, err := awsEnv.InstanceProvider.Get(ctx, instanceID)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should return a NodeClaimNotFoundError when deleting an instance which is already terminated", func() {
+			setState(ec2types.InstanceStateNameTerminated)
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(awsEnv.InstanceProvider.Delete(ctx, instanceID))).To(BeTrue())
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should remove the termination protection of a protected instance before terminating it", func() {
+			inst := lo.Must(awsEnv.EC2API.Instances.Load(instanceID)).(ec2types.Instance)
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(v1.TerminationProtectionTagKey), Value: aws.String("true")})
+			awsEnv.EC2API.Instances.Store(instanceID, inst)
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+
+			Expect(awsEnv.EC2API.ModifyInstanceAttributeBehavior.CalledWithInput.Len()).To(Equal(1))
+			input := awsEnv.EC2API.ModifyInstanceAttributeBehavior.CalledWithInput.Pop()
+			Expect(aws.ToString(input.InstanceId)).To(Equal(instanceID))
+			Expect(aws.ToBool(input.DisableApiTermination.Value)).To(BeFalse())
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should not terminate a protected instance when its termination protection can't be removed", func() {
+			inst := lo.Must(awsEnv.EC2API.Instances.Load(instanceID)).(ec2types.Instance)
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(v1.TerminationProtectionTagKey), Value: aws.String("true")})
+			awsEnv.EC2API.Instances.Store(instanceID, inst)
+			awsEnv.EC2API.ModifyInstanceAttributeBehavior.Error.Set(fmt.Errorf("not authorized to perform ec2:ModifyInstanceAttribute"))
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).ToNot(Succeed())
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should not modify the attributes of an unprotected instance before terminating it", func() {
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+			Expect(awsEnv.EC2API.ModifyInstanceAttributeBehavior.Calls()).To(Equal(0))
+		})
+		It("should tag on-demand instances which are launched with termination protection", func() {
+			nodeClass.Spec.TerminationProtection = aws.Bool(true)
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+				Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand},
+			}}}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTags()).To(HaveKeyWithValue(v1.TerminationProtectionTagKey, "true"))
+		})
+		It("should not tag spot instances which are launched for an EC2NodeClass with termination protection", func() {
+			nodeClass.Spec.TerminationProtection = aws.Bool(true)
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+				Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot},
+			}}}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTags()).ToNot(HaveKey(v1.TerminationProtectionTagKey))
+		})
+		It("should not trust a not found error for a launched instance which hasn't been described", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.Instances.Delete(inst.ID)
+
+			err = awsEnv.InstanceProvider.Delete(ctx, inst.ID)
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		})
+	})
+	Context("Launch Journal", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should launch journaled launches with the client token of the journal", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchClientToken: "test-token"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(aws.ToString(input.ClientToken)).To(Equal("test-token"))
+			spec, ok := lo.Find(input.TagSpecifications, func(s ec2types.TagSpecification) bool { return s.ResourceType == ec2types.ResourceTypeInstance })
+			Expect(ok).To(BeTrue())
+			Expect(spec.Tags).To(ContainElement(ec2types.Tag{Key: aws.String(v1.LaunchClientTokenTagKey), Value: aws.String("test-token")}))
+		})
+		It("should not launch with a client token when the launch isn't journaled", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(input.ClientToken).To(BeNil())
+			for _, spec := range input.TagSpecifications {
+				Expect(lo.Map(spec.Tags, func(t ec2types.Tag, _ int) string { return aws.ToString(t.Key) })).ToNot(ContainElement(v1.LaunchClientTokenTagKey))
+			}
+		})
+		It("should get the instance which was launched with a client token", func() {
+			instanceID := fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId:     aws.String(instanceID),
+				InstanceType:   "m5.large",
+				State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				PrivateDnsName: aws.String(fake.PrivateDNSName()),
+				Placement:      &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+				Tags: []ec2types.Tag{
+					{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+					{Key: aws.String(v1.LaunchClientTokenTagKey), Value: aws.String("test-token")},
+				},
+			})
+			inst, err := awsEnv.InstanceProvider.GetByClientToken(ctx, "test-token")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.ID).To(Equal(instanceID))
+
+			_, err = awsEnv.InstanceProvider.GetByClientToken(ctx, "other-token")
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		})
+	})
+	Context("License Manager", func() {
+		licenseConfigurationARN := "arn:aws:license-manager:us-west-2:111122223333:license-configuration:lic-0123456789abcdef0123456789abcdef"
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		BeforeEach(func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationLicenseConfigurationARN: licenseConfigurationARN,
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "m5.metal"}, i.Name)
+			})
+		})
+		It("should associate launched instances with the license configuration", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				Expect(input.LaunchTemplateData.LicenseSpecifications).To(HaveLen(1))
+				Expect(aws.ToString(input.LaunchTemplateData.LicenseSpecifications[0].LicenseConfigurationArn)).To(Equal(licenseConfigurationARN))
+			})
+		})
+		It("should block the launch when the license configuration has no remaining seats", func() {
+			awsEnv.LicenseManagerAPI.GetLicenseConfigurationBehavior.Output.Set(&licensemanager.GetLicenseConfigurationOutput{
+				LicenseConfigurationArn: aws.String(licenseConfigurationARN),
+				LicenseCountingType:     licensemanagertypes.LicenseCountingTypeInstance,
+				LicenseCount:            aws.Int64(10),
+				ConsumedLicenses:        aws.Int64(10),
+			})
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			var createErr *corecloudprovider.CreateError
+			Expect(errors.As(err, &createErr)).To(BeTrue())
+			Expect(createErr.ConditionMessage).To(Equal("License configuration has no remaining seats"))
+			Expect(instance).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+			ExpectMetricGaugeValue(license.RemainingSeats, 0, map[string]string{"license_configuration_arn": licenseConfigurationARN})
+			ExpectMetricCounterValue(license.ExhaustedTotal, 1, map[string]string{"license_configuration_arn": licenseConfigurationARN})
+		})
+		It("should only launch instance types which fit within the remaining vCPU licenses", func() {
+			awsEnv.LicenseManagerAPI.GetLicenseConfigurationBehavior.Output.Set(&licensemanager.GetLicenseConfigurationOutput{
+				LicenseConfigurationArn: aws.String(licenseConfigurationARN),
+				LicenseCountingType:     licensemanagertypes.LicenseCountingTypeVcpu,
+				LicenseCount:            aws.Int64(100),
+				ConsumedLicenses:        aws.Int64(96),
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			launched := sets.New[string]()
+			for _, config := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range config.Overrides {
+					launched.Insert(string(override.InstanceType))
+				}
+			}
+			Expect(sets.List(launched)).To(ConsistOf("m5.large", "m5.xlarge"))
+		})
+		It("should refresh the remaining seats after launching an instance", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.LicenseManagerAPI.GetLicenseConfigurationBehavior.Calls()).To(Equal(2))
+		})
+		It("should not check license seats for nodeclaims without a license configuration", func() {
+			delete(nodeClaim.Annotations, v1.AnnotationLicenseConfigurationARN)
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.LicenseManagerAPI.GetLicenseConfigurationBehavior.Calls()).To(Equal(0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				Expect(input.LaunchTemplateData.LicenseSpecifications).To(BeEmpty())
+			})
+		})
+	})
+	Context("Launch Policies", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		launched := func() (instanceTypes sets.Set[string], subnets sets.Set[string]) {
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			instanceTypes, subnets = sets.New[string](), sets.New[string]()
+			for _, config := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range config.Overrides {
+					instanceTypes.Insert(string(override.InstanceType))
+					subnets.Insert(aws.ToString(override.SubnetId))
+				}
+			}
+			return instanceTypes, subnets
+		}
+
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return lo.Contains([]string{"m5.large", "m5.xlarge", "t3.large"}, i.Name)
+			})
+		})
+		It("should not launch offerings of instance families which are denied", func() {
+			Expect(awsEnv.PolicyProvider.Update(ctx, "1", map[string]string{
+				"families.cedar": `forbid (principal, action, resource) when { context.instanceFamily == "t3" };`,
+			})).To(Succeed())
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			launchedInstanceTypes, _ := launched()
+			Expect(sets.List(launchedInstanceTypes)).To(ConsistOf("m5.large", "m5.xlarge"))
+		})
+		It("should not launch offerings in public subnets when they're denied", func() {
+			Expect(awsEnv.PolicyProvider.Update(ctx, "1", map[string]string{
+				"public-subnets.cedar": `forbid (principal, action, resource) when { context.publicSubnet };`,
+			})).To(Succeed())
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			_, subnets := launched()
+			Expect(subnets.Len()).To(BeNumerically(">", 0))
+			Expect(subnets.Has("subnet-test2")).To(BeFalse())
+		})
+		It("should fail the launch when every offering is denied", func() {
+			Expect(awsEnv.PolicyProvider.Update(ctx, "1", map[string]string{
+				"nodepool.cedar": fmt.Sprintf(`forbid (principal == NodePool::%q, action, resource);`, nodePool.Name),
+			})).To(Succeed())
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("denied by launch policies"))
+			Expect(instance).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+	})
+	Context("Placement Groups", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		// launch creates instances for the NodeClaim and returns the partitions that they were launched into
+		launch := func(count int) []int32 {
+			var partitions []int32
+			for range count {
+				instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+				Expect(err).ToNot(HaveOccurred())
+				partitions = append(partitions, instance.PartitionNumber)
+			}
+			launchTemplatePartitions := sets.New[int32]()
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.GroupName)).To(Equal("test-placement-group"))
+				launchTemplatePartitions.Insert(aws.ToInt32(ltInput.LaunchTemplateData.Placement.PartitionNumber))
+			})
+			Expect(sets.List(launchTemplatePartitions)).To(ConsistOf(lo.Uniq(partitions)))
+			return partitions
+		}
+
+		BeforeEach(func() {
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 3}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should assign partitions round-robin", func() {
+			partitions := launch(4)
+			Expect(partitions[:3]).To(ConsistOf(int32(1), int32(2), int32(3)))
+			for i := 1; i < len(partitions); i++ {
+				Expect(partitions[i]).To(Equal(partitions[i-1]%3 + 1))
+			}
+		})
+		It("should only assign partitions which satisfy the NodeClaim's requirements", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+					Key:      v1.LabelPlacementPartition,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"2", "3"},
+				},
+			})
+			partitions := launch(4)
+			Expect(partitions).To(HaveEach(BeElementOf(int32(2), int32(3))))
+			for i := 1; i < len(partitions); i++ {
+				Expect(partitions[i]).ToNot(Equal(partitions[i-1]))
+			}
+		})
+		It("should fail the launch when no partition satisfies the NodeClaim's requirements", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+					Key:      v1.LabelPlacementPartition,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"4"},
+				},
+			})
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(instance).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+	})
+	Context("Resolved Placement Groups", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Tags: map[string]string{"karpenter.sh/discovery": "test-cluster"}}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		})
+		It("should launch into a cluster placement group without a partition", func() {
+			nodeClass.Status.PlacementGroup = &v1.ResolvedPlacementGroup{ID: "pg-test1", Name: "test-cluster", Strategy: v1.PlacementGroupStrategyCluster}
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.PartitionNumber).To(BeZero())
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.GroupName)).To(Equal("test-cluster"))
+				Expect(ltInput.LaunchTemplateData.Placement.PartitionNumber).To(BeNil())
+			})
+		})
+		It("should default the partitions to the partition count of the placement group", func() {
+			nodeClass.Status.PlacementGroup = &v1.ResolvedPlacementGroup{ID: "pg-test2", Name: "test-partition", Strategy: v1.PlacementGroupStrategyPartition, PartitionCount: 2}
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			var partitions []int32
+			for range 3 {
+				instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+				Expect(err).ToNot(HaveOccurred())
+				partitions = append(partitions, instance.PartitionNumber)
+			}
+			Expect(partitions[:2]).To(ConsistOf(int32(1), int32(2)))
+			Expect(partitions[2]).To(Equal(partitions[0]))
+		})
+		It("should fail the launch when the placement group hasn't been resolved", func() {
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("placement group hasn't been resolved"))
+			Expect(instance).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+	})
 })
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// maxTags is the number of tags that EC2 resources support
+const maxTags = 50
+
+// Tags returns the tags of the instances, volumes and launch templates that are launched for a NodeClaim
+func Tags(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim) (map[string]string, error) {
+	if offendingTag, found := lo.FindKeyBy(nodeClass.Spec.Tags, func(k string, v string) bool {
+		for _, exp := range v1.RestrictedTagPatterns {
+			if exp.MatchString(k) {
+				return true
+			}
+		}
+		return false
+	}); found {
+		return nil, fmt.Errorf("%q tag does not pass tag validation requirements", offendingTag)
+	}
+	staticTags := map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		karpv1.NodePoolLabelKey: nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
+		v1.LabelNodeClass:       nodeClass.Name,
+	}
+	tags := lo.Assign(nodeClass.Spec.Tags, staticTags)
+	// The instance is tagged with its name and NodeClaim once it registers, with the termination protection tag when
+	// it's protected, with its client token when launches are journaled, and with the workload that it runs when costs
+	// are attributed, so those tags must also fit within the limit
+	karpenterTags := map[string]string{v1.NameTagKey: "", v1.NodeClaimTagKey: ""}
+	if lo.FromPtr(nodeClass.Spec.TerminationProtection) {
+		karpenterTags[v1.TerminationProtectionTagKey] = ""
+	}
+	if options.FromContext(ctx).FeatureGates.LaunchJournal {
+		karpenterTags[v1.LaunchClientTokenTagKey] = ""
+	}
+	if label := options.FromContext(ctx).CostAttributionLabel; label != "" {
+		karpenterTags[v1.CostAttributionNamespaceTagKey] = ""
+		karpenterTags[label] = ""
+	}
+	if count := len(lo.Assign(tags, karpenterTags)); count > maxTags {
+		return nil, fmt.Errorf("instances would have %d tags once Karpenter's tags are added, exceeding the EC2 limit of %d tags", count, maxTags)
+	}
+	return tags, nil
+}
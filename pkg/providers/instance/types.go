@@ -22,6 +22,8 @@ import (
 	"github.com/samber/lo"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 // Instance is an internal data representation of either an ec2.Instance or an ec2.FleetInstance
@@ -38,6 +40,11 @@ type Instance struct {
 	SubnetID         string
 	Tags             map[string]string
 	EFAEnabled       bool
+	// PartitionNumber is the partition of the placement group that the instance was launched into, or 0 if the
+	// instance wasn't launched into a partition placement group
+	PartitionNumber int32
+	// CapacityBlockID is the id of the Capacity Block for ML that the instance was launched into, if any
+	CapacityBlockID string
 }
 
 func NewInstance(out ec2types.Instance) *Instance {
@@ -48,7 +55,7 @@ func NewInstance(out ec2types.Instance) *Instance {
 		ImageID:      aws.ToString(out.ImageId),
 		Type:         out.InstanceType,
 		Zone:         aws.ToString(out.Placement.AvailabilityZone),
-		CapacityType: lo.Ternary(out.SpotInstanceRequestId != nil, karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand),
+		CapacityType: capacityType(out),
 		SecurityGroupIDs: lo.Map(out.SecurityGroups, func(securitygroup ec2types.GroupIdentifier, _ int) string {
 			return aws.ToString(securitygroup.GroupId)
 		}),
@@ -57,10 +64,29 @@ func NewInstance(out ec2types.Instance) *Instance {
 		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(item ec2types.InstanceNetworkInterface) bool {
 			return item.InterfaceType != nil && *item.InterfaceType == string(ec2types.NetworkInterfaceTypeEfa)
 		}),
+		PartitionNumber: aws.ToInt32(out.Placement.PartitionNumber),
+		CapacityBlockID: lo.Ternary(capacityType(out) == v1.CapacityTypeCapacityBlock, aws.ToString(out.CapacityReservationId), ""),
 	}
 
 }
 
+// Standby returns true if the instance is a standby instance of a warm pool, which hasn't been resumed for a NodeClaim
+func (i *Instance) Standby() bool {
+	_, ok := i.Tags[v1.WarmPoolTagKey]
+	return ok
+}
+
+func capacityType(out ec2types.Instance) string {
+	switch {
+	case string(out.InstanceLifecycle) == v1.CapacityTypeCapacityBlock:
+		return v1.CapacityTypeCapacityBlock
+	case out.SpotInstanceRequestId != nil:
+		return karpv1.CapacityTypeSpot
+	default:
+		return karpv1.CapacityTypeOnDemand
+	}
+}
+
 func NewInstanceFromFleet(out ec2types.CreateFleetInstance, tags map[string]string, efaEnabled bool) *Instance {
 	return &Instance{
 		LaunchTime:   time.Now(), // estimate the launch time since we just launched
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// Volume is an EBS volume which is attached to an instance
+type Volume struct {
+	ID         string
+	DeviceName string
+	// Size of the volume in GiB
+	Size int32
+}
+
+// GetVolumes returns the EBS volumes which are attached to the instance
+func (p *DefaultProvider) GetVolumes(ctx context.Context, id string) ([]Volume, error) {
+	var volumes []Volume
+	paginator := ec2.NewDescribeVolumesPaginator(p.ec2api, &ec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("attachment.instance-id"), Values: []string{id}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing volumes, %w", err)
+		}
+		for _, volume := range page.Volumes {
+			attachment, ok := lo.Find(volume.Attachments, func(a ec2types.VolumeAttachment) bool { return aws.ToString(a.InstanceId) == id })
+			if !ok {
+				continue
+			}
+			volumes = append(volumes, Volume{
+				ID:         aws.ToString(volume.VolumeId),
+				DeviceName: aws.ToString(attachment.Device),
+				Size:       aws.ToInt32(volume.Size),
+			})
+		}
+	}
+	return volumes, nil
+}
+
+// ModifyVolume grows an EBS volume to the size in GiB. The volume is usable while it's being modified, but its
+// filesystem has to be expanded on the instance before the additional capacity can be used.
+func (p *DefaultProvider) ModifyVolume(ctx context.Context, volumeID string, size int32) error {
+	if _, err := p.ec2api.ModifyVolume(ctx, &ec2.ModifyVolumeInput{
+		VolumeId: aws.String(volumeID),
+		Size:     aws.Int32(size),
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("modifying volume, %w", err))
+		}
+		return fmt.Errorf("modifying volume, %w", err)
+	}
+	return nil
+}
+
+// VolumeResizes returns the sizes in GiB that the volumes of an instance need to be grown to, keyed by volume id, so
+// that they match the block device mappings of the EC2NodeClass. Volumes that aren't configured by the block device
+// mappings are ignored. EBS volumes can't be shrunk, so false is returned if a volume is larger than its mapping.
+func VolumeResizes(nodeClass *v1.EC2NodeClass, volumes []Volume) (map[string]int32, bool) {
+	resizes := map[string]int32{}
+	for _, volume := range volumes {
+		bdm, ok := lo.Find(nodeClass.Spec.BlockDeviceMappings, func(bdm *v1.BlockDeviceMapping) bool {
+			return bdm != nil && bdm.EBS != nil && bdm.EBS.VolumeSize != nil && lo.FromPtr(bdm.DeviceName) == volume.DeviceName
+		})
+		if !ok {
+			continue
+		}
+		// Volume sizes are rounded up to the nearest GiB, matching the launch template
+		size := int32(math.Ceil(bdm.EBS.VolumeSize.AsApproximateFloat64() / math.Pow(2, 30)))
+		if size < volume.Size {
+			return nil, false
+		}
+		if size > volume.Size {
+			resizes[volume.ID] = size
+		}
+	}
+	return resizes, true
+}
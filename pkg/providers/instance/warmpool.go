@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// WarmPoolSize returns the number of standby instances which are maintained for the NodePool, as configured by the
+// karpenter.k8s.aws/warm-pool-size annotation
+func WarmPoolSize(nodePool *karpv1.NodePool) (int, error) {
+	value, ok := nodePool.Annotations[v1.AnnotationWarmPoolSize]
+	if !ok {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("%s annotation must be a non-negative integer, got %q", v1.AnnotationWarmPoolSize, value)
+	}
+	return size, nil
+}
+
+// ListStandby returns the standby instances of the warm pools of the cluster. Standby instances are tagged with the
+// NodePool of their warm pool.
+func (p *DefaultProvider) ListStandby(ctx context.Context) ([]*Instance, error) {
+	var out = &ec2.DescribeInstancesOutput{}
+	paginator := ec2.NewDescribeInstancesPaginator(p.ec2api, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{v1.WarmPoolTagKey},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1.EKSClusterNameTagKey)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+			instanceStateFilter,
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing standby ec2 instances, %w", err)
+		}
+		out.Reservations = append(out.Reservations, page.Reservations...)
+	}
+	instances, err := instancesFromOutput(out)
+	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+}
+
+// Stop stops a standby instance once it's initialized, so that it can be resumed for a NodeClaim
+func (p *DefaultProvider) Stop(ctx context.Context, id string) error {
+	if _, err := p.ec2api.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{id}}); err != nil {
+		return fmt.Errorf("stopping standby instance, %w", err)
+	}
+	return nil
+}
+
+// Resume starts the cheapest stopped standby instance of the NodeClaim's NodePool which is compatible with the
+// NodeClaim, and releases it from the warm pool. Only standby instances which were launched from the current
+// EC2NodeClass and NodePool hashes are resumed, since the NodeClaim would otherwise be drifted as soon as it launches. A
+// nil instance is returned if no standby instance is compatible. If the instance fails to start after it's released
+// from the warm pool, it's garbage collected like any other instance without a NodeClaim.
+func (p *DefaultProvider) Resume(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClassHash, nodePoolHash string, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+	p.resumeMu.Lock()
+	defer p.resumeMu.Unlock()
+
+	standby, err := p.ListStandby(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	prices := map[ec2types.InstanceType]float64{}
+	for _, it := range instanceTypes {
+		offerings := it.Offerings.Available().Compatible(requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		prices[ec2types.InstanceType(it.Name)] = offerings.Cheapest().Price
+	}
+	candidates := lo.Filter(standby, func(instance *Instance, _ int) bool {
+		if instance.State != ec2types.InstanceStateNameStopped || instance.Tags[v1.WarmPoolTagKey] != nodeClaim.Labels[karpv1.NodePoolLabelKey] ||
+			instance.Tags[v1.NodeClassTagKey] != nodeClaim.Spec.NodeClassRef.Name {
+			return false
+		}
+		if instance.Tags[v1.WarmPoolNodeClassHashTagKey] != nodeClassHash || instance.Tags[v1.WarmPoolNodePoolHashTagKey] != nodePoolHash {
+			return false
+		}
+		if _, ok := prices[instance.Type]; !ok {
+			return false
+		}
+		return requirements.Compatible(scheduling.NewLabelRequirements(map[string]string{
+			corev1.LabelInstanceTypeStable: string(instance.Type),
+			corev1.LabelTopologyZone:       instance.Zone,
+			karpv1.CapacityTypeLabelKey:    instance.CapacityType,
+		}), scheduling.AllowUndefinedWellKnownLabels) == nil
+	})
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if prices[candidates[i].Type] != prices[candidates[j].Type] {
+			return prices[candidates[i].Type] < prices[candidates[j].Type]
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	instance := candidates[0]
+	// The instance is released from the warm pool before it's started, so that it isn't stopped again as a standby
+	if err := p.DeleteTags(ctx, instance.ID, []string{v1.WarmPoolTagKey, v1.WarmPoolNodeClassHashTagKey, v1.WarmPoolNodePoolHashTagKey}); err != nil {
+		return nil, fmt.Errorf("releasing standby instance, %w", err)
+	}
+	if _, err := p.ec2api.StartInstances(ctx, &ec2.StartInstancesInput{InstanceIds: []string{instance.ID}}); err != nil {
+		return nil, fmt.Errorf("starting standby instance, %w", err)
+	}
+	delete(instance.Tags, v1.WarmPoolTagKey)
+	delete(instance.Tags, v1.WarmPoolNodeClassHashTagKey)
+	delete(instance.Tags, v1.WarmPoolNodePoolHashTagKey)
+	instance.State = ec2types.InstanceStateNamePending
+	instance.LaunchTime = time.Now()
+	log.FromContext(ctx).WithValues("id", instance.ID, "instance-type", instance.Type, "zone", instance.Zone).Info("resumed standby instance")
+	return instance, nil
+}
@@ -17,6 +17,7 @@ package instanceprofile
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -43,6 +44,7 @@ type ResourceOwner interface {
 type Provider interface {
 	Create(context.Context, ResourceOwner) (string, error)
 	Delete(context.Context, ResourceOwner) error
+	DeniedActions(context.Context, string, []string) ([]string, error)
 }
 
 type DefaultProvider struct {
@@ -138,3 +140,41 @@ func (p *DefaultProvider) Delete(ctx context.Context, m ResourceOwner) error {
 	}
 	return nil
 }
+
+// DeniedActions returns the actions which the role of the instance profile isn't allowed to perform on any resource,
+// as evaluated by the IAM policy simulator. The evaluation is cached per role, so changes to the role's policies are
+// picked up once the cache expires.
+func (p *DefaultProvider) DeniedActions(ctx context.Context, instanceProfileName string, actions []string) ([]string, error) {
+	out, err := p.iamapi.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(instanceProfileName)})
+	if err != nil {
+		return nil, fmt.Errorf("getting instance profile %q, %w", instanceProfileName, err)
+	}
+	if len(out.InstanceProfile.Roles) == 0 {
+		return nil, fmt.Errorf("instance profile %q has no role", instanceProfileName)
+	}
+	roleARN := aws.ToString(out.InstanceProfile.Roles[0].Arn)
+	key := fmt.Sprintf("simulation/%s/%s", roleARN, strings.Join(actions, ","))
+	if denied, ok := p.cache.Get(key); ok {
+		return denied.([]string), nil
+	}
+	denied := []string{}
+	input := &iam.SimulatePrincipalPolicyInput{PolicySourceArn: aws.String(roleARN), ActionNames: actions}
+	for {
+		out, err := p.iamapi.SimulatePrincipalPolicy(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("simulating policies of role %q, %w", roleARN, err)
+		}
+		for _, result := range out.EvaluationResults {
+			if result.EvalDecision != iamtypes.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, aws.ToString(result.EvalActionName))
+			}
+		}
+		if !out.IsTruncated {
+			break
+		}
+		input.Marker = out.Marker
+	}
+	sort.Strings(denied)
+	p.cache.SetDefault(key, denied)
+	return denied, nil
+}
@@ -121,4 +121,27 @@ var _ = Describe("InstanceProfileProvider", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles[instanceProfile].Roles).To(HaveLen(1))
 		Expect(aws.ToString(awsEnv.IAMAPI.InstanceProfiles[instanceProfile].Roles[0].RoleName)).To(Equal(nodeRole))
 	})
+	Context("DeniedActions", func() {
+		It("should return the actions which the role of the instance profile isn't allowed", func() {
+			nodeClass.Spec.Role = nodeRole
+			instanceProfile, err := awsEnv.InstanceProfileProvider.Create(ctx, &nodeClass)
+			Expect(err).To(BeNil())
+			awsEnv.IAMAPI.DeniedActions.Insert("ecr:BatchGetImage", "ec2:AttachVolume")
+			denied, err := awsEnv.InstanceProfileProvider.DeniedActions(ctx, instanceProfile, []string{"ecr:GetAuthorizationToken", "ecr:BatchGetImage", "ec2:AttachVolume"})
+			Expect(err).To(BeNil())
+			Expect(denied).To(Equal([]string{"ec2:AttachVolume", "ecr:BatchGetImage"}))
+		})
+		It("should cache the simulation for the role", func() {
+			nodeClass.Spec.Role = nodeRole
+			instanceProfile, err := awsEnv.InstanceProfileProvider.Create(ctx, &nodeClass)
+			Expect(err).To(BeNil())
+			_, err = awsEnv.InstanceProfileProvider.DeniedActions(ctx, instanceProfile, []string{"ecr:BatchGetImage"})
+			Expect(err).To(BeNil())
+			awsEnv.IAMAPI.DeniedActions.Insert("ecr:BatchGetImage")
+			denied, err := awsEnv.InstanceProfileProvider.DeniedActions(ctx, instanceProfile, []string{"ecr:BatchGetImage"})
+			Expect(err).To(BeNil())
+			Expect(denied).To(BeEmpty())
+			Expect(awsEnv.IAMAPI.SimulatePrincipalPolicyBehavior.Calls()).To(Equal(1))
+		})
+	})
 })
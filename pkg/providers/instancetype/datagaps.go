@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"context"
+	"sort"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// generatedDatasets are the datasets generated into Karpenter at build time which are keyed by instance type. Instance
+// types launched after Karpenter was built, or only offered in newly opened regions, are missing from these datasets
+// and have their values derived from the EC2 API instead.
+var generatedDatasets = map[string]struct {
+	contains func(string) bool
+	fallback string
+}{
+	"bandwidth": {
+		contains: func(instanceType string) bool { _, ok := InstanceTypeBandwidthMegabits[instanceType]; return ok },
+		fallback: "network card baseline bandwidth",
+	},
+	"vpc-limits": {
+		contains: func(instanceType string) bool { _, ok := Limits[instanceType]; return ok },
+		fallback: "ipv4 addresses per interface, without pod ENI support",
+	},
+}
+
+// reportGeneratedDataGaps logs a single warning per generated dataset listing the discovered instance types which are
+// missing from it. The warning is only logged again if the set of missing instance types changes.
+func (p *DefaultProvider) reportGeneratedDataGaps(ctx context.Context, instanceTypes []ec2types.InstanceTypeInfo) {
+	names := lo.Map(instanceTypes, func(info ec2types.InstanceTypeInfo, _ int) string { return string(info.InstanceType) })
+	for _, dataset := range lo.Keys(generatedDatasets) {
+		missing := lo.Reject(names, func(name string, _ int) bool { return generatedDatasets[dataset].contains(name) })
+		sort.Strings(missing)
+		if len(missing) == 0 || !p.cm.HasChanged("generated-data-gaps-"+dataset, missing) {
+			continue
+		}
+		log.FromContext(ctx).WithValues(
+			"dataset", dataset,
+			"fallback", generatedDatasets[dataset].fallback,
+			"count", len(missing),
+			"instance-types", utils.PrettySlice(missing, 5),
+		).Info("generated data is missing instance types, falling back to values derived from the EC2 API")
+	}
+}
@@ -17,6 +17,7 @@ package instancetype
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -39,6 +40,7 @@ import (
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 
@@ -65,6 +67,12 @@ type DefaultProvider struct {
 
 	muInstanceTypesOfferings sync.RWMutex
 	instanceTypesOfferings   map[string]sets.Set[string]
+	// outpostInstanceTypes are the instance types which are configured on the Outposts that EC2NodeClasses launch on,
+	// keyed by Outpost ARN. Outposts are discovered when an EC2NodeClass which launches on them is first listed.
+	outpostInstanceTypes map[string]sets.Set[string]
+	// zones are the zones of the region which the account is opted into, keyed by zone name. They distinguish Local
+	// Zones and Wavelength Zones from Availability Zones.
+	zones map[string]ec2types.AvailabilityZone
 
 	instanceTypesCache      *cache.Cache
 	discoveredCapacityCache *cache.Cache
@@ -73,6 +81,11 @@ type DefaultProvider struct {
 	instanceTypesSeqNum uint64
 	// instanceTypesOfferingsSeqNum is a monotonically increasing change counter used to avoid the expensive hashing operation on instance types
 	instanceTypesOfferingsSeqNum uint64
+	// learnedVMMemoryOverheadSeqNum is a monotonically increasing change counter of the learned VM memory overheads, so
+	// that a newly learned overhead is applied without waiting for the fully initialized instance types to expire
+	learnedVMMemoryOverheadSeqNum uint64
+	// prefixDelegation is true if the VPC CNI assigns IPv4 prefixes to ENIs, which raises the ENI limited pods
+	prefixDelegation atomic.Bool
 }
 
 func NewDefaultProvider(instanceTypesCache *cache.Cache, discoveredCapacityCache *cache.Cache, ec2api sdk.EC2API, subnetProvider subnet.Provider, instanceTypesResolver Resolver) *DefaultProvider {
@@ -81,6 +94,8 @@ func NewDefaultProvider(instanceTypesCache *cache.Cache, discoveredCapacityCache
 		subnetProvider:          subnetProvider,
 		instanceTypesInfo:       []ec2types.InstanceTypeInfo{},
 		instanceTypesOfferings:  map[string]sets.Set[string]{},
+		outpostInstanceTypes:    map[string]sets.Set[string]{},
+		zones:                   map[string]ec2types.AvailabilityZone{},
 		instanceTypesResolver:   instanceTypesResolver,
 		instanceTypesCache:      instanceTypesCache,
 		discoveredCapacityCache: discoveredCapacityCache,
@@ -91,6 +106,11 @@ func NewDefaultProvider(instanceTypesCache *cache.Cache, discoveredCapacityCache
 
 //nolint:gocyclo
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]*cloudprovider.InstanceType, error) {
+	if nodeClass.Spec.OutpostARN != nil {
+		if err := p.discoverOutpost(ctx, lo.FromPtr(nodeClass.Spec.OutpostARN)); err != nil {
+			return nil, err
+		}
+	}
 	p.muInstanceTypesInfo.RLock()
 	p.muInstanceTypesOfferings.RLock()
 	defer p.muInstanceTypesInfo.RUnlock()
@@ -116,12 +136,17 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	// Compute hash key against node class AMIs (used to force cache rebuild when AMIs change)
 	amiHash, _ := hashstructure.Hash(nodeClass.Status.AMIs, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 
-	key := fmt.Sprintf("%d-%d-%016x-%016x-%016x",
+	prefixDelegation := p.prefixDelegation.Load()
+	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%s-%t-%t",
 		p.instanceTypesSeqNum,
 		p.instanceTypesOfferingsSeqNum,
+		atomic.LoadUint64(&p.learnedVMMemoryOverheadSeqNum),
 		amiHash,
 		subnetZonesHash,
 		p.instanceTypesResolver.CacheKey(nodeClass),
+		lo.FromPtr((*string)(nodeClass.Spec.InstanceStoreEncryption)),
+		nodeClass.EnclavesEnabled(),
+		prefixDelegation,
 	)
 	if item, ok := p.instanceTypesCache.Get(key); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
@@ -143,7 +168,12 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	subnetZoneToID := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, string) {
 		return s.Zone, s.ZoneID
 	})
-	result := lo.Map(p.instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
+	exclusions := options.FromContext(ctx).InstanceTypePolicyExclusions()
+	instanceTypesInfo := lo.Filter(p.instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) bool {
+		return satisfiesInstanceStoreEncryption(i, nodeClass.Spec.InstanceStoreEncryption) && satisfiesEnclaves(i, nodeClass.EnclavesEnabled()) &&
+			!excludedByInstanceTypePolicy(i, exclusions)
+	})
+	result := lo.Map(instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
 		InstanceTypeVCPU.Set(float64(lo.FromPtr(i.VCpuInfo.DefaultVCpus)), map[string]string{
 			instanceTypeLabel: string(i.InstanceType),
 		})
@@ -152,22 +182,35 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 		})
 
 		zoneData := lo.Map(allZones.UnsortedList(), func(zoneName string, _ int) ZoneData {
-			if !p.instanceTypesOfferings[string(i.InstanceType)].Has(zoneName) || !subnetZones.Has(zoneName) {
+			offered := p.instanceTypesOfferings[string(i.InstanceType)].Has(zoneName)
+			// Instances on an Outpost are launched into the zone that the Outpost is anchored to, but only the instance
+			// types which are configured on the Outpost can be launched
+			if nodeClass.Spec.OutpostARN != nil {
+				offered = p.outpostInstanceTypes[lo.FromPtr(nodeClass.Spec.OutpostARN)].Has(string(i.InstanceType))
+			}
+			zone := p.zones[zoneName]
+			if !offered || !subnetZones.Has(zoneName) {
 				return ZoneData{
-					Name:      zoneName,
-					Available: false,
+					Name:       zoneName,
+					Type:       lo.FromPtr(zone.ZoneType),
+					ParentName: lo.FromPtr(zone.ParentZoneName),
+					Available:  false,
 				}
 			}
 			return ZoneData{
-				Name:      zoneName,
-				ID:        subnetZoneToID[zoneName],
-				Available: true,
+				Name:       zoneName,
+				ID:         subnetZoneToID[zoneName],
+				Type:       lo.FromPtr(zone.ZoneType),
+				ParentName: lo.FromPtr(zone.ParentZoneName),
+				Available:  true,
 			}
 		})
 
-		it := p.instanceTypesResolver.Resolve(ctx, i, zoneData, nodeClass)
+		it := p.instanceTypesResolver.Resolve(WithPrefixDelegation(ctx, prefixDelegation), i, zoneData, nodeClass)
 		if cached, ok := p.discoveredCapacityCache.Get(fmt.Sprintf("%s-%016x", it.Name, amiHash)); ok {
 			it.Capacity[corev1.ResourceMemory] = cached.(resource.Quantity)
+		} else if overhead, ok := p.learnedVMMemoryOverhead(ctx, i, amiHash); ok {
+			it.Capacity[corev1.ResourceMemory] = *memoryWithOverhead(i, overhead)
 		}
 		for _, of := range it.Offerings {
 			InstanceTypeOfferingAvailable.Set(float64(lo.Ternary(of.Available, 1, 0)), map[string]string{
@@ -187,6 +230,14 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	return result, nil
 }
 
+// SetPrefixDelegation records whether the VPC CNI assigns IPv4 prefixes to ENIs, so that the max pods of instance types
+// account for the addresses of the prefixes
+func (p *DefaultProvider) SetPrefixDelegation(ctx context.Context, enabled bool) {
+	if p.prefixDelegation.Swap(enabled) != enabled {
+		log.FromContext(ctx).WithValues("enabled", enabled).Info("detected change in vpc cni prefix delegation")
+	}
+}
+
 func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 	// DO NOT REMOVE THIS LOCK ----------------------------------------------------------------------------
 	// We lock here so that multiple callers to getInstanceTypeOfferings do not result in cache misses and multiple
@@ -226,6 +277,7 @@ func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 		log.FromContext(ctx).WithValues(
 			"count", len(instanceTypes)).V(1).Info("discovered instance types")
 	}
+	p.reportGeneratedDataGaps(ctx, instanceTypes)
 	p.instanceTypesInfo = instanceTypes
 	return nil
 }
@@ -267,6 +319,70 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 		log.FromContext(ctx).WithValues("instance-type-count", len(instanceTypeOfferings)).V(1).Info("discovered offerings for instance types")
 	}
 	p.instanceTypesOfferings = instanceTypeOfferings
+	p.updateZones(ctx)
+	for outpostARN := range p.outpostInstanceTypes {
+		if err := p.updateOutpost(ctx, outpostARN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateZones describes the zones of the region, so that offerings in Local Zones and Wavelength Zones can be told
+// apart from offerings in Availability Zones. Zones are only used to label offerings and to fall back to the pricing
+// of their parent zone, so a failure is logged rather than blocking the offerings from being refreshed. It must be
+// called while holding the offerings lock.
+func (p *DefaultProvider) updateZones(ctx context.Context) {
+	out, err := p.ec2api.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed describing availability zones, offerings won't be labeled with their zone type")
+		return
+	}
+	zones := lo.SliceToMap(out.AvailabilityZones, func(z ec2types.AvailabilityZone) (string, ec2types.AvailabilityZone) {
+		return lo.FromPtr(z.ZoneName), z
+	})
+	zoneTypes := lo.MapValues(zones, func(z ec2types.AvailabilityZone, _ string) string { return lo.FromPtr(z.ZoneType) })
+	if p.cm.HasChanged("zone-types", zoneTypes) {
+		atomic.AddUint64(&p.instanceTypesOfferingsSeqNum, 1)
+		log.FromContext(ctx).WithValues("zone-types", zoneTypes).V(1).Info("discovered zone types")
+	}
+	p.zones = zones
+}
+
+// discoverOutpost discovers the instance types which are configured on the Outpost, unless they've already been
+// discovered. The instance types of discovered Outposts are refreshed with the offerings of the region.
+func (p *DefaultProvider) discoverOutpost(ctx context.Context, outpostARN string) error {
+	p.muInstanceTypesOfferings.Lock()
+	defer p.muInstanceTypesOfferings.Unlock()
+
+	if _, ok := p.outpostInstanceTypes[outpostARN]; ok {
+		return nil
+	}
+	return p.updateOutpost(ctx, outpostARN)
+}
+
+// updateOutpost describes the instance types which are configured on the Outpost. It must be called while holding the
+// offerings lock.
+func (p *DefaultProvider) updateOutpost(ctx context.Context, outpostARN string) error {
+	instanceTypes := sets.New[string]()
+	paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(p.ec2api, &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: ec2types.LocationTypeOutpost,
+		Filters:      []ec2types.Filter{{Name: aws.String("location"), Values: []string{outpostARN}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing instance type outpost offerings, %w", err)
+		}
+		for _, offering := range page.InstanceTypeOfferings {
+			instanceTypes.Insert(string(offering.InstanceType))
+		}
+	}
+	if p.cm.HasChanged(fmt.Sprintf("outpost-instance-types/%s", outpostARN), instanceTypes) {
+		atomic.AddUint64(&p.instanceTypesOfferingsSeqNum, 1)
+		log.FromContext(ctx).WithValues("outpost-arn", outpostARN, "instance-types", sets.List(instanceTypes)).V(1).Info("discovered instance types for outpost")
+	}
+	p.outpostInstanceTypes[outpostARN] = instanceTypes
 	return nil
 }
 
@@ -293,12 +409,98 @@ func (p *DefaultProvider) UpdateInstanceTypeCapacityFromNode(ctx context.Context
 		log.FromContext(ctx).WithValues("memory-capacity", actualCapacity, "instance-type", instanceTypeName).V(1).Info("updating discovered capacity cache")
 		p.discoveredCapacityCache.SetDefault(key, *actualCapacity)
 	}
+	if options.FromContext(ctx).FeatureGates.LearnVMMemoryOverhead {
+		p.learnVMMemoryOverhead(ctx, instanceTypeName, actualCapacity, amiHash)
+	}
 	return nil
 }
 
+// learnVMMemoryOverhead records the VM memory overhead observed on a node against the node's instance family, so that
+// it can be applied to the instance types of the family that haven't been launched yet. The largest overhead observed
+// for the family is kept so that we don't overestimate the memory of the family's other instance types.
+func (p *DefaultProvider) learnVMMemoryOverhead(ctx context.Context, instanceTypeName string, actualCapacity *resource.Quantity, amiHash uint64) {
+	p.muInstanceTypesInfo.RLock()
+	info, ok := lo.Find(p.instanceTypesInfo, func(i ec2types.InstanceTypeInfo) bool {
+		return string(i.InstanceType) == instanceTypeName
+	})
+	p.muInstanceTypesInfo.RUnlock()
+	if !ok {
+		return
+	}
+	rawCapacity := memoryWithOverhead(info, 0)
+	if rawCapacity.IsZero() || actualCapacity.Cmp(*rawCapacity) >= 0 {
+		return
+	}
+	overhead := 1 - float64(actualCapacity.Value())/float64(rawCapacity.Value())
+	key := vmMemoryOverheadKey(instanceTypeName, amiHash)
+	if cached, ok := p.discoveredCapacityCache.Get(key); !ok || overhead > cached.(float64) {
+		log.FromContext(ctx).WithValues("vm-memory-overhead-percent", overhead, "instance-type", instanceTypeName).V(1).Info("updating learned vm memory overhead")
+		p.discoveredCapacityCache.SetDefault(key, overhead)
+		atomic.AddUint64(&p.learnedVMMemoryOverheadSeqNum, 1)
+	}
+}
+
+// learnedVMMemoryOverhead returns the VM memory overhead learned for the instance type's family, if learning is
+// enabled and no override is configured for the instance type.
+func (p *DefaultProvider) learnedVMMemoryOverhead(ctx context.Context, info ec2types.InstanceTypeInfo, amiHash uint64) (float64, bool) {
+	opts := options.FromContext(ctx)
+	if !opts.FeatureGates.LearnVMMemoryOverhead {
+		return 0, false
+	}
+	if _, ok := opts.VMMemoryOverheadPercentOverride(string(info.InstanceType)); ok {
+		return 0, false
+	}
+	cached, ok := p.discoveredCapacityCache.Get(vmMemoryOverheadKey(string(info.InstanceType), amiHash))
+	if !ok {
+		return 0, false
+	}
+	return cached.(float64), true
+}
+
+func vmMemoryOverheadKey(instanceTypeName string, amiHash uint64) string {
+	family, _, _ := strings.Cut(instanceTypeName, ".")
+	return fmt.Sprintf("%s-family-%016x", family, amiHash)
+}
+
 func (p *DefaultProvider) Reset() {
 	p.instanceTypesInfo = []ec2types.InstanceTypeInfo{}
 	p.instanceTypesOfferings = map[string]sets.Set[string]{}
+	p.outpostInstanceTypes = map[string]sets.Set[string]{}
+	p.zones = map[string]ec2types.AvailabilityZone{}
 	p.instanceTypesCache.Flush()
 	p.discoveredCapacityCache.Flush()
 }
+
+// excludedByInstanceTypePolicy returns true if the instance-type-policy excludes the instance type by name, by instance
+// family or by one of the categories of instance types that it belongs to
+func excludedByInstanceTypePolicy(info ec2types.InstanceTypeInfo, exclusions []string) bool {
+	family, _, _ := strings.Cut(string(info.InstanceType), ".")
+	return lo.ContainsBy(exclusions, func(exclusion string) bool {
+		switch exclusion {
+		case options.InstanceTypeCategoryPreviousGeneration:
+			_, ok := PreviousGenerationFamilies[family]
+			return ok || (info.CurrentGeneration != nil && !*info.CurrentGeneration)
+		case options.InstanceTypeCategoryMetal:
+			return lo.FromPtr(info.BareMetal)
+		case options.InstanceTypeCategoryBurstable:
+			return lo.FromPtr(info.BurstablePerformanceSupported)
+		default:
+			return exclusion == string(info.InstanceType) || exclusion == family
+		}
+	})
+}
+
+// satisfiesEnclaves returns true if the instance type supports Nitro Enclaves, or if the EC2NodeClass doesn't enable
+// them
+func satisfiesEnclaves(info ec2types.InstanceTypeInfo, enabled bool) bool {
+	return !enabled || info.NitroEnclavesSupport == ec2types.NitroEnclavesSupportSupported
+}
+
+// satisfiesInstanceStoreEncryption returns true if the instance store disks of the instance type satisfy the
+// encryption requirements of the EC2NodeClass. Instance types without instance store disks always satisfy them.
+func satisfiesInstanceStoreEncryption(info ec2types.InstanceTypeInfo, encryption *v1.InstanceStoreEncryption) bool {
+	if lo.FromPtr(encryption) != v1.InstanceStoreEncryptionRequired || info.InstanceStorageInfo == nil {
+		return true
+	}
+	return info.InstanceStorageInfo.EncryptionSupport == ec2types.InstanceStorageEncryptionSupportRequired
+}
@@ -0,0 +1,152 @@
+//go:build test_performance
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+)
+
+// benchmarkNodePoolCount is the number of NodePools whose requirements the instance types are filtered against
+const benchmarkNodePoolCount = 20
+
+func BenchmarkListCached(b *testing.B) {
+	benchmarkList(b, true)
+}
+
+func BenchmarkListUncached(b *testing.B) {
+	benchmarkList(b, false)
+}
+
+func BenchmarkOfferingFiltering(b *testing.B) {
+	benchCtx := benchmarkContext()
+	provider, _ := benchmarkProvider(benchCtx, b)
+	instanceTypes, err := provider.List(benchCtx, test.EC2NodeClass())
+	if err != nil {
+		b.Fatalf("listing instance types, %s", err)
+	}
+	nodePoolRequirements := benchmarkNodePoolRequirements()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	compatible := 0
+	for range b.N {
+		compatible = 0
+		for _, requirements := range nodePoolRequirements {
+			for _, it := range instanceTypes {
+				if it.Requirements.Compatible(requirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+					continue
+				}
+				if it.Offerings.Available().HasCompatible(requirements) {
+					compatible++
+				}
+			}
+		}
+	}
+	b.ReportMetric(float64(len(instanceTypes)), "instancetypes")
+	b.ReportMetric(float64(compatible), "compatible/op")
+}
+
+func benchmarkList(b *testing.B, cached bool) {
+	benchCtx := benchmarkContext()
+	provider, instanceTypesCache := benchmarkProvider(benchCtx, b)
+	nodeClass := test.EC2NodeClass()
+	// Resolve the instance types before the timer is reset so that a cached run only measures cache hits
+	if _, err := provider.List(benchCtx, nodeClass); err != nil {
+		b.Fatalf("listing instance types, %s", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var instanceTypes []*corecloudprovider.InstanceType
+	for range b.N {
+		if !cached {
+			instanceTypesCache.Flush()
+		}
+		var err error
+		if instanceTypes, err = provider.List(benchCtx, nodeClass); err != nil {
+			b.Fatalf("listing instance types, %s", err)
+		}
+	}
+	b.ReportMetric(float64(len(instanceTypes)), "instancetypes")
+}
+
+func benchmarkContext() context.Context {
+	benchCtx := coreoptions.ToContext(context.Background(), coretest.Options())
+	return options.ToContext(benchCtx, test.Options())
+}
+
+// benchmarkProvider returns an instance type provider which resolves every instance type that has static pricing data
+// (700+ instance types) in each of the zones of the test EC2NodeClass, along with its instance type cache
+func benchmarkProvider(ctx context.Context, b *testing.B) (*instancetype.DefaultProvider, *cache.Cache) {
+	ec2api := fake.NewEC2API()
+	instanceTypes := fake.MakeInstances()
+	ec2api.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instanceTypes})
+	ec2api.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+		InstanceTypeOfferings: fake.MakeZonalInstanceOfferings(instanceTypes, "test-zone-1a", "test-zone-1b", "test-zone-1c"),
+	})
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+		cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	pricingProvider := pricing.NewDefaultProvider(ctx, &fake.PricingAPI{}, ec2api, fake.DefaultRegion)
+	instanceTypesCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	provider := instancetype.NewDefaultProvider(instanceTypesCache, cache.New(awscache.DiscoveredCapacityCacheTTL, awscache.DefaultCleanupInterval),
+		ec2api, subnetProvider, instancetype.NewDefaultResolver(fake.DefaultRegion, pricingProvider, awscache.NewUnavailableOfferings()))
+	if err := provider.UpdateInstanceTypes(ctx); err != nil {
+		b.Fatalf("updating instance types, %s", err)
+	}
+	if err := provider.UpdateInstanceTypeOfferings(ctx); err != nil {
+		b.Fatalf("updating instance type offerings, %s", err)
+	}
+	return provider, instanceTypesCache
+}
+
+// benchmarkNodePoolRequirements returns the requirements of NodePools which differ by capacity type, instance category,
+// instance generation and zone, as a cluster which separates its workloads across NodePools would
+func benchmarkNodePoolRequirements() []scheduling.Requirements {
+	capacityTypes := [][]string{{karpv1.CapacityTypeOnDemand}, {karpv1.CapacityTypeSpot}, {karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}
+	categories := [][]string{{"c"}, {"m"}, {"r"}, {"c", "m", "r"}, {"t"}}
+	zones := [][]string{{"test-zone-1a"}, {"test-zone-1a", "test-zone-1b", "test-zone-1c"}}
+	return lo.Times(benchmarkNodePoolCount, func(i int) scheduling.Requirements {
+		return scheduling.NewRequirements(
+			scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityTypes[i%len(capacityTypes)]...),
+			scheduling.NewRequirement(v1.LabelInstanceCategory, corev1.NodeSelectorOpIn, categories[i%len(categories)]...),
+			scheduling.NewRequirement(v1.LabelInstanceGeneration, corev1.NodeSelectorOpGt, fmt.Sprint(2+i%3)),
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zones[i%len(zones)]...),
+			scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, karpv1.ArchitectureAmd64),
+		)
+	})
+}
@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// PreviousGeneration returns the family of the instance type and the reason that it's aging, if the family is a
+// previous generation family or its retirement has been announced
+func PreviousGeneration(instanceType *cloudprovider.InstanceType) (family string, reason string, ok bool) {
+	family = instanceType.Requirements.Get(v1.LabelInstanceFamily).Any()
+	reason, ok = PreviousGenerationFamilies[family]
+	return family, reason, ok
+}
+
+// ExcludePreviousGeneration removes the instance types of previous generation families, unless the NodePool
+// explicitly selects them with an In requirement on their instance family or instance type
+func ExcludePreviousGeneration(nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	selected := func(key, value string) bool {
+		return lo.ContainsBy(nodePool.Spec.Template.Spec.Requirements, func(r karpv1.NodeSelectorRequirementWithMinValues) bool {
+			return r.Key == key && r.Operator == corev1.NodeSelectorOpIn && lo.Contains(r.Values, value)
+		})
+	}
+	return lo.Reject(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		family, _, ok := PreviousGeneration(it)
+		return ok && !selected(v1.LabelInstanceFamily, family) && !selected(corev1.LabelInstanceTypeStable, it.Name)
+	})
+}
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+var maxNodeSizeAnnotations = map[corev1.ResourceName]string{
+	corev1.ResourceCPU:    v1.AnnotationMaxNodeCPU,
+	corev1.ResourceMemory: v1.AnnotationMaxNodeMemory,
+}
+
+// MaxNodeSize returns the largest cpu and memory capacity of the instance types that the NodePool may launch, as
+// configured by the karpenter.k8s.aws/max-node-cpu and karpenter.k8s.aws/max-node-memory annotations. Annotations which
+// aren't positive quantities are left out of the maximum node size and returned as an error, so that a malformed
+// annotation doesn't block the NodePool from launching.
+func MaxNodeSize(nodePool *karpv1.NodePool) (corev1.ResourceList, error) {
+	maxSize := corev1.ResourceList{}
+	var errs error
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		annotation := maxNodeSizeAnnotations[resourceName]
+		value, ok := nodePool.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil || quantity.Sign() <= 0 {
+			errs = multierr.Append(errs, fmt.Errorf("%s annotation must be a positive quantity, got %q", annotation, value))
+			continue
+		}
+		maxSize[resourceName] = quantity
+	}
+	return maxSize, errs
+}
+
+// ExcludeAboveMaxNodeSize removes the instance types whose cpu or memory capacity exceeds the maximum node size, and
+// returns the names of the instance types that were removed
+func ExcludeAboveMaxNodeSize(maxSize corev1.ResourceList, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, []string) {
+	kept, excluded := lo.FilterReject(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return lo.NoneBy(lo.Keys(maxSize), func(resourceName corev1.ResourceName) bool {
+			capacity, ok := it.Capacity[resourceName]
+			return ok && capacity.Cmp(maxSize[resourceName]) > 0
+		})
+	})
+	return kept, lo.Map(excluded, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })
+}
@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// VPCCNINamespace and VPCCNIDaemonSet identify the DaemonSet of the Amazon VPC CNI
+	VPCCNINamespace = "kube-system"
+	VPCCNIDaemonSet = "aws-node"
+	// PrefixDelegationEnv is the environment variable of the VPC CNI which enables prefix delegation
+	PrefixDelegationEnv = "ENABLE_PREFIX_DELEGATION"
+
+	// prefixDelegationIPsPerPrefix is the number of IPv4 addresses of each /28 prefix which is assigned to an ENI slot
+	prefixDelegationIPsPerPrefix = 16
+	// prefixDelegationMaxPodsCPUThreshold is the number of vCPUs above which the max pods of instance types with prefix
+	// delegation are capped at prefixDelegationMaxPodsLarge rather than prefixDelegationMaxPodsSmall, as recommended
+	// by https://github.com/awslabs/amazon-eks-ami/blob/main/templates/al2/runtime/max-pods-calculator.sh
+	prefixDelegationMaxPodsCPUThreshold = 30
+	prefixDelegationMaxPodsSmall        = 110
+	prefixDelegationMaxPodsLarge        = 250
+)
+
+type prefixDelegationKey struct{}
+
+// WithPrefixDelegation returns a context which computes the ENI limited pods of instance types with prefix delegation
+func WithPrefixDelegation(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, prefixDelegationKey{}, enabled)
+}
+
+func prefixDelegationFromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(prefixDelegationKey{}).(bool)
+	return enabled
+}
+
+// PrefixDelegationEnabled returns true if the VPC CNI assigns IPv4 prefixes rather than individual IPv4 addresses to
+// the ENIs of nodes. The ENABLE_PREFIX_DELEGATION environment variable of the aws-node DaemonSet is read, resolving it
+// from a ConfigMap when it's set through a configMapKeyRef. Clusters without the VPC CNI don't use prefix delegation.
+func PrefixDelegationEnabled(ctx context.Context, kubernetesInterface kubernetes.Interface) (bool, error) {
+	daemonSet, err := kubernetesInterface.AppsV1().DaemonSets(VPCCNINamespace).Get(ctx, VPCCNIDaemonSet, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting vpc cni daemonset, %w", err)
+	}
+	container, ok := lo.Find(daemonSet.Spec.Template.Spec.Containers, func(c corev1.Container) bool { return c.Name == VPCCNIDaemonSet })
+	if !ok {
+		return false, nil
+	}
+	env, ok := lo.Find(container.Env, func(e corev1.EnvVar) bool { return e.Name == PrefixDelegationEnv })
+	if !ok {
+		return false, nil
+	}
+	value := env.Value
+	if ref := lo.FromPtr(env.ValueFrom).ConfigMapKeyRef; ref != nil {
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps(VPCCNINamespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) && lo.FromPtr(ref.Optional) {
+				return false, nil
+			}
+			return false, fmt.Errorf("getting vpc cni configmap, %w", err)
+		}
+		value = configMap.Data[ref.Key]
+	}
+	enabled, _ := strconv.ParseBool(value)
+	return enabled, nil
+}
+
+// supportsPrefixDelegation returns true if prefixes can be assigned to the ENIs of the instance type, which is only
+// supported by Nitro instance types
+func supportsPrefixDelegation(info ec2types.InstanceTypeInfo) bool {
+	return info.Hypervisor == ec2types.InstanceTypeHypervisorNitro || lo.FromPtr(info.BareMetal)
+}
+
+// prefixDelegationPods returns the number of pods that the IPv4 prefixes of the usable ENIs can address, capped at the
+// max pods that are recommended for the size of the instance type
+func prefixDelegationPods(info ec2types.InstanceTypeInfo, usableNetworkInterfaces int64) int64 {
+	addressesPerInterface := int64(lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface))
+	pods := usableNetworkInterfaces*(addressesPerInterface-1)*prefixDelegationIPsPerPrefix + 2
+	return lo.Min([]int64{pods, lo.Ternary(lo.FromPtr(info.VCpuInfo.DefaultVCpus) > prefixDelegationMaxPodsCPUThreshold,
+		int64(prefixDelegationMaxPodsLarge), int64(prefixDelegationMaxPodsSmall))})
+}
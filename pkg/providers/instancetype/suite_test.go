@@ -21,6 +21,7 @@ import (
 	"net"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -221,6 +222,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 	})
 
 	It("should support individual instance type labels", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 2}
 		ExpectApplied(ctx, env.Client, nodePool, windowsNodePool, nodeClass, windowsNodeClass)
 
 		nodeSelector := map[string]string{
@@ -234,7 +236,10 @@ var _ = Describe("InstanceTypeProvider", func() {
 			karpv1.CapacityTypeLabelKey:    "on-demand",
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
+			v1.LabelInstanceBareMetal:                    "false",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelNitroEnclavesSupported:               "true",
+			v1.LabelInstanceNetworkAcceleration:          "efa",
 			v1.LabelInstanceCategory:                     "g",
 			v1.LabelInstanceGeneration:                   "4",
 			v1.LabelInstanceFamily:                       "g4dn",
@@ -244,7 +249,13 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceCPUSustainedClockSpeedMhz:    "2500",
 			v1.LabelInstanceMemory:                       "131072",
 			v1.LabelInstanceEBSBandwidth:                 "9500",
+			v1.LabelInstanceEBSBaselineThroughput:        "1187",
+			v1.LabelInstanceEBSMaximumThroughput:         "1187",
+			v1.LabelInstanceEBSBaselineIOPS:              "40000",
+			v1.LabelInstanceEBSMaximumIOPS:               "40000",
 			v1.LabelInstanceNetworkBandwidth:             "50000",
+			v1.LabelInstanceNetworkCards:                 "1",
+			v1.LabelInstanceNetworkInterfaces:            "4",
 			v1.LabelInstanceGPUName:                      "t4",
 			v1.LabelInstanceGPUManufacturer:              "nvidia",
 			v1.LabelInstanceGPUCount:                     "1",
@@ -255,6 +266,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceAcceleratorManufacturer: "aws",
 			v1.LabelInstanceAcceleratorCount:        "1",
 			v1.LabelTopologyZoneID:                  "tstz1-1a",
+			v1.LabelTopologyZoneType:                "availability-zone",
+			v1.LabelPlacementPartition:              "2",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -291,7 +304,10 @@ var _ = Describe("InstanceTypeProvider", func() {
 			karpv1.CapacityTypeLabelKey:    "on-demand",
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
+			v1.LabelInstanceBareMetal:                    "false",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelNitroEnclavesSupported:               "true",
+			v1.LabelInstanceNetworkAcceleration:          "efa",
 			v1.LabelInstanceCategory:                     "g",
 			v1.LabelInstanceGeneration:                   "4",
 			v1.LabelInstanceFamily:                       "g4dn",
@@ -301,13 +317,20 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceCPUSustainedClockSpeedMhz:    "2500",
 			v1.LabelInstanceMemory:                       "131072",
 			v1.LabelInstanceEBSBandwidth:                 "9500",
+			v1.LabelInstanceEBSBaselineThroughput:        "1187",
+			v1.LabelInstanceEBSMaximumThroughput:         "1187",
+			v1.LabelInstanceEBSBaselineIOPS:              "40000",
+			v1.LabelInstanceEBSMaximumIOPS:               "40000",
 			v1.LabelInstanceNetworkBandwidth:             "50000",
+			v1.LabelInstanceNetworkCards:                 "1",
+			v1.LabelInstanceNetworkInterfaces:            "4",
 			v1.LabelInstanceGPUName:                      "t4",
 			v1.LabelInstanceGPUManufacturer:              "nvidia",
 			v1.LabelInstanceGPUCount:                     "1",
 			v1.LabelInstanceGPUMemory:                    "16384",
 			v1.LabelInstanceLocalNVME:                    "900",
 			v1.LabelTopologyZoneID:                       "tstz1-1a",
+			v1.LabelTopologyZoneType:                     "availability-zone",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -317,13 +340,14 @@ var _ = Describe("InstanceTypeProvider", func() {
 			"topology.ebs.csi.aws.com/zone":     "test-zone-1a",
 		}
 
-		// Ensure that we're exercising all well known labels except for accelerator labels
+		// Ensure that we're exercising all well known labels except for accelerator and placement labels
 		Expect(lo.Keys(nodeSelector)).To(ContainElements(
 			append(
 				karpv1.WellKnownLabels.Difference(sets.New(
 					v1.LabelInstanceAcceleratorCount,
 					v1.LabelInstanceAcceleratorName,
 					v1.LabelInstanceAcceleratorManufacturer,
+					v1.LabelPlacementPartition,
 					corev1.LabelWindowsBuild,
 				)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)))
 
@@ -345,7 +369,10 @@ var _ = Describe("InstanceTypeProvider", func() {
 			karpv1.CapacityTypeLabelKey:    "on-demand",
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
+			v1.LabelInstanceBareMetal:                    "false",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelNitroEnclavesSupported:               "false",
+			v1.LabelInstanceNetworkAcceleration:          "ena",
 			v1.LabelInstanceCategory:                     "inf",
 			v1.LabelInstanceGeneration:                   "2",
 			v1.LabelInstanceFamily:                       "inf2",
@@ -355,11 +382,18 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceCPUManufacturer:              "amd",
 			v1.LabelInstanceMemory:                       "16384",
 			v1.LabelInstanceEBSBandwidth:                 "10000",
+			v1.LabelInstanceEBSBaselineThroughput:        "156",
+			v1.LabelInstanceEBSMaximumThroughput:         "1250",
+			v1.LabelInstanceEBSBaselineIOPS:              "6000",
+			v1.LabelInstanceEBSMaximumIOPS:               "40000",
 			v1.LabelInstanceNetworkBandwidth:             "2083",
+			v1.LabelInstanceNetworkCards:                 "1",
+			v1.LabelInstanceNetworkInterfaces:            "4",
 			v1.LabelInstanceAcceleratorName:              "inferentia2",
 			v1.LabelInstanceAcceleratorManufacturer:      "aws",
 			v1.LabelInstanceAcceleratorCount:             "1",
 			v1.LabelTopologyZoneID:                       "tstz1-1a",
+			v1.LabelTopologyZoneType:                     "availability-zone",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -384,6 +418,63 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	It("should label bare metal instance types without a hypervisor", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		metal, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.metal" })
+		Expect(ok).To(BeTrue())
+		Expect(metal.Requirements.Get(v1.LabelInstanceBareMetal).Values()).To(ConsistOf("true"))
+		Expect(metal.Requirements.Get(v1.LabelInstanceHypervisor).Values()).To(ConsistOf(""))
+		Expect(metal.Requirements.Get(v1.LabelInstanceGeneration).Values()).To(ConsistOf("5"))
+	})
+	It("should exclude the instance types, families and categories in the instance type policy", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InstanceTypePolicy: lo.ToPtr("metal,burstable,c6g,m5.xlarge")}))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			Expect(it.Name).ToNot(BeElementOf("m5.metal", "t3.large", "t4g.medium", "m5.xlarge", "c6g.large"))
+		}
+		Expect(lo.ContainsBy(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })).To(BeTrue())
+	})
+	It("should exclude bare metal and xen instance types when requiring nitro", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceHypervisor, Operator: corev1.NodeSelectorOpIn, Values: []string{"nitro"}},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceHypervisor, "nitro"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceBareMetal, "false"))
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+			for _, override := range ltc.Overrides {
+				Expect(string(override.InstanceType)).ToNot(BeElementOf("m5.metal", "p3.8xlarge"))
+			}
+		}
+	})
+	It("should advertise the partitions of the placement group", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: "test-placement-group", Partitions: 3}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			Expect(it.Requirements.Get(v1.LabelPlacementPartition).Values()).To(ConsistOf("1", "2", "3"))
+		}
+	})
+	It("should not advertise placement partitions without a placement group", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		for _, it := range instanceTypes {
+			Expect(it.Requirements.Has(v1.LabelPlacementPartition)).To(BeFalse())
+		}
+	})
 	It("should not launch AWS Pod ENI on a t3", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 		pod := coretest.UnschedulablePod(coretest.PodOptions{
@@ -888,6 +979,145 @@ var _ = Describe("InstanceTypeProvider", func() {
 		}
 		Expect(nodes.Len()).To(Equal(1))
 	})
+	It("should not advertise network bandwidth as a resource by default", func() {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity).ToNot(HaveKey(v1.ResourceNetworkBandwidth))
+		}
+	})
+	It("should launch instances with sufficient bandwidth for networking.k8s.aws/bandwidth-mbps resource requests", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseNetworkBandwidth: lo.ToPtr(true)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		m5large, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(m5large.Capacity).To(HaveKeyWithValue(v1.ResourceNetworkBandwidth, resource.MustParse("750")))
+
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{v1.ResourceNetworkBandwidth: resource.MustParse("20000")},
+				Limits:   corev1.ResourceList{v1.ResourceNetworkBandwidth: resource.MustParse("20000")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool {
+			return it.Name == node.Labels[corev1.LabelInstanceTypeStable]
+		})
+		Expect(ok).To(BeTrue())
+		Expect(it.Capacity.Name(v1.ResourceNetworkBandwidth, resource.DecimalSI).Value()).To(BeNumerically(">=", 20000))
+	})
+	It("should not advertise secondary ENIs unless enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseSecondaryENIs: lo.ToPtr(false)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity).ToNot(HaveKey(v1.ResourceSecondaryENI))
+		}
+	})
+	It("should only advertise ENIs on non-default network cards as secondary ENIs", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseSecondaryENIs: lo.ToPtr(true)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		its := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		Expect(its["m5.large"].Capacity).To(HaveKeyWithValue(v1.ResourceSecondaryENI, resource.MustParse("0")))
+		Expect(its["m6idn.32xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourceSecondaryENI, resource.MustParse("8")))
+	})
+	It("should advertise reserved ENIs on the default network card as secondary ENIs", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ReservedENIs: lo.ToPtr(1), FeatureGates: test.FeatureGates{AdvertiseSecondaryENIs: lo.ToPtr(true)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		its := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		Expect(its["m5.large"].Capacity).To(HaveKeyWithValue(v1.ResourceSecondaryENI, resource.MustParse("1")))
+		Expect(its["m6idn.32xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourceSecondaryENI, resource.MustParse("9")))
+	})
+	It("should not advertise network cards unless enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseNetworkCards: lo.ToPtr(false)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity).ToNot(HaveKey(v1.ResourceNetworkCard))
+		}
+	})
+	It("should advertise the network cards and network interfaces of the instance type", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseNetworkCards: lo.ToPtr(true)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		its := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		Expect(its["m5.large"].Capacity).To(HaveKeyWithValue(v1.ResourceNetworkCard, resource.MustParse("1")))
+		Expect(its["m5.large"].Requirements.Get(v1.LabelInstanceNetworkCards).Values()).To(ConsistOf("1"))
+		Expect(its["m5.large"].Requirements.Get(v1.LabelInstanceNetworkInterfaces).Values()).To(ConsistOf("3"))
+		Expect(its["dl1.24xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourceNetworkCard, resource.MustParse("4")))
+		Expect(its["dl1.24xlarge"].Requirements.Get(v1.LabelInstanceNetworkCards).Values()).To(ConsistOf("4"))
+		Expect(its["dl1.24xlarge"].Requirements.Get(v1.LabelInstanceNetworkInterfaces).Values()).To(ConsistOf("60"))
+	})
+	It("should not advertise EBS performance unless enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseEBSPerformance: lo.ToPtr(false)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity).ToNot(HaveKey(v1.ResourceEBSThroughput))
+			Expect(it.Capacity).ToNot(HaveKey(v1.ResourceEBSIOPS))
+		}
+	})
+	It("should advertise the baseline EBS throughput and IOPS of the instance type", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseEBSPerformance: lo.ToPtr(true)}}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		its := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		Expect(its["inf2.xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourceEBSThroughput, resource.MustParse("156")))
+		Expect(its["inf2.xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourceEBSIOPS, resource.MustParse("6000")))
+		Expect(its["inf2.xlarge"].Requirements.Get(v1.LabelInstanceEBSBaselineThroughput).Values()).To(ConsistOf("156"))
+		Expect(its["inf2.xlarge"].Requirements.Get(v1.LabelInstanceEBSMaximumThroughput).Values()).To(ConsistOf("1250"))
+	})
+	It("should launch instances for EBS throughput requirements", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceEBSBaselineThroughput, Operator: corev1.NodeSelectorOpGt, Values: []string{"1000"}},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(strconv.Atoi(node.Labels[v1.LabelInstanceEBSBaselineThroughput])).To(BeNumerically(">", 1000))
+	})
+	It("should launch multi-network card instance types for network card requirements", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.LabelInstanceNetworkCards, Operator: corev1.NodeSelectorOpGt, Values: []string{"1"}},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(BeElementOf("dl1.24xlarge", "m6idn.32xlarge"))
+	})
+	It("should launch instances for networking.k8s.aws/network-card resource requests", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseNetworkCards: lo.ToPtr(true)}}))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{v1.ResourceNetworkCard: resource.MustParse("4")},
+				Limits:   corev1.ResourceList{v1.ResourceNetworkCard: resource.MustParse("4")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("dl1.24xlarge"))
+	})
+	It("should launch instances for networking.k8s.aws/secondary-eni resource requests", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{AdvertiseSecondaryENIs: lo.ToPtr(true)}}))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{v1.ResourceSecondaryENI: resource.MustParse("2")},
+				Limits:   corev1.ResourceList{v1.ResourceSecondaryENI: resource.MustParse("2")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(BeElementOf("dl1.24xlarge", "m6idn.32xlarge"))
+	})
 	It("should launch instances for amd.com/gpu resource requests", func() {
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -988,6 +1218,57 @@ var _ = Describe("InstanceTypeProvider", func() {
 			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 110))
 		}
 	})
+	Context("Generated Data Gaps", func() {
+		var info ec2types.InstanceTypeInfo
+		newInstanceType := func(nodeClass *v1.EC2NodeClass) *corecloudprovider.InstanceType {
+			return instancetype.NewInstanceType(ctx,
+				info,
+				fake.DefaultRegion,
+				nodeClass.Spec.BlockDeviceMappings,
+				nodeClass.Spec.InstanceStorePolicy,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nodeClass.AMIFamily(),
+				nil,
+			)
+		}
+		BeforeEach(func() {
+			// An instance type which is only offered in a region opened after the generated data was last updated
+			info = lo.Must(lo.Find(fake.MakeInstances(), func(info ec2types.InstanceTypeInfo) bool { return info.InstanceType == "m5.large" }))
+			info.InstanceType = "m5.new-region"
+			info.NetworkInfo = lo.ToPtr(*info.NetworkInfo)
+			info.NetworkInfo.NetworkCards = []ec2types.NetworkCardInfo{{
+				NetworkCardIndex:         aws.Int32(0),
+				MaximumNetworkInterfaces: aws.Int32(3),
+				BaselineBandwidthInGbps:  aws.Float64(0.75),
+			}}
+		})
+		It("should derive the network bandwidth label from the network cards reported by EC2", func() {
+			it := newInstanceType(nodeClass)
+			Expect(it.Requirements.Get(v1.LabelInstanceNetworkBandwidth).Values()).To(ConsistOf("750"))
+		})
+		It("should not set the network bandwidth label when EC2 doesn't report the bandwidth", func() {
+			info.NetworkInfo.NetworkCards[0].BaselineBandwidthInGbps = nil
+			it := newInstanceType(nodeClass)
+			Expect(it.Requirements.Get(v1.LabelInstanceNetworkBandwidth).Len()).To(Equal(0))
+		})
+		It("should derive private IPv4 addresses from the addresses per interface reported by EC2", func() {
+			it := newInstanceType(windowsNodeClass)
+			Expect(it.Capacity).To(HaveKeyWithValue(v1.ResourcePrivateIPv4Address, resource.MustParse("9")))
+		})
+		It("should not advertise pod ENIs when trunking compatibility is unknown", func() {
+			it := newInstanceType(nodeClass)
+			Expect(it.Capacity).To(HaveKeyWithValue(v1.ResourceAWSPodENI, resource.MustParse("0")))
+		})
+		It("should not panic when EC2 doesn't report the default network card", func() {
+			info.NetworkInfo.NetworkCards = nil
+			Expect(instancetype.ENILimitedPods(ctx, info).Value()).To(BeNumerically("==", 0))
+		})
+	})
 	Context("Metrics", func() {
 		It("should expose vcpu metrics for instance types", func() {
 			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
@@ -1072,6 +1353,83 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	Context("Zone Types", func() {
+		BeforeEach(func() {
+			nodeClass.Status.Subnets = append(nodeClass.Status.Subnets, v1.Subnet{
+				ID:   "subnet-test4",
+				Zone: "test-zone-1a-local",
+			})
+		})
+		It("should label offerings with the type of their zone", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			for _, of := range it.Offerings {
+				zoneType := lo.Ternary(of.Requirements.Get(corev1.LabelTopologyZone).Any() == "test-zone-1a-local", v1.ZoneTypeLocalZone, v1.ZoneTypeAvailabilityZone)
+				Expect(of.Requirements.Get(v1.LabelTopologyZoneType).Any()).To(Equal(zoneType))
+			}
+			Expect(it.Requirements.Get(v1.LabelTopologyZoneType).Values()).To(ConsistOf(v1.ZoneTypeAvailabilityZone, v1.ZoneTypeLocalZone))
+		})
+		It("should launch nodes into local zones with the zone type label", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{v1.LabelTopologyZoneType: v1.ZoneTypeLocalZone},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1a-local"))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZoneType, v1.ZoneTypeLocalZone))
+		})
+		It("should fall back to the spot price of the parent zone in local zones", func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "m5.large",
+						SpotPrice:        aws.String("0.004"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			of, ok := lo.Find(it.Offerings, func(of corecloudprovider.Offering) bool {
+				return of.Requirements.Get(corev1.LabelTopologyZone).Any() == "test-zone-1a-local" && of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == karpv1.CapacityTypeSpot
+			})
+			Expect(ok).To(BeTrue())
+			Expect(of.Available).To(BeTrue())
+			Expect(of.Price).To(BeNumerically("==", 0.004))
+		})
+		It("should not offer spot capacity in wavelength zones", func() {
+			awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
+				{ZoneName: aws.String("test-zone-1a"), ZoneId: aws.String("tstz1-1a"), ZoneType: aws.String(v1.ZoneTypeAvailabilityZone)},
+				{ZoneName: aws.String("test-zone-1a-wl1"), ZoneId: aws.String("tstz1-1awl1"), ZoneType: aws.String(v1.ZoneTypeWavelengthZone), ParentZoneName: aws.String("test-zone-1a")},
+			}})
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
+				{InstanceType: "m5.large", Location: aws.String("test-zone-1a")},
+				{InstanceType: "m5.large", Location: aws.String("test-zone-1a-wl1")},
+			}})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			nodeClass.Status.Subnets = []v1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}, {ID: "subnet-test5", Zone: "test-zone-1a-wl1"}}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			wavelength := lo.Filter(it.Offerings, func(of corecloudprovider.Offering, _ int) bool {
+				return of.Requirements.Get(corev1.LabelTopologyZone).Any() == "test-zone-1a-wl1"
+			})
+			Expect(wavelength).ToNot(BeEmpty())
+			for _, of := range wavelength {
+				Expect(of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+				Expect(of.Requirements.Get(v1.LabelTopologyZoneType).Any()).To(Equal(v1.ZoneTypeWavelengthZone))
+			}
+		})
+	})
 	Context("Overhead", func() {
 		var info ec2types.InstanceTypeInfo
 		BeforeEach(func() {
@@ -2207,6 +2565,113 @@ var _ = Describe("InstanceTypeProvider", func() {
 			Expect(node.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
 		})
 	})
+	Context("Capacity Blocks", func() {
+		BeforeEach(func() {
+			now := time.Now()
+			nodeClass.Spec.CapacityBlockSelectorTerms = []v1.CapacityBlockSelectorTerm{{Tags: map[string]string{"team": "ml"}}}
+			nodeClass.Status.CapacityBlocks = []v1.CapacityBlock{
+				{
+					ID:                     "cr-test1",
+					InstanceType:           "m5.large",
+					Zone:                   "test-zone-1a",
+					State:                  "active",
+					StartTime:              metav1.NewTime(now.Add(-time.Hour)),
+					EndTime:                metav1.NewTime(now.Add(24 * time.Hour)),
+					AvailableInstanceCount: 2,
+				},
+				{
+					ID:                     "cr-test2",
+					InstanceType:           "m5.large",
+					Zone:                   "test-zone-1b",
+					State:                  "scheduled",
+					StartTime:              metav1.NewTime(now.Add(24 * time.Hour)),
+					EndTime:                metav1.NewTime(now.Add(48 * time.Hour)),
+					AvailableInstanceCount: 2,
+				},
+				{
+					ID:                     "cr-test3",
+					InstanceType:           "m5.large",
+					Zone:                   "test-zone-1c",
+					State:                  "active",
+					StartTime:              metav1.NewTime(now.Add(-24 * time.Hour)),
+					EndTime:                metav1.NewTime(now.Add(30 * time.Minute)),
+					AvailableInstanceCount: 2,
+				},
+			}
+			nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeCapacityBlock}}},
+			}
+		})
+		It("should create capacity block offerings for the capacity blocks in the nodeClass status", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			offerings := it.Offerings.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeCapacityBlock)))
+			Expect(offerings).To(HaveLen(3))
+			for _, offering := range offerings {
+				capacityBlock, ok := lo.Find(nodeClass.Status.CapacityBlocks, func(cb v1.CapacityBlock) bool {
+					return cb.ID == offering.Requirements.Get(v1.LabelCapacityBlockID).Any()
+				})
+				Expect(ok).To(BeTrue())
+				Expect(offering.Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal(capacityBlock.Zone))
+				Expect(offering.Requirements.Get(v1.LabelTopologyZoneID).Any()).To(Equal(strings.Replace(capacityBlock.Zone, "test-zone-", "tstz1-", 1)))
+				Expect(offering.Price).To(BeNumerically("==", 0))
+				// Only the active capacity block which isn't about to end can be launched into
+				Expect(offering.Available).To(Equal(capacityBlock.ID == "cr-test1"))
+			}
+			other, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(other.Offerings.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeCapacityBlock)))).To(BeEmpty())
+		})
+		It("should launch instances into a capacity block", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, v1.CapacityTypeCapacityBlock))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelCapacityBlockID, "cr-test1"))
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "m5.large"))
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1a"))
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(ec2types.DefaultTargetCapacityTypeCapacityBlock))
+			Expect(createFleetInput.SpotOptions).To(BeNil())
+			Expect(createFleetInput.OnDemandOptions).To(BeNil())
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.InstanceMarketOptions.MarketType).To(Equal(ec2types.MarketTypeCapacityBlock))
+				Expect(aws.ToString(ltInput.LaunchTemplateData.CapacityReservationSpecification.CapacityReservationTarget.CapacityReservationId)).To(Equal("cr-test1"))
+			})
+		})
+		It("should not launch instances into capacity blocks which haven't started", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeRequirements: []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1b"}}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not launch instances into capacity blocks which are about to end", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeRequirements: []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1c"}}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not create capacity block offerings without capacity blocks in the nodeClass status", func() {
+			nodeClass.Status.CapacityBlocks = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			for _, it := range instanceTypes {
+				Expect(it.Offerings.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeCapacityBlock)))).To(BeEmpty())
+			}
+		})
+	})
 	Context("Ephemeral Storage", func() {
 		BeforeEach(func() {
 			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
@@ -2286,6 +2751,116 @@ var _ = Describe("InstanceTypeProvider", func() {
 			})
 		})
 	})
+	Context("Instance Store Encryption", func() {
+		BeforeEach(func() {
+			// Replace the instance store of one instance type with one that doesn't support encryption
+			output, err := awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			instanceTypes := lo.Map(output.InstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) ec2types.InstanceTypeInfo {
+				if info.InstanceType == "m6idn.32xlarge" {
+					instanceStorageInfo := *info.InstanceStorageInfo
+					instanceStorageInfo.EncryptionSupport = ec2types.InstanceStorageEncryptionSupportUnsupported
+					info.InstanceStorageInfo = &instanceStorageInfo
+				}
+				return info
+			})
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instanceTypes})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		})
+		It("should exclude instance types whose instance store doesn't support encryption when encryption is required", func() {
+			nodeClass.Spec.InstanceStoreEncryption = lo.ToPtr(v1.InstanceStoreEncryptionRequired)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).To(BeNil())
+			names := lo.Map(its, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+			Expect(names).ToNot(ContainElement("m6idn.32xlarge"))
+			// Instance types with encrypted instance store and instance types without instance store are still included
+			Expect(names).To(ContainElements("dl1.24xlarge", "m5.large"))
+		})
+		It("should include instance types whose instance store doesn't support encryption when encryption isn't required", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).To(BeNil())
+			names := lo.Map(its, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+			Expect(names).To(ContainElements("m6idn.32xlarge", "dl1.24xlarge", "m5.large"))
+		})
+	})
+	Context("Nitro Enclaves", func() {
+		It("should only include instance types which support nitro enclaves when enclaves are enabled", func() {
+			nodeClass.Spec.EnclaveOptions = &v1.EnclaveOptions{Enabled: lo.ToPtr(true)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(its, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("c6g.large", "g4dn.8xlarge", "m5.xlarge", "m6idn.32xlarge"))
+		})
+		It("should include instance types which don't support nitro enclaves when enclaves aren't enabled", func() {
+			nodeClass.Spec.EnclaveOptions = &v1.EnclaveOptions{Enabled: lo.ToPtr(false)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(its, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ContainElements("m5.large", "t3.large"))
+		})
+		It("should schedule pods which require nitro enclaves onto instance types which support them", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelNitroEnclavesSupported: "true"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(BeElementOf("c6g.large", "g4dn.8xlarge", "m5.xlarge", "m6idn.32xlarge"))
+		})
+	})
+	Context("Outposts", func() {
+		outpostARN := "arn:aws:outposts:test-region:123456789012:outpost/op-0123456789abcdef0"
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeOutpostInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
+					{InstanceType: "m5.large", Location: lo.ToPtr(outpostARN), LocationType: ec2types.LocationTypeOutpost},
+					{InstanceType: "m5.xlarge", Location: lo.ToPtr(outpostARN), LocationType: ec2types.LocationTypeOutpost},
+				},
+			})
+			nodeClass.Spec.OutpostARN = lo.ToPtr(outpostARN)
+		})
+		It("should only offer the instance types which are configured on the outpost", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			available := lo.FilterMap(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) (string, bool) {
+				return it.Name, len(it.Offerings.Available()) > 0
+			})
+			Expect(available).To(ConsistOf("m5.large", "m5.xlarge"))
+		})
+		It("should only offer on-demand capacity at the regional on-demand price", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			for _, it := range instanceTypes {
+				for _, of := range it.Offerings {
+					Expect(of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+				}
+			}
+			// The regional price is kept as a relative cost, so that smaller instance types are preferred
+			prices := lo.SliceToMap(lo.Filter(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) bool {
+				return len(it.Offerings.Available()) > 0
+			}), func(it *corecloudprovider.InstanceType) (string, float64) {
+				return it.Name, it.Offerings.Available().Cheapest().Price
+			})
+			Expect(prices["m5.large"]).To(BeNumerically("==", lo.Must(awsEnv.PricingProvider.OnDemandPrice("m5.large"))))
+			Expect(prices["m5.large"]).To(BeNumerically("<", prices["m5.xlarge"]))
+		})
+		It("should refresh the instance types of the outpost with the offerings of the region", func() {
+			_, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			awsEnv.EC2API.DescribeOutpostInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
+					{InstanceType: "m5.large", Location: lo.ToPtr(outpostARN), LocationType: ec2types.LocationTypeOutpost},
+				},
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			available := lo.FilterMap(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) (string, bool) {
+				return it.Name, len(it.Offerings.Available()) > 0
+			})
+			Expect(available).To(ConsistOf("m5.large"))
+		})
+	})
 	Context("Metadata Options", func() {
 		It("should default metadata options on generated launch template", func() {
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -53,9 +54,13 @@ var (
 )
 
 type ZoneData struct {
-	Name      string
-	ID        string
-	Available bool
+	Name string
+	ID   string
+	// Type is the zone type of EC2, which distinguishes Local Zones and Wavelength Zones from Availability Zones
+	Type string
+	// ParentName is the zone that a Local Zone or Wavelength Zone is anchored to
+	ParentName string
+	Available  bool
 }
 
 type Resolver interface {
@@ -86,12 +91,18 @@ func (d *DefaultResolver) CacheKey(nodeClass *v1.EC2NodeClass) string {
 	}
 	kcHash, _ := hashstructure.Hash(kc, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	blockDeviceMappingsHash, _ := hashstructure.Hash(nodeClass.Spec.BlockDeviceMappings, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
-	return fmt.Sprintf("%016x-%016x-%s-%s-%d",
+	// Capacity blocks become launchable and stop being launchable over time, so the launchable capacity blocks are
+	// part of the key rather than the capacity blocks themselves
+	capacityBlocksHash, _ := hashstructure.Hash(launchableCapacityBlocks(nodeClass, time.Now()), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	return fmt.Sprintf("%016x-%016x-%016x-%s-%s-%d-%d-%s",
 		kcHash,
 		blockDeviceMappingsHash,
+		capacityBlocksHash,
 		lo.FromPtr((*string)(nodeClass.Spec.InstanceStorePolicy)),
 		nodeClass.AMIFamily(),
 		d.unavailableOfferings.SeqNum,
+		nodeClass.PlacementGroupPartitions(),
+		lo.FromPtr(nodeClass.Spec.OutpostARN),
 	)
 }
 
@@ -104,8 +115,15 @@ func (d *DefaultResolver) Resolve(ctx context.Context, info ec2types.InstanceTyp
 	if nodeClass.Spec.Kubelet != nil {
 		kc = nodeClass.Spec.Kubelet
 	}
-	return NewInstanceType(ctx, info, d.region, nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy, kc.MaxPods, kc.PodsPerCore, kc.KubeReserved,
-		kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft, nodeClass.AMIFamily(), d.createOfferings(ctx, info, zoneData))
+	it := NewInstanceType(ctx, info, d.region, nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy, kc.MaxPods, kc.PodsPerCore, kc.KubeReserved,
+		kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft, nodeClass.AMIFamily(), append(d.createOfferings(ctx, info, zoneData, nodeClass), d.createCapacityBlockOfferings(info, zoneData, nodeClass)...))
+	// Advertise the partitions of the placement group so that pods can spread across them
+	if partitions := nodeClass.PlacementGroupPartitions(); partitions > 0 {
+		it.Requirements.Add(scheduling.NewRequirement(v1.LabelPlacementPartition, corev1.NodeSelectorOpIn, lo.Times(int(partitions), func(i int) string {
+			return fmt.Sprint(i + 1)
+		})...))
+	}
+	return it
 }
 
 // createOfferings creates a set of mutually exclusive offerings for a given instance type. This provider maintains an
@@ -117,7 +135,7 @@ func (d *DefaultResolver) Resolve(ctx context.Context, info ec2types.InstanceTyp
 // offering, you can do the following thanks to this invariant:
 //
 //	offering.Requirements.Get(v1.TopologyLabelZone).Any()
-func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData) []cloudprovider.Offering {
+func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData, nodeClass *v1.EC2NodeClass) []cloudprovider.Offering {
 	var offerings []cloudprovider.Offering
 	for _, zone := range zoneData {
 		// while usage classes should be a distinct set, there's no guarantee of that
@@ -126,13 +144,27 @@ func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2t
 			isUnavailable := d.unavailableOfferings.IsUnavailable(instanceType.InstanceType, zone.Name, string(capacityType))
 			var price float64
 			var ok bool
-			switch capacityType {
-			case ec2types.UsageClassTypeSpot:
+			switch {
+			case nodeClass.Spec.OutpostARN != nil && capacityType != ec2types.UsageClassTypeOnDemand:
+				// Outposts only support on-demand capacity. It's paid for upfront, but the offerings keep the regional
+				// on-demand price as a relative cost, so that smaller instance types are still preferred by launches and
+				// consolidation.
+				continue
+			case capacityType == ec2types.UsageClassTypeSpot:
+				// Wavelength Zones don't support spot instances
+				if zone.Type == v1.ZoneTypeWavelengthZone {
+					continue
+				}
 				price, ok = d.pricingProvider.SpotPrice(instanceType.InstanceType, zone.Name)
-			case ec2types.UsageClassTypeOnDemand:
+				// Spot prices aren't always published for Local Zones, so the price of the parent zone is used as an
+				// estimate rather than leaving the offering unavailable
+				if !ok && zone.ParentName != "" {
+					price, ok = d.pricingProvider.SpotPrice(instanceType.InstanceType, zone.ParentName)
+				}
+			case capacityType == ec2types.UsageClassTypeOnDemand:
 				price, ok = d.pricingProvider.OnDemandPrice(instanceType.InstanceType)
-			case "capacity-block":
-				// ignore since karpenter doesn't support it yet, but do not log an unknown capacity type error
+			case capacityType == v1.CapacityTypeCapacityBlock:
+				// capacity-block offerings are only created for the capacity blocks which are selected by the EC2NodeClass
 				continue
 			default:
 				log.FromContext(ctx).WithValues("capacity-type", capacityType, "instance-type", instanceType.InstanceType).Error(fmt.Errorf("received unknown capacity type"), "failed parsing offering")
@@ -150,12 +182,58 @@ func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2t
 			if zone.ID != "" {
 				offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zone.ID))
 			}
+			if zone.Type != "" {
+				offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zone.Type))
+			}
 			offerings = append(offerings, offering)
 		}
 	}
 	return offerings
 }
 
+// createCapacityBlockOfferings creates an offering for each capacity block of the EC2NodeClass which reserves the
+// instance type. Capacity blocks are paid for upfront, so their offerings have no price. The offerings are only
+// available while instances can be launched into the capacity blocks. Since there can be multiple capacity blocks for
+// an instance type in a zone, the offerings are kept mutually exclusive by the capacity block id requirement.
+func (d *DefaultResolver) createCapacityBlockOfferings(instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData, nodeClass *v1.EC2NodeClass) []cloudprovider.Offering {
+	var offerings []cloudprovider.Offering
+	launchable := sets.New(launchableCapacityBlocks(nodeClass, time.Now())...)
+	for _, capacityBlock := range nodeClass.Status.CapacityBlocks {
+		if capacityBlock.InstanceType != string(instanceType.InstanceType) {
+			continue
+		}
+		zone, ok := lo.Find(zoneData, func(z ZoneData) bool { return z.Name == capacityBlock.Zone })
+		if !ok {
+			continue
+		}
+		isUnavailable := d.unavailableOfferings.IsUnavailable(instanceType.InstanceType, zone.Name, v1.CapacityTypeCapacityBlock)
+		offering := cloudprovider.Offering{
+			Requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeCapacityBlock),
+				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone.Name),
+				scheduling.NewRequirement(v1.LabelCapacityBlockID, corev1.NodeSelectorOpIn, capacityBlock.ID),
+			),
+			Available: !isUnavailable && zone.Available && launchable.Has(capacityBlock.ID),
+		}
+		if zone.ID != "" {
+			offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zone.ID))
+		}
+		if zone.Type != "" {
+			offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zone.Type))
+		}
+		offerings = append(offerings, offering)
+	}
+	return offerings
+}
+
+// launchableCapacityBlocks returns the ids of the capacity blocks of the EC2NodeClass which instances can be launched
+// into at the given time
+func launchableCapacityBlocks(nodeClass *v1.EC2NodeClass, now time.Time) []string {
+	return lo.FilterMap(nodeClass.Status.CapacityBlocks, func(capacityBlock v1.CapacityBlock, _ int) (string, bool) {
+		return capacityBlock.ID, capacityBlock.Launchable(now)
+	})
+}
+
 func NewInstanceType(ctx context.Context, info ec2types.InstanceTypeInfo, region string,
 	blockDeviceMappings []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, maxPods *int32, podsPerCore *int32,
 	kubeReserved map[string]string, systemReserved map[string]string, evictionHard map[string]string, evictionSoft map[string]string,
@@ -174,7 +252,7 @@ func NewInstanceType(ctx context.Context, info ec2types.InstanceTypeInfo, region
 		},
 	}
 	if it.Requirements.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(corev1.LabelOSStable, corev1.NodeSelectorOpIn, string(corev1.Windows)))) == nil {
-		it.Capacity[v1.ResourcePrivateIPv4Address] = *privateIPv4Address(string(info.InstanceType))
+		it.Capacity[v1.ResourcePrivateIPv4Address] = *privateIPv4Address(info)
 	}
 	return it
 }
@@ -201,7 +279,13 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 		scheduling.NewRequirement(v1.LabelInstanceCPUSustainedClockSpeedMhz, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceMemory, corev1.NodeSelectorOpIn, fmt.Sprint(lo.FromPtr(info.MemoryInfo.SizeInMiB))),
 		scheduling.NewRequirement(v1.LabelInstanceEBSBandwidth, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceEBSBaselineThroughput, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceEBSMaximumThroughput, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceEBSBaselineIOPS, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceEBSMaximumIOPS, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceNetworkBandwidth, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceNetworkCards, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceNetworkInterfaces, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceCategory, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceFamily, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceGeneration, corev1.NodeSelectorOpDoesNotExist),
@@ -215,7 +299,10 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 		scheduling.NewRequirement(v1.LabelInstanceAcceleratorManufacturer, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceAcceleratorCount, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceHypervisor, corev1.NodeSelectorOpIn, string(info.Hypervisor)),
+		scheduling.NewRequirement(v1.LabelInstanceBareMetal, corev1.NodeSelectorOpIn, fmt.Sprint(aws.ToBool(info.BareMetal))),
 		scheduling.NewRequirement(v1.LabelInstanceEncryptionInTransitSupported, corev1.NodeSelectorOpIn, fmt.Sprint(aws.ToBool(info.NetworkInfo.EncryptionInTransitSupported))),
+		scheduling.NewRequirement(v1.LabelNitroEnclavesSupported, corev1.NodeSelectorOpIn, fmt.Sprint(info.NitroEnclavesSupport == ec2types.NitroEnclavesSupportSupported)),
+		scheduling.NewRequirement(v1.LabelInstanceNetworkAcceleration, corev1.NodeSelectorOpDoesNotExist),
 	)
 	// Only add zone-id label when available in offerings. It may not be available if a user has upgraded from a
 	// previous version of Karpenter w/o zone-id support and the nodeclass subnet status has not yet updated.
@@ -225,6 +312,12 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 	}); len(zoneIDs) != 0 {
 		requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zoneIDs...))
 	}
+	// Zone types are only known once the zones of the region have been described
+	if zoneTypes := lo.FilterMap(offerings.Available(), func(o cloudprovider.Offering, _ int) (string, bool) {
+		return o.Requirements.Get(v1.LabelTopologyZoneType).Any(), o.Requirements.Has(v1.LabelTopologyZoneType)
+	}); len(zoneTypes) != 0 {
+		requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zoneTypes...))
+	}
 	// Instance Type Labels
 	instanceFamilyParts := instanceTypeScheme.FindStringSubmatch(string(info.InstanceType))
 	if len(instanceFamilyParts) == 4 {
@@ -239,9 +332,20 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 	if info.InstanceStorageInfo != nil && info.InstanceStorageInfo.NvmeSupport != ec2types.EphemeralNvmeSupportUnsupported && info.InstanceStorageInfo.TotalSizeInGB != nil {
 		requirements[v1.LabelInstanceLocalNVME].Insert(fmt.Sprint(lo.FromPtr(info.InstanceStorageInfo.TotalSizeInGB)))
 	}
-	// Network bandwidth
-	if bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]; ok {
-		requirements[v1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth))
+	// Network bandwidth, falling back to the bandwidth reported by EC2 for instance types missing from the generated table
+	if bandwidth := networkBandwidth(info); !bandwidth.IsZero() {
+		requirements[v1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth.Value()))
+	}
+	// Network card topology, so that multi-network card instance types (e.g. p5 and trn1) can be selected
+	if cards := networkCards(info); !cards.IsZero() {
+		requirements[v1.LabelInstanceNetworkCards].Insert(fmt.Sprint(cards.Value()))
+	}
+	if info.NetworkInfo != nil && info.NetworkInfo.MaximumNetworkInterfaces != nil {
+		requirements[v1.LabelInstanceNetworkInterfaces].Insert(fmt.Sprint(lo.FromPtr(info.NetworkInfo.MaximumNetworkInterfaces)))
+	}
+	// Network Acceleration, an instance type may support multiple network acceleration technologies
+	if values := networkAcceleration(info); len(values) != 0 {
+		requirements.Get(v1.LabelInstanceNetworkAcceleration).Insert(values...)
 	}
 	// GPU Labels
 	if info.GpuInfo != nil && len(info.GpuInfo.Gpus) == 1 {
@@ -282,9 +386,43 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 	if info.EbsInfo != nil && info.EbsInfo.EbsOptimizedInfo != nil && info.EbsInfo.EbsOptimizedSupport == ec2types.EbsOptimizedSupportDefault {
 		requirements.Get(v1.LabelInstanceEBSBandwidth).Insert(fmt.Sprint(lo.FromPtr(info.EbsInfo.EbsOptimizedInfo.MaximumBandwidthInMbps)))
 	}
+	// EBS Throughput and IOPS - throughput is rounded down to whole MB/s to support Gt and Lt operators
+	if info.EbsInfo != nil && info.EbsInfo.EbsOptimizedInfo != nil {
+		ebs := info.EbsInfo.EbsOptimizedInfo
+		if ebs.BaselineThroughputInMBps != nil {
+			requirements.Get(v1.LabelInstanceEBSBaselineThroughput).Insert(fmt.Sprint(int64(math.Floor(*ebs.BaselineThroughputInMBps))))
+		}
+		if ebs.MaximumThroughputInMBps != nil {
+			requirements.Get(v1.LabelInstanceEBSMaximumThroughput).Insert(fmt.Sprint(int64(math.Floor(*ebs.MaximumThroughputInMBps))))
+		}
+		if ebs.BaselineIops != nil {
+			requirements.Get(v1.LabelInstanceEBSBaselineIOPS).Insert(fmt.Sprint(*ebs.BaselineIops))
+		}
+		if ebs.MaximumIops != nil {
+			requirements.Get(v1.LabelInstanceEBSMaximumIOPS).Insert(fmt.Sprint(*ebs.MaximumIops))
+		}
+	}
 	return requirements
 }
 
+// networkAcceleration returns the SR-IOV based network acceleration technologies supported by the instance type
+func networkAcceleration(info ec2types.InstanceTypeInfo) []string {
+	if info.NetworkInfo == nil {
+		return nil
+	}
+	var values []string
+	if info.NetworkInfo.EnaSupport == ec2types.EnaSupportSupported || info.NetworkInfo.EnaSupport == ec2types.EnaSupportRequired {
+		values = append(values, v1.NetworkAccelerationENA)
+	}
+	if aws.ToBool(info.NetworkInfo.EnaSrdSupported) {
+		values = append(values, v1.NetworkAccelerationENAExpress)
+	}
+	if aws.ToBool(info.NetworkInfo.EfaSupported) {
+		values = append(values, v1.NetworkAccelerationEFA)
+	}
+	return values
+}
+
 func getOS(info ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily) []string {
 	if _, ok := amiFamily.(*amifamily.Windows); ok {
 		if getArchitecture(info) == karpv1.ArchitectureAmd64 {
@@ -321,6 +459,19 @@ func computeCapacity(ctx context.Context, info ec2types.InstanceTypeInfo, amiFam
 		v1.ResourceHabanaGaudi:          *habanaGaudis(info),
 		v1.ResourceEFA:                  *efas(info),
 	}
+	if options.FromContext(ctx).FeatureGates.AdvertiseNetworkBandwidth {
+		resourceList[v1.ResourceNetworkBandwidth] = *networkBandwidth(info)
+	}
+	if options.FromContext(ctx).FeatureGates.AdvertiseSecondaryENIs {
+		resourceList[v1.ResourceSecondaryENI] = *secondaryENIs(ctx, info)
+	}
+	if options.FromContext(ctx).FeatureGates.AdvertiseNetworkCards {
+		resourceList[v1.ResourceNetworkCard] = *networkCards(info)
+	}
+	if options.FromContext(ctx).FeatureGates.AdvertiseEBSPerformance {
+		resourceList[v1.ResourceEBSThroughput] = *ebsThroughput(info)
+		resourceList[v1.ResourceEBSIOPS] = *ebsIOPS(info)
+	}
 	return resourceList
 }
 
@@ -329,6 +480,10 @@ func cpu(info ec2types.InstanceTypeInfo) *resource.Quantity {
 }
 
 func memory(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quantity {
+	return memoryWithOverhead(info, options.FromContext(ctx).VMMemoryOverheadPercentFor(string(info.InstanceType)))
+}
+
+func memoryWithOverhead(info ec2types.InstanceTypeInfo, vmMemoryOverheadPercent float64) *resource.Quantity {
 	sizeInMib := *info.MemoryInfo.SizeInMiB
 	// Gravitons have an extra 64 MiB of cma reserved memory that we can't use
 	if len(info.ProcessorInfo.SupportedArchitectures) > 0 && info.ProcessorInfo.SupportedArchitectures[0] == "arm64" {
@@ -336,7 +491,7 @@ func memory(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quant
 	}
 	mem := resources.Quantity(fmt.Sprintf("%dMi", sizeInMib))
 	// Account for VM overhead in calculation
-	mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*options.FromContext(ctx).VMMemoryOverheadPercent/1024/1024)))))
+	mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*vmMemoryOverheadPercent/1024/1024)))))
 	return mem
 }
 
@@ -452,6 +607,72 @@ func efas(info ec2types.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(count))
 }
 
+// networkBandwidth returns the aggregate network bandwidth of the instance type in megabits per second. We prefer
+// the generated bandwidth table and fall back to the baseline bandwidth of each network card reported by EC2.
+func networkBandwidth(info ec2types.InstanceTypeInfo) *resource.Quantity {
+	if bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]; ok {
+		return resources.Quantity(fmt.Sprint(bandwidth))
+	}
+	bandwidth := int64(0)
+	if info.NetworkInfo != nil {
+		for _, card := range info.NetworkInfo.NetworkCards {
+			bandwidth += int64(math.Round(lo.FromPtr(card.BaselineBandwidthInGbps) * 1000))
+		}
+	}
+	return resources.Quantity(fmt.Sprint(bandwidth))
+}
+
+// secondaryENIs returns the number of network interfaces which can be attached to an instance for secondary pod
+// interfaces (e.g. Multus) without competing with the VPC CNI. The VPC CNI uses all ENIs on the default network card,
+// apart from those excluded by reserved-enis, so the available interfaces are the reserved ENIs on the default network
+// card plus every interface on the remaining network cards.
+func secondaryENIs(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quantity {
+	count := int64(0)
+	if info.NetworkInfo == nil {
+		return resources.Quantity(fmt.Sprint(count))
+	}
+	defaultCardIndex := lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex)
+	for _, card := range info.NetworkInfo.NetworkCards {
+		if lo.FromPtr(card.NetworkCardIndex) == defaultCardIndex {
+			// The primary network interface of the instance can never be used for a secondary pod interface
+			count += lo.Clamp(int64(options.FromContext(ctx).ReservedENIs), 0, int64(lo.FromPtr(card.MaximumNetworkInterfaces))-1)
+			continue
+		}
+		count += int64(lo.FromPtr(card.MaximumNetworkInterfaces))
+	}
+	return resources.Quantity(fmt.Sprint(count))
+}
+
+// networkCards returns the number of network cards of the instance type. Each network card has its own network
+// interfaces and bandwidth, which EFA and multi-NIC workloads use to spread traffic across the cards.
+func networkCards(info ec2types.InstanceTypeInfo) *resource.Quantity {
+	count := 0
+	if info.NetworkInfo != nil {
+		count = len(info.NetworkInfo.NetworkCards)
+	}
+	return resources.Quantity(fmt.Sprint(count))
+}
+
+// ebsThroughput returns the baseline EBS throughput of the instance type in MB/s, which the instance type can sustain
+// indefinitely, unlike its maximum throughput
+func ebsThroughput(info ec2types.InstanceTypeInfo) *resource.Quantity {
+	throughput := int64(0)
+	if info.EbsInfo != nil && info.EbsInfo.EbsOptimizedInfo != nil {
+		throughput = int64(math.Floor(lo.FromPtr(info.EbsInfo.EbsOptimizedInfo.BaselineThroughputInMBps)))
+	}
+	return resources.Quantity(fmt.Sprint(throughput))
+}
+
+// ebsIOPS returns the baseline EBS IOPS of the instance type, which the instance type can sustain indefinitely, unlike
+// its maximum IOPS
+func ebsIOPS(info ec2types.InstanceTypeInfo) *resource.Quantity {
+	iops := int32(0)
+	if info.EbsInfo != nil && info.EbsInfo.EbsOptimizedInfo != nil {
+		iops = lo.FromPtr(info.EbsInfo.EbsOptimizedInfo.BaselineIops)
+	}
+	return resources.Quantity(fmt.Sprint(iops))
+}
+
 func ENILimitedPods(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quantity {
 	// The number of pods per node is calculated using the formula:
 	// max number of ENIs * (IPv4 Addresses per ENI -1) + 2
@@ -459,22 +680,34 @@ func ENILimitedPods(ctx context.Context, info ec2types.InstanceTypeInfo) *resour
 
 	// VPC CNI only uses the default network interface
 	// https://github.com/aws/amazon-vpc-cni-k8s/blob/3294231c0dce52cfe473bf6c62f47956a3b333b6/scripts/gen_vpc_ip_limits.go#L162
-	networkInterfaces := *info.NetworkInfo.NetworkCards[*info.NetworkInfo.DefaultNetworkCardIndex].MaximumNetworkInterfaces
+	// Instance types in newly opened regions may be reported without complete network information
+	if info.NetworkInfo == nil || int(lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex)) >= len(info.NetworkInfo.NetworkCards) {
+		return resource.NewQuantity(0, resource.DecimalSI)
+	}
+	networkInterfaces := lo.FromPtr(info.NetworkInfo.NetworkCards[lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex)].MaximumNetworkInterfaces)
 	usableNetworkInterfaces := lo.Max([]int64{int64(int(networkInterfaces) - options.FromContext(ctx).ReservedENIs), 0})
 	if usableNetworkInterfaces == 0 {
 		return resource.NewQuantity(0, resource.DecimalSI)
 	}
-	addressesPerInterface := *info.NetworkInfo.Ipv4AddressesPerInterface
+	// With prefix delegation, each secondary IPv4 address slot of an ENI is assigned a /28 prefix rather than an address
+	if prefixDelegationFromContext(ctx) && supportsPrefixDelegation(info) {
+		return resources.Quantity(fmt.Sprint(prefixDelegationPods(info, usableNetworkInterfaces)))
+	}
+	addressesPerInterface := lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface)
 	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*(int64(addressesPerInterface)-1) + 2))
 }
 
-func privateIPv4Address(instanceTypeName string) *resource.Quantity {
+// privateIPv4Address prefers the generated limits and falls back to the IPv4 addresses per interface reported by EC2
+// for instance types missing from the generated limits
+func privateIPv4Address(info ec2types.InstanceTypeInfo) *resource.Quantity {
 	//https://github.com/aws/amazon-vpc-resource-controller-k8s/blob/ecbd6965a0100d9a070110233762593b16023287/pkg/provider/ip/provider.go#L297
-	limits, ok := Limits[instanceTypeName]
-	if !ok {
+	if limits, ok := Limits[string(info.InstanceType)]; ok {
+		return resources.Quantity(fmt.Sprint(limits.IPv4PerInterface - 1))
+	}
+	if info.NetworkInfo == nil || lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface) == 0 {
 		return resources.Quantity("0")
 	}
-	return resources.Quantity(fmt.Sprint(limits.IPv4PerInterface - 1))
+	return resources.Quantity(fmt.Sprint(lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface) - 1))
 }
 
 func systemReservedResources(systemReserved map[string]string) corev1.ResourceList {
@@ -0,0 +1,37 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+// GENERATED FILE. DO NOT EDIT DIRECTLY.
+// Update hack/code/lifecycle_gen/main.go and re-generate to edit
+
+var (
+	// PreviousGenerationFamilies are the instance families which EC2 reports as previous generation, or whose
+	// retirement has been announced, mapped to the reason that they're aging
+	PreviousGenerationFamilies = map[string]string{
+		"c1":  "previous generation",
+		"c3":  "previous generation",
+		"cc2": "previous generation",
+		"cr1": "previous generation",
+		"g2":  "previous generation",
+		"hs1": "previous generation",
+		"i2":  "previous generation",
+		"m1":  "previous generation",
+		"m2":  "previous generation",
+		"m3":  "previous generation",
+		"r3":  "previous generation",
+		"t1":  "previous generation",
+	}
+)
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// MaxNodeLabelsBytes bounds the size of the labels that the kubelet registers the node with. The labels are rendered
+// into the user data of the launch template, which EC2 limits to 16 KB alongside the rest of the bootstrap configuration.
+const MaxNodeLabelsBytes = 4096
+
+// retainedNodeLabels are the system-generated labels which are never trimmed, since they're commonly used by
+// DaemonSets which need to schedule to the node as soon as it registers
+var retainedNodeLabels = sets.New(
+	karpv1.NodePoolLabelKey,
+	karpv1.CapacityTypeLabelKey,
+	corev1.LabelTopologyZone,
+	corev1.LabelTopologyRegion,
+	corev1.LabelInstanceTypeStable,
+	corev1.LabelArchStable,
+	corev1.LabelOSStable,
+)
+
+// nodeLabels returns the labels that the kubelet registers the node with, given the labels of the NodeClaim, along with
+// the system-generated labels which were trimmed to fit within MaxNodeLabelsBytes.
+func nodeLabels(labels map[string]string) (map[string]string, []string, error) {
+	// Remove any labels passed into userData that are prefixed with "node-restriction.kubernetes.io" or "kops.k8s.io" since the kubelet can't
+	// register the node with any labels from this domain: https://kubernetes.io/docs/reference/access-authn-authz/admission-controllers/#noderestriction
+	labels = lo.OmitBy(labels, func(k, _ string) bool {
+		labelDomain := karpv1.GetLabelDomain(k)
+		return strings.HasSuffix(labelDomain, corev1.LabelNamespaceNodeRestriction) || strings.HasSuffix(labelDomain, "kops.k8s.io")
+	})
+	trimmed, err := trimNodeLabels(labels)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labels, trimmed, nil
+}
+
+// trimNodeLabels removes system-generated labels from the labels that the kubelet registers the node with until they fit
+// within MaxNodeLabelsBytes, and returns the labels which were trimmed. Trimmed labels are still applied to the node,
+// since Karpenter syncs the labels of the NodeClaim to the node when it registers. An error is returned if the labels
+// don't fit after trimming every system-generated label, since the node would otherwise fail to launch or register.
+func trimNodeLabels(labels map[string]string) ([]string, error) {
+	size := nodeLabelsBytes(labels)
+	if size <= MaxNodeLabelsBytes {
+		return nil, nil
+	}
+	trimmable := lo.Filter(lo.Keys(labels), func(k string, _ int) bool {
+		return karpv1.WellKnownLabels.Has(k) && !retainedNodeLabels.Has(k)
+	})
+	sort.Strings(trimmable)
+	var trimmed []string
+	for _, k := range trimmable {
+		if size <= MaxNodeLabelsBytes {
+			break
+		}
+		size -= nodeLabelsBytes(map[string]string{k: labels[k]})
+		delete(labels, k)
+		trimmed = append(trimmed, k)
+	}
+	if size > MaxNodeLabelsBytes {
+		return nil, fmt.Errorf("node labels are %d bytes after trimming system-generated labels, exceeding the limit of %d bytes; reduce the labels of the NodePool", size, MaxNodeLabelsBytes)
+	}
+	return trimmed, nil
+}
+
+// nodeLabelsBytes returns the size of the labels when they're rendered as the kubelet's --node-labels argument
+func nodeLabelsBytes(labels map[string]string) int {
+	return lo.Sum(lo.MapToSlice(labels, func(k, v string) int { return len(k) + len(v) + len("=,") }))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -36,6 +37,7 @@ import (
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
@@ -47,6 +49,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
@@ -56,9 +59,17 @@ type Provider interface {
 	EnsureAll(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim,
 		[]*cloudprovider.InstanceType, string, map[string]string) ([]*LaunchTemplate, error)
 	DeleteAll(context.Context, *v1.EC2NodeClass) error
+	GarbageCollect(context.Context, time.Duration) (int, error)
+	Count(context.Context) (int, int, error)
 	InvalidateCache(context.Context, string, string)
+	InvalidateNodeClass(context.Context, *v1.EC2NodeClass)
 	ResolveClusterCIDR(context.Context) error
 }
+
+// Quota is the number of launch templates that an account can have in a region. It isn't adjustable through Service
+// Quotas, and launches fail once it's reached.
+const Quota = 5000
+
 type LaunchTemplate struct {
 	Name          string
 	InstanceTypes []*cloudprovider.InstanceType
@@ -73,29 +84,33 @@ type DefaultProvider struct {
 	securityGroupProvider securitygroup.Provider
 	subnetProvider        subnet.Provider
 	cache                 *cache.Cache
-	cm                    *pretty.ChangeMonitor
-	KubeDNSIP             net.IP
-	CABundle              *string
-	ClusterEndpoint       string
-	ClusterCIDR           atomic.Pointer[string]
-	ClusterIPFamily       corev1.IPFamily
+	// nodeClassLaunchTemplates tracks the names of the launch templates which were ensured for each EC2NodeClass, so
+	// that they can be evicted from the cache when the EC2NodeClass changes
+	nodeClassLaunchTemplates map[string]sets.Set[string]
+	cm                       *pretty.ChangeMonitor
+	KubeDNSIP                net.IP
+	CABundle                 *string
+	ClusterEndpoint          string
+	ClusterCIDR              atomic.Pointer[string]
+	ClusterIPFamily          corev1.IPFamily
 }
 
 func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api sdk.EC2API, eksapi sdk.EKSAPI, amiFamily amifamily.Resolver,
 	securityGroupProvider securitygroup.Provider, subnetProvider subnet.Provider,
 	caBundle *string, startAsync <-chan struct{}, kubeDNSIP net.IP, clusterEndpoint string) *DefaultProvider {
 	l := &DefaultProvider{
-		ec2api:                ec2api,
-		eksapi:                eksapi,
-		amiFamily:             amiFamily,
-		securityGroupProvider: securityGroupProvider,
-		subnetProvider:        subnetProvider,
-		cache:                 cache,
-		CABundle:              caBundle,
-		cm:                    pretty.NewChangeMonitor(),
-		KubeDNSIP:             kubeDNSIP,
-		ClusterEndpoint:       clusterEndpoint,
-		ClusterIPFamily:       lo.Ternary(kubeDNSIP != nil && kubeDNSIP.To4() == nil, corev1.IPv6Protocol, corev1.IPv4Protocol),
+		ec2api:                   ec2api,
+		eksapi:                   eksapi,
+		amiFamily:                amiFamily,
+		securityGroupProvider:    securityGroupProvider,
+		subnetProvider:           subnetProvider,
+		cache:                    cache,
+		nodeClassLaunchTemplates: map[string]sets.Set[string]{},
+		CABundle:                 caBundle,
+		cm:                       pretty.NewChangeMonitor(),
+		KubeDNSIP:                kubeDNSIP,
+		ClusterEndpoint:          clusterEndpoint,
+		ClusterIPFamily:          lo.Ternary(kubeDNSIP != nil && kubeDNSIP.To4() == nil, corev1.IPv6Protocol, corev1.IPv4Protocol),
 	}
 	l.cache.OnEvicted(l.cachedEvictedFunc(ctx))
 	go func() {
@@ -117,6 +132,18 @@ func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 	if err != nil {
 		return nil, err
 	}
+	options.LicenseConfigurationARN = nodeClaim.Annotations[v1.AnnotationLicenseConfigurationARN]
+	options.PlacementGroup = nodeClass.PlacementGroupName()
+	if nodeClass.PlacementGroupPartitions() > 0 {
+		partition, err := strconv.ParseInt(nodeClaim.Labels[v1.LabelPlacementPartition], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing placement partition, %w", err)
+		}
+		options.PlacementPartition = int32(partition)
+	}
+	if capacityType == v1.CapacityTypeCapacityBlock {
+		options.CapacityReservationID = scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelCapacityBlockID).Any()
+	}
 	resolvedLaunchTemplates, err := p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
 	if err != nil {
 		return nil, err
@@ -130,6 +157,10 @@ func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 		}
 		launchTemplates = append(launchTemplates, &LaunchTemplate{Name: *ec2LaunchTemplate.LaunchTemplateName, InstanceTypes: resolvedLaunchTemplate.InstanceTypes, ImageID: resolvedLaunchTemplate.AMIID})
 	}
+	if _, ok := p.nodeClassLaunchTemplates[nodeClass.Name]; !ok {
+		p.nodeClassLaunchTemplates[nodeClass.Name] = sets.New[string]()
+	}
+	p.nodeClassLaunchTemplates[nodeClass.Name].Insert(lo.Map(launchTemplates, func(lt *LaunchTemplate, _ int) string { return lt.Name })...)
 	return launchTemplates, nil
 }
 
@@ -143,17 +174,35 @@ func (p *DefaultProvider) InvalidateCache(ctx context.Context, ltName string, lt
 	log.FromContext(ctx).V(1).Info("invalidating launch template in the cache because it no longer exists")
 	p.cache.Delete(ltName)
 }
+
+// InvalidateNodeClass evicts the launch templates which were ensured for an EC2NodeClass from the cache, e.g. once the
+// EC2NodeClass has changed and launches no longer use them. The launch templates aren't deleted, since launches which
+// started before the change may still use them, and are garbage collected once they're no longer in use.
+func (p *DefaultProvider) InvalidateNodeClass(ctx context.Context, nodeClass *v1.EC2NodeClass) {
+	p.Lock()
+	defer p.Unlock()
+	names, ok := p.nodeClassLaunchTemplates[nodeClass.Name]
+	if !ok {
+		return
+	}
+	delete(p.nodeClassLaunchTemplates, nodeClass.Name)
+	defer p.cache.OnEvicted(p.cachedEvictedFunc(ctx))
+	p.cache.OnEvicted(nil)
+	for name := range names {
+		p.cache.Delete(name)
+	}
+	log.FromContext(ctx).WithValues("ec2nodeclass", nodeClass.Name, "count", names.Len()).V(1).Info("invalidated cached launch templates of changed ec2nodeclass")
+}
 func LaunchTemplateName(options *amifamily.LaunchTemplate) string {
 	return fmt.Sprintf("%s/%d", v1.LaunchTemplateNamePrefix, lo.Must(hashstructure.Hash(options, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})))
 }
 func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1.EC2NodeClass, labels, tags map[string]string) (*amifamily.Options, error) {
-	// Remove any labels passed into userData that are prefixed with "node-restriction.kubernetes.io" or "kops.k8s.io" since the kubelet can't
-	// register the node with any labels from this domain: https://kubernetes.io/docs/reference/access-authn-authz/admission-controllers/#noderestriction
-	for k := range labels {
-		labelDomain := karpv1.GetLabelDomain(k)
-		if strings.HasSuffix(labelDomain, corev1.LabelNamespaceNodeRestriction) || strings.HasSuffix(labelDomain, "kops.k8s.io") {
-			delete(labels, k)
-		}
+	labels, trimmed, err := nodeLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(trimmed) != 0 && p.cm.HasChanged(fmt.Sprintf("trimmed-node-labels/%s", nodeClass.Name), trimmed) {
+		log.FromContext(ctx).WithValues("ec2nodeclass", nodeClass.Name, "labels", trimmed).Info("trimmed system-generated labels from the kubelet's node labels, they'll be applied when the node registers")
 	}
 	// Relying on the status rather than an API call means that Karpenter is subject to a race
 	// condition where EC2NodeClass spec changes haven't propagated to the status once a node
@@ -166,18 +215,22 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1.EC
 		return nil, fmt.Errorf("no security groups are present in the status")
 	}
 	return &amifamily.Options{
-		ClusterName:              options.FromContext(ctx).ClusterName,
-		ClusterEndpoint:          p.ClusterEndpoint,
-		ClusterCIDR:              p.ClusterCIDR.Load(),
-		InstanceProfile:          nodeClass.Status.InstanceProfile,
-		InstanceStorePolicy:      nodeClass.Spec.InstanceStorePolicy,
-		SecurityGroups:           nodeClass.Status.SecurityGroups,
-		Tags:                     tags,
-		Labels:                   labels,
-		CABundle:                 p.CABundle,
-		KubeDNSIP:                p.KubeDNSIP,
-		AssociatePublicIPAddress: nodeClass.Spec.AssociatePublicIPAddress,
-		NodeClassName:            nodeClass.Name,
+		ClusterName:               options.FromContext(ctx).ClusterName,
+		ClusterEndpoint:           p.ClusterEndpoint,
+		ClusterCIDR:               p.ClusterCIDR.Load(),
+		InstanceProfile:           nodeClass.Status.InstanceProfile,
+		InstanceStorePolicy:       nodeClass.Spec.InstanceStorePolicy,
+		InstanceStoreSecureWipe:   lo.FromPtr(nodeClass.Spec.InstanceStoreSecureWipe),
+		KubeletConfigurationPatch: nodeClass.Spec.KubeletConfigurationPatch,
+		WindowsGMSA:               nodeClass.Spec.WindowsGMSA,
+		DNS:                       nodeClass.Spec.DNS,
+		SecurityGroups:            nodeClass.Status.SecurityGroups,
+		Tags:                      tags,
+		Labels:                    labels,
+		CABundle:                  p.CABundle,
+		KubeDNSIP:                 p.KubeDNSIP,
+		AssociatePublicIPAddress:  nodeClass.Spec.AssociatePublicIPAddress,
+		NodeClassName:             nodeClass.Name,
 	}, nil
 }
 
@@ -236,10 +289,13 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 			Monitoring: &ec2types.LaunchTemplatesMonitoringRequest{
 				Enabled: aws.Bool(options.DetailedMonitoring),
 			},
+			DisableApiTermination: aws.Bool(options.TerminationProtection),
+			EnclaveOptions:        lo.Ternary(options.EnclavesEnabled, &ec2types.LaunchTemplateEnclaveOptionsRequest{Enabled: aws.Bool(true)}, nil),
 			// If the network interface is defined, the security groups are defined within it
 			SecurityGroupIds: lo.Ternary(networkInterfaces != nil, nil, lo.Map(options.SecurityGroups, func(s v1.SecurityGroup, _ int) string { return s.ID })),
 			UserData:         aws.String(userData),
 			ImageId:          aws.String(options.AMIID),
+			KeyName:          lo.EmptyableToPtr(options.KeyName),
 			MetadataOptions: &ec2types.LaunchTemplateInstanceMetadataOptionsRequest{
 				HttpEndpoint:     ec2types.LaunchTemplateInstanceMetadataEndpointState(lo.FromPtr(options.MetadataOptions.HTTPEndpoint)),
 				HttpProtocolIpv6: ec2types.LaunchTemplateInstanceMetadataProtocolIpv6(lo.FromPtr(options.MetadataOptions.HTTPProtocolIPv6)),
@@ -254,13 +310,18 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 				// See https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/configuring-instance-metadata-options.html#instance-metadata-options-order-of-precedence
 				InstanceMetadataTags: ec2types.LaunchTemplateInstanceMetadataTagsStateDisabled,
 			},
-			NetworkInterfaces: networkInterfaces,
-			TagSpecifications: launchTemplateDataTags,
+			NetworkInterfaces:                networkInterfaces,
+			TagSpecifications:                launchTemplateDataTags,
+			LicenseSpecifications:            p.licenseSpecifications(options),
+			Placement:                        p.placement(options),
+			InstanceMarketOptions:            p.instanceMarketOptions(options),
+			CapacityReservationSpecification: p.capacityReservationSpecification(options),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
 			{
 				ResourceType: ec2types.ResourceTypeLaunchTemplate,
-				Tags:         utils.MergeTags(options.Tags),
+				// The owner tag fences garbage collection to the launch templates which Karpenter created for the cluster
+				Tags: utils.MergeTags(options.Tags, map[string]string{v1.LaunchTemplateOwnerTagKey: options.ClusterName}),
 			},
 		},
 	})
@@ -271,6 +332,50 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 	return lo.FromPtr(output.LaunchTemplate), nil
 }
 
+// licenseSpecifications associates instances launched from the launch template with the License Manager license
+// configuration requested by the NodePool, if any
+func (p *DefaultProvider) licenseSpecifications(options *amifamily.LaunchTemplate) []ec2types.LaunchTemplateLicenseConfigurationRequest {
+	if options.LicenseConfigurationARN == "" {
+		return nil
+	}
+	return []ec2types.LaunchTemplateLicenseConfigurationRequest{{LicenseConfigurationArn: aws.String(options.LicenseConfigurationARN)}}
+}
+
+// placement launches instances from the launch template into the EC2NodeClass' placement group, if any, and into the
+// assigned partition of partition placement groups
+func (p *DefaultProvider) placement(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplatePlacementRequest {
+	if options.PlacementGroup == "" {
+		return nil
+	}
+	placement := &ec2types.LaunchTemplatePlacementRequest{
+		GroupName: aws.String(options.PlacementGroup),
+	}
+	if options.PlacementPartition != 0 {
+		placement.PartitionNumber = aws.Int32(options.PlacementPartition)
+	}
+	return placement
+}
+
+// instanceMarketOptions launches instances from the launch template with the capacity-block market type when they're
+// launched into a capacity block
+func (p *DefaultProvider) instanceMarketOptions(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplateInstanceMarketOptionsRequest {
+	if options.CapacityReservationID == "" {
+		return nil
+	}
+	return &ec2types.LaunchTemplateInstanceMarketOptionsRequest{MarketType: ec2types.MarketTypeCapacityBlock}
+}
+
+// capacityReservationSpecification launches instances from the launch template into the capacity block selected for
+// the launch, if any
+func (p *DefaultProvider) capacityReservationSpecification(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplateCapacityReservationSpecificationRequest {
+	if options.CapacityReservationID == "" {
+		return nil
+	}
+	return &ec2types.LaunchTemplateCapacityReservationSpecificationRequest{
+		CapacityReservationTarget: &ec2types.CapacityReservationTarget{CapacityReservationId: aws.String(options.CapacityReservationID)},
+	}
+}
+
 // generateNetworkInterfaces generates network interfaces for the launch template.
 func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 	if options.EFACount != 0 {
@@ -417,6 +522,9 @@ func (p *DefaultProvider) DeleteAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 		}
 	}
 
+	p.Lock()
+	delete(p.nodeClassLaunchTemplates, nodeClass.Name)
+	p.Unlock()
 	var deleteErr error
 	for _, name := range ltNames {
 		_, err := p.ec2api.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateName: name})
@@ -430,6 +538,81 @@ func (p *DefaultProvider) DeleteAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 	}
 	return nil
 }
+
+// GarbageCollect deletes the launch templates which Karpenter created for the cluster, but which aren't in use and
+// weren't created within the grace period. Launch templates are normally deleted once they expire from the cache, but
+// they're leaked when the deletion fails or the cache isn't hydrated after a restart. Only launch templates which carry
+// the owner tag of the cluster and Karpenter's name prefix are deleted, so launch templates that users created are never
+// deleted. It returns the number of launch templates that were deleted.
+func (p *DefaultProvider) GarbageCollect(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	var launchTemplates []ec2types.LaunchTemplate
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1.LaunchTemplateOwnerTagKey)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("fetching launch templates, %w", err)
+		}
+		launchTemplates = append(launchTemplates, page.LaunchTemplates...)
+	}
+	// Launch templates are resolved and added to the cache while holding the lock, so the candidates are snapshotted
+	// under the lock, but deleted without it so that resolving launch templates isn't blocked for the whole pass. A
+	// launch template which is resolved again after the snapshot is recreated when the launch fails with not found.
+	p.Lock()
+	candidates := lo.Filter(launchTemplates, func(lt ec2types.LaunchTemplate, _ int) bool {
+		name := aws.ToString(lt.LaunchTemplateName)
+		if !strings.HasPrefix(name, v1.LaunchTemplateNamePrefix+"/") || lt.CreateTime == nil || time.Since(*lt.CreateTime) < gracePeriod {
+			return false
+		}
+		_, ok := p.cache.Get(name)
+		return !ok
+	})
+	p.Unlock()
+	var deleted []*string
+	var deleteErr error
+	for _, lt := range candidates {
+		if _, err := p.ec2api.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateName: lt.LaunchTemplateName}); awserrors.IgnoreNotFound(err) != nil {
+			deleteErr = multierr.Append(deleteErr, err)
+			continue
+		}
+		deleted = append(deleted, lt.LaunchTemplateName)
+	}
+	if len(deleted) > 0 {
+		log.FromContext(ctx).WithValues("launchTemplates", utils.PrettySlice(deleted, 5)).V(1).Info("garbage collected launch templates")
+	}
+	if deleteErr != nil {
+		return len(deleted), fmt.Errorf("deleting launch templates, %w", deleteErr)
+	}
+	return len(deleted), nil
+}
+
+// Count returns the number of launch templates in the region, which count towards the launch template Quota, and the
+// number of them which Karpenter created for the cluster
+func (p *DefaultProvider) Count(ctx context.Context) (int, int, error) {
+	clusterName := options.FromContext(ctx).ClusterName
+	var total, owned int
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("fetching launch templates, %w", err)
+		}
+		total += len(page.LaunchTemplates)
+		owned += lo.CountBy(page.LaunchTemplates, func(lt ec2types.LaunchTemplate) bool {
+			return lo.ContainsBy(lt.Tags, func(t ec2types.Tag) bool {
+				return aws.ToString(t.Key) == v1.LaunchTemplateOwnerTagKey && aws.ToString(t.Value) == clusterName
+			})
+		})
+	}
+	return total, owned, nil
+}
+
 func (p *DefaultProvider) ResolveClusterCIDR(ctx context.Context) error {
 	if p.ClusterCIDR.Load() != nil {
 		return nil
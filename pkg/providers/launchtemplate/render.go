@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchtemplate
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+// Cluster describes the cluster that user data is rendered for. These values are discovered by Karpenter when it
+// starts, and must be provided by tools which render user data outside of Karpenter.
+type Cluster struct {
+	Name      string
+	Endpoint  string
+	CIDR      *string
+	CABundle  *string
+	KubeDNSIP net.IP
+}
+
+// RenderUserData renders the user data that Karpenter launches an instance of the instance type for the NodeClaim
+// with, including the kubelet configuration and the bootstrap script of the EC2NodeClass's AMI family. The NodeClaim's
+// labels must include the capacity type that the instance is launched with, and the EC2NodeClass's status must include
+// its resolved AMIs. RenderUserData doesn't call any AWS APIs, so that tools outside of Karpenter can validate the
+// user data of an EC2NodeClass, e.g. by comparing it against golden files in CI.
+func RenderUserData(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceType *cloudprovider.InstanceType, cluster Cluster) (string, error) {
+	labels, _, err := nodeLabels(lo.Assign(nodeClaim.Labels))
+	if err != nil {
+		return "", err
+	}
+	nodeClass = nodeClass.DeepCopy()
+	nodeClass.SetDefaults(context.Background())
+	resolved, err := amifamily.NewDefaultResolver().Resolve(nodeClass, nodeClaim, []*cloudprovider.InstanceType{instanceType}, nodeClaim.Labels[karpv1.CapacityTypeLabelKey], &amifamily.Options{
+		ClusterName:               cluster.Name,
+		ClusterEndpoint:           cluster.Endpoint,
+		ClusterCIDR:               cluster.CIDR,
+		CABundle:                  cluster.CABundle,
+		KubeDNSIP:                 cluster.KubeDNSIP,
+		InstanceStorePolicy:       nodeClass.Spec.InstanceStorePolicy,
+		InstanceStoreSecureWipe:   lo.FromPtr(nodeClass.Spec.InstanceStoreSecureWipe),
+		KubeletConfigurationPatch: nodeClass.Spec.KubeletConfigurationPatch,
+		WindowsGMSA:               nodeClass.Spec.WindowsGMSA,
+		DNS:                       nodeClass.Spec.DNS,
+		Labels:                    labels,
+		NodeClassName:             nodeClass.Name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("resolving launch template, %w", err)
+	}
+	// A single instance type always resolves to a single launch template
+	userData, err := resolved[0].UserData.Script()
+	if err != nil {
+		return "", fmt.Errorf("rendering user data, %w", err)
+	}
+	return userData, nil
+}
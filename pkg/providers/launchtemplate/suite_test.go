@@ -39,6 +39,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -1370,6 +1371,40 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			ExpectLaunchTemplatesCreatedWithUserDataNotContaining(corev1.LabelNamespaceNodeRestriction)
 		})
+		It("should trim system-generated labels when the node labels exceed the size limit", func() {
+			nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, lo.SliceToMap(lo.Range(50), func(i int) (string, string) {
+				return fmt.Sprintf("label-%02d", i), strings.Repeat("v", 63)
+			}))
+			nodePool.Spec.Template.Spec.Requirements = lo.Map([]corev1.NodeSelectorRequirement{
+				{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}},
+				{Key: v1.LabelInstanceCategory, Operator: corev1.NodeSelectorOpIn, Values: []string{"m"}},
+				{Key: v1.LabelInstanceFamily, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5"}},
+				{Key: v1.LabelInstanceGeneration, Operator: corev1.NodeSelectorOpIn, Values: []string{"5"}},
+				{Key: v1.LabelInstanceSize, Operator: corev1.NodeSelectorOpIn, Values: []string{"large"}},
+				{Key: v1.LabelInstanceCPU, Operator: corev1.NodeSelectorOpIn, Values: []string{"2"}},
+				{Key: v1.LabelInstanceMemory, Operator: corev1.NodeSelectorOpIn, Values: []string{"8192"}},
+				{Key: v1.LabelInstanceHypervisor, Operator: corev1.NodeSelectorOpIn, Values: []string{"nitro"}},
+			}, func(r corev1.NodeSelectorRequirement, _ int) karpv1.NodeSelectorRequirementWithMinValues {
+				return karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: r}
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining(fmt.Sprintf("%s=%s", karpv1.NodePoolLabelKey, nodePool.Name))
+			ExpectLaunchTemplatesCreatedWithUserDataContaining(fmt.Sprintf("label-49=%s", strings.Repeat("v", 63)))
+			ExpectLaunchTemplatesCreatedWithUserDataNotContaining(v1.LabelInstanceCategory)
+		})
+		It("should fail to provision if the NodePool labels exceed the size limit", func() {
+			nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, lo.SliceToMap(lo.Range(60), func(i int) (string, string) {
+				return fmt.Sprintf("label-%02d", i), strings.Repeat("v", 63)
+			}))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+		})
 		It("should specify --local-disks raid0 when instance-store policy is set on AL2", func() {
 			nodeClass.Spec.InstanceStorePolicy = lo.ToPtr(v1.InstanceStorePolicyRAID0)
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -1380,6 +1415,30 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically("==", 5))
 			ExpectLaunchTemplatesCreatedWithUserDataContaining("--local-disks raid0")
 		})
+		It("should install the instance store secure wipe unit when instance-store secure wipe is enabled on AL2", func() {
+			nodeClass.Spec.InstanceStoreSecureWipe = lo.ToPtr(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("karpenter-instance-store-wipe.service")
+		})
+		It("should configure the DNS servers of the EC2NodeClass on AL2", func() {
+			nodeClass.Spec.DNS = &v1.DNS{Nameservers: []string{"10.0.0.2", "10.0.0.3"}, SearchDomains: []string{"corp.example.com"}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("nameserver 10.0.0.2\nnameserver 10.0.0.3\nsearch corp.example.com\n")
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("supersede domain-name-servers 10.0.0.2, 10.0.0.3;")
+		})
+		It("should not install the instance store secure wipe unit when instance-store secure wipe is not enabled on AL2", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataNotContaining("karpenter-instance-store-wipe.service")
+		})
 		It("should specify RAID0 bootstrap-command when instance-store policy is set on Bottlerocket", func() {
 			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
 			nodeClass.Spec.InstanceStorePolicy = lo.ToPtr(v1.InstanceStorePolicyRAID0)
@@ -1503,6 +1562,42 @@ essential = true
 					Expect(config.Settings.Kubernetes.SystemReserved[corev1.ResourceEphemeralStorage.String()]).To(Equal("10Gi"))
 				})
 			})
+			It("should merge the kubelet configuration patch into the kubernetes settings", func() {
+				nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(`{"cpu-manager-policy":"static","topology-manager-policy":"single-numa-node"}`)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.Kubernetes.ClusterName)).To(Equal("test-cluster"))
+					Expect(config.SettingsRaw["kubernetes"]).To(HaveKeyWithValue("cpu-manager-policy", "static"))
+					Expect(config.SettingsRaw["kubernetes"]).To(HaveKeyWithValue("topology-manager-policy", "single-numa-node"))
+				})
+			})
+			It("should not merge the kubelet settings which instance types are modeled with from the kubelet configuration patch", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					EvictionHard: map[string]string{"nodefs.available": "10%"},
+				}
+				nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(`{"max-pods":50,"eviction-hard":{"memory.available":"5%"}}`)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.Kubernetes.MaxPods)).ToNot(Equal(50))
+					Expect(config.Settings.Kubernetes.EvictionHard).To(Equal(map[string]string{"nodefs.available": "10%"}))
+				})
+			})
 			It("should override kube reserved values in user data", func() {
 				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 					KubeReserved: map[string]string{
@@ -1867,6 +1962,64 @@ essential = true
 					Expect(configs[0].Spec.Instance.LocalStorage.Strategy).To(Equal(admv1alpha1.LocalStorageRAID0))
 				}
 			})
+			It("should install the instance store secure wipe unit when instance-store secure wipe is enabled", func() {
+				nodeClass.Spec.InstanceStoreSecureWipe = lo.ToPtr(true)
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("karpenter-instance-store-wipe.service")
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(ExpectUserDataCreatedWithNodeConfigs(userData)).To(HaveLen(1))
+				}
+			})
+			It("should route every domain to the DNS servers of the EC2NodeClass", func() {
+				nodeClass.Spec.DNS = &v1.DNS{Nameservers: []string{"10.0.0.2"}, SearchDomains: []string{"corp.example.com"}}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("[Resolve]\nDNS=10.0.0.2\nDomains=~. corp.example.com\n")
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(ExpectUserDataCreatedWithNodeConfigs(userData)).To(HaveLen(1))
+				}
+			})
+			It("should merge the kubelet configuration patch into the kubelet configuration", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					ImageGCHighThresholdPercent: lo.ToPtr[int32](80),
+				}
+				nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(`{"cpuManagerPolicy":"static","imageGCHighThresholdPercent":null}`)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(configs).To(HaveLen(1))
+					Expect(configs[0].Spec.Kubelet.Config).ToNot(HaveKey("imageGCHighThresholdPercent"))
+					Expect(configs[0].Spec.Kubelet.Config).To(HaveKey("registerWithTaints"))
+					Expect(string(configs[0].Spec.Kubelet.Config["cpuManagerPolicy"].Raw)).To(Equal(`"static"`))
+				}
+			})
+			It("should not merge the kubelet settings which instance types are modeled with from the kubelet configuration patch", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					MaxPods:      lo.ToPtr[int32](110),
+					EvictionHard: map[string]string{"nodefs.available": "10%"},
+				}
+				nodeClass.Spec.KubeletConfigurationPatch = &apiextensionsv1.JSON{Raw: []byte(`{"maxPods":null,"evictionHard":{"memory.available":"5%"}}`)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(configs).To(HaveLen(1))
+					Expect(string(configs[0].Spec.Kubelet.Config["maxPods"].Raw)).To(Equal("110"))
+					evictionHard := map[string]string{}
+					Expect(json.Unmarshal(configs[0].Spec.Kubelet.Config["evictionHard"].Raw, &evictionHard)).To(Succeed())
+					Expect(evictionHard).To(Equal(map[string]string{"nodefs.available": "10%"}))
+				}
+			})
 			DescribeTable(
 				"should merge custom user data",
 				func(inputFile *string, mergedFile string) {
@@ -2027,7 +2180,7 @@ essential = true
 				nodeClass.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyCustom)
 				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass)
-				controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.InstanceProvider, awsEnv.VPCEndpointProvider, awsEnv.DHCPOptionsProvider, awsEnv.CapacityBlockProvider, awsEnv.PlacementGroupProvider, awsEnv.InstanceTypesProvider, awsEnv.QuotaProvider, fake.DefaultRegion, nil)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
 					{
@@ -2172,6 +2325,34 @@ essential = true
 				Expect(err).To(BeNil())
 				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), nodeClass.Name, karpv1.NodePoolLabelKey, nodePool.Name))
 			})
+			It("should configure the domain DNS settings when gMSA is specified", func() {
+				nodeClass.Spec.WindowsGMSA = &v1.WindowsGMSA{DomainName: "corp.example.com", DNSServers: []string{"10.0.0.10", "10.0.0.11"}}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"-ServerAddresses @('10.0.0.10','10.0.0.11')",
+					"Set-DnsClientGlobalSetting -SuffixSearchList ((Get-DnsClientGlobalSetting).SuffixSearchList + @('corp.example.com'))",
+				)
+			})
+			It("should not configure the domain DNS settings when gMSA isn't specified", func() {
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("Set-DnsClientServerAddress", "Set-DnsClientGlobalSetting")
+			})
 		})
 	})
 	Context("Detailed Monitoring", func() {
@@ -2197,6 +2378,88 @@ essential = true
 			})
 		})
 	})
+	Context("Termination Protection", func() {
+		It("should not set termination protection on the launch template by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.DisableApiTermination)).To(BeFalse())
+			})
+		})
+		It("should set termination protection on the launch templates of on-demand instances", func() {
+			nodeClass.Spec.TerminationProtection = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeRequirements: []corev1.NodeSelectorRequirement{
+				{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.DisableApiTermination)).To(BeTrue())
+			})
+		})
+		It("should not set termination protection on the launch templates of spot instances", func() {
+			nodeClass.Spec.TerminationProtection = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeRequirements: []corev1.NodeSelectorRequirement{
+				{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.DisableApiTermination)).To(BeFalse())
+			})
+		})
+	})
+	Context("Nitro Enclaves", func() {
+		It("should not enable nitro enclaves on the launch template by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.EnclaveOptions).To(BeNil())
+			})
+		})
+		It("should enable nitro enclaves on the launch template", func() {
+			nodeClass.Spec.EnclaveOptions = &v1.EnclaveOptions{Enabled: aws.Bool(true)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.EnclaveOptions.Enabled)).To(BeTrue())
+			})
+		})
+	})
+	Context("Key Pair", func() {
+		It("should not set a key pair on the launch template by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically("==", 5))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.KeyName).To(BeNil())
+			})
+		})
+		It("should pass the key pair to the launch template at creation", func() {
+			nodeClass.Spec.KeyName = aws.String("test-key-pair")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically("==", 5))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToString(ltInput.LaunchTemplateData.KeyName)).To(Equal("test-key-pair"))
+			})
+		})
+	})
 	Context("Instance Metadata", func() {
 		It("should set the default instance metadata settings on instances", func() {
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -2285,6 +2548,55 @@ essential = true
 			)
 		})
 	})
+	Context("RenderUserData", func() {
+		var nodeClaim *karpv1.NodeClaim
+		var instanceType *corecloudprovider.InstanceType
+		BeforeEach(func() {
+			awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+			nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						karpv1.NodePoolLabelKey:                        nodePool.Name,
+						karpv1.CapacityTypeLabelKey:                    karpv1.CapacityTypeOnDemand,
+						corev1.LabelInstanceTypeStable:                 "m5.large",
+						corev1.LabelNamespaceNodeRestriction + "/team": "team-1",
+						"example.com/label":                            "value",
+					},
+				},
+			})
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			instanceType, _ = lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(instanceType).ToNot(BeNil())
+		})
+		DescribeTable(
+			"should render the user data that the launch template is created with",
+			func(alias string) {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: alias}}
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](42)}
+				_, err := awsEnv.LaunchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, []*corecloudprovider.InstanceType{instanceType}, karpv1.CapacityTypeOnDemand, map[string]string{})
+				Expect(err).To(BeNil())
+				userData, err := launchtemplate.RenderUserData(nodeClass, nodeClaim, instanceType, launchtemplate.Cluster{
+					Name:      options.FromContext(ctx).ClusterName,
+					Endpoint:  awsEnv.LaunchTemplateProvider.ClusterEndpoint,
+					CIDR:      awsEnv.LaunchTemplateProvider.ClusterCIDR.Load(),
+					CABundle:  awsEnv.LaunchTemplateProvider.CABundle,
+					KubeDNSIP: awsEnv.LaunchTemplateProvider.KubeDNSIP,
+				})
+				Expect(err).To(BeNil())
+				Expect(userData).ToNot(ContainSubstring(corev1.LabelNamespaceNodeRestriction))
+				ExpectLaunchTemplatesCreatedWithUserData(userData)
+			},
+			Entry("AL2", "al2@latest"),
+			Entry("AL2023", "al2023@latest"),
+			Entry("Bottlerocket", "bottlerocket@latest"),
+		)
+		It("should fail when no AMI is compatible with the instance type", func() {
+			nodeClass.Status.AMIs = lo.Filter(nodeClass.Status.AMIs, func(ami v1.AMI, _ int) bool { return ami.ID == "ami-test4" })
+			_, err := launchtemplate.RenderUserData(nodeClass, nodeClaim, instanceType, launchtemplate.Cluster{Name: "test-cluster"})
+			Expect(err).ToNot(BeNil())
+		})
+	})
 })
 
 // ExpectTags verifies that the expected tags are a subset of the tags found
@@ -0,0 +1,97 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package license
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/licensemanager"
+	licensemanagertypes "github.com/aws/aws-sdk-go-v2/service/licensemanager/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type Provider interface {
+	Seats(context.Context, string) (Seats, error)
+	Invalidate(string)
+}
+
+// Seats describes the licenses which remain available within a License Manager license configuration
+type Seats struct {
+	CountingType licensemanagertypes.LicenseCountingType
+	// Remaining is nil when the license configuration doesn't limit the number of licenses
+	Remaining *int64
+}
+
+// Required returns the number of licenses consumed by launching an instance of the instance type. vCPU based license
+// configurations consume a license per vCPU. Every other counting type consumes at least one license per instance.
+func (s Seats) Required(instanceType *cloudprovider.InstanceType) int64 {
+	if s.CountingType == licensemanagertypes.LicenseCountingTypeVcpu {
+		return instanceType.Capacity.Cpu().Value()
+	}
+	return 1
+}
+
+// Allows returns true if enough licenses remain to launch an instance of the instance type
+func (s Seats) Allows(instanceType *cloudprovider.InstanceType) bool {
+	return s.Remaining == nil || s.Required(instanceType) <= lo.FromPtr(s.Remaining)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	licensemanagerapi sdk.LicenseManagerAPI
+	cache             *cache.Cache
+}
+
+func NewDefaultProvider(licensemanagerapi sdk.LicenseManagerAPI, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		licensemanagerapi: licensemanagerapi,
+		cache:             cache,
+	}
+}
+
+// Seats returns the licenses which remain available within the license configuration
+func (p *DefaultProvider) Seats(ctx context.Context, arn string) (Seats, error) {
+	p.Lock()
+	defer p.Unlock()
+	if seats, ok := p.cache.Get(arn); ok {
+		return seats.(Seats), nil
+	}
+	out, err := p.licensemanagerapi.GetLicenseConfiguration(ctx, &licensemanager.GetLicenseConfigurationInput{
+		LicenseConfigurationArn: aws.String(arn),
+	})
+	if err != nil {
+		return Seats{}, fmt.Errorf("getting license configuration %q, %w", arn, err)
+	}
+	seats := Seats{CountingType: out.LicenseCountingType}
+	if out.LicenseCount != nil {
+		seats.Remaining = lo.ToPtr(max(aws.ToInt64(out.LicenseCount)-aws.ToInt64(out.ConsumedLicenses), 0))
+		RemainingSeats.Set(float64(lo.FromPtr(seats.Remaining)), Labels(arn))
+	}
+	p.cache.SetDefault(arn, seats)
+	return seats, nil
+}
+
+// Invalidate drops the cached seats of the license configuration. This should be called after launching an instance
+// associated with the license configuration, since the launch consumes licenses.
+func (p *DefaultProvider) Invalidate(arn string) {
+	p.cache.Delete(arn)
+}
@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package license
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem    = "cloudprovider"
+	licenseConfigurationLabel = "license_configuration_arn"
+)
+
+var (
+	RemainingSeats = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "license_configuration_remaining_seats",
+			Help:      "Number of licenses which remain available within a License Manager license configuration. Labeled by license configuration ARN.",
+		},
+		[]string{licenseConfigurationLabel},
+	)
+	ExhaustedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "license_configuration_exhausted_total",
+			Help:      "Number of launches which were blocked because a License Manager license configuration had no remaining seats. Labeled by license configuration ARN.",
+		},
+		[]string{licenseConfigurationLabel},
+	)
+)
+
+// Labels returns the metric labels for the license configuration
+func Labels(arn string) map[string]string {
+	return map[string]string{licenseConfigurationLabel: arn}
+}
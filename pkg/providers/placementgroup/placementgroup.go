@@ -0,0 +1,102 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementgroup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type Provider interface {
+	List(context.Context, *v1.EC2NodeClass) ([]ec2types.PlacementGroup, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cache:  cache,
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+// List returns the placement groups which are selected by the placement group of the EC2NodeClass, in any state.
+// Instances are only launched into the placement group when exactly one is selected.
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.PlacementGroup, error) {
+	p.Lock()
+	defer p.Unlock()
+	if nodeClass.Spec.PlacementGroup == nil {
+		return nil, nil
+	}
+	// The group-name filter is used rather than GroupNames, which fails the request when the placement group doesn't exist
+	filters := getFilters(nodeClass.Spec.PlacementGroup)
+	hash, err := hashstructure.Hash(filters, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if placementGroups, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		return append([]ec2types.PlacementGroup{}, placementGroups.([]ec2types.PlacementGroup)...), nil
+	}
+	out, err := p.ec2api.DescribePlacementGroups(ctx, &ec2.DescribePlacementGroupsInput{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("describing placement groups, %w", err)
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), out.PlacementGroups)
+	names := lo.Map(out.PlacementGroups, func(pg ec2types.PlacementGroup, _ int) string { return aws.ToString(pg.GroupName) })
+	if p.cm.HasChanged(fmt.Sprintf("placement-groups/%s", nodeClass.Name), names) {
+		log.FromContext(ctx).WithValues("placement-groups", names).V(1).Info("discovered placement groups")
+	}
+	return append([]ec2types.PlacementGroup{}, out.PlacementGroups...), nil
+}
+
+func getFilters(placementGroup *v1.PlacementGroup) []ec2types.Filter {
+	if placementGroup.Name != "" {
+		return []ec2types.Filter{{Name: aws.String("group-name"), Values: []string{placementGroup.Name}}}
+	}
+	var filters []ec2types.Filter
+	for k, v := range placementGroup.Tags {
+		if v == "*" {
+			filters = append(filters, ec2types.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []string{k},
+			})
+		} else {
+			filters = append(filters, ec2types.Filter{
+				Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+				Values: []string{v},
+			})
+		}
+	}
+	return filters
+}
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+)
+
+// DocumentSuffix is the suffix of the ConfigMap keys which contain Cedar policies
+const DocumentSuffix = ".cedar"
+
+// permitAll is appended to every document so that launch policies only need to express the offerings which are denied
+const permitAll = "\npermit (principal, action, resource);\n"
+
+// Launch is a candidate offering of a launch which launch policies are evaluated over. Policies are evaluated with the
+// NodePool::"<name>" principal, the Action::"Launch" action, and the InstanceType::"<name>" resource. The remaining
+// attributes of the offering are available in the context.
+type Launch struct {
+	NodePool     string
+	NodeClass    string
+	InstanceType string
+	Zone         string
+	CapacityType string
+	Architecture string
+	SubnetID     string
+	PublicSubnet bool
+}
+
+func (l Launch) request() cedar.Request {
+	return cedar.Request{
+		Principal: cedar.NewEntityUID("NodePool", cedar.String(l.NodePool)),
+		Action:    cedar.NewEntityUID("Action", "Launch"),
+		Resource:  cedar.NewEntityUID("InstanceType", cedar.String(l.InstanceType)),
+		Context: cedar.NewRecord(cedar.RecordMap{
+			"nodeClass":      cedar.String(l.NodeClass),
+			"instanceType":   cedar.String(l.InstanceType),
+			"instanceFamily": cedar.String(strings.Split(l.InstanceType, ".")[0]),
+			"zone":           cedar.String(l.Zone),
+			"capacityType":   cedar.String(l.CapacityType),
+			"architecture":   cedar.String(l.Architecture),
+			"subnetID":       cedar.String(l.SubnetID),
+			"publicSubnet":   cedar.Boolean(l.PublicSubnet),
+		}),
+	}
+}
+
+type Provider interface {
+	Allowed(context.Context, Launch) bool
+}
+
+// DefaultProvider evaluates launch policies which are expressed centrally, outside of individual NodePools. Every
+// document is evaluated independently and an offering is denied if any document denies it.
+type DefaultProvider struct {
+	mu       sync.RWMutex
+	version  string
+	policies map[string]*cedar.PolicySet
+	cm       *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider() *DefaultProvider {
+	return &DefaultProvider{
+		policies: map[string]*cedar.PolicySet{},
+		cm:       pretty.NewChangeMonitor(),
+	}
+}
+
+// Update replaces the launch policies with the policies parsed from the documents. The documents are only parsed when
+// the version changes. If any document fails to parse, the previous launch policies are retained.
+func (p *DefaultProvider) Update(ctx context.Context, version string, documents map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if version == p.version {
+		return nil
+	}
+	policies := map[string]*cedar.PolicySet{}
+	var errs error
+	for name, document := range documents {
+		if !strings.HasSuffix(name, DocumentSuffix) {
+			continue
+		}
+		ps, err := cedar.NewPolicySetFromBytes(name, []byte(document+permitAll))
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("parsing %s, %w", name, err))
+			continue
+		}
+		policies[name] = ps
+	}
+	if errs != nil {
+		return errs
+	}
+	p.version = version
+	p.policies = policies
+	log.FromContext(ctx).WithValues("version", version, "documents", lo.Keys(policies)).Info("updated launch policies")
+	return nil
+}
+
+// Reset removes all launch policies, allowing every offering to be launched
+func (p *DefaultProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.version = ""
+	p.policies = map[string]*cedar.PolicySet{}
+}
+
+// Allowed returns whether the launch policies allow the offering to be launched. Denied offerings are logged along
+// with the policies which denied them, once per version of the launch policies.
+func (p *DefaultProvider) Allowed(ctx context.Context, launch Launch) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var denied []string
+	names := lo.Keys(p.policies)
+	sort.Strings(names)
+	for _, name := range names {
+		decision, diagnostic := p.policies[name].IsAuthorized(cedar.EntityMap{}, launch.request())
+		if decision == cedar.Allow {
+			continue
+		}
+		for _, reason := range diagnostic.Reasons {
+			denied = append(denied, fmt.Sprintf("%s/%s", name, reason.PolicyID))
+		}
+	}
+	if len(denied) == 0 {
+		return true
+	}
+	if p.cm.HasChanged(fmt.Sprintf("%s/%s/%s/%s/%s", launch.NodePool, launch.InstanceType, launch.Zone, launch.CapacityType, launch.SubnetID), p.version) {
+		log.FromContext(ctx).WithValues(
+			"nodepool", launch.NodePool,
+			"instance-type", launch.InstanceType,
+			"zone", launch.Zone,
+			"capacity-type", launch.CapacityType,
+			"subnet", launch.SubnetID,
+			"policies", denied,
+		).Info("launch policies denied offering")
+	}
+	return false
+}
@@ -29,6 +29,7 @@ import (
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -49,8 +50,13 @@ type Provider interface {
 	SpotPrice(ec2types.InstanceType, string) (float64, bool)
 	UpdateOnDemandPricing(context.Context) error
 	UpdateSpotPricing(context.Context) error
+	SetCommittedPrices(map[ec2types.InstanceType]float64)
 }
 
+// staticPricingFallbackRegion is the region whose static pricing data is used for regions which were opened after
+// Karpenter was built and are missing from the static pricing data
+const staticPricingFallbackRegion = "us-east-1"
+
 // DefaultProvider provides actual pricing data to the AWS cloud provider to allow it to make more informed decisions
 // regarding which instances to launch.  This is initialized at startup with a periodically updated static price list to
 // support running in locations where pricing data is unavailable.  In those cases the static pricing data provides a
@@ -65,6 +71,12 @@ type DefaultProvider struct {
 
 	muOnDemand     sync.RWMutex
 	onDemandPrices map[ec2types.InstanceType]float64
+	// staticOnDemandPrices is true when the on-demand prices were loaded from an offering snapshot, and therefore
+	// shouldn't be updated from the pricing API
+	staticOnDemandPrices bool
+	// committedPrices are the effective prices of the instance types which the account has committed to with Savings
+	// Plans and Reserved Instances. They replace the on-demand prices when they're lower.
+	committedPrices map[ec2types.InstanceType]float64
 
 	muSpot             sync.RWMutex
 	spotPrices         map[ec2types.InstanceType]zonal
@@ -102,10 +114,20 @@ func NewAPI(cfg aws.Config) *pricing.Client {
 	//create pricing config using pricing endpoint
 	pricingCfg := cfg.Copy()
 	pricingCfg.Region = pricingAPIRegion
-	return pricing.NewFromConfig(pricingCfg)
+	// The pricing API doesn't have FIPS endpoints, so the standard endpoint is used even if FIPS endpoints are used for
+	// the other APIs
+	return pricing.NewFromConfig(pricingCfg, func(o *pricing.Options) {
+		o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateDisabled
+	})
+}
+
+// hasAPI returns true if the pricing API can be reached from the partition of the region. The pricing API is only
+// available in the aws and aws-cn partitions.
+func hasAPI(region string) bool {
+	return lo.Contains([]string{"aws", "aws-cn"}, utils.Partition(region))
 }
 
-func NewDefaultProvider(_ context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC2API, region string) *DefaultProvider {
+func NewDefaultProvider(ctx context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC2API, region string) *DefaultProvider {
 	p := &DefaultProvider{
 		region:  region,
 		ec2:     ec2Api,
@@ -114,6 +136,13 @@ func NewDefaultProvider(_ context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC
 	}
 	// sets the pricing data from the static default state for the provider
 	p.Reset()
+	if _, ok := initialOnDemandPrices[region]; !ok {
+		log.FromContext(ctx).WithValues(
+			"dataset", "pricing",
+			"region", region,
+			"fallback-region", staticPricingFallbackRegion,
+		).Info("generated data is missing the region, falling back to static pricing from another region until pricing is updated from the pricing API")
+	}
 
 	return p
 }
@@ -128,7 +157,7 @@ func (p *DefaultProvider) InstanceTypes() []ec2types.InstanceType {
 }
 
 // OnDemandPrice returns the last known on-demand price for a given instance type, returning an error if there is no
-// known on-demand pricing for the instance type.
+// known on-demand pricing for the instance type. The committed price is returned instead when it's lower.
 func (p *DefaultProvider) OnDemandPrice(instanceType ec2types.InstanceType) (float64, bool) {
 	p.muOnDemand.RLock()
 	defer p.muOnDemand.RUnlock()
@@ -136,6 +165,9 @@ func (p *DefaultProvider) OnDemandPrice(instanceType ec2types.InstanceType) (flo
 	if !ok {
 		return 0.0, false
 	}
+	if committed, ok := p.committedPrices[instanceType]; ok && committed < price {
+		return committed, true
+	}
 	return price, true
 }
 
@@ -170,10 +202,23 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 		}
 		return nil
 	}
+	if !hasAPI(p.region) {
+		if p.cm.HasChanged("on-demand-prices", nil) {
+			log.FromContext(ctx).WithValues("partition", utils.Partition(p.region)).V(1).Info("the pricing api isn't available in the partition, on-demand pricing information will not be updated")
+		}
+		return nil
+	}
 
 	p.muOnDemand.Lock()
 	defer p.muOnDemand.Unlock()
 
+	if p.staticOnDemandPrices {
+		if p.cm.HasChanged("on-demand-prices", nil) {
+			log.FromContext(ctx).V(1).Info("using on-demand pricing from the offering snapshot, on-demand pricing information will not be updated")
+		}
+		return nil
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -398,6 +443,23 @@ func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 	return nil
 }
 
+// SetStaticOnDemandPrices replaces the on-demand prices with prices which were exported from another environment, and
+// stops the on-demand prices from being updated from the pricing API
+func (p *DefaultProvider) SetStaticOnDemandPrices(prices map[ec2types.InstanceType]float64) {
+	p.muOnDemand.Lock()
+	defer p.muOnDemand.Unlock()
+	p.onDemandPrices = prices
+	p.staticOnDemandPrices = true
+}
+
+// SetCommittedPrices replaces the effective prices of the instance types which the account has committed to with
+// Savings Plans and Reserved Instances
+func (p *DefaultProvider) SetCommittedPrices(prices map[ec2types.InstanceType]float64) {
+	p.muOnDemand.Lock()
+	defer p.muOnDemand.Unlock()
+	p.committedPrices = prices
+}
+
 func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
 	// ensure we don't deadlock and nolint for the empty critical section
 	p.muOnDemand.Lock()
@@ -421,10 +483,12 @@ func (p *DefaultProvider) Reset() {
 	staticPricing, ok := initialOnDemandPrices[p.region]
 	if !ok {
 		// and if not, fall back to the always available us-east-1
-		staticPricing = initialOnDemandPrices["us-east-1"]
+		staticPricing = initialOnDemandPrices[staticPricingFallbackRegion]
 	}
 
 	p.onDemandPrices = staticPricing
+	p.staticOnDemandPrices = false
+	p.committedPrices = nil
 	// default our spot pricing to the same as the on-demand pricing until a price update
 	p.spotPrices = populateInitialSpotPricing(staticPricing)
 	p.spotPricingUpdated = false
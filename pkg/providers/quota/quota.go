@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/patrickmn/go-cache"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+const (
+	ServiceCodeEC2 = "ec2"
+	ServiceCodeEBS = "ebs"
+)
+
+// Quota is a service quota, identified by its service code and quota code
+type Quota struct {
+	ServiceCode string
+	Code        string
+	Name        string
+}
+
+func (q Quota) String() string {
+	return fmt.Sprintf("%s (%s)", q.Name, q.Code)
+}
+
+// The quotas on the number of vCPUs of running instances are shared by groups of instance families. Families which
+// aren't listed, like the standard A, C, D, H, I, M, R, T and Z families, share the standard quota.
+var (
+	onDemandStandard = Quota{ServiceCodeEC2, "L-1216C47A", "Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances"}
+	onDemandVCPU     = []familyQuota{
+		{"dl", Quota{ServiceCodeEC2, "L-6E869C2A", "Running On-Demand DL instances"}},
+		{"trn", Quota{ServiceCodeEC2, "L-2C3B7624", "Running On-Demand Trn instances"}},
+		{"inf", Quota{ServiceCodeEC2, "L-1945791B", "Running On-Demand Inf instances"}},
+		{"u", Quota{ServiceCodeEC2, "L-43DA4232", "Running On-Demand High Memory instances"}},
+		{"vt", Quota{ServiceCodeEC2, "L-DB2E81BA", "Running On-Demand G and VT instances"}},
+		{"g", Quota{ServiceCodeEC2, "L-DB2E81BA", "Running On-Demand G and VT instances"}},
+		{"f", Quota{ServiceCodeEC2, "L-74FC7D96", "Running On-Demand F instances"}},
+		{"p", Quota{ServiceCodeEC2, "L-417A185B", "Running On-Demand P instances"}},
+		{"x", Quota{ServiceCodeEC2, "L-7295265B", "Running On-Demand X instances"}},
+	}
+	spotStandard = Quota{ServiceCodeEC2, "L-34B43A08", "All Standard (A, C, D, H, I, M, R, T, Z) Spot Instance Requests"}
+	spotVCPU     = []familyQuota{
+		{"dl", Quota{ServiceCodeEC2, "L-85EED4F7", "All DL Spot Instance Requests"}},
+		{"trn", Quota{ServiceCodeEC2, "L-6B0D517C", "All Trn Spot Instance Requests"}},
+		{"inf", Quota{ServiceCodeEC2, "L-B5D1601B", "All Inf Spot Instance Requests"}},
+		{"vt", Quota{ServiceCodeEC2, "L-3819A6DF", "All G and VT Spot Instance Requests"}},
+		{"g", Quota{ServiceCodeEC2, "L-3819A6DF", "All G and VT Spot Instance Requests"}},
+		{"f", Quota{ServiceCodeEC2, "L-88CF9481", "All F Spot Instance Requests"}},
+		{"p", Quota{ServiceCodeEC2, "L-7212CCBC", "All P Spot Instance Requests"}},
+		{"x", Quota{ServiceCodeEC2, "L-E3A00192", "All X Spot Instance Requests"}},
+	}
+	// volumeStorage are the quotas on the storage of the volumes of each volume type, in TiB
+	volumeStorage = map[string]Quota{
+		"gp2":      {ServiceCodeEBS, "L-D18FCD1D", "Storage for General Purpose SSD (gp2) volumes, in TiB"},
+		"gp3":      {ServiceCodeEBS, "L-7A658B76", "Storage for General Purpose SSD (gp3) volumes, in TiB"},
+		"io1":      {ServiceCodeEBS, "L-FD252861", "Storage for Provisioned IOPS SSD (io1) volumes, in TiB"},
+		"io2":      {ServiceCodeEBS, "L-09BD8365", "Storage for Provisioned IOPS SSD (io2) volumes, in TiB"},
+		"st1":      {ServiceCodeEBS, "L-82ACEF56", "Storage for Throughput Optimized HDD (st1) volumes, in TiB"},
+		"sc1":      {ServiceCodeEBS, "L-17AF77E8", "Storage for Cold HDD (sc1) volumes, in TiB"},
+		"standard": {ServiceCodeEBS, "L-9CF3C2EB", "Storage for Magnetic (standard) volumes, in TiB"},
+	}
+)
+
+type familyQuota struct {
+	prefix string
+	quota  Quota
+}
+
+// VCPUQuota returns the quota on the number of vCPUs of the running instances of the instance family and capacity
+// type. The second return value is false if instances of the family and capacity type aren't limited by a vCPU quota,
+// like the Mac families, which are launched onto Dedicated Hosts, and instances launched into capacity blocks.
+func VCPUQuota(family string, capacityType string) (Quota, bool) {
+	if strings.HasPrefix(family, "mac") {
+		return Quota{}, false
+	}
+	var quotas []familyQuota
+	var standard Quota
+	switch capacityType {
+	case karpv1.CapacityTypeOnDemand:
+		quotas, standard = onDemandVCPU, onDemandStandard
+	case karpv1.CapacityTypeSpot:
+		// High memory instances can't be launched as spot instances
+		if strings.HasPrefix(family, "u") {
+			return Quota{}, false
+		}
+		quotas, standard = spotVCPU, spotStandard
+	default:
+		return Quota{}, false
+	}
+	for _, q := range quotas {
+		if strings.HasPrefix(family, q.prefix) {
+			return q.quota, true
+		}
+	}
+	return standard, true
+}
+
+// VolumeStorageQuota returns the quota on the storage of the volumes of the volume type, in TiB
+func VolumeStorageQuota(volumeType string) (Quota, bool) {
+	q, ok := volumeStorage[volumeType]
+	return q, ok
+}
+
+type Provider interface {
+	Get(context.Context, Quota) (float64, bool, error)
+}
+
+// DefaultProvider resolves the values of service quotas which are applied to the account in the region
+type DefaultProvider struct {
+	serviceQuotasAPI sdk.ServiceQuotasAPI
+	cache            *cache.Cache
+}
+
+func NewDefaultProvider(serviceQuotasAPI sdk.ServiceQuotasAPI, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		serviceQuotasAPI: serviceQuotasAPI,
+		cache:            cache,
+	}
+}
+
+// Get returns the applied value of the quota. The second return value is false if the quota isn't available in the
+// region.
+func (p *DefaultProvider) Get(ctx context.Context, q Quota) (float64, bool, error) {
+	key := q.ServiceCode + "/" + q.Code
+	if value, ok := p.cache.Get(key); ok {
+		// Quotas which aren't available in the region are cached as nil
+		v := value.(*float64)
+		return aws.ToFloat64(v), v != nil, nil
+	}
+	out, err := p.serviceQuotasAPI.GetServiceQuota(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(q.ServiceCode),
+		QuotaCode:   aws.String(q.Code),
+	})
+	if awserrors.IsNotFound(err) {
+		p.cache.SetDefault(key, (*float64)(nil))
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("getting service quota %s, %w", q.Code, err)
+	}
+	p.cache.SetDefault(key, out.Quota.Value)
+	return aws.ToFloat64(out.Quota.Value), true, nil
+}
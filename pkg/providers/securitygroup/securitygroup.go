@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -31,10 +32,14 @@ import (
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 type Provider interface {
 	List(context.Context, *v1.EC2NodeClass) ([]ec2types.SecurityGroup, error)
+	Invalidate()
 }
 
 type DefaultProvider struct {
@@ -58,7 +63,7 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	defer p.Unlock()
 
 	// Get SecurityGroups
-	filterSets := getFilterSets(nodeClass.Spec.SecurityGroupSelectorTerms)
+	filterSets := getFilterSets(nodeClass.Spec.SecurityGroupSelectorTerms, options.FromContext(ctx).DiscoveryClusterNames(time.Now()))
 	securityGroups, err := p.getSecurityGroups(ctx, filterSets)
 	if err != nil {
 		return nil, err
@@ -72,6 +77,13 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	return securityGroups, nil
 }
 
+// Invalidate removes the cached security groups so that changes to security groups are discovered on the next List
+func (p *DefaultProvider) Invalidate() {
+	p.Lock()
+	defer p.Unlock()
+	p.cache.Flush()
+}
+
 func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]ec2types.Filter) ([]ec2types.SecurityGroup, error) {
 	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
@@ -86,17 +98,22 @@ func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]
 	for _, filters := range filterSets {
 		output, err := p.ec2api.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{Filters: filters})
 		if err != nil {
+			health.Providers.Observe(health.SecurityGroups, err)
 			return nil, fmt.Errorf("describing security groups %+v, %w", filterSets, err)
 		}
 		for i := range output.SecurityGroups {
+			if utils.Excluded(output.SecurityGroups[i].Tags) {
+				continue
+			}
 			securityGroups[lo.FromPtr(output.SecurityGroups[i].GroupId)] = output.SecurityGroups[i]
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(securityGroups))
+	health.Providers.Observe(health.SecurityGroups, nil)
 	return lo.Values(securityGroups), nil
 }
 
-func getFilterSets(terms []v1.SecurityGroupSelectorTerm) (res [][]ec2types.Filter) {
+func getFilterSets(terms []v1.SecurityGroupSelectorTerm, clusterNames []string) (res [][]ec2types.Filter) {
 	idFilter := ec2types.Filter{Name: aws.String("group-id")}
 	nameFilter := ec2types.Filter{Name: aws.String("group-name")}
 	for _, term := range terms {
@@ -116,7 +133,7 @@ func getFilterSets(terms []v1.SecurityGroupSelectorTerm) (res [][]ec2types.Filte
 				} else {
 					filters = append(filters, ec2types.Filter{
 						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []string{v},
+						Values: utils.TagFilterValues(k, v, clusterNames),
 					})
 				}
 			}
@@ -134,6 +134,21 @@ var _ = Describe("SecurityGroupProvider", func() {
 			},
 		}, securityGroups)
 	})
+	It("should not discover the security groups tagged for exclusion", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []ec2types.SecurityGroup{
+			{GroupName: aws.String("test-sgName-1"), GroupId: aws.String("test-sg-1")},
+			{GroupName: aws.String("test-sgName-2"), GroupId: aws.String("test-sg-2"), Tags: []ec2types.Tag{{Key: aws.String(v1.ExcludeTagKey), Value: aws.String("true")}}},
+		}})
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{ID: "test-sg-1"}, {ID: "test-sg-2"}}
+		securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfSecurityGroups([]ec2types.SecurityGroup{
+			{
+				GroupId:   aws.String("test-sg-1"),
+				GroupName: aws.String("test-sgName-1"),
+			},
+		}, securityGroups)
+	})
 	It("should discover security groups by multiple tag values", func() {
 		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
 			{
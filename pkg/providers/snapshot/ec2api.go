@@ -0,0 +1,98 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// EC2API serves the instance types, offerings and spot prices of the EC2 API from a snapshot, so that they don't need
+// to be discovered from the EC2 API. Every other call is passed through to the EC2 API.
+type EC2API struct {
+	sdk.EC2API
+	snapshot *Snapshot
+	clk      clock.Clock
+	cm       *pretty.ChangeMonitor
+}
+
+func NewEC2API(ec2api sdk.EC2API, snapshot *Snapshot, clk clock.Clock) *EC2API {
+	return &EC2API{
+		EC2API:   ec2api,
+		snapshot: snapshot,
+		clk:      clk,
+		cm:       pretty.NewChangeMonitor(),
+	}
+}
+
+// DescribeInstanceTypes returns every instance type in the snapshot, ignoring the input's filters since the snapshot
+// only contains the instance types that Karpenter discovers. A warning is logged once a day while the snapshot is stale.
+func (a *EC2API) DescribeInstanceTypes(ctx context.Context, _ *ec2.DescribeInstanceTypesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	if a.snapshot.Stale(a.clk.Now()) && a.cm.HasChanged("stale", int(a.snapshot.Age(a.clk.Now())/(24*time.Hour))) {
+		log.FromContext(ctx).WithValues(
+			"created-at", a.snapshot.CreatedAt,
+			"age", a.snapshot.Age(a.clk.Now()).Round(time.Hour),
+		).Info("offering snapshot is stale, it may be missing instance types and have outdated prices, export a new snapshot")
+	}
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: a.snapshot.InstanceTypes}, nil
+}
+
+// DescribeInstanceTypeOfferings returns the availability zone offerings of every instance type in the snapshot
+func (a *EC2API) DescribeInstanceTypeOfferings(_ context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	if input.LocationType != ec2types.LocationTypeAvailabilityZone {
+		return nil, fmt.Errorf("offering snapshot only contains %s offerings", ec2types.LocationTypeAvailabilityZone)
+	}
+	var offerings []ec2types.InstanceTypeOffering
+	for instanceType, zones := range a.snapshot.Zones {
+		for _, zone := range zones {
+			offerings = append(offerings, ec2types.InstanceTypeOffering{
+				InstanceType: instanceType,
+				Location:     lo.ToPtr(zone),
+				LocationType: ec2types.LocationTypeAvailabilityZone,
+			})
+		}
+	}
+	return &ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: offerings}, nil
+}
+
+// DescribeSpotPriceHistory returns the spot price of every instance type and zone in the snapshot, timestamped with the
+// time that the snapshot was exported
+func (a *EC2API) DescribeSpotPriceHistory(_ context.Context, _ *ec2.DescribeSpotPriceHistoryInput, _ ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
+	var history []ec2types.SpotPrice
+	for instanceType, prices := range a.snapshot.SpotPrices {
+		for zone, price := range prices {
+			history = append(history, ec2types.SpotPrice{
+				InstanceType:       instanceType,
+				AvailabilityZone:   lo.ToPtr(zone),
+				ProductDescription: ec2types.RIProductDescriptionLinuxUnix,
+				SpotPrice:          aws.String(strconv.FormatFloat(price, 'f', -1, 64)),
+				Timestamp:          lo.ToPtr(a.snapshot.CreatedAt),
+			})
+		}
+	}
+	return &ec2.DescribeSpotPriceHistoryOutput{SpotPriceHistory: history}, nil
+}
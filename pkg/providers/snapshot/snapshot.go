@@ -0,0 +1,153 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+const (
+	// FormatVersion is the version of the snapshot format. Snapshots with a different version can't be imported.
+	FormatVersion = 1
+	// ConfigMapKey is the key of the ConfigMap's binary data which contains the snapshot
+	ConfigMapKey = "snapshot.json.gz"
+	// StaleAfter is the age after which a snapshot is considered stale. Stale snapshots are still used, but are likely
+	// to be missing newly launched instance types and to have outdated prices.
+	StaleAfter = 30 * 24 * time.Hour
+)
+
+// Snapshot is a point in time copy of the instance types, offerings and prices of a region. Snapshots are exported from
+// an environment which can reach the EC2 and pricing APIs, and imported into air-gapped environments which can't.
+type Snapshot struct {
+	Version       int                         `json:"version"`
+	Region        string                      `json:"region"`
+	CreatedAt     time.Time                   `json:"createdAt"`
+	InstanceTypes []ec2types.InstanceTypeInfo `json:"instanceTypes"`
+	// Zones are the availability zones that each instance type is offered in
+	Zones          map[ec2types.InstanceType][]string           `json:"zones"`
+	OnDemandPrices map[ec2types.InstanceType]float64            `json:"onDemandPrices"`
+	SpotPrices     map[ec2types.InstanceType]map[string]float64 `json:"spotPrices"`
+}
+
+// Export snapshots the instance types and offerings of the region from the EC2 API, and their prices from the pricing
+// provider. The pricing provider's prices must be updated before the snapshot is exported.
+func Export(ctx context.Context, ec2api sdk.EC2API, pricingProvider pricing.Provider, region string, now time.Time) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:        FormatVersion,
+		Region:         region,
+		CreatedAt:      now.UTC(),
+		Zones:          map[ec2types.InstanceType][]string{},
+		OnDemandPrices: map[ec2types.InstanceType]float64{},
+		SpotPrices:     map[ec2types.InstanceType]map[string]float64{},
+	}
+	// These filters match the instance types that Karpenter discovers
+	instanceTypes := ec2.NewDescribeInstanceTypesPaginator(ec2api, &ec2.DescribeInstanceTypesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("supported-virtualization-type"),
+				Values: []string{"hvm"},
+			},
+			{
+				Name:   aws.String("processor-info.supported-architecture"),
+				Values: []string{"x86_64", "arm64"},
+			},
+		},
+	})
+	for instanceTypes.HasMorePages() {
+		page, err := instanceTypes.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing instance types, %w", err)
+		}
+		snapshot.InstanceTypes = append(snapshot.InstanceTypes, page.InstanceTypes...)
+	}
+	offerings := ec2.NewDescribeInstanceTypeOfferingsPaginator(ec2api, &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: ec2types.LocationTypeAvailabilityZone,
+	})
+	for offerings.HasMorePages() {
+		page, err := offerings.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing instance type offerings, %w", err)
+		}
+		for _, offering := range page.InstanceTypeOfferings {
+			snapshot.Zones[offering.InstanceType] = append(snapshot.Zones[offering.InstanceType], lo.FromPtr(offering.Location))
+		}
+	}
+	for _, info := range snapshot.InstanceTypes {
+		if price, ok := pricingProvider.OnDemandPrice(info.InstanceType); ok {
+			snapshot.OnDemandPrices[info.InstanceType] = price
+		}
+		for _, zone := range snapshot.Zones[info.InstanceType] {
+			if price, ok := pricingProvider.SpotPrice(info.InstanceType, zone); ok {
+				snapshot.SpotPrices[info.InstanceType] = lo.Assign(snapshot.SpotPrices[info.InstanceType], map[string]float64{zone: price})
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// Age returns how long ago the snapshot was exported
+func (s *Snapshot) Age(now time.Time) time.Duration {
+	return now.Sub(s.CreatedAt)
+}
+
+// Stale returns true if the snapshot was exported more than StaleAfter ago
+func (s *Snapshot) Stale(now time.Time) bool {
+	return s.Age(now) > StaleAfter
+}
+
+// Write writes the snapshot as gzipped JSON
+func (s *Snapshot) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(s); err != nil {
+		return fmt.Errorf("encoding snapshot, %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("compressing snapshot, %w", err)
+	}
+	return nil
+}
+
+// Read reads a snapshot which was written by Write
+func Read(r io.Reader) (*Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snapshot, %w", err)
+	}
+	defer gz.Close()
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(gz).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("decoding snapshot, %w", err)
+	}
+	if snapshot.Version != FormatVersion {
+		return nil, fmt.Errorf("snapshot has version %d, expected version %d", snapshot.Version, FormatVersion)
+	}
+	if len(snapshot.InstanceTypes) == 0 {
+		return nil, fmt.Errorf("snapshot has no instance types")
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,181 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/samber/lo"
+	clock "k8s.io/utils/clock/testing"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Snapshot")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = Describe("Snapshot", func() {
+	var now time.Time
+	BeforeEach(func() {
+		now = time.Now()
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []ec2types.SpotPrice{
+				{
+					AvailabilityZone: aws.String("test-zone-1a"),
+					InstanceType:     "m5.large",
+					SpotPrice:        aws.String("0.05"),
+					Timestamp:        &now,
+				},
+			},
+		})
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{fake.NewOnDemandPrice("m5.large", 0.096)},
+		})
+		Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+		Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+	})
+	It("should export instance types, offerings and prices", func() {
+		s, err := snapshot.Export(ctx, awsEnv.EC2API, awsEnv.PricingProvider, fake.DefaultRegion, now)
+		Expect(err).To(BeNil())
+		Expect(s.Version).To(Equal(snapshot.FormatVersion))
+		Expect(s.Region).To(Equal(fake.DefaultRegion))
+		Expect(s.InstanceTypes).ToNot(BeEmpty())
+		Expect(s.Zones).To(HaveKeyWithValue(ec2types.InstanceType("m5.large"), ContainElement("test-zone-1a")))
+		Expect(s.OnDemandPrices).To(HaveKeyWithValue(ec2types.InstanceType("m5.large"), 0.096))
+		Expect(s.SpotPrices).To(HaveKeyWithValue(ec2types.InstanceType("m5.large"), map[string]float64{"test-zone-1a": 0.05}))
+	})
+	It("should read the snapshot that was written", func() {
+		s, err := snapshot.Export(ctx, awsEnv.EC2API, awsEnv.PricingProvider, fake.DefaultRegion, now)
+		Expect(err).To(BeNil())
+		buf := &bytes.Buffer{}
+		Expect(s.Write(buf)).To(Succeed())
+		read, err := snapshot.Read(buf)
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(s))
+	})
+	It("should fail to read a snapshot with a different version", func() {
+		s, err := snapshot.Export(ctx, awsEnv.EC2API, awsEnv.PricingProvider, fake.DefaultRegion, now)
+		Expect(err).To(BeNil())
+		s.Version = snapshot.FormatVersion + 1
+		buf := &bytes.Buffer{}
+		Expect(s.Write(buf)).To(Succeed())
+		_, err = snapshot.Read(buf)
+		Expect(err).ToNot(BeNil())
+	})
+	It("should fail to read a snapshot which isn't gzipped", func() {
+		_, err := snapshot.Read(bytes.NewBufferString(`{"version": 1}`))
+		Expect(err).ToNot(BeNil())
+	})
+	It("should be stale after 30 days", func() {
+		s, err := snapshot.Export(ctx, awsEnv.EC2API, awsEnv.PricingProvider, fake.DefaultRegion, now)
+		Expect(err).To(BeNil())
+		Expect(s.Stale(now.Add(29 * 24 * time.Hour))).To(BeFalse())
+		Expect(s.Stale(now.Add(31 * 24 * time.Hour))).To(BeTrue())
+	})
+	Context("EC2API", func() {
+		var s *snapshot.Snapshot
+		var ec2api *snapshot.EC2API
+		BeforeEach(func() {
+			var err error
+			s, err = snapshot.Export(ctx, awsEnv.EC2API, awsEnv.PricingProvider, fake.DefaultRegion, now)
+			Expect(err).To(BeNil())
+			ec2api = snapshot.NewEC2API(awsEnv.EC2API, s, clock.NewFakeClock(now))
+			awsEnv.EC2API.Reset()
+		})
+		It("should serve instance types from the snapshot", func() {
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{})
+			out, err := ec2api.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			Expect(out.InstanceTypes).To(Equal(s.InstanceTypes))
+		})
+		It("should serve offerings from the snapshot", func() {
+			out, err := ec2api.DescribeInstanceTypeOfferings(ctx, &ec2.DescribeInstanceTypeOfferingsInput{LocationType: ec2types.LocationTypeAvailabilityZone})
+			Expect(err).To(BeNil())
+			Expect(out.InstanceTypeOfferings).To(HaveLen(lo.Sum(lo.MapToSlice(s.Zones, func(_ ec2types.InstanceType, zones []string) int { return len(zones) }))))
+			Expect(out.InstanceTypeOfferings).To(ContainElement(ec2types.InstanceTypeOffering{
+				InstanceType: "m5.large",
+				Location:     aws.String("test-zone-1a"),
+				LocationType: ec2types.LocationTypeAvailabilityZone,
+			}))
+		})
+		It("should serve spot prices from the snapshot", func() {
+			pricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, ec2api, fake.DefaultRegion)
+			Expect(pricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			price, ok := pricingProvider.SpotPrice("m5.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(Equal(0.05))
+		})
+		It("should use on-demand prices from the snapshot", func() {
+			pricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, ec2api, fake.DefaultRegion)
+			pricingProvider.SetStaticOnDemandPrices(s.OnDemandPrices)
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)},
+			})
+			Expect(pricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+			price, ok := pricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(Equal(0.096))
+		})
+		It("should pass other calls through to the EC2 API", func() {
+			out, err := ec2api.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{})
+			Expect(err).To(BeNil())
+			Expect(out.Subnets).ToNot(BeEmpty())
+		})
+	})
+})
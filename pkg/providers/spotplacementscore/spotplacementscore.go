@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+const (
+	// MaxFamilies bounds the number of instance families whose scores are refreshed, since the number of distinct
+	// configurations that GetSpotPlacementScores can be called with is limited
+	MaxFamilies = 10
+	// targetCapacity is the number of instances that scores are requested for. Nearly every zone has the highest score
+	// for a single instance, so scores for a single instance don't distinguish between zones during a scale-up.
+	targetCapacity = 10
+)
+
+type Provider interface {
+	// Scores returns the spot placement scores of the zones for the instance types, keyed by zone ID, from the scores
+	// of their instance families which were last refreshed. It never calls EC2, and registers the families to be
+	// refreshed by UpdateScores.
+	Scores(context.Context, []string) map[string]int32
+	// UpdateScores refreshes the scores of the registered instance families whose scores have expired
+	UpdateScores(context.Context) error
+}
+
+type DefaultProvider struct {
+	ec2api sdk.EC2API
+	region string
+	// cache holds the scores of each instance family, keyed by zone ID
+	cache *cache.Cache
+
+	mu sync.Mutex
+	// families holds the instance types of each instance family which spot instances were launched for. Families expire
+	// once no spot instances of the family have been launched for a day, so that they don't count toward MaxFamilies.
+	families *cache.Cache
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, region string, scoreCache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api:   ec2api,
+		region:   region,
+		cache:    scoreCache,
+		families: cache.New(awscache.SpotPlacementScoreFamiliesTTL, awscache.DefaultCleanupInterval),
+	}
+}
+
+func (p *DefaultProvider) Scores(ctx context.Context, instanceTypes []string) map[string]int32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	scores := map[string]int32{}
+	for family, types := range lo.GroupBy(instanceTypes, instanceFamily) {
+		p.register(ctx, family, types)
+		familyScores, ok := p.cache.Get(family)
+		if !ok {
+			continue
+		}
+		// The instances can launch into a zone if any of the families has capacity in it
+		for zoneID, score := range familyScores.(map[string]int32) {
+			scores[zoneID] = max(scores[zoneID], score)
+		}
+	}
+	return scores
+}
+
+// register records the instance types of the family, so that its scores are refreshed. Families beyond MaxFamilies
+// aren't registered, and don't have scores.
+func (p *DefaultProvider) register(ctx context.Context, family string, instanceTypes []string) {
+	if existing, ok := p.families.Get(family); ok {
+		p.families.SetDefault(family, existing.(sets.Set[string]).Insert(instanceTypes...))
+		return
+	}
+	if p.families.ItemCount() >= MaxFamilies {
+		log.FromContext(ctx).WithValues("instance-family", family).V(1).Info("not tracking spot placement scores, too many instance families are tracked")
+		return
+	}
+	p.families.SetDefault(family, sets.New(instanceTypes...))
+}
+
+func (p *DefaultProvider) UpdateScores(ctx context.Context) error {
+	p.mu.Lock()
+	families := map[string][]string{}
+	for family, item := range p.families.Items() {
+		if _, ok := p.cache.Get(family); !ok {
+			families[family] = sets.List(item.Object.(sets.Set[string]))
+		}
+	}
+	p.mu.Unlock()
+
+	var errs []error
+	names := lo.Keys(families)
+	sort.Strings(names)
+	for _, family := range names {
+		scores, err := p.getScores(ctx, families[family])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("getting spot placement scores for %s, %w", family, err))
+			// The family isn't retried until its scores expire, since failed requests may still count toward the limit
+			scores = map[string]int32{}
+		}
+		p.cache.SetDefault(family, scores)
+	}
+	return multierr.Combine(errs...)
+}
+
+func (p *DefaultProvider) getScores(ctx context.Context, instanceTypes []string) (map[string]int32, error) {
+	sort.Strings(instanceTypes)
+	scores := map[string]int32{}
+	paginator := ec2.NewGetSpotPlacementScoresPaginator(p.ec2api, &ec2.GetSpotPlacementScoresInput{
+		InstanceTypes:          instanceTypes,
+		RegionNames:            []string{p.region},
+		SingleAvailabilityZone: aws.Bool(true),
+		TargetCapacity:         aws.Int32(targetCapacity),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, score := range out.SpotPlacementScores {
+			if score.AvailabilityZoneId != nil {
+				scores[aws.ToString(score.AvailabilityZoneId)] = aws.ToInt32(score.Score)
+			}
+		}
+	}
+	return scores, nil
+}
+
+// Reset forgets the instance families whose scores are refreshed
+func (p *DefaultProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.families.Flush()
+}
+
+func instanceFamily(instanceType string) string {
+	return strings.Split(instanceType, ".")[0]
+}
@@ -16,25 +16,34 @@ package sqs
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/samber/lo"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 type Provider interface {
 	Name() string
+	Region() string
 	GetSQSMessages(context.Context) ([]*sqstypes.Message, error)
 	SendMessage(context.Context, interface{}) (string, error)
 	DeleteSQSMessage(context.Context, *sqstypes.Message) error
+	ReleaseSQSMessage(context.Context, *sqstypes.Message) error
 }
 
+// messageGroupID is the message group of messages sent to FIFO queues
+const messageGroupID = "karpenter"
+
 type DefaultProvider struct {
 	client sdk.SQSAPI
 
@@ -53,6 +62,47 @@ func (p *DefaultProvider) Name() string {
 	return ss[len(ss)-1]
 }
 
+// Region returns the region of the queue, which may differ from the region of the cluster
+func (p *DefaultProvider) Region() string {
+	return QueueRegion(p.queueURL)
+}
+
+// QueueRegion returns the region of the queue from its URL, e.g. https://sqs.us-west-2.amazonaws.com/<account>/<name>.
+// An empty string is returned if the URL doesn't contain a region.
+func QueueRegion(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	// Queue URLs are either of the form sqs.<region>.amazonaws.com or the legacy <region>.queue.amazonaws.com
+	parts := strings.Split(u.Hostname(), ".")
+	switch {
+	case len(parts) > 2 && parts[0] == "sqs":
+		return parts[1]
+	case len(parts) > 2 && parts[1] == "queue":
+		return parts[0]
+	}
+	return ""
+}
+
+// QueueURL returns the URL of a queue from its ARN, e.g. arn:aws:sqs:<region>:<account>:<name>, so that queues in
+// other accounts can be polled without calling GetQueueUrl
+func QueueURL(queueARN string) (string, error) {
+	a, err := arn.Parse(queueARN)
+	if err != nil {
+		return "", fmt.Errorf("parsing queue arn, %w", err)
+	}
+	if a.Service != "sqs" || a.Region == "" || a.AccountID == "" || a.Resource == "" {
+		return "", fmt.Errorf("%q is not the arn of an sqs queue", queueARN)
+	}
+	return fmt.Sprintf("https://sqs.%s.%s/%s/%s", a.Region, utils.DNSSuffix(a.Partition), a.AccountID, a.Resource), nil
+}
+
+// FIFO returns true if the queue is a FIFO queue. The names of FIFO queues are required to end with the .fifo suffix.
+func (p *DefaultProvider) FIFO() bool {
+	return strings.HasSuffix(p.Name(), ".fifo")
+}
+
 func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqstypes.Message, error) {
 	input := &sqs.ReceiveMessageInput{
 		MaxNumberOfMessages: int32(10),
@@ -84,6 +134,12 @@ func (p *DefaultProvider) SendMessage(ctx context.Context, body interface{}) (st
 		MessageBody: aws.String(string(raw)),
 		QueueUrl:    aws.String(p.queueURL),
 	}
+	if p.FIFO() {
+		// FIFO queues require every message to belong to a message group. A deduplication ID derived from the body is
+		// set explicitly, since content-based deduplication may not be enabled on the queue.
+		input.MessageGroupId = aws.String(messageGroupID)
+		input.MessageDeduplicationId = aws.String(fmt.Sprintf("%x", sha256.Sum256(raw)))
+	}
 	result, err := p.client.SendMessage(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("sending messages to sqs queue, %w", err)
@@ -102,3 +158,18 @@ func (p *DefaultProvider) DeleteSQSMessage(ctx context.Context, msg *sqstypes.Me
 	}
 	return nil
 }
+
+// ReleaseSQSMessage makes the passed message visible on the queue again, so that it can immediately be received by
+// other consumers of the queue
+func (p *DefaultProvider) ReleaseSQSMessage(ctx context.Context, msg *sqstypes.Message) error {
+	input := &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(p.queueURL),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: 0,
+	}
+
+	if _, err := p.client.ChangeMessageVisibility(ctx, input); err != nil {
+		return fmt.Errorf("changing message visibility in sqs queue, %w", err)
+	}
+	return nil
+}
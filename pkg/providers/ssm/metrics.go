@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssm
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	parameterLabel         = "parameter"
+	resultLabel            = "result"
+	reasonLabel            = "reason"
+
+	ResultHit  = "hit"
+	ResultMiss = "miss"
+
+	// InvalidationReasonDeprecated is the reason that a parameter is invalidated when the AMI it resolves to is deprecated
+	InvalidationReasonDeprecated = "deprecated"
+)
+
+var (
+	CacheLookupsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "ssm_cache_lookups_total",
+			Help:      "Number of lookups of SSM parameters in the SSM cache. Labeled by whether the parameter was cached (hit) or had to be resolved from SSM (miss).",
+		},
+		[]string{
+			resultLabel,
+		},
+	)
+	ParameterVersion = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "ssm_parameter_version",
+			Help:      "The version of each SSM parameter that was last resolved from SSM. Labeled by parameter.",
+		},
+		[]string{
+			parameterLabel,
+		},
+	)
+	ParameterChangesTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "ssm_parameter_changes_total",
+			Help:      "Number of times that an SSM parameter resolved to a different value than it was previously resolved to. A change of a parameter that an EC2NodeClass resolves its AMIs from drifts the nodes of the EC2NodeClass. Labeled by parameter.",
+		},
+		[]string{
+			parameterLabel,
+		},
+	)
+	ParameterInvalidationsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "ssm_parameter_invalidations_total",
+			Help:      "Number of times that an SSM parameter was removed from the SSM cache before it expired, so that it's resolved from SSM again. Labeled by parameter and reason.",
+		},
+		[]string{
+			parameterLabel,
+			reasonLabel,
+		},
+	)
+)
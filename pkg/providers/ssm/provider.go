@@ -16,30 +16,64 @@ package ssm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 type Provider interface {
 	Get(context.Context, Parameter) (string, error)
 }
 
+// DebugPath is the path of the metrics server that the SSM parameters which have been resolved are served at
+const DebugPath = "/debug/ssm"
+
 type DefaultProvider struct {
 	sync.Mutex
-	cache  *cache.Cache
-	ssmapi sdk.SSMAPI
+	cache     *cache.Cache
+	ssmapi    sdk.SSMAPI
+	partition string
+	cm        *pretty.ChangeMonitor
+
+	// observations outlive the entries of the cache, so that a parameter which changed while its entry was cached is
+	// detected when it's resolved again
+	observations map[string]Observation
+}
+
+// Observation is the last value that an SSM parameter was resolved to, and when it last changed
+type Observation struct {
+	Name          string     `json:"name"`
+	Value         string     `json:"value"`
+	Version       int64      `json:"version"`
+	Mutable       bool       `json:"mutable"`
+	ResolvedAt    time.Time  `json:"resolvedAt"`
+	PreviousValue string     `json:"previousValue,omitempty"`
+	ChangedAt     *time.Time `json:"changedAt,omitempty"`
+	// Cached is true if the parameter is served from the cache until ExpiresAt, rather than resolved from SSM
+	Cached    bool       `json:"cached"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
-func NewDefaultProvider(ssmapi sdk.SSMAPI, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(ssmapi sdk.SSMAPI, cache *cache.Cache, region string) *DefaultProvider {
 	return &DefaultProvider{
-		ssmapi: ssmapi,
-		cache:  cache,
+		ssmapi:       ssmapi,
+		cache:        cache,
+		partition:    utils.Partition(region),
+		cm:           pretty.NewChangeMonitor(),
+		observations: map[string]Observation{},
 	}
 }
 
@@ -47,16 +81,71 @@ func (p *DefaultProvider) Get(ctx context.Context, parameter Parameter) (string,
 	p.Lock()
 	defer p.Unlock()
 	if entry, ok := p.cache.Get(parameter.CacheKey()); ok {
+		CacheLookupsTotal.Inc(map[string]string{resultLabel: ResultHit})
 		return entry.(CacheEntry).Value, nil
 	}
-	result, err := p.ssmapi.GetParameter(ctx, parameter.GetParameterInput())
+	CacheLookupsTotal.Inc(map[string]string{resultLabel: ResultMiss})
+	prefix := options.FromContext(ctx).SSMParameterPrefix
+	result, err := p.ssmapi.GetParameter(ctx, parameter.GetParameterInput(prefix))
+	health.Providers.Observe(health.SSM, err)
 	if err != nil {
-		return "", fmt.Errorf("getting ssm parameter %q, %w", parameter.Name, err)
+		// Public parameters aren't published under the same path in every partition, so failures to resolve them
+		// outside of the aws partition are most likely fixed by configuring the path that they're published under
+		if parameter.IsPublic() && prefix == "" && p.partition != "aws" && p.cm.HasChanged("public-parameter-not-found", p.partition) {
+			log.FromContext(ctx).WithValues("parameter", parameter.Name, "partition", p.partition).Error(err, "failed resolving public ssm parameter, set ssm-parameter-prefix if public parameters are published under a different path in the partition")
+		}
+		return "", fmt.Errorf("getting ssm parameter %q, %w", parameter.ResolvedName(prefix), err)
 	}
 	p.cache.SetDefault(parameter.CacheKey(), CacheEntry{
 		Parameter: parameter,
 		Value:     lo.FromPtr(result.Parameter.Value),
 	})
-	log.FromContext(ctx).WithValues("parameter", parameter.Name, "value", result.Parameter.Value).Info("discovered ssm parameter")
+	log.FromContext(ctx).WithValues("parameter", parameter.ResolvedName(prefix), "value", result.Parameter.Value).Info("discovered ssm parameter")
+	p.observe(ctx, parameter, result.Parameter.Value, result.Parameter.Version)
 	return lo.FromPtr(result.Parameter.Value), nil
 }
+
+// observe records the value that the parameter was resolved to, and detects when it changed since it was last resolved
+func (p *DefaultProvider) observe(ctx context.Context, parameter Parameter, value *string, version int64) {
+	now := time.Now()
+	observation := Observation{
+		Name:       parameter.Name,
+		Value:      lo.FromPtr(value),
+		Version:    version,
+		Mutable:    parameter.IsMutable,
+		ResolvedAt: now,
+	}
+	if previous, ok := p.observations[parameter.Name]; ok {
+		observation.PreviousValue, observation.ChangedAt = previous.PreviousValue, previous.ChangedAt
+		if previous.Value != observation.Value {
+			observation.PreviousValue, observation.ChangedAt = previous.Value, lo.ToPtr(now)
+			ParameterChangesTotal.Inc(map[string]string{parameterLabel: parameter.Name})
+			log.FromContext(ctx).WithValues("parameter", parameter.Name, "previous-value", previous.Value, "value", observation.Value).Info("detected ssm parameter change")
+		}
+	}
+	p.observations[parameter.Name] = observation
+	ParameterVersion.Set(float64(version), map[string]string{parameterLabel: parameter.Name})
+}
+
+// Observations returns the last observation of every parameter which has been resolved, sorted by name
+func (p *DefaultProvider) Observations() []Observation {
+	p.Lock()
+	defer p.Unlock()
+	items := p.cache.Items()
+	observations := lo.Values(p.observations)
+	for i := range observations {
+		if item, ok := items[observations[i].Name]; ok {
+			observations[i].Cached = true
+			if item.Expiration > 0 {
+				observations[i].ExpiresAt = lo.ToPtr(time.Unix(0, item.Expiration))
+			}
+		}
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].Name < observations[j].Name })
+	return observations
+}
+
+func (p *DefaultProvider) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p.Observations())
+}
@@ -0,0 +1,135 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	awsssm "github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SSM")
+}
+
+var _ = Describe("SSM", func() {
+	var ssmapi *fake.SSMAPI
+	var ssmCache *cache.Cache
+	var provider *ssm.DefaultProvider
+	parameter := ssm.Parameter{Name: "/aws/service/eks/optimized-ami/1.31/amazon-linux-2023/x86_64/standard/recommended/image_id", IsMutable: true}
+	resolveTo := func(value string, version int64) {
+		ssmapi.GetParameterOutput = &awsssm.GetParameterOutput{
+			Parameter: &ssmtypes.Parameter{Name: lo.ToPtr(parameter.Name), Value: lo.ToPtr(value), Version: version},
+		}
+	}
+	serve := func() []ssm.Observation {
+		recorder := httptest.NewRecorder()
+		provider.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ssm.DebugPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		var observations []ssm.Observation
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &observations)).To(Succeed())
+		return observations
+	}
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ssmapi = fake.NewSSMAPI()
+		ssmCache = cache.New(awscache.SSMCacheTTL, awscache.DefaultCleanupInterval)
+		provider = ssm.NewDefaultProvider(ssmapi, ssmCache, fake.DefaultRegion)
+		ssm.CacheLookupsTotal.Reset()
+		ssm.ParameterChangesTotal.Reset()
+		ssm.ParameterVersion.Reset()
+	})
+	It("should count cache hits and misses", func() {
+		resolveTo("ami-1", 1)
+		for range 3 {
+			value, err := provider.Get(ctx, parameter)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("ami-1"))
+		}
+		ExpectMetricCounterValue(ssm.CacheLookupsTotal, 1, map[string]string{"result": ssm.ResultMiss})
+		ExpectMetricCounterValue(ssm.CacheLookupsTotal, 2, map[string]string{"result": ssm.ResultHit})
+		ExpectMetricGaugeValue(ssm.ParameterVersion, 1, map[string]string{"parameter": parameter.Name})
+	})
+	It("should detect a change of a parameter which is resolved again after its cache entry is removed", func() {
+		resolveTo("ami-1", 1)
+		_, err := provider.Get(ctx, parameter)
+		Expect(err).ToNot(HaveOccurred())
+
+		resolveTo("ami-2", 2)
+		ssmCache.Delete(parameter.CacheKey())
+		value, err := provider.Get(ctx, parameter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("ami-2"))
+		ExpectMetricCounterValue(ssm.ParameterChangesTotal, 1, map[string]string{"parameter": parameter.Name})
+		ExpectMetricGaugeValue(ssm.ParameterVersion, 2, map[string]string{"parameter": parameter.Name})
+
+		observations := serve()
+		Expect(observations).To(HaveLen(1))
+		Expect(observations[0].Name).To(Equal(parameter.Name))
+		Expect(observations[0].Value).To(Equal("ami-2"))
+		Expect(observations[0].Version).To(BeNumerically("==", 2))
+		Expect(observations[0].PreviousValue).To(Equal("ami-1"))
+		Expect(observations[0].ChangedAt).ToNot(BeNil())
+		Expect(observations[0].Cached).To(BeTrue())
+		Expect(observations[0].ExpiresAt).ToNot(BeNil())
+	})
+	It("should not detect a change of a parameter which resolves to the same value", func() {
+		resolveTo("ami-1", 1)
+		_, err := provider.Get(ctx, parameter)
+		Expect(err).ToNot(HaveOccurred())
+		ssmCache.Delete(parameter.CacheKey())
+		_, err = provider.Get(ctx, parameter)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found := FindMetricWithLabelValues("karpenter_cloudprovider_ssm_parameter_changes_total", map[string]string{"parameter": parameter.Name})
+		Expect(found).To(BeFalse())
+		observations := serve()
+		Expect(observations).To(HaveLen(1))
+		Expect(observations[0].ChangedAt).To(BeNil())
+	})
+	It("should serve parameters whose cache entries were removed as not cached", func() {
+		resolveTo("ami-1", 1)
+		_, err := provider.Get(ctx, parameter)
+		Expect(err).ToNot(HaveOccurred())
+		ssmCache.Delete(parameter.CacheKey())
+
+		observations := serve()
+		Expect(observations).To(HaveLen(1))
+		Expect(observations[0].Cached).To(BeFalse())
+		Expect(observations[0].ExpiresAt).To(BeNil())
+	})
+})
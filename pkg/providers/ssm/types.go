@@ -15,10 +15,15 @@ limitations under the License.
 package ssm
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/samber/lo"
 )
 
+// PublicParameterPrefix is the path that AWS publishes public parameters under, e.g. the IDs of the EKS optimized AMIs
+const PublicParameterPrefix = "/aws/service"
+
 type Parameter struct {
 	Name string
 	// IsMutable indicates if the value associated with an SSM parameter is expected to change. An example of a mutable
@@ -27,12 +32,27 @@ type Parameter struct {
 	IsMutable bool
 }
 
-func (p *Parameter) GetParameterInput() *ssm.GetParameterInput {
+// GetParameterInput returns the input to get the parameter. Public parameters are resolved under prefix instead of
+// PublicParameterPrefix, if it's set.
+func (p *Parameter) GetParameterInput(prefix string) *ssm.GetParameterInput {
 	return &ssm.GetParameterInput{
-		Name: lo.ToPtr(p.Name),
+		Name: lo.ToPtr(p.ResolvedName(prefix)),
 	}
 }
 
+// ResolvedName returns the name of the parameter, with PublicParameterPrefix replaced with prefix if it's set
+func (p *Parameter) ResolvedName(prefix string) string {
+	if prefix == "" || !p.IsPublic() {
+		return p.Name
+	}
+	return prefix + strings.TrimPrefix(p.Name, PublicParameterPrefix)
+}
+
+// IsPublic returns true if the parameter is one of the public parameters that AWS publishes
+func (p *Parameter) IsPublic() bool {
+	return strings.HasPrefix(p.Name, PublicParameterPrefix+"/")
+}
+
 func (p *Parameter) CacheKey() string {
 	return p.Name
 }
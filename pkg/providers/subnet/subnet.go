@@ -18,7 +18,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -38,6 +41,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 type Provider interface {
@@ -45,6 +51,7 @@ type Provider interface {
 	List(context.Context, *v1.EC2NodeClass) ([]ec2types.Subnet, error)
 	ZonalSubnetsForLaunch(context.Context, *v1.EC2NodeClass, []*cloudprovider.InstanceType, string) (map[string]*Subnet, error)
 	UpdateInflightIPs(*ec2.CreateFleetInput, *ec2.CreateFleetOutput, []*cloudprovider.InstanceType, []*Subnet, string)
+	Invalidate()
 }
 
 type DefaultProvider struct {
@@ -62,6 +69,10 @@ type Subnet struct {
 	Zone                    string
 	ZoneID                  string
 	AvailableIPAddressCount int32
+	// Public is true if instances launched into the subnet are assigned a public IPv4 address by default
+	Public bool
+	// Weight is the preference for the zone of the subnet, from the subnet selector terms
+	Weight int32
 }
 
 func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableIPAddressCache *cache.Cache, associatePublicIPAddressCache *cache.Cache) *DefaultProvider {
@@ -81,7 +92,7 @@ func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableIPAddres
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.Subnet, error) {
 	p.Lock()
 	defer p.Unlock()
-	filterSets := getFilterSets(nodeClass.Spec.SubnetSelectorTerms)
+	filterSets := getFilterSets(nodeClass.Spec.SubnetSelectorTerms, options.FromContext(ctx).DiscoveryClusterNames(time.Now()))
 	if len(filterSets) == 0 {
 		return []ec2types.Subnet{}, nil
 	}
@@ -92,16 +103,20 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	if subnets, ok := p.cache.Get(fmt.Sprint(hash)); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
-		return append([]ec2types.Subnet{}, subnets.([]ec2types.Subnet)...), nil
+		return outpostSubnets(nodeClass, append([]ec2types.Subnet{}, subnets.([]ec2types.Subnet)...)), nil
 	}
 	// Ensure that all the subnets that are returned here are unique
 	subnets := map[string]ec2types.Subnet{}
 	for _, filters := range filterSets {
 		output, err := p.ec2api.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
 		if err != nil {
+			health.Providers.Observe(health.Subnets, err)
 			return nil, fmt.Errorf("describing subnets %s, %w", pretty.Concise(filters), err)
 		}
 		for i := range output.Subnets {
+			if utils.Excluded(output.Subnets[i].Tags) {
+				continue
+			}
 			subnets[lo.FromPtr(output.Subnets[i].SubnetId)] = output.Subnets[i]
 			p.availableIPAddressCache.SetDefault(lo.FromPtr(output.Subnets[i].SubnetId), lo.FromPtr(output.Subnets[i].AvailableIpAddressCount))
 			p.associatePublicIPAddressCache.SetDefault(lo.FromPtr(output.Subnets[i].SubnetId), lo.FromPtr(output.Subnets[i].MapPublicIpOnLaunch))
@@ -111,6 +126,7 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(subnets))
+	health.Providers.Observe(health.Subnets, nil)
 	if p.cm.HasChanged(fmt.Sprintf("subnets/%s", nodeClass.Name), lo.Keys(subnets)) {
 		log.FromContext(ctx).
 			WithValues("subnets", lo.Map(lo.Values(subnets), func(s ec2types.Subnet, _ int) v1.Subnet {
@@ -121,7 +137,18 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 				}
 			})).V(1).Info("discovered subnets")
 	}
-	return lo.Values(subnets), nil
+	return outpostSubnets(nodeClass, lo.Values(subnets)), nil
+}
+
+// outpostSubnets returns the subnets which are on the Outpost of the EC2NodeClass. Subnets are cached by their
+// selector terms, so they're filtered after they're described.
+func outpostSubnets(nodeClass *v1.EC2NodeClass, subnets []ec2types.Subnet) []ec2types.Subnet {
+	if nodeClass.Spec.OutpostARN == nil {
+		return subnets
+	}
+	return lo.Filter(subnets, func(s ec2types.Subnet, _ int) bool {
+		return lo.FromPtr(s.OutpostArn) == lo.FromPtr(nodeClass.Spec.OutpostARN)
+	})
 }
 
 // ZonalSubnetsForLaunch returns a mapping of zone to the subnet with the most available IP addresses and deducts the passed ips from the available count
@@ -143,6 +170,10 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 
 	for _, subnet := range nodeClass.Status.Subnets {
 		if v, ok := zonalSubnets[subnet.Zone]; ok {
+			// Subnets with a higher weight are preferred within a zone, regardless of their available IP addresses
+			if v.Weight > subnet.Weight {
+				continue
+			}
 			currentZonalSubnetIPAddressCount := v.AvailableIPAddressCount
 			newZonalSubnetIPAddressCount := availableIPAddressCount[subnet.ID]
 			if ips, ok := p.inflightIPs[v.ID]; ok {
@@ -152,11 +183,13 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 				newZonalSubnetIPAddressCount = ips
 			}
 
-			if currentZonalSubnetIPAddressCount >= newZonalSubnetIPAddressCount {
+			if v.Weight == subnet.Weight && currentZonalSubnetIPAddressCount >= newZonalSubnetIPAddressCount {
 				continue
 			}
 		}
-		zonalSubnets[subnet.Zone] = &Subnet{ID: subnet.ID, Zone: subnet.Zone, ZoneID: subnet.ZoneID, AvailableIPAddressCount: availableIPAddressCount[subnet.ID]}
+		cached, _ := p.associatePublicIPAddressCache.Get(subnet.ID)
+		public, _ := cached.(bool)
+		zonalSubnets[subnet.Zone] = &Subnet{ID: subnet.ID, Zone: subnet.Zone, ZoneID: subnet.ZoneID, AvailableIPAddressCount: availableIPAddressCount[subnet.ID], Public: public, Weight: subnet.Weight}
 	}
 
 	for _, subnet := range zonalSubnets {
@@ -232,6 +265,15 @@ func (p *DefaultProvider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInp
 	}
 }
 
+// Invalidate removes the cached subnets so that changes to subnets are discovered on the next List. The inflight IP
+// tracking of subnets is retained.
+func (p *DefaultProvider) Invalidate() {
+	p.Lock()
+	defer p.Unlock()
+	p.cache.Flush()
+	p.associatePublicIPAddressCache.Flush()
+}
+
 func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
 	p.Lock()
 	//nolint: staticcheck
@@ -255,7 +297,44 @@ func (p *DefaultProvider) minPods(instanceTypes []*cloudprovider.InstanceType, r
 	return int32(pods)
 }
 
-func getFilterSets(terms []v1.SubnetSelectorTerm) (res [][]ec2types.Filter) {
+// Weight returns the highest weight of the subnet selector terms which select the subnet, or 0 if the subnet isn't
+// selected by a weighted term
+func Weight(terms []v1.SubnetSelectorTerm, subnet ec2types.Subnet, clusterNames []string) int32 {
+	tags := lo.SliceToMap(subnet.Tags, func(t ec2types.Tag) (string, string) { return lo.FromPtr(t.Key), lo.FromPtr(t.Value) })
+	var weight int32
+	for _, term := range terms {
+		if lo.FromPtr(term.Weight) <= weight || !selects(term, lo.FromPtr(subnet.SubnetId), tags, clusterNames) {
+			continue
+		}
+		weight = lo.FromPtr(term.Weight)
+	}
+	return weight
+}
+
+func selects(term v1.SubnetSelectorTerm, id string, tags map[string]string, clusterNames []string) bool {
+	if term.ID != "" {
+		return term.ID == id
+	}
+	for k, v := range term.Tags {
+		value, ok := tags[k]
+		switch {
+		case v == "*" && (ok || k == "*"):
+			continue
+		case !ok || !lo.ContainsBy(utils.TagFilterValues(k, v, clusterNames), func(pattern string) bool { return matchesWildcard(pattern, value) }):
+			return false
+		}
+	}
+	return true
+}
+
+// matchesWildcard returns true if the value matches the pattern of an EC2 filter value, where '*' matches any
+// sequence of characters and '?' matches any single character
+func matchesWildcard(pattern, value string) bool {
+	expression := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+	return regexp.MustCompile("^" + expression + "$").MatchString(value)
+}
+
+func getFilterSets(terms []v1.SubnetSelectorTerm, clusterNames []string) (res [][]ec2types.Filter) {
 	idFilter := ec2types.Filter{Name: aws.String("subnet-id")}
 	for _, term := range terms {
 		switch {
@@ -272,7 +351,7 @@ func getFilterSets(terms []v1.SubnetSelectorTerm) (res [][]ec2types.Filter) {
 				} else {
 					filters = append(filters, ec2types.Filter{
 						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []string{v},
+						Values: utils.TagFilterValues(k, v, clusterNames),
 					})
 				}
 			}
@@ -16,17 +16,21 @@ package subnet_test
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
@@ -114,6 +118,57 @@ var _ = Describe("SubnetProvider", func() {
 				},
 			}, subnets)
 		})
+		It("should discover the subnets tagged with the previous cluster name during a migration", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				MigrationClusterName: lo.ToPtr("previous-cluster"),
+				MigrationEndTime:     lo.ToPtr(time.Now().Add(time.Hour).Format(time.RFC3339)),
+			}))
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{
+					SubnetId:         lo.ToPtr("subnet-current"),
+					AvailabilityZone: lo.ToPtr("test-zone-1a"),
+					Tags:             []ec2types.Tag{{Key: lo.ToPtr(v1.DiscoveryTagKey), Value: lo.ToPtr(options.FromContext(ctx).ClusterName)}},
+				},
+				{
+					SubnetId:         lo.ToPtr("subnet-previous"),
+					AvailabilityZone: lo.ToPtr("test-zone-1b"),
+					Tags:             []ec2types.Tag{{Key: lo.ToPtr(v1.DiscoveryTagKey), Value: lo.ToPtr("previous-cluster")}},
+				},
+			}})
+			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{v1.DiscoveryTagKey: options.FromContext(ctx).ClusterName}}}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(subnets, func(s ec2types.Subnet, _ int) string { return lo.FromPtr(s.SubnetId) })).To(ConsistOf("subnet-current", "subnet-previous"))
+		})
+		It("should not discover the subnets tagged for exclusion", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{
+					SubnetId:         lo.ToPtr("subnet-included"),
+					AvailabilityZone: lo.ToPtr("test-zone-1a"),
+					Tags:             []ec2types.Tag{{Key: lo.ToPtr(v1.DiscoveryTagKey), Value: lo.ToPtr(options.FromContext(ctx).ClusterName)}},
+				},
+				{
+					SubnetId:         lo.ToPtr("subnet-excluded"),
+					AvailabilityZone: lo.ToPtr("test-zone-1b"),
+					Tags: []ec2types.Tag{
+						{Key: lo.ToPtr(v1.DiscoveryTagKey), Value: lo.ToPtr(options.FromContext(ctx).ClusterName)},
+						{Key: lo.ToPtr(v1.ExcludeTagKey), Value: lo.ToPtr("true")},
+					},
+				},
+				{
+					SubnetId:         lo.ToPtr("subnet-not-excluded"),
+					AvailabilityZone: lo.ToPtr("test-zone-1c"),
+					Tags: []ec2types.Tag{
+						{Key: lo.ToPtr(v1.DiscoveryTagKey), Value: lo.ToPtr(options.FromContext(ctx).ClusterName)},
+						{Key: lo.ToPtr(v1.ExcludeTagKey), Value: lo.ToPtr("false")},
+					},
+				},
+			}})
+			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{v1.DiscoveryTagKey: options.FromContext(ctx).ClusterName}}}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(subnets, func(s ec2types.Subnet, _ int) string { return lo.FromPtr(s.SubnetId) })).To(ConsistOf("subnet-included", "subnet-not-excluded"))
+		})
 		It("should discover subnets by IDs", func() {
 			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
 				{
@@ -275,6 +330,43 @@ var _ = Describe("SubnetProvider", func() {
 				lo.Contains(lo.ToSlicePtr(expectedSubnets), lo.ToPtr(cachedSubnet[0]))
 			}
 		})
+		It("should only discover the subnets on the outpost of the nodeClass", func() {
+			outpostARN := "arn:aws:outposts:test-region:123456789012:outpost/op-0123456789abcdef0"
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{
+					SubnetId:         lo.ToPtr("subnet-regional"),
+					AvailabilityZone: lo.ToPtr("test-zone-1a"),
+					Tags:             []ec2types.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}},
+				},
+				{
+					SubnetId:         lo.ToPtr("subnet-outpost"),
+					AvailabilityZone: lo.ToPtr("test-zone-1a"),
+					OutpostArn:       lo.ToPtr(outpostARN),
+					Tags:             []ec2types.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}},
+				},
+			}})
+			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"foo": "bar"}}}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(subnets, func(s ec2types.Subnet, _ int) string { return lo.FromPtr(s.SubnetId) })).To(ConsistOf("subnet-regional", "subnet-outpost"))
+
+			nodeClass.Spec.OutpostARN = lo.ToPtr(outpostARN)
+			subnets, err = awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(subnets, func(s ec2types.Subnet, _ int) string { return lo.FromPtr(s.SubnetId) })).To(ConsistOf("subnet-outpost"))
+		})
+		It("should report refreshing subnets in the provider health", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{ID: "subnet-test1"}}
+			_, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(health.Providers.Statuses()[health.Subnets].LastRefreshed).ToNot(BeNil())
+
+			awsEnv.SubnetCache.Flush()
+			awsEnv.EC2API.NextError.Set(fmt.Errorf("throttled"))
+			_, err = awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(BeNil())
+			Expect(health.Providers.Statuses()[health.Subnets].LastError).To(ContainSubstring("throttled"))
+		})
 	})
 	It("should not cause data races when calling List() simultaneously", func() {
 		wg := sync.WaitGroup{}
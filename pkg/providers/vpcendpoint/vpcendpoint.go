@@ -0,0 +1,76 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vpcendpoint
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/patrickmn/go-cache"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type Provider interface {
+	List(context.Context, string) ([]ec2types.VpcEndpoint, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cache:  cache,
+	}
+}
+
+// List returns the available VPC endpoints of the VPC
+func (p *DefaultProvider) List(ctx context.Context, vpcID string) ([]ec2types.VpcEndpoint, error) {
+	p.Lock()
+	defer p.Unlock()
+	if endpoints, ok := p.cache.Get(vpcID); ok {
+		return append([]ec2types.VpcEndpoint{}, endpoints.([]ec2types.VpcEndpoint)...), nil
+	}
+	var endpoints []ec2types.VpcEndpoint
+	paginator := ec2.NewDescribeVpcEndpointsPaginator(p.ec2api, &ec2.DescribeVpcEndpointsInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []string{vpcID},
+			},
+			{
+				Name:   aws.String("vpc-endpoint-state"),
+				Values: []string{string(ec2types.StateAvailable)},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing vpc endpoints for %s, %w", vpcID, err)
+		}
+		endpoints = append(endpoints, out.VpcEndpoints...)
+	}
+	p.cache.SetDefault(vpcID, endpoints)
+	return append([]ec2types.VpcEndpoint{}, endpoints...), nil
+}
@@ -29,16 +29,26 @@ import (
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/health"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/commitment"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/dhcpoptions"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/license"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/policy"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/vpcendpoint"
 
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -55,11 +65,14 @@ type Environment struct {
 	Clock *clock.FakeClock
 
 	// API
-	EC2API     *fake.EC2API
-	EKSAPI     *fake.EKSAPI
-	SSMAPI     *fake.SSMAPI
-	IAMAPI     *fake.IAMAPI
-	PricingAPI *fake.PricingAPI
+	EC2API            *fake.EC2API
+	EKSAPI            *fake.EKSAPI
+	SSMAPI            *fake.SSMAPI
+	IAMAPI            *fake.IAMAPI
+	PricingAPI        *fake.PricingAPI
+	LicenseManagerAPI *fake.LicenseManagerAPI
+	SavingsPlansAPI   *fake.SavingsPlansAPI
+	ServiceQuotasAPI  *fake.ServiceQuotasAPI
 
 	// Cache
 	EC2Cache                      *cache.Cache
@@ -73,19 +86,35 @@ type Environment struct {
 	InstanceProfileCache          *cache.Cache
 	SSMCache                      *cache.Cache
 	DiscoveredCapacityCache       *cache.Cache
+	LicenseCache                  *cache.Cache
+	VPCEndpointCache              *cache.Cache
+	DHCPOptionsCache              *cache.Cache
+	CapacityBlockCache            *cache.Cache
+	PlacementGroupCache           *cache.Cache
+	SpotPlacementScoreCache       *cache.Cache
+	QuotaCache                    *cache.Cache
 
 	// Providers
-	InstanceTypesResolver   *instancetype.DefaultResolver
-	InstanceTypesProvider   *instancetype.DefaultProvider
-	InstanceProvider        *instance.DefaultProvider
-	SubnetProvider          *subnet.DefaultProvider
-	SecurityGroupProvider   *securitygroup.DefaultProvider
-	InstanceProfileProvider *instanceprofile.DefaultProvider
-	PricingProvider         *pricing.DefaultProvider
-	AMIProvider             *amifamily.DefaultProvider
-	AMIResolver             *amifamily.DefaultResolver
-	VersionProvider         *version.DefaultProvider
-	LaunchTemplateProvider  *launchtemplate.DefaultProvider
+	InstanceTypesResolver      *instancetype.DefaultResolver
+	InstanceTypesProvider      *instancetype.DefaultProvider
+	InstanceProvider           *instance.DefaultProvider
+	SubnetProvider             *subnet.DefaultProvider
+	SecurityGroupProvider      *securitygroup.DefaultProvider
+	InstanceProfileProvider    *instanceprofile.DefaultProvider
+	PricingProvider            *pricing.DefaultProvider
+	AMIProvider                *amifamily.DefaultProvider
+	AMIResolver                *amifamily.DefaultResolver
+	VersionProvider            *version.DefaultProvider
+	LaunchTemplateProvider     *launchtemplate.DefaultProvider
+	LicenseProvider            *license.DefaultProvider
+	VPCEndpointProvider        *vpcendpoint.DefaultProvider
+	DHCPOptionsProvider        *dhcpoptions.DefaultProvider
+	CapacityBlockProvider      *capacityblock.DefaultProvider
+	PlacementGroupProvider     *placementgroup.DefaultProvider
+	PolicyProvider             *policy.DefaultProvider
+	SpotPlacementScoreProvider *spotplacementscore.DefaultProvider
+	CommitmentProvider         *commitment.DefaultProvider
+	QuotaProvider              *quota.DefaultProvider
 }
 
 func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment {
@@ -97,6 +126,9 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	eksapi := fake.NewEKSAPI()
 	ssmapi := fake.NewSSMAPI()
 	iamapi := fake.NewIAMAPI()
+	licensemanagerapi := fake.NewLicenseManagerAPI()
+	savingsplansapi := fake.NewSavingsPlansAPI()
+	servicequotasapi := fake.NewServiceQuotasAPI()
 
 	// cache
 	ec2Cache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
@@ -110,10 +142,19 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	securityGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceProfileCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	ssmCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	licenseCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	vpcEndpointCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	dhcpOptionsCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	capacityBlockCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	placementGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	spotPlacementScoreCache := cache.New(awscache.SpotPlacementScoresTTL, awscache.DefaultCleanupInterval)
+	quotaCache := cache.New(awscache.ServiceQuotasTTL, awscache.DefaultCleanupInterval)
 	fakePricingAPI := &fake.PricingAPI{}
 
 	// Providers
 	pricingProvider := pricing.NewDefaultProvider(ctx, fakePricingAPI, ec2api, fake.DefaultRegion)
+	commitmentProvider := commitment.NewDefaultProvider(ec2api, savingsplansapi, fake.DefaultRegion)
+	quotaProvider := quota.NewDefaultProvider(servicequotasapi, quotaCache)
 	subnetProvider := subnet.NewDefaultProvider(ec2api, subnetCache, availableIPAdressCache, associatePublicIPAddressCache)
 	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, securityGroupCache)
 	versionProvider := version.NewDefaultProvider(env.KubernetesInterface, eksapi)
@@ -122,7 +163,7 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	// the previously resolved value will be used.
 	lo.Must0(versionProvider.UpdateVersion(ctx))
 	instanceProfileProvider := instanceprofile.NewDefaultProvider(fake.DefaultRegion, iamapi, instanceProfileCache)
-	ssmProvider := ssmp.NewDefaultProvider(ssmapi, ssmCache)
+	ssmProvider := ssmp.NewDefaultProvider(ssmapi, ssmCache, fake.DefaultRegion)
 	amiProvider := amifamily.NewDefaultProvider(clock, versionProvider, ssmProvider, ec2api, ec2Cache)
 	amiResolver := amifamily.NewDefaultResolver()
 	instanceTypesResolver := instancetype.NewDefaultResolver(fake.DefaultRegion, pricingProvider, unavailableOfferingsCache)
@@ -141,6 +182,13 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			net.ParseIP("10.0.100.10"),
 			"https://test-cluster",
 		)
+	licenseProvider := license.NewDefaultProvider(licensemanagerapi, licenseCache)
+	vpcEndpointProvider := vpcendpoint.NewDefaultProvider(ec2api, vpcEndpointCache)
+	dhcpOptionsProvider := dhcpoptions.NewDefaultProvider(ec2api, dhcpOptionsCache)
+	capacityBlockProvider := capacityblock.NewDefaultProvider(ec2api, capacityBlockCache)
+	placementGroupProvider := placementgroup.NewDefaultProvider(ec2api, placementGroupCache)
+	policyProvider := policy.NewDefaultProvider()
+	spotPlacementScoreProvider := spotplacementscore.NewDefaultProvider(ec2api, fake.DefaultRegion, spotPlacementScoreCache)
 	instanceProvider :=
 		instance.NewDefaultProvider(ctx,
 			"",
@@ -148,16 +196,22 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			unavailableOfferingsCache,
 			subnetProvider,
 			launchTemplateProvider,
+			licenseProvider,
+			policyProvider,
+			spotPlacementScoreProvider,
 		)
 
 	return &Environment{
 		Clock: clock,
 
-		EC2API:     ec2api,
-		EKSAPI:     eksapi,
-		SSMAPI:     ssmapi,
-		IAMAPI:     iamapi,
-		PricingAPI: fakePricingAPI,
+		EC2API:            ec2api,
+		EKSAPI:            eksapi,
+		SSMAPI:            ssmapi,
+		IAMAPI:            iamapi,
+		PricingAPI:        fakePricingAPI,
+		LicenseManagerAPI: licensemanagerapi,
+		SavingsPlansAPI:   savingsplansapi,
+		ServiceQuotasAPI:  servicequotasapi,
 
 		EC2Cache:                      ec2Cache,
 		InstanceTypeCache:             instanceTypeCache,
@@ -170,18 +224,34 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 		UnavailableOfferingsCache:     unavailableOfferingsCache,
 		SSMCache:                      ssmCache,
 		DiscoveredCapacityCache:       discoveredCapacityCache,
+		LicenseCache:                  licenseCache,
+		VPCEndpointCache:              vpcEndpointCache,
+		DHCPOptionsCache:              dhcpOptionsCache,
+		CapacityBlockCache:            capacityBlockCache,
+		PlacementGroupCache:           placementGroupCache,
+		SpotPlacementScoreCache:       spotPlacementScoreCache,
+		QuotaCache:                    quotaCache,
 
-		InstanceTypesResolver:   instanceTypesResolver,
-		InstanceTypesProvider:   instanceTypesProvider,
-		InstanceProvider:        instanceProvider,
-		SubnetProvider:          subnetProvider,
-		SecurityGroupProvider:   securityGroupProvider,
-		LaunchTemplateProvider:  launchTemplateProvider,
-		InstanceProfileProvider: instanceProfileProvider,
-		PricingProvider:         pricingProvider,
-		AMIProvider:             amiProvider,
-		AMIResolver:             amiResolver,
-		VersionProvider:         versionProvider,
+		InstanceTypesResolver:      instanceTypesResolver,
+		InstanceTypesProvider:      instanceTypesProvider,
+		InstanceProvider:           instanceProvider,
+		SubnetProvider:             subnetProvider,
+		SecurityGroupProvider:      securityGroupProvider,
+		LaunchTemplateProvider:     launchTemplateProvider,
+		InstanceProfileProvider:    instanceProfileProvider,
+		PricingProvider:            pricingProvider,
+		AMIProvider:                amiProvider,
+		AMIResolver:                amiResolver,
+		VersionProvider:            versionProvider,
+		LicenseProvider:            licenseProvider,
+		VPCEndpointProvider:        vpcEndpointProvider,
+		DHCPOptionsProvider:        dhcpOptionsProvider,
+		CapacityBlockProvider:      capacityBlockProvider,
+		PlacementGroupProvider:     placementGroupProvider,
+		PolicyProvider:             policyProvider,
+		SpotPlacementScoreProvider: spotPlacementScoreProvider,
+		CommitmentProvider:         commitmentProvider,
+		QuotaProvider:              quotaProvider,
 	}
 }
 
@@ -192,8 +262,15 @@ func (env *Environment) Reset() {
 	env.SSMAPI.Reset()
 	env.IAMAPI.Reset()
 	env.PricingAPI.Reset()
+	env.LicenseManagerAPI.Reset()
+	env.SavingsPlansAPI.Reset()
+	env.ServiceQuotasAPI.Reset()
 	env.PricingProvider.Reset()
 	env.InstanceTypesProvider.Reset()
+	env.AMIProvider.Reset()
+	env.PolicyProvider.Reset()
+	env.SpotPlacementScoreProvider.Reset()
+	health.Providers.Reset()
 
 	env.EC2Cache.Flush()
 	env.UnavailableOfferingsCache.Flush()
@@ -205,6 +282,13 @@ func (env *Environment) Reset() {
 	env.InstanceProfileCache.Flush()
 	env.SSMCache.Flush()
 	env.DiscoveredCapacityCache.Flush()
+	env.LicenseCache.Flush()
+	env.VPCEndpointCache.Flush()
+	env.DHCPOptionsCache.Flush()
+	env.CapacityBlockCache.Flush()
+	env.PlacementGroupCache.Flush()
+	env.SpotPlacementScoreCache.Flush()
+	env.QuotaCache.Flush()
 	mfs, err := crmetrics.Registry.Gather()
 	if err != nil {
 		for _, mf := range mfs {
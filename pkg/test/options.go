@@ -16,6 +16,7 @@ package test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
@@ -24,14 +25,68 @@ import (
 )
 
 type OptionsFields struct {
-	ClusterCABundle         *string
-	ClusterName             *string
-	ClusterEndpoint         *string
-	IsolatedVPC             *bool
-	EKSControlPlane         *bool
-	VMMemoryOverheadPercent *float64
-	InterruptionQueue       *string
-	ReservedENIs            *int
+	ClusterCABundle                    *string
+	ClusterName                        *string
+	ClusterEndpoint                    *string
+	IsolatedVPC                        *bool
+	EKSControlPlane                    *bool
+	VMMemoryOverheadPercent            *float64
+	InterruptionQueue                  *string
+	ReservedENIs                       *int
+	AdaptiveRegistrationTTLMax         *time.Duration
+	ArchitecturePreference             *string
+	InterruptionQueueMessageAttribute  *string
+	PolicyConfigMap                    *string
+	TerminationCircuitBreakerThreshold *float64
+	TerminationCircuitBreakerWindow    *time.Duration
+	OfferingSnapshotConfigMap          *string
+	ProvisioningAuditSize              *int
+	VMMemoryOverheadPercentOverrides   *string
+	LifecycleWebhookURLs               *string
+	LifecycleWebhookSigningKey         *string
+	MigrationClusterName               *string
+	MigrationEndTime                   *string
+	InterruptionQueueRoleARN           *string
+	ClientMetricsEMFNamespace          *string
+	ExcludePreviousGenerationFamilies  *bool
+	PriceChangeThreshold               *float64
+	PreflightConfigRules               *string
+	InstanceTypePolicy                 *string
+	RequireEncryption                  *bool
+	EncryptionKMSKeyARNs               *string
+	SSMParameterPrefix                 *string
+	InstanceTagLabels                  *string
+	UseFIPSEndpoints                   *bool
+	AWSClientMaxIdleConnsPerHost       *int
+	AWSClientIdleConnTimeout           *time.Duration
+	AWSClientDisableHTTP2              *bool
+	UnavailableOfferingsTTLs           *string
+	CostAttributionLabel               *string
+	FeatureGates                       FeatureGates
+}
+
+type FeatureGates struct {
+	InPlaceUpdates              *bool
+	WarmPools                   *bool
+	SpotPriceDrift              *bool
+	LaunchJournal               *bool
+	AWSClientAdaptiveThrottling *bool
+	AdvertiseNetworkBandwidth   *bool
+	AdvertiseSecondaryENIs      *bool
+	AdvertiseNetworkCards       *bool
+	AdaptiveRegistrationTTL     *bool
+	DisruptionProtectionTagSync *bool
+	PublishNodeTemplates        *bool
+	PublishFleetComposition     *bool
+	LaunchDryRun                *bool
+	SimulateNodeRolePermissions *bool
+	SpotPlacementScores         *bool
+	CommitmentAwarePricing      *bool
+	ValidateQuotas              *bool
+	LearnVMMemoryOverhead       *bool
+	AdvertiseEBSPerformance     *bool
+	PrewarmLaunchTemplates      *bool
+	ODCRFirst                   *bool
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -42,13 +97,65 @@ func Options(overrides ...OptionsFields) *options.Options {
 		}
 	}
 	return &options.Options{
-		ClusterCABundle:         lo.FromPtrOr(opts.ClusterCABundle, ""),
-		ClusterName:             lo.FromPtrOr(opts.ClusterName, "test-cluster"),
-		ClusterEndpoint:         lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
-		IsolatedVPC:             lo.FromPtrOr(opts.IsolatedVPC, false),
-		EKSControlPlane:         lo.FromPtrOr(opts.EKSControlPlane, false),
-		VMMemoryOverheadPercent: lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
-		InterruptionQueue:       lo.FromPtrOr(opts.InterruptionQueue, ""),
-		ReservedENIs:            lo.FromPtrOr(opts.ReservedENIs, 0),
+		ClusterCABundle:                    lo.FromPtrOr(opts.ClusterCABundle, ""),
+		ClusterName:                        lo.FromPtrOr(opts.ClusterName, "test-cluster"),
+		ClusterEndpoint:                    lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
+		IsolatedVPC:                        lo.FromPtrOr(opts.IsolatedVPC, false),
+		EKSControlPlane:                    lo.FromPtrOr(opts.EKSControlPlane, false),
+		VMMemoryOverheadPercent:            lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
+		InterruptionQueue:                  lo.FromPtrOr(opts.InterruptionQueue, ""),
+		ReservedENIs:                       lo.FromPtrOr(opts.ReservedENIs, 0),
+		AdaptiveRegistrationTTLMax:         lo.FromPtrOr(opts.AdaptiveRegistrationTTLMax, 15*time.Minute),
+		ArchitecturePreference:             lo.FromPtrOr(opts.ArchitecturePreference, options.ArchitecturePreferenceCost),
+		InterruptionQueueMessageAttribute:  lo.FromPtrOr(opts.InterruptionQueueMessageAttribute, ""),
+		PolicyConfigMap:                    lo.FromPtrOr(opts.PolicyConfigMap, ""),
+		TerminationCircuitBreakerThreshold: lo.FromPtrOr(opts.TerminationCircuitBreakerThreshold, 0),
+		TerminationCircuitBreakerWindow:    lo.FromPtrOr(opts.TerminationCircuitBreakerWindow, 10*time.Minute),
+		OfferingSnapshotConfigMap:          lo.FromPtrOr(opts.OfferingSnapshotConfigMap, ""),
+		ProvisioningAuditSize:              lo.FromPtrOr(opts.ProvisioningAuditSize, 0),
+		VMMemoryOverheadPercentOverrides:   lo.FromPtrOr(opts.VMMemoryOverheadPercentOverrides, ""),
+		LifecycleWebhookURLs:               lo.FromPtrOr(opts.LifecycleWebhookURLs, ""),
+		LifecycleWebhookSigningKey:         lo.FromPtrOr(opts.LifecycleWebhookSigningKey, ""),
+		MigrationClusterName:               lo.FromPtrOr(opts.MigrationClusterName, ""),
+		MigrationEndTime:                   lo.FromPtrOr(opts.MigrationEndTime, ""),
+		InterruptionQueueRoleARN:           lo.FromPtrOr(opts.InterruptionQueueRoleARN, ""),
+		ClientMetricsEMFNamespace:          lo.FromPtrOr(opts.ClientMetricsEMFNamespace, ""),
+		ExcludePreviousGenerationFamilies:  lo.FromPtrOr(opts.ExcludePreviousGenerationFamilies, false),
+		PriceChangeThreshold:               lo.FromPtrOr(opts.PriceChangeThreshold, 0),
+		PreflightConfigRules:               lo.FromPtrOr(opts.PreflightConfigRules, ""),
+		InstanceTypePolicy:                 lo.FromPtrOr(opts.InstanceTypePolicy, ""),
+		RequireEncryption:                  lo.FromPtrOr(opts.RequireEncryption, false),
+		EncryptionKMSKeyARNs:               lo.FromPtrOr(opts.EncryptionKMSKeyARNs, ""),
+		SSMParameterPrefix:                 lo.FromPtrOr(opts.SSMParameterPrefix, ""),
+		InstanceTagLabels:                  lo.FromPtrOr(opts.InstanceTagLabels, ""),
+		UseFIPSEndpoints:                   lo.FromPtrOr(opts.UseFIPSEndpoints, false),
+		AWSClientMaxIdleConnsPerHost:       lo.FromPtrOr(opts.AWSClientMaxIdleConnsPerHost, 10),
+		AWSClientIdleConnTimeout:           lo.FromPtrOr(opts.AWSClientIdleConnTimeout, 90*time.Second),
+		AWSClientDisableHTTP2:              lo.FromPtrOr(opts.AWSClientDisableHTTP2, false),
+		UnavailableOfferingsTTLs:           lo.FromPtrOr(opts.UnavailableOfferingsTTLs, ""),
+		CostAttributionLabel:               lo.FromPtrOr(opts.CostAttributionLabel, ""),
+		FeatureGates: options.FeatureGates{
+			InPlaceUpdates:              lo.FromPtrOr(opts.FeatureGates.InPlaceUpdates, false),
+			WarmPools:                   lo.FromPtrOr(opts.FeatureGates.WarmPools, false),
+			SpotPriceDrift:              lo.FromPtrOr(opts.FeatureGates.SpotPriceDrift, false),
+			LaunchJournal:               lo.FromPtrOr(opts.FeatureGates.LaunchJournal, false),
+			AWSClientAdaptiveThrottling: lo.FromPtrOr(opts.FeatureGates.AWSClientAdaptiveThrottling, false),
+			AdvertiseNetworkBandwidth:   lo.FromPtrOr(opts.FeatureGates.AdvertiseNetworkBandwidth, false),
+			AdvertiseSecondaryENIs:      lo.FromPtrOr(opts.FeatureGates.AdvertiseSecondaryENIs, false),
+			AdvertiseNetworkCards:       lo.FromPtrOr(opts.FeatureGates.AdvertiseNetworkCards, false),
+			AdaptiveRegistrationTTL:     lo.FromPtrOr(opts.FeatureGates.AdaptiveRegistrationTTL, false),
+			DisruptionProtectionTagSync: lo.FromPtrOr(opts.FeatureGates.DisruptionProtectionTagSync, false),
+			PublishNodeTemplates:        lo.FromPtrOr(opts.FeatureGates.PublishNodeTemplates, false),
+			PublishFleetComposition:     lo.FromPtrOr(opts.FeatureGates.PublishFleetComposition, false),
+			LaunchDryRun:                lo.FromPtrOr(opts.FeatureGates.LaunchDryRun, false),
+			SimulateNodeRolePermissions: lo.FromPtrOr(opts.FeatureGates.SimulateNodeRolePermissions, false),
+			SpotPlacementScores:         lo.FromPtrOr(opts.FeatureGates.SpotPlacementScores, false),
+			CommitmentAwarePricing:      lo.FromPtrOr(opts.FeatureGates.CommitmentAwarePricing, false),
+			ValidateQuotas:              lo.FromPtrOr(opts.FeatureGates.ValidateQuotas, false),
+			LearnVMMemoryOverhead:       lo.FromPtrOr(opts.FeatureGates.LearnVMMemoryOverhead, false),
+			AdvertiseEBSPerformance:     lo.FromPtrOr(opts.FeatureGates.AdvertiseEBSPerformance, false),
+			PrewarmLaunchTemplates:      lo.FromPtrOr(opts.FeatureGates.PrewarmLaunchTemplates, false),
+			ODCRFirst:                   lo.FromPtrOr(opts.FeatureGates.ODCRFirst, false),
+		},
 	}
 }
@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	serviceLabel           = "service"
+	operationLabel         = "operation"
+)
+
+var (
+	ThrottledRequestsTotal = opmetrics.NewPrometheusCounter(crmetrics.Registry, prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: cloudProviderSubsystem,
+		Name:      "aws_client_throttled_requests_total",
+		Help:      "The number of attempts of AWS API requests which were throttled, e.g. with RequestLimitExceeded. Labeled by service and operation.",
+	}, []string{serviceLabel, operationLabel})
+	RateLimit = opmetrics.NewPrometheusGauge(crmetrics.Registry, prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: cloudProviderSubsystem,
+		Name:      "aws_client_rate_limit",
+		Help:      "The requests per second that a batched AWS API operation is limited to since it was throttled. The metric is only present while the operation is limited. Labeled by service and operation.",
+	}, []string{serviceLabel, operationLabel})
+)
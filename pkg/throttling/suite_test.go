@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/throttling"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+
+func TestAWS(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Throttling")
+}
+
+var throttlingErr = &smithy.GenericAPIError{Code: "RequestLimitExceeded"}
+
+var _ = Describe("Throttling", func() {
+	var fakeClock *clock.FakeClock
+	var controller *throttling.Controller
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		controller = throttling.NewController(fakeClock, true)
+		throttling.ThrottledRequestsTotal.Reset()
+		throttling.RateLimit.Reset()
+	})
+	It("should count the throttled attempts of each operation", func() {
+		controller.ObserveAttempt(ctx, "EC2", "DescribeSubnets", throttlingErr)
+		controller.ObserveAttempt(ctx, "EC2", "DescribeSubnets", throttlingErr)
+		controller.ObserveAttempt(ctx, "EC2", "DescribeSubnets", nil)
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", &smithy.GenericAPIError{Code: "Throttling"})
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity"})
+		ExpectMetricCounterValue(throttling.ThrottledRequestsTotal, 2, map[string]string{"service": "EC2", "operation": "DescribeSubnets"})
+		ExpectMetricCounterValue(throttling.ThrottledRequestsTotal, 1, map[string]string{"service": "EC2", "operation": "CreateFleet"})
+	})
+	It("should not limit operations which aren't batched", func() {
+		controller.ObserveAttempt(ctx, "EC2", "DescribeSubnets", throttlingErr)
+		Expect(controller.Limit("EC2", "DescribeSubnets")).To(BeZero())
+	})
+	It("should not limit operations when adaptive throttling is disabled", func() {
+		controller = throttling.NewController(fakeClock, false)
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeZero())
+		ExpectMetricCounterValue(throttling.ThrottledRequestsTotal, 1, map[string]string{"service": "EC2", "operation": "CreateFleet"})
+	})
+	It("should limit a batched operation to half the rate that it was throttled at", func() {
+		for range 19 {
+			controller.ObserveAttempt(ctx, "EC2", "CreateFleet", nil)
+		}
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeZero())
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", 10))
+		ExpectMetricGaugeValue(throttling.RateLimit, 10, map[string]string{"service": "EC2", "operation": "CreateFleet"})
+	})
+	It("should decrease the limit once for the throttled attempts of a burst", func() {
+		for range 20 {
+			controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		}
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", throttling.MinRate))
+
+		controller = throttling.NewController(fakeClock, true)
+		for range 19 {
+			controller.ObserveAttempt(ctx, "EC2", "CreateFleet", nil)
+		}
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", 10))
+		fakeClock.Step(time.Second)
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", 5))
+	})
+	It("should not decrease the limit below the minimum rate", func() {
+		for range 5 {
+			controller.ObserveAttempt(ctx, "EC2", "DescribeInstances", throttlingErr)
+			fakeClock.Step(time.Second)
+		}
+		Expect(controller.Limit("EC2", "DescribeInstances")).To(BeNumerically("==", throttling.MinRate))
+	})
+	It("should raise the limit for attempts which aren't throttled", func() {
+		for range 3 {
+			controller.ObserveAttempt(ctx, "EC2", "CreateFleet", nil)
+		}
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", 2))
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", nil)
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", nil)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("~", 2.9, 0.01))
+	})
+	It("should space out the attempts of a limited operation", func() {
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeNumerically("==", throttling.MinRate))
+
+		Expect(controller.Wait(ctx, "EC2", "CreateFleet")).To(Succeed())
+		done := make(chan error)
+		go func() { done <- controller.Wait(ctx, "EC2", "CreateFleet") }()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		Consistently(done).ShouldNot(Receive())
+		fakeClock.Step(time.Second)
+		Eventually(done).Should(Receive(BeNil()))
+
+		// Operations which aren't limited aren't delayed
+		Expect(controller.Wait(ctx, "EC2", "DescribeInstances")).To(Succeed())
+		Expect(fakeClock.HasWaiters()).To(BeFalse())
+	})
+	It("should stop waiting when the context is cancelled", func() {
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		Expect(controller.Wait(ctx, "EC2", "CreateFleet")).To(Succeed())
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(errors.Is(controller.Wait(cancelCtx, "EC2", "CreateFleet"), context.Canceled)).To(BeTrue())
+	})
+	It("should remove the limit once the operation hasn't been throttled for the recovery period", func() {
+		controller.ObserveAttempt(ctx, "EC2", "CreateFleet", throttlingErr)
+		ExpectMetricGaugeValue(throttling.RateLimit, throttling.MinRate, map[string]string{"service": "EC2", "operation": "CreateFleet"})
+		fakeClock.Step(throttling.RecoveryPeriod)
+		Expect(controller.Wait(ctx, "EC2", "CreateFleet")).To(Succeed())
+		Expect(controller.Limit("EC2", "CreateFleet")).To(BeZero())
+		_, found := FindMetricWithLabelValues("karpenter_cloudprovider_aws_client_rate_limit", map[string]string{"service": "EC2", "operation": "CreateFleet"})
+		Expect(found).To(BeFalse())
+	})
+})
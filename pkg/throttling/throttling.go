@@ -0,0 +1,200 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+const (
+	// MinRate is the lowest requests per second that an operation is limited to
+	MinRate = 1.0
+	// DecreaseFactor is the factor that the rate limit of an operation is multiplied by when it's throttled
+	DecreaseFactor = 0.5
+	// RecoveryPeriod is the period without throttling after which an operation is no longer limited
+	RecoveryPeriod = 5 * time.Minute
+)
+
+type operation struct {
+	service string
+	name    string
+}
+
+// batchedOperations are the operations of the calls which Karpenter batches. Bursts of these calls, e.g. while a large
+// number of NodeClaims are launched or terminated at once, are the most likely to exhaust the request tokens of the
+// account, which are shared with every other client of the account in the region.
+var batchedOperations = sets.New(
+	operation{service: "EC2", name: "CreateFleet"},
+	operation{service: "EC2", name: "DescribeInstances"},
+	operation{service: "EC2", name: "TerminateInstances"},
+)
+
+type limiter struct {
+	// limit is the requests per second that the operation is limited to, or zero if it isn't limited
+	limit float64
+	// next is the earliest time that the next attempt may be sent at
+	next          time.Time
+	lastThrottled time.Time
+	lastDecreased time.Time
+	// The attempts of the current one second window, and the rate of attempts of the previous window, which the limit
+	// starts from when the operation is first throttled
+	windowStart    time.Time
+	windowAttempts int
+	rate           float64
+}
+
+// Controller tracks the attempts of the AWS clients which are throttled. When adaptive throttling is enabled, the
+// batched operations are rate limited once they're throttled, so that a burst of calls is spread out by Karpenter
+// rather than retried against the API until the retries are exhausted. The limit starts at half the rate that the
+// operation was throttled at, is halved at most once a second while it's throttled, and is raised by about one request
+// per second for each second of attempts which aren't. It's removed once the operation hasn't been throttled for the
+// RecoveryPeriod.
+type Controller struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	adaptive bool
+	limiters map[operation]*limiter
+}
+
+func NewController(clk clock.Clock, adaptive bool) *Controller {
+	return &Controller{
+		clock:    clk,
+		adaptive: adaptive,
+		limiters: map[operation]*limiter{},
+	}
+}
+
+// APIOptions returns the smithy middleware stack mutators which track the throttling of an AWS client. Every attempt is
+// tracked, so the middleware is registered after the retry middleware.
+func (c *Controller) APIOptions() []func(*middleware.Stack) error {
+	return []func(*middleware.Stack) error{
+		func(stack *middleware.Stack) error {
+			return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("AdaptiveThrottling", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				service, name := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+				if err := c.Wait(ctx, service, name); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+				out, metadata, err := next.HandleFinalize(ctx, in)
+				c.ObserveAttempt(ctx, service, name, err)
+				return out, metadata, err
+			}), "Retry", middleware.After)
+		},
+	}
+}
+
+// Wait blocks until an attempt of the operation may be sent under its rate limit, or the context is cancelled
+func (c *Controller) Wait(ctx context.Context, service, name string) error {
+	delay := c.reserve(ctx, operation{service: service, name: name})
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(delay):
+		return nil
+	}
+}
+
+// reserve returns the delay until an attempt of the operation may be sent, and holds the slot of the limit for it
+func (c *Controller) reserve(ctx context.Context, op operation) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.limiters[op]
+	if !ok || l.limit == 0 {
+		return 0
+	}
+	now := c.clock.Now()
+	if now.Sub(l.lastThrottled) >= RecoveryPeriod {
+		l.limit = 0
+		RateLimit.Delete(map[string]string{serviceLabel: op.service, operationLabel: op.name})
+		log.FromContext(ctx).WithValues("service", op.service, "operation", op.name).V(1).Info("removed rate limit of aws operation")
+		return 0
+	}
+	start := now
+	if l.next.After(now) {
+		start = l.next
+	}
+	l.next = start.Add(time.Duration(float64(time.Second) / l.limit))
+	return start.Sub(now)
+}
+
+// ObserveAttempt records an attempt of a call to an operation, and adapts the rate limit of the operation to whether
+// the attempt was throttled
+func (c *Controller) ObserveAttempt(ctx context.Context, service, name string, err error) {
+	throttled := awserrors.Classify(err) == awserrors.ClassThrottled
+	if throttled {
+		ThrottledRequestsTotal.Inc(map[string]string{serviceLabel: service, operationLabel: name})
+	}
+	op := operation{service: service, name: name}
+	if !c.adaptive || !batchedOperations.Has(op) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.limiters[op]
+	if !ok {
+		l = &limiter{}
+		c.limiters[op] = l
+	}
+	now := c.clock.Now()
+	if elapsed := now.Sub(l.windowStart); elapsed >= time.Second {
+		l.rate = float64(l.windowAttempts) / elapsed.Seconds()
+		l.windowStart, l.windowAttempts = now, 0
+	}
+	l.windowAttempts++
+
+	switch {
+	case throttled:
+		l.lastThrottled = now
+		// The attempts of a burst which were sent before the limit was decreased are throttled together, so the limit
+		// is only decreased once for them
+		if l.limit != 0 && now.Sub(l.lastDecreased) < time.Second {
+			return
+		}
+		base := l.limit
+		if base == 0 {
+			base = max(l.rate, float64(l.windowAttempts))
+		}
+		l.limit = max(MinRate, base*DecreaseFactor)
+		l.lastDecreased = now
+		log.FromContext(ctx).WithValues("service", service, "operation", name, "requests-per-second", l.limit).V(1).Info("rate limiting throttled aws operation")
+	case l.limit != 0:
+		l.limit += 1 / l.limit
+	default:
+		return
+	}
+	RateLimit.Set(l.limit, map[string]string{serviceLabel: service, operationLabel: name})
+}
+
+// Limit returns the requests per second that an operation is limited to, or zero if it isn't limited
+func (c *Controller) Limit(service, name string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.limiters[operation{service: service, name: name}]; ok {
+		return l.limit
+	}
+	return 0
+}
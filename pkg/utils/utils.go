@@ -20,17 +20,41 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
 	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 var (
 	instanceIDRegex = regexp.MustCompile(`aws:///(?P<AZ>.*)/(?P<InstanceID>.*)`)
 )
 
+// partitionRegionPrefixes are the prefixes of the regions in each partition other than the aws partition
+var partitionRegionPrefixes = map[string]string{
+	"cn-":      "aws-cn",
+	"us-gov-":  "aws-us-gov",
+	"us-iso-":  "aws-iso",
+	"us-isob-": "aws-iso-b",
+	"eu-isoe-": "aws-iso-e",
+	"us-isof-": "aws-iso-f",
+}
+
+// partitionDNSSuffixes are the DNS suffixes of the endpoints in each partition other than the aws and aws-us-gov
+// partitions
+var partitionDNSSuffixes = map[string]string{
+	"aws-cn":    "amazonaws.com.cn",
+	"aws-iso":   "c2s.ic.gov",
+	"aws-iso-b": "sc2s.sgov.gov",
+	"aws-iso-e": "cloud.adc-e.uk",
+	"aws-iso-f": "csp.hci.ic.gov",
+}
+
 // ParseInstanceID parses the provider ID stored on the node to get the instance ID
 // associated with a node
 func ParseInstanceID(providerID string) (string, error) {
@@ -46,6 +70,24 @@ func ParseInstanceID(providerID string) (string, error) {
 	return "", fmt.Errorf("parsing instance id %s", providerID)
 }
 
+// Partition returns the partition of a region
+func Partition(region string) string {
+	for prefix, partition := range partitionRegionPrefixes {
+		if strings.HasPrefix(region, prefix) {
+			return partition
+		}
+	}
+	return "aws"
+}
+
+// DNSSuffix returns the DNS suffix of the endpoints in a partition, e.g. amazonaws.com.cn for the aws-cn partition
+func DNSSuffix(partition string) string {
+	if suffix, ok := partitionDNSSuffixes[partition]; ok {
+		return suffix
+	}
+	return "amazonaws.com"
+}
+
 // MergeTags takes a variadic list of maps and merges them together into a list of
 // EC2 tags to be passed into EC2 API calls
 func MergeTags(tags ...map[string]string) []ec2types.Tag {
@@ -83,3 +125,34 @@ func WithDefaultFloat64(key string, def float64) float64 {
 	}
 	return f
 }
+
+// RollRequestedAt returns the time at which a roll of the NodePool was requested, and whether a roll was requested.
+// An error is returned if the karpenter.k8s.aws/roll-requested-at annotation isn't an RFC3339 timestamp.
+func RollRequestedAt(nodePool *karpv1.NodePool) (time.Time, bool, error) {
+	value, ok := nodePool.Annotations[v1.AnnotationRollRequestedAt]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	requestedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("parsing %s, %w", v1.AnnotationRollRequestedAt, err)
+	}
+	return requestedAt, true, nil
+}
+
+// Excluded returns true if the subnet or security group with the tags is tagged with karpenter.sh/exclude set to
+// "true", which excludes it from every EC2NodeClass regardless of the selector terms which select it
+func Excluded(tags []ec2types.Tag) bool {
+	return lo.ContainsBy(tags, func(t ec2types.Tag) bool {
+		return lo.FromPtr(t.Key) == v1.ExcludeTagKey && strings.EqualFold(lo.FromPtr(t.Value), "true")
+	})
+}
+
+// TagFilterValues returns the values of the tag filter for a tag of a selector term. While the cluster is being migrated
+// from a previous name, a karpenter.sh/discovery tag which selects either name of the cluster selects both.
+func TagFilterValues(key, value string, clusterNames []string) []string {
+	if key == v1.DiscoveryTagKey && lo.Contains(clusterNames, value) {
+		return clusterNames
+	}
+	return []string{value}
+}
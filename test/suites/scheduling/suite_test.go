@@ -19,6 +19,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
 	"github.com/awslabs/operatorpkg/object"
@@ -149,6 +151,35 @@ var _ = Describe("Scheduling", Ordered, ContinueOnFailure, func() {
 			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
 			env.ExpectCreatedNodeCount("==", 1)
 		})
+		It("should support well-known labels for placement partitions", func() {
+			selectors.Insert(v1.LabelPlacementPartition) // Add node selector keys to selectors used in testing to ensure we test all labels
+			placementGroupName := fmt.Sprintf("%s-%s", env.ClusterName, test.RandomName())
+			_, err := env.EC2API.CreatePlacementGroup(env.Context, &ec2.CreatePlacementGroupInput{
+				GroupName:      aws.String(placementGroupName),
+				Strategy:       ec2types.PlacementStrategyPartition,
+				PartitionCount: aws.Int32(3),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := env.EC2API.DeletePlacementGroup(env.Context, &ec2.DeletePlacementGroupInput{GroupName: aws.String(placementGroupName)})
+				Expect(err).ToNot(HaveOccurred())
+			})
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroup{Name: placementGroupName, Partitions: 3}
+			podLabels := map[string]string{"app": "placement-partitions"}
+			deployment := test.Deployment(test.DeploymentOptions{Replicas: 3, PodOptions: test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       v1.LabelPlacementPartition,
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: podLabels},
+				}},
+			}})
+			env.ExpectCreated(nodeClass, nodePool, deployment)
+			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
+			nodes := env.ExpectCreatedNodeCount("==", 3)
+			Expect(lo.Uniq(lo.Map(nodes, func(n *corev1.Node, _ int) string { return n.Labels[v1.LabelPlacementPartition] }))).To(ConsistOf("1", "2", "3"))
+		})
 		It("should support well-known labels for local NVME storage", func() {
 			selectors.Insert(v1.LabelInstanceLocalNVME) // Add node selector keys to selectors used in testing to ensure we test all labels
 			deployment := test.Deployment(test.DeploymentOptions{Replicas: 1, PodOptions: test.PodOptions{
@@ -193,6 +224,22 @@ var _ = Describe("Scheduling", Ordered, ContinueOnFailure, func() {
 			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
 			env.ExpectCreatedNodeCount("==", 1)
 		})
+		It("should support well-known labels for nitro enclaves", func() {
+			selectors.Insert(v1.LabelNitroEnclavesSupported) // Add node selector keys to selectors used in testing to ensure we test all labels
+			nodeClass.Spec.EnclaveOptions = &v1.EnclaveOptions{Enabled: lo.ToPtr(true)}
+			deployment := test.Deployment(test.DeploymentOptions{Replicas: 1, PodOptions: test.PodOptions{
+				NodeRequirements: []corev1.NodeSelectorRequirement{
+					{
+						Key:      v1.LabelNitroEnclavesSupported,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+				},
+			}})
+			env.ExpectCreated(nodeClass, nodePool, deployment)
+			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
+			env.ExpectCreatedNodeCount("==", 1)
+		})
 		It("should support well-known deprecated labels", func() {
 			nodeSelector := map[string]string{
 				// Deprecated Labels
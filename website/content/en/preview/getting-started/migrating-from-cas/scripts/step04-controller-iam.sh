@@ -29,19 +29,32 @@ cat << EOF > controller-policy.json
             "Action": [
                 "ssm:GetParameter",
                 "ec2:DescribeImages",
+                "ec2:DescribeFastLaunchImages",
+                "ec2:EnableFastLaunch",
+                "license-manager:GetLicenseConfiguration",
                 "ec2:RunInstances",
                 "ec2:DescribeSubnets",
+                "ec2:DescribeVpcEndpoints",
+                "ec2:DescribeVolumes",
                 "ec2:DescribeSecurityGroups",
                 "ec2:DescribeLaunchTemplates",
                 "ec2:DescribeInstances",
                 "ec2:DescribeInstanceTypes",
                 "ec2:DescribeInstanceTypeOfferings",
+                "ec2:DescribeAvailabilityZones",
                 "ec2:DeleteLaunchTemplate",
                 "ec2:CreateTags",
                 "ec2:CreateLaunchTemplate",
                 "ec2:CreateFleet",
                 "ec2:DescribeSpotPriceHistory",
-                "pricing:GetProducts"
+                "ec2:DescribeCapacityReservations",
+                "ec2:DescribePlacementGroups",
+                "ec2:GetSpotPlacementScores",
+                "ec2:DescribeReservedInstances",
+                "pricing:GetProducts",
+                "savingsplans:DescribeSavingsPlans",
+                "savingsplans:DescribeSavingsPlanRates",
+                "servicequotas:GetServiceQuota"
             ],
             "Effect": "Allow",
             "Resource": "*",
@@ -58,12 +71,45 @@ cat << EOF > controller-policy.json
             "Resource": "*",
             "Sid": "ConditionalEC2Termination"
         },
+        {
+            "Action": [
+                "ec2:StartInstances",
+                "ec2:StopInstances",
+                "ec2:RebootInstances",
+                "ec2:ModifyInstanceAttribute"
+            ],
+            "Condition": {
+                "StringLike": {
+                    "ec2:ResourceTag/karpenter.sh/nodepool": "*"
+                }
+            },
+            "Effect": "Allow",
+            "Resource": "*",
+            "Sid": "ConditionalEC2StartStop"
+        },
+        {
+            "Action": "ec2:ModifyVolume",
+            "Condition": {
+                "StringLike": {
+                    "ec2:ResourceTag/karpenter.sh/nodepool": "*"
+                }
+            },
+            "Effect": "Allow",
+            "Resource": "*",
+            "Sid": "ConditionalEC2VolumeModification"
+        },
         {
             "Effect": "Allow",
             "Action": "iam:PassRole",
             "Resource": "arn:${AWS_PARTITION}:iam::${AWS_ACCOUNT_ID}:role/KarpenterNodeRole-${CLUSTER_NAME}",
             "Sid": "PassNodeIAMRole"
         },
+        {
+            "Effect": "Allow",
+            "Action": "iam:SimulatePrincipalPolicy",
+            "Resource": "arn:${AWS_PARTITION}:iam::${AWS_ACCOUNT_ID}:role/KarpenterNodeRole-${CLUSTER_NAME}",
+            "Sid": "SimulateNodeIAMRolePolicies"
+        },
         {
             "Effect": "Allow",
             "Action": "eks:DescribeCluster",